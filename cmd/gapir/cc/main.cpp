@@ -93,6 +93,7 @@ struct Options {
   int idleTimeoutSec = 0;
   const char* replayArchive = nullptr;
   const char* postbackDirectory = "";
+  bool traceOpcodes = false;
   bool version = false;
   bool help = false;
 
@@ -113,6 +114,14 @@ struct Options {
     GAPID_WARNING("  --postback-dir string\n");
     GAPID_WARNING(
         "    Path to a directory to use for outputs of the replay-archive\n");
+    GAPID_WARNING(
+        "    Posts are written as <id>.bin, replay errors to errors.txt, and\n");
+    GAPID_WARNING(
+        "    an empty 'finished' file once the replay has completed\n");
+    GAPID_WARNING("  --trace-opcodes\n");
+    GAPID_WARNING(
+        "    If set, logs each opcode executed by the replay-archive, along\n");
+    GAPID_WARNING("    with the command label and the resource it loads\n");
     GAPID_WARNING("  --auth-token-file string\n");
     GAPID_WARNING(
         "    Path to the a file containing the authentication token\n");
@@ -190,6 +199,10 @@ struct Options {
           GAPID_FATAL("Usage: --postback-dir <output-directory>");
         }
         opts->postbackDirectory = argv[++i];
+      } else if (strcmp(argv[i], "--trace-opcodes") == 0) {
+        ensureNotAndroid("--trace-opcodes");
+        opts->SetMode(kReplayArchive);
+        opts->traceOpcodes = true;
       } else if (strcmp(argv[i], "--auth-token-file") == 0) {
         opts->SetMode(kReplayServer);
         if (i + 1 >= argc) {
@@ -475,7 +488,8 @@ std::unique_ptr<Server> Setup(const char* uri, const char* authToken,
 
 static int replayArchive(core::CrashHandler* crashHandler,
                          std::unique_ptr<ResourceCache> resourceCache,
-                         gapir::ReplayService* replayArchiveService) {
+                         gapir::ReplayService* replayArchiveService,
+                         bool traceOpcodes) {
   std::shared_ptr<MemoryAllocator> allocator = createAllocator();
 
   // The directory consists an archive(resources.{index,data}) and payload.bin.
@@ -486,6 +500,7 @@ static int replayArchive(core::CrashHandler* crashHandler,
 
   std::unique_ptr<Context> context = Context::create(
       replayArchiveService, *crashHandler, resLoader.get(), &memoryManager);
+  context->setTraceOpcodes(traceOpcodes);
 
   if (replayArchiveService->getPayload("payload") == NULL) {
     GAPID_ERROR("Replay payload could not be found.");
@@ -731,7 +746,7 @@ void android_main(struct android_app* app) {
       gapir::AssetReplayService assetReplayService(asset_manager);
 
       replayArchive(&crashHandler, std::move(assetResourceCache),
-                    &assetReplayService);
+                    &assetReplayService, false);
 
       app->activity->vm->DetachCurrentThread();
 
@@ -992,7 +1007,7 @@ int main(int argc, const char* argv[]) {
     // loader to fetch uncached resources data.
     auto onDiskCache = OnDiskResourceCache::create(opts.replayArchive, false);
     return replayArchive(&crashHandler, std::move(onDiskCache),
-                         &replayArchiveService);
+                         &replayArchiveService, opts.traceOpcodes);
   } else {
     return startServer(&crashHandler, opts);
   }
@@ -16,10 +16,13 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
 	"flag"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -38,6 +41,7 @@ import (
 	"github.com/google/gapid/core/os/file"
 	"github.com/google/gapid/core/text"
 	"github.com/google/gapid/gapir/client"
+	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/database"
 	"github.com/google/gapid/gapis/replay"
 	"github.com/google/gapid/gapis/server"
@@ -62,7 +66,18 @@ var (
 	idleTimeout      = flag.Duration("idle-timeout", 0, "_Closes GAPIS if the server is not repeatedly pinged within this duration")
 	adbPath          = flag.String("adb", "", "Path to the adb executable; leave empty to search the environment")
 	enableLocalFiles = flag.Bool("enable-local-files", false, "Allow clients to access local .gfxtrace files by path")
+	readOnly         = flag.Bool("read-only", false, "Serve captures read-only, disallowing edits, exports and imports of captures, and changes to the server and its devices")
+	maxPayloadSize   = flag.Uint64("max-payload-size", 16<<20, "The largest blob of raw data, such as a texture, returned in read-only mode; 0 for no limit")
 	remoteSSHConfig  = flag.String("ssh-config", "", "_Path to an ssh config file for remote devices")
+	healthAddr       = flag.String("health-http", "", "_TCP host:port to serve the /healthz and /readyz HTTP endpoints on, disabled if empty")
+	logRingSize      = flag.Int("log-ring-size", 10000, "_The number of recent log messages retained for bug reports")
+	workers          = flag.String("workers", "", "Comma separated host:port addresses of worker servers to dispatch replays to")
+	workerAuthToken  = flag.String("worker-auth-token", "", "_The connection authorization token for the worker servers")
+	captureIndexDir  = flag.String("capture-index-dir", "", "Directory tree of .gfxtrace files searched by default by SearchCaptures")
+	webAddr          = flag.String("web-bridge", "", "TCP host:port to serve the RPCs used to view captures as HTTP/JSON for browser clients, disabled if empty")
+	webOrigin        = flag.String("web-origin", "", "The origin of the web pages allowed to make cross-origin requests to the web bridge")
+	replayRewrites   = flag.String("replay-rewrites", "", "Path to a text protobuf file of rewrites applied to the commands of all replays")
+	captureKeyFile   = flag.String("capture-key-file", "", "Path to a file holding the hex encoded AES key used to read and write encrypted captures")
 )
 
 func main() {
@@ -88,7 +103,8 @@ func addFallbackLogHandler(b *log.Broadcaster, fallback log.Handler) {
 
 func run(ctx context.Context) error {
 	logBroadcaster := log.Broadcast()
-	if oldHandler, oldWasDefault := app.LogHandler.SetTarget(logBroadcaster, false); oldWasDefault {
+	logRing := log.NewRing(*logRingSize)
+	if oldHandler, oldWasDefault := app.LogHandler.SetTarget(log.Broadcast(logRing, logBroadcaster), false); oldWasDefault {
 		addFallbackLogHandler(logBroadcaster, oldHandler)
 	} else {
 		logBroadcaster.Listen(oldHandler)
@@ -100,6 +116,18 @@ func run(ctx context.Context) error {
 
 	r := bind.NewRegistry()
 	ctx = bind.PutRegistry(ctx, r)
+	if *replayRewrites != "" {
+		rewrites, err := replay.LoadRewrites(*replayRewrites)
+		if err != nil {
+			return log.Errf(ctx, err, "Couldn't load the replay rewrites")
+		}
+		ctx = replay.PutRewrites(ctx, rewrites)
+	}
+	if *captureKeyFile != "" {
+		if err := loadCaptureKey(*captureKeyFile); err != nil {
+			return log.Errf(ctx, err, "Couldn't load the capture key")
+		}
+	}
 	m := replay.New(ctx)
 	ctx = replay.PutManager(ctx, m)
 	ctx = trace.PutManager(ctx, trace.New(ctx))
@@ -138,6 +166,11 @@ func run(ctx context.Context) error {
 		onDeviceScanDone(ctx)
 	})
 
+	if *readOnly {
+		// Lets clients hide the operations that the server does not permit.
+		features = append(features, "read-only")
+	}
+
 	return server.Listen(ctx, *rpc, server.Config{
 		Info: &service.ServerInfo{
 			Name:              host.Instance(ctx).Name,
@@ -149,13 +182,47 @@ func run(ctx context.Context) error {
 		},
 		StringTables:     loadStrings(ctx),
 		EnableLocalFiles: *enableLocalFiles,
+		ReadOnly:         *readOnly,
+		MaxPayloadSize:   *maxPayloadSize,
 		AuthToken:        auth.Token(*gapisAuthToken),
 		DeviceScanDone:   deviceScanDone,
 		LogBroadcaster:   logBroadcaster,
+		LogRing:          logRing,
 		IdleTimeout:      *idleTimeout,
+		HealthAddr:       *healthAddr,
+		Workers:          workerAddrs(*workers),
+		WorkerAuthToken:  auth.Token(*workerAuthToken),
+		CaptureIndexDir:  *captureIndexDir,
+		WebAddr:          *webAddr,
+		WebOrigin:        *webOrigin,
 	})
 }
 
+// loadCaptureKey sets the key used to read and write encrypted captures to the
+// hex encoded key held by the file at path.
+func loadCaptureKey(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return err
+	}
+	return capture.SetKey(key)
+}
+
+// workerAddrs returns the worker addresses listed by the -workers flag value.
+func workerAddrs(list string) []string {
+	out := []string{}
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
 func monitorAndroidDevices(ctx context.Context, r *bind.Registry, scanDone func()) {
 	// Populate the registry with all the existing devices.
 	func() {
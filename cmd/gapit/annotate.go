@@ -0,0 +1,58 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+)
+
+type annotateVerb struct{ AnnotateFlags }
+
+func init() {
+	verb := &annotateVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "annotate",
+		ShortHelp: "Tags and annotates a gfx trace file, so it can be found with list",
+		Action:    verb,
+	})
+}
+
+func (verb *annotateVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+	if verb.Annotation != "" && verb.ClearAnnotation {
+		app.Usage(ctx, "Only one of -annotation and -clearannotation may be given")
+		return nil
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, GapirFlags{})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	setAnnotation := verb.Annotation != "" || verb.ClearAnnotation
+	err = client.AnnotateCapture(ctx, flags.Arg(0), splitList(verb.Add), splitList(verb.Remove), setAnnotation, verb.Annotation)
+	if err != nil {
+		return log.Errf(ctx, err, "AnnotateCapture(%v)", flags.Arg(0))
+	}
+	return nil
+}
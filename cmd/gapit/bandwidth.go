@@ -0,0 +1,88 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type bandwidthVerb struct{ BandwidthFlags }
+
+func init() {
+	verb := &bandwidthVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "bandwidth",
+		ShortHelp: "Prints the estimated memory bandwidth per frame of a capture file as CSV",
+		Action:    verb,
+	})
+}
+
+func (verb *bandwidthVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	boxedVal, err := client.Get(ctx, (&path.Stats{
+		Capture:   capture,
+		Bandwidth: true,
+		Frames:    &path.FrameRange{First: verb.Frames.Start, Count: verb.Frames.Count},
+	}).Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to estimate the bandwidth")
+	}
+
+	var out io.Writer = os.Stdout
+	if verb.Out != "" {
+		f, err := os.OpenFile(verb.Out, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to open CSV output file")
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := csv.NewWriter(out)
+	defer w.Flush()
+
+	if err := w.Write([]string{"Frame", "Category", "Read(bytes)", "Write(bytes)"}); err != nil {
+		return log.Err(ctx, err, "Failed to write header")
+	}
+	for i, frame := range boxedVal.(*service.Stats).Bandwidth {
+		for _, u := range frame.Usages {
+			record := []string{fmt.Sprint(uint32(i) + verb.Frames.Start), u.Category.String(), fmt.Sprint(u.ReadBytes), fmt.Sprint(u.WriteBytes)}
+			if err := w.Write(record); err != nil {
+				return log.Err(ctx, err, "Failed to write record")
+			}
+		}
+	}
+	return nil
+}
@@ -166,7 +166,7 @@ func (verb *benchmarkVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	status.Event(ctx, status.GlobalScope, "Trace Size %+v", verb.traceSizeInBytes)
 
 	ctx = status.Start(oldCtx, "Initializing Capture")
-	c, err := client.LoadCapture(ctx, BenchmarkName)
+	c, err := client.LoadCapture(ctx, BenchmarkName, nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,70 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type bindChurnVerb struct{ BindChurnFlags }
+
+func init() {
+	verb := &bindChurnVerb{BindChurnFlags{Max: 10}}
+	app.AddVerb(&app.Verb{
+		Name:      "bindchurn",
+		ShortHelp: "Prints the render passes of a capture file with the most avoidable pipeline and descriptor set binds",
+		Action:    verb,
+	})
+}
+
+func (verb *bindChurnVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	p := capture.BindChurn(verb.Max)
+	p.Frames = &path.FrameRange{First: verb.Frames.Start, Count: verb.Frames.Count}
+	boxedVal, err := client.Get(ctx, p.Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the bind churn")
+	}
+	churn := boxedVal.(*api.BindChurn)
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "Render pass\tDraws\tPipeline binds\tDescriptor set binds\tPush constants\tRedundant\tMergeable\tAvoidable pipelines")
+	for _, rp := range churn.RenderPasses {
+		fmt.Fprintf(w, "%v\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n", rp.Begin.Indices, rp.Draws,
+			rp.PipelineBinds, rp.DescriptorSetBinds, rp.PushConstantUpdates,
+			rp.RedundantBinds, rp.MergeableBinds, rp.AvoidablePipelineBinds)
+	}
+	return nil
+}
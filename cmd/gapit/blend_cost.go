@@ -0,0 +1,87 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type blendCostVerb struct{ BlendCostFlags }
+
+func init() {
+	verb := &blendCostVerb{BlendCostFlags{Max: 20}}
+	app.AddVerb(&app.Verb{
+		Name:      "blendcost",
+		ShortHelp: "Prints the blended draw calls of a frame that change the most pixels",
+		Action:    verb,
+	})
+}
+
+func (verb *blendCostVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+	if verb.Frame < 0 {
+		app.Usage(ctx, "The frame index must not be negative")
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, verb.Gapir, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	device, err := getDevice(ctx, client, capture, verb.Gapir)
+	if err != nil {
+		return err
+	}
+
+	p := capture.BlendCost(uint32(verb.Frame))
+	boxedVal, err := client.Get(ctx, p.Path(), &path.ResolveConfig{ReplayDevice: device})
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to analyze the blending of frame %v", verb.Frame)
+	}
+	cost := boxedVal.(*api.BlendCost)
+
+	fmt.Fprintf(os.Stdout, "Draw calls:           %d (%d blended)\n", cost.Draws, len(cost.BlendedDraws))
+	fmt.Fprintf(os.Stdout, "Blended pixels:       %d\n", cost.BlendedPixels)
+	fmt.Fprintf(os.Stdout, "Transparent overdraw: %.2fx\n", cost.TransparentOverdraw)
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "\nDraw call\tChanged pixels")
+	for i, d := range cost.BlendedDraws {
+		if verb.Max > 0 && i >= verb.Max {
+			break
+		}
+		if d.Measured {
+			fmt.Fprintf(w, "%v\t%d\n", d.Command.Indices, d.ChangedPixels)
+		} else {
+			fmt.Fprintf(w, "%v\tunknown\n", d.Command.Indices)
+		}
+	}
+	return nil
+}
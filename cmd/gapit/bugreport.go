@@ -0,0 +1,238 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/client"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/stringtable"
+)
+
+type bugReportVerb struct{ BugReportFlags }
+
+func init() {
+	verb := &bugReportVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "bugreport",
+		ShortHelp: "Bundles the diagnostics of a capture into a zip file to attach to issue reports",
+		Action:    verb,
+	})
+}
+
+func (verb *bugReportVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, verb.Gapir, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	out := verb.Out
+	if out == "" {
+		out = "bugreport.zip"
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return log.Errf(ctx, err, "Creating file: %v", out)
+	}
+	defer f.Close()
+
+	r := &bugReport{zip: zip.NewWriter(f)}
+
+	info, err := client.GetServerInfo(ctx)
+	r.addJSON(ctx, "server.json", info, err)
+
+	c, err := client.Get(ctx, (&path.Capture{ID: capture.ID, ExcludeMemoryRanges: true}).Path(), nil)
+	r.addJSON(ctx, "capture.json", c, err)
+
+	if !verb.CaptureID {
+		if p, err := filepath.Abs(flags.Arg(0)); err == nil {
+			metadata, err := client.GetCaptureMetadata(ctx, p)
+			r.addJSON(ctx, "metadata.json", metadata, err)
+		}
+	}
+
+	devices, err := verb.devices(ctx, client)
+	r.addJSON(ctx, "devices.json", devices, err)
+
+	replay, err := verb.replayDiagnostics(ctx, client, capture)
+	r.add(ctx, "replay.txt", replay, err)
+
+	if verb.IncludeCapture {
+		data, err := verb.capture(ctx, client, capture)
+		r.add(ctx, "capture.gfxtrace", data, err)
+	}
+
+	// The logs are gathered last to include those of the requests above.
+	logs, err := client.GetRecentLogs(ctx, &service.GetRecentLogsRequest{})
+	if err == nil {
+		w, buf := log.Buffer()
+		h := log.Detailed.Handler(w)
+		for _, m := range logs {
+			h.Handle(m)
+		}
+		r.add(ctx, "logs.txt", buf.Bytes(), nil)
+	} else {
+		r.add(ctx, "logs.txt", nil, err)
+	}
+
+	if len(r.errors) > 0 {
+		r.add(ctx, "errors.txt", []byte(strings.Join(r.errors, "\n")+"\n"), nil)
+	}
+	if err := r.zip.Close(); err != nil {
+		return log.Errf(ctx, err, "Writing file: %v", out)
+	}
+
+	fmt.Fprintf(os.Stdout, "Bug report written to %v\n", out)
+	return nil
+}
+
+// devices returns the description of each of the devices known to the server.
+func (verb *bugReportVerb) devices(ctx context.Context, client client.Client) ([]*device.Instance, error) {
+	paths, err := client.GetDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []*device.Instance{}
+	for _, p := range paths {
+		o, err := client.Get(ctx, p.Path(), nil)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o.(*device.Instance))
+	}
+	return out, nil
+}
+
+// replayDiagnostics replays the capture on the replay device, returning the
+// differences between the capture and replay devices and the issues found.
+func (verb *bugReportVerb) replayDiagnostics(ctx context.Context, client client.Client, capture *path.Capture) ([]byte, error) {
+	device, err := getDevice(ctx, client, capture, verb.Gapir)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, log.Err(ctx, nil, "No replay device")
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Replay device: %v\n", device.ID.ID())
+
+	boxedDevice, err := client.Get(ctx, capture.Device(device).Path(), nil)
+	if err != nil {
+		return nil, err
+	}
+	mismatches := boxedDevice.(*service.CaptureDevice).Mismatches
+	if len(mismatches) == 0 {
+		fmt.Fprintln(buf, "The replay device matches the capture device")
+	}
+	for _, m := range mismatches {
+		fmt.Fprintf(buf, "%s: captured on '%s', replaying on '%s'\n", m.Property, m.CaptureValue, m.ReplayValue)
+	}
+
+	var stringTable *stringtable.StringTable
+	if stringTables, err := client.GetAvailableStringTables(ctx); err == nil && len(stringTables) > 0 {
+		stringTable, _ = client.GetStringTable(ctx, stringTables[0])
+	}
+
+	boxedReport, err := client.Get(ctx, capture.Report(device, nil, false).Path(), nil)
+	if err != nil {
+		return buf.Bytes(), err
+	}
+	report := boxedReport.(*service.Report)
+	fmt.Fprintf(buf, "\n%d issues found\n", len(report.Items))
+	for _, e := range report.Items {
+		where := ""
+		if e.Command != nil {
+			where = fmt.Sprintf("%v ", e.Command.Indices)
+		}
+		msg := report.Msg(e.Message).Text(stringTable)
+		fmt.Fprintf(buf, "[%s] %s%s\n", e.Severity.String(), where, msg)
+	}
+	return buf.Bytes(), nil
+}
+
+// capture returns the data of the capture, trimmed to the dependencies of the
+// first TrimFrames frames if set.
+func (verb *bugReportVerb) capture(ctx context.Context, client client.Client, capture *path.Capture) ([]byte, error) {
+	if verb.TrimFrames > 0 {
+		eofEvents, err := getEvents(ctx, client, &path.Events{
+			Capture:     capture,
+			LastInFrame: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(eofEvents) > verb.TrimFrames {
+			cmds := make([]*path.Command, verb.TrimFrames)
+			for i := range cmds {
+				cmds[i] = &path.Command{Capture: capture, Indices: eofEvents[i].Command.Indices}
+			}
+			if capture, err = client.DCECapture(ctx, capture, cmds); err != nil {
+				return nil, log.Errf(ctx, err, "DCECapture(%v)", cmds)
+			}
+		}
+	}
+	return client.ExportCapture(ctx, capture)
+}
+
+// bugReport writes the entries of a bug report zip file. Failures to gather
+// an entry are recorded in the report rather than aborting it.
+type bugReport struct {
+	zip    *zip.Writer
+	errors []string
+}
+
+// add writes data as the entry name, or records err if not nil.
+func (r *bugReport) add(ctx context.Context, name string, data []byte, err error) {
+	if err == nil {
+		var w io.Writer
+		if w, err = r.zip.Create(name); err == nil {
+			_, err = w.Write(data)
+		}
+	}
+	if err != nil {
+		log.W(ctx, "Couldn't add %v to the bug report: %v", name, err)
+		r.errors = append(r.errors, fmt.Sprintf("%v: %v", name, err))
+	}
+}
+
+// addJSON writes v as JSON as the entry name, or records err if not nil.
+func (r *bugReport) addJSON(ctx context.Context, name string, v interface{}, err error) {
+	var data []byte
+	if err == nil {
+		data, err = json.MarshalIndent(v, "", "  ")
+	}
+	r.add(ctx, name, data, err)
+}
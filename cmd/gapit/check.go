@@ -0,0 +1,304 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/client"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type checkVerb struct{ CheckFlags }
+
+func init() {
+	verb := &checkVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "check",
+		ShortHelp: "Check a capture against performance budgets, failing if any is exceeded",
+		Action:    verb,
+	})
+}
+
+// The metrics that budget rules can limit.
+const (
+	// The GPU time of each frame in milliseconds, measured by profiling the
+	// replay of the capture.
+	frameGpuTimeMetric = "frame_gpu_time_ms"
+	// The number of draw calls of each frame.
+	drawCallsMetric = "draw_calls"
+	// The total size in bytes of the memory allocations after the last
+	// command of the capture.
+	memoryMetric = "memory_bytes"
+)
+
+// defaultBudgets is the budget file used if none is specified.
+const defaultBudgets = ".gapid-budgets.json"
+
+// budgetRule is a limit of a metric of the capture.
+type budgetRule struct {
+	// Metric is the name of the limited metric.
+	Metric string `json:"metric"`
+	// Max is the largest value of the metric within the budget.
+	Max float64 `json:"max"`
+	// Device restricts the rule to replays on the device with this name or
+	// serial, if not empty.
+	Device string `json:"device,omitempty"`
+}
+
+// The statuses of budget results.
+const (
+	budgetPass    = "pass"
+	budgetFail    = "fail"
+	budgetSkipped = "skipped"
+)
+
+// budgetResult is the outcome of checking a budget rule.
+type budgetResult struct {
+	Rule   budgetRule `json:"rule"`
+	Status string     `json:"status"`
+	// Worst is the largest value of the metric.
+	Worst float64 `json:"worst"`
+	// Frame is the frame of the largest value, for per-frame metrics.
+	Frame int `json:"frame"`
+	// Failures are the values over the budget.
+	Failures []budgetFailure `json:"failures,omitempty"`
+	// Reason explains why a rule was skipped.
+	Reason string `json:"reason,omitempty"`
+}
+
+// budgetFailure is a value of a metric over its budget.
+type budgetFailure struct {
+	Frame int     `json:"frame"`
+	Value float64 `json:"value"`
+}
+
+func (verb *checkVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	file := verb.Budgets
+	if file == "" {
+		file = defaultBudgets
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to read the budgets")
+	}
+	rules := []budgetRule{}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return log.Errf(ctx, err, "Failed to parse the budgets %v", file)
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, verb.Gapir, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	replayDevice, err := getDevice(ctx, client, capture, verb.Gapir)
+	if err != nil {
+		return err
+	}
+	var instance *device.Instance
+	if replayDevice != nil {
+		boxedDevice, err := client.Get(ctx, replayDevice.Path(), nil)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to get the replay device")
+		}
+		instance = boxedDevice.(*device.Instance)
+	}
+
+	// The metrics are only resolved if a rule needs them.
+	var frameTimes, drawCalls []float64
+	var memory *float64
+	metric := func(name string) ([]float64, error) {
+		switch name {
+		case frameGpuTimeMetric:
+			if frameTimes == nil {
+				res, err := client.GpuProfile(ctx, &service.GpuProfileRequest{Capture: capture, Device: replayDevice})
+				if err != nil {
+					return nil, log.Err(ctx, err, "Failed to profile the capture")
+				}
+				frameTimes = []float64{}
+				for _, f := range res.GetQueueOverlap().GetFrames() {
+					frameTimes = append(frameTimes, frameGpuTime(f))
+				}
+			}
+			return frameTimes, nil
+		case drawCallsMetric:
+			if drawCalls == nil {
+				boxedStats, err := client.Get(ctx, (&path.Stats{Capture: capture, DrawCall: true}).Path(), nil)
+				if err != nil {
+					return nil, log.Err(ctx, err, "Failed to get the draw calls")
+				}
+				drawCalls = []float64{}
+				for _, draws := range boxedStats.(*service.Stats).DrawCalls {
+					drawCalls = append(drawCalls, float64(draws))
+				}
+			}
+			return drawCalls, nil
+		case memoryMetric:
+			if memory == nil {
+				size, err := memorySize(ctx, client, capture)
+				if err != nil {
+					return nil, err
+				}
+				memory = &size
+			}
+			return []float64{*memory}, nil
+		default:
+			return nil, fmt.Errorf("Unknown budget metric '%v'", name)
+		}
+	}
+
+	results, failed, err := checkBudgets(rules, instance, replayDevice != nil, metric)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Status == budgetSkipped && r.Rule.Device != "" {
+			log.W(ctx, "The budget of %v for device %v was not checked: %v", r.Rule.Metric, r.Rule.Device, r.Reason)
+		}
+	}
+
+	for _, r := range results {
+		fmt.Fprintf(os.Stdout, "[%s] %s <= %v", r.Status, r.Rule.Metric, r.Rule.Max)
+		if r.Rule.Device != "" {
+			fmt.Fprintf(os.Stdout, " on %s", r.Rule.Device)
+		}
+		switch {
+		case r.Status == budgetSkipped:
+			fmt.Fprintf(os.Stdout, ": %s\n", r.Reason)
+		case r.Rule.Metric == memoryMetric:
+			fmt.Fprintf(os.Stdout, ": %v\n", r.Worst)
+		default:
+			fmt.Fprintf(os.Stdout, ": worst %v in frame %d, %d frames over budget\n", r.Worst, r.Frame, len(r.Failures))
+		}
+	}
+
+	if verb.Out != "" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return log.Err(ctx, err, "Failed to marshal the budget results")
+		}
+		if err := ioutil.WriteFile(verb.Out, data, 0644); err != nil {
+			return log.Err(ctx, err, "Failed to write the budget results")
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d budgets exceeded", failed, len(rules))
+	}
+	return nil
+}
+
+// checkBudgets evaluates the rules against the values of the metrics returned
+// by metric, for the replays on the device instance, and returns the result of
+// each rule and the number of failed rules. Rules bound to a device other than
+// instance are skipped, as are the rules profiling the replay if there is no
+// replay device.
+func checkBudgets(rules []budgetRule, instance *device.Instance, canReplay bool, metric func(name string) ([]float64, error)) ([]*budgetResult, int, error) {
+	results := make([]*budgetResult, len(rules))
+	failed := 0
+	for i, rule := range rules {
+		result := &budgetResult{Rule: rule, Status: budgetPass}
+		results[i] = result
+		if rule.Device != "" {
+			if instance == nil {
+				result.Status, result.Reason = budgetSkipped, "not replayed on any device"
+				continue
+			}
+			if instance.GetName() != rule.Device && instance.GetSerial() != rule.Device {
+				result.Status = budgetSkipped
+				result.Reason = fmt.Sprintf("replayed on %v (%v) instead", instance.GetName(), instance.GetSerial())
+				continue
+			}
+		}
+		if rule.Metric == frameGpuTimeMetric && !canReplay {
+			result.Status, result.Reason = budgetSkipped, "profiling needs a replay device"
+			continue
+		}
+		values, err := metric(rule.Metric)
+		if err != nil {
+			return nil, 0, err
+		}
+		for frame, v := range values {
+			if frame == 0 || v > result.Worst {
+				result.Worst, result.Frame = v, frame
+			}
+			if v > rule.Max {
+				result.Failures = append(result.Failures, budgetFailure{frame, v})
+			}
+		}
+		if len(result.Failures) > 0 {
+			result.Status = budgetFail
+			failed++
+		}
+	}
+	return results, failed, nil
+}
+
+// frameGpuTime returns the time in milliseconds that the GPU was executing
+// the work of the frame f on any of its queues, excluding the time it was idle
+// between the start and end of the frame.
+func frameGpuTime(f *service.FrameQueueOverlap) float64 {
+	busy := uint64(0)
+	for _, q := range f.GetQueues() {
+		busy += q.GetBusy()
+	}
+	if overlap := f.GetGraphicsComputeOverlap(); overlap < busy {
+		busy -= overlap
+	}
+	return float64(busy) / 1e6
+}
+
+// memorySize returns the total size of the memory allocations after the last
+// command of the capture.
+func memorySize(ctx context.Context, client client.Client, capture *path.Capture) (float64, error) {
+	boxedCapture, err := client.Get(ctx, capture.Path(), nil)
+	if err != nil {
+		return 0, log.Err(ctx, err, "Failed to load the capture")
+	}
+	numCommands := uint64(boxedCapture.(*service.Capture).NumCommands)
+	if numCommands == 0 {
+		return 0, nil
+	}
+	last := numCommands - 1
+	boxedMetrics, err := client.Get(ctx, (&path.Metrics{
+		Command:         capture.Command(last),
+		MemoryBreakdown: true,
+	}).Path(), nil)
+	if err != nil {
+		return 0, log.Err(ctx, err, "Failed to load the memory breakdown")
+	}
+	size := uint64(0)
+	for _, alloc := range boxedMetrics.(*api.Metrics).GetMemoryBreakdown().GetAllocations() {
+		size += alloc.Size
+	}
+	return float64(size), nil
+}
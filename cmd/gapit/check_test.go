@@ -0,0 +1,79 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/service"
+)
+
+func TestCheckBudgets(t *testing.T) {
+	ctx := log.Testing(t)
+	values := map[string][]float64{
+		frameGpuTimeMetric: {12, 18, 15},
+		drawCallsMetric:    {100, 300, 250},
+		memoryMetric:       {1024},
+	}
+	metric := func(name string) ([]float64, error) { return values[name], nil }
+	instance := &device.Instance{Name: "Pixel 4", Serial: "ABC123"}
+	rules := []budgetRule{
+		{Metric: frameGpuTimeMetric, Max: 16},
+		{Metric: drawCallsMetric, Max: 500},
+		{Metric: drawCallsMetric, Max: 200, Device: "ABC123"},
+		{Metric: memoryMetric, Max: 512, Device: "Pixel 4"},
+		{Metric: drawCallsMetric, Max: 10, Device: "Pixel 5"},
+	}
+
+	results, failed, err := checkBudgets(rules, instance, true, metric)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "failed").That(failed).Equals(3)
+	statuses := []string{}
+	for _, r := range results {
+		statuses = append(statuses, r.Status)
+	}
+	assert.For(ctx, "statuses").ThatSlice(statuses).Equals([]string{
+		budgetFail, budgetPass, budgetFail, budgetFail, budgetSkipped,
+	})
+	assert.For(ctx, "worst").That(results[0].Worst).Equals(18.0)
+	assert.For(ctx, "worst frame").That(results[0].Frame).Equals(1)
+	assert.For(ctx, "failures").ThatSlice(results[2].Failures).Equals([]budgetFailure{{1, 300}, {2, 250}})
+	assert.For(ctx, "other device").ThatString(results[4].Reason).Contains("ABC123")
+
+	results, failed, err = checkBudgets(rules, nil, false, metric)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "failed without device").That(failed).Equals(0)
+	assert.For(ctx, "profile without device").That(results[0].Status).Equals(budgetSkipped)
+	assert.For(ctx, "rule without device").That(results[1].Status).Equals(budgetPass)
+	assert.For(ctx, "device rule without device").That(results[2].Status).Equals(budgetSkipped)
+}
+
+func TestFrameGpuTime(t *testing.T) {
+	ctx := log.Testing(t)
+	f := &service.FrameQueueOverlap{
+		Start: 0,
+		End:   20000000,
+		Queues: []*service.QueueActivity{
+			{Busy: 8000000, Idle: 12000000},
+			{Busy: 4000000, Idle: 16000000, Compute: true},
+		},
+		GraphicsComputeOverlap: 2000000,
+	}
+	assert.For(ctx, "gpu time").That(frameGpuTime(f)).Equals(10.0)
+	assert.For(ctx, "empty frame").That(frameGpuTime(&service.FrameQueueOverlap{})).Equals(0.0)
+}
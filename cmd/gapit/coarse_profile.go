@@ -57,7 +57,7 @@ func (verb *coarseProfileVerb) Run(ctx context.Context, flags flag.FlagSet) erro
 	}
 	defer client.Close()
 
-	capturePath, err := client.LoadCapture(ctx, capture)
+	capturePath, err := client.LoadCapture(ctx, capture, nil)
 	if err != nil {
 		return log.Err(ctx, err, "Failed to load the capture file")
 	}
@@ -90,9 +90,10 @@ func (verb *coarseProfileVerb) Run(ctx context.Context, flags flag.FlagSet) erro
 	}
 
 	req := &service.GetTimestampsRequest{
-		Capture:   capturePath,
-		Device:    device,
-		LoopCount: int32(verb.LoopCount),
+		Capture:     capturePath,
+		Device:      device,
+		LoopCount:   int32(verb.LoopCount),
+		SubCommands: verb.SubCommands,
 	}
 
 	client.GetTimestamps(ctx, req, func(r *service.GetTimestampsResponse) error {
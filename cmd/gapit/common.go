@@ -16,8 +16,10 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 	"os"
 	"path/filepath"
@@ -107,6 +109,14 @@ func getGapis(ctx context.Context, gapisFlags GapisFlags, gapirFlags GapirFlags)
 		args = append(args, "--gapir-args", gapirFlags.Args)
 	}
 	args = append(args, "--idle-timeout", "1m")
+	if gapisFlags.CaptureKeyFile != "" {
+		// The key is used for all the captures of the server, so it can only
+		// be given to a server started for this command.
+		if gapisFlags.Port != 0 {
+			return nil, log.Err(ctx, nil, "The capture key file can only be used when gapit starts gapis")
+		}
+		args = append(args, "--capture-key-file", gapisFlags.CaptureKeyFile)
+	}
 
 	var token auth.Token
 	if gapisFlags.Port == 0 {
@@ -178,7 +188,7 @@ func getGapis(ctx context.Context, gapisFlags GapisFlags, gapirFlags GapirFlags)
 
 	if !gapisFlags.DisableLog {
 		if h := log.GetHandler(ctx); h != nil {
-			crash.Go(func() { client.GetLogStream(ctx, h) })
+			crash.Go(func() { client.GetLogStream(ctx, &service.GetLogStreamRequest{}, h) })
 		}
 	}
 
@@ -218,7 +228,14 @@ func getGapisAndLoadCapture(ctx context.Context, gapisFlags GapisFlags, gapirFla
 	if captureFileFlags.CaptureID {
 		capture = &path.Capture{ID: path.NewID(captureID)}
 	} else {
-		capture, err = client.LoadCapture(ctx, capturePath)
+		var key []byte
+		if captureFileFlags.KeyFile != "" {
+			if key, err = readCaptureKey(captureFileFlags.KeyFile); err != nil {
+				client.Close()
+				return nil, nil, log.Err(ctx, err, "Could not read the capture key")
+			}
+		}
+		capture, err = client.LoadCapture(ctx, capturePath, key)
 		if err != nil {
 			client.Close()
 			return nil, nil, log.Err(ctx, err, "Failed to load the capture file")
@@ -230,6 +247,15 @@ func getGapisAndLoadCapture(ctx context.Context, gapisFlags GapisFlags, gapirFla
 	return client, capture, nil
 }
 
+// readCaptureKey returns the hex encoded AES key held by the file at path.
+func readCaptureKey(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimSpace(string(data)))
+}
+
 func getDevice(ctx context.Context, client client.Client, capture *path.Capture, flags GapirFlags) (*path.Device, error) {
 	if flags.Device == "none" {
 		return nil, nil
@@ -531,6 +557,8 @@ func printCommand(ctx context.Context, client service.Service, p *path.Command,
 				return log.Err(ctx, err, "Couldn't fetch constant set")
 			}
 			v = constants.Sprint(v)
+		} else if p.Units != "" {
+			v = api.FormatWithUnits(v, p.Units)
 		}
 		params[i] = fmt.Sprintf("%v: %v", p.Name, v)
 	}
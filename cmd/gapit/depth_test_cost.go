@@ -0,0 +1,89 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type depthTestCostVerb struct{ DepthTestCostFlags }
+
+func init() {
+	verb := &depthTestCostVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "depthtestcost",
+		ShortHelp: "Prints the samples of each draw call of a frame that fail the depth test, to find draws that benefit from reordering or a depth pre-pass",
+		Action:    verb,
+	})
+}
+
+func (verb *depthTestCostVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+	if verb.Frame < 0 {
+		app.Usage(ctx, "The frame index must not be negative")
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, verb.Gapir, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	device, err := getDevice(ctx, client, capture, verb.Gapir)
+	if err != nil {
+		return err
+	}
+
+	p := capture.DepthTestCost(uint32(verb.Frame))
+	boxedVal, err := client.Get(ctx, p.Path(), &path.ResolveConfig{ReplayDevice: device})
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to measure the depth test of frame %v", verb.Frame)
+	}
+	cost := boxedVal.(*service.DepthTestCost)
+
+	fmt.Fprintf(os.Stdout, "Draw calls:     %d (%d not measured)\n", len(cost.Draws), cost.Skipped)
+	fmt.Fprintf(os.Stdout, "Samples:        %d\n", cost.Samples)
+	fmt.Fprintf(os.Stdout, "Failed samples: %d\n", cost.FailedSamples)
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "\nDraw call\tSamples\tFailed\tFailed %\tSuggestion")
+	printed := 0
+	for _, d := range cost.Draws {
+		if verb.Max > 0 && printed >= verb.Max {
+			break
+		}
+		if !verb.All && d.Suggestion == service.DepthTestSuggestion_NoDepthTestSuggestion {
+			continue
+		}
+		fmt.Fprintf(w, "%v\t%d\t%d\t%.1f\t%v\n", d.Command.Indices, d.Samples,
+			d.Samples-d.PassedSamples, d.FailedFraction*100, d.Suggestion)
+		printed++
+	}
+	return nil
+}
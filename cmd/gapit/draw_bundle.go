@@ -0,0 +1,73 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+type drawBundleVerb struct{ DrawBundleFlags }
+
+func init() {
+	verb := &drawBundleVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "draw_bundle",
+		ShortHelp: "Saves the state used by a single draw call as a self-contained bundle",
+		Action:    verb,
+	})
+}
+
+func (verb *drawBundleVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+	if len(verb.At) == 0 {
+		app.Usage(ctx, "The command index of the draw call must be specified with --at")
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	cmd := capture.Command(verb.At[0], verb.At[1:]...)
+	boxedVal, err := client.Get(ctx, cmd.DrawBundle().Path(), nil)
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to load the draw bundle of %v", cmd)
+	}
+	data, err := proto.Marshal(boxedVal.(*api.DrawBundle))
+	if err != nil {
+		return log.Err(ctx, err, "Couldn't marshal the draw bundle")
+	}
+
+	filePath := verb.Out
+	if filePath == "" {
+		filePath = "draw_bundle.pb"
+	}
+	if err := ioutil.WriteFile(filePath, data, 0666); err != nil {
+		return log.Errf(ctx, err, "Writing file (%v)", filePath)
+	}
+	return nil
+}
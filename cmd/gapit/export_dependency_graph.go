@@ -0,0 +1,85 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type exportDependencyGraphVerb struct{ ExportDependencyGraphFlags }
+
+func init() {
+	verb := &exportDependencyGraphVerb{ExportDependencyGraphFlags{Format: "dot", Radius: 2}}
+	app.AddVerb(&app.Verb{
+		Name:      "export_dependency_graph",
+		ShortHelp: "Export the dependency graph of a capture, or of the commands around a command, as DOT or JSON",
+		Action:    verb,
+	})
+}
+
+func (verb *exportDependencyGraphVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+	var format service.GraphFormat
+	switch verb.Format {
+	case "dot":
+		format = service.GraphFormat_DOT
+	case "json":
+		format = service.GraphFormat_JSON
+	default:
+		app.Usage(ctx, "invalid format (supported formats: dot and json)")
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var around *path.Command
+	if len(verb.At) > 0 {
+		around = capture.Command(verb.At[0], verb.At[1:]...)
+	}
+
+	graph, err := client.ExportDependencyGraph(ctx, capture, around, uint32(verb.Radius), format)
+	if err != nil {
+		return log.Errf(ctx, err, "ExportDependencyGraph(%v)", capture)
+	}
+
+	filePath := verb.Out
+	if filePath == "" {
+		filePath = "dependency_graph." + verb.Format
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return log.Errf(ctx, err, "Creating file (%v)", filePath)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(graph); err != nil {
+		return log.Errf(ctx, err, "Writing file (%v)", filePath)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/app/flags"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+type exportStateVerb struct{ ExportStateFlags }
+
+func init() {
+	verb := &exportStateVerb{ExportStateFlags{At: flags.U64Slice{}, MaxMemorySize: 1024}}
+	app.AddVerb(&app.Verb{
+		Name:      "export_state",
+		ShortHelp: "Export the entire state after a command of a capture as JSON",
+		Action:    verb,
+	})
+}
+
+func (verb *exportStateVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if len(verb.At) == 0 {
+		boxedCapture, err := client.Get(ctx, capture.Path(), nil)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to load the capture")
+		}
+		verb.At = []uint64{uint64(boxedCapture.(*service.Capture).NumCommands) - 1}
+	}
+	after := capture.Command(verb.At[0], verb.At[1:]...)
+
+	state, err := client.ExportState(ctx, after, verb.MaxMemorySize, nil)
+	if err != nil {
+		return log.Errf(ctx, err, "ExportState(%v)", after)
+	}
+
+	filePath := verb.Out
+	if filePath == "" {
+		filePath = "state.json"
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return log.Errf(ctx, err, "Creating file (%v)", filePath)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(state); err != nil {
+		return log.Errf(ctx, err, "Writing file (%v)", filePath)
+	}
+	return nil
+}
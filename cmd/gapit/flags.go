@@ -138,7 +138,8 @@ func (v PerfettoOutputFormat) String() string {
 
 type (
 	CaptureFileFlags struct {
-		CaptureID bool `help:"if true then interpret the capture file argument as a capture ID that is already loaded in gapis"`
+		CaptureID bool   `help:"if true then interpret the capture file argument as a capture ID that is already loaded in gapis"`
+		KeyFile   string `help:"file holding the hex encoded AES key used to decrypt the capture file"`
 	}
 	CommandFilterFlags struct {
 		Context     int    `help:"Filter to the i'th context. Does nothing with -contextname."`
@@ -167,11 +168,12 @@ type (
 		Trace string `help:"_produce a trace file"`
 	}
 	GapisFlags struct {
-		Profile    ProfileFlags
-		Port       int    `help:"gapis tcp port to connect to, 0 means start new instance."`
-		Args       string `help:"_The arguments to be passed to gapis"`
-		Token      string `help:"_The auth token to use when connecting to an existing server."`
-		DisableLog bool   `help:"_Disable the log output"`
+		Profile        ProfileFlags
+		Port           int    `help:"gapis tcp port to connect to, 0 means start new instance."`
+		Args           string `help:"_The arguments to be passed to gapis"`
+		CaptureKeyFile string `help:"file holding the hex encoded AES key used by the started gapis to read and write encrypted captures"`
+		Token          string `help:"_The auth token to use when connecting to an existing server."`
+		DisableLog     bool   `help:"_Disable the log output"`
 	}
 	GapirFlags struct {
 		DeviceFlags
@@ -186,9 +188,20 @@ type (
 		Gapir            GapirFlags
 		Out              string `help:"output report path"`
 		DisplayToSurface bool   `help:"display the frames rendered in the replay back to the surface"`
+		Suppressions     string `help:"path of a JSON list of rules silencing known issues, such as a baseline"`
+		WriteBaseline    string `help:"path to write a JSON baseline suppressing all the reported issues to"`
+		Config           string `help:"path of a JSON config of the analyzers and their budgets (default .gapid-analysis.json, if present)"`
 		CommandFilterFlags
 		CaptureFileFlags
 	}
+	ReportDiffFlags struct {
+		Gapis            GapisFlags
+		Gapir            GapirFlags
+		Out              string `help:"output report diff path"`
+		DisplayToSurface bool   `help:"display the frames rendered in the replay back to the surface"`
+		Unchanged        bool   `help:"also list the issues found in both captures"`
+		CaptureFileFlags
+	}
 	ExportReplayFlags struct {
 		Gapis          GapisFlags
 		Gapir          GapirFlags
@@ -218,7 +231,8 @@ type (
 			Count   int `help:"number of frames after Start to capture: -1 for all frames"`
 			Minimum int `help:"_return error when less than this number of frames is found"`
 		}
-		NoOpt bool `help:"disables optimization of the replay stream"`
+		NoOpt   bool          `help:"disables optimization of the replay stream"`
+		Timeout time.Duration `help:"time limit for rendering the frames, the video is made of the frames rendered by then"`
 		CommandFilterFlags
 		CaptureFileFlags
 	}
@@ -298,6 +312,7 @@ type (
 		Observe        struct {
 			Frames uint `help:"capture the framebuffer every n frames (0 to disable)"`
 			Draws  uint `help:"capture the framebuffer every n draws (0 to disable)"`
+			LowRes bool `help:"downsample the captured framebuffers to low resolution screenshots"`
 		}
 		Disable struct {
 			PCS     bool `help:"disable pre-compiled shaders"`
@@ -307,8 +322,13 @@ type (
 			CoherentMemoryTracker bool `help:"_disables the coherent memory tracker so it won't interfere with gdb during trace"`
 		}
 		Record struct {
-			Errors     bool `help:"_record device error state"`
-			TraceTimes bool `help:"record trace timing into the capture"`
+			Errors      bool `help:"_record device error state"`
+			TraceTimes  bool `help:"record trace timing into the capture"`
+			InputEvents bool `help:"record the input events of the device into the capture. Only valid for Android."`
+			IO          bool `help:"sample the disk and network I/O counters of the process at every frame"`
+			Device      struct {
+				Metrics bool `help:"sample the thermal zones, CPU and GPU frequencies and battery state of the device. Only valid for Android."`
+			}
 		}
 		Clear struct {
 			Cache bool `help:"clear package data before running it"`
@@ -347,6 +367,9 @@ type (
 		OutputCSV      bool   `help:"outputs data in CSV-friendly format"`
 	}
 
+	SelectionFlags struct {
+		Gapis GapisFlags
+	}
 	StatusFlags struct {
 		Gapis                GapisFlags
 		StatusUpdateInterval int `help:"Provides status updates at the given interval (in ms)"`
@@ -384,6 +407,38 @@ type (
 		Verbose bool `help:"if true, then output will not be truncated"`
 	}
 
+	UploadsFlags struct {
+		Gapis   GapisFlags
+		MinSize uint64 `help:"ignore uploads of fewer bytes than this"`
+		CaptureFileFlags
+	}
+	FrameRangeFlags struct {
+		Start uint32 `help:"first frame to analyze, skipping the frames loading the application"`
+		Count uint32 `help:"number of frames to analyze: 0 for all frames from Start"`
+	}
+	BandwidthFlags struct {
+		Gapis  GapisFlags
+		Out    string `help:"output CSV file, standard output if none"`
+		Frames FrameRangeFlags
+		CaptureFileFlags
+	}
+	BindChurnFlags struct {
+		Gapis  GapisFlags
+		Max    uint32 `help:"maximum number of render passes to print, all if 0"`
+		Frames FrameRangeFlags
+		CaptureFileFlags
+	}
+	FrameRedundancyFlags struct {
+		Gapis  GapisFlags
+		Out    string `help:"output CSV file, standard output if none"`
+		Frames FrameRangeFlags
+		CaptureFileFlags
+	}
+	ShaderClustersFlags struct {
+		Gapis         GapisFlags
+		MinSimilarity float64 `help:"minimum similarity, from 0 to 1, of clustered shaders. 0 for the default"`
+		CaptureFileFlags
+	}
 	MemoryFlags struct {
 		Gapis GapisFlags
 		At    flags.U64Slice `help:"command/subcommand index to get the memory after. Empty for last"`
@@ -398,6 +453,14 @@ type (
 		Compute bool `help:"print out the most recently bound compute pipeline instead of graphics pipeline"`
 		CaptureFileFlags
 	}
+	BugReportFlags struct {
+		Gapis          GapisFlags
+		Gapir          GapirFlags
+		Out            string `help:"zip file to write the bug report to (default bugreport.zip)"`
+		IncludeCapture bool   `help:"include the capture in the bug report"`
+		TrimFrames     int    `help:"trim the included capture to the dependencies of its first N frames: 0 for all frames"`
+		CaptureFileFlags
+	}
 	TrimFlags struct {
 		Gapis         GapisFlags
 		Gapir         GapirFlags
@@ -411,17 +474,65 @@ type (
 		CommandFilterFlags
 		CaptureFileFlags
 	}
-	GetTimestampsFlags struct {
+	ScrubFlags struct {
+		Gapis GapisFlags
+		Gapir GapirFlags
+		Out   string `help:"gfxtrace file to save the scrubbed capture (default scrubbed.gfxtrace)"`
+		CaptureFileFlags
+	}
+	ListFlags struct {
 		Gapis     GapisFlags
-		Gapir     GapirFlags
-		LoopCount int    `help:"_The number of times to loop the trace. (experimental)"`
-		Out       string `help:"output file to save the profiling result"`
+		Text      string        `help:"only list captures with this text in their path, application, device or tags"`
+		Tags      string        `help:"comma separated tags that the captures must have"`
+		APIs      string        `help:"comma separated graphics APIs that the captures must use"`
+		Device    string        `help:"only list captures made on a device with this text in its name, serial or model"`
+		MinFrames uint64        `help:"only list captures with at least this many frames"`
+		Since     time.Duration `help:"only list captures modified within this duration"`
+		Limit     uint32        `help:"the maximum number of captures to list, 0 for all"`
+		Refresh   bool          `help:"scan the directory for changed captures before listing"`
+	}
+	PushFlags struct {
+		Gapis       GapisFlags
+		Remote      string `help:"host:port of the server to push the capture to"`
+		RemoteToken string `help:"connection authorization token of the remote server"`
+		CaptureFileFlags
+	}
+	PullFlags struct {
+		Gapis       GapisFlags
+		Remote      string `help:"host:port of the server to pull the capture from"`
+		RemoteToken string `help:"connection authorization token of the remote server"`
+		Out         string `help:"gfxtrace file to save the pulled capture (default pulled.gfxtrace)"`
+	}
+	HTMLReportFlags struct {
+		Gapis       GapisFlags
+		Gapir       GapirFlags
+		Out         string `help:"HTML file to write the report to (default report.html)"`
+		Frames      int    `help:"the maximum number of frame thumbnails: 0 for none, -1 for all"`
+		Thumbnail   int    `help:"the maximum width and height of the frame thumbnails"`
+		Issues      int    `help:"the maximum number of distinct report issues listed"`
+		MaxCommands int    `help:"the maximum number of commands in the command list: 0 for all"`
+		CaptureFileFlags
+	}
+	AnnotateFlags struct {
+		Gapis           GapisFlags
+		Add             string `help:"comma separated tags to give the capture"`
+		Remove          string `help:"comma separated tags to remove from the capture"`
+		Annotation      string `help:"free-text annotation replacing that of the capture"`
+		ClearAnnotation bool   `help:"remove the annotation of the capture"`
+	}
+	GetTimestampsFlags struct {
+		Gapis       GapisFlags
+		Gapir       GapirFlags
+		LoopCount   int    `help:"_The number of times to loop the trace. (experimental)"`
+		Out         string `help:"output file to save the profiling result"`
+		SubCommands bool   `help:"also time each command recorded into the command buffers"`
 	}
 
 	GpuProfileFlags struct {
-		Gapis GapisFlags
-		Gapir GapirFlags
-		Json  bool `help:"Return replay profiling data as JSON instead of text"`
+		Gapis   GapisFlags
+		Gapir   GapirFlags
+		Json    bool `help:"Return replay profiling data as JSON instead of text"`
+		Overlap bool `help:"Print the GPU queue activity and overlap of each frame instead"`
 	}
 
 	CreateGraphVisualizationFlags struct {
@@ -430,6 +541,73 @@ type (
 		Format string `help:"output format of the graph: 'pbtxt' (Tensorboard) or 'dot' (Graphviz)"`
 	}
 
+	ExportDependencyGraphFlags struct {
+		Gapis  GapisFlags
+		Out    string         `help:"path to save the dependency graph"`
+		Format string         `help:"output format of the graph: 'dot' (Graphviz) or 'json'"`
+		At     flags.U64Slice `help:"command/subcommand index to export the graph around. Empty for the full graph"`
+		Radius uint           `help:"maximum number of dependencies between the exported nodes and the command at"`
+		CaptureFileFlags
+	}
+
+	CheckFlags struct {
+		Gapis   GapisFlags
+		Gapir   GapirFlags
+		Budgets string `help:"path of the JSON list of budget rules to check (default .gapid-budgets.json)"`
+		Out     string `help:"path to write the JSON results of the budget rules to"`
+		CaptureFileFlags
+	}
+
+	ExportStateFlags struct {
+		Gapis         GapisFlags
+		Out           string         `help:"path to save the state (default 'state.json')"`
+		At            flags.U64Slice `help:"command index to export the state after. Empty for last"`
+		MaxMemorySize uint64         `help:"largest memory slice in bytes to export the contents of"`
+		CaptureFileFlags
+	}
+
+	DrawBundleFlags struct {
+		Gapis GapisFlags
+		Out   string         `help:"path to save the draw bundle"`
+		At    flags.U64Slice `help:"command/subcommand index of the draw call"`
+		CaptureFileFlags
+	}
+
+	SceneFlags struct {
+		Gapis GapisFlags
+		Out   string `help:"path to save the glTF scene"`
+		Frame int    `help:"index of the frame to reconstruct"`
+		CaptureFileFlags
+	}
+	TextureUsageFlags struct {
+		Gapis       GapisFlags
+		Gapir       GapirFlags
+		Frame       int `help:"index of the frame to analyze"`
+		MaxTextures int `help:"maximum number of textures to measure, largest first: 0 for all"`
+		CaptureFileFlags
+	}
+	BlendCostFlags struct {
+		Gapis GapisFlags
+		Gapir GapirFlags
+		Frame int `help:"index of the frame to analyze"`
+		Max   int `help:"maximum number of blended draw calls to print: 0 for all"`
+		CaptureFileFlags
+	}
+	UniformsFlags struct {
+		Gapis  GapisFlags
+		All    bool `help:"also print the uniforms without a suggested optimization"`
+		Frames FrameRangeFlags
+		CaptureFileFlags
+	}
+	DepthTestCostFlags struct {
+		Gapis GapisFlags
+		Gapir GapirFlags
+		Frame int  `help:"index of the frame to analyze"`
+		Max   int  `help:"maximum number of draw calls to print: 0 for all"`
+		All   bool `help:"also print the draw calls without a suggested optimization"`
+		CaptureFileFlags
+	}
+
 	SmokeTestsFlags struct {
 	}
 
@@ -455,4 +633,26 @@ type (
 		Out        string               `help:"Output file."`
 		Format     PerfettoOutputFormat `help:"Output file format: {text|json}."`
 	}
+
+	InfoFlags struct {
+		Gapis GapisFlags
+		Json  bool `help:"if true then print the capture summary as JSON."`
+	}
+
+	InspectFlags struct {
+		Gapis GapisFlags
+		Gapir GapirFlags
+		At    flags.U64Slice `help:"command/subcommand index to get the state after. 0 for first command. Empty for last"`
+		Depth int            `help:"How many levels of the value's children should be displayed. -1 for all"`
+		CaptureFileFlags
+	}
+	SeriesFlags struct {
+		Gapis GapisFlags
+		Gapir GapirFlags
+		From  uint64 `help:"first command index to sample"`
+		To    int    `help:"last command index to sample. -1 for last"`
+		Param string `help:"sample the parameter of the commands instead of the state, as 'cmdName.Param'"`
+		Out   string `help:"output CSV file, standard output if none"`
+		CaptureFileFlags
+	}
 )
@@ -0,0 +1,354 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	img "github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/client"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/stringtable"
+)
+
+type htmlReportVerb struct{ HTMLReportFlags }
+
+func init() {
+	verb := &htmlReportVerb{HTMLReportFlags{
+		Frames:      64,
+		Thumbnail:   256,
+		Issues:      50,
+		MaxCommands: 100000,
+	}}
+	app.AddVerb(&app.Verb{
+		Name:      "html-report",
+		ShortHelp: "Writes a self-contained HTML summary of a gfx trace, with thumbnails, stats, issues and commands",
+		Action:    verb,
+	})
+}
+
+// htmlReport is the data of the HTML report template.
+type htmlReport struct {
+	Name        string
+	Device      string
+	ABI         string
+	NumCommands uint64
+	Frames      []htmlFrame
+	NumFrames   int
+	Issues      []htmlIssue
+	NumIssues   int
+	Commands    []htmlCommand
+	Truncated   bool
+	Errors      []string
+}
+
+// htmlFrame is a frame of the HTML report.
+type htmlFrame struct {
+	Index     int
+	Command   string
+	DrawCalls string
+	Thumbnail template.URL
+}
+
+// htmlIssue is a distinct report issue of the HTML report.
+type htmlIssue struct {
+	Severity string
+	Message  string
+	Count    int
+	First    string
+}
+
+// htmlCommand is a command of the HTML report's command list.
+type htmlCommand struct {
+	Index  string `json:"i"`
+	Name   string `json:"n"`
+	Params string `json:"p"`
+}
+
+func (verb *htmlReportVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, verb.Gapir, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	r := &htmlReport{}
+	fail := func(what string, err error) {
+		log.W(ctx, "%v: %v", what, err)
+		r.Errors = append(r.Errors, fmt.Sprintf("%v: %v", what, err))
+	}
+
+	boxedCapture, err := client.Get(ctx, (&path.Capture{ID: capture.ID, ExcludeMemoryRanges: true}).Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the capture")
+	}
+	c := boxedCapture.(*service.Capture)
+	r.Name, r.ABI, r.NumCommands = c.Name, c.ABI.GetName(), c.NumCommands
+	if d := c.Device; d != nil {
+		r.Device = strings.TrimSpace(fmt.Sprintf("%v %v", d.Name, d.GetConfiguration().GetHardware().GetName()))
+	}
+
+	device, err := getDevice(ctx, client, capture, verb.Gapir)
+	if err != nil {
+		fail("Replay device", err)
+	}
+
+	if err := verb.frames(ctx, client, capture, device, r); err != nil {
+		fail("Frames", err)
+	}
+	if device != nil {
+		if err := verb.issues(ctx, client, capture, device, r); err != nil {
+			fail("Report", err)
+		}
+	}
+	if err := verb.commands(ctx, client, capture, r); err != nil {
+		fail("Commands", err)
+	}
+
+	out := verb.Out
+	if out == "" {
+		out = "report.html"
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return log.Errf(ctx, err, "Creating file: %v", out)
+	}
+	defer f.Close()
+	if err := htmlReportTemplate.Execute(f, r); err != nil {
+		return log.Errf(ctx, err, "Writing file: %v", out)
+	}
+	fmt.Fprintf(os.Stdout, "HTML report written to %v\n", out)
+	return nil
+}
+
+// frames adds the frames of the capture, with their draw call counts and the
+// thumbnails of the first Frames frames, to r.
+func (verb *htmlReportVerb) frames(ctx context.Context, client client.Client, capture *path.Capture, device *path.Device, r *htmlReport) error {
+	events, err := getEvents(ctx, client, &path.Events{Capture: capture, LastInFrame: true})
+	if err != nil {
+		return err
+	}
+	r.NumFrames = len(events)
+
+	var drawCalls []uint64
+	if boxed, err := client.Get(ctx, (&path.Stats{Capture: capture, DrawCall: true}).Path(), nil); err == nil {
+		drawCalls = boxed.(*service.Stats).DrawCalls
+	} else {
+		log.W(ctx, "Couldn't get the draw calls: %v", err)
+	}
+
+	count := len(events)
+	if verb.Frames >= 0 && verb.Frames < count {
+		count = verb.Frames
+	}
+	for i, e := range events[:count] {
+		f := htmlFrame{Index: i, Command: fmt.Sprint(e.Command.Indices), DrawCalls: "-"}
+		if i < len(drawCalls) {
+			f.DrawCalls = fmt.Sprint(drawCalls[i])
+		}
+		if device != nil {
+			thumbnail, err := verb.thumbnail(ctx, client, e.Command, device)
+			if err != nil {
+				log.W(ctx, "Couldn't get the thumbnail of frame %v: %v", i, err)
+			}
+			f.Thumbnail = thumbnail
+		}
+		r.Frames = append(r.Frames, f)
+	}
+	return nil
+}
+
+// thumbnail returns the thumbnail of the framebuffer after cmd as a PNG data
+// URL.
+func (verb *htmlReportVerb) thumbnail(ctx context.Context, client client.Client, cmd *path.Command, device *path.Device) (template.URL, error) {
+	p := &path.Thumbnail{
+		DesiredMaxWidth:  uint32(verb.Thumbnail),
+		DesiredMaxHeight: uint32(verb.Thumbnail),
+		DesiredFormat:    img.RGBA_U8_NORM,
+		Object:           &path.Thumbnail_Command{Command: cmd},
+	}
+	boxed, err := client.Get(ctx, p.Path(), &path.ResolveConfig{ReplayDevice: device})
+	if err != nil {
+		return "", err
+	}
+	info := boxed.(*img.Info)
+	data, err := client.Get(ctx, path.NewBlob(info.Bytes.ID()).Path(), nil)
+	if err != nil {
+		return "", err
+	}
+	w, h := int(info.Width), int(info.Height)
+	frame := flipImg(&image.NRGBA{Rect: image.Rect(0, 0, w, h), Stride: w * 4, Pix: data.([]byte)})
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, frame); err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+// issues adds the Issues most frequent issues of the capture's report to r.
+func (verb *htmlReportVerb) issues(ctx context.Context, client client.Client, capture *path.Capture, device *path.Device, r *htmlReport) error {
+	var stringTable *stringtable.StringTable
+	if stringTables, err := client.GetAvailableStringTables(ctx); err == nil && len(stringTables) > 0 {
+		stringTable, _ = client.GetStringTable(ctx, stringTables[0])
+	}
+
+	boxed, err := client.Get(ctx, capture.Report(device, nil, false).Path(), nil)
+	if err != nil {
+		return err
+	}
+	report := boxed.(*service.Report)
+	r.NumIssues = len(report.Items)
+
+	byMessage := map[string]*htmlIssue{}
+	for _, e := range report.Items {
+		msg := report.Msg(e.Message).Text(stringTable)
+		key := e.Severity.String() + msg
+		issue, ok := byMessage[key]
+		if !ok {
+			issue = &htmlIssue{Severity: e.Severity.String(), Message: msg}
+			if e.Command != nil {
+				issue.First = fmt.Sprint(e.Command.Indices)
+			}
+			byMessage[key] = issue
+		}
+		issue.Count++
+	}
+	for _, issue := range byMessage {
+		r.Issues = append(r.Issues, *issue)
+	}
+	sort.Slice(r.Issues, func(i, j int) bool {
+		a, b := r.Issues[i], r.Issues[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Message < b.Message
+	})
+	if verb.Issues >= 0 && len(r.Issues) > verb.Issues {
+		r.Issues = r.Issues[:verb.Issues]
+	}
+	return nil
+}
+
+// commands adds the first MaxCommands commands of the capture to r.
+func (verb *htmlReportVerb) commands(ctx context.Context, client client.Client, capture *path.Capture, r *htmlReport) error {
+	const window = 1000
+	for offset := uint64(0); ; offset += window {
+		w, err := client.GetCommandListWindow(ctx, capture, offset, window, nil, nil)
+		if err != nil {
+			return err
+		}
+		for _, e := range w.Entries {
+			if verb.MaxCommands > 0 && len(r.Commands) >= verb.MaxCommands {
+				r.Truncated = true
+				return nil
+			}
+			r.Commands = append(r.Commands, htmlCommand{
+				Index:  fmt.Sprint(e.Command.Indices),
+				Name:   e.Name,
+				Params: strings.Join(e.Parameters, ", "),
+			})
+		}
+		if offset+window >= w.Total {
+			return nil
+		}
+	}
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} - GAPID report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 2px 8px; text-align: left; vertical-align: top; }
+.frames { display: flex; flex-wrap: wrap; }
+.frame { margin: 4px; font-size: small; }
+.frame img { display: block; border: 1px solid #ccc; }
+#commands td { font-family: monospace; }
+.error { color: #c00; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<table>
+<tr><th>Device</th><td>{{.Device}}</td></tr>
+<tr><th>ABI</th><td>{{.ABI}}</td></tr>
+<tr><th>Commands</th><td>{{.NumCommands}}</td></tr>
+<tr><th>Frames</th><td>{{.NumFrames}}</td></tr>
+<tr><th>Issues</th><td>{{.NumIssues}}</td></tr>
+</table>
+{{range .Errors}}<p class="error">{{.}}</p>
+{{end}}
+<h2>Frames</h2>
+<div class="frames">
+{{range .Frames}}<div class="frame">{{if .Thumbnail}}<img src="{{.Thumbnail}}">{{end}}Frame {{.Index}}, command {{.Command}}, {{.DrawCalls}} draw calls</div>
+{{end}}</div>
+<h2>Issues</h2>
+<table>
+<tr><th>Severity</th><th>Count</th><th>First command</th><th>Message</th></tr>
+{{range .Issues}}<tr><td>{{.Severity}}</td><td>{{.Count}}</td><td>{{.First}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+<h2>Commands</h2>
+{{if .Truncated}}<p>Only the first {{len .Commands}} commands are listed.</p>{{end}}
+<p><input id="search" type="search" placeholder="Search commands" size="60"></p>
+<table id="commands"></table>
+<script>
+var commands = {{.Commands}};
+var table = document.getElementById("commands");
+var search = document.getElementById("search");
+function show() {
+  var text = search.value.toLowerCase();
+  var rows = [];
+  for (var i = 0; i < commands.length && rows.length < 1000; i++) {
+    var c = commands[i];
+    if (text === "" || (c.n + "(" + c.p + ")").toLowerCase().indexOf(text) >= 0) {
+      var row = document.createElement("tr");
+      [c.i, c.n + "(" + c.p + ")"].forEach(function(s) {
+        var cell = document.createElement("td");
+        cell.textContent = s;
+        row.appendChild(cell);
+      });
+      rows.push(row);
+    }
+  }
+  table.replaceChildren.apply(table, rows);
+}
+search.addEventListener("input", show);
+show();
+</script>
+</body>
+</html>
+`))
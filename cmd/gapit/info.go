@@ -0,0 +1,94 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+)
+
+type infoVerb struct{ InfoFlags }
+
+func init() {
+	verb := &infoVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "info",
+		ShortHelp: "Prints a summary of a .gfxtrace file without loading it",
+		Action:    verb,
+	})
+}
+
+func (verb *infoVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	capturePath, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return log.Err(ctx, err, "Could not find capture file")
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, GapirFlags{})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	info, err := client.GetCaptureMetadata(ctx, capturePath)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to read the capture metadata")
+	}
+
+	if verb.Json {
+		jsonBytes, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return log.Err(ctx, err, "Failed to marshal capture metadata to JSON")
+		}
+		fmt.Fprintln(os.Stdout, string(jsonBytes))
+		return nil
+	}
+
+	fmt.Fprintf(os.Stdout, "Name:      %s\n", info.Name)
+	if d := info.Device; d != nil {
+		fmt.Fprintf(os.Stdout, "Device:    %s\n", d.Name)
+	}
+	if abi := info.ABI; abi != nil {
+		fmt.Fprintf(os.Stdout, "ABI:       %s\n", abi.Name)
+	}
+	if !info.HasSummary {
+		fmt.Fprintln(os.Stdout, "The capture file does not contain a summary. Load the capture for more information.")
+		return nil
+	}
+	fmt.Fprintf(os.Stdout, "APIs:      %s\n", strings.Join(info.APIs, ", "))
+	fmt.Fprintf(os.Stdout, "Commands:  %d\n", info.NumCommands)
+	fmt.Fprintf(os.Stdout, "Frames:    %d\n", info.NumFrames)
+	if info.Duration > 0 {
+		fmt.Fprintf(os.Stdout, "Duration:  %v\n", time.Duration(info.Duration))
+	}
+	if t := info.Thumbnail; t != nil {
+		fmt.Fprintf(os.Stdout, "Thumbnail: %dx%d\n", t.Width, t.Height)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/app/flags"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/client"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type inspectVerb struct{ InspectFlags }
+
+func init() {
+	verb := &inspectVerb{
+		InspectFlags{
+			At:    flags.U64Slice{},
+			Depth: 1,
+		},
+	}
+
+	app.AddVerb(&app.Verb{
+		Name:      "inspect",
+		ShortHelp: "Prints a single value of the state at a point in a .gfxtrace file",
+		Action:    verb,
+	})
+}
+
+func (verb *inspectVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 2 {
+		app.Usage(ctx, "Expected a gfx trace file and a state path (e.g. 'Contexts[1].Bound'), got %d arguments", flags.NArg())
+		return nil
+	}
+
+	client, c, err := getGapisAndLoadCapture(ctx, verb.Gapis, verb.Gapir, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if len(verb.At) == 0 {
+		boxedCapture, err := client.Get(ctx, c.Path(), nil)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to load the capture")
+		}
+		verb.At = []uint64{uint64(boxedCapture.(*service.Capture).NumCommands) - 1}
+	}
+
+	boxedTree, err := client.Get(ctx, c.Command(uint64(verb.At[0]), verb.At[1:]...).StateAfter().Tree().Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the state tree")
+	}
+
+	node := boxedTree.(*service.StateTree).Root
+	for _, name := range splitStatePath(flags.Arg(1)) {
+		child, err := findStateTreeChild(ctx, client, node, name)
+		if err != nil {
+			return err
+		}
+		if child == nil {
+			return log.Errf(ctx, nil, "'%v' not found in state", flags.Arg(1))
+		}
+		node = child
+	}
+
+	return traverseStateTree(ctx, client, node, verb.Depth, nil, func(n *service.StateTreeNode, prefix string) error {
+		return printStateTreeNode(ctx, client, n, prefix)
+	}, "", true)
+}
+
+// splitStatePath splits the textual state path s into the names of the state
+// tree nodes it refers to. Both 'a.b.c' and 'a[b].c' forms are accepted.
+func splitStatePath(s string) []string {
+	s = strings.Replace(s, "[", ".", -1)
+	s = strings.Replace(s, "]", "", -1)
+	out := []string{}
+	for _, name := range strings.Split(s, ".") {
+		if name = strings.TrimSpace(name); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// findStateTreeChild returns the path to the child of the state tree node p
+// with the given name, or nil if there is no such child. The children of
+// array subgroups are also searched.
+func findStateTreeChild(ctx context.Context, c client.Client, p *path.StateTreeNode, name string) (*path.StateTreeNode, error) {
+	boxedNode, err := c.Get(ctx, p.Path(), nil)
+	if err != nil {
+		return nil, log.Errf(ctx, err, "Failed to load the node at: %v", p)
+	}
+	n := boxedNode.(*service.StateTreeNode)
+
+	subgroups := []*path.StateTreeNode{}
+	for i := uint64(0); i < n.NumChildren; i++ {
+		if task.Stopped(ctx) {
+			return nil, task.StopReason(ctx)
+		}
+		childPath := p.Index(i)
+		boxedChild, err := c.Get(ctx, childPath.Path(), nil)
+		if err != nil {
+			return nil, log.Errf(ctx, err, "Failed to load the node at: %v", childPath)
+		}
+		child := boxedChild.(*service.StateTreeNode)
+		if child.Name == name {
+			return childPath, nil
+		}
+		if strings.HasPrefix(child.Name, "[") {
+			subgroups = append(subgroups, childPath)
+		}
+	}
+	for _, s := range subgroups {
+		if found, err := findStateTreeChild(ctx, c, s, name); found != nil || err != nil {
+			return found, err
+		}
+	}
+	return nil, nil
+}
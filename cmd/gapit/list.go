@@ -0,0 +1,101 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+type listVerb struct{ ListFlags }
+
+func init() {
+	verb := &listVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "list",
+		ShortHelp: "Lists the gfx trace files of a directory tree matching the filters",
+		Action:    verb,
+	})
+}
+
+func (verb *listVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() > 1 {
+		app.Usage(ctx, "At most one directory expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, GapirFlags{})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	query := &service.CaptureQuery{
+		Text:      verb.Text,
+		Tags:      splitList(verb.Tags),
+		APIs:      splitList(verb.APIs),
+		Device:    verb.Device,
+		MinFrames: verb.MinFrames,
+		Limit:     verb.Limit,
+	}
+	if verb.Since > 0 {
+		query.ModifiedAfter = time.Now().Add(-verb.Since).UnixNano()
+	}
+
+	captures, err := client.SearchCaptures(ctx, flags.Arg(0), query, verb.Refresh)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to search the captures")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "Path\tModified\tDevice\tApplication\tAPIs\tFrames\tTags\tAnnotation")
+	for _, c := range captures {
+		if c.Error != "" {
+			fmt.Fprintf(w, "%v\t%v\t\t\t\t\t\terror: %v\n", c.Path, time.Unix(0, c.Modified).Format(time.RFC3339), c.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%d\t%v\t%v\n",
+			c.Path,
+			time.Unix(0, c.Modified).Format(time.RFC3339),
+			c.Device.GetName(),
+			c.Application,
+			strings.Join(c.APIs, ","),
+			c.NumFrames,
+			strings.Join(c.Tags, ","),
+			strings.Replace(c.Annotation, "\n", " ", -1))
+	}
+	return nil
+}
+
+// splitList returns the non-empty elements of the comma separated list s.
+func splitList(s string) []string {
+	out := []string{}
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -19,8 +19,11 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/gapid/core/app"
@@ -55,7 +58,7 @@ func (verb *profileVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	}
 	defer client.Close()
 
-	capturePath, err := client.LoadCapture(ctx, capture)
+	capturePath, err := client.LoadCapture(ctx, capture, nil)
 	if err != nil {
 		return log.Err(ctx, err, "Failed to load the capture file")
 	}
@@ -75,7 +78,9 @@ func (verb *profileVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		return err
 	}
 
-	if verb.Json {
+	if verb.Overlap {
+		printQueueOverlap(os.Stdout, res.QueueOverlap)
+	} else if verb.Json {
 		jsonBytes, err := json.MarshalIndent(res, "", "  ")
 		if err != nil {
 			return log.Err(ctx, err, "Couldn't marshal trace to JSON")
@@ -89,3 +94,34 @@ func (verb *profileVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	}
 	return nil
 }
+
+// queueOverlapBarWidth is the number of characters of the bars printed by
+// printQueueOverlap.
+const queueOverlapBarWidth = 40
+
+// printQueueOverlap prints the activity of the GPU queues of each frame, with
+// a bar showing the fraction of the frame each queue was busy.
+func printQueueOverlap(out io.Writer, o *service.QueueOverlap) {
+	w := tabwriter.NewWriter(out, 4, 4, 2, ' ', 0)
+	defer w.Flush()
+	ms := func(ns uint64) string { return fmt.Sprintf("%.3fms", float64(ns)/1e6) }
+	for _, f := range o.GetFrames() {
+		duration := f.End - f.Start
+		fmt.Fprintf(w, "Frame %d:\t%s\toverlap %s\tserialized %s\n",
+			f.Frame, ms(duration), ms(f.GraphicsComputeOverlap), ms(f.Serialized))
+		for _, q := range f.Queues {
+			busy := 0
+			if duration > 0 {
+				busy = int(q.Busy * queueOverlapBarWidth / duration)
+			}
+			kind := "graphics"
+			if q.Compute {
+				kind = "compute"
+			}
+			fmt.Fprintf(w, "  %s (%s)\t[%s%s]\tbusy %s\t%d gaps, longest %s\n",
+				q.Name, kind,
+				strings.Repeat("#", busy), strings.Repeat(".", queueOverlapBarWidth-busy),
+				ms(q.Busy), q.Gaps, ms(q.LongestGap))
+		}
+	}
+}
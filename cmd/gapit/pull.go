@@ -0,0 +1,78 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type pullVerb struct{ PullFlags }
+
+func init() {
+	verb := &pullVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "pull",
+		ShortHelp: "Downloads a capture from a remote server, given its capture ID on the server",
+		Action:    verb,
+	})
+}
+
+func (verb *pullVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one capture ID expected, got %d", flags.NArg())
+		return nil
+	}
+	if verb.Remote == "" {
+		app.Usage(ctx, "The remote server must be given with -remote")
+		return nil
+	}
+	captureID, err := id.Parse(flags.Arg(0))
+	if err != nil {
+		return log.Err(ctx, err, "Could not parse capture ID")
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, GapirFlags{})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	remote := &path.Capture{ID: path.NewID(captureID)}
+	capture, err := client.PullCapture(ctx, verb.Remote, verb.RemoteToken, remote)
+	if err != nil {
+		return log.Errf(ctx, err, "PullCapture(%v, %v)", verb.Remote, remote)
+	}
+
+	data, err := client.ExportCapture(ctx, capture)
+	if err != nil {
+		return log.Errf(ctx, err, "ExportCapture(%v)", capture)
+	}
+
+	output := verb.Out
+	if output == "" {
+		output = "pulled.gfxtrace"
+	}
+	if err := ioutil.WriteFile(output, data, 0666); err != nil {
+		return log.Errf(ctx, err, "Writing file: %v", output)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+)
+
+type pushVerb struct{ PushFlags }
+
+func init() {
+	verb := &pushVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "push",
+		ShortHelp: "Uploads a gfx trace file to a remote server, printing its capture ID on the server",
+		Action:    verb,
+	})
+}
+
+func (verb *pushVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+	if verb.Remote == "" {
+		app.Usage(ctx, "The remote server must be given with -remote")
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	remote, err := client.PushCapture(ctx, capture, verb.Remote, verb.RemoteToken)
+	if err != nil {
+		return log.Errf(ctx, err, "PushCapture(%v, %v)", capture, verb.Remote)
+	}
+	fmt.Println(remote.ID.ID())
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type redundancyVerb struct{ FrameRedundancyFlags }
+
+func init() {
+	verb := &redundancyVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "redundancy",
+		ShortHelp: "Prints the percentage of work each frame of a capture file repeats from the previous frame as CSV",
+		Action:    verb,
+	})
+}
+
+func (verb *redundancyVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	p := capture.FrameRedundancy()
+	p.Frames = &path.FrameRange{First: verb.Frames.Start, Count: verb.Frames.Count}
+	boxedVal, err := client.Get(ctx, p.Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to analyze the frame redundancy")
+	}
+
+	var out io.Writer = os.Stdout
+	if verb.Out != "" {
+		f, err := os.OpenFile(verb.Out, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to open CSV output file")
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := csv.NewWriter(out)
+	defer w.Flush()
+
+	percent := func(n, total uint64) string {
+		if total == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%.1f", float64(n)*100/float64(total))
+	}
+	header := []string{
+		"Frame",
+		"Commands", "Identical commands(%)",
+		"Bindings", "Identical bindings(%)",
+		"Command buffers", "Re-recorded command buffers(%)",
+	}
+	if err := w.Write(header); err != nil {
+		return log.Err(ctx, err, "Failed to write header")
+	}
+	for i, f := range boxedVal.(*service.FrameRedundancy).Frames {
+		record := []string{
+			fmt.Sprint(uint32(i) + verb.Frames.Start),
+			fmt.Sprint(f.Commands), percent(f.IdenticalCommands, f.Commands),
+			fmt.Sprint(f.Bindings), percent(f.IdenticalBindings, f.Bindings),
+			fmt.Sprint(f.RecordedCommandBuffers), percent(f.RerecordedCommandBuffers, f.RecordedCommandBuffers),
+		}
+		if err := w.Write(record); err != nil {
+			return log.Err(ctx, err, "Failed to write record")
+		}
+	}
+	return nil
+}
@@ -152,7 +152,7 @@ func (verb *replaceResourceVerb) Run(ctx context.Context, flags flag.FlagSet) er
 		if err != nil {
 			return log.Errf(ctx, err, "Could not handle capture file path '%s'", newCaptureFilepath)
 		}
-		err = client.SaveCapture(ctx, newCapture, newCaptureFilepath)
+		err = client.SaveCapture(ctx, newCapture, newCaptureFilepath, nil)
 		if err != nil {
 			return log.Errf(ctx, err, "Failed to write capture to: '%s'", newCaptureFilepath)
 		}
@@ -17,15 +17,19 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 
+	"github.com/golang/protobuf/jsonpb"
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
 	"github.com/google/gapid/gapis/stringtable"
 )
 
@@ -98,7 +102,21 @@ func (verb *reportVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	}
 	commands := boxedCommands.(*service.Commands).List
 
-	boxedReport, err := client.Get(ctx, capturePath.Report(device, filter, verb.DisplayToSurface).Path(), nil)
+	reportPath := capturePath.Report(device, filter, verb.DisplayToSurface)
+	if verb.Suppressions != "" {
+		data, err := ioutil.ReadFile(verb.Suppressions)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to read the suppressions")
+		}
+		if err := json.Unmarshal(data, &reportPath.Suppressions); err != nil {
+			return log.Err(ctx, err, "Failed to parse the suppressions")
+		}
+	}
+	if reportPath.Config, err = verb.loadConfig(ctx); err != nil {
+		return err
+	}
+
+	boxedReport, err := client.Get(ctx, reportPath.Path(), nil)
 	if err != nil {
 		return log.Err(ctx, err, "Failed to acquire the capture's report")
 	}
@@ -128,6 +146,70 @@ func (verb *reportVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	} else {
 		fmt.Fprintf(reportWriter, "%d issues found\n", len(report.Items))
 	}
+	for i, count := range report.Suppressed {
+		if count > 0 {
+			rule, _ := json.Marshal(reportPath.Suppressions[i])
+			fmt.Fprintf(reportWriter, "%d issues suppressed by %s\n", count, rule)
+		}
+	}
+
+	if verb.WriteBaseline != "" {
+		data, err := json.MarshalIndent(baseline(report, commands), "", "  ")
+		if err != nil {
+			return log.Err(ctx, err, "Failed to encode the baseline")
+		}
+		if err := ioutil.WriteFile(verb.WriteBaseline, data, 0644); err != nil {
+			return log.Err(ctx, err, "Failed to write the baseline")
+		}
+	}
 
 	return nil
 }
+
+// baseline returns the rules suppressing the items of the report: one rule per
+// message and command name, rather than per command index, so that the
+// baseline still matches once commands are added or removed. Items without a
+// command are suppressed by a rule matching their message on any command.
+func baseline(report *service.Report, commands []*api.Command) []*path.ReportSuppression {
+	type key struct{ msg, name string }
+	rules := []*path.ReportSuppression{}
+	seen := map[key]bool{}
+	for _, e := range report.Items {
+		k := key{msg: report.Strings[e.Message.Identifier]}
+		if e.Command != nil {
+			k.name = commands[e.Command.Indices[0]].Name // TODO: Subcommands
+		}
+		if !seen[k] {
+			seen[k] = true
+			rules = append(rules, &path.ReportSuppression{Message: k.msg, CommandName: k.name})
+		}
+	}
+	return rules
+}
+
+// defaultReportConfig is the config file used if none is specified, letting a
+// project keep its analyzer budgets next to its sources.
+const defaultReportConfig = ".gapid-analysis.json"
+
+// loadConfig returns the report config of the Config flag, or of the default
+// config file if it exists, or nil if there is none.
+func (verb *reportVerb) loadConfig(ctx context.Context) (*path.ReportConfig, error) {
+	file := verb.Config
+	if file == "" {
+		if _, err := os.Stat(defaultReportConfig); err != nil {
+			return nil, nil
+		}
+		file = defaultReportConfig
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, log.Err(ctx, err, "Failed to read the report config")
+	}
+	defer f.Close()
+	config := &path.ReportConfig{}
+	if err := jsonpb.Unmarshal(f, config); err != nil {
+		return nil, log.Errf(ctx, err, "Failed to parse the report config %v", file)
+	}
+	log.I(ctx, "Using report config %v", file)
+	return config, nil
+}
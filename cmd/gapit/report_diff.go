@@ -0,0 +1,139 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/stringtable"
+)
+
+type reportDiffVerb struct{ ReportDiffFlags }
+
+func init() {
+	verb := &reportDiffVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "report-diff",
+		ShortHelp: "Compare the report issues of two captures, such as before and after an optimization",
+		Action:    verb,
+	})
+}
+
+func (verb *reportDiffVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 2 {
+		app.Usage(ctx, "Exactly two gfx trace files expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, before, err := getGapisAndLoadCapture(ctx, verb.Gapis, verb.Gapir, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var after *path.Capture
+	if verb.CaptureID {
+		var afterID id.ID
+		if afterID, err = id.Parse(flags.Arg(1)); err == nil {
+			after = path.NewCapture(afterID)
+		}
+	} else {
+		var afterPath string
+		var key []byte
+		if verb.KeyFile != "" {
+			key, err = readCaptureKey(verb.KeyFile)
+		}
+		if err == nil {
+			afterPath, err = filepath.Abs(flags.Arg(1))
+		}
+		if err == nil {
+			after, err = client.LoadCapture(ctx, afterPath, key)
+		}
+	}
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the second capture file")
+	}
+
+	stringTables, err := client.GetAvailableStringTables(ctx)
+	if err != nil {
+		return log.Err(ctx, err, "Failed get list of string tables")
+	}
+
+	var stringTable *stringtable.StringTable
+	if len(stringTables) > 0 {
+		// TODO: Let the user pick the string table.
+		stringTable, err = client.GetStringTable(ctx, stringTables[0])
+		if err != nil {
+			return log.Err(ctx, err, "Failed get string table")
+		}
+	}
+
+	device, err := getDevice(ctx, client, before, verb.Gapir)
+	if err != nil {
+		return err
+	}
+
+	diff, err := client.DiffReports(ctx,
+		before.Report(device, nil, verb.DisplayToSurface),
+		after.Report(device, nil, verb.DisplayToSurface), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to diff the reports")
+	}
+
+	var w io.Writer = os.Stdout
+	if verb.Out != "" {
+		f, err := os.OpenFile(verb.Out, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to open report diff output file")
+		}
+		defer f.Close()
+		w = f
+	}
+
+	for _, item := range diff.Items {
+		var status string
+		switch item.Status {
+		case service.ReportDiffStatus_NewIssue:
+			status = "NEW"
+		case service.ReportDiffStatus_FixedIssue:
+			status = "FIXED"
+		default:
+			if !verb.Unchanged {
+				continue
+			}
+			status = "UNCHANGED"
+		}
+		where := ""
+		if cmd := item.After; cmd != nil {
+			where = fmt.Sprintf("%v ", cmd.Indices)
+		} else if cmd := item.Before; cmd != nil {
+			where = fmt.Sprintf("%v ", cmd.Indices)
+		}
+		fmt.Fprintf(w, "[%s] [%s] %s%s (%d -> %d)\n", status, item.Severity.String(),
+			where, item.Message.Text(stringTable), item.BeforeCount, item.AfterCount)
+	}
+	fmt.Fprintf(w, "%d new, %d fixed, %d unchanged issues\n", diff.New, diff.Fixed, diff.Unchanged)
+	return nil
+}
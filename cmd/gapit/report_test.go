@@ -0,0 +1,49 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/stringtable"
+)
+
+func TestBaseline(t *testing.T) {
+	ctx := log.Testing(t)
+	commands := []*api.Command{{Name: "glClear"}, {Name: "glDrawArrays"}, {Name: "glDrawArrays"}}
+	b := service.NewReportBuilder()
+	add := func(msg string, cmd *path.Command) {
+		b.Add(ctx, service.WrapReportItem(&service.ReportItem{
+			Severity: service.Severity_ErrorLevel,
+			Command:  cmd,
+		}, &stringtable.Msg{Identifier: msg}))
+	}
+	// The items are not in command order.
+	add("ERR_A", &path.Command{Indices: []uint64{2}})
+	add("ERR_A", &path.Command{Indices: []uint64{0}})
+	add("ERR_A", &path.Command{Indices: []uint64{1}})
+	add("ERR_B", nil)
+
+	assert.For(ctx, "baseline").That(baseline(b.Build(), commands)).DeepEquals([]*path.ReportSuppression{
+		{Message: "ERR_A", CommandName: "glDrawArrays"},
+		{Message: "ERR_A", CommandName: "glClear"},
+		{Message: "ERR_B"},
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+type sceneVerb struct{ SceneFlags }
+
+func init() {
+	verb := &sceneVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "scene",
+		ShortHelp: "Experimental: saves the geometry drawn by a frame as a glTF scene",
+		Action:    verb,
+	})
+}
+
+func (verb *sceneVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+	if verb.Frame < 0 {
+		app.Usage(ctx, "The frame index must not be negative")
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	boxedVal, err := client.Get(ctx, capture.Scene(uint32(verb.Frame)).Path(), nil)
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to reconstruct frame %v", verb.Frame)
+	}
+	scene := boxedVal.(*api.Scene)
+	log.I(ctx, "Reconstructed %v draw calls, skipped %v", scene.Draws, scene.SkippedDraws)
+
+	filePath := verb.Out
+	if filePath == "" {
+		filePath = "scene.gltf"
+	}
+	if err := ioutil.WriteFile(filePath, scene.Gltf, 0666); err != nil {
+		return log.Errf(ctx, err, "Writing file (%v)", filePath)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+type selectionVerb struct{ SelectionFlags }
+
+func init() {
+	verb := &selectionVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "selection",
+		ShortHelp: "Attaches to an existing gapis, and prints the selection shared by its clients as it changes",
+		Action:    verb,
+	})
+}
+
+func (verb *selectionVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	client, err := getGapis(ctx, verb.Gapis, GapirFlags{})
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	return client.FollowSelection(ctx, func(s *service.Selection) error {
+		fmt.Fprintf(os.Stdout, "%v: command %v", s.Source, s.Command.GetIndices())
+		if s.State != nil {
+			fmt.Fprintf(os.Stdout, ", state %v", s.State.Node())
+		}
+		fmt.Fprintln(os.Stdout)
+		return nil
+	})
+}
@@ -0,0 +1,130 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type seriesVerb struct{ SeriesFlags }
+
+func init() {
+	verb := &seriesVerb{SeriesFlags{To: -1}}
+	app.AddVerb(&app.Verb{
+		Name:      "series",
+		ShortHelp: "Exports a numeric state value or command parameter across commands as CSV",
+		Action:    verb,
+	})
+}
+
+func (verb *seriesVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if verb.Param == "" && flags.NArg() != 2 {
+		app.Usage(ctx, "Expected a gfx trace file and a state path (e.g. 'Contexts[1].Bound'), got %d arguments", flags.NArg())
+		return nil
+	}
+	if verb.Param != "" && flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected with --param, got %d", flags.NArg())
+		return nil
+	}
+
+	client, c, err := getGapisAndLoadCapture(ctx, verb.Gapis, verb.Gapir, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	to := uint64(verb.To)
+	if verb.To < 0 {
+		boxedCapture, err := client.Get(ctx, c.Path(), nil)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to load the capture")
+		}
+		to = uint64(boxedCapture.(*service.Capture).NumCommands) - 1
+	}
+	cmds := c.CommandRange(verb.From, to)
+
+	var p *path.ValueSeries
+	if verb.Param != "" {
+		i := strings.LastIndex(verb.Param, ".")
+		if i <= 0 || i == len(verb.Param)-1 {
+			app.Usage(ctx, "Expected --param in the form 'cmdName.Param', got '%v'", verb.Param)
+			return nil
+		}
+		p = cmds.ParameterSeries(verb.Param[:i], verb.Param[i+1:])
+	} else {
+		// The state path is looked up in the state at the end of the range, as
+		// the value may not exist at the start of it.
+		boxedTree, err := client.Get(ctx, c.Command(to).StateAfter().Tree().Path(), nil)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to load the state tree")
+		}
+		node := boxedTree.(*service.StateTree).Root
+		for _, name := range splitStatePath(flags.Arg(1)) {
+			child, err := findStateTreeChild(ctx, client, node, name)
+			if err != nil {
+				return err
+			}
+			if child == nil {
+				return log.Errf(ctx, nil, "'%v' not found in state", flags.Arg(1))
+			}
+			node = child
+		}
+		boxedNode, err := client.Get(ctx, node.Path(), nil)
+		if err != nil {
+			return log.Errf(ctx, err, "Failed to load the node at: %v", node)
+		}
+		p = cmds.StateSeries(boxedNode.(*service.StateTreeNode).ValuePath.Node())
+	}
+
+	boxedSeries, err := client.Get(ctx, p.Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to get the value series")
+	}
+
+	var out io.Writer = os.Stdout
+	if verb.Out != "" {
+		f, err := os.OpenFile(verb.Out, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return log.Err(ctx, err, "Failed to open CSV output file")
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := csv.NewWriter(out)
+	defer w.Flush()
+
+	if err := w.Write([]string{"Command", "Value"}); err != nil {
+		return log.Err(ctx, err, "Failed to write header")
+	}
+	for _, s := range boxedSeries.(*service.ValueSeries).Samples {
+		cmd := strings.Trim(strings.Join(strings.Fields(fmt.Sprint(s.Command.Indices)), "."), "[]")
+		if err := w.Write([]string{cmd, fmt.Sprint(s.Value)}); err != nil {
+			return log.Err(ctx, err, "Failed to write record")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+type shaderClustersVerb struct{ ShaderClustersFlags }
+
+func init() {
+	verb := &shaderClustersVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "shaderclusters",
+		ShortHelp: "Prints the groups of near-duplicate shaders of a capture file",
+		Action:    verb,
+	})
+}
+
+func (verb *shaderClustersVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	boxedVal, err := client.Get(ctx, capture.ShaderClusters(float32(verb.MinSimilarity)).Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the shader clusters")
+	}
+	clusters := boxedVal.(*service.ShaderClusters)
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	defer w.Flush()
+	for i, c := range clusters.Clusters {
+		fmt.Fprintf(w, "Cluster %d:\t%v\t%d shaders\n", i, c.Type, len(c.Shaders))
+		for _, s := range c.Shaders {
+			fmt.Fprintf(w, "\t%v\t%.1f%% similar\n", s.Handle, s.Similarity*100)
+		}
+	}
+	return nil
+}
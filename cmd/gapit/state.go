@@ -75,22 +75,28 @@ func (verb *stateVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	tree := boxedTree.(*service.StateTree)
 
 	return traverseStateTree(ctx, client, tree.Root, verb.Depth, verb.Filter, func(n *service.StateTreeNode, prefix string) error {
-		name := n.Name + ":"
-		if n.Preview != nil {
-			v := n.Preview.Get()
-			if n.Constants != nil {
-				constants, err := getConstantSet(ctx, client, n.Constants)
-				if err != nil {
-					return log.Err(ctx, err, "Couldn't fetch constant set")
-				}
-				v = constants.Sprint(v)
+		return printStateTreeNode(ctx, client, n, prefix)
+	}, "", true)
+}
+
+// printStateTreeNode prints the name and preview of the state tree node n,
+// decoding the preview with the node's constant set, if it has one.
+func printStateTreeNode(ctx context.Context, c client.Client, n *service.StateTreeNode, prefix string) error {
+	name := n.Name + ":"
+	if n.Preview != nil {
+		v := n.Preview.Get()
+		if n.Constants != nil {
+			constants, err := getConstantSet(ctx, c, n.Constants)
+			if err != nil {
+				return log.Err(ctx, err, "Couldn't fetch constant set")
 			}
-			fmt.Fprintln(os.Stdout, prefix, name, v)
-		} else {
-			fmt.Fprintln(os.Stdout, prefix, name)
+			v = constants.Sprint(v)
 		}
-		return nil
-	}, "", true)
+		fmt.Fprintln(os.Stdout, prefix, name, v)
+	} else {
+		fmt.Fprintln(os.Stdout, prefix, name)
+	}
+	return nil
 }
 
 func traverseStateTree(
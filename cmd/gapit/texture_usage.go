@@ -0,0 +1,80 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type textureUsageVerb struct{ TextureUsageFlags }
+
+func init() {
+	verb := &textureUsageVerb{TextureUsageFlags{MaxTextures: 16}}
+	app.AddVerb(&app.Verb{
+		Name:      "textureusage",
+		ShortHelp: "Prints the mip levels of the textures of a frame that affect the frame, to find oversized textures",
+		Action:    verb,
+	})
+}
+
+func (verb *textureUsageVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+	if verb.Frame < 0 || verb.MaxTextures < 0 {
+		app.Usage(ctx, "The frame index and maximum number of textures must not be negative")
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, verb.Gapir, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	device, err := getDevice(ctx, client, capture, verb.Gapir)
+	if err != nil {
+		return err
+	}
+
+	p := capture.TextureUsage(uint32(verb.Frame), uint32(verb.MaxTextures))
+	boxedVal, err := client.Get(ctx, p.Path(), &path.ResolveConfig{ReplayDevice: device})
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to measure the textures of frame %v", verb.Frame)
+	}
+	usage := boxedVal.(*service.TextureUsage)
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "Texture\tFormat\tSize\tLevels\tFinest used\tUnused bytes\tTotal bytes")
+	for _, t := range usage.Textures {
+		fmt.Fprintf(w, "%#x\t%v\t%dx%d\t%d\t%d\t%d\t%d\n",
+			t.Handle, t.Format, t.Width, t.Height, t.Levels, t.FinestUsedLevel, t.UnusedSize, t.Size)
+	}
+	if usage.Skipped > 0 {
+		fmt.Fprintf(w, "%d smaller textures not measured\n", usage.Skipped)
+	}
+	return nil
+}
@@ -247,6 +247,10 @@ func (verb *traceVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		ServerLocalSavePath:          out,
 		PipeName:                     verb.PipeName,
 		DisableCoherentMemoryTracker: verb.Disable.CoherentMemoryTracker,
+		LowResObservations:           verb.Observe.LowRes,
+		RecordInputEvents:            verb.Record.InputEvents,
+		SampleIoCounters:             verb.Record.IO,
+		RecordDeviceMetrics:          verb.Record.Device.Metrics,
 	}
 	target(options)
 
@@ -0,0 +1,79 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type uniformsVerb struct{ UniformsFlags }
+
+func init() {
+	verb := &uniformsVerb{}
+	app.AddVerb(&app.Verb{
+		Name:      "uniforms",
+		ShortHelp: "Prints the uniforms of a capture file that are never read or never change",
+		Action:    verb,
+	})
+}
+
+func (verb *uniformsVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	p := capture.UniformUsage()
+	p.Frames = &path.FrameRange{First: verb.Frames.Start, Count: verb.Frames.Count}
+	boxedVal, err := client.Get(ctx, p.Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the uniform usage")
+	}
+	usage := boxedVal.(*api.UniformUsage)
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "Program\tUniform\tSets\tDraws\tDistinct values\tSuggestion")
+	for _, u := range usage.Uniforms {
+		suggestion := ""
+		switch u.Suggestion {
+		case api.UniformSuggestion_RemoveUniform:
+			suggestion = "never read: remove"
+		case api.UniformSuggestion_MakeConstant:
+			suggestion = "never changes: make constant"
+		default:
+			if !verb.All {
+				continue
+			}
+		}
+		fmt.Fprintf(w, "%v\t%v\t%d\t%d\t%d\t%v\n", u.Program, u.Name, u.Sets, u.Draws, u.DistinctValues, suggestion)
+	}
+	return nil
+}
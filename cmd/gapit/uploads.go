@@ -0,0 +1,73 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+type uploadsVerb struct{ UploadsFlags }
+
+func init() {
+	verb := &uploadsVerb{UploadsFlags{MinSize: 1024}}
+	app.AddVerb(&app.Verb{
+		Name:      "uploads",
+		ShortHelp: "Prints the data uploaded per frame of a capture file, and the uploads of duplicate content",
+		Action:    verb,
+	})
+}
+
+func (verb *uploadsVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	client, capture, err := getGapisAndLoadCapture(ctx, verb.Gapis, GapirFlags{}, flags.Arg(0), verb.CaptureFileFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	boxedVal, err := client.Get(ctx, capture.Uploads(verb.MinSize).Path(), nil)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the uploads")
+	}
+	report := boxedVal.(*service.UploadReport)
+
+	fmt.Fprintf(os.Stdout, "Total uploaded:  %d bytes\n", report.TotalBytes)
+	fmt.Fprintf(os.Stdout, "Total redundant: %d bytes\n", report.RedundantBytes)
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 4, 2, ' ', 0)
+	defer w.Flush()
+	for i, f := range report.Frames {
+		if f.RedundantBytes == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nFrame %d:\t%d bytes uploaded\t%d bytes redundant\n", i, f.TotalBytes, f.RedundantBytes)
+		for _, u := range f.Redundant {
+			fmt.Fprintf(w, "\t%v\t%d bytes\tfirst uploaded by %v\n", u.Command.Indices, u.Size, u.Original.Indices)
+		}
+	}
+	return nil
+}
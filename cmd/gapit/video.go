@@ -28,6 +28,7 @@ import (
 	fp "path/filepath"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/app/crash"
@@ -125,12 +126,29 @@ func (verb *videoVerb) regularVideoSource(
 	rendered := make([]*image.NRGBA, frameCount)
 	errors := make([]error, frameCount)
 
-	var errorCount uint32
+	// With a timeout, each frame may only take the time left until the
+	// deadline. The frames not rendered by then are left out of the video.
+	deadline := time.Now().Add(verb.Timeout)
+	timedOut := make([]bool, frameCount)
+	var errorCount, timeoutCount uint32
 	for i, e := range eofEvents {
 		i, e := i, e
 		executor(ctx, func(ctx context.Context) error {
-			if frame, err := getFrame(ctx, verb.Max.Width, verb.Max.Height, e.Command, device, client, verb.NoOpt); err == nil {
+			var hints *service.UsageHints
+			if verb.Timeout > 0 {
+				remaining := time.Until(deadline)
+				if remaining < time.Millisecond {
+					timedOut[i] = true
+					atomic.AddUint32(&timeoutCount, 1)
+					return nil
+				}
+				hints = &service.UsageHints{TimeoutMs: uint32(remaining / time.Millisecond)}
+			}
+			if frame, err := getFrame(ctx, verb.Max.Width, verb.Max.Height, e.Command, device, client, verb.NoOpt, hints); err == nil {
 				rendered[i] = flipImg(frame)
+			} else if verb.Timeout > 0 && time.Now().After(deadline) {
+				timedOut[i] = true
+				atomic.AddUint32(&timeoutCount, 1)
 			} else {
 				errors[i] = err
 				atomic.AddUint32(&errorCount, 1)
@@ -143,6 +161,9 @@ func (verb *videoVerb) regularVideoSource(
 	if errorCount > 0 {
 		log.W(ctx, "%d/%d frames errored", errorCount, len(eofEvents))
 	}
+	if timeoutCount > 0 {
+		log.W(ctx, "Timed out after %v, %d/%d frames not rendered", verb.Timeout, timeoutCount, len(eofEvents))
+	}
 
 	// Get the max width and height
 	width, height := 0, 0
@@ -165,6 +186,9 @@ func (verb *videoVerb) regularVideoSource(
 
 	return func(frames chan<- image.Image) error {
 		for i, frame := range rendered {
+			if timedOut[i] {
+				continue
+			}
 			if err := errors[i]; err != nil {
 				log.E(ctx, "Error getting frame at %v: %v", eofEvents[i].Command, err)
 				continue
@@ -336,13 +360,13 @@ func (verb *videoVerb) encodeVideo(ctx context.Context, filepath string, vidFun
 	return nil
 }
 
-func getFrame(ctx context.Context, maxWidth, maxHeight int, cmd *path.Command, device *path.Device, client service.Service, noOpt bool) (*image.NRGBA, error) {
+func getFrame(ctx context.Context, maxWidth, maxHeight int, cmd *path.Command, device *path.Device, client service.Service, noOpt bool, hints *service.UsageHints) (*image.NRGBA, error) {
 	ctx = log.V{"cmd": cmd.Indices}.Bind(ctx)
 	settings := &service.RenderSettings{MaxWidth: uint32(maxWidth), MaxHeight: uint32(maxHeight)}
 	iip, err := client.GetFramebufferAttachment(ctx, &service.ReplaySettings{
 		Device:                    device,
 		DisableReplayOptimization: noOpt,
-	}, cmd, api.FramebufferAttachment_Color0, settings, nil)
+	}, cmd, api.FramebufferAttachment_Color0, settings, hints)
 	if err != nil {
 		return nil, log.Errf(ctx, err, "GetFramebufferAttachment failed at %v", cmd)
 	}
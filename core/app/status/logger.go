@@ -76,5 +76,5 @@ func (l statusLogger) OnMemorySnapshot(ctx context.Context, stats runtime.MemSta
 }
 
 func (l statusLogger) OnReplayStatusUpdate(ctx context.Context, r *Replay, label uint64, totalInstrs, finishedInstrs uint32) {
-	log.I(ctx, "Replay Status: started: %v finished: %v label: %v, total instructions: %v, finished instructions: %v.", r.Started(), r.Finished(), label, totalInstrs, finishedInstrs)
+	log.I(ctx, "Replay Status: started: %v finished: %v reconnecting: %v (attempt %v) label: %v, total instructions: %v, finished instructions: %v.", r.Started(), r.Finished(), r.Reconnecting(), r.Attempt(), label, totalInstrs, finishedInstrs)
 }
@@ -17,17 +17,29 @@ package status
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/google/gapid/core/data/id"
 )
 
 // Replay contains status information about a replay.
 type Replay struct {
-	ID       uint32
-	Device   id.ID
-	started  bool
-	finished bool
-	mutex    sync.RWMutex
+	ID           uint32
+	Device       id.ID
+	started      bool
+	finished     bool
+	reconnecting bool
+	attempt      uint32
+	mutex        sync.RWMutex
+}
+
+// numActive is the number of replays that have started but not finished.
+var numActive int32
+
+// ActiveReplays returns the number of replays that have started but not yet
+// finished.
+func ActiveReplays() int {
+	return int(atomic.LoadInt32(&numActive))
 }
 
 // ReplayQueued notifies listeners that a new replay has been queued.
@@ -56,6 +68,22 @@ func (r *Replay) Finished() bool {
 	return r.finished
 }
 
+// Reconnecting returns wether a replay is waiting to be attempted again, after
+// the connection to the replay device was lost.
+func (r *Replay) Reconnecting() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.reconnecting
+}
+
+// Attempt returns the number of times the replay was attempted again after the
+// connection to the replay device was lost.
+func (r *Replay) Attempt() uint32 {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.attempt
+}
+
 // Start notifies listeners that a replay has started.
 func (r *Replay) Start(ctx context.Context) {
 	r.start()
@@ -67,6 +95,13 @@ func (r *Replay) Progress(ctx context.Context, label uint64, totalInstrs, finish
 	onReplayStatusUpdate(ctx, r, label, totalInstrs, finishedInstrs)
 }
 
+// Reconnect notifies listeners that the connection to the replay device was
+// lost, and that the replay will be attempted again.
+func (r *Replay) Reconnect(ctx context.Context) {
+	r.reconnect()
+	onReplayStatusUpdate(ctx, r, 0, 0, 0)
+}
+
 // Finish notifies listeners that a replay has finished.
 func (r *Replay) Finish(ctx context.Context) {
 	r.finish()
@@ -76,11 +111,24 @@ func (r *Replay) Finish(ctx context.Context) {
 func (r *Replay) start() {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	r.started, r.finished = true, false
+	if !r.started || r.finished {
+		atomic.AddInt32(&numActive, 1)
+	}
+	r.started, r.finished, r.reconnecting = true, false, false
+}
+
+func (r *Replay) reconnect() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.reconnecting = true
+	r.attempt++
 }
 
 func (r *Replay) finish() {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	r.started, r.finished = true, true
+	if r.started && !r.finished {
+		atomic.AddInt32(&numActive, -1)
+	}
+	r.started, r.finished, r.reconnecting = true, true, false
 }
@@ -0,0 +1,61 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import "sync"
+
+// Ring is a Handler that retains the most recent messages passed to Handle,
+// discarding the oldest message once it is full.
+// Ring is safe to use from multiple threads.
+type Ring struct {
+	m    sync.Mutex
+	msgs []*Message
+	next int
+	full bool
+}
+
+// NewRing returns a new Ring that retains up to size messages.
+func NewRing(size int) *Ring {
+	return &Ring{msgs: make([]*Message, size)}
+}
+
+// Handle adds m to the ring, replacing the oldest message if the ring is full.
+func (r *Ring) Handle(m *Message) {
+	if m == nil || len(r.msgs) == 0 {
+		return
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.msgs[r.next] = m
+	r.next++
+	if r.next == len(r.msgs) {
+		r.next, r.full = 0, true
+	}
+}
+
+// Close does nothing. The retained messages remain available after Close.
+func (r *Ring) Close() {}
+
+// Messages returns the retained messages, oldest first.
+func (r *Ring) Messages() []*Message {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if !r.full {
+		return append([]*Message{}, r.msgs[:r.next]...)
+	}
+	out := make([]*Message, 0, len(r.msgs))
+	out = append(out, r.msgs[r.next:]...)
+	return append(out, r.msgs[:r.next]...)
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+)
+
+func TestRing(t *testing.T) {
+	assert := assert.To(t)
+	texts := func(l []*log.Message) []string {
+		out := make([]string, len(l))
+		for i, m := range l {
+			out[i] = m.Text
+		}
+		return out
+	}
+
+	r := log.NewRing(3)
+	assert.For("empty").ThatSlice(r.Messages()).IsEmpty()
+
+	r.Handle(&log.Message{Text: "a"})
+	r.Handle(&log.Message{Text: "b"})
+	assert.For("partial").ThatSlice(texts(r.Messages())).Equals([]string{"a", "b"})
+
+	r.Handle(&log.Message{Text: "c"})
+	assert.For("full").ThatSlice(texts(r.Messages())).Equals([]string{"a", "b", "c"})
+
+	r.Handle(&log.Message{Text: "d"})
+	r.Handle(&log.Message{Text: "e"})
+	assert.For("wrapped").ThatSlice(texts(r.Messages())).Equals([]string{"c", "d", "e"})
+
+	r.Close()
+	r.Handle(nil)
+	assert.For("closed").ThatSlice(texts(r.Messages())).Equals([]string{"c", "d", "e"})
+}
@@ -37,6 +37,7 @@ debug_device                unknown
 debug_device2               unknown
 dumpsys_device              offline
 error_device                device
+gapid_logcat_device         device
 install_device              unauthorized
 invalid_device              unknown
 logcat_device               unauthorized
@@ -171,7 +172,7 @@ u0_a69    22841 5062  1255788 88672 SyS_epoll_ 0000000000 S com.example.meh`),
 		stub.Match(adbPath.System()+` -s error_device shell getenforce`, &stub.Response{WaitErr: fmt.Errorf(`not a normal response`)}),
 
 		// Logcat command responses
-		stub.RespondTo(adbPath.System()+` -s logcat_device logcat -v long -T 0 GAPID:V *:W`, `
+		stub.RespondTo(adbPath.System()+` -s logcat_device logcat -v long -T 0 GAPID:V *:S`, `
 [ 03-29 15:16:29.514 24153:24153 V/AndroidRuntime ]
 >>>>>> START com.android.internal.os.RuntimeInit uid 0 <<<<<<
 
@@ -191,6 +192,20 @@ untagSocket(48) failed with errno -22
 
 [ 03-29 15:16:32.219 31608:31608 F/Finsky   ]
 [1] PackageVerificationReceiver.onReceive: Verification requested, id = 331
+`),
+
+		stub.RespondTo(adbPath.System()+` -s gapid_logcat_device logcat -v long -T 0 GAPID:V *:S`, `
+[ 03-29 15:16:29.514 24153:24153 V/GAPID    ]
+Starting replay
+
+[ 03-29 15:16:29.761 24153:24160 I/GAPID    ]
+Connected to gapis
+
+[ 03-29 15:16:32.205 24153:24160 W/GAPID    ]
+Unsupported extension
+
+[ 03-29 15:16:32.219 24153:24160 E/GAPID    ]
+Replay failed
 `),
 
 		// Common responses to all devices
@@ -91,3 +91,34 @@ func TestLogcat(t_ *testing.T) {
 	assert.For(ctx, "msg").That(<-msgs).Equals(android.LogcatMessage{})
 	<-done
 }
+
+func TestForwardLogcat(t_ *testing.T) {
+	ctx, _ := task.WithDeadline(log.Testing(t_), time.Now().Add(3*time.Second))
+	d := mustConnect(ctx, "gapid_logcat_device")
+	got := make(chan *log.Message, 32)
+	lctx := log.PutHandler(ctx, log.NewHandler(func(m *log.Message) { got <- m }, nil))
+	lctx = log.PutFilter(lctx, nil)
+	stop := android.ForwardLogcat(lctx, d, "gapir")
+	defer stop.Invoke(ctx)
+
+	expected := []struct {
+		text     string
+		severity log.Severity
+	}{
+		{"Starting replay", log.Verbose},
+		{"Connected to gapis", log.Info},
+		{"Unsupported extension", log.Warning},
+		{"Replay failed", log.Error},
+	}
+	for _, e := range expected {
+		select {
+		case m := <-got:
+			assert.For(ctx, "process").ThatString(m.Process).Equals("gapir")
+			assert.For(ctx, "tag").ThatString(m.Tag).Equals("GAPID")
+			assert.For(ctx, "text").ThatString(m.Text).Contains(e.text)
+			assert.For(ctx, "severity").That(m.Severity).Equals(e.severity)
+		case <-task.ShouldStop(ctx):
+			assert.For(ctx, "msg").Fatal("Timed out waiting for forwarded message")
+		}
+	}
+}
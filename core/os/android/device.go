@@ -104,10 +104,16 @@ type LogcatMessage struct {
 
 // Log writes the LogcatMessage to ctx with the corresponding message severity.
 func (m LogcatMessage) Log(ctx context.Context) {
+	m.LogAs(ctx, "logcat")
+}
+
+// LogAs writes the LogcatMessage to ctx with the corresponding message
+// severity, as raised by the named process.
+func (m LogcatMessage) LogAs(ctx context.Context, process string) {
 	// Override the timestamping function to replicate the logcat timestamp
 	ctx = log.PutClock(ctx, log.FixedClock(m.Timestamp))
 	ctx = log.PutTag(ctx, m.Tag)
-	ctx = log.PutProcess(ctx, "logcat")
+	ctx = log.PutProcess(ctx, process)
 	ctx = log.V{
 		"pid": m.ProcessID,
 		"tid": m.ThreadID,
@@ -0,0 +1,44 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"context"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+)
+
+// ForwardLogcat logs the logcat messages reported by d to ctx, as raised by
+// the named process, until the returned cleanup is invoked or ctx is stopped.
+// This makes the output of the device-side components visible to the host
+// log handlers.
+func ForwardLogcat(ctx context.Context, d Device, process string) app.Cleanup {
+	ctx, stop := task.WithCancel(ctx)
+	msgs := make(chan LogcatMessage, 64)
+	crash.Go(func() {
+		for m := range msgs {
+			m.LogAs(ctx, process)
+		}
+	})
+	crash.Go(func() {
+		if err := d.Logcat(ctx, msgs); err != nil && !task.Stopped(ctx) {
+			log.W(ctx, "Forwarding logcat of %v stopped: %v", process, err)
+		}
+	})
+	return func(context.Context) { stop() }
+}
@@ -0,0 +1,41 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/text"
+)
+
+func TestNaturalLess(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		unsorted []string
+		expected []string
+	}{
+		{[]string{"1", "10", "100", "2"}, []string{"1", "2", "10", "100"}},
+		{[]string{"a10", "a2", "b1", "a"}, []string{"a", "a2", "a10", "b1"}},
+		{[]string{"x10y2", "x10y10", "x9y9"}, []string{"x9y9", "x10y2", "x10y10"}},
+		{[]string{"007", "8", "06"}, []string{"06", "007", "8"}},
+	} {
+		got := append([]string{}, test.unsorted...)
+		sort.SliceStable(got, func(i, j int) bool { return text.NaturalLess(got[i], got[j]) })
+		assert.For(ctx, "sort(%v)", test.unsorted).ThatSlice(got).Equals(test.expected)
+	}
+}
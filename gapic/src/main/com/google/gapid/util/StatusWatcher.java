@@ -212,6 +212,7 @@ public class StatusWatcher {
     private int queued = 0;
     private int started = 0;
     private int executing = 0;
+    private int reconnecting = 0;
     private long doneInstr = 0;
     private long totalInstr = 0;
 
@@ -234,6 +235,10 @@ public class StatusWatcher {
         if (totalInstr > 0) {
           sb.append(" ").append((int)(((double)doneInstr / totalInstr) * 100)).append("%");
         }
+        sep = ", ";
+      }
+      if (reconnecting > 0) {
+        sb.append(sep).append(reconnecting).append(" Reconnecting");
       }
       return (sb.length() == 0) ? "Idle" : sb.toString();
     }
@@ -260,6 +265,9 @@ public class StatusWatcher {
             doneInstr += replay.doneInstr;
             totalInstr += replay.totalInstr;
             return true;
+          case REPLAY_RECONNECTING:
+            reconnecting++;
+            return true;
           default:
             return false;
         }
@@ -285,6 +293,9 @@ public class StatusWatcher {
             case REPLAY_EXECUTING:
               started--;
               return executing(replay, update);
+            case REPLAY_RECONNECTING:
+              started--;
+              return reconnecting(replay);
             case REPLAY_FINISHED:
               started--;
               return finished(replay);
@@ -295,12 +306,31 @@ public class StatusWatcher {
           switch (update.getStatus()) {
             case REPLAY_EXECUTING:
               return executing(replay, update);
+            case REPLAY_RECONNECTING:
+              executing--;
+              doneInstr -= replay.doneInstr;
+              totalInstr -= replay.totalInstr;
+              return reconnecting(replay);
             case REPLAY_FINISHED:
               executing--;
               return finished(replay);
             default:
               return false;
           }
+        case REPLAY_RECONNECTING:
+          switch (update.getStatus()) {
+            case REPLAY_STARTED:
+              reconnecting--;
+              return started(replay);
+            case REPLAY_EXECUTING:
+              reconnecting--;
+              return executing(replay, update);
+            case REPLAY_FINISHED:
+              reconnecting--;
+              return finished(replay);
+            default:
+              return false;
+          }
         default:
           return false;
       }
@@ -312,6 +342,12 @@ public class StatusWatcher {
       return true;
     }
 
+    private boolean reconnecting(Replay replay) {
+      reconnecting++;
+      replay.status = Service.ReplayStatus.REPLAY_RECONNECTING;
+      return true;
+    }
+
     private boolean executing(Replay replay, Service.ReplayUpdate update) {
       int done = update.getFinishedInstrs();
       int total = update.getTotalInstrs();
@@ -52,6 +52,10 @@ class ConnectionHeader {
   static const uint32_t FLAG_STORE_TIMESTAMPS = 0x00000080;
   // Disables the coherent memory tracker (useful for debug)
   static const uint32_t FLAG_DISABLE_COHERENT_MEMORY_TRACKER = 0x00000100;
+  // Downsamples the framebuffer observations to low resolution screenshots
+  static const uint32_t FLAG_LOW_RES_OBSERVATIONS = 0x00000200;
+  // Samples the I/O counters of the process at every frame
+  static const uint32_t FLAG_SAMPLE_IO_COUNTERS = 0x00000400;
 
   // read reads the ConnectionHeader from the provided stream, returning true
   // on success or false on error.
@@ -42,7 +42,9 @@ enum class MessageType : uint8_t {
   kData = 0x00u,
   kStartTrace = 0x01u,
   kEndTrace = 0x02u,
-  kError = 0x03u
+  kError = 0x03u,
+  kInputEvent = 0x04u,
+  kDeviceSample = 0x05u
 };
 
 // Write header into given buffer. Buffer size must be at least kHeaderSize
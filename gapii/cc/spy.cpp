@@ -38,7 +38,11 @@
 #include "gapis/capture/capture.pb.h"
 #include "gapis/memory/memory_pb/memory.pb.h"
 
+#include <time.h>
+
+#include <cstdio>
 #include <cstdlib>
+#include <cstring>
 #include <memory>
 #include <sstream>
 #include <thread>
@@ -92,11 +96,28 @@ const EGLint EGL_CONTEXT_OPENGL_PROFILE_MASK_KHR = 0x30FD;
 
 const uint32_t kMaxFramebufferObservationWidth = 3840;
 const uint32_t kMaxFramebufferObservationHeight = 2560;
+const uint32_t kMaxLowResFramebufferObservationWidth = 256;
+const uint32_t kMaxLowResFramebufferObservationHeight = 256;
 
 const int32_t kSuspendIndefinitely = -1;
 
 thread_local gapii::CallObserver* gContext = nullptr;
 
+// monotonicToTraceTime converts a CLOCK_MONOTONIC timestamp, as used by the
+// kernel for input events, to the clock used for the trace timestamps.
+uint64_t monotonicToTraceTime(uint64_t timestamp) {
+#if TARGET_OS == GAPID_OS_LINUX || TARGET_OS == GAPID_OS_ANDROID
+  struct timespec monotonic, boottime;
+  clock_gettime(CLOCK_MONOTONIC, &monotonic);
+  clock_gettime(CLOCK_BOOTTIME, &boottime);
+  int64_t offset = (boottime.tv_sec - monotonic.tv_sec) * 1000000000LL +
+                   (boottime.tv_nsec - monotonic.tv_nsec);
+  return timestamp + offset;
+#else
+  return timestamp;
+#endif
+}
+
 }  // anonymous namespace
 
 namespace gapii {
@@ -127,6 +148,8 @@ Spy::Spy()
       mNumDrawsPerFrame(0),
       mObserveFrameFrequency(0),
       mObserveDrawFrequency(0),
+      mLowResObservations(false),
+      mSampleIOCounters(false),
       mDisablePrecompiledShaders(false),
       mRecordGLErrorState(false),
       mNestedFrameStart(0),
@@ -171,6 +194,10 @@ Spy::Spy()
 
   mObserveFrameFrequency = header.mObserveFrameFrequency;
   mObserveDrawFrequency = header.mObserveDrawFrequency;
+  mLowResObservations =
+      (header.mFlags & ConnectionHeader::FLAG_LOW_RES_OBSERVATIONS) != 0;
+  mSampleIOCounters =
+      (header.mFlags & ConnectionHeader::FLAG_SAMPLE_IO_COUNTERS) != 0;
   mDisablePrecompiledShaders =
       (header.mFlags & ConnectionHeader::FLAG_DISABLE_PRECOMPILED_SHADERS) != 0;
   mRecordGLErrorState =
@@ -193,6 +220,9 @@ Spy::Spy()
   GAPID_INFO("GAPII connection established. Settings:");
   GAPID_INFO("Observe framebuffer every %d frames", mObserveFrameFrequency);
   GAPID_INFO("Observe framebuffer every %d draws", mObserveDrawFrequency);
+  GAPID_INFO("Low resolution framebuffer observations: %s",
+             mLowResObservations ? "true" : "false");
+  GAPID_INFO("Sample I/O counters: %s", mSampleIOCounters ? "true" : "false");
   GAPID_INFO("Disable precompiled shaders: %s",
              mDisablePrecompiledShaders ? "true" : "false");
   GAPID_INFO("Hide unknown extensions: %s",
@@ -259,6 +289,26 @@ Spy::Spy()
                     mCaptureFrames = usesFrameBounds ? 1 : -1;
                   }
                   break;
+                case protocol::MessageType::kInputEvent:
+                case protocol::MessageType::kDeviceSample: {
+                  uint64_t size = 0;
+                  for (int i = 4; i >= 0; i--) {
+                    size = (size << 8) | buffer[i + 1];
+                  }
+                  std::string data(size, '\0');
+                  if (mConnection->read(&data[0], size) != size) {
+                    GAPID_WARNING("Failed to read message data");
+                    count = 0;
+                    break;
+                  }
+                  if (static_cast<protocol::MessageType>(buffer[0]) ==
+                      protocol::MessageType::kInputEvent) {
+                    recordInputEvent(data);
+                  } else {
+                    recordDeviceSample(data);
+                  }
+                  break;
+                }
                 default:
                   GAPID_WARNING("Invalid message type: %u", buffer[0]);
                   break;
@@ -530,6 +580,9 @@ void Spy::onPreStartOfFrame(CallObserver* observer, uint8_t api) {
     GAPID_DEBUG("Observe framebuffer after frame %d", mNumFrames);
     observeFramebuffer(observer, api);
   }
+  if (mSampleIOCounters) {
+    sampleIOCounters(observer);
+  }
   GAPID_DEBUG("NumFrames:%d NumDraws:%d NumDrawsPerFrame:%d", mNumFrames,
               mNumDraws, mNumDrawsPerFrame);
   mNumFrames++;
@@ -558,6 +611,33 @@ void Spy::saveInitialState() {
   set_recording_state(false);
 }
 
+void Spy::recordInputEvent(const std::string& data) {
+  capture::InputEvent event;
+  if (!event.ParseFromString(data)) {
+    GAPID_WARNING("Received malformed input event");
+    return;
+  }
+  event.set_timestamp(monotonicToTraceTime(event.timestamp()));
+  lock();
+  if (!is_suspended()) {
+    mEncoder->object(&event);
+  }
+  unlock();
+}
+
+void Spy::recordDeviceSample(const std::string& data) {
+  capture::DeviceSample sample;
+  if (!sample.ParseFromString(data)) {
+    GAPID_WARNING("Received malformed device sample");
+    return;
+  }
+  lock();
+  if (!is_suspended()) {
+    mEncoder->object(&sample);
+  }
+  unlock();
+}
+
 template <typename T>
 void Spy::saveInitialStateForApi(const char* name) {
   if (should_trace(T::kApiIndex)) {
@@ -623,6 +703,9 @@ void Spy::onPreEndOfFrame(CallObserver* observer, uint8_t api) {
     GAPID_DEBUG("Observe framebuffer after frame %d", mNumFrames);
     observeFramebuffer(observer, api);
   }
+  if (mSampleIOCounters) {
+    sampleIOCounters(observer);
+  }
   GAPID_DEBUG("NumFrames:%d NumDraws:%d NumDrawsPerFrame:%d", mNumFrames,
               mNumDraws, mNumDrawsPerFrame);
   mNumFrames++;
@@ -712,11 +795,17 @@ void Spy::observeFramebuffer(CallObserver* observer, uint8_t api) {
       break;
   }
 
+  uint32_t maxW = kMaxFramebufferObservationWidth;
+  uint32_t maxH = kMaxFramebufferObservationHeight;
+  if (mLowResObservations) {
+    maxW = kMaxLowResFramebufferObservationWidth;
+    maxH = kMaxLowResFramebufferObservationHeight;
+  }
+
   uint32_t downsampledW, downsampledH;
   std::vector<uint8_t> downsampledData;
   if (downsamplePixels(data, w, h, &downsampledData, &downsampledW,
-                       &downsampledH, kMaxFramebufferObservationWidth,
-                       kMaxFramebufferObservationHeight)) {
+                       &downsampledH, maxW, maxH)) {
     capture::FramebufferObservation observation;
     observation.set_original_width(w);
     observation.set_original_height(h);
@@ -727,6 +816,68 @@ void Spy::observeFramebuffer(CallObserver* observer, uint8_t api) {
   }
 }
 
+// readIOCounters reads the disk I/O counters of the process from /proc/self/io
+// and the network counters from /proc/self/net/dev, returning false if they
+// are not available.
+static bool readIOCounters(capture::IOCounters* counters) {
+#if TARGET_OS == GAPID_OS_LINUX || TARGET_OS == GAPID_OS_ANDROID
+  FILE* io = fopen("/proc/self/io", "r");
+  if (io == nullptr) {
+    return false;
+  }
+  char key[32];
+  unsigned long long value;
+  while (fscanf(io, "%31s %llu", key, &value) == 2) {
+    if (strcmp(key, "rchar:") == 0) {
+      counters->set_read_chars(value);
+    } else if (strcmp(key, "wchar:") == 0) {
+      counters->set_write_chars(value);
+    } else if (strcmp(key, "read_bytes:") == 0) {
+      counters->set_read_bytes(value);
+    } else if (strcmp(key, "write_bytes:") == 0) {
+      counters->set_write_bytes(value);
+    }
+  }
+  fclose(io);
+
+  // The network counters are per network namespace, not per process.
+  FILE* net = fopen("/proc/self/net/dev", "r");
+  if (net == nullptr) {
+    return true;
+  }
+  char line[512];
+  while (fgets(line, sizeof(line), net) != nullptr) {
+    char* stats = strchr(line, ':');
+    if (stats == nullptr) {
+      continue;  // Header line.
+    }
+    *stats = '\0';
+    char name[32];
+    if (sscanf(line, "%31s", name) == 1 && strcmp(name, "lo") == 0) {
+      continue;  // Ignore the loopback interface.
+    }
+    unsigned long long rx, tx;
+    if (sscanf(stats + 1, "%llu %*u %*u %*u %*u %*u %*u %*u %llu", &rx, &tx) ==
+        2) {
+      counters->set_net_received_bytes(counters->net_received_bytes() + rx);
+      counters->set_net_transmitted_bytes(counters->net_transmitted_bytes() +
+                                          tx);
+    }
+  }
+  fclose(net);
+  return true;
+#else
+  return false;
+#endif
+}
+
+void Spy::sampleIOCounters(CallObserver* observer) {
+  capture::IOCounters counters;
+  if (readIOCounters(&counters)) {
+    observer->encode_message(&counters);
+  }
+}
+
 void Spy::onPostFence(CallObserver* observer) {
   if (mRecordGLErrorState) {
     auto traceErr = GlesSpy::mImports.glGetError();
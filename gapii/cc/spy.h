@@ -116,6 +116,18 @@ class Spy : public GlesSpy, public GvrSpy, public VulkanSpy {
   // onPostFrameBoundary is called from onPost{Start,End}OfFrame().
   void onPostFrameBoundary(bool isStartOfFrame);
 
+  // sampleIOCounters reads the disk and network I/O counters of the process,
+  // and writes them to an IOCounters extra.
+  void sampleIOCounters(CallObserver* observer);
+
+  // recordInputEvent writes the serialized capture::InputEvent data, sent by
+  // GAPIS, to the trace. The event is only an annotation and is not replayed.
+  void recordInputEvent(const std::string& data);
+
+  // recordDeviceSample writes the serialized capture::DeviceSample data, sent
+  // by GAPIS, to the trace.
+  void recordDeviceSample(const std::string& data);
+
   std::unordered_map<std::string, void*> mSymbols;
 
   int mNumFrames;
@@ -132,6 +144,10 @@ class Spy : public GlesSpy, public GvrSpy, public VulkanSpy {
   int mNumDrawsPerFrame;
   int mObserveFrameFrequency;
   int mObserveDrawFrequency;
+  // If true, framebuffer observations are downsampled to low resolution.
+  bool mLowResObservations;
+  // If true, the I/O counters of the process are sampled at every frame.
+  bool mSampleIOCounters;
   bool mDisablePrecompiledShaders;
   bool mRecordGLErrorState;
   // These keep track of nested frame start/end callbacks.
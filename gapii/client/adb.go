@@ -52,6 +52,15 @@ type Process struct {
 	// The options used for the capture.
 	Options Options
 
+	// If not nil, the serialized capture.InputEvent messages received on this
+	// channel are sent to GAPII while capturing, to be stored in the trace.
+	InputEvents <-chan []byte
+
+	// If not nil, the serialized capture.DeviceSample messages received on
+	// this channel are sent to GAPII while capturing, to be stored in the
+	// trace.
+	DeviceSamples <-chan []byte
+
 	// The connection
 	conn net.Conn
 }
@@ -140,6 +149,10 @@ func Start(ctx context.Context, p *android.InstalledPackage, a *android.Activity
 		additionalArgs = append(additionalArgs, android.CustomExtras(text.Quote(text.SplitArgs(o.AdditionalFlags))))
 	}
 
+	// Forward the logcat output so that device-side tracing failures show up
+	// in the host logs.
+	cleanup = cleanup.Then(android.ForwardLogcat(ctx, d, "gapii"))
+
 	if a != nil {
 		if useLayers {
 			log.I(ctx, "Starting activity")
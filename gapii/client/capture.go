@@ -53,6 +53,12 @@ const (
 	StoreTimestamps Flags = 0x00000080
 	// DisableCoherentMemoryTracker disables the coherent memory tracker from running.
 	DisableCoherentMemoryTracker Flags = 0x000000100
+	// LowResObservations downsamples the framebuffer observations to low
+	// resolution screenshots, small enough to be stored at every frame.
+	LowResObservations Flags = 0x00000200
+	// SampleIOCounters samples the disk and network I/O counters of the
+	// process at every frame.
+	SampleIOCounters Flags = 0x00000400
 
 	// GlesAPI is hard-coded bit mask for GLES API, it needs to be kept in sync
 	// with the api_index in the gles.api file.
@@ -165,6 +171,26 @@ func handleCommError(ctx context.Context, commErr error, anyDataReceived bool) (
 	return
 }
 
+// forwardMessages sends the data received on the channel msgs to GAPII as
+// messages of the type msgType, until the channel is closed or the context is
+// stopped.
+func forwardMessages(ctx context.Context, conn net.Conn, msgType messageType, msgs <-chan []byte) {
+	for {
+		select {
+		case <-task.ShouldStop(ctx):
+			return
+		case data, ok := <-msgs:
+			if !ok {
+				return
+			}
+			if err := writeMessage(conn, msgType, data); err != nil {
+				log.W(ctx, "Failed to send message %v: %v", msgType, err)
+				return
+			}
+		}
+	}
+}
+
 // Capture opens up the specified port and then waits for a capture to be
 // delivered using the specified capture options o.
 // It copies the capture into the supplied writer.
@@ -210,6 +236,12 @@ func (p *Process) Capture(ctx context.Context, start task.Signal, stop task.Sign
 			}
 		}()
 	}
+	if p.InputEvents != nil {
+		go forwardMessages(ctx, conn, messageInputEvent, p.InputEvents)
+	}
+	if p.DeviceSamples != nil {
+		go forwardMessages(ctx, conn, messageDeviceSample, p.DeviceSamples)
+	}
 	go func() {
 		if stop.Wait(ctx) {
 			if err := writeEndTrace(conn); err == nil {
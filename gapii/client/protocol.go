@@ -30,11 +30,13 @@ const messageDataBytes uint = 5
 type messageType byte
 
 const (
-	messageData       messageType = 0x00
-	messageStartTrace messageType = 0x01
-	messageEndTrace   messageType = 0x02
-	messageError      messageType = 0x03
-	messageInvalid    messageType = 0xff
+	messageData         messageType = 0x00
+	messageStartTrace   messageType = 0x01
+	messageEndTrace     messageType = 0x02
+	messageError        messageType = 0x03
+	messageInputEvent   messageType = 0x04
+	messageDeviceSample messageType = 0x05
+	messageInvalid      messageType = 0xff
 )
 
 var startTraceMessage [messageHeaderSize]byte = [messageHeaderSize]byte{byte(messageStartTrace)}
@@ -95,3 +97,15 @@ func writeEndTrace(conn net.Conn) error {
 	_, err := conn.Write(endTraceMessage[:])
 	return err
 }
+
+func writeMessage(conn net.Conn, msgType messageType, data []byte) error {
+	// The header and data are written with a single call so that the message
+	// cannot be interleaved with the messages of other goroutines.
+	buf := make([]byte, messageHeaderSize, messageHeaderSize+uint(len(data)))
+	buf[0] = byte(msgType)
+	for i := uint(0); i < messageDataBytes; i++ {
+		buf[i+1] = byte(uint64(len(data)) >> (i * 8))
+	}
+	_, err := conn.Write(append(buf, data...))
+	return err
+}
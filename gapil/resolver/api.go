@@ -192,6 +192,7 @@ func global(rv *resolver, out *semantic.Global) {
 	out.Type = semantic.VoidType
 
 	in := out.AST
+	out.Docs = rv.findDocumentation(in)
 	out.Annotations = annotations(rv, in.Annotations)
 	out.Type = type_(rv, in.Type)
 	if isVoid(out.Type) {
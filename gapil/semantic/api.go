@@ -98,11 +98,12 @@ func (a Annotations) GetAnnotation(name string) *Annotation {
 // Global represents a global variable.
 type Global struct {
 	owned
-	AST         *ast.Field // the underlying syntax node this was built from
-	Annotations            // the annotations applied to this global
-	Type        Type       // the type the global stores
-	Named                  // the name of the global
-	Default     Expression // the initial value of the global
+	AST         *ast.Field    // the underlying syntax node this was built from
+	Annotations               // the annotations applied to this global
+	Type        Type          // the type the global stores
+	Named                     // the name of the global
+	Docs        Documentation // the documentation for the global
+	Default     Expression    // the initial value of the global
 }
 
 func (*Global) isNode()       {}
@@ -124,10 +124,14 @@ func (p u64s) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 type ConstantSets struct {
 	Pack constset.Pack
 	Sets map[semantic.Node]*int
+	// KeySets maps the semantic nodes of maps to the constset.Set index of
+	// their keys.
+	KeySets map[semantic.Node]*int
 }
 
 type nodeLabeler struct {
 	nodes map[semantic.Node]analysis.Labels
+	keys  map[semantic.Node]analysis.Labels // The labels of the keys of maps.
 	seen  map[analysis.Value]bool
 }
 
@@ -156,6 +160,14 @@ func (nl *nodeLabeler) traverse(n semantic.Node, v analysis.Value) {
 		}
 	case *analysis.MapValue:
 		for k, v := range v.KeyToValue {
+			if ev, ok := k.(*analysis.EnumValue); ok {
+				l, ok := nl.keys[n]
+				if !ok {
+					l = analysis.Labels{}
+					nl.keys[n] = l
+				}
+				l.Merge(ev.Labels)
+			}
 			nl.traverse(n, k)
 			nl.traverse(n, v)
 		}
@@ -215,6 +227,7 @@ func buildConstantSets(api *semantic.API, mappings *semantic.Mappings) *Constant
 	}
 	nl := nodeLabeler{
 		nodes: map[semantic.Node]analysis.Labels{},
+		keys:  map[semantic.Node]analysis.Labels{},
 		seen:  map[analysis.Value]bool{},
 	}
 	// Gather all the labeled nodes.
@@ -240,11 +253,50 @@ func buildConstantSets(api *semantic.API, mappings *semantic.Mappings) *Constant
 			constsets[n] = b.addLabels(l, isBitfield)
 		}
 	}
+	// Create constant sets for the keys of all the map nodes, using the set
+	// of the key type if it is an enum with its own set.
+	keysets := map[semantic.Node]*int{}
+	for n, ty := range mapNodes(api) {
+		if enum, ok := ty.KeyType.(*semantic.Enum); ok {
+			if i, ok := constsets[enum]; ok {
+				keysets[n] = i
+				continue
+			}
+		}
+		if l := nl.keys[n]; len(l) != 0 {
+			isBitfield := false
+			if enum, ok := ty.KeyType.(*semantic.Enum); ok {
+				isBitfield = enum.IsBitfield
+			}
+			keysets[n] = b.addLabels(l, isBitfield)
+		}
+	}
 
 	return &ConstantSets{
-		Pack: b.build(),
-		Sets: constsets,
+		Pack:    b.build(),
+		Sets:    constsets,
+		KeySets: keysets,
+	}
+}
+
+// mapNodes returns the globals and class fields of the API that hold maps,
+// with their map types.
+func mapNodes(api *semantic.API) map[semantic.Node]*semantic.Map {
+	out := map[semantic.Node]*semantic.Map{}
+	add := func(n semantic.Node, ty semantic.Type) {
+		if m, ok := semantic.Underlying(ty).(*semantic.Map); ok {
+			out[n] = m
+		}
+	}
+	for _, g := range api.Globals {
+		add(g, g.Type)
 	}
+	for _, c := range api.Classes {
+		for _, f := range c.Fields {
+			add(f, f.Type)
+		}
+	}
+	return out
 }
 
 func (f *Functions) constantSets() *ConstantSets {
@@ -267,3 +319,12 @@ func (f *Functions) ConstantSetIndex(n semantic.Node) int {
 	}
 	return -1
 }
+
+// KeyConstantSetIndex returns the constant set for the keys of the given map
+// global or field.
+func (f *Functions) KeyConstantSetIndex(n semantic.Node) int {
+	if i, ok := f.constantSets().KeySets[n]; ok {
+		return *i
+	}
+	return -1
+}
@@ -52,8 +52,45 @@ bool ArchiveReplayService::sendPosts(
     std::string path = mPostbackDir + "/" + std::to_string(id) + ".bin";
     std::fstream output(path, std::ios::out | std::ios::binary);
     output.write(data.data(), data.size());
+    if (!output) {
+      GAPID_ERROR("Failed to write postback data to %s.", path.c_str());
+      return false;
+    }
   }
 
   return true;
 }
+
+bool ArchiveReplayService::sendReplayFinished() {
+  if (mPostbackDir.empty()) {
+    return true;
+  }
+
+  std::string path = mPostbackDir + "/finished";
+  std::fstream output(path, std::ios::out | std::ios::binary);
+  if (!output) {
+    GAPID_ERROR("Failed to write replay finished marker to %s.", path.c_str());
+    return false;
+  }
+  return true;
+}
+
+bool ArchiveReplayService::sendErrorMsg(uint64_t seq_num, uint32_t severity,
+                                        uint32_t api_index, uint64_t label,
+                                        const std::string& msg,
+                                        const void* data, uint32_t data_size) {
+  if (mPostbackDir.empty()) {
+    return true;
+  }
+
+  std::string path = mPostbackDir + "/errors.txt";
+  std::fstream output(path, std::ios::out | std::ios::app);
+  output << seq_num << " severity:" << severity << " api:" << api_index
+         << " label:" << label << " " << msg << std::endl;
+  if (!output) {
+    GAPID_ERROR("Failed to write replay error to %s.", path.c_str());
+    return false;
+  }
+  return true;
+}
 }  // namespace gapir
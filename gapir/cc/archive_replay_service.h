@@ -29,6 +29,9 @@ namespace gapir {
 
 // ArchiveReplayService implements ReplayService interface for exported replays.
 // It represents an local on-disk source of replay payload data.
+// If a postback directory is given, the replay outputs are written to it:
+// the data of each post as <id>.bin, the errors reported by the replay to
+// errors.txt, and an empty 'finished' file once the replay has completed.
 class ArchiveReplayService : public ReplayService {
  public:
   ArchiveReplayService(const std::string& fileprefix,
@@ -56,7 +59,8 @@ class ArchiveReplayService : public ReplayService {
         new replay_service::ReplayRequest());
   }
 
-  bool sendReplayFinished() override { return true; }
+  // Write the finished marker file to the postback directory.
+  bool sendReplayFinished() override;
 
   bool sendCrashDump(const std::string& filepath, const void* crash_data,
                      uint32_t crash_size) override {
@@ -64,11 +68,10 @@ class ArchiveReplayService : public ReplayService {
     return true;
   }
 
+  // Append the error message to the errors file of the postback directory.
   bool sendErrorMsg(uint64_t seq_num, uint32_t severity, uint32_t api_index,
                     uint64_t label, const std::string& msg, const void* data,
-                    uint32_t data_size) override {
-    return true;
-  }
+                    uint32_t data_size) override;
 
   bool sendReplayStatus(uint64_t label, uint32_t total_instrs,
                         uint32_t finished_instrs) override {
@@ -66,7 +66,8 @@ Context::Context(ReplayService* srv, core::CrashHandler& crash_handler,
             }
             return false;
           })),
-      mNumSentDebugMessages(0) {}
+      mNumSentDebugMessages(0),
+      mTraceOpcodes(false) {}
 
 Context::~Context() {
   for (auto it = mGlesRenderers.begin(); it != mGlesRenderers.end(); it++) {
@@ -156,10 +157,22 @@ bool Context::interpret(bool cleanup, bool isPrewarm) {
   }
   mInterpreter->setApiRequestCallback(std::move(callback));
   mInterpreter->setCheckReplayStatusCallback(std::move(replayStatusCallback));
+  if (mTraceOpcodes) {
+    mInterpreter->setOpcodeTraceCallback(
+        [this](uint32_t instruction, uint32_t label, const char* name,
+               uint32_t opcode) {
+          this->traceOpcode(instruction, label, name, opcode);
+        });
+  } else {
+    mInterpreter->setOpcodeTraceCallback(nullptr);
+  }
 
   auto instAndCount = mReplayRequest->getInstructionList();
-  auto res = mInterpreter->run(instAndCount.first, instAndCount.second) &&
-             mPostBuffer->flush();
+  auto ok = mInterpreter->run(instAndCount.first, instAndCount.second);
+  if (!ok) {
+    sendInterpreterError();
+  }
+  auto res = ok && mPostBuffer->flush();
   if (cleanup) {
     mInterpreter.reset(nullptr);
   } else {
@@ -187,6 +200,38 @@ void Context::onDebugMessage(uint32_t severity, uint8_t api_index,
                      str_msg, nullptr, 0);
 }
 
+void Context::traceOpcode(uint32_t instruction, uint32_t label,
+                          const char* name, uint32_t opcode) const {
+  auto code = static_cast<vm::Opcode>(opcode >> 26);
+  if (code == vm::Opcode::RESOURCE) {
+    uint32_t index = opcode & 0x03ffffff;
+    const auto& resources = mReplayRequest->getResources();
+    if (index < resources.size()) {
+      GAPID_INFO("[%u] %u: %s(%u) -> resource %s (%u bytes)", label,
+                 instruction, name, index, resources[index].getID().c_str(),
+                 resources[index].getSize());
+      return;
+    }
+  }
+  GAPID_INFO("[%u] %u: %s(%#010x)", label, instruction, name, opcode);
+}
+
+void Context::sendInterpreterError() {
+  if (mSrv == nullptr) {
+    return;
+  }
+  auto label = mInterpreter->getLabel();
+  uint32_t instruction = mInterpreter->getCurrentInstruction();
+  std::stringstream msg;
+  msg << "Replay stopped because of an interpretation error at opcode "
+      << instruction << ". Last reached label: " << label;
+  // The instruction index is also sent as the message data, for GAPIS to map
+  // it back to the command and transform that emitted it.
+  mSrv->sendErrorMsg(mNumSentDebugMessages++, LOG_LEVEL_ERROR,
+                     Interpreter::GLOBAL_INDEX, label, msg.str(), &instruction,
+                     sizeof(instruction));
+}
+
 void Context::registerCallbacks(Interpreter* interpreter) {
   // Custom function for posting and fetching resources to and from the server
   interpreter->registerBuiltin(Interpreter::GLOBAL_INDEX,
@@ -74,6 +74,10 @@ class Context : private Renderer::Listener {
   // Clean up the context for the next replay.
   bool cleanup();
 
+  // Enables or disables the logging of each opcode executed by interpret(),
+  // together with the resources it loads.
+  void setTraceOpcodes(bool trace) { mTraceOpcodes = trace; }
+
  private:
   enum {
     MAX_TIMERS = 256,
@@ -110,6 +114,15 @@ class Context : private Renderer::Listener {
   // Flushes any pending post data buffered from calling postData.
   bool flushPostBuffer(Stack* stack);
 
+  // Logs the opcode about to be executed by the interpreter, along with the
+  // resource it maps to, if any.
+  void traceOpcode(uint32_t instruction, uint32_t label, const char* name,
+                   uint32_t opcode) const;
+
+  // Reports the instruction at which the interpreter stopped to the server, so
+  // that the failure can be mapped back to the command that emitted it.
+  void sendInterpreterError();
+
   // Send a chunk of notification data where the number of bytes is on the top
   // of the stack (uint32_t) and the address for the data is the second element
   // on the stack (void*)
@@ -153,6 +166,9 @@ class Context : private Renderer::Listener {
 
   // The total number of debug messages sent to GAPIS.
   uint64_t mNumSentDebugMessages;
+
+  // If true, each opcode executed by the interpreter is logged.
+  bool mTraceOpcodes;
 };
 
 }  // namespace gapir
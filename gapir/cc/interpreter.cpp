@@ -87,6 +87,10 @@ void Interpreter::setCheckReplayStatusCallback(
   checkReplayStatusCallback = std::move(callback);
 }
 
+void Interpreter::setOpcodeTraceCallback(OpcodeTraceCallback callback) {
+  opcodeTraceCallback = std::move(callback);
+}
+
 void Interpreter::registerBuiltin(uint8_t api, FunctionTable::Id id,
                                   FunctionTable::Function func) {
   mBuiltins[api].insert(id, func);
@@ -619,12 +623,22 @@ Interpreter::Result Interpreter::jumpZ(uint32_t opcode) {
   return mStack.isValid() ? SUCCESS : ERROR;
 }
 
-#define DEBUG_OPCODE(name, value) GAPID_VERBOSE(name)
-#define DEBUG_OPCODE_26(name, value) \
-  GAPID_VERBOSE(name "(%#010x)", value& DATA_MASK26)
+void Interpreter::traceOpcode(const char* name, uint32_t opcode) {
+  if (opcodeTraceCallback) {
+    opcodeTraceCallback(mCurrentInstruction, mLabel, name, opcode);
+  }
+}
+
+#define DEBUG_OPCODE(name, value) \
+  GAPID_VERBOSE(name);            \
+  traceOpcode(name, value)
+#define DEBUG_OPCODE_26(name, value)                  \
+  GAPID_VERBOSE(name "(%#010x)", value& DATA_MASK26); \
+  traceOpcode(name, value)
 #define DEBUG_OPCODE_TY_20(name, value)                  \
   GAPID_VERBOSE(name "(%#010x, %s)", value& DATA_MASK20, \
-                baseTypeName(extractType(value)))
+                baseTypeName(extractType(value)));       \
+  traceOpcode(name, value)
 
 Interpreter::Result Interpreter::interpret(uint32_t opcode) {
   InstructionCode code =
@@ -706,5 +720,7 @@ Interpreter::Result Interpreter::interpret(uint32_t opcode) {
 }
 
 #undef DEBUG_OPCODE
+#undef DEBUG_OPCODE_26
+#undef DEBUG_OPCODE_TY_20
 
 }  // namespace gapir
@@ -49,6 +49,11 @@ class Interpreter {
   using ApiRequestCallback = std::function<bool(Interpreter*, uint8_t)>;
   using CheckReplayStatusCallback =
       std::function<void(uint64_t, uint32_t, uint32_t)>;
+  // The type of the callback function invoked before each opcode is executed.
+  // It takes the index of the instruction, the last reached label, the name of
+  // the opcode and the raw opcode.
+  using OpcodeTraceCallback =
+      std::function<void(uint32_t, uint32_t, const char*, uint32_t)>;
 
   using InstructionCode = vm::Opcode;
 
@@ -80,6 +85,10 @@ class Interpreter {
   // Register a call back function for interpreter to report replay status.
   void setCheckReplayStatusCallback(CheckReplayStatusCallback callback);
 
+  // Register a call back function for interpreter to trace each executed
+  // opcode. Passing an empty callback disables the tracing.
+  void setOpcodeTraceCallback(OpcodeTraceCallback callback);
+
   // Registers a builtin function to the builtin function table.
   void registerBuiltin(uint8_t api, FunctionTable::Id, FunctionTable::Function);
 
@@ -104,6 +113,9 @@ class Interpreter {
   // Returns the last reached label value.
   inline uint32_t getLabel() const;
 
+  // Returns the index of the instruction being, or last, executed.
+  inline uint32_t getCurrentInstruction() const;
+
  private:
   void exec();
 
@@ -178,6 +190,10 @@ class Interpreter {
   // Interpret one specific opcode.
   Result interpret(uint32_t opcode);
 
+  // Reports the opcode about to be executed to the opcode trace callback, if
+  // any.
+  void traceOpcode(const char* name, uint32_t opcode);
+
   // The crash handler used for catching and reporting crashes.
   core::CrashHandler& mCrashHandler;
 
@@ -201,6 +217,9 @@ class Interpreter {
   // at right time.
   CheckReplayStatusCallback checkReplayStatusCallback;
 
+  // Callback function for tracing each executed opcode.
+  OpcodeTraceCallback opcodeTraceCallback;
+
   // The stack of the Virtual Machine.
   Stack mStack;
 
@@ -254,6 +273,10 @@ inline bool Interpreter::isWriteAddress(void* address) const {
 
 inline uint32_t Interpreter::getLabel() const { return mLabel; }
 
+inline uint32_t Interpreter::getCurrentInstruction() const {
+  return mCurrentInstruction;
+}
+
 }  // namespace gapir
 
 #endif  // GAPIR_INTERPRETER_H
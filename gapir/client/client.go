@@ -29,6 +29,7 @@ import (
 	"github.com/google/gapid/core/os/device"
 	"github.com/google/gapid/core/os/device/bind"
 	"github.com/google/gapid/gapir"
+	"github.com/pkg/errors"
 )
 
 type tyLaunchArgsKey string
@@ -107,11 +108,11 @@ func (client *Client) Connect(ctx context.Context, device bind.Device, abi *devi
 		return nil, log.Err(ctx, err, "Timeout waiting for connection")
 	}
 
-	crash.Go(func() { client.heartbeat(ctx, heartbeatInterval, key) })
+	crash.Go(func() { client.heartbeat(ctx, heartbeatInterval, key, connection) })
 
 	log.I(ctx, "Heartbeat connection setup done")
 
-	bgConnection, err := client.makeBackgroundConnection(ctx, device, connection)
+	bgConnection, err := client.makeBackgroundConnection(ctx, key, device, connection)
 	if err != nil {
 		return nil, log.Err(ctx, err, "Background connection error")
 	}
@@ -126,7 +127,7 @@ func (client *Client) Connect(ctx context.Context, device bind.Device, abi *devi
 	return &key, nil
 }
 
-func (client *Client) makeBackgroundConnection(ctx context.Context, device bind.Device, conn gapir.Connection) (*backgroundConnection, error) {
+func (client *Client) makeBackgroundConnection(ctx context.Context, key ConnectionKey, device bind.Device, conn gapir.Connection) (*backgroundConnection, error) {
 	bgc := &backgroundConnection{conn: conn, OS: device.Instance().GetConfiguration().GetOS()}
 
 	connected := make(chan error)
@@ -141,6 +142,11 @@ func (client *Client) makeBackgroundConnection(ctx context.Context, device bind.
 		err := conn.HandleReplayCommunication(cctx, bgc, connected)
 		if err != nil {
 			log.E(cctx, "Error communication with gapir: %v", err)
+			if errors.Cause(err) == ErrConnectionLost {
+				// Drop the broken connection so that the next call to Connect
+				// establishes a new one.
+				client.dropConnection(cctx, key, conn)
+			}
 		}
 
 		bgc.HandleFinished(ctx, err)
@@ -155,7 +161,8 @@ func (client *Client) makeBackgroundConnection(ctx context.Context, device bind.
 func (client *Client) closeConnection(ctx context.Context, key ConnectionKey) {
 	clientInfo, found := client.clientInfos[key]
 	if !found {
-		log.Err(ctx, nil, "Connection could not be found!")
+		log.E(ctx, "Connection could not be found!")
+		return
 	}
 
 	clientInfo.connection.Shutdown(ctx)
@@ -171,6 +178,26 @@ func (client *Client) removeConnection(ctx context.Context, key ConnectionKey) {
 	delete(client.clientInfos, key)
 }
 
+// dropConnection closes and removes the connection for key, if it is still
+// conn.
+func (client *Client) dropConnection(ctx context.Context, key ConnectionKey, conn gapir.Connection) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if info, ok := client.clientInfos[key]; ok && info.connection == conn {
+		client.closeConnection(ctx, key)
+		delete(client.clientInfos, key)
+	}
+}
+
+// connection returns the current connection for key, or nil if there is none.
+func (client *Client) connection(key ConnectionKey) gapir.Connection {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	return client.clientInfos[key].connection
+}
+
 func (client *Client) shutdown(ctx context.Context) {
 	client.mutex.Lock()
 	defer client.mutex.Unlock()
@@ -183,7 +210,12 @@ func (client *Client) shutdown(ctx context.Context) {
 }
 
 func (client *Client) reconnect(ctx context.Context, key ConnectionKey) {
-	clientInfo := client.clientInfos[key]
+	client.mutex.Lock()
+	clientInfo, found := client.clientInfos[key]
+	client.mutex.Unlock()
+	if !found {
+		return
+	}
 	device := clientInfo.device
 	abi := clientInfo.abi
 
@@ -204,13 +236,18 @@ func (client *Client) ping(ctx context.Context, connection gapir.Connection) (ti
 	return time.Since(start), nil
 }
 
-func (client *Client) heartbeat(ctx context.Context, pingInterval time.Duration, key ConnectionKey) {
+// heartbeat pings conn every pingInterval, reconnecting if a ping fails. It
+// returns once conn is no longer the connection for key.
+func (client *Client) heartbeat(ctx context.Context, pingInterval time.Duration, key ConnectionKey, conn gapir.Connection) {
 	for {
 		select {
 		case <-task.ShouldStop(ctx):
 			return
 		case <-time.After(pingInterval):
-			_, err := client.ping(ctx, client.clientInfos[key].connection)
+			if client.connection(key) != conn {
+				return
+			}
+			_, err := client.ping(ctx, conn)
 			if err != nil {
 				log.E(ctx, "Error sending keep-alive ping. Error: %v", err)
 				client.reconnect(ctx, key)
@@ -228,6 +265,12 @@ func (client *Client) SetReplayExecutor(ctx context.Context, conn *ConnectionKey
 	return client.clientInfos[*conn].bgConnection.SetReplayExecutor(ctx, executor)
 }
 
+// Disconnect closes and removes the connection for conn, abandoning any replay
+// in progress on it. The next call to Connect establishes a new connection.
+func (client *Client) Disconnect(ctx context.Context, conn *ConnectionKey) {
+	client.removeConnection(ctx, *conn)
+}
+
 func (client *Client) PrewarmReplay(ctx context.Context, conn *ConnectionKey, payload string, cleanup string) error {
 	return client.clientInfos[*conn].bgConnection.PrewarmReplay(ctx, payload, cleanup)
 }
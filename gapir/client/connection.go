@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/fault"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/gapir"
 	replaysrv "github.com/google/gapid/gapir/replay_service"
@@ -32,6 +33,10 @@ const (
 	// common knowledge shared between GAPIR client (which is GAPIS) and GAPIR
 	// server (which is GAPIR device)
 	gapirAuthTokenMetaDataName = "gapir-auth-token"
+
+	// ErrConnectionLost is the cause of the error returned when the replay
+	// stream to GAPIR breaks, for example when the device is disconnected.
+	ErrConnectionLost = fault.Const("Connection to GAPIR lost")
 )
 
 // connection implements the gapir.Connection interface.
@@ -223,11 +228,11 @@ func (c *connection) HandleReplayCommunication(
 	}()
 	for {
 		if c.stream == nil {
-			return log.Errf(ctx, nil, "Replay stream connection lost")
+			return log.Errf(ctx, ErrConnectionLost, "Replay stream connection lost")
 		}
 		r, err := c.stream.Recv()
 		if err != nil {
-			return log.Errf(ctx, err, "Recv")
+			return log.Errf(ctx, ErrConnectionLost, "Recv: %v", err)
 		}
 		switch r.Res.(type) {
 		case *replaysrv.ReplayResponse_PayloadRequest:
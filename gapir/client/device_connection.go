@@ -286,6 +286,16 @@ func newADB(ctx context.Context, d adb.Device, abi *device.ABI, launchArgs []str
 		cleanup.Invoke(ctx)
 	}
 
+	// Forward the logcat output so that device-side replay failures, including
+	// those during startup, show up in the host logs.
+	stopLogcat := android.ForwardLogcat(ctx, d, "gapir")
+	started := false
+	defer func() {
+		if !started {
+			stopLogcat.Invoke(ctx)
+		}
+	}()
+
 	if err := d.StartActivity(ctx, *apk.ActivityActions[gapirActivityIndex],
 		android.StringExtra{"gapir-intent-flag", strings.Join(completeLaunchArgs, " ")},
 	); err != nil {
@@ -342,8 +352,10 @@ func newADB(ctx context.Context, d adb.Device, abi *device.ABI, launchArgs []str
 
 	cleanupFunc := func() {
 		cleanup.Invoke(ctx)
+		stopLogcat.Invoke(ctx)
 		d.RemoveForward(ctx, localPort)
 	}
 
+	started = true
 	return &deviceConnectionInfo{port: port, authToken: "", cleanupFunc: cleanupFunc}, nil
 }
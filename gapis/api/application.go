@@ -0,0 +1,25 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// ApplicationNamer is the interface implemented by APIs that record the name
+// the application gives itself.
+type ApplicationNamer interface {
+	// ApplicationName returns the name of the application held by the state
+	// s, or an empty string if the application has not named itself.
+	ApplicationName(ctx context.Context, s *GlobalState) string
+}
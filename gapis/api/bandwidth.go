@@ -0,0 +1,41 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// BandwidthEstimator is the interface implemented by APIs that can estimate
+// the memory bandwidth used by their commands.
+type BandwidthEstimator interface {
+	// EstimateBandwidth mutates the command cmd with the state s, adding the
+	// estimated memory bandwidth used by the command, and any subcommands it
+	// executes, to b.
+	EstimateBandwidth(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState, b *FrameBandwidth) error
+}
+
+// Add adds read and write bytes to the usage of the category c.
+func (b *FrameBandwidth) Add(c BandwidthCategory, read, write uint64) {
+	if read == 0 && write == 0 {
+		return
+	}
+	for _, u := range b.Usages {
+		if u.Category == c {
+			u.ReadBytes += read
+			u.WriteBytes += write
+			return
+		}
+	}
+	b.Usages = append(b.Usages, &BandwidthUsage{Category: c, ReadBytes: read, WriteBytes: write})
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+func TestFrameBandwidthAdd(t *testing.T) {
+	ctx := log.Testing(t)
+	b := &api.FrameBandwidth{}
+	b.Add(api.BandwidthCategory_TEXTURES, 100, 0)
+	b.Add(api.BandwidthCategory_ATTACHMENTS, 0, 0)
+	b.Add(api.BandwidthCategory_ATTACHMENTS, 10, 20)
+	b.Add(api.BandwidthCategory_TEXTURES, 50, 5)
+
+	assert.For(ctx, "usages").That(b.Usages).DeepEquals([]*api.BandwidthUsage{
+		{Category: api.BandwidthCategory_TEXTURES, ReadBytes: 150, WriteBytes: 5},
+		{Category: api.BandwidthCategory_ATTACHMENTS, ReadBytes: 10, WriteBytes: 20},
+	})
+}
@@ -0,0 +1,34 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// BindChurnAnalyzer is the interface implemented by APIs that can count the
+// state binding commands used by their render passes.
+type BindChurnAnalyzer interface {
+	// AnalyzeBindChurn mutates the command cmd with the state s, appending
+	// the counts of each of the render passes begun by the command, or by
+	// any subcommands it executes, to out. The Capture of each render pass's
+	// Begin path is left nil.
+	AnalyzeBindChurn(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState, out *BindChurn) error
+}
+
+// AvoidableBinds returns the number of bind commands of the render pass that
+// could be avoided by removing redundant binds, merging descriptor set binds
+// and sorting draws by pipeline.
+func (c *RenderPassChurn) AvoidableBinds() uint32 {
+	return c.RedundantBinds + c.MergeableBinds + c.AvoidablePipelineBinds
+}
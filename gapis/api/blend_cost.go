@@ -0,0 +1,27 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// BlendAnalyzer is the interface implemented by APIs that can report which of
+// their draw calls blend with their color attachments.
+type BlendAnalyzer interface {
+	// AnalyzeBlending mutates the command cmd with the state s, calling draw
+	// with the indices of each draw call the command performs, or executes as
+	// a subcommand, and whether that draw call has blending enabled for any of
+	// the color attachments it writes.
+	AnalyzeBlending(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState, draw func(indices []uint64, blended bool)) error
+}
@@ -75,6 +75,29 @@ func (e *CmdExtras) Aborted() *ErrCmdAborted {
 	return nil
 }
 
+// StableID returns a pointer to the StableID structure in the CmdExtras, or nil
+// if not found.
+func (e *CmdExtras) StableID() *StableID {
+	for _, e := range e.All() {
+		if e, ok := e.(*StableID); ok {
+			return e
+		}
+	}
+	return nil
+}
+
+// StateEdits returns the StateEdit structures in the CmdExtras, in the order
+// they were added.
+func (e *CmdExtras) StateEdits() []*StateEdit {
+	var out []*StateEdit
+	for _, e := range e.All() {
+		if e, ok := e.(*StateEdit); ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
 // Observations returns a pointer to the CmdObservations structure in the
 // CmdExtras, or nil if there are no observations in the CmdExtras.
 func (e *CmdExtras) Observations() *CmdObservations {
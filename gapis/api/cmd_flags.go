@@ -28,6 +28,7 @@ const (
 	UserMarker
 	ExecutedDraw
 	Submission
+	Upload
 )
 
 // IsDrawCall returns true if the command is a draw call.
@@ -65,3 +66,7 @@ func (f CmdFlags) IsExecutedDraw() bool { return (f & ExecutedDraw) != 0 }
 
 // IsSubmission returns true if the command is a submission
 func (f CmdFlags) IsSubmission() bool { return (f & Submission) != 0 }
+
+// IsUpload returns true if the command uploads texture or buffer data read
+// from application memory.
+func (f CmdFlags) IsUpload() bool { return (f & Upload) != 0 }
@@ -32,6 +32,10 @@ func CmdToService(c Cmd) (*Command, error) {
 		Terminated: c.Terminated(),
 	}
 
+	if s := c.Extras().StableID(); s != nil {
+		out.StableId = s.Id
+	}
+
 	if api := c.API(); api != nil {
 		out.API = &path.API{ID: path.NewID(id.ID(api.ID()))}
 	}
@@ -44,6 +48,7 @@ func CmdToService(c Cmd) (*Command, error) {
 		param := &Parameter{
 			Name:  p.Name,
 			Value: box.NewValue(p.Get()),
+			Units: p.Units,
 			Type: &path.Type{
 				TypeIndex: t,
 				API:       out.API,
@@ -59,6 +64,7 @@ func CmdToService(c Cmd) (*Command, error) {
 		out.Result = &Parameter{
 			Name:  p.Name,
 			Value: box.NewValue(p.Get()),
+			Units: p.Units,
 		}
 		if p.Constants >= 0 {
 			out.Result.Constants = out.API.ConstantSet(p.Constants)
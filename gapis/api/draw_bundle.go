@@ -0,0 +1,31 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// DrawBundleProvider is the interface implemented by APIs that can extract the
+// state used by a single draw call.
+type DrawBundleProvider interface {
+	// DrawBundle returns the shaders, buffers, images and push constants used
+	// by the draw call o at p. The Command and Pipelines of the bundle are
+	// populated by the caller.
+	// If nil, nil then o is not a draw call.
+	DrawBundle(ctx context.Context, o interface{}, p *path.DrawBundle, r *path.ResolveConfig) (*DrawBundle, error)
+}
@@ -0,0 +1,176 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// FrameGraphProvider is the interface implemented by APIs that can describe
+// the passes of a frame, and the resources they access.
+type FrameGraphProvider interface {
+	// AddFrameGraphPasses mutates the command cmd with the state s, adding
+	// the passes executed by the command, or by any subcommands it executes,
+	// to b. The Capture of each pass's command path is left nil.
+	AddFrameGraphPasses(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState, b *FrameGraphBuilder) error
+}
+
+// Reads returns true if the access mode reads the resource.
+func (m FrameGraphAccess_Mode) Reads() bool {
+	return m == FrameGraphAccess_READ || m == FrameGraphAccess_READ_WRITE
+}
+
+// Writes returns true if the access mode writes the resource.
+func (m FrameGraphAccess_Mode) Writes() bool {
+	return m == FrameGraphAccess_WRITE || m == FrameGraphAccess_READ_WRITE
+}
+
+// Access adds an access of the resource with the given index to the pass,
+// combining it with any existing access of the same resource.
+func (p *FrameGraphPass) Access(resource uint32, mode FrameGraphAccess_Mode) {
+	for _, a := range p.Accesses {
+		if a.Resource == resource {
+			if a.Mode != mode {
+				a.Mode = FrameGraphAccess_READ_WRITE
+			}
+			return
+		}
+	}
+	p.Accesses = append(p.Accesses, &FrameGraphAccess{Resource: resource, Mode: mode})
+}
+
+type frameGraphResourceKey struct {
+	kind   FrameGraphResource_Kind
+	handle uint64
+}
+
+// frameGraphResourceState is the state of a single resource while building a
+// FrameGraph.
+type frameGraphResourceState struct {
+	// The index of the pass that last wrote the resource, or -1.
+	writer int
+	// The passes that read the resource since it was last written.
+	readers []uint32
+	// True if a barrier on the resource was recorded since it was last
+	// written.
+	barrier bool
+}
+
+// FrameGraphBuilder builds a FrameGraph from the passes of a frame, in
+// submission order. Each pass that reads a resource depends on the last pass
+// to write it, and each pass that writes a resource depends on the passes
+// that read it since, or otherwise on the last pass to write it.
+type FrameGraphBuilder struct {
+	out     *FrameGraph
+	indices map[frameGraphResourceKey]uint32
+	states  []*frameGraphResourceState
+	edges   map[frameGraphEdgeKey]struct{}
+}
+
+type frameGraphEdgeKey struct {
+	from, to, resource uint32
+}
+
+// NewFrameGraphBuilder returns a new, empty, FrameGraphBuilder.
+func NewFrameGraphBuilder() *FrameGraphBuilder {
+	return &FrameGraphBuilder{
+		out: &FrameGraph{
+			Passes:    []*FrameGraphPass{},
+			Resources: []*FrameGraphResource{},
+			Edges:     []*FrameGraphEdge{},
+		},
+		indices: map[frameGraphResourceKey]uint32{},
+		edges:   map[frameGraphEdgeKey]struct{}{},
+	}
+}
+
+// Resource returns the index of the resource with the given kind and handle,
+// adding it to the graph with the given label if it is not yet known.
+func (b *FrameGraphBuilder) Resource(kind FrameGraphResource_Kind, handle uint64, label string) uint32 {
+	key := frameGraphResourceKey{kind, handle}
+	if i, ok := b.indices[key]; ok {
+		return i
+	}
+	i := uint32(len(b.out.Resources))
+	b.indices[key] = i
+	b.out.Resources = append(b.out.Resources, &FrameGraphResource{
+		Kind:   kind,
+		Handle: handle,
+		Label:  label,
+	})
+	b.states = append(b.states, &frameGraphResourceState{writer: -1})
+	return i
+}
+
+// Barrier records a barrier on the resource with the given kind and handle.
+// Barriers on resources not yet accessed by any pass are ignored.
+func (b *FrameGraphBuilder) Barrier(kind FrameGraphResource_Kind, handle uint64) {
+	if i, ok := b.indices[frameGraphResourceKey{kind, handle}]; ok {
+		b.states[i].barrier = true
+	}
+}
+
+// BarrierAll records a barrier on all the resources accessed so far.
+func (b *FrameGraphBuilder) BarrierAll() {
+	for _, s := range b.states {
+		s.barrier = true
+	}
+}
+
+// AddPass adds the pass p to the graph, along with the edges to the passes it
+// depends on.
+func (b *FrameGraphBuilder) AddPass(p *FrameGraphPass) {
+	to := uint32(len(b.out.Passes))
+	b.out.Passes = append(b.out.Passes, p)
+	for _, a := range p.Accesses {
+		s := b.states[a.Resource]
+		if a.Mode.Reads() && s.writer >= 0 {
+			b.edge(uint32(s.writer), to, a, s.barrier)
+		}
+		if !a.Mode.Writes() {
+			s.readers = append(s.readers, to)
+			continue
+		}
+		if len(s.readers) > 0 {
+			for _, from := range s.readers {
+				b.edge(from, to, a, s.barrier)
+			}
+		} else if s.writer >= 0 {
+			b.edge(uint32(s.writer), to, a, s.barrier)
+		}
+		s.writer, s.readers, s.barrier = int(to), nil, false
+	}
+}
+
+func (b *FrameGraphBuilder) edge(from, to uint32, a *FrameGraphAccess, barrier bool) {
+	if from == to {
+		return
+	}
+	key := frameGraphEdgeKey{from, to, a.Resource}
+	if _, ok := b.edges[key]; ok {
+		return
+	}
+	b.edges[key] = struct{}{}
+	b.out.Edges = append(b.out.Edges, &FrameGraphEdge{
+		From:     from,
+		To:       to,
+		Resource: a.Resource,
+		Mode:     a.Mode,
+		Barrier:  barrier,
+	})
+}
+
+// Graph returns the graph built so far.
+func (b *FrameGraphBuilder) Graph() *FrameGraph {
+	return b.out
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+func TestFrameGraphBuilder(t *testing.T) {
+	ctx := log.Testing(t)
+	b := api.NewFrameGraphBuilder()
+	shadow := b.Resource(api.FrameGraphResource_IMAGE, 1, "shadow")
+	color := b.Resource(api.FrameGraphResource_IMAGE, 2, "color")
+	particles := b.Resource(api.FrameGraphResource_BUFFER, 3, "particles")
+	assert.For(ctx, "existing resource").That(b.Resource(api.FrameGraphResource_IMAGE, 1, "")).Equals(shadow)
+
+	pass := func(ty api.FrameGraphPass_Type, accesses ...interface{}) {
+		p := &api.FrameGraphPass{Type: ty}
+		for i := 0; i < len(accesses); i += 2 {
+			p.Access(accesses[i].(uint32), accesses[i+1].(api.FrameGraphAccess_Mode))
+		}
+		b.AddPass(p)
+	}
+
+	// 0: simulate the particles.
+	pass(api.FrameGraphPass_COMPUTE, particles, api.FrameGraphAccess_READ, particles, api.FrameGraphAccess_WRITE)
+	// 1: render the shadow map.
+	pass(api.FrameGraphPass_RENDER_PASS, shadow, api.FrameGraphAccess_WRITE)
+	b.Barrier(api.FrameGraphResource_IMAGE, 1)
+	b.Barrier(api.FrameGraphResource_IMAGE, 100)
+	// 2: render the scene.
+	pass(api.FrameGraphPass_RENDER_PASS,
+		shadow, api.FrameGraphAccess_READ,
+		particles, api.FrameGraphAccess_READ,
+		color, api.FrameGraphAccess_WRITE)
+	// 3: render the next shadow map.
+	pass(api.FrameGraphPass_RENDER_PASS, shadow, api.FrameGraphAccess_WRITE)
+
+	g := b.Graph()
+	assert.For(ctx, "passes").That(len(g.Passes)).Equals(4)
+	assert.For(ctx, "merged access").That(g.Passes[0].Accesses).DeepEquals([]*api.FrameGraphAccess{
+		{Resource: particles, Mode: api.FrameGraphAccess_READ_WRITE},
+	})
+	assert.For(ctx, "edges").That(g.Edges).DeepEquals([]*api.FrameGraphEdge{
+		{From: 1, To: 2, Resource: shadow, Mode: api.FrameGraphAccess_READ, Barrier: true},
+		{From: 0, To: 2, Resource: particles, Mode: api.FrameGraphAccess_READ},
+		{From: 2, To: 3, Resource: shadow, Mode: api.FrameGraphAccess_WRITE, Barrier: true},
+	})
+}
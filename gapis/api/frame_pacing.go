@@ -0,0 +1,27 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// PresentAnalyzer is the interface implemented by APIs that can report the
+// swapchain images acquired and presented by their commands.
+type PresentAnalyzer interface {
+	// AnalyzePresents mutates the command cmd with the state s, calling acquire
+	// for each swapchain image the command acquires, and present for each
+	// swapchain image the command presents. The presents only describe the
+	// swapchain and its image, leaving the commands and timing for the caller.
+	AnalyzePresents(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState, acquire func(swapchain uint64, image uint32), present func(p *SwapchainPresent)) error
+}
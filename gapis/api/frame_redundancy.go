@@ -0,0 +1,29 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+// FrameRedundancyClassifier is the interface implemented by APIs that can
+// classify their commands for the analysis of the work repeated by
+// consecutive frames.
+type FrameRedundancyClassifier interface {
+	// IsBinding returns true if cmd binds a resource, or pipeline state
+	// object, for use by subsequent commands.
+	IsBinding(cmd Cmd) bool
+
+	// RecordsInto returns the handle of the command buffer that cmd records
+	// into, and whether cmd begins a new recording of the command buffer.
+	// ok is false if cmd does not record into a command buffer.
+	RecordsInto(cmd Cmd) (buffer uint64, begin, ok bool)
+}
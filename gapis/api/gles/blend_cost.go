@@ -0,0 +1,50 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.BlendAnalyzer(API{})
+)
+
+// AnalyzeBlending implements api.BlendAnalyzer.
+func (API) AnalyzeBlending(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState, draw func(indices []uint64, blended bool)) error {
+	if err := cmd.Mutate(ctx, id, s, nil, nil); err != nil {
+		return err
+	}
+	if !cmd.CmdFlags(ctx, id, s).IsDrawCall() {
+		return nil
+	}
+	c := GetContext(s, cmd.Thread())
+	if c.IsNil() {
+		return nil
+	}
+
+	blended := false
+	for _, b := range c.Pixel().Blend().All() {
+		if b.Enabled() == GLboolean_GL_TRUE {
+			blended = true
+			break
+		}
+	}
+	draw([]uint64{uint64(id)}, blended)
+	return nil
+}
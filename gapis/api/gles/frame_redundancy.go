@@ -0,0 +1,50 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import "github.com/google/gapid/gapis/api"
+
+// Interface compliance test
+var (
+	_ = api.FrameRedundancyClassifier(API{})
+)
+
+// IsBinding implements api.FrameRedundancyClassifier.
+func (API) IsBinding(cmd api.Cmd) bool {
+	switch cmd.(type) {
+	case *GlBindBuffer,
+		*GlBindBufferBase,
+		*GlBindBufferRange,
+		*GlBindFramebuffer,
+		*GlBindImageTexture,
+		*GlBindProgramPipeline,
+		*GlBindRenderbuffer,
+		*GlBindSampler,
+		*GlBindTexture,
+		*GlBindTransformFeedback,
+		*GlBindVertexArray,
+		*GlBindVertexArrayOES,
+		*GlBindVertexBuffer,
+		*GlUseProgram:
+		return true
+	}
+	return false
+}
+
+// RecordsInto implements api.FrameRedundancyClassifier.
+// OpenGL ES has no command buffers.
+func (API) RecordsInto(cmd api.Cmd) (buffer uint64, begin, ok bool) {
+	return 0, false, false
+}
@@ -30,12 +30,14 @@ import (
 	"github.com/google/gapid/gapis/replay"
 	"github.com/google/gapid/gapis/resolve/dependencygraph"
 	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
 )
 
 var (
 	// Interface compliance tests
 	_ = replay.QueryIssues(API{})
 	_ = replay.QueryFramebufferAttachment(API{})
+	_ = replay.QueryShaderInputs(API{})
 	_ = replay.Support(API{})
 	_ = replay.Profiler(API{})
 )
@@ -74,6 +76,14 @@ type framebufferRequest struct {
 type profileRequest struct {
 }
 
+// shaderInputsRequest requests a postback of the inputs of a single vertex or
+// fragment shader invocation of the draw call at draw.
+type shaderInputsRequest struct {
+	draw   api.CmdID
+	vertex uint64
+	pixel  *path.ShaderInputs_Position
+}
+
 // GetReplayPriority returns a uint32 representing the preference for
 // replaying this trace on the given device.
 // A lower number represents a higher priority, and zero represents
@@ -134,6 +144,9 @@ func (a API) Replay(
 	if dependentPayload != "" {
 		return log.Errf(ctx, nil, "GLES does not support dependent payloads")
 	}
+	if len(replay.GetRewrites(ctx).GetRewrites()) > 0 {
+		return log.Errf(ctx, nil, "GLES does not support replay rewrites")
+	}
 	if a.GetReplayPriority(ctx, device, capture.Header) == 0 {
 		return log.Errf(ctx, nil, "Cannot replay GLES commands on device '%v'", device.Name)
 	}
@@ -159,9 +172,17 @@ func (a API) Replay(
 	var rt *readTexture     // Transform for all texture reads.
 
 	var wire transform.Transformer
+	var inputs transform.Transformer
 
 	transforms := transform.Transforms{deadCodeElimination}
 
+	// The commands with state edits, and the commands the edits depend on,
+	// are not known to the dependency graph, so nothing is eliminated.
+	if hasStateEdits(cmds) {
+		deadCodeElimination.KeepAllAlive = true
+		transforms.Add(stateEdits())
+	}
+
 	onCompatError := func(ctx context.Context, id api.CmdID, cmd api.Cmd, err error) {
 		ctx = log.Enter(ctx, "Compat")
 		log.E(ctx, "%v: %v - %v", id, cmd, err)
@@ -217,8 +238,16 @@ func (a API) Replay(
 				wire = wireframeOverlay(ctx, req.after)
 			case service.DrawMode_OVERDRAW:
 				return fmt.Errorf("Overdraw is not currently supported for GLES")
+			case service.DrawMode_TEXTURE_SAMPLES:
+				wire = textureSamples(ctx, req.after)
+			case service.DrawMode_TEXTURE_SAMPLES_ALL:
+				wire = textureSamplesAll(ctx)
 			}
 
+		case shaderInputsRequest:
+			deadCodeElimination.Request(req.draw)
+			inputs = captureShaderInputs(ctx, req, rr.Result)
+
 		case profileRequest:
 			if profile == nil {
 				profile = &replay.EndOfReplay{}
@@ -231,6 +260,10 @@ func (a API) Replay(
 		transforms.Add(wire)
 	}
 
+	if inputs != nil {
+		transforms.Add(inputs)
+	}
+
 	if issues != nil {
 		transforms.Add(issues) // Issue reporting required.
 	}
@@ -354,6 +387,24 @@ func (a API) QueryFramebufferAttachment(
 	return res.(*image.Data), nil
 }
 
+func (a API) QueryShaderInputs(
+	ctx context.Context,
+	intent replay.Intent,
+	mgr replay.Manager,
+	draw api.CmdID,
+	vertex uint64,
+	pixel *path.ShaderInputs_Position,
+	hints *service.UsageHints) (*service.ShaderInputs, error) {
+
+	c := uniqueConfig()
+	r := shaderInputsRequest{draw: draw, vertex: vertex, pixel: pixel}
+	res, err := mgr.Replay(ctx, intent, c, r, a, hints, true)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*service.ShaderInputs), nil
+}
+
 func (a API) Profile(
 	ctx context.Context,
 	intent replay.Intent,
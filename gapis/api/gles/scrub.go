@@ -0,0 +1,126 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.Scrubber(API{})
+)
+
+// ScrubbableReads implements api.Scrubber.
+// The commands that upload texture or buffer data, that draw from client-side
+// vertex and index arrays, or that label objects and debug groups, only read
+// the data or the label from application memory, so all of their reads are
+// scrubbable. Of the reads of glShaderSource, the source strings are
+// scrubbable, but not the arrays of pointers to, and lengths of, the strings.
+func (API) ScrubbableReads(ctx context.Context, cmd api.Cmd, s *api.GlobalState) []api.ScrubbableRead {
+	var kind api.ScrubKind
+	switch cmd := cmd.(type) {
+	case *GlBufferData,
+		*GlBufferSubData,
+		*GlCompressedTexImage2D,
+		*GlCompressedTexImage3D,
+		*GlCompressedTexImage3DOES,
+		*GlCompressedTexSubImage2D,
+		*GlCompressedTexSubImage3D,
+		*GlCompressedTexSubImage3DOES,
+		*GlTexImage2D,
+		*GlTexImage3D,
+		*GlTexImage3DOES,
+		*GlTexSubImage2D,
+		*GlTexSubImage3D,
+		*GlTexSubImage3DOES,
+		*GlDrawArrays,
+		*GlDrawArraysInstanced,
+		*GlDrawArraysInstancedANGLE,
+		*GlDrawArraysInstancedBaseInstanceEXT,
+		*GlDrawArraysInstancedEXT,
+		*GlDrawArraysInstancedNV,
+		*GlDrawElements,
+		*GlDrawElementsBaseVertex,
+		*GlDrawElementsBaseVertexEXT,
+		*GlDrawElementsBaseVertexOES,
+		*GlDrawElementsInstanced,
+		*GlDrawElementsInstancedANGLE,
+		*GlDrawElementsInstancedBaseInstanceEXT,
+		*GlDrawElementsInstancedBaseVertex,
+		*GlDrawElementsInstancedBaseVertexBaseInstanceEXT,
+		*GlDrawElementsInstancedBaseVertexEXT,
+		*GlDrawElementsInstancedBaseVertexOES,
+		*GlDrawElementsInstancedEXT,
+		*GlDrawElementsInstancedNV,
+		*GlDrawRangeElements,
+		*GlDrawRangeElementsBaseVertex,
+		*GlDrawRangeElementsBaseVertexEXT,
+		*GlDrawRangeElementsBaseVertexOES:
+		kind = api.ScrubAsset
+	case *GlDebugMessageInsert,
+		*GlDebugMessageInsertKHR,
+		*GlInsertEventMarkerEXT,
+		*GlLabelObjectEXT,
+		*GlObjectLabel,
+		*GlObjectLabelKHR,
+		*GlObjectPtrLabel,
+		*GlObjectPtrLabelKHR,
+		*GlPushDebugGroup,
+		*GlPushDebugGroupKHR,
+		*GlPushGroupMarkerEXT:
+		kind = api.ScrubName
+	case *GlShaderSource:
+		return shaderSourceReads(ctx, cmd, s)
+	default:
+		return nil
+	}
+	o := cmd.Extras().Observations()
+	if o == nil {
+		return nil
+	}
+	out := make([]api.ScrubbableRead, len(o.Reads))
+	for i := range o.Reads {
+		out[i] = api.ScrubbableRead{Index: i, Kind: kind}
+	}
+	return out
+}
+
+// shaderSourceReads returns the reads of the source strings of cmd.
+func shaderSourceReads(ctx context.Context, cmd *GlShaderSource, s *api.GlobalState) []api.ScrubbableRead {
+	o := cmd.Extras().Observations()
+	if o == nil || cmd.Count() <= 0 {
+		return nil
+	}
+	sources, err := cmd.Source().Slice(0, uint64(cmd.Count()), s.MemoryLayout).Read(ctx, cmd, s, nil)
+	if err != nil {
+		return nil
+	}
+	strings := map[uint64]bool{}
+	for _, src := range sources {
+		strings[src.Address()] = true
+	}
+	delete(strings, 0)
+
+	out := []api.ScrubbableRead{}
+	for i, read := range o.Reads {
+		if strings[read.Range.Base] {
+			out = append(out, api.ScrubbableRead{Index: i, Kind: api.ScrubShader})
+		}
+	}
+	return out
+}
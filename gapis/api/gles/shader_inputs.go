@@ -0,0 +1,473 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/transform"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/replay/builder"
+	"github.com/google/gapid/gapis/replay/value"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+)
+
+// shaderInputsPerPass is the number of scalar components captured by each
+// replay of the draw call. The first component of the uvec4 written by the
+// instrumented shaders marks that the pixel was written.
+const shaderInputsPerPass = 3
+
+// stageVariable matches the declaration of a stage input or output of a
+// normalized shader, capturing the direction, the type, the name and the
+// array length.
+var stageVariable = regexp.MustCompile(
+	`(?m)^(?:layout\s*\([^)]*\)\s*)?(?:(?:flat|smooth|centroid|invariant)\s+)*(in|out)\s+(?:(?:lowp|mediump|highp)\s+)?(\w+)\s+(\w+)\s*(?:\[\s*(\d+)\s*\])?\s*;`)
+
+// shaderVariable is a variable of a shader invocation to capture.
+type shaderVariable struct {
+	name string
+	ty   string
+	size int // The array length, or 0 if the variable is not an array.
+	kind service.ShaderInputKind
+}
+
+// typeName returns the GLSL type of the variable, including its array length.
+func (v shaderVariable) typeName() string {
+	if v.size > 0 {
+		return fmt.Sprintf("%v[%d]", v.ty, v.size)
+	}
+	return v.ty
+}
+
+// components returns the GLSL expressions converting each scalar component of
+// the variable, or of each of its elements in order if it is an array, to a
+// uint, or nil if the type of the variable is not supported.
+func (v shaderVariable) components() []string {
+	if v.size > 0 {
+		out := []string{}
+		for i := 0; i < v.size; i++ {
+			el := shaderVariable{name: fmt.Sprintf("%v[%d]", v.name, i), ty: v.ty, kind: v.kind}
+			c := el.components()
+			if c == nil {
+				return nil
+			}
+			out = append(out, c...)
+		}
+		return out
+	}
+
+	base, n := v.ty, 1
+	if i := strings.Index(v.ty, "vec"); i >= 0 {
+		c, err := strconv.Atoi(v.ty[i+3:])
+		if err != nil {
+			return nil
+		}
+		base, n = map[string]string{"": "float", "i": "int", "u": "uint", "b": "bool"}[v.ty[:i]], c
+	}
+
+	var expr string
+	switch base {
+	case "float":
+		expr = fmt.Sprintf("floatBitsToUint(%v)", v.name)
+	case "uint":
+		expr = v.name
+	case "int", "bool":
+		if n == 1 {
+			expr = fmt.Sprintf("uint(%v)", v.name)
+		} else {
+			expr = fmt.Sprintf("uvec%d(%v)", n, v.name)
+		}
+	default:
+		return nil
+	}
+	if n == 1 {
+		return []string{expr}
+	}
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprintf("%v.%c", expr, "xyzw"[i])
+	}
+	return out
+}
+
+// decode returns the value of the variable from the captured components.
+func (v shaderVariable) decode(words []uint32) interface{} {
+	switch {
+	case v.ty == "float" || strings.HasPrefix(v.ty, "vec"):
+		out := make([]float32, len(words))
+		for i, w := range words {
+			out[i] = math.Float32frombits(w)
+		}
+		return out
+	case v.ty == "int" || strings.HasPrefix(v.ty, "ivec"):
+		out := make([]int32, len(words))
+		for i, w := range words {
+			out[i] = int32(w)
+		}
+		return out
+	case v.ty == "bool" || strings.HasPrefix(v.ty, "bvec"):
+		out := make([]bool, len(words))
+		for i, w := range words {
+			out[i] = w != 0
+		}
+		return out
+	default:
+		return words
+	}
+}
+
+// stageVariables returns the variables declared by the normalized shader
+// source with the direction dir, which is either "in" or "out", as variables
+// of the given kind.
+func stageVariables(source, dir string, kind service.ShaderInputKind) []shaderVariable {
+	out := []shaderVariable{}
+	for _, m := range stageVariable.FindAllStringSubmatch(source, -1) {
+		if m[1] != dir {
+			continue
+		}
+		v := shaderVariable{name: m[3], ty: m[2], kind: kind}
+		if m[4] != "" {
+			v.size, _ = strconv.Atoi(m[4])
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// uniformElementType returns the type of the elements of the value of a
+// uniform of the GLSL type ty, as returned by glslTypeFor.
+func uniformElementType(ty string) api.UniformType {
+	switch {
+	case strings.Contains(ty, "sampler"),
+		strings.HasPrefix(ty, "unsigned"), strings.HasPrefix(ty, "uvec"):
+		return api.UniformType_Uint32
+	case strings.HasPrefix(ty, "int"), strings.HasPrefix(ty, "ivec"):
+		return api.UniformType_Int32
+	case strings.HasPrefix(ty, "bool"), strings.HasPrefix(ty, "bvec"):
+		return api.UniformType_Bool
+	default:
+		return api.UniformType_Float
+	}
+}
+
+// pointsDrawCall returns a copy of the draw call drawing its vertices as
+// points, so that each vertex is drawn on its own, with the same vertex
+// indices and instances.
+func pointsDrawCall(a arena.Arena, dc drawCall) (api.Cmd, error) {
+	cmd := dc.Clone(a)
+	for _, name := range []string{"draw_mode", "mode"} {
+		if err := api.SetParameter(cmd, name, GLenum_GL_POINTS); err == nil {
+			return cmd, nil
+		}
+	}
+	return nil, fmt.Errorf("The vertices of %v cannot be drawn as points", dc.CmdName())
+}
+
+// captureShaderInputs returns a command transform that captures the inputs of
+// the shader invocation requested by req, and posts them to res.
+func captureShaderInputs(ctx context.Context, req shaderInputsRequest, res replay.Result) transform.Transformer {
+	ctx = log.Enter(ctx, "ShaderInputs")
+	return transform.Transform("ShaderInputs", func(ctx context.Context, id api.CmdID, cmd api.Cmd, out transform.Writer) error {
+		if id != req.draw {
+			return out.MutateAndWrite(ctx, id, cmd)
+		}
+		dc, ok := cmd.(drawCall)
+		if !ok {
+			res(nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()})
+			return out.MutateAndWrite(ctx, id, cmd)
+		}
+		if err := drawShaderInputs(ctx, id, dc, req, out, res); err != nil {
+			log.E(ctx, "Unable to capture the shader inputs of draw call %v: %v", id, err)
+			res(nil, err)
+		}
+		return out.MutateAndWrite(ctx, id, cmd)
+	})
+}
+
+// drawShaderInputs replays the draw call with copies of the bound program
+// whose shaders write the inputs of the requested invocation, three scalar
+// components at a time, to an RGBA32UI renderbuffer, which is read back after
+// each replay.
+//
+// Only programs of GLSL ES 3.00 or later vertex and fragment shaders are
+// supported, and stage variables of matrix and struct types are not captured.
+// The fragment captured is the last one drawn at the pixel, as the depth and
+// stencil tests are disabled. The vertex captured is that of the first
+// instance, drawn as a point.
+func drawShaderInputs(ctx context.Context, id api.CmdID, dc drawCall, req shaderInputsRequest, out transform.Writer, res replay.Result) error {
+	s := out.State()
+	c := GetContext(s, dc.Thread())
+	if c.IsNil() {
+		return fmt.Errorf("No OpenGL ES context")
+	}
+	p := c.Bound().Program()
+	if p.IsNil() {
+		return &service.ErrDataUnavailable{Reason: messages.ErrNoProgramBound()}
+	}
+	if p.SuccessfulLinkExtra().IsNil() {
+		return &service.ErrDataUnavailable{Reason: messages.ErrProgramNotLinked()}
+	}
+	extra := p.SuccessfulLinkExtra()
+	vs := extra.Shaders().Get(GLenum_GL_VERTEX_SHADER)
+	fs := extra.Shaders().Get(GLenum_GL_FRAGMENT_SHADER)
+	if vs.IsNil() || fs.IsNil() || extra.Shaders().Len() != 2 {
+		return fmt.Errorf("The program is not made of a vertex and a fragment shader")
+	}
+	version := shaderVersion(vs.Source())
+	if version < 300 {
+		return fmt.Errorf("Shader inputs can only be captured for GLSL ES 3.00 shaders or later")
+	}
+	nvs, err := normalizeShader(vs.Source(), GLenum_GL_VERTEX_SHADER, version)
+	if err != nil {
+		return err
+	}
+	nfs, err := normalizeShader(fs.Source(), GLenum_GL_FRAGMENT_SHADER, version)
+	if err != nil {
+		return err
+	}
+
+	var vars []shaderVariable
+	var draw api.Cmd = dc
+	x, y := uint32(0), uint32(0)
+	source := vs.Source()
+	if req.pixel != nil {
+		x, y, source = req.pixel.X, req.pixel.Y, fs.Source()
+		vars = append(stageVariables(nfs, "in", service.ShaderInputKind_VaryingInput),
+			shaderVariable{name: "gl_FragCoord", ty: "vec4", kind: service.ShaderInputKind_BuiltinInput},
+			shaderVariable{name: "gl_FrontFacing", ty: "bool", kind: service.ShaderInputKind_BuiltinInput})
+	} else {
+		if draw, err = pointsDrawCall(s.Arena, dc); err != nil {
+			return err
+		}
+		vars = append(stageVariables(nvs, "in", service.ShaderInputKind_AttributeInput),
+			stageVariables(nvs, "out", service.ShaderInputKind_VaryingInput)...)
+		vars = append(vars,
+			shaderVariable{name: "gl_VertexID", ty: "int", kind: service.ShaderInputKind_BuiltinInput},
+			shaderVariable{name: "gl_InstanceID", ty: "int", kind: service.ShaderInputKind_BuiltinInput},
+			shaderVariable{name: "gl_Position", ty: "vec4", kind: service.ShaderInputKind_BuiltinInput})
+	}
+
+	captured, components := []shaderVariable{}, []string{}
+	for _, v := range vars {
+		comps := v.components()
+		if comps == nil {
+			log.W(ctx, "Shader variable %v of type %v cannot be captured", v.name, v.typeName())
+			continue
+		}
+		captured = append(captured, v)
+		components = append(components, comps...)
+	}
+
+	uniforms := []*service.ShaderInput{}
+	for _, k := range p.ActiveResources().DefaultUniformBlock().Keys() {
+		u := p.ActiveResources().DefaultUniformBlock().Get(k)
+		ty, err := glslTypeFor(u.Type())
+		if err != nil {
+			ty = u.Type().String()
+		}
+		elTy := uniformElementType(ty)
+		if u.ArraySize() > 1 {
+			ty = fmt.Sprintf("%v[%d]", ty, u.ArraySize())
+		}
+		uniforms = append(uniforms, &service.ShaderInput{
+			Name:  u.Name(),
+			Kind:  service.ShaderInputKind_UniformInput,
+			Type:  ty,
+			Value: box.NewValue(uniformValue(ctx, s, elTy, u.Value())),
+		})
+	}
+
+	passes := (len(components) + shaderInputsPerPass - 1) / shaderInputsPerPass
+	if passes == 0 {
+		passes = 1
+	}
+	// Instrument the shaders of every pass before changing any state, so
+	// that the draw call is left unmodified if any fails to compile.
+	type pass struct{ vs, fs string }
+	instrumented := make([]pass, passes)
+	for i := range instrumented {
+		capture := []string{"1u"}
+		for c := i * shaderInputsPerPass; c < (i+1)*shaderInputsPerPass; c++ {
+			if c < len(components) {
+				capture = append(capture, components[c])
+			} else {
+				capture = append(capture, "0u")
+			}
+		}
+		vss, fss, err := instrumentShaderInputs(nvs, nfs, version, req.pixel != nil, req.vertex, strings.Join(capture, ", "))
+		if err != nil {
+			return err
+		}
+		instrumented[i] = pass{vss, fss}
+	}
+
+	cb := CommandBuilder{Thread: dc.Thread(), Arena: s.Arena}
+	t := newTweaker(out, id.Derived(), cb)
+	defer t.revert(ctx)
+
+	// Render to an RGBA32UI renderbuffer just large enough to hold the pixel.
+	rb := t.glGenRenderbuffer(ctx)
+	t.glBindRenderbuffer(ctx, rb)
+	out.MutateAndWrite(ctx, t.dID, cb.GlRenderbufferStorage(GLenum_GL_RENDERBUFFER, GLenum_GL_RGBA32UI, GLsizei(x+1), GLsizei(y+1)))
+	fb := t.glGenFramebuffer(ctx)
+	t.glBindFramebuffer_Draw(ctx, fb)
+	t.glBindFramebuffer_Read(ctx, fb)
+	out.MutateAndWrite(ctx, t.dID, cb.GlFramebufferRenderbuffer(GLenum_GL_DRAW_FRAMEBUFFER, GLenum_GL_COLOR_ATTACHMENT0, GLenum_GL_RENDERBUFFER, rb))
+	t.glReadBuffer(ctx, GLenum_GL_COLOR_ATTACHMENT0)
+
+	t.glDisable(ctx, GLenum_GL_RASTERIZER_DISCARD)
+	t.glDisable(ctx, GLenum_GL_DEPTH_TEST)
+	t.glDisable(ctx, GLenum_GL_STENCIL_TEST)
+	t.glEnable(ctx, GLenum_GL_SCISSOR_TEST)
+	t.glScissor(ctx, GLint(x), GLint(y), 1, 1)
+	t.glColorMask(ctx, GLboolean_GL_TRUE, GLboolean_GL_TRUE, GLboolean_GL_TRUE, GLboolean_GL_TRUE)
+	if req.pixel == nil {
+		t.glViewport(ctx, 0, 0, 1, 1)
+	}
+	t.setPackStorage(ctx, NewPixelStorageState(s.Arena,
+		0, // ImageHeight
+		0, // SkipImages
+		0, // RowLength
+		0, // SkipRows
+		0, // SkipPixels
+		1, // Alignment
+	), 0)
+	zero := t.AllocData(ctx, []GLuint{0, 0, 0, 0})
+
+	notDrawn := messages.ErrNoFragmentAtPixel()
+	if req.pixel == nil {
+		notDrawn = messages.ErrVertexNotDrawn()
+	}
+	words := make([]uint32, 0, passes*shaderInputsPerPass)
+	var postErr error
+	for i, sources := range instrumented {
+		t.useProgramCopy(ctx, p, sources.vs, sources.fs)
+
+		out.MutateAndWrite(ctx, t.dID, cb.GlClearBufferuiv(GLenum_GL_COLOR, 0, zero.Ptr()).AddRead(zero.Data()))
+		out.MutateAndWrite(ctx, id, draw)
+
+		last := i == passes-1
+		tmp := s.AllocOrPanic(ctx, 16)
+		out.MutateAndWrite(ctx, t.dID, cb.Custom(func(ctx context.Context, s *api.GlobalState, b *builder.Builder) error {
+			b.ReserveMemory(tmp.Range())
+			cb.GlReadPixels(GLint(x), GLint(y), 1, 1, GLenum_GL_RGBA_INTEGER, GLenum_GL_UNSIGNED_INT, tmp.Ptr()).
+				Call(ctx, s, b)
+			b.Post(value.ObservedPointer(tmp.Address()), 16, func(r binary.Reader, err error) {
+				if err == nil {
+					if r.Uint32() == 0 && postErr == nil {
+						postErr = &service.ErrDataUnavailable{Reason: notDrawn}
+					}
+					for i := 0; i < shaderInputsPerPass; i++ {
+						words = append(words, r.Uint32())
+					}
+					err = r.Error()
+				}
+				if err != nil && postErr == nil {
+					postErr = err
+				}
+				if !last {
+					return
+				}
+				res.Do(func() (interface{}, error) {
+					if postErr != nil {
+						return nil, postErr
+					}
+					inputs := []*service.ShaderInput{}
+					for _, v := range captured {
+						n := len(v.components())
+						inputs = append(inputs, &service.ShaderInput{
+							Name:  v.name,
+							Kind:  v.kind,
+							Type:  v.typeName(),
+							Value: box.NewValue(v.decode(words[:n])),
+						})
+						words = words[n:]
+					}
+					return &service.ShaderInputs{
+						Inputs: append(inputs, uniforms...),
+						Source: source,
+					}, nil
+				})
+			})
+			return nil
+		}))
+		tmp.Free()
+	}
+	return nil
+}
+
+// instrumentShaderInputs returns the vertex and fragment shader sources of a
+// replay writing the GLSL expressions of capture, a comma separated list of
+// four uint expressions, to the first colour attachment. vs and fs are the
+// shaders of the program normalized as GLSL ES of the given version. If
+// fragment is true, the expressions are evaluated by the fragment shader after
+// its main function, otherwise by the vertex shader after its main function,
+// and only the vertex of the first instance with the given index is drawn, at
+// the origin, when the vertices are drawn as points.
+func instrumentShaderInputs(vs, fs string, version int, fragment bool, vertex uint64, capture string) (string, string, error) {
+	if fragment {
+		// The outputs of the fragment shader become globals, so that the
+		// capture is the only output.
+		fs = insertAfterDirectives(fragmentOutputsToGlobals(renameMain(fs, "gapid_main")), `
+layout(location = 0) out highp uvec4 gapid_capture;
+`) + fmt.Sprintf(`
+void main() {
+    gapid_main();
+    gapid_capture = uvec4(%v);
+}
+`, capture)
+	} else {
+		vs = insertAfterDirectives(renameMain(vs, "gapid_main"), `
+flat out highp uvec4 gapid_capture;
+`) + fmt.Sprintf(`
+void main() {
+    gapid_main();
+    gapid_capture = uvec4(%v);
+    if (gl_VertexID == %d && gl_InstanceID == 0) {
+        gl_Position = vec4(0.0, 0.0, 0.0, 1.0);
+    } else {
+        // Clip the other vertices.
+        gl_Position = vec4(2.0, 2.0, 2.0, 1.0);
+    }
+    gl_PointSize = 1.0;
+}
+`, capture, int32(vertex))
+		fs = fmt.Sprintf(`#version %d es
+flat in highp uvec4 gapid_capture;
+layout(location = 0) out highp uvec4 gapid_output;
+void main() {
+    gapid_output = gapid_capture;
+}
+`, version)
+	}
+	if err := checkShader(vs, GLenum_GL_VERTEX_SHADER); err != nil {
+		return "", "", err
+	}
+	if err := checkShader(fs, GLenum_GL_FRAGMENT_SHADER); err != nil {
+		return "", "", err
+	}
+	return vs, fs, nil
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+func TestShaderVariableComponents(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		v      shaderVariable
+		expect []string
+	}{
+		{shaderVariable{name: "f", ty: "float"}, []string{"floatBitsToUint(f)"}},
+		{shaderVariable{name: "v", ty: "vec2"}, []string{"floatBitsToUint(v).x", "floatBitsToUint(v).y"}},
+		{shaderVariable{name: "i", ty: "int"}, []string{"uint(i)"}},
+		{shaderVariable{name: "i", ty: "ivec2"}, []string{"uvec2(i).x", "uvec2(i).y"}},
+		{shaderVariable{name: "u", ty: "uint"}, []string{"u"}},
+		{shaderVariable{name: "b", ty: "bool"}, []string{"uint(b)"}},
+		{shaderVariable{name: "a", ty: "uint", size: 2}, []string{"a[0]", "a[1]"}},
+		{shaderVariable{name: "a", ty: "vec2", size: 2}, []string{
+			"floatBitsToUint(a[0]).x", "floatBitsToUint(a[0]).y",
+			"floatBitsToUint(a[1]).x", "floatBitsToUint(a[1]).y",
+		}},
+		{shaderVariable{name: "m", ty: "mat4"}, nil},
+		{shaderVariable{name: "s", ty: "Light"}, nil},
+		{shaderVariable{name: "s", ty: "Light", size: 2}, nil},
+	} {
+		assert.For(ctx, "%v %v", test.v.typeName(), test.v.name).ThatSlice(test.v.components()).Equals(test.expect)
+	}
+}
+
+func TestStageVariables(t *testing.T) {
+	ctx := log.Testing(t)
+	source := `#version 300 es
+
+layout(location = 0) in highp vec4 position;
+in highp vec2 uv[2];
+flat out mediump int index;
+centroid out highp vec2 texCoord;
+uniform highp mat4 transform;
+
+void main()
+{
+    gl_Position = transform * position;
+}
+`
+	assert.For(ctx, "in").ThatSlice(stageVariables(source, "in", service.ShaderInputKind_AttributeInput)).Equals([]shaderVariable{
+		{name: "position", ty: "vec4", kind: service.ShaderInputKind_AttributeInput},
+		{name: "uv", ty: "vec2", size: 2, kind: service.ShaderInputKind_AttributeInput},
+	})
+	assert.For(ctx, "out").ThatSlice(stageVariables(source, "out", service.ShaderInputKind_VaryingInput)).Equals([]shaderVariable{
+		{name: "index", ty: "int", kind: service.ShaderInputKind_VaryingInput},
+		{name: "texCoord", ty: "vec2", kind: service.ShaderInputKind_VaryingInput},
+	})
+}
+
+func TestInstrumentShaderInputs(t *testing.T) {
+	ctx := log.Testing(t)
+	vs, err := normalizeShader(`#version 300 es
+in vec4 position;
+out vec2 uv;
+// main() is renamed.
+void main() {
+    uv = position.xy;
+    gl_Position = position;
+}
+`, GLenum_GL_VERTEX_SHADER, 300)
+	if !assert.For(ctx, "vs").ThatError(err).Succeeded() {
+		return
+	}
+	fs, err := normalizeShader(`#version 300 es
+precision mediump float;
+in vec2 uv;
+layout(location = 0) out vec4 color;
+layout(location = 1) out vec4 extra[2];
+void main() {
+    color = vec4(uv, 0.0, 1.0);
+    extra[0] = color;
+    extra[1] = color;
+}
+`, GLenum_GL_FRAGMENT_SHADER, 300)
+	if !assert.For(ctx, "fs").ThatError(err).Succeeded() {
+		return
+	}
+
+	ivs, ifs, err := instrumentShaderInputs(vs, fs, 300, true, 0, "1u, floatBitsToUint(uv.x), floatBitsToUint(uv.y), 0u")
+	if assert.For(ctx, "fragment").ThatError(err).Succeeded() {
+		assert.For(ctx, "fragment vs").ThatString(ivs).Equals(vs)
+		assert.For(ctx, "fragment outputs").That(strings.Count(ifs, "out ")).Equals(1)
+		assert.For(ctx, "fragment main").ThatString(ifs).Contains("gapid_main();")
+	}
+
+	ivs, ifs, err = instrumentShaderInputs(vs, fs, 300, false, 7, "1u, floatBitsToUint(uv.x), floatBitsToUint(uv.y), 0u")
+	if assert.For(ctx, "vertex").ThatError(err).Succeeded() {
+		assert.For(ctx, "vertex index").ThatString(ivs).Contains("gl_VertexID == 7")
+		assert.For(ctx, "vertex main").ThatString(ivs).Contains("gapid_main();")
+		assert.For(ctx, "vertex comments").ThatString(ivs).DoesNotContain("main() is renamed")
+		assert.For(ctx, "vertex fs").ThatString(ifs).Contains("gapid_output = gapid_capture;")
+	}
+}
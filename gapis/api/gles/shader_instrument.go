@@ -0,0 +1,163 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/gapid/gapis/shadertools"
+	"github.com/google/gapid/gapis/shadertools/spirv"
+)
+
+// userFunctionPrefix is prepended by normalizeShader to the names of the
+// functions declared by a shader, so that they cannot be mistaken for the
+// built-in functions of GLSL, or clash with the names declared by the
+// instrumentation, which all start with "gapid_".
+const userFunctionPrefix = "user_"
+
+var (
+	// glslVersion matches the version directive of a shader.
+	glslVersion = regexp.MustCompile(`#version\s+(\d+)`)
+	// mainFunction matches the name of the entry point of a normalized shader.
+	mainFunction = regexp.MustCompile(`\bmain(\s*\()`)
+	// prefixedName matches a name prefixed by the shader tools with
+	// userFunctionPrefix, capturing the declared name.
+	prefixedName = regexp.MustCompile(`\b` + userFunctionPrefix + `(\w+)`)
+	// fragmentOutput matches the declaration of an output of a normalized
+	// fragment shader, capturing the type and name.
+	fragmentOutput = regexp.MustCompile(`(?m)^(?:layout\s*\([^)]*\)\s*)?out\s+([^;]+);`)
+)
+
+// shaderVersion returns the GLSL ES version of the shader source, which is
+// 100 if the source has no version directive.
+func shaderVersion(source string) int {
+	if m := glslVersion.FindStringSubmatch(source); m != nil {
+		if v, err := strconv.Atoi(m[1]); err == nil {
+			return v
+		}
+	}
+	return 100
+}
+
+// normalizeShader returns the GLSL ES source of the shader of type ty,
+// compiled and decompiled by the shader tools as GLSL ES of the given version,
+// so that it can be instrumented by matching its text: comments are removed,
+// macros expanded, declarations written one per line and the user-defined
+// functions renamed with userFunctionPrefix. The names of the variables,
+// which are visible to the API, are kept, except for the outputs replacing the
+// deprecated built-in variables of GLSL ES 1.00, such as gl_FragColor, which
+// keep the prefix.
+func normalizeShader(source string, ty GLenum, version int) (string, error) {
+	st, err := ty.ShaderType()
+	if err != nil {
+		return "", err
+	}
+	source = strings.TrimLeft(source, "\n\r\t ")
+	words, err := shadertools.CompileGlsl(source, shadertools.CompileOptions{
+		ShaderType: st,
+		ClientType: shadertools.OpenGLES,
+	})
+	if err != nil {
+		return "", err
+	}
+	m, err := spirv.Parse(words)
+	if err != nil {
+		return "", err
+	}
+	functions := map[string]bool{}
+	for _, inst := range m.Instructions {
+		if inst.Opcode == spirv.OpFunction {
+			// glslang names functions with their mangled signature.
+			name := m.Name(inst.Result())
+			if i := strings.IndexByte(name, '('); i >= 0 {
+				name = name[:i]
+			}
+			functions[name] = true
+		}
+	}
+
+	res, err := shadertools.ConvertGlsl(source, &shadertools.ConvertOptions{
+		ShaderType:        st,
+		TargetGLSLVersion: version,
+		TargetES:          true,
+		PrefixNames:       true,
+		NamesPrefix:       userFunctionPrefix,
+		Relaxed:           true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// The shader tools prefix every declared name, so remove the prefix from
+	// all but the function names. Functions with the same name may be renamed
+	// with a numeric suffix.
+	return prefixedName.ReplaceAllStringFunc(res.SourceCode, func(prefixed string) string {
+		name := strings.TrimPrefix(prefixed, userFunctionPrefix)
+		base := name
+		if i := strings.LastIndexByte(name, '_'); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				base = name[:i]
+			}
+		}
+		if functions[name] || functions[base] || strings.HasPrefix(name, "gl_") {
+			return prefixed
+		}
+		return name
+	}), nil
+}
+
+// renameMain returns the normalized shader source with its entry point
+// renamed to name, so that the instrumentation can declare a main function
+// calling it.
+func renameMain(source, name string) string {
+	return mainFunction.ReplaceAllString(source, name+"$1")
+}
+
+// fragmentOutputsToGlobals returns the normalized fragment shader source with
+// its outputs declared as global variables instead, so that the
+// instrumentation can declare its own outputs. The shader can still assign
+// the variables, whatever their type.
+func fragmentOutputsToGlobals(source string) string {
+	return fragmentOutput.ReplaceAllString(source, "$1;")
+}
+
+// insertAfterDirectives returns the shader source with text inserted after
+// the version and extension directives, where declarations can start.
+func insertAfterDirectives(source, text string) string {
+	lines := strings.Split(source, "\n")
+	header := 0
+	for i, l := range lines {
+		if l := strings.TrimSpace(l); strings.HasPrefix(l, "#version") || strings.HasPrefix(l, "#extension") {
+			header = i + 1
+		}
+	}
+	return strings.Join(lines[:header], "\n") + text + strings.Join(lines[header:], "\n")
+}
+
+// checkShader returns an error if the instrumented GLSL ES source of the
+// shader of type ty does not compile.
+func checkShader(source string, ty GLenum) error {
+	st, err := ty.ShaderType()
+	if err != nil {
+		return err
+	}
+	_, err = shadertools.CompileGlsl(source, shadertools.CompileOptions{
+		ShaderType: st,
+		ClientType: shadertools.OpenGLES,
+	})
+	return err
+}
@@ -771,58 +771,65 @@ func (sb *stateBuilder) attachShaders(ctx context.Context, p Programʳ) {
 }
 
 func (sb *stateBuilder) uniform(ctx context.Context, ty GLenum, loc UniformLocation, n GLsizei, v memory.Pointer) {
-	write, cb := sb.write, sb.cb
+	if cmd := setUniform(sb.cb, ty, loc, n, v); cmd != nil {
+		sb.write(ctx, cmd)
+	}
+}
 
+// setUniform returns the command that sets the n uniform values of type ty
+// at loc to the values at v, or nil if the type is not supported.
+func setUniform(cb CommandBuilder, ty GLenum, loc UniformLocation, n GLsizei, v memory.Pointer) api.Cmd {
 	switch ty {
 	case GLenum_GL_FLOAT:
-		write(ctx, cb.GlUniform1fv(loc, n, v))
+		return cb.GlUniform1fv(loc, n, v)
 	case GLenum_GL_FLOAT_VEC2:
-		write(ctx, cb.GlUniform2fv(loc, n, v))
+		return cb.GlUniform2fv(loc, n, v)
 	case GLenum_GL_FLOAT_VEC3:
-		write(ctx, cb.GlUniform3fv(loc, n, v))
+		return cb.GlUniform3fv(loc, n, v)
 	case GLenum_GL_FLOAT_VEC4:
-		write(ctx, cb.GlUniform4fv(loc, n, v))
+		return cb.GlUniform4fv(loc, n, v)
 	case GLenum_GL_BOOL:
 	case GLenum_GL_INT:
-		write(ctx, cb.GlUniform1iv(loc, n, v))
+		return cb.GlUniform1iv(loc, n, v)
 	case GLenum_GL_BOOL_VEC2:
 	case GLenum_GL_INT_VEC2:
-		write(ctx, cb.GlUniform2iv(loc, n, v))
+		return cb.GlUniform2iv(loc, n, v)
 	case GLenum_GL_BOOL_VEC3:
 	case GLenum_GL_INT_VEC3:
-		write(ctx, cb.GlUniform3iv(loc, n, v))
+		return cb.GlUniform3iv(loc, n, v)
 	case GLenum_GL_BOOL_VEC4:
 	case GLenum_GL_INT_VEC4:
-		write(ctx, cb.GlUniform4iv(loc, n, v))
+		return cb.GlUniform4iv(loc, n, v)
 	case GLenum_GL_UNSIGNED_INT:
-		write(ctx, cb.GlUniform1uiv(loc, n, v))
+		return cb.GlUniform1uiv(loc, n, v)
 	case GLenum_GL_UNSIGNED_INT_VEC2:
-		write(ctx, cb.GlUniform2uiv(loc, n, v))
+		return cb.GlUniform2uiv(loc, n, v)
 	case GLenum_GL_UNSIGNED_INT_VEC3:
-		write(ctx, cb.GlUniform3uiv(loc, n, v))
+		return cb.GlUniform3uiv(loc, n, v)
 	case GLenum_GL_UNSIGNED_INT_VEC4:
-		write(ctx, cb.GlUniform4uiv(loc, n, v))
+		return cb.GlUniform4uiv(loc, n, v)
 	case GLenum_GL_FLOAT_MAT2:
-		write(ctx, cb.GlUniformMatrix2fv(loc, n, GLboolean_GL_FALSE, v))
+		return cb.GlUniformMatrix2fv(loc, n, GLboolean_GL_FALSE, v)
 	case GLenum_GL_FLOAT_MAT3:
-		write(ctx, cb.GlUniformMatrix3fv(loc, n, GLboolean_GL_FALSE, v))
+		return cb.GlUniformMatrix3fv(loc, n, GLboolean_GL_FALSE, v)
 	case GLenum_GL_FLOAT_MAT4:
-		write(ctx, cb.GlUniformMatrix4fv(loc, n, GLboolean_GL_FALSE, v))
+		return cb.GlUniformMatrix4fv(loc, n, GLboolean_GL_FALSE, v)
 	case GLenum_GL_FLOAT_MAT2x3:
-		write(ctx, cb.GlUniformMatrix2x3fv(loc, n, GLboolean_GL_FALSE, v))
+		return cb.GlUniformMatrix2x3fv(loc, n, GLboolean_GL_FALSE, v)
 	case GLenum_GL_FLOAT_MAT2x4:
-		write(ctx, cb.GlUniformMatrix2x4fv(loc, n, GLboolean_GL_FALSE, v))
+		return cb.GlUniformMatrix2x4fv(loc, n, GLboolean_GL_FALSE, v)
 	case GLenum_GL_FLOAT_MAT3x2:
-		write(ctx, cb.GlUniformMatrix3x2fv(loc, n, GLboolean_GL_FALSE, v))
+		return cb.GlUniformMatrix3x2fv(loc, n, GLboolean_GL_FALSE, v)
 	case GLenum_GL_FLOAT_MAT3x4:
-		write(ctx, cb.GlUniformMatrix3x4fv(loc, n, GLboolean_GL_FALSE, v))
+		return cb.GlUniformMatrix3x4fv(loc, n, GLboolean_GL_FALSE, v)
 	case GLenum_GL_FLOAT_MAT4x2:
-		write(ctx, cb.GlUniformMatrix4x2fv(loc, n, GLboolean_GL_FALSE, v))
+		return cb.GlUniformMatrix4x2fv(loc, n, GLboolean_GL_FALSE, v)
 	case GLenum_GL_FLOAT_MAT4x3:
-		write(ctx, cb.GlUniformMatrix4x3fv(loc, n, GLboolean_GL_FALSE, v))
+		return cb.GlUniformMatrix4x3fv(loc, n, GLboolean_GL_FALSE, v)
 	default:
-		write(ctx, cb.GlUniform1iv(loc, n, v))
+		return cb.GlUniform1iv(loc, n, v)
 	}
+	return nil
 }
 
 func (sb *stateBuilder) pipelineObject(ctx context.Context, pipe Pipelineʳ) {
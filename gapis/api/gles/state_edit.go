@@ -0,0 +1,101 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+
+	"github.com/google/gapid/core/math/interval"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/transform"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+var _ = api.StateEditor(API{})
+
+// CanEditState implements api.StateEditor.
+// Only edits of the rasterization and pixel state of the context current on the
+// command's thread can be replayed, as only that state is set again by
+// StateEditCmds.
+func (API) CanEditState(cmd api.Cmd, e *api.StateEdit) bool {
+	nodes, ok := e.APINodes(API{}.ID())
+	if !ok || len(nodes) < 3 {
+		return false
+	}
+	if f, ok := nodes[0].(*path.Field); !ok || f.Name != "Contexts" {
+		return false
+	}
+	if m, ok := nodes[1].(*path.MapIndex); !ok || m.KeyValue() != cmd.Thread() {
+		return false
+	}
+	f, ok := nodes[2].(*path.Field)
+	return ok && (f.Name == "Rasterization" || f.Name == "Pixel")
+}
+
+// StateEditCmds implements api.StateEditor.
+// The rasterization and pixel state of the context current on the command's
+// thread is set again, so edits to any other state are not replayed.
+func (API) StateEditCmds(ctx context.Context, cmd api.Cmd, s *api.GlobalState) []api.Cmd {
+	c := GetContext(s, cmd.Thread())
+	if c.IsNil() || !c.Other().Initialized() {
+		return nil
+	}
+	// The commands are mutated on the replay state by the caller, not by the
+	// state builder.
+	sb := &stateBuilder{
+		oldState:        s,
+		newState:        api.NewStateWithEmptyAllocator(s.MemoryLayout),
+		cb:              CommandBuilder{Thread: cmd.Thread(), Arena: s.Arena},
+		tmpArena:        arena.New(),
+		seen:            map[interface{}]bool{},
+		memoryIntervals: interval.U64RangeList{},
+		cloneCtx:        api.CloneContext{},
+	}
+	defer sb.tmpArena.Dispose()
+
+	sb.reasterizationState(ctx, c.Rasterization())
+	sb.pixelState(ctx, c.Pixel())
+	return sb.cmds
+}
+
+// hasStateEdits returns true if any of cmds has state edits.
+func hasStateEdits(cmds []api.Cmd) bool {
+	for _, cmd := range cmds {
+		if len(cmd.Extras().StateEdits()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// stateEdits returns a transform that follows each command with state edits
+// by the commands that replay the edits.
+func stateEdits() transform.Transformer {
+	return transform.Transform("StateEdits", func(ctx context.Context, id api.CmdID, cmd api.Cmd, out transform.Writer) error {
+		if err := out.MutateAndWrite(ctx, id, cmd); err != nil {
+			return err
+		}
+		if len(cmd.Extras().StateEdits()) == 0 {
+			return nil
+		}
+		for _, c := range (API{}).StateEditCmds(ctx, cmd, out.State()) {
+			if err := out.MutateAndWrite(ctx, api.CmdNoID, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/transform"
+)
+
+const (
+	textureSamplesCounter = "gapid_texture_samples"
+	textureSamplesOutput  = "gapid_texture_samples_output"
+)
+
+// textureSampleCall matches the start of a call to a GLSL built-in function
+// that samples a texture in a normalized shader, whose user-defined functions
+// are all prefixed.
+var textureSampleCall = regexp.MustCompile(`\b(texture\w*|texelFetch\w*)\s*\(`)
+
+// textureQueryFunctions are the built-in functions matched by
+// textureSampleCall that query textures without sampling them.
+var textureQueryFunctions = map[string]bool{
+	"textureSize":        true,
+	"textureQueryLod":    true,
+	"textureQueryLevels": true,
+	"textureSamples":     true,
+}
+
+// textureSamples returns a command transform that replaces the colours drawn
+// by the specified draw call with the number of texture samples taken by each
+// of its fragments.
+func textureSamples(ctx context.Context, i api.CmdID) transform.Transformer {
+	ctx = log.Enter(ctx, "DrawMode_TEXTURE_SAMPLES")
+	return transform.Transform("DrawMode_TEXTURE_SAMPLES", func(ctx context.Context, id api.CmdID, cmd api.Cmd, out transform.Writer) error {
+		if dc, ok := cmd.(drawCall); ok && i == id {
+			return drawTextureSamples(ctx, id, dc, out, false)
+		}
+		return out.MutateAndWrite(ctx, id, cmd)
+	})
+}
+
+// textureSamplesAll returns a command transform that replaces the colours
+// drawn by all draw calls with the number of texture samples taken by the
+// fragments drawn at each pixel, accumulated over the draw calls. The colour
+// buffers are cleared to black so that only the samples are counted.
+func textureSamplesAll(ctx context.Context) transform.Transformer {
+	ctx = log.Enter(ctx, "DrawMode_TEXTURE_SAMPLES_ALL")
+	return transform.Transform("DrawMode_TEXTURE_SAMPLES_ALL", func(ctx context.Context, id api.CmdID, cmd api.Cmd, out transform.Writer) error {
+		switch cmd := cmd.(type) {
+		case drawCall:
+			return drawTextureSamples(ctx, id, cmd, out, true)
+		case *GlClear:
+			if cmd.Mask()&GLbitfield_GL_COLOR_BUFFER_BIT != 0 {
+				t := newTweaker(out, id.Derived(), CommandBuilder{Thread: cmd.Thread(), Arena: out.State().Arena})
+				defer t.revert(ctx)
+				t.glClearColor(ctx, 0, 0, 0, 1)
+			}
+		}
+		return out.MutateAndWrite(ctx, id, cmd)
+	})
+}
+
+// drawTextureSamples writes the draw call using a copy of the bound program
+// whose fragment shader outputs the number of texture samples taken by each
+// fragment, scaled by 1/255, instead of its colour. If accumulate is true, the
+// counts are added to the colours already drawn. If the program cannot be
+// instrumented, the draw call is written unmodified.
+func drawTextureSamples(ctx context.Context, id api.CmdID, dc drawCall, out transform.Writer, accumulate bool) error {
+	s := out.State()
+	c := GetContext(s, dc.Thread())
+	if c.IsNil() {
+		return out.MutateAndWrite(ctx, id, dc)
+	}
+
+	p := c.Bound().Program()
+	if p.IsNil() || p.SuccessfulLinkExtra().IsNil() {
+		log.W(ctx, "Draw call %v has no linked program", id)
+		return out.MutateAndWrite(ctx, id, dc)
+	}
+	extra := p.SuccessfulLinkExtra()
+	vs := extra.Shaders().Get(GLenum_GL_VERTEX_SHADER)
+	fs := extra.Shaders().Get(GLenum_GL_FRAGMENT_SHADER)
+	if vs.IsNil() || fs.IsNil() || extra.Shaders().Len() != 2 {
+		log.W(ctx, "Draw call %v does not use a program of vertex and fragment shaders", id)
+		return out.MutateAndWrite(ctx, id, dc)
+	}
+	// Both shaders are normalized, so that their interfaces match.
+	version := shaderVersion(vs.Source())
+	if version < 300 {
+		version = 300
+	}
+	vss, err := normalizeShader(vs.Source(), GLenum_GL_VERTEX_SHADER, version)
+	if err != nil {
+		log.E(ctx, "Unable to normalize the vertex shader of draw call %v: %v", id, err)
+		return out.MutateAndWrite(ctx, id, dc)
+	}
+	fss, err := instrumentTextureSamples(fs.Source(), version)
+	if err != nil {
+		log.E(ctx, "Unable to instrument the fragment shader of draw call %v: %v", id, err)
+		return out.MutateAndWrite(ctx, id, dc)
+	}
+
+	cb := CommandBuilder{Thread: dc.Thread(), Arena: s.Arena}
+	t := newTweaker(out, id.Derived(), cb)
+	defer t.revert(ctx)
+
+	t.useProgramCopy(ctx, p, vss, fss)
+	if accumulate {
+		t.glEnable(ctx, GLenum_GL_BLEND)
+		t.glBlendEquation(ctx, GLenum_GL_FUNC_ADD)
+		t.glBlendFunc(ctx, GLenum_GL_ONE, GLenum_GL_ONE)
+	}
+
+	return out.MutateAndWrite(ctx, id, dc)
+}
+
+// instrumentTextureSamples returns the source of the fragment shader,
+// normalized as GLSL ES of the given version, with every call to a texture
+// sampling function counted, and the count written to the first colour
+// attachment instead of the colours computed by the shader. The calls are
+// counted as they are executed, so samples taken in loops or skipped by
+// branches are accounted for.
+func instrumentTextureSamples(source string, version int) (string, error) {
+	source, err := normalizeShader(source, GLenum_GL_FRAGMENT_SHADER, version)
+	if err != nil {
+		return "", err
+	}
+
+	// Wrap each sampling call in a comma expression that increments the
+	// counter, so that the type of the call is preserved.
+	type insertion struct {
+		at   int
+		text string
+	}
+	insertions := []insertion{}
+	for _, m := range textureSampleCall.FindAllStringSubmatchIndex(source, -1) {
+		if textureQueryFunctions[source[m[2]:m[3]]] {
+			continue
+		}
+		end, depth := m[1], 1
+		for ; end < len(source) && depth > 0; end++ {
+			switch source[end] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+		}
+		if depth > 0 {
+			return "", fmt.Errorf("Unterminated call to %v", source[m[2]:m[3]])
+		}
+		insertions = append(insertions,
+			insertion{m[0], "(" + textureSamplesCounter + " += 1.0, "},
+			insertion{end, ")"})
+	}
+	sort.SliceStable(insertions, func(i, j int) bool { return insertions[i].at < insertions[j].at })
+
+	body := strings.Builder{}
+	last := 0
+	for _, i := range insertions {
+		body.WriteString(source[last:i.at])
+		body.WriteString(i.text)
+		last = i.at
+	}
+	body.WriteString(source[last:])
+	instrumented := fragmentOutputsToGlobals(renameMain(body.String(), "gapid_main"))
+
+	instrumented = insertAfterDirectives(instrumented, fmt.Sprintf(`
+layout(location = 0) out mediump vec4 %[2]s;
+mediump float %[1]s = 0.0;
+`, textureSamplesCounter, textureSamplesOutput)) + fmt.Sprintf(`
+void main() {
+    gapid_main();
+    %[2]s = vec4(vec3(%[1]s / 255.0), 1.0);
+}
+`, textureSamplesCounter, textureSamplesOutput)
+	if err := checkShader(instrumented, GLenum_GL_FRAGMENT_SHADER); err != nil {
+		return "", err
+	}
+	return instrumented, nil
+}
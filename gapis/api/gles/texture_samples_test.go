@@ -0,0 +1,81 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+)
+
+func TestInstrumentTextureSamples(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		name    string
+		source  string
+		samples int
+	}{
+		{
+			name: "GLSL ES 3.00",
+			source: `#version 300 es
+precision mediump float;
+#define SAMPLE(at) texture(tex, at)
+uniform sampler2D tex;
+in vec2 uv;
+out vec4 color;
+// Each iteration calls texture(tex, at) once.
+vec4 textureBlur(vec2 at) {
+    vec4 sum = vec4(0.0);
+    for (int i = 0; i < 4; i++) {
+        sum += SAMPLE(at + float(i) * 0.01);
+    }
+    return sum / 4.0;
+}
+void main() {
+    color = textureBlur(uv) + vec4(vec2(textureSize(tex, 0)), 0.0, 0.0);
+}
+`,
+			samples: 1,
+		},
+		{
+			name: "GLSL ES 1.00",
+			source: `precision mediump float;
+uniform sampler2D tex;
+varying vec2 uv;
+void main() {
+    /* texture2D(tex, uv * 2.0) */
+    gl_FragColor = texture2D(tex, uv) * texture2D(tex, uv.yx);
+}
+`,
+			samples: 2,
+		},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		source, err := instrumentTextureSamples(test.source, 300)
+		if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+			continue
+		}
+		assert.For(ctx, "samples").That(strings.Count(source, textureSamplesCounter+" += 1.0")).Equals(test.samples)
+		assert.For(ctx, "line comments").ThatString(source).DoesNotContain("//")
+		assert.For(ctx, "block comments").ThatString(source).DoesNotContain("/*")
+		assert.For(ctx, "output").ThatString(source).Contains(textureSamplesOutput + " = vec4(")
+		assert.For(ctx, "main").ThatString(source).Contains("gapid_main();")
+		if strings.Contains(test.source, "textureBlur") {
+			assert.For(ctx, "function").ThatString(source).Contains(userFunctionPrefix + "textureBlur(")
+		}
+	}
+}
@@ -15,12 +15,18 @@
 package gles
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/api/transform"
 	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/replay/builder"
+	"github.com/google/gapid/gapis/replay/value"
 )
 
 // tweaker provides a set of methods for temporarily changing the GLES state.
@@ -126,6 +132,23 @@ func (t *tweaker) glDisableVertexAttribArray(ctx context.Context, l AttributeLoc
 	}
 }
 
+func (t *tweaker) glColorMask(ctx context.Context, r, g, b, a GLboolean) {
+	// TODO: This does not correctly handle indexed state.
+	if o := t.c.Pixel().ColorWritemask().Get(0); o.R() != r || o.G() != g || o.B() != b || o.A() != a {
+		t.doAndUndo(ctx,
+			t.cb.GlColorMask(r, g, b, a),
+			t.cb.GlColorMask(o.R(), o.G(), o.B(), o.A()))
+	}
+}
+
+func (t *tweaker) glClearColor(ctx context.Context, r, g, b, a GLfloat) {
+	if o := t.c.Pixel().ColorClearValue(); o.Get(0) != r || o.Get(1) != g || o.Get(2) != b || o.Get(3) != a {
+		t.doAndUndo(ctx,
+			t.cb.GlClearColor(r, g, b, a),
+			t.cb.GlClearColor(o.Get(0), o.Get(1), o.Get(2), o.Get(3)))
+	}
+}
+
 func (t *tweaker) glDepthMask(ctx context.Context, v GLboolean) {
 	if o := t.c.Pixel().DepthWritemask(); o != v {
 		t.doAndUndo(ctx,
@@ -150,6 +173,16 @@ func (t *tweaker) glBlendColor(ctx context.Context, r, g, b, a GLfloat) {
 	}
 }
 
+func (t *tweaker) glBlendEquation(ctx context.Context, mode GLenum) {
+	// TODO: This does not correctly handle indexed state.
+	o := t.c.Pixel().Blend().Get(0)
+	if o.EquationRgb() != mode || o.EquationAlpha() != mode {
+		t.doAndUndo(ctx,
+			t.cb.GlBlendEquation(mode),
+			t.cb.GlBlendEquationSeparate(o.EquationRgb(), o.EquationAlpha()))
+	}
+}
+
 func (t *tweaker) glBlendFunc(ctx context.Context, src, dst GLenum) {
 	t.glBlendFuncSeparate(ctx, src, dst, src, dst)
 }
@@ -295,6 +328,88 @@ func (t *tweaker) makeProgram(ctx context.Context, vertexShaderSource, fragmentS
 	return programID
 }
 
+// useProgramCopy makes, links and uses a copy of the program p with its
+// shaders replaced by the given sources, which must declare the attributes,
+// uniforms and uniform blocks of p with the same names. The attribute
+// locations, uniform values and uniform block bindings of p are copied to the
+// new program, whose uniforms are located by name as their locations may
+// differ from those of p.
+func (t *tweaker) useProgramCopy(ctx context.Context, p Programʳ, vertexShaderSource, fragmentShaderSource string) ProgramId {
+	extra := p.SuccessfulLinkExtra()
+	programID := t.makeProgram(ctx, vertexShaderSource, fragmentShaderSource)
+	for _, name := range extra.AttributeBindings().Keys() {
+		tmp := t.AllocData(ctx, name)
+		t.out.MutateAndWrite(ctx, t.dID, t.cb.GlBindAttribLocation(programID, extra.AttributeBindings().Get(name), tmp.Ptr()).
+			AddRead(tmp.Data()))
+	}
+	t.out.MutateAndWrite(ctx, t.dID, api.WithExtras(t.cb.GlLinkProgram(programID), extra.Get().Clone(t.s.Arena, api.CloneContext{})))
+	t.checkLinkStatus(ctx, programID)
+	t.glUseProgram(ctx, programID)
+
+	// Linking resets the uniforms, so copy the current values of the
+	// uniforms of p. Querying the location of each uniform element remaps the
+	// locations of p to those of the new program.
+	for _, k := range p.ActiveResources().DefaultUniformBlock().Keys() {
+		u := p.ActiveResources().DefaultUniformBlock().Get(k)
+		loc, ok := u.Locations().Lookup(0)
+		if !ok || strings.HasPrefix(u.Name(), "gl_") {
+			continue
+		}
+		baseName := strings.TrimSuffix(u.Name(), "[0]")
+		for i := uint32(0); i < uint32(u.ArraySize()); i++ {
+			name := baseName
+			if i != 0 {
+				name = fmt.Sprintf("%v[%v]", name, i)
+			}
+			if l, ok := u.Locations().Lookup(i); ok {
+				t.out.MutateAndWrite(ctx, t.dID, GetUniformLocation(ctx, t.s, t.cb, programID, name, UniformLocation(l)))
+			}
+		}
+		tmp := t.s.AllocOrPanic(ctx, u.Value().Size())
+		if cmd := setUniform(t.cb, u.Type(), UniformLocation(loc), GLsizei(u.ArraySize()), tmp.Ptr()); cmd != nil {
+			cmd.Extras().GetOrAppendObservations().AddRead(tmp.Range(), u.Value().ResourceID(ctx, t.s))
+			t.out.MutateAndWrite(ctx, t.dID, cmd)
+		}
+		tmp.Free()
+	}
+	for _, loc := range p.ActiveResources().UniformBlocks().Keys() {
+		b := p.ActiveResources().UniformBlocks().Get(loc)
+		if index := b.Binding(); index > 0 {
+			t.out.MutateAndWrite(ctx, t.dID, t.cb.GlUniformBlockBinding(programID, UniformBlockIndex(loc), GLuint(index)))
+		}
+	}
+	return programID
+}
+
+// checkLinkStatus logs an error during the replay if the program failed to
+// link.
+func (t *tweaker) checkLinkStatus(ctx context.Context, programID ProgramId) {
+	const buflen = 2048
+	tmp := t.s.AllocOrPanic(ctx, 4+buflen)
+	defer tmp.Free()
+	t.out.MutateAndWrite(ctx, t.dID, t.cb.GlGetProgramiv(programID, GLenum_GL_LINK_STATUS, tmp.Ptr()))
+	t.out.MutateAndWrite(ctx, t.dID, t.cb.GlGetProgramInfoLog(programID, buflen, memory.Nullptr, tmp.Offset(4)))
+	t.out.MutateAndWrite(ctx, t.dID, t.cb.Custom(func(ctx context.Context, s *api.GlobalState, b *builder.Builder) error {
+		b.ReserveMemory(tmp.Range())
+		b.Post(value.ObservedPointer(tmp.Address()), 4+buflen, func(r binary.Reader, err error) {
+			if err != nil {
+				log.E(ctx, "Failed to decode the link status of program %v: %v", programID, err)
+				return
+			}
+			msg := make([]byte, buflen)
+			res := r.Uint32()
+			r.Data(msg)
+			if res != uint32(GLboolean_GL_TRUE) {
+				if i := bytes.IndexByte(msg, 0); i >= 0 {
+					msg = msg[:i]
+				}
+				log.E(ctx, "Program %v failed to link. Error:\n%v", programID, string(msg))
+			}
+		})
+		return nil
+	}))
+}
+
 func (t *tweaker) glCreateShader(ctx context.Context, shaderType GLenum) ShaderId {
 	id := ShaderId(newUnusedID(ctx, 'S', func(x uint32) bool {
 		return !t.c.Objects().Programs().Get(ProgramId(x)).IsNil() || !t.c.Objects().Shaders().Get(ShaderId(x)).IsNil()
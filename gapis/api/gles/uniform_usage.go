@@ -0,0 +1,98 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.UniformUsageAnalyzer(API{})
+)
+
+// uniformSetter is implemented by the glUniform* and glProgramUniform*
+// commands, along with other commands taking a uniform location.
+type uniformSetter interface {
+	Location() UniformLocation
+}
+
+// AnalyzeUniformUsage implements api.UniformUsageAnalyzer.
+// Only the default uniform block of programs bound with glUseProgram is
+// analyzed.
+func (API) AnalyzeUniformUsage(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState, b *api.UniformUsageBuilder) error {
+	if err := cmd.Mutate(ctx, id, s, nil, nil); err != nil {
+		return err
+	}
+	c := GetContext(s, cmd.Thread())
+	if c.IsNil() {
+		return nil
+	}
+
+	name := cmd.CmdName()
+	if set, ok := cmd.(uniformSetter); ok &&
+		(strings.HasPrefix(name, "glUniform") || strings.HasPrefix(name, "glProgramUniform")) {
+		program := c.Bound().Program()
+		if p, ok := cmd.(interface{ Program() ProgramId }); ok {
+			program, _ = c.Objects().Programs().Lookup(p.Program())
+		}
+		if program.IsNil() {
+			return nil
+		}
+		uniform, read := uniformName(program, set.Location())
+		b.Set(programName(program), uniform, read, []uint64{uint64(id)})
+		return nil
+	}
+
+	if !cmd.CmdFlags(ctx, id, s).IsDrawCall() {
+		return nil
+	}
+	program := c.Bound().Program()
+	if program.IsNil() || program.ActiveResources().IsNil() {
+		return nil
+	}
+	block := program.ActiveResources().DefaultUniformBlock()
+	for _, i := range block.Keys() {
+		u := block.Get(i)
+		value, err := u.Value().Read(ctx, cmd, s, nil)
+		if err != nil {
+			return err
+		}
+		b.Draw(programName(program), u.Name(), value)
+	}
+	return nil
+}
+
+// programName returns the description of the program p used by the uniform
+// usage.
+func programName(p Programʳ) string {
+	return fmt.Sprintf("Program %v", p.ID())
+}
+
+// uniformName returns the name of the active uniform of the program p at the
+// location loc, and true, or a description of the location and false if the
+// program has no such active uniform.
+func uniformName(p Programʳ, loc UniformLocation) (string, bool) {
+	if u, ok := p.UniformLocations().Lookup(loc); ok && !p.ActiveResources().IsNil() {
+		if r, ok := p.ActiveResources().DefaultUniformBlock().Lookup(u.UniformIndex()); ok {
+			return r.Name(), true
+		}
+	}
+	return fmt.Sprintf("<location %v>", loc), false
+}
@@ -16,6 +16,7 @@ package api
 
 import (
 	"reflect"
+	"sync"
 
 	"github.com/google/gapid/core/data/deep"
 	"github.com/google/gapid/core/data/generic"
@@ -37,6 +38,18 @@ type Property struct {
 	// Constants is the optional index of the constant set used by the value.
 	// -1 represents no constant set.
 	Constants int
+	// KeyConstants is the optional index of the constant set used by the keys
+	// of a map value. -1 represents no constant set.
+	KeyConstants int
+	// Docs is the optional documentation of the property.
+	Docs string
+	// Units is the optional unit of the value, such as "bytes" or "ns", taken
+	// from the @units annotation of the API definition.
+	Units string
+	// Group is the optional name of the group of related properties that this
+	// property belongs to, taken from the @group annotation of the API
+	// definition.
+	Group string
 }
 
 // SetConstants is a helper method for setting the Constants field in a
@@ -46,6 +59,34 @@ func (p *Property) SetConstants(idx int) *Property {
 	return p
 }
 
+// SetKeyConstants is a helper method for setting the KeyConstants field in a
+// fluent expression.
+func (p *Property) SetKeyConstants(idx int) *Property {
+	p.KeyConstants = idx
+	return p
+}
+
+// SetDocs is a helper method for setting the Docs field in a fluent
+// expression.
+func (p *Property) SetDocs(docs string) *Property {
+	p.Docs = docs
+	return p
+}
+
+// SetUnits is a helper method for setting the Units field in a fluent
+// expression.
+func (p *Property) SetUnits(units string) *Property {
+	p.Units = units
+	return p
+}
+
+// SetGroup is a helper method for setting the Group field in a fluent
+// expression.
+func (p *Property) SetGroup(group string) *Property {
+	p.Group = group
+	return p
+}
+
 // Properties is a list of property pointers.
 type Properties []*Property
 
@@ -55,9 +96,24 @@ type PropertyProvider interface {
 	Properties() Properties
 }
 
-// NewProperty returns a new Property using the given getter and setter
-// functions. set may be nil in the case of a read-only property.
-func NewProperty(name string, get, set interface{}) *Property {
+// propertySig is the key to propertyTypes.
+type propertySig struct {
+	get, set reflect.Type
+}
+
+// propertyTypes is a map of propertySig to the reflect.Type of the property
+// value. Properties are created each time PropertyProvider.Properties() is
+// called, so this avoids checking the signatures of the same getter and
+// setter types over and over again.
+var propertyTypes sync.Map
+
+// propertyType returns the type of the value of the property with the given
+// getter and setter functions. set may be nil.
+func propertyType(get, set interface{}) reflect.Type {
+	sig := propertySig{get: reflect.TypeOf(get), set: reflect.TypeOf(set)}
+	if ty, ok := propertyTypes.Load(sig); ok {
+		return ty.(reflect.Type)
+	}
 	type Value = generic.T1
 	var ValueTy = generic.T1Ty
 	sigs := []generic.Sig{generic.Sig{Name: "get", Interface: func() Value { return Value{} }, Function: get}}
@@ -68,13 +124,22 @@ func NewProperty(name string, get, set interface{}) *Property {
 	if !m.Ok() {
 		panic(m.Errors)
 	}
-	g, s := reflect.ValueOf(get), reflect.ValueOf(set)
 	ty := m.Bindings[ValueTy]
+	propertyTypes.Store(sig, ty)
+	return ty
+}
+
+// NewProperty returns a new Property using the given getter and setter
+// functions. set may be nil in the case of a read-only property.
+func NewProperty(name string, get, set interface{}) *Property {
+	g, s := reflect.ValueOf(get), reflect.ValueOf(set)
+	ty := propertyType(get, set)
 	out := &Property{
-		Name:      name,
-		Type:      ty,
-		Get:       func() interface{} { return g.Call([]reflect.Value{})[0].Interface() },
-		Constants: -1,
+		Name:         name,
+		Type:         ty,
+		Get:          func() interface{} { return g.Call([]reflect.Value{})[0].Interface() },
+		Constants:    -1,
+		KeyConstants: -1,
 	}
 	if set != nil {
 		out.Set = func(value interface{}) {
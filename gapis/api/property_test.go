@@ -0,0 +1,65 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+type propertyObject struct {
+	i int
+	s string
+}
+
+func (o *propertyObject) I() int        { return o.i }
+func (o *propertyObject) SetI(i int)    { o.i = i }
+func (o *propertyObject) S() string     { return o.s }
+func (o *propertyObject) SetS(s string) { o.s = s }
+
+func (o *propertyObject) Properties() api.Properties {
+	return api.Properties{
+		api.NewProperty("I", o.I, o.SetI),
+		api.NewProperty("S", o.S, o.SetS),
+		api.NewProperty("ReadOnlyI", o.I, nil),
+	}
+}
+
+func TestNewProperty(t *testing.T) {
+	ctx := log.Testing(t)
+	a, b := &propertyObject{i: 1, s: "a"}, &propertyObject{i: 2, s: "b"}
+
+	// Build the properties twice for each object, so that the second set are
+	// created using the cached property types.
+	for i := 0; i < 2; i++ {
+		pa, pb := a.Properties(), b.Properties()
+		assert.For(ctx, "I.Type").That(pa.Find("I").Type).Equals(reflect.TypeOf(0))
+		assert.For(ctx, "S.Type").That(pa.Find("S").Type).Equals(reflect.TypeOf(""))
+		assert.For(ctx, "ReadOnlyI.Type").That(pa.Find("ReadOnlyI").Type).Equals(reflect.TypeOf(0))
+		assert.For(ctx, "ReadOnlyI.Set").That(pa.Find("ReadOnlyI").Set == nil).Equals(true)
+		assert.For(ctx, "a.I").That(pa.Find("I").Get()).Equals(a.i)
+		assert.For(ctx, "b.I").That(pb.Find("I").Get()).Equals(b.i)
+		assert.For(ctx, "b.S").That(pb.Find("S").Get()).Equals(b.s)
+	}
+
+	a.Properties().Find("I").Set(10)
+	b.Properties().Find("S").Set("z")
+	assert.For(ctx, "a.i").That(a.i).Equals(10)
+	assert.For(ctx, "b.s").That(b.s).Equals("z")
+}
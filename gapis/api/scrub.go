@@ -0,0 +1,45 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// ScrubKind is the kind of application data held by an observed read.
+type ScrubKind int
+
+const (
+	// ScrubAsset is the contents of a texture or buffer.
+	ScrubAsset ScrubKind = iota
+	// ScrubName is a string naming the application, or one of its objects.
+	ScrubName
+	// ScrubShader is the source of a shader.
+	ScrubShader
+)
+
+// ScrubbableRead is an observed read of a command that holds application data
+// that can be replaced without changing the structure of the capture.
+type ScrubbableRead struct {
+	Index int       // The index of the read in the command's observations.
+	Kind  ScrubKind // The kind of data held by the read.
+}
+
+// Scrubber is the interface implemented by APIs that can identify the
+// application data read by their commands.
+type Scrubber interface {
+	// ScrubbableReads returns the observed reads of cmd that hold application
+	// data. s is the state before cmd is mutated, with the reads of cmd
+	// already applied to the application pool.
+	ScrubbableReads(ctx context.Context, cmd Cmd, s *GlobalState) []ScrubbableRead
+}
@@ -69,6 +69,10 @@ type GlobalState struct {
 
 	// AddTag is called when we want to tag report item.
 	AddTag func(msgID uint32, msg *stringtable.Msg)
+
+	// StateEdits holds the edits to apply to the state once each of the
+	// commands has been mutated. It is nil if the commands have no edits.
+	StateEdits map[Cmd][]*StateEdit
 }
 
 // State represents the graphics state for a single API.
@@ -0,0 +1,247 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/gapid/core/data/deep"
+	"github.com/google/gapid/core/data/dictionary"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// StateEditor is the interface implemented by APIs whose replays support edits
+// of their state.
+type StateEditor interface {
+	// CanEditState returns true if the replays of the API can make the state
+	// edit e once the command cmd has been mutated.
+	CanEditState(cmd Cmd, e *StateEdit) bool
+
+	// StateEditCmds returns the commands that make the state of the replay
+	// device match the API state of s, once the state edits of the command cmd
+	// have been applied to s.
+	StateEditCmds(ctx context.Context, cmd Cmd, s *GlobalState) []Cmd
+}
+
+// NewStateEdit returns a StateEdit that sets the value found by following the
+// field, array index and map index nodes from the global state to v.
+func NewStateEdit(nodes []path.Node, v interface{}) (*StateEdit, error) {
+	out := &StateEdit{Value: box.NewValue(v)}
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *path.Field:
+			out.Nodes = append(out.Nodes, (&path.Field{Name: n.Name}).Path())
+		case *path.ArrayIndex:
+			out.Nodes = append(out.Nodes, (&path.ArrayIndex{Index: n.Index}).Path())
+		case *path.MapIndex:
+			out.Nodes = append(out.Nodes, (&path.MapIndex{Key: n.Key}).Path())
+		default:
+			return nil, fmt.Errorf("State edits cannot follow %T nodes", n)
+		}
+	}
+	return out, nil
+}
+
+// StateEditsOf returns the state edits of each of the commands cmds that has
+// any, or nil if none of the commands has state edits.
+func StateEditsOf(cmds []Cmd) map[Cmd][]*StateEdit {
+	var out map[Cmd][]*StateEdit
+	for _, cmd := range cmds {
+		if edits := cmd.Extras().StateEdits(); len(edits) > 0 {
+			if out == nil {
+				out = map[Cmd][]*StateEdit{}
+			}
+			out[cmd] = edits
+		}
+	}
+	return out
+}
+
+// ApplyStateEdits applies the state edits to the global state s, in the order
+// they were made.
+func ApplyStateEdits(ctx context.Context, edits []*StateEdit, s *GlobalState) error {
+	for _, e := range edits {
+		if err := e.Apply(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// APINodes returns the nodes of the edit that follow from the state of the API
+// a, and true, or false if the edit is not of the state of the API a.
+func (e *StateEdit) APINodes(a ID) ([]path.Node, bool) {
+	if len(e.Nodes) < 2 {
+		return nil, false
+	}
+	if f, ok := e.Nodes[0].Node().(*path.Field); !ok || f.Name != "APIs" {
+		return nil, false
+	}
+	m, ok := e.Nodes[1].Node().(*path.MapIndex)
+	if !ok {
+		return nil, false
+	}
+	key, err := convertStateValue(reflect.ValueOf(m.KeyValue()), reflect.TypeOf(a))
+	if err != nil || key.Interface() != a {
+		return nil, false
+	}
+	nodes := make([]path.Node, len(e.Nodes)-2)
+	for i, n := range e.Nodes[2:] {
+		nodes[i] = n.Node()
+	}
+	return nodes, true
+}
+
+// Apply sets the value of the global state s found by following the nodes of
+// the edit to the value of the edit.
+func (e *StateEdit) Apply(ctx context.Context, s *GlobalState) error {
+	nodes := make([]path.Node, len(e.Nodes))
+	for i, n := range e.Nodes {
+		nodes[i] = n.Node()
+	}
+	_, err := setStateValue(reflect.ValueOf(s), nodes, reflect.ValueOf(e.Value.Get()))
+	return err
+}
+
+// setStateValue sets the value found by following nodes from v to val. It
+// returns v, or a modified copy of v if v is not a reference type, which the
+// caller must store in place of v.
+func setStateValue(v reflect.Value, nodes []path.Node, val reflect.Value) (reflect.Value, error) {
+	if len(nodes) == 0 {
+		return convertStateValue(val, v.Type())
+	}
+	switch n := nodes[0].(type) {
+	case *path.Field:
+		if pp, ok := v.Interface().(PropertyProvider); ok {
+			if p := pp.Properties().Find(n.Name); p != nil {
+				child, err := setStateValue(reflect.ValueOf(p.Get()), nodes[1:], val)
+				if err != nil {
+					return v, err
+				}
+				set := p.Set
+				if set == nil {
+					set = setterOf(v, n.Name)
+				}
+				switch {
+				case set != nil:
+					set(child.Interface())
+				case len(nodes) == 1:
+					return v, fmt.Errorf("Field %v is read-only", n.Name)
+				}
+				return v, nil
+			}
+		}
+		s := v
+		for s.Kind() == reflect.Ptr || s.Kind() == reflect.Interface {
+			s = s.Elem()
+		}
+		if s.Kind() != reflect.Struct || !s.FieldByName(n.Name).IsValid() {
+			return v, fmt.Errorf("%v has no field %v", v.Type(), n.Name)
+		}
+		if !s.CanSet() {
+			c := reflect.New(s.Type()).Elem()
+			c.Set(s)
+			s, v = c, c
+		}
+		f := s.FieldByName(n.Name)
+		child, err := setStateValue(f, nodes[1:], val)
+		if err != nil {
+			return v, err
+		}
+		f.Set(child)
+		return v, nil
+
+	case *path.ArrayIndex:
+		switch v.Kind() {
+		case reflect.Array, reflect.Slice:
+		default:
+			return v, fmt.Errorf("%v is not array indexable", v.Type())
+		}
+		if count := uint64(v.Len()); n.Index >= count {
+			return v, fmt.Errorf("Index %v is out of bounds [0-%v)", n.Index, count)
+		}
+		if v.Kind() == reflect.Array {
+			c := reflect.New(v.Type()).Elem()
+			c.Set(v)
+			v = c
+		}
+		el := v.Index(int(n.Index))
+		child, err := setStateValue(el, nodes[1:], val)
+		if err != nil {
+			return v, err
+		}
+		el.Set(child)
+		return v, nil
+
+	case *path.MapIndex:
+		d := dictionary.From(v.Interface())
+		if d == nil {
+			return v, fmt.Errorf("%v is not map indexable", v.Type())
+		}
+		key, err := convertStateValue(reflect.ValueOf(n.KeyValue()), d.KeyTy())
+		if err != nil {
+			return v, err
+		}
+		var old reflect.Value
+		switch o, ok := d.Lookup(key.Interface()); {
+		case ok:
+			old = reflect.ValueOf(o)
+		case len(nodes) == 1:
+			// The edit adds a new entry to the map.
+			old = reflect.Zero(d.ValTy())
+		default:
+			return v, fmt.Errorf("Map key %v does not exist", key.Interface())
+		}
+		child, err := setStateValue(old, nodes[1:], val)
+		if err != nil {
+			return v, err
+		}
+		d.Add(key.Interface(), child.Interface())
+		return v, nil
+	}
+	return v, fmt.Errorf("State edits cannot follow %T nodes", nodes[0])
+}
+
+// setterOf returns a function calling the setter method of the field name of
+// v, or nil if v has no such method. The properties of the API states have no
+// setters, as the setters of the states have pointer receivers.
+func setterOf(v reflect.Value, name string) func(interface{}) {
+	m := v.MethodByName("Set" + strings.ToUpper(name[:1]) + name[1:])
+	if !m.IsValid() || m.Type().NumIn() != 1 {
+		return nil
+	}
+	return func(val interface{}) { m.Call([]reflect.Value{reflect.ValueOf(val)}) }
+}
+
+// convertStateValue returns val converted to the type ty.
+func convertStateValue(val reflect.Value, ty reflect.Type) (reflect.Value, error) {
+	switch {
+	case !val.IsValid():
+		return reflect.Zero(ty), nil
+	case val.Type() == ty:
+		return val, nil
+	case val.Type().ConvertibleTo(ty):
+		return val.Convert(ty), nil
+	}
+	out := reflect.New(ty)
+	if err := deep.Copy(out.Interface(), val.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("Cannot assign a value of type %v to %v", val.Type(), ty)
+	}
+	return out.Elem(), nil
+}
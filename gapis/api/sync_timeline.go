@@ -0,0 +1,27 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import "context"
+
+// SyncEventLister is the interface implemented by APIs that can list the
+// semaphore and fence events of their commands.
+type SyncEventLister interface {
+	// ListSyncEvents mutates the command cmd with the state s, appending the
+	// semaphore and fence events of the command to out. The Capture of each
+	// event's command path, and the timing and Signal of each event, are left
+	// for the caller.
+	ListSyncEvents(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState, out *SyncTimeline) error
+}
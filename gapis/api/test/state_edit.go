@@ -0,0 +1,35 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+var _ = api.StateEditor(API{})
+
+// CanEditState implements api.StateEditor.
+// The test API has no replay, so all edits of its state are supported.
+func (API) CanEditState(cmd api.Cmd, e *api.StateEdit) bool {
+	_, ok := e.APINodes(API{}.ID())
+	return ok
+}
+
+// StateEditCmds implements api.StateEditor.
+func (API) StateEditCmds(ctx context.Context, cmd api.Cmd, s *api.GlobalState) []api.Cmd {
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestStateEdits(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	s := api.NewStateWithEmptyAllocator(device.Little32)
+	cb := CommandBuilder{Thread: 0, Arena: s.Arena}
+
+	state := (&path.GlobalState{}).Field("APIs").MapIndex(API{}.ID())
+	edit := func(v interface{}, field string) *api.StateEdit {
+		p := state.Field(field)
+		e, err := api.NewStateEdit([]path.Node{p.Parent().Parent(), p.Parent(), p}, v)
+		assert.For(ctx, "NewStateEdit(%v)", field).ThatError(err).Succeeded()
+		return e
+	}
+
+	cmds := []api.Cmd{
+		cb.CmdVoid(),
+		api.WithExtras(cb.CmdVoid(), edit("edited", "Str")),
+	}
+	s.StateEdits = api.StateEditsOf(cmds)
+	assert.For(ctx, "edits").That(len(s.StateEdits)).Equals(1)
+	err := api.MutateCmds(ctx, s, nil, nil, cmds...)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "Str").That(GetState(s).Str()).Equals("edited")
+
+	cmds = []api.Cmd{api.WithExtras(cb.CmdVoid(), edit("edited", "Missing"))}
+	s.StateEdits = api.StateEditsOf(cmds)
+	err = api.MutateCmds(ctx, s, nil, nil, cmds...)
+	assert.For(ctx, "err").ThatError(err).Failed()
+
+	assert.For(ctx, "no edits").That(api.StateEditsOf([]api.Cmd{cb.CmdVoid()})).IsNil()
+}
@@ -275,3 +275,40 @@ func NewTexture(t interface{}) *Texture {
 		panic(fmt.Errorf("%T is not a Texture type", t))
 	}
 }
+
+// Images returns the images of every mip-level, layer and face of the texture.
+func (t *Texture) Images() []*image.Info {
+	out := []*image.Info{}
+	cubemap := func(c *Cubemap) {
+		for _, l := range c.Levels {
+			for _, f := range l.faces() {
+				if f != nil {
+					out = append(out, f)
+				}
+			}
+		}
+	}
+	switch t := protoutil.OneOf(t.Type).(type) {
+	case *Texture1D:
+		out = append(out, t.Levels...)
+	case *Texture1DArray:
+		for _, l := range t.Layers {
+			out = append(out, l.Levels...)
+		}
+	case *Texture2D:
+		out = append(out, t.Levels...)
+	case *Texture2DArray:
+		for _, l := range t.Layers {
+			out = append(out, l.Levels...)
+		}
+	case *Texture3D:
+		out = append(out, t.Levels...)
+	case *Cubemap:
+		cubemap(t)
+	case *CubemapArray:
+		for _, l := range t.Layers {
+			cubemap(l)
+		}
+	}
+	return out
+}
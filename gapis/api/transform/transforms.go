@@ -16,6 +16,8 @@ package transform
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/config"
@@ -28,11 +30,15 @@ type Transforms []Transformer
 // the list, before writing the final output to the output command Writer.
 func (l Transforms) TransformAll(ctx context.Context, cmds []api.Cmd, numberOfInitialCommands uint64, out Writer) error {
 	chain := out
+	if len(l) > 0 {
+		chain = originWriter{out, name(l[len(l)-1])}
+	}
 	for i := len(l) - 1; i >= 0; i-- {
 		s := chain.State()
 		if config.SeparateMutateStates || (i+1 < len(l) && l[i+1].BuffersCommands()) {
 			newState := api.NewStateWithAllocator(s.Allocator, s.MemoryLayout)
 			newState.Memory = s.Memory.Clone()
+			newState.StateEdits = s.StateEdits
 			for k, v := range s.APIs {
 				clonedState := v.Clone(newState.Arena)
 				clonedState.SetupInitialState(ctx)
@@ -40,12 +46,18 @@ func (l Transforms) TransformAll(ctx context.Context, cmds []api.Cmd, numberOfIn
 			}
 			s = newState
 		}
-		chain = TransformWriter{s, l[i], chain}
+		from := InitialCommandsOrigin
+		if i > 0 {
+			from = name(l[i-1])
+		}
+		chain = TransformWriter{s, l[i], chain, from}
 	}
 	err := api.ForeachCmd(ctx, cmds, true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
 		captureCmdID := api.CmdNoID
 		if uint64(id) >= numberOfInitialCommands {
 			captureCmdID = id - api.CmdID(numberOfInitialCommands)
+		} else {
+			ctx = withOrigin(ctx, InitialCommandsOrigin)
 		}
 
 		return chain.MutateAndWrite(ctx, captureCmdID, cmd)
@@ -106,6 +118,7 @@ type TransformWriter struct {
 	S *api.GlobalState
 	T Transformer
 	O Writer
+	F string // Name of the transform writing to this writer.
 }
 
 func (p TransformWriter) State() *api.GlobalState {
@@ -113,6 +126,9 @@ func (p TransformWriter) State() *api.GlobalState {
 }
 
 func (p TransformWriter) MutateAndWrite(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	if id == api.CmdNoID {
+		ctx = withOrigin(ctx, p.F)
+	}
 	if config.SeparateMutateStates || p.O.State() != p.S {
 		if err := cmd.Mutate(ctx, id, p.S, nil, nil /* no builder, no watcher, just mutate */); err != nil {
 			return err
@@ -130,3 +146,48 @@ func (p TransformWriter) NotifyPreLoop(ctx context.Context) {
 func (p TransformWriter) NotifyPostLoop(ctx context.Context) {
 	p.T.PostLoop(ctx, p.O)
 }
+
+// InitialCommandsOrigin is the origin of the commands that rebuild the initial
+// state of the capture.
+const InitialCommandsOrigin = "initial commands"
+
+type originKey struct{}
+
+// Origin returns the name of the transform that emitted the command written
+// with ctx, or InitialCommandsOrigin for the commands rebuilding the initial
+// state. Only commands without an identifier are attributed to the first
+// transform that wrote them, for other commands an empty string is returned.
+func Origin(ctx context.Context) string {
+	o, _ := ctx.Value(originKey{}).(string)
+	return o
+}
+
+// withOrigin returns ctx with the origin o, unless ctx already holds one.
+func withOrigin(ctx context.Context, o string) context.Context {
+	if Origin(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, originKey{}, o)
+}
+
+// originWriter is the Writer at the end of the transform chain. It attributes
+// the commands without identifier to the last transform of the chain.
+type originWriter struct {
+	Writer
+	from string
+}
+
+func (w originWriter) MutateAndWrite(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	if id == api.CmdNoID {
+		ctx = withOrigin(ctx, w.from)
+	}
+	return w.Writer.MutateAndWrite(ctx, id, cmd)
+}
+
+// name returns the name used to identify the transform t.
+func name(t Transformer) string {
+	if n, ok := t.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return strings.Replace(fmt.Sprintf("%T", t), "*", "", -1)
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/api/transform"
+)
+
+// originRecorder is a Writer that records the origin of each command written
+// to it.
+type originRecorder struct {
+	transform.Recorder
+	origins []string
+}
+
+func (r *originRecorder) MutateAndWrite(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	r.origins = append(r.origins, transform.Origin(ctx))
+	return r.Recorder.MutateAndWrite(ctx, id, cmd)
+}
+
+func TestOrigin(t *testing.T) {
+	ctx := log.Testing(t)
+
+	cb := test.CommandBuilder{Arena: test.Cmds.Arena}
+	newCmd := func(tag uint64) api.Cmd {
+		return cb.CmdTypeMix(0, 10, 20, 30, 40, 50, 60, tag, 80, 90, 100, true, test.Voidᵖ(0x12345678), 100)
+	}
+
+	injector := &transform.Injector{}
+	injector.Inject(0, newCmd(3))
+	passthrough := transform.Transform("passthrough", func(ctx context.Context, id api.CmdID, cmd api.Cmd, out transform.Writer) error {
+		return out.MutateAndWrite(ctx, id, cmd)
+	})
+
+	r := &originRecorder{}
+	transforms := transform.Transforms{injector, passthrough}
+	err := transforms.TransformAll(ctx, []api.Cmd{newCmd(0), newCmd(1), newCmd(2)}, 1, r)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+
+	assert.For(ctx, "origins").ThatSlice(r.origins).Equals([]string{
+		transform.InitialCommandsOrigin,
+		"",
+		"transform.Injector",
+		"",
+	})
+}
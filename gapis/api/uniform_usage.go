@@ -0,0 +1,116 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// UniformUsageAnalyzer is the interface implemented by APIs that can report
+// the uniforms set by their commands and the uniform values used by their draw
+// calls.
+type UniformUsageAnalyzer interface {
+	// AnalyzeUniformUsage mutates the command cmd with the state s, recording
+	// the uniforms set by the command, and the values of the uniforms used by
+	// any draw calls it performs or executes as subcommands, to b.
+	AnalyzeUniformUsage(ctx context.Context, id CmdID, cmd Cmd, s *GlobalState, b *UniformUsageBuilder) error
+}
+
+// UniformUsageBuilder builds a UniformUsage from the uniform sets and draw
+// calls of a capture, in command order.
+type UniformUsageBuilder struct {
+	out     *UniformUsage
+	indices map[uniformKey]int
+	values  []map[id.ID]struct{}
+}
+
+type uniformKey struct {
+	program, name string
+}
+
+// NewUniformUsageBuilder returns a new, empty, UniformUsageBuilder.
+func NewUniformUsageBuilder() *UniformUsageBuilder {
+	return &UniformUsageBuilder{
+		out:     &UniformUsage{Uniforms: []*UniformStats{}},
+		indices: map[uniformKey]int{},
+	}
+}
+
+// uniform returns the stats of the named uniform of program, adding them if
+// needed.
+func (b *UniformUsageBuilder) uniform(program, name string) (*UniformStats, map[id.ID]struct{}) {
+	key := uniformKey{program, name}
+	i, ok := b.indices[key]
+	if !ok {
+		i = len(b.out.Uniforms)
+		b.indices[key] = i
+		b.out.Uniforms = append(b.out.Uniforms, &UniformStats{Program: program, Name: name})
+		b.values = append(b.values, map[id.ID]struct{}{})
+	}
+	return b.out.Uniforms[i], b.values[i]
+}
+
+// Set records that the command with the given indices set the named uniform
+// of program. read is false if the program does not read the uniform.
+func (b *UniformUsageBuilder) Set(program, name string, read bool, cmd []uint64) {
+	u, _ := b.uniform(program, name)
+	if u.Sets == 0 {
+		u.FirstSet = &path.Command{Indices: append([]uint64{}, cmd...)}
+	}
+	u.Sets++
+	u.Read = u.Read || read
+}
+
+// Draw records that a draw call used the value of the named uniform of
+// program.
+func (b *UniformUsageBuilder) Draw(program, name string, value []byte) {
+	u, values := b.uniform(program, name)
+	u.Read = true
+	u.Draws++
+	values[id.OfBytes(value)] = struct{}{}
+	u.DistinctValues = uint32(len(values))
+}
+
+// Usage returns the built UniformUsage, with the suggestions for each of the
+// uniforms. The Capture of each FirstSet path is left nil.
+func (b *UniformUsageBuilder) Usage() *UniformUsage {
+	// Keep the uniforms of each program together, in the order the programs
+	// are first used.
+	programs := map[string]int{}
+	for _, u := range b.out.Uniforms {
+		if _, ok := programs[u.Program]; !ok {
+			programs[u.Program] = len(programs)
+		}
+	}
+	out := &UniformUsage{Uniforms: append([]*UniformStats{}, b.out.Uniforms...)}
+	sort.SliceStable(out.Uniforms, func(i, j int) bool {
+		return programs[out.Uniforms[i].Program] < programs[out.Uniforms[j].Program]
+	})
+	for _, u := range out.Uniforms {
+		switch {
+		case !u.Read:
+			u.Suggestion = UniformSuggestion_RemoveUniform
+		case u.DistinctValues == 1:
+			u.Suggestion = UniformSuggestion_MakeConstant
+		default:
+			u.Suggestion = UniformSuggestion_NoSuggestion
+		}
+	}
+	return out
+}
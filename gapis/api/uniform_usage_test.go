@@ -0,0 +1,68 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestUniformUsageBuilder(t *testing.T) {
+	ctx := log.Testing(t)
+	b := api.NewUniformUsageBuilder()
+
+	b.Set("Program 1", "color", true, []uint64{1})
+	b.Set("Program 2", "<location -1>", false, []uint64{2})
+	b.Draw("Program 1", "color", []byte{1, 2, 3, 4})
+	b.Draw("Program 1", "mvp", []byte{5})
+	b.Set("Program 1", "color", true, []uint64{3})
+	b.Draw("Program 1", "color", []byte{1, 2, 3, 4})
+	b.Set("Program 1", "mvp", true, []uint64{4})
+	b.Draw("Program 1", "mvp", []byte{6})
+
+	assert.For(ctx, "usage").That(b.Usage().Uniforms).DeepEquals([]*api.UniformStats{
+		{
+			Program:        "Program 1",
+			Name:           "color",
+			Read:           true,
+			Sets:           2,
+			FirstSet:       &path.Command{Indices: []uint64{1}},
+			Draws:          2,
+			DistinctValues: 1,
+			Suggestion:     api.UniformSuggestion_MakeConstant,
+		},
+		{
+			Program:        "Program 1",
+			Name:           "mvp",
+			Read:           true,
+			Sets:           1,
+			FirstSet:       &path.Command{Indices: []uint64{4}},
+			Draws:          2,
+			DistinctValues: 2,
+			Suggestion:     api.UniformSuggestion_NoSuggestion,
+		},
+		{
+			Program:    "Program 2",
+			Name:       "<location -1>",
+			Sets:       1,
+			FirstSet:   &path.Command{Indices: []uint64{2}},
+			Suggestion: api.UniformSuggestion_RemoveUniform,
+		},
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The units that API definitions may tag fields and command parameters with,
+// using the @units annotation.
+const (
+	// UnitsBytes is the unit of sizes and offsets of memory.
+	UnitsBytes = "bytes"
+	// UnitsPixels is the unit of positions and sizes of images and viewports.
+	UnitsPixels = "pixels"
+	// UnitsNanoseconds is the unit of durations and timeouts.
+	UnitsNanoseconds = "ns"
+	// UnitsNormalized is the unit of floats in the range [0, 1], such as
+	// depths and blend factors.
+	UnitsNormalized = "normalized"
+)
+
+// FormatWithUnits returns the value v, tagged with the given units, formatted
+// for display. For example 65536 bytes is formatted as "64 KB". Values that
+// are not numbers, or that have unknown or no units, are formatted with %v.
+func FormatWithUnits(v interface{}, units string) string {
+	n, ok := toFloat(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	switch units {
+	case UnitsBytes:
+		if isAllOnes(v) {
+			return "whole size" // The value of sizes such as VK_WHOLE_SIZE.
+		}
+		return scaled(n, 1024, "B", "KB", "MB", "GB", "TB")
+	case UnitsPixels:
+		return fmt.Sprintf("%v px", v)
+	case UnitsNanoseconds:
+		if n == float64(^uint64(0)) {
+			return "infinite" // The value of timeouts that never expire.
+		}
+		return scaled(n, 1000, "ns", "µs", "ms", "s")
+	case UnitsNormalized:
+		return fmt.Sprintf("%v (%v%%)", v, formatFloat(n*100))
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// scaled returns n divided by the largest power of step that keeps it at
+// least 1, followed by the suffix for that power.
+func scaled(n, step float64, suffixes ...string) string {
+	i := 0
+	for ; i < len(suffixes)-1 && (n >= step || n <= -step); i++ {
+		n /= step
+	}
+	return formatFloat(n) + " " + suffixes[i]
+}
+
+// formatFloat returns n with at most two decimal places and without trailing
+// zeros.
+func formatFloat(n float64) string {
+	return strings.TrimSuffix(strings.TrimRight(strconv.FormatFloat(n, 'f', 2, 64), "0"), ".")
+}
+
+// isAllOnes returns true if v is an unsigned integer with all its bits set.
+func isAllOnes(v interface{}) bool {
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return r.Uint() == ^uint64(0)>>(64-uint(r.Type().Bits()))
+	default:
+		return false
+	}
+}
+
+// toFloat returns v as a float64 if it is a number.
+func toFloat(v interface{}) (float64, bool) {
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(r.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(r.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return r.Float(), true
+	default:
+		return 0, false
+	}
+}
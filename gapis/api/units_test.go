@@ -0,0 +1,51 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+func TestFormatWithUnits(t *testing.T) {
+	ctx := log.Testing(t)
+	type deviceSize uint64
+	for _, test := range []struct {
+		value    interface{}
+		units    string
+		expected string
+	}{
+		{uint32(512), api.UnitsBytes, "512 B"},
+		{deviceSize(65536), api.UnitsBytes, "64 KB"},
+		{int64(3 << 19), api.UnitsBytes, "1.5 MB"},
+		{^deviceSize(0), api.UnitsBytes, "whole size"},
+		{^uint32(0), api.UnitsBytes, "whole size"},
+		{int64(-1), api.UnitsBytes, "-1 B"},
+		{int32(1920), api.UnitsPixels, "1920 px"},
+		{uint64(250), api.UnitsNanoseconds, "250 ns"},
+		{uint64(16666666), api.UnitsNanoseconds, "16.67 ms"},
+		{^uint64(0), api.UnitsNanoseconds, "infinite"},
+		{float32(0.5), api.UnitsNormalized, "0.5 (50%)"},
+		{uint32(65536), "", "65536"},
+		{uint32(65536), "furlongs", "65536"},
+		{"text", api.UnitsBytes, "text"},
+	} {
+		assert.For(ctx, "FormatWithUnits(%v, %v)", test.value, test.units).
+			ThatString(api.FormatWithUnits(test.value, test.units)).Equals(test.expected)
+	}
+}
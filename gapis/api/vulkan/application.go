@@ -0,0 +1,43 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.ApplicationNamer(API{})
+)
+
+// ApplicationName implements api.ApplicationNamer.
+// The name is taken from the VkApplicationInfo of the first instance that
+// provided one.
+func (API) ApplicationName(ctx context.Context, s *api.GlobalState) string {
+	st := GetState(s)
+	if st == nil {
+		return ""
+	}
+	for _, k := range st.Instances().Keys() {
+		info := st.Instances().Get(k).ApplicationInfo()
+		if !info.IsNil() && info.ApplicationName() != "" {
+			return info.ApplicationName()
+		}
+	}
+	return ""
+}
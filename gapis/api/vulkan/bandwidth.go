@@ -0,0 +1,210 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.BandwidthEstimator(API{})
+)
+
+// bandwidthEstimate accumulates the estimated bandwidth of the subcommands
+// executed by a single command.
+type bandwidthEstimate struct {
+	out *api.FrameBandwidth
+	// The images and buffers already accounted for in the current render
+	// pass. Resources used by multiple draws of a render pass are assumed to
+	// be fetched from memory only once.
+	images  map[VkImageView]struct{}
+	buffers map[VkBuffer]struct{}
+}
+
+// EstimateBandwidth implements api.BandwidthEstimator.
+// Attachments are estimated from the load and store operations of each render
+// pass, and are attributed to the command that begins the render pass.
+// Textures and buffers are estimated from the whole of the bound image level
+// or buffer range, once per render pass. The data of vkCmdUpdateBuffer is not
+// estimated here, as it is counted from the observed reads of the command.
+func (API) EstimateBandwidth(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState, b *api.FrameBandwidth) error {
+	c := GetState(s)
+	e := &bandwidthEstimate{
+		out:     b,
+		images:  map[VkImageView]struct{}{},
+		buffers: map[VkBuffer]struct{}{},
+	}
+	c.PostSubcommand = func(ref interface{}) {
+		if cr, ok := ref.(CommandReferenceʳ); ok {
+			e.subcommand(ctx, c, GetCommandArgs(ctx, cr, c))
+		}
+	}
+	defer func() { c.PostSubcommand = nil }()
+	return cmd.Mutate(ctx, id, s, nil, nil)
+}
+
+func (e *bandwidthEstimate) subcommand(ctx context.Context, c *State, args interface{}) {
+	switch args := args.(type) {
+	case VkCmdBeginRenderPassArgsʳ:
+		e.images = map[VkImageView]struct{}{}
+		e.buffers = map[VkBuffer]struct{}{}
+		e.renderPass(c, args)
+	case VkCmdDrawArgsʳ, VkCmdDrawIndexedArgsʳ, VkCmdDrawIndirectArgsʳ, VkCmdDrawIndexedIndirectArgsʳ,
+		VkCmdDrawIndirectCountKHRArgsʳ, VkCmdDrawIndexedIndirectCountKHRArgsʳ,
+		VkCmdDrawIndirectCountAMDArgsʳ, VkCmdDrawIndexedIndirectCountAMDArgsʳ:
+		e.draw(c)
+	case VkCmdCopyBufferArgsʳ:
+		for _, r := range args.CopyRegions().All() {
+			e.out.Add(api.BandwidthCategory_BUFFERS, uint64(r.Size()), uint64(r.Size()))
+		}
+	case VkCmdFillBufferArgsʳ:
+		size := uint64(args.Size())
+		if args.Size() == ^VkDeviceSize(0) {
+			size = e.bufferSize(c, args.Buffer(), args.DstOffset())
+		}
+		e.out.Add(api.BandwidthCategory_BUFFERS, 0, size)
+	}
+}
+
+// renderPass adds the attachment loads and stores of the render pass begun
+// by args.
+func (e *bandwidthEstimate) renderPass(c *State, args VkCmdBeginRenderPassArgsʳ) {
+	rp, ok := c.RenderPasses().Lookup(args.RenderPass())
+	if !ok {
+		return
+	}
+	fb, ok := c.Framebuffers().Lookup(args.Framebuffer())
+	if !ok {
+		return
+	}
+	for i, desc := range rp.AttachmentDescriptions().All() {
+		view, ok := fb.ImageAttachments().Lookup(i)
+		if !ok {
+			continue
+		}
+		size := imageViewLevelSize(view)
+		var read, write uint64
+		if desc.LoadOp() == VkAttachmentLoadOp_VK_ATTACHMENT_LOAD_OP_LOAD ||
+			desc.StencilLoadOp() == VkAttachmentLoadOp_VK_ATTACHMENT_LOAD_OP_LOAD {
+			read = size
+		}
+		if desc.StoreOp() == VkAttachmentStoreOp_VK_ATTACHMENT_STORE_OP_STORE ||
+			desc.StencilStoreOp() == VkAttachmentStoreOp_VK_ATTACHMENT_STORE_OP_STORE {
+			write = size
+		}
+		e.out.Add(api.BandwidthCategory_ATTACHMENTS, read, write)
+	}
+}
+
+// draw adds the images and buffers bound for the last draw that have not yet
+// been accounted for in the current render pass.
+func (e *bandwidthEstimate) draw(c *State) {
+	queue := c.LastBoundQueue()
+	if queue.IsNil() {
+		return
+	}
+	ldi, ok := c.LastDrawInfos().Lookup(queue.VulkanHandle())
+	if !ok {
+		return
+	}
+
+	buffer := func(b VkBuffer, offset, size VkDeviceSize) {
+		if _, seen := e.buffers[b]; seen {
+			return
+		}
+		e.buffers[b] = struct{}{}
+		if size == ^VkDeviceSize(0) {
+			e.out.Add(api.BandwidthCategory_BUFFERS, e.bufferSize(c, b, offset), 0)
+		} else {
+			e.out.Add(api.BandwidthCategory_BUFFERS, uint64(size), 0)
+		}
+	}
+
+	for _, vb := range ldi.BoundVertexBuffers().All() {
+		if !vb.Buffer().IsNil() {
+			buffer(vb.Buffer().VulkanHandle(), vb.Offset(), vb.Range())
+		}
+	}
+	if ib := ldi.BoundIndexBuffer(); !ib.IsNil() && !ib.BoundBuffer().Buffer().IsNil() {
+		bb := ib.BoundBuffer()
+		buffer(bb.Buffer().VulkanHandle(), bb.Offset(), bb.Range())
+	}
+
+	for _, set := range ldi.DescriptorSets().All() {
+		if set.IsNil() {
+			continue
+		}
+		for _, binding := range set.Bindings().All() {
+			switch binding.BindingType() {
+			case VkDescriptorType_VK_DESCRIPTOR_TYPE_COMBINED_IMAGE_SAMPLER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_SAMPLED_IMAGE,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_IMAGE:
+				for _, info := range binding.ImageBinding().All() {
+					if _, seen := e.images[info.ImageView()]; seen {
+						continue
+					}
+					e.images[info.ImageView()] = struct{}{}
+					if view, ok := c.ImageViews().Lookup(info.ImageView()); ok {
+						e.out.Add(api.BandwidthCategory_TEXTURES, imageViewLevelSize(view), 0)
+					}
+				}
+			case VkDescriptorType_VK_DESCRIPTOR_TYPE_UNIFORM_BUFFER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_BUFFER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_UNIFORM_BUFFER_DYNAMIC,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_BUFFER_DYNAMIC:
+				for _, info := range binding.BufferBinding().All() {
+					buffer(info.Buffer(), info.Offset(), info.Range())
+				}
+			}
+		}
+	}
+}
+
+// bufferSize returns the size of the buffer b from offset to its end.
+func (e *bandwidthEstimate) bufferSize(c *State, b VkBuffer, offset VkDeviceSize) uint64 {
+	buf, ok := c.Buffers().Lookup(b)
+	if !ok || buf.Info().Size() < offset {
+		return 0
+	}
+	return uint64(buf.Info().Size() - offset)
+}
+
+// imageViewLevelSize returns the size in bytes of the base mip level of each
+// of the layers and aspects of the image viewed by view.
+func imageViewLevelSize(view ImageViewObjectʳ) uint64 {
+	img := view.Image()
+	if img.IsNil() {
+		return 0
+	}
+	rng := view.SubresourceRange()
+	size := uint64(0)
+	for bit, aspect := range img.Aspects().All() {
+		if VkImageAspectFlags(bit)&rng.AspectMask() == 0 {
+			continue
+		}
+		for layer, l := range aspect.Layers().All() {
+			if layer < rng.BaseArrayLayer() || layer-rng.BaseArrayLayer() >= rng.LayerCount() {
+				continue
+			}
+			if level, ok := l.Levels().Lookup(rng.BaseMipLevel()); ok {
+				size += level.Data().Size()
+			}
+		}
+	}
+	return size
+}
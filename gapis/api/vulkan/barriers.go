@@ -0,0 +1,119 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Interface compliance test
+var (
+	_ = api.BarrierLister(API{})
+)
+
+// broadStages are the pipeline stages that cover whole groups of commands.
+const broadStages = VkPipelineStageFlags(VkPipelineStageFlagBits_VK_PIPELINE_STAGE_ALL_COMMANDS_BIT |
+	VkPipelineStageFlagBits_VK_PIPELINE_STAGE_ALL_GRAPHICS_BIT)
+
+// ListBarriers implements api.BarrierLister.
+func (API) ListBarriers(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState, out *api.Barriers) error {
+	c := GetState(s)
+	c.PostSubcommand = func(ref interface{}) {
+		if cr, ok := ref.(CommandReferenceʳ); ok {
+			if b := subcommandBarrier(GetCommandArgs(ctx, cr, c)); b != nil {
+				b.Command = &path.Command{Indices: append([]uint64{}, c.SubCmdIdx...)}
+				out.Barriers = append(out.Barriers, b)
+			}
+		}
+	}
+	defer func() { c.PostSubcommand = nil }()
+	return cmd.Mutate(ctx, id, s, nil, nil)
+}
+
+// subcommandBarrier returns the barrier recorded by the subcommand with the arguments
+// args, or nil if the subcommand is not a barrier or event command.
+func subcommandBarrier(args interface{}) *api.Barrier {
+	switch args := args.(type) {
+	case VkCmdPipelineBarrierArgsʳ:
+		b := newBarrier(api.Barrier_PIPELINE_BARRIER, args.SrcStageMask(), args.DstStageMask())
+		b.MemoryBarriers = uint32(args.MemoryBarriers().Len())
+		addBarrierResources(b, args.BufferMemoryBarriers(), args.ImageMemoryBarriers())
+		return b
+	case VkCmdWaitEventsArgsʳ:
+		b := newBarrier(api.Barrier_WAIT_EVENTS, args.SrcStageMask(), args.DstStageMask())
+		b.MemoryBarriers = uint32(args.MemoryBarriers().Len())
+		addBarrierResources(b, args.BufferMemoryBarriers(), args.ImageMemoryBarriers())
+		return b
+	case VkCmdSetEventArgsʳ:
+		return newBarrier(api.Barrier_SET_EVENT, args.StageMask(), 0)
+	case VkCmdResetEventArgsʳ:
+		return newBarrier(api.Barrier_RESET_EVENT, args.StageMask(), 0)
+	}
+	return nil
+}
+
+func newBarrier(ty api.Barrier_Type, src, dst VkPipelineStageFlags) *api.Barrier {
+	b := &api.Barrier{
+		Type:        ty,
+		SrcStages:   pipelineStages(src),
+		OverlyBroad: (src|dst)&broadStages != 0,
+		Resources:   []*api.BarrierResource{},
+	}
+	if dst != 0 {
+		b.DstStages = pipelineStages(dst)
+	}
+	return b
+}
+
+// addBarrierResources adds the resources of the buffer and image memory
+// barriers to b.
+func addBarrierResources(b *api.Barrier, buffers U32ːVkBufferMemoryBarrierᵐ, images U32ːVkImageMemoryBarrierᵐ) {
+	for _, i := range buffers.Keys() {
+		m := buffers.Get(i)
+		b.Resources = append(b.Resources, &api.BarrierResource{
+			Kind:      api.FrameGraphResource_BUFFER,
+			Handle:    uint64(m.Buffer()),
+			Label:     fmt.Sprintf("Buffer %v", m.Buffer()),
+			SrcAccess: accessFlags(m.SrcAccessMask()),
+			DstAccess: accessFlags(m.DstAccessMask()),
+		})
+	}
+	for _, i := range images.Keys() {
+		m := images.Get(i)
+		b.Resources = append(b.Resources, &api.BarrierResource{
+			Kind:      api.FrameGraphResource_IMAGE,
+			Handle:    uint64(m.Image()),
+			Label:     fmt.Sprintf("Image %v", m.Image()),
+			SrcAccess: accessFlags(m.SrcAccessMask()),
+			DstAccess: accessFlags(m.DstAccessMask()),
+			OldLayout: api.CreateEnumDataValue("VkImageLayout", m.OldLayout()),
+			NewLayout: api.CreateEnumDataValue("VkImageLayout", m.NewLayout()),
+		})
+	}
+}
+
+// pipelineStages returns the names of the stages of f.
+func pipelineStages(f VkPipelineStageFlags) *api.DataValue {
+	return api.CreateBitfieldDataValue("VkPipelineStageFlagBits", f, VkPipelineStageFlagBitsConstants(), API{})
+}
+
+// accessFlags returns the names of the access types of f.
+func accessFlags(f VkAccessFlags) *api.DataValue {
+	return api.CreateBitfieldDataValue("VkAccessFlagBits", f, VkAccessFlagBitsConstants(), API{})
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Interface compliance test
+var (
+	_ = api.BindChurnAnalyzer(API{})
+)
+
+// bindChurn counts the binding commands of the render passes executed by a
+// single command.
+type bindChurn struct {
+	out *api.BindChurn
+	// The render pass currently being counted, or nil if outside of a render
+	// pass.
+	pass *api.RenderPassChurn
+	// The distinct pipelines, and the number of non-redundant pipeline binds,
+	// of the current render pass.
+	pipelines     map[VkPipeline]struct{}
+	pipelineBinds uint32
+	// The command buffer of the last subcommand, and the objects bound by it.
+	buffer        VkCommandBuffer
+	boundPipeline map[VkPipelineBindPoint]VkPipeline
+	boundSets     map[VkPipelineBindPoint]map[uint32]VkDescriptorSet
+	// True if the last binding command was a descriptor set bind.
+	lastWasSetBind bool
+}
+
+// AnalyzeBindChurn implements api.BindChurnAnalyzer.
+func (API) AnalyzeBindChurn(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState, out *api.BindChurn) error {
+	c := GetState(s)
+	b := &bindChurn{out: out}
+	c.PostSubcommand = func(ref interface{}) {
+		if cr, ok := ref.(CommandReferenceʳ); ok {
+			b.subcommand(ctx, c, cr)
+		}
+	}
+	defer func() { c.PostSubcommand = nil }()
+	err := cmd.Mutate(ctx, id, s, nil, nil)
+	b.endRenderPass()
+	return err
+}
+
+func (b *bindChurn) subcommand(ctx context.Context, c *State, cr CommandReferenceʳ) {
+	if cr.Buffer() != b.buffer || b.boundPipeline == nil {
+		// Bound objects are not inherited between command buffers.
+		b.buffer = cr.Buffer()
+		b.boundPipeline = map[VkPipelineBindPoint]VkPipeline{}
+		b.boundSets = map[VkPipelineBindPoint]map[uint32]VkDescriptorSet{}
+		b.lastWasSetBind = false
+	}
+
+	switch args := GetCommandArgs(ctx, cr, c).(type) {
+	case VkCmdBeginRenderPassArgsʳ:
+		b.endRenderPass()
+		b.pass = &api.RenderPassChurn{
+			Begin: &path.Command{Indices: append([]uint64{}, c.SubCmdIdx...)},
+		}
+		b.pipelines = map[VkPipeline]struct{}{}
+		b.pipelineBinds = 0
+	case VkCmdEndRenderPassArgsʳ:
+		b.endRenderPass()
+	case VkCmdBindPipelineArgsʳ:
+		b.lastWasSetBind = false
+		redundant := b.boundPipeline[args.PipelineBindPoint()] == args.Pipeline()
+		b.boundPipeline[args.PipelineBindPoint()] = args.Pipeline()
+		if b.pass != nil {
+			b.pass.PipelineBinds++
+			if redundant {
+				b.pass.RedundantBinds++
+			} else {
+				b.pipelineBinds++
+				b.pipelines[args.Pipeline()] = struct{}{}
+			}
+		}
+	case VkCmdBindDescriptorSetsArgsʳ:
+		sets, ok := b.boundSets[args.PipelineBindPoint()]
+		if !ok {
+			sets = map[uint32]VkDescriptorSet{}
+			b.boundSets[args.PipelineBindPoint()] = sets
+		}
+		redundant := args.DynamicOffsets().Len() == 0
+		for i, set := range args.DescriptorSets().All() {
+			idx := args.FirstSet() + i
+			if bound, ok := sets[idx]; !ok || bound != set {
+				redundant = false
+			}
+			sets[idx] = set
+		}
+		if b.pass != nil {
+			b.pass.DescriptorSetBinds++
+			switch {
+			case redundant:
+				b.pass.RedundantBinds++
+			case b.lastWasSetBind:
+				b.pass.MergeableBinds++
+			}
+		}
+		b.lastWasSetBind = true
+	case VkCmdPushConstantsArgsʳ:
+		if b.pass != nil {
+			b.pass.PushConstantUpdates++
+		}
+	case VkCmdDrawArgsʳ, VkCmdDrawIndexedArgsʳ, VkCmdDrawIndirectArgsʳ, VkCmdDrawIndexedIndirectArgsʳ,
+		VkCmdDrawIndirectCountKHRArgsʳ, VkCmdDrawIndexedIndirectCountKHRArgsʳ,
+		VkCmdDrawIndirectCountAMDArgsʳ, VkCmdDrawIndexedIndirectCountAMDArgsʳ:
+		b.lastWasSetBind = false
+		if b.pass != nil {
+			b.pass.Draws++
+		}
+	}
+}
+
+// endRenderPass completes the counts of the current render pass, if any, and
+// adds it to the output.
+func (b *bindChurn) endRenderPass() {
+	if b.pass == nil {
+		return
+	}
+	b.pass.AvoidablePipelineBinds = b.pipelineBinds - uint32(len(b.pipelines))
+	b.out.RenderPasses = append(b.out.RenderPasses, b.pass)
+	b.pass = nil
+}
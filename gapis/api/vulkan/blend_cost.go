@@ -0,0 +1,62 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.BlendAnalyzer(API{})
+)
+
+// AnalyzeBlending implements api.BlendAnalyzer.
+// Only the draw calls executed by queue submissions are reported.
+func (API) AnalyzeBlending(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState, draw func(indices []uint64, blended bool)) error {
+	c := GetState(s)
+	c.PostSubcommand = func(ref interface{}) {
+		cr, ok := ref.(CommandReferenceʳ)
+		if !ok {
+			return
+		}
+		switch GetCommandArgs(ctx, cr, c).(type) {
+		case VkCmdDrawArgsʳ, VkCmdDrawIndexedArgsʳ, VkCmdDrawIndirectArgsʳ, VkCmdDrawIndexedIndirectArgsʳ,
+			VkCmdDrawIndirectCountKHRArgsʳ, VkCmdDrawIndexedIndirectCountKHRArgsʳ,
+			VkCmdDrawIndirectCountAMDArgsʳ, VkCmdDrawIndexedIndirectCountAMDArgsʳ:
+		default:
+			return
+		}
+
+		blended := false
+		if queue := c.LastBoundQueue(); !queue.IsNil() {
+			if ldi, ok := c.LastDrawInfos().Lookup(queue.VulkanHandle()); ok && !ldi.GraphicsPipeline().IsNil() {
+				if cb := ldi.GraphicsPipeline().ColorBlendState(); !cb.IsNil() {
+					for _, a := range cb.Attachments().All() {
+						if a.BlendEnable() != 0 && a.ColorWriteMask() != 0 {
+							blended = true
+							break
+						}
+					}
+				}
+			}
+		}
+		draw(append([]uint64{}, c.SubCmdIdx...), blended)
+	}
+	defer func() { c.PostSubcommand = nil }()
+	return cmd.Mutate(ctx, id, s, nil, nil)
+}
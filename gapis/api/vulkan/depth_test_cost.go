@@ -0,0 +1,427 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/transform"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/replay/builder"
+	"github.com/google/gapid/gapis/replay/value"
+	"github.com/google/gapid/gapis/service"
+)
+
+var (
+	_ = transform.Transformer(&depthTestQueries{})
+)
+
+// depthTestConfig is a replay.Config used by depthTestRequests. The graphics
+// pipelines are created with their depth test disabled if disableDepthTest is
+// true.
+type depthTestConfig struct {
+	disableDepthTest bool
+}
+
+// depthTestRequest requests the number of samples that pass the depth and
+// stencil tests for each draw call of the command buffers submitted by the
+// commands in the range [begin, end).
+type depthTestRequest struct {
+	begin, end api.CmdID
+}
+
+// depthTestSamples is the number of samples of a draw call that passed the
+// depth and stencil tests.
+type depthTestSamples struct {
+	draw    api.SubCmdIdx
+	samples uint64
+}
+
+// depthTestQueries is a transformation that records an occlusion query around
+// each draw call of the primary command buffers submitted by the commands in
+// a range, and posts back the results once the submission has completed.
+// Command buffers that begin queries of their own, and the draw calls of
+// secondary command buffers, are not measured.
+type depthTestQueries struct {
+	replay.EndOfReplay
+	begin, end       api.CmdID
+	disableDepthTest bool
+	samples          []depthTestSamples
+}
+
+func newDepthTestQueries(disableDepthTest bool) *depthTestQueries {
+	return &depthTestQueries{
+		begin:            api.CmdNoID,
+		disableDepthTest: disableDepthTest,
+	}
+}
+
+// add extends the range of the measured commands to include [begin, end).
+func (t *depthTestQueries) add(begin, end api.CmdID) {
+	if begin < t.begin {
+		t.begin = begin
+	}
+	if end > t.end {
+		t.end = end
+	}
+}
+
+func (t *depthTestQueries) Transform(ctx context.Context, id api.CmdID, cmd api.Cmd, out transform.Writer) error {
+	ctx = log.Enter(ctx, "depthTestQueries")
+	switch cmd := cmd.(type) {
+	case *VkCreateGraphicsPipelines:
+		if t.disableDepthTest {
+			return t.rewriteGraphicsPipelines(ctx, id, cmd, out)
+		}
+	case *VkQueueSubmit:
+		if id >= t.begin && id < t.end {
+			return t.rewriteQueueSubmit(ctx, id, cmd, out)
+		}
+	}
+	return out.MutateAndWrite(ctx, id, cmd)
+}
+
+// rewriteGraphicsPipelines creates the pipelines of cmd with their depth test
+// disabled, so that every rasterized sample that passes the stencil test is
+// counted by the occlusion queries.
+func (t *depthTestQueries) rewriteGraphicsPipelines(ctx context.Context, id api.CmdID, cmd *VkCreateGraphicsPipelines, out transform.Writer) error {
+	s := out.State()
+	l := s.MemoryLayout
+	cb := CommandBuilder{Thread: cmd.Thread(), Arena: s.Arena}
+	cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+
+	count := uint64(cmd.CreateInfoCount())
+	infos := cmd.PCreateInfos().Slice(0, count, l).MustRead(ctx, cmd, s, nil)
+	reads := []api.AllocResult{}
+	defer func() {
+		for _, r := range reads {
+			r.Free()
+		}
+	}()
+	for i, info := range infos {
+		if info.PDepthStencilState().IsNullptr() {
+			continue
+		}
+		depthStencil := info.PDepthStencilState().MustRead(ctx, cmd, s, nil)
+		depthStencil.SetDepthTestEnable(0)
+		data := s.AllocDataOrPanic(ctx, depthStencil)
+		reads = append(reads, data)
+		info.SetPDepthStencilState(NewVkPipelineDepthStencilStateCreateInfoᶜᵖ(data.Ptr()))
+		infos[i] = info
+	}
+	infosData := s.AllocDataOrPanic(ctx, infos)
+	reads = append(reads, infosData)
+
+	newCmd := cb.VkCreateGraphicsPipelines(cmd.Device(), cmd.PipelineCache(),
+		cmd.CreateInfoCount(), infosData.Ptr(), cmd.PAllocator(), cmd.PPipelines(), cmd.Result())
+	for _, e := range cmd.Extras().All() {
+		if _, ok := e.(*api.CmdObservations); !ok {
+			newCmd.Extras().Add(e)
+		}
+	}
+	observations := cmd.Extras().Observations()
+	for _, r := range observations.Reads {
+		newCmd.AddRead(r.Range, r.ID)
+	}
+	for _, r := range reads {
+		newCmd.AddRead(r.Data())
+	}
+	for _, w := range observations.Writes {
+		newCmd.AddWrite(w.Range, w.ID)
+	}
+	return out.MutateAndWrite(ctx, id, newCmd)
+}
+
+// measuredDraws returns the indices of the draw calls of the command buffer
+// cmdBuf, or nil if the command buffer cannot be measured.
+func measuredDraws(ctx context.Context, c *State, cmdBuf VkCommandBuffer) []uint64 {
+	buf, ok := c.CommandBuffers().Lookup(cmdBuf)
+	if !ok || buf.Level() != VkCommandBufferLevel_VK_COMMAND_BUFFER_LEVEL_PRIMARY {
+		return nil
+	}
+	draws := []uint64{}
+	for i := 0; i < buf.CommandReferences().Len(); i++ {
+		switch GetCommandArgs(ctx, buf.CommandReferences().Get(uint32(i)), c).(type) {
+		case VkCmdDrawArgsʳ, VkCmdDrawIndexedArgsʳ, VkCmdDrawIndirectArgsʳ, VkCmdDrawIndexedIndirectArgsʳ,
+			VkCmdDrawIndirectCountKHRArgsʳ, VkCmdDrawIndexedIndirectCountKHRArgsʳ,
+			VkCmdDrawIndirectCountAMDArgsʳ, VkCmdDrawIndexedIndirectCountAMDArgsʳ:
+			draws = append(draws, uint64(i))
+		case VkCmdBeginQueryArgsʳ:
+			return nil
+		}
+	}
+	return draws
+}
+
+// rewriteQueueSubmit submits copies of the command buffers of submit with an
+// occlusion query around each of their draw calls, and posts back the
+// results of the queries.
+func (t *depthTestQueries) rewriteQueueSubmit(ctx context.Context, id api.CmdID, submit *VkQueueSubmit, out transform.Writer) error {
+	s := out.State()
+	l := s.MemoryLayout
+	c := GetState(s)
+	cb := CommandBuilder{Thread: submit.Thread(), Arena: s.Arena}
+	submit.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+
+	infos := submit.PSubmits().Slice(0, uint64(submit.SubmitCount()), l).MustRead(ctx, submit, s, nil)
+	cmdBufs := make([][]VkCommandBuffer, len(infos))
+	draws := make([][][]uint64, len(infos))
+	count := uint32(0)
+	for i, info := range infos {
+		cmdBufs[i] = info.PCommandBuffers().Slice(0, uint64(info.CommandBufferCount()), l).MustRead(ctx, submit, s, nil)
+		draws[i] = make([][]uint64, len(cmdBufs[i]))
+		for j, cmdBuf := range cmdBufs[i] {
+			draws[i][j] = measuredDraws(ctx, c, cmdBuf)
+			count += uint32(len(draws[i][j]))
+		}
+	}
+	if count == 0 {
+		return out.MutateAndWrite(ctx, id, submit)
+	}
+
+	device := c.Queues().Get(submit.Queue()).Device()
+	precise := c.Devices().Get(device).EnabledFeatures().OcclusionQueryPrecise() != 0
+	pool := t.createQueryPool(ctx, cb, out, device, count)
+
+	reads := []api.AllocResult{}
+	defer func() {
+		for _, r := range reads {
+			r.Free()
+		}
+	}()
+	measured := []api.SubCmdIdx{}
+	for i, info := range infos {
+		for j, cmdBuf := range cmdBufs[i] {
+			if len(draws[i][j]) == 0 {
+				continue
+			}
+			first := uint32(len(measured))
+			cmdBufs[i][j] = t.recordWithQueries(ctx, cb, out, cmdBuf, draws[i][j], pool, first, precise)
+			for _, d := range draws[i][j] {
+				measured = append(measured, api.SubCmdIdx{uint64(id), uint64(i), uint64(j), d})
+			}
+		}
+		if len(cmdBufs[i]) > 0 {
+			data := s.AllocDataOrPanic(ctx, cmdBufs[i])
+			reads = append(reads, data)
+			info.SetPCommandBuffers(NewVkCommandBufferᶜᵖ(data.Ptr()))
+			infos[i] = info
+		}
+	}
+	infosData := s.AllocDataOrPanic(ctx, infos)
+	reads = append(reads, infosData)
+
+	newCmd := cb.VkQueueSubmit(submit.Queue(), submit.SubmitCount(), infosData.Ptr(),
+		submit.Fence(), submit.Result())
+	for _, e := range submit.Extras().All() {
+		if _, ok := e.(*api.CmdObservations); !ok {
+			newCmd.Extras().Add(e)
+		}
+	}
+	observations := submit.Extras().Observations()
+	for _, r := range observations.Reads {
+		newCmd.AddRead(r.Range, r.ID)
+	}
+	for _, r := range reads {
+		newCmd.AddRead(r.Data())
+	}
+	for _, w := range observations.Writes {
+		newCmd.AddWrite(w.Range, w.ID)
+	}
+	if err := out.MutateAndWrite(ctx, id, newCmd); err != nil {
+		return err
+	}
+
+	writeEach(ctx, out, cb.VkQueueWaitIdle(submit.Queue(), VkResult_VK_SUCCESS))
+	t.postResults(ctx, cb, out, device, pool, measured)
+	return nil
+}
+
+// createQueryPool creates an occlusion query pool of count queries.
+func (t *depthTestQueries) createQueryPool(ctx context.Context, cb CommandBuilder, out transform.Writer, device VkDevice, count uint32) VkQueryPool {
+	s := out.State()
+	pool := VkQueryPool(newUnusedID(false, func(id uint64) bool {
+		return GetState(s).QueryPools().Contains(VkQueryPool(id))
+	}))
+	poolData := s.AllocDataOrPanic(ctx, pool)
+	defer poolData.Free()
+	infoData := s.AllocDataOrPanic(ctx, NewVkQueryPoolCreateInfo(s.Arena,
+		VkStructureType_VK_STRUCTURE_TYPE_QUERY_POOL_CREATE_INFO, // sType
+		0,                                   // pNext
+		0,                                   // flags
+		VkQueryType_VK_QUERY_TYPE_OCCLUSION, // queryType
+		count,                               // queryCount
+		0,                                   // pipelineStatistics
+	))
+	defer infoData.Free()
+
+	writeEach(ctx, out, cb.VkCreateQueryPool(
+		device,
+		infoData.Ptr(),
+		memory.Nullptr,
+		poolData.Ptr(),
+		VkResult_VK_SUCCESS,
+	).AddRead(infoData.Data()).AddWrite(poolData.Data()))
+	return pool
+}
+
+// recordWithQueries records a copy of the primary command buffer cmdBuf that
+// resets the queries of pool it uses, and wraps the draw calls at the indices
+// draws in occlusion queries numbered from first.
+func (t *depthTestQueries) recordWithQueries(ctx context.Context, cb CommandBuilder, out transform.Writer, cmdBuf VkCommandBuffer, draws []uint64, pool VkQueryPool, first uint32, precise bool) VkCommandBuffer {
+	s := out.State()
+	c := GetState(s)
+	buf := c.CommandBuffers().Get(cmdBuf)
+
+	newCmdBuf, cmds, cleanup := allocateNewCmdBufFromExistingOneAndBegin(ctx, cb, cmdBuf, s)
+	writeEach(ctx, out, cmds...)
+	for _, f := range cleanup {
+		f()
+	}
+	writeEach(ctx, out, cb.VkCmdResetQueryPool(newCmdBuf, pool, first, uint32(len(draws))))
+
+	flags := VkQueryControlFlags(0)
+	if precise {
+		flags = VkQueryControlFlags(VkQueryControlFlagBits_VK_QUERY_CONTROL_PRECISE_BIT)
+	}
+	query := first
+	next := 0
+	for i := 0; i < buf.CommandReferences().Len(); i++ {
+		args := GetCommandArgs(ctx, buf.CommandReferences().Get(uint32(i)), c)
+		isDraw := next < len(draws) && draws[next] == uint64(i)
+		if isDraw {
+			writeEach(ctx, out, cb.VkCmdBeginQuery(newCmdBuf, pool, query, flags))
+		}
+		cleanup, cmd, _ := AddCommand(ctx, cb, newCmdBuf, s, s, args)
+		writeEach(ctx, out, cmd)
+		cleanup()
+		if isDraw {
+			writeEach(ctx, out, cb.VkCmdEndQuery(newCmdBuf, pool, query))
+			query++
+			next++
+		}
+	}
+	writeEach(ctx, out, cb.VkEndCommandBuffer(newCmdBuf, VkResult_VK_SUCCESS))
+	return newCmdBuf
+}
+
+// postResults posts back the results of the queries of pool, one for each of
+// the draw calls measured, and then destroys the pool.
+func (t *depthTestQueries) postResults(ctx context.Context, cb CommandBuilder, out transform.Writer, device VkDevice, pool VkQueryPool, measured []api.SubCmdIdx) {
+	s := out.State()
+	size := uint64(len(measured) * 8)
+	tmp := s.AllocOrPanic(ctx, size)
+	defer tmp.Free()
+
+	flags := VkQueryResultFlags(VkQueryResultFlagBits_VK_QUERY_RESULT_64_BIT | VkQueryResultFlagBits_VK_QUERY_RESULT_WAIT_BIT)
+	writeEach(ctx, out,
+		cb.VkGetQueryPoolResults(device, pool, 0, uint32(len(measured)),
+			memory.Size(size), tmp.Ptr(), 8, flags, VkResult_VK_SUCCESS),
+		cb.Custom(func(ctx context.Context, s *api.GlobalState, b *builder.Builder) error {
+			b.ReserveMemory(tmp.Range())
+			b.Post(value.ObservedPointer(tmp.Address()), size, func(r binary.Reader, err error) {
+				if err != nil {
+					log.E(ctx, "Failed to read the occlusion query results: %v", err)
+					return
+				}
+				for _, draw := range measured {
+					t.samples = append(t.samples, depthTestSamples{draw, r.Uint64()})
+				}
+			})
+			return nil
+		}),
+		cb.VkDestroyQueryPool(device, pool, memory.Nullptr),
+	)
+}
+
+func (t *depthTestQueries) Flush(ctx context.Context, out transform.Writer) error {
+	t.AddNotifyInstruction(ctx, out, func() interface{} { return t.samples })
+	return nil
+}
+
+func (t *depthTestQueries) PreLoop(ctx context.Context, out transform.Writer)  {}
+func (t *depthTestQueries) PostLoop(ctx context.Context, out transform.Writer) {}
+func (t *depthTestQueries) BuffersCommands() bool                              { return false }
+
+// QueryDepthTestCost implements replay.QueryDepthTestCost.
+// The commands are replayed twice, counting the samples of each draw call
+// that pass the depth and stencil tests, and then with the depth test of all
+// the graphics pipelines disabled. Only the draw calls of primary command
+// buffers that do not begin queries of their own are measured.
+func (a API) QueryDepthTestCost(
+	ctx context.Context,
+	intent replay.Intent,
+	mgr replay.Manager,
+	begin, end api.CmdID,
+	hints *service.UsageHints) (*service.DepthTestCost, error) {
+
+	samples := func(disableDepthTest bool) ([]depthTestSamples, error) {
+		c := depthTestConfig{disableDepthTest}
+		r := depthTestRequest{begin: begin, end: end}
+		res, err := mgr.Replay(ctx, intent, c, r, a, hints, true)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := mgr.(replay.Exporter); ok {
+			return nil, nil
+		}
+		return res.([]depthTestSamples), nil
+	}
+
+	passed, err := samples(false)
+	if err != nil {
+		return nil, err
+	}
+	all, err := samples(true)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := mgr.(replay.Exporter); ok {
+		return nil, nil
+	}
+
+	key := func(idx api.SubCmdIdx) string { return fmt.Sprint(idx) }
+	rasterized := make(map[string]uint64, len(all))
+	for _, d := range all {
+		rasterized[key(d.draw)] = d.samples
+	}
+
+	out := &service.DepthTestCost{Draws: []*service.DrawDepthTest{}}
+	for _, d := range passed {
+		if api.CmdID(d.draw[0]) < begin || api.CmdID(d.draw[0]) >= end {
+			continue
+		}
+		n, ok := rasterized[key(d.draw)]
+		if !ok {
+			continue
+		}
+		if n < d.samples {
+			// Both replays are expected to rasterize the same samples, but
+			// the results of imprecise queries may not be exact.
+			n = d.samples
+		}
+		out.Draws = append(out.Draws, &service.DrawDepthTest{
+			Command:       intent.Capture.Command(d.draw[0], d.draw[1:]...),
+			Samples:       n,
+			PassedSamples: d.samples,
+		})
+	}
+	return out, nil
+}
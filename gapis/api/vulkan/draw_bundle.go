@@ -0,0 +1,250 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/resolve"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/shadertools"
+)
+
+// Interface compliance test
+var (
+	_ = api.DrawBundleProvider(API{})
+)
+
+// drawBundle reads the state used by a single draw call into a bundle.
+type drawBundle struct {
+	s      *api.GlobalState
+	c      *State
+	thread uint64
+	out    *api.DrawBundle
+}
+
+// DrawBundle implements api.DrawBundleProvider.
+// Only draw calls executed by a queue submission, whose paths have subcommand
+// indices, have state to extract.
+func (API) DrawBundle(ctx context.Context, o interface{}, p *path.DrawBundle, r *path.ResolveConfig) (*api.DrawBundle, error) {
+	switch o.(type) {
+	case *VkCmdDraw, *VkCmdDrawIndexed, *VkCmdDrawIndirect, *VkCmdDrawIndexedIndirect,
+		*VkCmdDrawIndirectCountKHR, *VkCmdDrawIndexedIndirectCountKHR,
+		*VkCmdDrawIndirectCountAMD, *VkCmdDrawIndexedIndirectCountAMD:
+	default:
+		return nil, nil
+	}
+	if len(p.Command.Indices) < 2 {
+		return nil, nil
+	}
+
+	s, err := resolve.GlobalState(ctx, p.Command.GlobalStateAfter(), r)
+	if err != nil {
+		return nil, err
+	}
+	c := GetState(s)
+
+	queue := c.LastBoundQueue()
+	if queue.IsNil() {
+		return nil, fmt.Errorf("No previous queue submission")
+	}
+	ldi, ok := c.LastDrawInfos().Lookup(queue.VulkanHandle())
+	if !ok {
+		return nil, fmt.Errorf("There have been no previous draws")
+	}
+
+	b := &drawBundle{
+		s:      s,
+		c:      c,
+		thread: o.(api.Cmd).Thread(),
+		out: &api.DrawBundle{
+			Shaders: []*api.DrawBundleShader{},
+			Buffers: []*api.DrawBundleBuffer{},
+			Images:  []*api.DrawBundleImage{},
+		},
+	}
+
+	if pipeline := ldi.GraphicsPipeline(); !pipeline.IsNil() {
+		for _, i := range pipeline.Stages().Keys() {
+			if err := b.shader(ctx, pipeline.Stages().Get(i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, i := range ldi.BoundVertexBuffers().Keys() {
+		vb := ldi.BoundVertexBuffers().Get(i)
+		if err := b.buffer(ctx, fmt.Sprintf("vertex %v", i), vb.Buffer(), vb.Offset(), vb.Range()); err != nil {
+			return nil, err
+		}
+	}
+	if ib := ldi.BoundIndexBuffer(); !ib.IsNil() {
+		bb := ib.BoundBuffer()
+		if err := b.buffer(ctx, "index", bb.Buffer(), bb.Offset(), bb.Range()); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, i := range ldi.DescriptorSets().Keys() {
+		set := ldi.DescriptorSets().Get(i)
+		if set.IsNil() {
+			continue
+		}
+		for _, j := range set.Bindings().Keys() {
+			if err := b.binding(ctx, fmt.Sprintf("set %v, binding %v", i, j), set.Bindings().Get(j)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if pc, ok := c.LastPushConstants().Lookup(queue.VulkanHandle()); ok {
+		if b.out.PushConstants, err = pc.Data().Read(ctx, nil, s, nil); err != nil {
+			return nil, err
+		}
+	}
+	return b.out, nil
+}
+
+// shader adds the shader of the pipeline stage to the bundle.
+func (b *drawBundle) shader(ctx context.Context, stage StageData) error {
+	module := stage.Module()
+	if module.IsNil() {
+		return nil
+	}
+	words, err := module.Words().Read(ctx, nil, b.s, nil)
+	if err != nil {
+		return err
+	}
+	b.out.Shaders = append(b.out.Shaders, &api.DrawBundleShader{
+		Stage:      fmt.Sprint(stage.Stage()),
+		EntryPoint: stage.EntryPoint(),
+		Words:      words,
+		Source:     shadertools.DisassembleSpirvBinary(words),
+	})
+	return nil
+}
+
+// binding adds the buffers and images bound to the descriptor binding to the
+// bundle.
+func (b *drawBundle) binding(ctx context.Context, usage string, binding DescriptorBindingʳ) error {
+	switch binding.BindingType() {
+	case VkDescriptorType_VK_DESCRIPTOR_TYPE_COMBINED_IMAGE_SAMPLER,
+		VkDescriptorType_VK_DESCRIPTOR_TYPE_SAMPLED_IMAGE,
+		VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_IMAGE,
+		VkDescriptorType_VK_DESCRIPTOR_TYPE_INPUT_ATTACHMENT:
+		for _, i := range binding.ImageBinding().Keys() {
+			info := binding.ImageBinding().Get(i)
+			if view, ok := b.c.ImageViews().Lookup(info.ImageView()); ok {
+				if err := b.image(ctx, usage, view); err != nil {
+					return err
+				}
+			}
+		}
+	case VkDescriptorType_VK_DESCRIPTOR_TYPE_UNIFORM_BUFFER,
+		VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_BUFFER,
+		VkDescriptorType_VK_DESCRIPTOR_TYPE_UNIFORM_BUFFER_DYNAMIC,
+		VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_BUFFER_DYNAMIC:
+		for _, i := range binding.BufferBinding().Keys() {
+			info := binding.BufferBinding().Get(i)
+			if buf, ok := b.c.Buffers().Lookup(info.Buffer()); ok {
+				if err := b.buffer(ctx, usage, buf, info.Offset(), info.Range()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// buffer adds the range of the buffer buf to the bundle.
+func (b *drawBundle) buffer(ctx context.Context, usage string, buf BufferObjectʳ, offset, size VkDeviceSize) error {
+	if buf.IsNil() {
+		return nil
+	}
+	if size == ^VkDeviceSize(0) {
+		if buf.Info().Size() < offset {
+			return nil
+		}
+		size = buf.Info().Size() - offset
+	}
+	pieces, err := subGetBufferBoundMemoryPiecesInRange(
+		ctx, nil, api.CmdNoID, nil, b.s, nil, b.thread, nil, nil, buf, offset, size)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, 0, size)
+	// In the order of the offsets in the buffer
+	for _, bufOffset := range pieces.Keys() {
+		piece := pieces.Get(bufOffset)
+		d, err := piece.DeviceMemory().Data().Slice(
+			uint64(piece.MemoryOffset()),
+			uint64(piece.MemoryOffset()+piece.Size())).Read(ctx, nil, b.s, nil)
+		if err != nil {
+			return err
+		}
+		data = append(data, d...)
+	}
+	b.out.Buffers = append(b.out.Buffers, &api.DrawBundleBuffer{
+		Usage:  usage,
+		Handle: uint64(buf.VulkanHandle()),
+		Offset: uint64(offset),
+		Data:   data,
+	})
+	return nil
+}
+
+// image adds the base mip level of the first layer and aspect viewed by view
+// to the bundle.
+func (b *drawBundle) image(ctx context.Context, usage string, view ImageViewObjectʳ) error {
+	img := view.Image()
+	if img.IsNil() {
+		return nil
+	}
+	rng := view.SubresourceRange()
+	for _, bit := range img.Aspects().Keys() {
+		if VkImageAspectFlags(bit)&rng.AspectMask() == 0 {
+			continue
+		}
+		layer, ok := img.Aspects().Get(bit).Layers().Lookup(rng.BaseArrayLayer())
+		if !ok {
+			continue
+		}
+		level, ok := layer.Levels().Lookup(rng.BaseMipLevel())
+		if !ok {
+			continue
+		}
+		data, err := level.Data().Read(ctx, nil, b.s, nil)
+		if err != nil {
+			return err
+		}
+		out := &api.DrawBundleImage{
+			Usage:  usage,
+			Handle: uint64(img.VulkanHandle()),
+			Format: fmt.Sprint(view.Fmt()),
+			Width:  level.Width(),
+			Height: level.Height(),
+			Depth:  level.Depth(),
+			Data:   data,
+		}
+		if f, err := getImageFormatFromVulkanFormat(view.Fmt()); err == nil {
+			out.ImageFormat = f
+		}
+		b.out.Images = append(b.out.Images, out)
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Interface compliance test
+var (
+	_ = api.FrameGraphProvider(API{})
+)
+
+// frameGraph adds the passes of the subcommands executed by a single command
+// to a frame graph.
+type frameGraph struct {
+	b *api.FrameGraphBuilder
+	// The render pass currently being recorded, or nil if outside of a render
+	// pass.
+	pass *api.FrameGraphPass
+}
+
+// AddFrameGraphPasses implements api.FrameGraphProvider.
+// Each render pass, dispatch and transfer command is a pass of the graph.
+// Render passes access their attachments according to their load and store
+// operations, and the resources bound for each of their draws.
+func (API) AddFrameGraphPasses(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState, b *api.FrameGraphBuilder) error {
+	c := GetState(s)
+	g := &frameGraph{b: b}
+	c.PostSubcommand = func(ref interface{}) {
+		if cr, ok := ref.(CommandReferenceʳ); ok {
+			g.subcommand(ctx, c, GetCommandArgs(ctx, cr, c))
+		}
+	}
+	defer func() { c.PostSubcommand = nil }()
+	err := cmd.Mutate(ctx, id, s, nil, nil)
+	g.endRenderPass()
+	return err
+}
+
+func (g *frameGraph) subcommand(ctx context.Context, c *State, args interface{}) {
+	switch args := args.(type) {
+	case VkCmdBeginRenderPassArgsʳ:
+		g.endRenderPass()
+		g.pass = g.newPass(c, api.FrameGraphPass_RENDER_PASS, fmt.Sprintf("Render pass %v", args.RenderPass()))
+		g.attachments(c, args)
+	case VkCmdEndRenderPassArgsʳ:
+		g.endRenderPass()
+	case VkCmdDrawArgsʳ, VkCmdDrawIndexedArgsʳ, VkCmdDrawIndirectArgsʳ, VkCmdDrawIndexedIndirectArgsʳ,
+		VkCmdDrawIndirectCountKHRArgsʳ, VkCmdDrawIndexedIndirectCountKHRArgsʳ,
+		VkCmdDrawIndirectCountAMDArgsʳ, VkCmdDrawIndexedIndirectCountAMDArgsʳ:
+		if g.pass != nil {
+			g.draw(c)
+		}
+	case VkCmdDispatchArgsʳ:
+		g.dispatch(c, g.newPass(c, api.FrameGraphPass_COMPUTE, "Dispatch"))
+	case VkCmdDispatchIndirectArgsʳ:
+		p := g.newPass(c, api.FrameGraphPass_COMPUTE, "Dispatch indirect")
+		g.buffer(p, args.Buffer(), api.FrameGraphAccess_READ)
+		g.dispatch(c, p)
+	case VkCmdCopyBufferArgsʳ:
+		p := g.newPass(c, api.FrameGraphPass_TRANSFER, "Copy buffer")
+		g.buffer(p, args.SrcBuffer(), api.FrameGraphAccess_READ)
+		g.buffer(p, args.DstBuffer(), api.FrameGraphAccess_WRITE)
+		g.b.AddPass(p)
+	case VkCmdUpdateBufferArgsʳ:
+		p := g.newPass(c, api.FrameGraphPass_TRANSFER, "Update buffer")
+		g.buffer(p, args.DstBuffer(), api.FrameGraphAccess_WRITE)
+		g.b.AddPass(p)
+	case VkCmdFillBufferArgsʳ:
+		p := g.newPass(c, api.FrameGraphPass_TRANSFER, "Fill buffer")
+		g.buffer(p, args.Buffer(), api.FrameGraphAccess_WRITE)
+		g.b.AddPass(p)
+	case VkCmdCopyImageArgsʳ:
+		g.imageTransfer(c, "Copy image", args.SrcImage(), args.DstImage())
+	case VkCmdBlitImageArgsʳ:
+		g.imageTransfer(c, "Blit image", args.SrcImage(), args.DstImage())
+	case VkCmdResolveImageArgsʳ:
+		g.imageTransfer(c, "Resolve image", args.SrcImage(), args.DstImage())
+	case VkCmdCopyBufferToImageArgsʳ:
+		p := g.newPass(c, api.FrameGraphPass_TRANSFER, "Copy buffer to image")
+		g.buffer(p, args.SrcBuffer(), api.FrameGraphAccess_READ)
+		g.image(p, args.DstImage(), api.FrameGraphAccess_WRITE)
+		g.b.AddPass(p)
+	case VkCmdCopyImageToBufferArgsʳ:
+		p := g.newPass(c, api.FrameGraphPass_TRANSFER, "Copy image to buffer")
+		g.image(p, args.SrcImage(), api.FrameGraphAccess_READ)
+		g.buffer(p, args.DstBuffer(), api.FrameGraphAccess_WRITE)
+		g.b.AddPass(p)
+	case VkCmdClearColorImageArgsʳ:
+		p := g.newPass(c, api.FrameGraphPass_TRANSFER, "Clear color image")
+		g.image(p, args.Image(), api.FrameGraphAccess_WRITE)
+		g.b.AddPass(p)
+	case VkCmdClearDepthStencilImageArgsʳ:
+		p := g.newPass(c, api.FrameGraphPass_TRANSFER, "Clear depth stencil image")
+		g.image(p, args.Image(), api.FrameGraphAccess_WRITE)
+		g.b.AddPass(p)
+	case VkCmdPipelineBarrierArgsʳ:
+		if args.MemoryBarriers().Len() > 0 {
+			g.b.BarrierAll()
+		}
+		for _, b := range args.BufferMemoryBarriers().All() {
+			g.b.Barrier(api.FrameGraphResource_BUFFER, uint64(b.Buffer()))
+		}
+		for _, b := range args.ImageMemoryBarriers().All() {
+			g.b.Barrier(api.FrameGraphResource_IMAGE, uint64(b.Image()))
+		}
+	}
+}
+
+// newPass returns a new pass begun by the current subcommand.
+func (g *frameGraph) newPass(c *State, ty api.FrameGraphPass_Type, label string) *api.FrameGraphPass {
+	return &api.FrameGraphPass{
+		Type:    ty,
+		Label:   label,
+		Command: &path.Command{Indices: append([]uint64{}, c.SubCmdIdx...)},
+	}
+}
+
+// endRenderPass adds the current render pass, if any, to the graph.
+func (g *frameGraph) endRenderPass() {
+	if g.pass == nil {
+		return
+	}
+	g.b.AddPass(g.pass)
+	g.pass = nil
+}
+
+// image adds an access of the image img to the pass p.
+func (g *frameGraph) image(p *api.FrameGraphPass, img VkImage, mode api.FrameGraphAccess_Mode) {
+	r := g.b.Resource(api.FrameGraphResource_IMAGE, uint64(img), fmt.Sprintf("Image %v", img))
+	p.Access(r, mode)
+}
+
+// buffer adds an access of the buffer buf to the pass p.
+func (g *frameGraph) buffer(p *api.FrameGraphPass, buf VkBuffer, mode api.FrameGraphAccess_Mode) {
+	r := g.b.Resource(api.FrameGraphResource_BUFFER, uint64(buf), fmt.Sprintf("Buffer %v", buf))
+	p.Access(r, mode)
+}
+
+// imageTransfer adds a transfer pass reading the image src and writing the
+// image dst.
+func (g *frameGraph) imageTransfer(c *State, label string, src, dst VkImage) {
+	p := g.newPass(c, api.FrameGraphPass_TRANSFER, label)
+	g.image(p, src, api.FrameGraphAccess_READ)
+	g.image(p, dst, api.FrameGraphAccess_WRITE)
+	g.b.AddPass(p)
+}
+
+// attachments adds the accesses of the attachments of the render pass begun
+// by args to the current pass. Attachments are read if they are loaded, and
+// written if they are stored. Transient attachments, which are neither, are
+// not accesses visible to other passes.
+func (g *frameGraph) attachments(c *State, args VkCmdBeginRenderPassArgsʳ) {
+	rp, ok := c.RenderPasses().Lookup(args.RenderPass())
+	if !ok {
+		return
+	}
+	fb, ok := c.Framebuffers().Lookup(args.Framebuffer())
+	if !ok {
+		return
+	}
+	for i, desc := range rp.AttachmentDescriptions().All() {
+		view, ok := fb.ImageAttachments().Lookup(i)
+		if !ok || view.Image().IsNil() {
+			continue
+		}
+		load := desc.LoadOp() == VkAttachmentLoadOp_VK_ATTACHMENT_LOAD_OP_LOAD ||
+			desc.StencilLoadOp() == VkAttachmentLoadOp_VK_ATTACHMENT_LOAD_OP_LOAD
+		store := desc.StoreOp() == VkAttachmentStoreOp_VK_ATTACHMENT_STORE_OP_STORE ||
+			desc.StencilStoreOp() == VkAttachmentStoreOp_VK_ATTACHMENT_STORE_OP_STORE
+		img := view.Image().VulkanHandle()
+		switch {
+		case load && store:
+			g.image(g.pass, img, api.FrameGraphAccess_READ_WRITE)
+		case load:
+			g.image(g.pass, img, api.FrameGraphAccess_READ)
+		case store:
+			g.image(g.pass, img, api.FrameGraphAccess_WRITE)
+		}
+	}
+}
+
+// draw adds the vertex, index and descriptor resources bound for the last
+// draw to the current render pass.
+func (g *frameGraph) draw(c *State) {
+	queue := c.LastBoundQueue()
+	if queue.IsNil() {
+		return
+	}
+	ldi, ok := c.LastDrawInfos().Lookup(queue.VulkanHandle())
+	if !ok {
+		return
+	}
+	for _, vb := range ldi.BoundVertexBuffers().All() {
+		if !vb.Buffer().IsNil() {
+			g.buffer(g.pass, vb.Buffer().VulkanHandle(), api.FrameGraphAccess_READ)
+		}
+	}
+	if ib := ldi.BoundIndexBuffer(); !ib.IsNil() && !ib.BoundBuffer().Buffer().IsNil() {
+		g.buffer(g.pass, ib.BoundBuffer().Buffer().VulkanHandle(), api.FrameGraphAccess_READ)
+	}
+	g.descriptorSets(c, g.pass, ldi.DescriptorSets().All())
+}
+
+// dispatch adds the descriptor resources bound for the last dispatch to the
+// compute pass p, and adds p to the graph.
+func (g *frameGraph) dispatch(c *State, p *api.FrameGraphPass) {
+	if queue := c.LastBoundQueue(); !queue.IsNil() {
+		if lci, ok := c.LastComputeInfos().Lookup(queue.VulkanHandle()); ok {
+			g.descriptorSets(c, p, lci.DescriptorSets().All())
+		}
+	}
+	g.b.AddPass(p)
+}
+
+// descriptorSets adds the images and buffers bound by the descriptor sets to
+// the pass p. Storage images and buffers may be written by the pass.
+func (g *frameGraph) descriptorSets(c *State, p *api.FrameGraphPass, sets map[uint32]DescriptorSetObjectʳ) {
+	for _, set := range sets {
+		if set.IsNil() {
+			continue
+		}
+		for _, binding := range set.Bindings().All() {
+			mode := api.FrameGraphAccess_READ
+			switch binding.BindingType() {
+			case VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_IMAGE,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_BUFFER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_BUFFER_DYNAMIC:
+				mode = api.FrameGraphAccess_READ_WRITE
+			}
+			switch binding.BindingType() {
+			case VkDescriptorType_VK_DESCRIPTOR_TYPE_COMBINED_IMAGE_SAMPLER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_SAMPLED_IMAGE,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_IMAGE,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_INPUT_ATTACHMENT:
+				for _, info := range binding.ImageBinding().All() {
+					if view, ok := c.ImageViews().Lookup(info.ImageView()); ok && !view.Image().IsNil() {
+						g.image(p, view.Image().VulkanHandle(), mode)
+					}
+				}
+			case VkDescriptorType_VK_DESCRIPTOR_TYPE_UNIFORM_BUFFER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_BUFFER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_UNIFORM_BUFFER_DYNAMIC,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_BUFFER_DYNAMIC:
+				for _, info := range binding.BufferBinding().All() {
+					g.buffer(p, info.Buffer(), mode)
+				}
+			}
+		}
+	}
+}
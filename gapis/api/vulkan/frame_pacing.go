@@ -0,0 +1,58 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.PresentAnalyzer(API{})
+)
+
+// AnalyzePresents implements api.PresentAnalyzer.
+func (API) AnalyzePresents(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState, acquire func(swapchain uint64, image uint32), present func(p *api.SwapchainPresent)) error {
+	if err := cmd.Mutate(ctx, id, s, nil, nil); err != nil {
+		return err
+	}
+
+	c := GetState(s)
+	l := s.MemoryLayout
+	switch cmd := cmd.(type) {
+	case *VkAcquireNextImageKHR:
+		image := cmd.PImageIndex().MustRead(ctx, cmd, s, nil)
+		acquire(uint64(cmd.Swapchain()), image)
+	case *VkQueuePresentKHR:
+		info := cmd.PPresentInfo().MustRead(ctx, cmd, s, nil)
+		count := uint64(info.SwapchainCount())
+		swapchains := info.PSwapchains().Slice(0, count, l).MustRead(ctx, cmd, s, nil)
+		images := info.PImageIndices().Slice(0, count, l).MustRead(ctx, cmd, s, nil)
+		for i, sc := range swapchains {
+			p := &api.SwapchainPresent{
+				Swapchain:  uint64(sc),
+				ImageIndex: images[i],
+			}
+			if obj := c.Swapchains().Get(sc); !obj.IsNil() {
+				p.PresentMode = api.CreateEnumDataValue("VkPresentModeKHR", obj.PresentMode())
+				p.ImageCount = uint32(obj.SwapchainImages().Len())
+			}
+			present(p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"strings"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.FrameRedundancyClassifier(API{})
+)
+
+// IsBinding implements api.FrameRedundancyClassifier.
+func (API) IsBinding(cmd api.Cmd) bool {
+	switch cmd.(type) {
+	case *VkCmdBindPipeline,
+		*VkCmdBindDescriptorSets,
+		*VkCmdBindVertexBuffers,
+		*VkCmdBindIndexBuffer:
+		return true
+	}
+	return false
+}
+
+// RecordsInto implements api.FrameRedundancyClassifier.
+func (API) RecordsInto(cmd api.Cmd) (buffer uint64, begin, ok bool) {
+	switch cmd := cmd.(type) {
+	case *VkBeginCommandBuffer:
+		return uint64(cmd.CommandBuffer()), true, true
+	case *VkEndCommandBuffer:
+		return uint64(cmd.CommandBuffer()), false, true
+	}
+	if !strings.HasPrefix(cmd.CmdName(), "vkCmd") {
+		return 0, false, false
+	}
+	if cmd, ok := cmd.(interface{ CommandBuffer() VkCommandBuffer }); ok {
+		return uint64(cmd.CommandBuffer()), false, true
+	}
+	return 0, false, false
+}
@@ -0,0 +1,374 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/transform"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/replay/builder"
+	"github.com/google/gapid/gapis/replay/value"
+	"github.com/google/gapid/gapis/service"
+)
+
+var (
+	_ = transform.Transformer(&pipelineStatisticsQueries{})
+)
+
+// measuredStatistics are the pipeline statistics counted for each draw call,
+// in the order their results are written by vkGetQueryPoolResults.
+const measuredStatistics = VkQueryPipelineStatisticFlags(
+	VkQueryPipelineStatisticFlagBits_VK_QUERY_PIPELINE_STATISTIC_INPUT_ASSEMBLY_PRIMITIVES_BIT |
+		VkQueryPipelineStatisticFlagBits_VK_QUERY_PIPELINE_STATISTIC_VERTEX_SHADER_INVOCATIONS_BIT |
+		VkQueryPipelineStatisticFlagBits_VK_QUERY_PIPELINE_STATISTIC_CLIPPING_INVOCATIONS_BIT |
+		VkQueryPipelineStatisticFlagBits_VK_QUERY_PIPELINE_STATISTIC_CLIPPING_PRIMITIVES_BIT |
+		VkQueryPipelineStatisticFlagBits_VK_QUERY_PIPELINE_STATISTIC_FRAGMENT_SHADER_INVOCATIONS_BIT)
+
+// numMeasuredStatistics is the number of flags set in measuredStatistics.
+const numMeasuredStatistics = 5
+
+// pipelineStatisticsConfig is a replay.Config used by
+// pipelineStatisticsRequests.
+type pipelineStatisticsConfig struct{}
+
+// pipelineStatisticsRequest requests the pipeline statistics of each draw call
+// of the command buffers submitted by the commands in the range [begin, end).
+type pipelineStatisticsRequest struct {
+	begin, end api.CmdID
+}
+
+// drawStatistics are the pipeline statistics of a draw call, in the order of
+// the flags of measuredStatistics.
+type drawStatistics struct {
+	draw   api.SubCmdIdx
+	counts [numMeasuredStatistics]uint64
+}
+
+// pipelineStatisticsQueries is a transformation that records a pipeline
+// statistics query around each draw call of the primary command buffers
+// submitted by the commands in a range, and posts back the results once the
+// submission has completed. The pipelineStatisticsQuery feature is enabled on
+// the devices that are created with explicit features. As for
+// depthTestQueries, command buffers that begin queries of their own, and the
+// draw calls of secondary command buffers, are not measured.
+type pipelineStatisticsQueries struct {
+	replay.EndOfReplay
+	begin, end api.CmdID
+	statistics []drawStatistics
+}
+
+func newPipelineStatisticsQueries() *pipelineStatisticsQueries {
+	return &pipelineStatisticsQueries{begin: api.CmdNoID}
+}
+
+// add extends the range of the measured commands to include [begin, end).
+func (t *pipelineStatisticsQueries) add(begin, end api.CmdID) {
+	if begin < t.begin {
+		t.begin = begin
+	}
+	if end > t.end {
+		t.end = end
+	}
+}
+
+func (t *pipelineStatisticsQueries) Transform(ctx context.Context, id api.CmdID, cmd api.Cmd, out transform.Writer) error {
+	ctx = log.Enter(ctx, "pipelineStatisticsQueries")
+	switch cmd := cmd.(type) {
+	case *VkCreateDevice:
+		return t.rewriteCreateDevice(ctx, id, cmd, out)
+	case *VkQueueSubmit:
+		if id >= t.begin && id < t.end {
+			return t.rewriteQueueSubmit(ctx, id, cmd, out)
+		}
+	}
+	return out.MutateAndWrite(ctx, id, cmd)
+}
+
+// rewriteCreateDevice creates the device of cmd with the
+// pipelineStatisticsQuery feature enabled. Devices created without explicit
+// features, or with features chained through pNext, are left unchanged.
+func (t *pipelineStatisticsQueries) rewriteCreateDevice(ctx context.Context, id api.CmdID, cmd *VkCreateDevice, out transform.Writer) error {
+	s := out.State()
+	cb := CommandBuilder{Thread: cmd.Thread(), Arena: s.Arena}
+	cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+
+	info := cmd.PCreateInfo().MustRead(ctx, cmd, s, nil)
+	if info.PEnabledFeatures().IsNullptr() {
+		return out.MutateAndWrite(ctx, id, cmd)
+	}
+	features := info.PEnabledFeatures().MustRead(ctx, cmd, s, nil)
+	if features.PipelineStatisticsQuery() != 0 {
+		return out.MutateAndWrite(ctx, id, cmd)
+	}
+	features.SetPipelineStatisticsQuery(1)
+	featuresData := s.AllocDataOrPanic(ctx, features)
+	defer featuresData.Free()
+	info.SetPEnabledFeatures(NewVkPhysicalDeviceFeaturesᶜᵖ(featuresData.Ptr()))
+	infoData := s.AllocDataOrPanic(ctx, info)
+	defer infoData.Free()
+
+	newCmd := cb.VkCreateDevice(cmd.PhysicalDevice(), infoData.Ptr(), cmd.PAllocator(), cmd.PDevice(), cmd.Result())
+	for _, e := range cmd.Extras().All() {
+		if _, ok := e.(*api.CmdObservations); !ok {
+			newCmd.Extras().Add(e)
+		}
+	}
+	observations := cmd.Extras().Observations()
+	for _, r := range observations.Reads {
+		newCmd.AddRead(r.Range, r.ID)
+	}
+	newCmd.AddRead(featuresData.Data()).AddRead(infoData.Data())
+	for _, w := range observations.Writes {
+		newCmd.AddWrite(w.Range, w.ID)
+	}
+	return out.MutateAndWrite(ctx, id, newCmd)
+}
+
+// rewriteQueueSubmit submits copies of the command buffers of submit with a
+// pipeline statistics query around each of their draw calls, and posts back
+// the results of the queries.
+func (t *pipelineStatisticsQueries) rewriteQueueSubmit(ctx context.Context, id api.CmdID, submit *VkQueueSubmit, out transform.Writer) error {
+	s := out.State()
+	l := s.MemoryLayout
+	c := GetState(s)
+	cb := CommandBuilder{Thread: submit.Thread(), Arena: s.Arena}
+	submit.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+
+	device := c.Queues().Get(submit.Queue()).Device()
+	if c.Devices().Get(device).EnabledFeatures().PipelineStatisticsQuery() == 0 {
+		return out.MutateAndWrite(ctx, id, submit)
+	}
+
+	infos := submit.PSubmits().Slice(0, uint64(submit.SubmitCount()), l).MustRead(ctx, submit, s, nil)
+	cmdBufs := make([][]VkCommandBuffer, len(infos))
+	draws := make([][][]uint64, len(infos))
+	count := uint32(0)
+	for i, info := range infos {
+		cmdBufs[i] = info.PCommandBuffers().Slice(0, uint64(info.CommandBufferCount()), l).MustRead(ctx, submit, s, nil)
+		draws[i] = make([][]uint64, len(cmdBufs[i]))
+		for j, cmdBuf := range cmdBufs[i] {
+			draws[i][j] = measuredDraws(ctx, c, cmdBuf)
+			count += uint32(len(draws[i][j]))
+		}
+	}
+	if count == 0 {
+		return out.MutateAndWrite(ctx, id, submit)
+	}
+
+	pool := t.createQueryPool(ctx, cb, out, device, count)
+
+	reads := []api.AllocResult{}
+	defer func() {
+		for _, r := range reads {
+			r.Free()
+		}
+	}()
+	measured := []api.SubCmdIdx{}
+	for i, info := range infos {
+		for j, cmdBuf := range cmdBufs[i] {
+			if len(draws[i][j]) == 0 {
+				continue
+			}
+			first := uint32(len(measured))
+			cmdBufs[i][j] = t.recordWithQueries(ctx, cb, out, cmdBuf, draws[i][j], pool, first)
+			for _, d := range draws[i][j] {
+				measured = append(measured, api.SubCmdIdx{uint64(id), uint64(i), uint64(j), d})
+			}
+		}
+		if len(cmdBufs[i]) > 0 {
+			data := s.AllocDataOrPanic(ctx, cmdBufs[i])
+			reads = append(reads, data)
+			info.SetPCommandBuffers(NewVkCommandBufferᶜᵖ(data.Ptr()))
+			infos[i] = info
+		}
+	}
+	infosData := s.AllocDataOrPanic(ctx, infos)
+	reads = append(reads, infosData)
+
+	newCmd := cb.VkQueueSubmit(submit.Queue(), submit.SubmitCount(), infosData.Ptr(),
+		submit.Fence(), submit.Result())
+	for _, e := range submit.Extras().All() {
+		if _, ok := e.(*api.CmdObservations); !ok {
+			newCmd.Extras().Add(e)
+		}
+	}
+	observations := submit.Extras().Observations()
+	for _, r := range observations.Reads {
+		newCmd.AddRead(r.Range, r.ID)
+	}
+	for _, r := range reads {
+		newCmd.AddRead(r.Data())
+	}
+	for _, w := range observations.Writes {
+		newCmd.AddWrite(w.Range, w.ID)
+	}
+	if err := out.MutateAndWrite(ctx, id, newCmd); err != nil {
+		return err
+	}
+
+	writeEach(ctx, out, cb.VkQueueWaitIdle(submit.Queue(), VkResult_VK_SUCCESS))
+	t.postResults(ctx, cb, out, device, pool, measured)
+	return nil
+}
+
+// createQueryPool creates a pipeline statistics query pool of count queries.
+func (t *pipelineStatisticsQueries) createQueryPool(ctx context.Context, cb CommandBuilder, out transform.Writer, device VkDevice, count uint32) VkQueryPool {
+	s := out.State()
+	pool := VkQueryPool(newUnusedID(false, func(id uint64) bool {
+		return GetState(s).QueryPools().Contains(VkQueryPool(id))
+	}))
+	poolData := s.AllocDataOrPanic(ctx, pool)
+	defer poolData.Free()
+	infoData := s.AllocDataOrPanic(ctx, NewVkQueryPoolCreateInfo(s.Arena,
+		VkStructureType_VK_STRUCTURE_TYPE_QUERY_POOL_CREATE_INFO, // sType
+		0, // pNext
+		0, // flags
+		VkQueryType_VK_QUERY_TYPE_PIPELINE_STATISTICS, // queryType
+		count,              // queryCount
+		measuredStatistics, // pipelineStatistics
+	))
+	defer infoData.Free()
+
+	writeEach(ctx, out, cb.VkCreateQueryPool(
+		device,
+		infoData.Ptr(),
+		memory.Nullptr,
+		poolData.Ptr(),
+		VkResult_VK_SUCCESS,
+	).AddRead(infoData.Data()).AddWrite(poolData.Data()))
+	return pool
+}
+
+// recordWithQueries records a copy of the primary command buffer cmdBuf that
+// resets the queries of pool it uses, and wraps the draw calls at the indices
+// draws in pipeline statistics queries numbered from first.
+func (t *pipelineStatisticsQueries) recordWithQueries(ctx context.Context, cb CommandBuilder, out transform.Writer, cmdBuf VkCommandBuffer, draws []uint64, pool VkQueryPool, first uint32) VkCommandBuffer {
+	s := out.State()
+	c := GetState(s)
+	buf := c.CommandBuffers().Get(cmdBuf)
+
+	newCmdBuf, cmds, cleanup := allocateNewCmdBufFromExistingOneAndBegin(ctx, cb, cmdBuf, s)
+	writeEach(ctx, out, cmds...)
+	for _, f := range cleanup {
+		f()
+	}
+	writeEach(ctx, out, cb.VkCmdResetQueryPool(newCmdBuf, pool, first, uint32(len(draws))))
+
+	query := first
+	next := 0
+	for i := 0; i < buf.CommandReferences().Len(); i++ {
+		args := GetCommandArgs(ctx, buf.CommandReferences().Get(uint32(i)), c)
+		isDraw := next < len(draws) && draws[next] == uint64(i)
+		if isDraw {
+			writeEach(ctx, out, cb.VkCmdBeginQuery(newCmdBuf, pool, query, 0))
+		}
+		cleanup, cmd, _ := AddCommand(ctx, cb, newCmdBuf, s, s, args)
+		writeEach(ctx, out, cmd)
+		cleanup()
+		if isDraw {
+			writeEach(ctx, out, cb.VkCmdEndQuery(newCmdBuf, pool, query))
+			query++
+			next++
+		}
+	}
+	writeEach(ctx, out, cb.VkEndCommandBuffer(newCmdBuf, VkResult_VK_SUCCESS))
+	return newCmdBuf
+}
+
+// postResults posts back the results of the queries of pool, one for each of
+// the draw calls measured, and then destroys the pool.
+func (t *pipelineStatisticsQueries) postResults(ctx context.Context, cb CommandBuilder, out transform.Writer, device VkDevice, pool VkQueryPool, measured []api.SubCmdIdx) {
+	s := out.State()
+	stride := uint64(numMeasuredStatistics * 8)
+	size := uint64(len(measured)) * stride
+	tmp := s.AllocOrPanic(ctx, size)
+	defer tmp.Free()
+
+	flags := VkQueryResultFlags(VkQueryResultFlagBits_VK_QUERY_RESULT_64_BIT | VkQueryResultFlagBits_VK_QUERY_RESULT_WAIT_BIT)
+	writeEach(ctx, out,
+		cb.VkGetQueryPoolResults(device, pool, 0, uint32(len(measured)),
+			memory.Size(size), tmp.Ptr(), VkDeviceSize(stride), flags, VkResult_VK_SUCCESS),
+		cb.Custom(func(ctx context.Context, s *api.GlobalState, b *builder.Builder) error {
+			b.ReserveMemory(tmp.Range())
+			b.Post(value.ObservedPointer(tmp.Address()), size, func(r binary.Reader, err error) {
+				if err != nil {
+					log.E(ctx, "Failed to read the pipeline statistics query results: %v", err)
+					return
+				}
+				for _, draw := range measured {
+					d := drawStatistics{draw: draw}
+					for i := range d.counts {
+						d.counts[i] = r.Uint64()
+					}
+					t.statistics = append(t.statistics, d)
+				}
+			})
+			return nil
+		}),
+		cb.VkDestroyQueryPool(device, pool, memory.Nullptr),
+	)
+}
+
+func (t *pipelineStatisticsQueries) Flush(ctx context.Context, out transform.Writer) error {
+	t.AddNotifyInstruction(ctx, out, func() interface{} { return t.statistics })
+	return nil
+}
+
+func (t *pipelineStatisticsQueries) PreLoop(ctx context.Context, out transform.Writer)  {}
+func (t *pipelineStatisticsQueries) PostLoop(ctx context.Context, out transform.Writer) {}
+func (t *pipelineStatisticsQueries) BuffersCommands() bool                              { return false }
+
+// QueryPipelineStatistics implements replay.QueryPipelineStatistics.
+// Only the draw calls of primary command buffers that do not begin queries of
+// their own, submitted to devices with the pipelineStatisticsQuery feature
+// enabled, are measured.
+func (a API) QueryPipelineStatistics(
+	ctx context.Context,
+	intent replay.Intent,
+	mgr replay.Manager,
+	begin, end api.CmdID,
+	hints *service.UsageHints) (*service.PipelineStatistics, error) {
+
+	c := pipelineStatisticsConfig{}
+	r := pipelineStatisticsRequest{begin: begin, end: end}
+	res, err := mgr.Replay(ctx, intent, c, r, a, hints, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := mgr.(replay.Exporter); ok {
+		return nil, nil
+	}
+
+	out := &service.PipelineStatistics{Draws: []*service.DrawPipelineStatistics{}}
+	for _, d := range res.([]drawStatistics) {
+		if api.CmdID(d.draw[0]) < begin || api.CmdID(d.draw[0]) >= end {
+			continue
+		}
+		out.Draws = append(out.Draws, &service.DrawPipelineStatistics{
+			Command:                   intent.Capture.Command(d.draw[0], d.draw[1:]...),
+			InputPrimitives:           d.counts[0],
+			VertexShaderInvocations:   d.counts[1],
+			ClippingInvocations:       d.counts[2],
+			ClippingPrimitives:        d.counts[3],
+			FragmentShaderInvocations: d.counts[4],
+		})
+	}
+	return out, nil
+}
@@ -38,8 +38,9 @@ const queryPoolSize = 256
 
 type timestampRecord struct {
 	timestamp service.TimestampsItem
-	// Is this timestamp from the last command in the commandbuffer
-	IsEoC bool
+	// The queries holding the timestamps written before and after the timed
+	// commands.
+	begin, end uint32
 }
 
 type queryResults []timestampRecord
@@ -70,16 +71,21 @@ type queryTimestamps struct {
 	allocated       []*api.AllocResult
 	willLoop        bool
 	readyToLoop     bool
+	subCommands     bool
 	handler         service.TimeStampsHandler
 	results         map[uint64]queryResults
 }
 
-func newQueryTimestamps(ctx context.Context, c *capture.GraphicsCapture, Cmds []api.Cmd, willLoop bool, handler service.TimeStampsHandler) *queryTimestamps {
+// newQueryTimestamps returns a transform that times the command buffers
+// submitted by Cmds. If subCommands is true, each of the commands recorded into
+// the submitted command buffers is timed too.
+func newQueryTimestamps(ctx context.Context, c *capture.GraphicsCapture, Cmds []api.Cmd, willLoop bool, subCommands bool, handler service.TimeStampsHandler) *queryTimestamps {
 	transform := &queryTimestamps{
 		cmds:         Cmds,
 		commandPools: make(map[commandPoolKey]VkCommandPool),
 		queryPools:   make(map[VkQueue]*queryPoolInfo),
 		willLoop:     willLoop,
+		subCommands:  subCommands,
 		handler:      handler,
 		results:      make(map[uint64]queryResults),
 	}
@@ -123,7 +129,7 @@ func (t *queryTimestamps) createQueryPoolIfNeeded(ctx context.Context,
 		// Increase the size of pool to 1.5 times of previous size or set it to numQuery whichever is larger.
 		qSize = max(numQuery, info.queryPoolSize*3/2)
 	} else {
-		qSize = queryPoolSize
+		qSize = max(numQuery, queryPoolSize)
 	}
 	log.I(ctx, "Create query pool of size %d", qSize)
 
@@ -263,6 +269,91 @@ func (t *queryTimestamps) generateQueryCommand(ctx context.Context,
 	return commandBufferID
 }
 
+// timedCommands returns whether a timestamp is written after each of the
+// commands args of a primary command buffer. Timestamps are not written inside
+// the subpasses for which isMultiview returns true, as they would be written
+// into as many queries as there are views in the subpass.
+func timedCommands(args []interface{}, isMultiview func(rp VkRenderPass, subpass uint32) bool) []bool {
+	out := make([]bool, len(args))
+	inRenderPass := false
+	var rp VkRenderPass
+	var subpass uint32
+	for i, a := range args {
+		switch a := a.(type) {
+		case VkCmdBeginRenderPassArgsʳ:
+			inRenderPass, rp, subpass = true, a.RenderPass(), 0
+		case VkCmdNextSubpassArgsʳ:
+			subpass++
+		case VkCmdEndRenderPassArgsʳ:
+			inRenderPass = false
+		}
+		out[i] = !inRenderPass || !isMultiview(rp, subpass)
+	}
+	return out
+}
+
+// isMultiviewSubpass returns true if the subpass of the render pass rp renders
+// to more than one view.
+func isMultiviewSubpass(c *State, rp VkRenderPass, subpass uint32) bool {
+	info := c.RenderPasses().Get(rp).MultiviewInfo()
+	return !info.IsNil() && info.ViewMasks().Get(subpass) != 0
+}
+
+// recordWithTimestamps records a copy of the primary command buffer cmdBuf
+// that writes a timestamp into the next query of queryPool, starting from
+// first, after each of its commands that is timed, and returns whether each of
+// its commands is timed. The commands of the secondary command buffers it
+// executes are not timed individually, and neither are the commands of the
+// multiview subpasses.
+func (t *queryTimestamps) recordWithTimestamps(ctx context.Context,
+	cb CommandBuilder,
+	out transform.Writer,
+	cmdBuf VkCommandBuffer,
+	queryPool VkQueryPool,
+	first uint32) (VkCommandBuffer, []bool) {
+	s := out.State()
+	c := GetState(s)
+	buf := c.CommandBuffers().Get(cmdBuf)
+	n := buf.CommandReferences().Len()
+
+	args := make([]interface{}, n)
+	for i := range args {
+		args[i] = GetCommandArgs(ctx, buf.CommandReferences().Get(uint32(i)), c)
+	}
+	timed := timedCommands(args, func(rp VkRenderPass, subpass uint32) bool {
+		return isMultiviewSubpass(c, rp, subpass)
+	})
+	count := uint32(0)
+	for _, t := range timed {
+		if t {
+			count++
+		}
+	}
+
+	newCmdBuf, cmds, cleanup := allocateNewCmdBufFromExistingOneAndBegin(ctx, cb, cmdBuf, s)
+	writeEach(ctx, out, cmds...)
+	for _, f := range cleanup {
+		f()
+	}
+	writeEach(ctx, out, cb.VkCmdResetQueryPool(newCmdBuf, queryPool, first, count))
+
+	query := first
+	for i, a := range args {
+		cleanup, cmd, _ := AddCommand(ctx, cb, newCmdBuf, s, s, a)
+		writeEach(ctx, out, cmd)
+		cleanup()
+		if timed[i] {
+			writeEach(ctx, out, cb.VkCmdWriteTimestamp(newCmdBuf,
+				VkPipelineStageFlagBits_VK_PIPELINE_STAGE_BOTTOM_OF_PIPE_BIT,
+				queryPool,
+				query))
+			query++
+		}
+	}
+	writeEach(ctx, out, cb.VkEndCommandBuffer(newCmdBuf, VkResult_VK_SUCCESS))
+	return newCmdBuf, timed
+}
+
 func (t *queryTimestamps) rewriteQueueSubmit(ctx context.Context,
 	cb CommandBuilder,
 	out transform.Writer,
@@ -313,6 +404,7 @@ func (t *queryTimestamps) rewriteQueueSubmit(ctx context.Context,
 		newCmdCount := uint32(0)
 		if cmdCount != 0 {
 			newCmdCount = cmdCount*2 + 1
+			start := queryPoolInfo.queryCount
 			commandbuffer := t.generateQueryCommand(ctx,
 				cb,
 				out,
@@ -327,6 +419,42 @@ func (t *queryTimestamps) rewriteQueueSubmit(ctx context.Context,
 				buf := cmdBuffers[j]
 				newCmdBuffers[j*2+1] = buf
 
+				c, ok := GetState(s).CommandBuffers().Lookup(buf)
+				if !ok {
+					return fmt.Errorf("Invalid command buffer %v", buf)
+				}
+				n := c.CommandReferences().Len()
+
+				subRecords := []timestampRecord{}
+				if t.subCommands && n > 0 {
+					first := queryPoolInfo.queryCount
+					var timed []bool
+					newCmdBuffers[j*2+1], timed = t.recordWithTimestamps(ctx, cb, out, buf, queryPoolInfo.queryPool, first)
+					// Each timed command is recorded with the untimed commands
+					// preceding it, as they completed between the same queries.
+					prev, query, begin := start, first, 0
+					for k, isTimed := range timed {
+						if !isTimed {
+							continue
+						}
+						timestampItem := service.TimestampsItem{
+							Begin: &path.Command{
+								Indices: []uint64{uint64(id), uint64(i), uint64(j), uint64(begin)},
+							},
+							End: &path.Command{
+								Indices: []uint64{uint64(id), uint64(i), uint64(j), uint64(k)},
+							},
+							TimeInNanoseconds: 0,
+						}
+						subRecords = append(subRecords,
+							timestampRecord{timestamp: timestampItem, begin: prev, end: query})
+						prev, begin = query, k+1
+						query++
+					}
+					queryPoolInfo.queryCount = query
+				}
+
+				end := queryPoolInfo.queryCount
 				commandbuffer = t.generateQueryCommand(ctx,
 					cb,
 					out,
@@ -337,25 +465,22 @@ func (t *queryTimestamps) rewriteQueueSubmit(ctx context.Context,
 				queryPoolInfo.queryCount++
 				newCmdBuffers[j*2+2] = commandbuffer
 
-				begin := &path.Command{
+				beginCmd := &path.Command{
 					Indices: []uint64{uint64(id), uint64(i), uint64(j), 0},
 				}
-				c, ok := GetState(s).CommandBuffers().Lookup(buf)
-				if !ok {
-					fmt.Errorf("Invalid command buffer %v", buf)
-				}
-				n := c.CommandReferences().Len()
 
 				k := 0
 				if n > 0 {
 					k = n - 1
 				}
-				end := &path.Command{
+				endCmd := &path.Command{
 					Indices: []uint64{uint64(id), uint64(i), uint64(j), uint64(k)},
 				}
-				timestampItem := service.TimestampsItem{Begin: begin, End: end, TimeInNanoseconds: 0}
+				timestampItem := service.TimestampsItem{Begin: beginCmd, End: endCmd, TimeInNanoseconds: 0}
 				queryPoolInfo.results = append(queryPoolInfo.results,
-					timestampRecord{timestamp: timestampItem, IsEoC: j == cmdCount-1})
+					timestampRecord{timestamp: timestampItem, begin: start, end: end})
+				queryPoolInfo.results = append(queryPoolInfo.results, subRecords...)
+				start = end
 			}
 
 			cmdBufferPtr = allocAndRead(newCmdBuffers).Ptr()
@@ -450,23 +575,21 @@ func (t *queryTimestamps) processNotification(ctx context.Context, s *api.Global
 
 	byteOrder := s.MemoryLayout.GetEndian()
 	r := endian.Reader(bytes.NewReader(timestampsData), byteOrder)
-	tStart := r.Uint64()
+	values := make([]uint64, len(timestampsData)/8)
+	for i := range values {
+		values[i] = r.Uint64()
+	}
 	var timestamps service.Timestamps
-	resultCount := uint32(len(timestampsData) / 8)
-	resIdx := 0
 
-	for i := uint32(1); i < resultCount; i++ {
-		tEnd := r.Uint64()
-		record := res[resIdx]
-		record.timestamp.TimeInNanoseconds = uint64(float32(tEnd-tStart) * t.timestampPeriod)
-		if record.IsEoC {
-			tStart = r.Uint64()
-			i++
-		} else {
-			tStart = tEnd
+	for i := range res {
+		record := res[i]
+		if int(record.end) >= len(values) {
+			log.W(ctx, "Missing timestamp query %d", record.end)
+			continue
 		}
+		tStart, tEnd := values[record.begin], values[record.end]
+		record.timestamp.TimeInNanoseconds = uint64(float32(tEnd-tStart) * t.timestampPeriod)
 		timestamps.Timestamps = append(timestamps.Timestamps, &record.timestamp)
-		resIdx++
 	}
 
 	t.handler(&service.GetTimestampsResponse{
@@ -505,11 +628,20 @@ func (t *queryTimestamps) Transform(ctx context.Context, id api.CmdID, cmd api.C
 		submitCount := cmd.SubmitCount()
 		submitInfos := cmd.pSubmits.Slice(0, uint64(submitCount), s.MemoryLayout).MustRead(ctx, cmd, s, nil)
 		cmdBufferCount := uint32(0)
+		subCommandCount := uint32(0)
 		for i := uint32(0); i < submitCount; i++ {
 			si := submitInfos[i]
 			cmdBufferCount += si.CommandBufferCount()
+			if t.subCommands {
+				cmdBuffers := si.PCommandBuffers().Slice(0, uint64(si.CommandBufferCount()), s.MemoryLayout).MustRead(ctx, cmd, s, nil)
+				for _, buf := range cmdBuffers {
+					if c, ok := GetState(s).CommandBuffers().Lookup(buf); ok {
+						subCommandCount += uint32(c.CommandReferences().Len())
+					}
+				}
+			}
 		}
-		queryCount := cmdBufferCount * 2
+		queryCount := cmdBufferCount*2 + subCommandCount
 
 		commandPool := t.createCommandpoolIfNeeded(ctx, cb, out, vkDevice, queueFamilyIndex)
 		queryPoolInfo := t.createQueryPoolIfNeeded(ctx, cb, out, vkQueue, vkDevice, queryCount)
@@ -0,0 +1,55 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+)
+
+func TestTimedCommands(t *testing.T) {
+	ctx := log.Testing(t)
+	a := arena.New()
+	defer a.Dispose()
+
+	begin := func(rp VkRenderPass) interface{} {
+		args := MakeVkCmdBeginRenderPassArgsʳ(a)
+		args.SetRenderPass(rp)
+		return args
+	}
+	draw := MakeVkCmdDrawArgsʳ(a)
+	next := MakeVkCmdNextSubpassArgsʳ(a)
+	end := MakeVkCmdEndRenderPassArgsʳ(a)
+
+	// Render pass 2 renders to multiple views in its second subpass.
+	isMultiview := func(rp VkRenderPass, subpass uint32) bool {
+		return rp == 2 && subpass == 1
+	}
+	got := timedCommands([]interface{}{
+		draw,
+		begin(1), draw, next, draw, end,
+		begin(2), draw, next, draw, draw, next, draw, end,
+		draw,
+	}, isMultiview)
+	assert.For(ctx, "timed").ThatSlice(got).Equals([]bool{
+		true,
+		true, true, true, true, true,
+		true, true, false, false, false, true, true, true,
+		true,
+	})
+}
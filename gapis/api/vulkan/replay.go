@@ -817,8 +817,9 @@ type timestampsConfig struct {
 }
 
 type timestampsRequest struct {
-	handler   service.TimeStampsHandler
-	loopCount int32
+	handler     service.TimeStampsHandler
+	loopCount   int32
+	subCommands bool
 }
 
 // uniqueConfig returns a replay.Config that is guaranteed to be unique.
@@ -842,6 +843,11 @@ func (a API) GetInitialPayload(ctx context.Context,
 	transforms := transform.Transforms{}
 	transforms.Add(&makeAttachementReadable{false})
 	transforms.Add(&dropInvalidDestroy{tag: "GetInitialPayload"})
+	rewrites, err := newUserRewrites(replay.GetRewrites(ctx))
+	if err != nil {
+		return err
+	}
+	transforms.Add(rewrites)
 	initialCmds, im, _ := initialcmds.InitialCommands(ctx, capture)
 	out.State().Allocator.ReserveRanges(im)
 
@@ -876,6 +882,11 @@ func (a API) Replay(
 	makeReadable := &makeAttachementReadable{false}
 	transforms.Add(makeReadable)
 	transforms.Add(&dropInvalidDestroy{tag: "Replay"})
+	rewrites, err := newUserRewrites(replay.GetRewrites(ctx))
+	if err != nil {
+		return err
+	}
+	transforms.Add(rewrites)
 
 	splitter := NewCommandSplitter(ctx)
 	readFramebuffer := newReadFramebuffer(ctx)
@@ -959,6 +970,9 @@ func (a API) Replay(
 	doDisplayToSurface := false
 	var overdraw *stencilOverdraw
 	var profile *replay.EndOfReplay
+	var dropLevels *dropMipLevels
+	var depthTest *depthTestQueries
+	var pipelineStats *pipelineStatisticsQueries
 
 	for _, rr := range rrs {
 		switch req := rr.Request.(type) {
@@ -982,7 +996,7 @@ func (a API) Replay(
 					frameloop = newFrameLoop(ctx, c, api.CmdID(0), frameLoopEndCmdID(cmds), req.loopCount)
 				}
 
-				timestamps = newQueryTimestamps(ctx, c, cmds, willLoop, req.handler)
+				timestamps = newQueryTimestamps(ctx, c, cmds, willLoop, req.subCommands, req.handler)
 			}
 			timestamps.AddResult(rr.Result)
 			optimize = false
@@ -1025,6 +1039,8 @@ func (a API) Replay(
 				wire = true
 			case service.DrawMode_WIREFRAME_OVERLAY:
 				return fmt.Errorf("Overlay wireframe view is not currently supported")
+			case service.DrawMode_TEXTURE_SAMPLES, service.DrawMode_TEXTURE_SAMPLES_ALL:
+				return fmt.Errorf("Texture sample counts are not currently supported for Vulkan")
 			// Overdraw is handled above, since it breaks out of the normal read flow.
 			default:
 			}
@@ -1041,6 +1057,54 @@ func (a API) Replay(
 			if req.displayToSurface {
 				doDisplayToSurface = true
 			}
+		case textureUsageRequest:
+			cfg := cfg.(textureUsageConfig)
+			// The views created by the initial commands must also drop their
+			// mip levels, so the commands cannot be eliminated.
+			optimize = false
+			if cfg.dropLevels > 0 && dropLevels == nil {
+				dropLevels = &dropMipLevels{image: cfg.image, levels: cfg.dropLevels}
+				transforms.Add(dropLevels)
+			}
+
+			results := req.results(rr.Result)
+			for i, d := range req.draws {
+				if err := earlyTerminator.Add(ctx, api.CmdID(d.Command[0]), api.SubCmdIdx{}); err != nil {
+					return err
+				}
+				subIdx := append(api.SubCmdIdx{}, d.Command...)
+				splitter.Split(ctx, subIdx)
+				readFramebuffer.Color(ctx, subIdx, d.Width, d.Height, d.FramebufferIndex, results[i])
+			}
+		case depthTestRequest:
+			cfg := cfg.(depthTestConfig)
+			// Without the depth test, the pipelines created by the initial
+			// commands must be rewritten too, so no commands are eliminated.
+			optimize = false
+			if depthTest == nil {
+				depthTest = newDepthTestQueries(cfg.disableDepthTest)
+			}
+			depthTest.add(req.begin, req.end)
+			depthTest.AddResult(rr.Result)
+			if req.end > 0 {
+				if err := earlyTerminator.Add(ctx, req.end-1, api.SubCmdIdx{}); err != nil {
+					return err
+				}
+			}
+		case pipelineStatisticsRequest:
+			// The devices created by the initial commands must enable the
+			// pipeline statistics queries, so no commands are eliminated.
+			optimize = false
+			if pipelineStats == nil {
+				pipelineStats = newPipelineStatisticsQueries()
+			}
+			pipelineStats.add(req.begin, req.end)
+			pipelineStats.AddResult(rr.Result)
+			if req.end > 0 {
+				if err := earlyTerminator.Add(ctx, req.end-1, api.SubCmdIdx{}); err != nil {
+					return err
+				}
+			}
 		case profileRequest:
 			if profile == nil {
 				profile = &replay.EndOfReplay{}
@@ -1102,6 +1166,14 @@ func (a API) Replay(
 		transforms.Add(overdraw)
 	}
 
+	if depthTest != nil {
+		transforms.Add(depthTest)
+	}
+
+	if pipelineStats != nil {
+		transforms.Add(pipelineStats)
+	}
+
 	if issues == nil && profile == nil {
 		transforms.Add(splitter)
 		transforms.Add(readFramebuffer, injector)
@@ -1212,12 +1284,14 @@ func (a API) QueryTimestamps(
 	intent replay.Intent,
 	mgr replay.Manager,
 	loopCount int32,
+	subCommands bool,
 	handler service.TimeStampsHandler,
 	hints *service.UsageHints) error {
 
 	c, r := timestampsConfig{}, timestampsRequest{
-		handler:   handler,
-		loopCount: loopCount}
+		handler:     handler,
+		loopCount:   loopCount,
+		subCommands: subCommands}
 	_, err := mgr.Replay(ctx, intent, c, r, a, hints, false)
 	if err != nil {
 		return err
@@ -0,0 +1,93 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/core/math/interval"
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.Scrubber(API{})
+)
+
+// ScrubbableReads implements api.Scrubber.
+// The contents of buffers and images are observed as reads of mapped device
+// memory, or as the data of vkCmdUpdateBuffer. The application and engine
+// names, and the names given to objects, are observed as reads of the strings
+// referenced by the commands that pass them.
+func (API) ScrubbableReads(ctx context.Context, cmd api.Cmd, s *api.GlobalState) []api.ScrubbableRead {
+	o := cmd.Extras().Observations()
+	if o == nil || len(o.Reads) == 0 {
+		return nil
+	}
+
+	out := []api.ScrubbableRead{}
+	names := map[uint64]bool{}
+	switch cmd := cmd.(type) {
+	case *VkCmdUpdateBuffer:
+		for i := range o.Reads {
+			out = append(out, api.ScrubbableRead{Index: i, Kind: api.ScrubAsset})
+		}
+		return out
+	case *VkCreateInstance:
+		if info, err := cmd.PCreateInfo().Read(ctx, cmd, s, nil); err == nil && !info.PApplicationInfo().IsNullptr() {
+			if app, err := info.PApplicationInfo().Read(ctx, cmd, s, nil); err == nil {
+				names[uint64(app.PApplicationName())] = true
+				names[uint64(app.PEngineName())] = true
+			}
+		}
+	case *VkSetDebugUtilsObjectNameEXT:
+		if info, err := cmd.PNameInfo().Read(ctx, cmd, s, nil); err == nil {
+			names[uint64(info.PObjectName())] = true
+		}
+	case *VkDebugMarkerSetObjectNameEXT:
+		if info, err := cmd.PNameInfo().Read(ctx, cmd, s, nil); err == nil {
+			names[uint64(info.PObjectName())] = true
+		}
+	}
+	delete(names, 0)
+
+	mapped := interval.U64RangeList{}
+	for _, mem := range GetState(s).DeviceMemories().All() {
+		if loc := uint64(mem.MappedLocation()); loc != 0 && mem.MappedSize() != 0 {
+			interval.Merge(&mapped, interval.U64Span{Start: loc, End: loc + uint64(mem.MappedSize())}, true)
+		}
+	}
+
+	for i, read := range o.Reads {
+		switch {
+		case names[read.Range.Base]:
+			out = append(out, api.ScrubbableRead{Index: i, Kind: api.ScrubName})
+		case isMapped(mapped, read.Range.Base, read.Range.Size):
+			out = append(out, api.ScrubbableRead{Index: i, Kind: api.ScrubAsset})
+		}
+	}
+	return out
+}
+
+// isMapped returns true if the range of size bytes at base lies entirely
+// within one of the mapped ranges.
+func isMapped(mapped interval.U64RangeList, base, size uint64) bool {
+	first, count := interval.Intersect(&mapped, interval.U64Span{Start: base, End: base + size})
+	if count != 1 {
+		return false
+	}
+	r := mapped[first]
+	return r.First <= base && base+size <= r.First+r.Count
+}
@@ -2143,6 +2143,20 @@ func (sb *stateBuilder) createRenderPass(rp RenderPassObjectʳ) {
 			),
 		).Ptr())
 	}
+	if !rp.MultiviewInfo().IsNil() {
+		pNext = NewVoidᶜᵖ(sb.MustAllocReadData(
+			NewVkRenderPassMultiviewCreateInfo(sb.ta,
+				VkStructureType_VK_STRUCTURE_TYPE_RENDER_PASS_MULTIVIEW_CREATE_INFO, // sType
+				pNext, // pNext
+				uint32(rp.MultiviewInfo().ViewMasks().Len()),                               // subpassCount
+				NewU32ᶜᵖ(sb.MustUnpackReadMap(rp.MultiviewInfo().ViewMasks().All()).Ptr()), // pViewMasks
+				0,                        // dependencyCount
+				NewS32ᶜᵖ(memory.Nullptr), // pViewOffsets
+				0,                        // correlationMaskCount
+				NewU32ᶜᵖ(memory.Nullptr), // pCorrelationMasks
+			),
+		).Ptr())
+	}
 
 	sb.write(sb.cb.VkCreateRenderPass(
 		rp.Device(),
@@ -0,0 +1,85 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Interface compliance test
+var (
+	_ = api.SyncEventLister(API{})
+)
+
+// ListSyncEvents implements api.SyncEventLister.
+// All semaphores are treated as binary semaphores, and the host waits and
+// signals of timeline semaphores are not listed.
+func (API) ListSyncEvents(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState, out *api.SyncTimeline) error {
+	if err := cmd.Mutate(ctx, id, s, nil, nil); err != nil {
+		return err
+	}
+
+	l := s.MemoryLayout
+	add := func(ty api.SyncEvent_Type, handle uint64, queue VkQueue) {
+		if handle == 0 {
+			return
+		}
+		out.Events = append(out.Events, &api.SyncEvent{
+			Type:    ty,
+			Command: &path.Command{Indices: []uint64{uint64(id)}},
+			Handle:  handle,
+			Queue:   uint64(queue),
+		})
+	}
+
+	switch cmd := cmd.(type) {
+	case *VkQueueSubmit:
+		infos := cmd.PSubmits().Slice(0, uint64(cmd.SubmitCount()), l).MustRead(ctx, cmd, s, nil)
+		for _, info := range infos {
+			waits := info.PWaitSemaphores().Slice(0, uint64(info.WaitSemaphoreCount()), l).MustRead(ctx, cmd, s, nil)
+			for _, sem := range waits {
+				add(api.SyncEvent_SEMAPHORE_WAIT, uint64(sem), cmd.Queue())
+			}
+		}
+		for _, info := range infos {
+			signals := info.PSignalSemaphores().Slice(0, uint64(info.SignalSemaphoreCount()), l).MustRead(ctx, cmd, s, nil)
+			for _, sem := range signals {
+				add(api.SyncEvent_SEMAPHORE_SIGNAL, uint64(sem), cmd.Queue())
+			}
+		}
+		add(api.SyncEvent_FENCE_SIGNAL, uint64(cmd.Fence()), cmd.Queue())
+	case *VkQueuePresentKHR:
+		info := cmd.PPresentInfo().MustRead(ctx, cmd, s, nil)
+		waits := info.PWaitSemaphores().Slice(0, uint64(info.WaitSemaphoreCount()), l).MustRead(ctx, cmd, s, nil)
+		for _, sem := range waits {
+			add(api.SyncEvent_SEMAPHORE_WAIT, uint64(sem), cmd.Queue())
+		}
+	case *VkAcquireNextImageKHR:
+		add(api.SyncEvent_SEMAPHORE_SIGNAL, uint64(cmd.Semaphore()), 0)
+		add(api.SyncEvent_FENCE_SIGNAL, uint64(cmd.Fence()), 0)
+	case *VkWaitForFences:
+		for _, f := range cmd.PFences().Slice(0, uint64(cmd.FenceCount()), l).MustRead(ctx, cmd, s, nil) {
+			add(api.SyncEvent_FENCE_WAIT, uint64(f), 0)
+		}
+	case *VkResetFences:
+		for _, f := range cmd.PFences().Slice(0, uint64(cmd.FenceCount()), l).MustRead(ctx, cmd, s, nil) {
+			add(api.SyncEvent_FENCE_RESET, uint64(f), 0)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/transform"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/resolve"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/shadertools/spirv"
+)
+
+// textureUsageTolerance is the largest difference of a color channel for which
+// two pixels of the replayed frames are considered equal, allowing for the
+// rounding of filtered samples.
+const textureUsageTolerance = 2
+
+// textureUsageConfig is a replay.Config used by textureUsageRequests. The views
+// of image are replayed with their dropLevels finest mip levels removed.
+type textureUsageConfig struct {
+	image      VkImage
+	dropLevels uint32
+}
+
+// textureUsageRequest requests a postback of the color attachments written by
+// the draws, replayed with the mip levels of a texture dropped as described by
+// the textureUsageConfig. The result is an []*image.Data holding the attachment
+// of each draw.
+type textureUsageRequest struct {
+	draws []replay.TextureUsageDraw
+}
+
+// results returns a replay.Result for each draw of the request, that calls res
+// with the attachments of all the draws once they have all been posted back.
+func (r textureUsageRequest) results(res replay.Result) []replay.Result {
+	var mutex sync.Mutex
+	data := make([]*image.Data, len(r.draws))
+	remaining := len(r.draws)
+	failed := false
+	out := make([]replay.Result, len(r.draws))
+	for i := range r.draws {
+		i := i
+		out[i] = func(val interface{}, err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if failed {
+				return
+			}
+			if err != nil {
+				failed = true
+				res(nil, err)
+				return
+			}
+			data[i] = val.(*image.Data)
+			if remaining--; remaining == 0 {
+				res(data, nil)
+			}
+		}
+	}
+	return out
+}
+
+// dropMipLevels is a transformation that creates the views of an image without
+// their finest mip levels, as if the image were created that many times
+// smaller. Views keep at least their coarsest mip level.
+type dropMipLevels struct {
+	image  VkImage
+	levels uint32
+}
+
+func (t *dropMipLevels) Transform(ctx context.Context, id api.CmdID, cmd api.Cmd, out transform.Writer) error {
+	create, ok := cmd.(*VkCreateImageView)
+	if !ok {
+		return out.MutateAndWrite(ctx, id, cmd)
+	}
+
+	s := out.State()
+	cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+	info := create.PCreateInfo().MustRead(ctx, create, s, nil)
+	if info.Image() != t.image {
+		return out.MutateAndWrite(ctx, id, cmd)
+	}
+
+	rng := info.SubresourceRange()
+	end := uint32(0xFFFFFFFF) // VK_REMAINING_MIP_LEVELS
+	if img, ok := GetState(s).Images().Lookup(t.image); ok {
+		end = img.Info().MipLevels()
+	}
+	if rng.LevelCount() != 0xFFFFFFFF {
+		end = rng.BaseMipLevel() + rng.LevelCount()
+	}
+	base := rng.BaseMipLevel() + t.levels
+	if base >= end {
+		base = end - 1
+	}
+	if rng.LevelCount() != 0xFFFFFFFF {
+		rng.SetLevelCount(end - base)
+	}
+	rng.SetBaseMipLevel(base)
+	info.SetSubresourceRange(rng)
+
+	cb := CommandBuilder{Thread: cmd.Thread(), Arena: s.Arena}
+	newInfo := s.AllocDataOrPanic(ctx, info)
+	newCmd := cb.VkCreateImageView(create.Device(), newInfo.Ptr(),
+		memory.Pointer(create.PAllocator()), memory.Pointer(create.PView()), create.Result())
+	for _, e := range create.Extras().All() {
+		if _, ok := e.(*api.CmdObservations); !ok {
+			newCmd.Extras().Add(e)
+		}
+	}
+	observations := create.Extras().Observations()
+	for _, r := range observations.Reads {
+		newCmd.AddRead(r.Range, r.ID)
+	}
+	newCmd.AddRead(newInfo.Data())
+	for _, w := range observations.Writes {
+		newCmd.AddWrite(w.Range, w.ID)
+	}
+	return out.MutateAndWrite(ctx, id, newCmd)
+}
+
+func (t *dropMipLevels) Flush(ctx context.Context, out transform.Writer) error { return nil }
+func (t *dropMipLevels) PreLoop(ctx context.Context, out transform.Writer)     {}
+func (t *dropMipLevels) PostLoop(ctx context.Context, out transform.Writer)    {}
+func (t *dropMipLevels) BuffersCommands() bool                                 { return false }
+
+// texture is an image sampled by the draws measured by QueryTextureUsage.
+type texture struct {
+	img    ImageObjectʳ
+	levels []uint64 // The size of the data of each mip level.
+	size   uint64
+	draws  []int // The indices of the draws that sample the image.
+}
+
+// newTexture returns the texture of the image, without any draws.
+func newTexture(img ImageObjectʳ) *texture {
+	t := &texture{img: img, levels: make([]uint64, img.Info().MipLevels())}
+	for _, aspect := range img.Aspects().All() {
+		for _, layer := range aspect.Layers().All() {
+			for i, level := range layer.Levels().All() {
+				if int(i) < len(t.levels) {
+					t.levels[i] += level.Data().Size()
+					t.size += level.Data().Size()
+				}
+			}
+		}
+	}
+	return t
+}
+
+// drawTextures returns the mipmapped images that are only ever sampled, and
+// that are sampled by the last draw executed in the state s. The sampled images
+// are those bound to the descriptor bindings that are accessed by the shaders
+// of the draw's pipeline.
+func drawTextures(ctx context.Context, s *api.GlobalState) ([]ImageObjectʳ, error) {
+	c := GetState(s)
+	queue := c.LastBoundQueue()
+	if queue.IsNil() {
+		return nil, nil
+	}
+	ldi, ok := c.LastDrawInfos().Lookup(queue.VulkanHandle())
+	if !ok || ldi.GraphicsPipeline().IsNil() {
+		return nil, nil
+	}
+	hasBit := func(flags VkImageUsageFlags, bit VkImageUsageFlagBits) bool {
+		return uint32(flags)&uint32(bit) != 0
+	}
+
+	out := []ImageObjectʳ{}
+	seen := map[VkImage]bool{}
+	stages := ldi.GraphicsPipeline().Stages()
+	for _, i := range stages.Keys() {
+		module := stages.Get(i).Module()
+		if module.IsNil() {
+			continue
+		}
+		words, err := module.Words().Read(ctx, nil, s, nil)
+		if err != nil {
+			return nil, err
+		}
+		m, err := spirv.Parse(words)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range m.AccessedBindings() {
+			set, ok := ldi.DescriptorSets().Lookup(b.Set)
+			if !ok || set.IsNil() {
+				continue
+			}
+			binding, ok := set.Bindings().Lookup(b.Binding)
+			if !ok || binding.IsNil() {
+				continue
+			}
+			switch binding.BindingType() {
+			case VkDescriptorType_VK_DESCRIPTOR_TYPE_COMBINED_IMAGE_SAMPLER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_SAMPLED_IMAGE:
+			default:
+				continue
+			}
+			for _, info := range binding.ImageBinding().All() {
+				view, ok := c.ImageViews().Lookup(info.ImageView())
+				if !ok || view.Image().IsNil() || seen[view.Image().VulkanHandle()] {
+					continue
+				}
+				img := view.Image()
+				seen[img.VulkanHandle()] = true
+
+				usage := img.Info().Usage()
+				if img.Info().MipLevels() < 2 ||
+					!hasBit(usage, VkImageUsageFlagBits_VK_IMAGE_USAGE_SAMPLED_BIT) ||
+					hasBit(usage, VkImageUsageFlagBits_VK_IMAGE_USAGE_COLOR_ATTACHMENT_BIT) ||
+					hasBit(usage, VkImageUsageFlagBits_VK_IMAGE_USAGE_DEPTH_STENCIL_ATTACHMENT_BIT) ||
+					hasBit(usage, VkImageUsageFlagBits_VK_IMAGE_USAGE_STORAGE_BIT) {
+					continue
+				}
+				out = append(out, img)
+			}
+		}
+	}
+	return out, nil
+}
+
+// changedPixels returns the fraction of the pixels of the RGBA_U8_NORM images
+// a and b that differ by more than textureUsageTolerance in any channel.
+func changedPixels(a, b []byte) float32 {
+	if len(a) != len(b) {
+		return 1
+	}
+	if len(a) < 4 {
+		return 0
+	}
+	changed := 0
+	for i := 0; i+3 < len(a); i += 4 {
+		for j := i; j < i+4; j++ {
+			d := int(a[j]) - int(b[j])
+			if d > textureUsageTolerance || d < -textureUsageTolerance {
+				changed++
+				break
+			}
+		}
+	}
+	return float32(changed) / float32(len(a)/4)
+}
+
+// QueryTextureUsage implements replay.QueryTextureUsage.
+// The textures of a draw are the mipmapped images bound to the descriptors
+// accessed by its shaders that are not also rendered to. Dropping mip levels
+// also changes the size of the image views, so shaders that fetch texels by
+// integer coordinates report all their levels as used.
+func (a API) QueryTextureUsage(
+	ctx context.Context,
+	intent replay.Intent,
+	mgr replay.Manager,
+	draws []replay.TextureUsageDraw,
+	maxTextures uint32,
+	config *path.ResolveConfig,
+	hints *service.UsageHints) (*service.TextureUsage, error) {
+
+	textures := []*texture{}
+	byImage := map[VkImage]*texture{}
+	for i, d := range draws {
+		s, err := resolve.GlobalState(ctx, intent.Capture.Command(d.Command[0], d.Command[1:]...).GlobalStateAfter(), config)
+		if err != nil {
+			return nil, err
+		}
+		imgs, err := drawTextures(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range imgs {
+			t, ok := byImage[img.VulkanHandle()]
+			if !ok {
+				t = newTexture(img)
+				byImage[img.VulkanHandle()] = t
+				textures = append(textures, t)
+			}
+			t.draws = append(t.draws, i)
+		}
+	}
+	sort.Slice(textures, func(i, j int) bool {
+		if textures[i].size != textures[j].size {
+			return textures[i].size > textures[j].size
+		}
+		return textures[i].img.VulkanHandle() < textures[j].img.VulkanHandle()
+	})
+
+	out := &service.TextureUsage{Textures: []*service.TextureUtilization{}}
+	if maxTextures > 0 && uint32(len(textures)) > maxTextures {
+		out.Skipped = uint32(len(textures)) - maxTextures
+		textures = textures[:maxTextures]
+	}
+	if len(textures) == 0 {
+		return out, nil
+	}
+
+	// frames returns the color attachments written by the draws of the given
+	// indices, replayed with the dropLevels finest mip levels of img dropped.
+	// The views created by the initial commands are changed too, so the
+	// replays cannot be split from the initial state.
+	frames := func(img VkImage, dropLevels uint32, indices []int) ([][]byte, error) {
+		r := textureUsageRequest{}
+		for _, i := range indices {
+			r.draws = append(r.draws, draws[i])
+		}
+		res, err := mgr.Replay(ctx, intent, textureUsageConfig{img, dropLevels}, r, a, hints, true)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := mgr.(replay.Exporter); ok {
+			return nil, nil
+		}
+		out := make([][]byte, len(indices))
+		for i, d := range res.([]*image.Data) {
+			data, err := d.Convert(image.RGBA_U8_NORM)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = data.Bytes
+		}
+		return out, nil
+	}
+
+	measured := []int{}
+	for i := range draws {
+		for _, t := range textures {
+			if containsDraw(t.draws, i) {
+				measured = append(measured, i)
+				break
+			}
+		}
+	}
+	baselineFrames, err := frames(VkImage(0), 0, measured)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := mgr.(replay.Exporter); ok {
+		return nil, nil
+	}
+	baseline := map[int][]byte{}
+	for i, d := range measured {
+		baseline[d] = baselineFrames[i]
+	}
+
+	for _, t := range textures {
+		levels := uint32(len(t.levels))
+
+		// changed returns the largest fraction of the pixels of the
+		// attachments of the draws sampling t that change when its drop finest
+		// mip levels are dropped.
+		changed := func(drop uint32) (float32, error) {
+			data, err := frames(t.img.VulkanHandle(), drop, t.draws)
+			if err != nil {
+				return 0, err
+			}
+			max := float32(0)
+			for i, d := range t.draws {
+				if c := changedPixels(baseline[d], data[i]); c > max {
+					max = c
+				}
+			}
+			return max, nil
+		}
+
+		// Dropping more levels changes at least the pixels changed by dropping
+		// fewer, so search for the fewest dropped levels that change a draw.
+		lo, hi := uint32(1), levels
+		changes := map[uint32]float32{}
+		for lo < hi {
+			mid := (lo + hi) / 2
+			c, err := changed(mid)
+			if err != nil {
+				return nil, err
+			}
+			if c > 0 {
+				changes[mid] = c
+				hi = mid
+			} else {
+				lo = mid + 1
+			}
+		}
+
+		info := t.img.Info()
+		u := &service.TextureUtilization{
+			Handle:          uint64(t.img.VulkanHandle()),
+			Format:          fmt.Sprint(info.Fmt()),
+			Width:           info.Extent().Width(),
+			Height:          info.Extent().Height(),
+			Levels:          levels,
+			FinestUsedLevel: lo - 1,
+			Size:            t.size,
+			ChangedPixels:   changes[lo],
+		}
+		for _, size := range t.levels[:u.FinestUsedLevel] {
+			u.UnusedSize += size
+		}
+		out.Textures = append(out.Textures, u)
+	}
+	return out, nil
+}
+
+// containsDraw returns true if the sorted draw indices contain the index i.
+func containsDraw(draws []int, i int) bool {
+	j := sort.SearchInts(draws, i)
+	return j < len(draws) && draws[j] == i
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Interface compliance test
+var (
+	_ = api.UniformUsageAnalyzer(API{})
+)
+
+// AnalyzeUniformUsage implements api.UniformUsageAnalyzer.
+// The uniforms of a pipeline are the push constant ranges of its pipeline
+// layout. Uniform buffers bound through descriptor sets are not analyzed.
+func (API) AnalyzeUniformUsage(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState, b *api.UniformUsageBuilder) error {
+	c := GetState(s)
+	var subErr error
+	c.PostSubcommand = func(ref interface{}) {
+		cr, ok := ref.(CommandReferenceʳ)
+		if !ok || subErr != nil {
+			return
+		}
+		switch args := GetCommandArgs(ctx, cr, c).(type) {
+		case VkCmdPushConstantsArgsʳ:
+			layout, ok := c.PipelineLayouts().Lookup(args.Layout())
+			if !ok {
+				return
+			}
+			begin, end := args.Offset(), args.Offset()+args.Size()
+			read := false
+			for _, r := range pushConstantRanges(layout) {
+				if r.Offset() < end && begin < r.Offset()+r.Size() {
+					b.Set(layoutName(layout), pushConstantName(r.Offset(), r.Size()), true, c.SubCmdIdx)
+					read = true
+				}
+			}
+			if !read {
+				b.Set(layoutName(layout), pushConstantName(args.Offset(), args.Size()), false, c.SubCmdIdx)
+			}
+		case VkCmdDrawArgsʳ, VkCmdDrawIndexedArgsʳ, VkCmdDrawIndirectArgsʳ, VkCmdDrawIndexedIndirectArgsʳ,
+			VkCmdDrawIndirectCountKHRArgsʳ, VkCmdDrawIndexedIndirectCountKHRArgsʳ,
+			VkCmdDrawIndirectCountAMDArgsʳ, VkCmdDrawIndexedIndirectCountAMDArgsʳ:
+			queue := c.LastBoundQueue()
+			if queue.IsNil() {
+				return
+			}
+			ldi, ok := c.LastDrawInfos().Lookup(queue.VulkanHandle())
+			if !ok || ldi.GraphicsPipeline().IsNil() || ldi.GraphicsPipeline().Layout().IsNil() {
+				return
+			}
+			layout := ldi.GraphicsPipeline().Layout()
+			ranges := pushConstantRanges(layout)
+			if len(ranges) == 0 {
+				return
+			}
+			pc, ok := c.LastPushConstants().Lookup(queue.VulkanHandle())
+			if !ok {
+				return
+			}
+			data, err := pc.Data().Read(ctx, nil, s, nil)
+			if err != nil {
+				subErr = err
+				return
+			}
+			for _, r := range ranges {
+				if end := r.Offset() + r.Size(); uint64(end) <= uint64(len(data)) {
+					b.Draw(layoutName(layout), pushConstantName(r.Offset(), r.Size()), data[r.Offset():end])
+				}
+			}
+		}
+	}
+	defer func() { c.PostSubcommand = nil }()
+	if err := cmd.Mutate(ctx, id, s, nil, nil); err != nil {
+		return err
+	}
+	return subErr
+}
+
+// pushConstantRanges returns the distinct push constant ranges of the
+// pipeline layout, in the order they were declared.
+func pushConstantRanges(layout PipelineLayoutObjectʳ) []VkPushConstantRange {
+	out := []VkPushConstantRange{}
+	seen := map[[2]uint32]bool{}
+	for _, i := range layout.PushConstantRanges().Keys() {
+		r := layout.PushConstantRanges().Get(i)
+		if key := [2]uint32{r.Offset(), r.Size()}; !seen[key] {
+			seen[key] = true
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// layoutName returns the description of the pipeline layout used by the
+// uniform usage.
+func layoutName(layout PipelineLayoutObjectʳ) string {
+	return fmt.Sprintf("Pipeline layout %v", layout.VulkanHandle())
+}
+
+// pushConstantName returns the name of the push constant range of the given
+// offset and size used by the uniform usage.
+func pushConstantName(offset, size uint32) string {
+	return fmt.Sprintf("push constants [%d, %d)", offset, offset+size)
+}
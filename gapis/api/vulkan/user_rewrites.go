@@ -0,0 +1,161 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/transform"
+	"github.com/google/gapid/gapis/replay"
+)
+
+// userRewrites is a transform that applies the rewrites declared by the user
+// to the samplers, images and image views created by the commands.
+type userRewrites struct {
+	maxAnisotropy *float32
+	mipLodBias    *float32
+	formats       map[VkFormat]VkFormat
+}
+
+// newUserRewrites returns a transform applying the Vulkan rewrites of r, or
+// nil if r holds none.
+func newUserRewrites(r *replay.Rewrites) (transform.Transformer, error) {
+	if r == nil {
+		return nil, nil
+	}
+	out := &userRewrites{formats: map[VkFormat]VkFormat{}}
+	for _, rw := range r.Rewrites {
+		switch rw := rw.Rewrite.(type) {
+		case *replay.Rewrite_MaxAnisotropy:
+			max := rw.MaxAnisotropy.Max
+			out.maxAnisotropy = &max
+		case *replay.Rewrite_MipLodBias:
+			bias := rw.MipLodBias.Bias
+			out.mipLodBias = &bias
+		case *replay.Rewrite_ReplaceFormat:
+			from, ok := vkFormatByName(rw.ReplaceFormat.From)
+			if !ok {
+				return nil, fmt.Errorf("Unknown Vulkan format '%v'", rw.ReplaceFormat.From)
+			}
+			to, ok := vkFormatByName(rw.ReplaceFormat.To)
+			if !ok {
+				return nil, fmt.Errorf("Unknown Vulkan format '%v'", rw.ReplaceFormat.To)
+			}
+			out.formats[from] = to
+		}
+	}
+	if out.maxAnisotropy == nil && out.mipLodBias == nil && len(out.formats) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// vkFormatByName returns the VkFormat with the given name, such as
+// VK_FORMAT_R8G8B8A8_UNORM, and true, or false if there is no such format.
+func vkFormatByName(name string) (VkFormat, bool) {
+	cs := API{}.ConstantSets()
+	for _, e := range cs.Sets[VkFormatConstants()].Entries {
+		if cs.Symbols.Get(e) == name {
+			return VkFormat(e.V), true
+		}
+	}
+	return 0, false
+}
+
+func (t *userRewrites) Transform(ctx context.Context, id api.CmdID, cmd api.Cmd, out transform.Writer) error {
+	s := out.State()
+	cb := CommandBuilder{Thread: cmd.Thread(), Arena: s.Arena}
+	switch cmd := cmd.(type) {
+	case *VkCreateSampler:
+		cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+		info := cmd.PCreateInfo().MustRead(ctx, cmd, s, nil)
+		if !t.rewriteSampler(&info) {
+			break
+		}
+		infoData := s.AllocDataOrPanic(ctx, info)
+		defer infoData.Free()
+		newCmd := cb.VkCreateSampler(cmd.Device(), infoData.Ptr(), cmd.PAllocator(),
+			cmd.PSampler(), cmd.Result())
+		return t.write(ctx, id, cmd, newCmd, infoData, out)
+	case *VkCreateImage:
+		cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+		info := cmd.PCreateInfo().MustRead(ctx, cmd, s, nil)
+		to, ok := t.formats[info.Fmt()]
+		if !ok {
+			break
+		}
+		info.SetFmt(to)
+		infoData := s.AllocDataOrPanic(ctx, info)
+		defer infoData.Free()
+		newCmd := cb.VkCreateImage(cmd.Device(), infoData.Ptr(), cmd.PAllocator(),
+			cmd.PImage(), cmd.Result())
+		return t.write(ctx, id, cmd, newCmd, infoData, out)
+	case *VkCreateImageView:
+		cmd.Extras().Observations().ApplyReads(s.Memory.ApplicationPool())
+		info := cmd.PCreateInfo().MustRead(ctx, cmd, s, nil)
+		to, ok := t.formats[info.Fmt()]
+		if !ok {
+			break
+		}
+		info.SetFmt(to)
+		infoData := s.AllocDataOrPanic(ctx, info)
+		defer infoData.Free()
+		newCmd := cb.VkCreateImageView(cmd.Device(), infoData.Ptr(), cmd.PAllocator(),
+			cmd.PView(), cmd.Result())
+		return t.write(ctx, id, cmd, newCmd, infoData, out)
+	}
+	return out.MutateAndWrite(ctx, id, cmd)
+}
+
+// rewriteSampler applies the sampler rewrites to info, returning true if info
+// was changed.
+func (t *userRewrites) rewriteSampler(info *VkSamplerCreateInfo) bool {
+	changed := false
+	if max := t.maxAnisotropy; max != nil && info.AnisotropyEnable() != 0 {
+		if *max < 1 {
+			info.SetAnisotropyEnable(0)
+			changed = true
+		} else if info.MaxAnisotropy() > *max {
+			info.SetMaxAnisotropy(*max)
+			changed = true
+		}
+	}
+	if bias := t.mipLodBias; bias != nil && info.MipLodBias() != *bias {
+		info.SetMipLodBias(*bias)
+		changed = true
+	}
+	return changed
+}
+
+// write writes newCmd, which replaces cmd with the create info held by
+// infoData, to out. The other memory read and written by cmd, such as the
+// extension structures of the create info, is read and written by newCmd.
+func (t *userRewrites) write(ctx context.Context, id api.CmdID, cmd, newCmd api.Cmd, infoData api.AllocResult, out transform.Writer) error {
+	for _, r := range cmd.Extras().Observations().Reads {
+		newCmd.Extras().GetOrAppendObservations().AddRead(r.Range, r.ID)
+	}
+	newCmd.Extras().GetOrAppendObservations().AddRead(infoData.Data())
+	for _, w := range cmd.Extras().Observations().Writes {
+		newCmd.Extras().GetOrAppendObservations().AddWrite(w.Range, w.ID)
+	}
+	return out.MutateAndWrite(ctx, id, newCmd)
+}
+
+func (t *userRewrites) Flush(ctx context.Context, out transform.Writer) error { return nil }
+func (t *userRewrites) PreLoop(ctx context.Context, out transform.Writer)     {}
+func (t *userRewrites) PostLoop(ctx context.Context, out transform.Writer)    {}
+func (t *userRewrites) BuffersCommands() bool                                 { return false }
@@ -0,0 +1,95 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/replay"
+)
+
+func TestNewUserRewrites(t *testing.T) {
+	ctx := log.Testing(t)
+	format := func(from, to string) *replay.Rewrite {
+		return &replay.Rewrite{Rewrite: &replay.Rewrite_ReplaceFormat{
+			ReplaceFormat: &replay.ReplaceFormat{From: from, To: to},
+		}}
+	}
+
+	got, err := newUserRewrites(nil)
+	assert.For(ctx, "nil err").ThatError(err).Succeeded()
+	assert.For(ctx, "nil").That(got).IsNil()
+
+	got, err = newUserRewrites(&replay.Rewrites{})
+	assert.For(ctx, "empty err").ThatError(err).Succeeded()
+	assert.For(ctx, "empty").That(got).IsNil()
+
+	_, err = newUserRewrites(&replay.Rewrites{Rewrites: []*replay.Rewrite{
+		format("VK_FORMAT_R8G8B8A8_SRGB", "VK_FORMAT_UNKNOWN"),
+	}})
+	assert.For(ctx, "unknown format").ThatError(err).Failed()
+
+	got, err = newUserRewrites(&replay.Rewrites{Rewrites: []*replay.Rewrite{
+		{Rewrite: &replay.Rewrite_MaxAnisotropy{MaxAnisotropy: &replay.MaxAnisotropy{Max: 4}}},
+		format("VK_FORMAT_R8G8B8A8_SRGB", "VK_FORMAT_R8G8B8A8_UNORM"),
+	}})
+	if assert.For(ctx, "err").ThatError(err).Succeeded() {
+		rw := got.(*userRewrites)
+		assert.For(ctx, "maxAnisotropy").That(*rw.maxAnisotropy).Equals(float32(4))
+		assert.For(ctx, "mipLodBias").That(rw.mipLodBias).IsNil()
+		assert.For(ctx, "formats").That(rw.formats).DeepEquals(map[VkFormat]VkFormat{
+			VkFormat_VK_FORMAT_R8G8B8A8_SRGB: VkFormat_VK_FORMAT_R8G8B8A8_UNORM,
+		})
+	}
+}
+
+func TestRewriteSampler(t *testing.T) {
+	ctx := log.Testing(t)
+	a := arena.New()
+	defer a.Dispose()
+
+	max, zero, bias := float32(4), float32(0), float32(-0.5)
+	sampler := func(anisotropy VkBool32, max float32) VkSamplerCreateInfo {
+		info := MakeVkSamplerCreateInfo(a)
+		info.SetAnisotropyEnable(anisotropy)
+		info.SetMaxAnisotropy(max)
+		return info
+	}
+	for _, test := range []struct {
+		name       string
+		rewrites   *userRewrites
+		info       VkSamplerCreateInfo
+		changed    bool
+		anisotropy VkBool32
+		max        float32
+		bias       float32
+	}{
+		{"capped", &userRewrites{maxAnisotropy: &max}, sampler(1, 16), true, 1, 4, 0},
+		{"under cap", &userRewrites{maxAnisotropy: &max}, sampler(1, 2), false, 1, 2, 0},
+		{"not anisotropic", &userRewrites{maxAnisotropy: &max}, sampler(0, 16), false, 0, 16, 0},
+		{"disabled", &userRewrites{maxAnisotropy: &zero}, sampler(1, 16), true, 0, 16, 0},
+		{"bias", &userRewrites{mipLodBias: &bias}, sampler(0, 1), true, 0, 1, -0.5},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		info := test.info
+		assert.For(ctx, "changed").That(test.rewrites.rewriteSampler(&info)).Equals(test.changed)
+		assert.For(ctx, "anisotropy").That(info.AnisotropyEnable()).Equals(test.anisotropy)
+		assert.For(ctx, "max").That(info.MaxAnisotropy()).Equals(test.max)
+		assert.For(ctx, "bias").That(info.MipLodBias()).Equals(test.bias)
+	}
+}
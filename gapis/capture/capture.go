@@ -22,6 +22,7 @@ import (
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/google/gapid/core/app/status"
 	"github.com/google/gapid/core/data/id"
@@ -38,9 +39,16 @@ import (
 // TODO: This needs to be moved to persistent storage.
 var (
 	capturesLock sync.RWMutex
-	captures     = []id.ID{}
+	captures     = []imported{}
 )
 
+// imported is an entry in the list of imported captures.
+type imported struct {
+	id   id.ID
+	name string
+	src  Source // nil if the capture was not imported from a Source.
+}
+
 // Capture represents data from a trace.
 type Capture interface {
 	// Name returns the name of the capture.
@@ -63,7 +71,7 @@ func New(ctx context.Context, c Capture) (*path.Capture, error) {
 	}
 
 	capturesLock.Lock()
-	captures = append(captures, id)
+	captures = append(captures, imported{id: id, name: c.Name()})
 	capturesLock.Unlock()
 
 	return &path.Capture{ID: path.NewID(id)}, nil
@@ -75,7 +83,7 @@ func Captures() []*path.Capture {
 	defer capturesLock.RUnlock()
 	out := make([]*path.Capture, len(captures))
 	for i, c := range captures {
-		out[i] = &path.Capture{ID: path.NewID(c)}
+		out[i] = &path.Capture{ID: path.NewID(c.id)}
 	}
 	return out
 }
@@ -130,22 +138,29 @@ func ResolvePerfettoFromPath(ctx context.Context, p *path.Capture) (*PerfettoCap
 
 // Import imports the capture by name and data, and stores it in the database.
 func Import(ctx context.Context, name string, key string, src Source) (*path.Capture, error) {
+	return ImportEncrypted(ctx, name, key, "", src)
+}
+
+// ImportEncrypted is like Import, but decrypts the capture with the key added
+// with the identifier keyID, instead of the current key, if it is encrypted.
+func ImportEncrypted(ctx context.Context, name string, key string, keyID string, src Source) (*path.Capture, error) {
 	dataID, err := database.Store(ctx, src)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to store capture data source: %v", err)
 	}
 
 	id, err := database.Store(ctx, &Record{
-		Key:  key,
-		Name: name,
-		Data: dataID[:],
+		Key:   key,
+		Name:  name,
+		Data:  dataID[:],
+		KeyId: keyID,
 	})
 	if err != nil {
 		return nil, err
 	}
 
 	capturesLock.Lock()
-	captures = append(captures, id)
+	captures = append(captures, imported{id: id, name: name, src: src})
 	capturesLock.Unlock()
 
 	return &path.Capture{ID: path.NewID(id)}, nil
@@ -244,7 +259,9 @@ func (l *loggingRC) Close() error {
 	return l.rc.Close()
 }
 
-func open(ctx context.Context, src Source) (r *bufio.Reader, close func() error, err error) {
+// open returns a reader of the capture held by src, decrypting it with the key
+// with the identifier keyID, or with the current key if keyID is empty.
+func open(ctx context.Context, src Source, keyID string) (r *bufio.Reader, close func() error, err error) {
 	in, err := src.ReadCloser()
 	if err != nil {
 		return nil, nil, err
@@ -255,13 +272,39 @@ func open(ctx context.Context, src Source) (r *bufio.Reader, close func() error,
 			rc:         in,
 		}
 	}
-	return bufio.NewReader(in), in.Close, nil
+	r = bufio.NewReader(in)
+	if isEncrypted(r) {
+		decrypted, err := decryptingReader(r, keyID)
+		if err != nil {
+			in.Close()
+			return nil, nil, err
+		}
+		r = bufio.NewReader(decrypted)
+		// Decrypt the first chunk now, so that a mismatched key is reported
+		// instead of an unrecognized format.
+		if _, err := r.Peek(1); err != nil && err != io.EOF {
+			in.Close()
+			return nil, nil, err
+		}
+	}
+	return r, in.Close, nil
+}
+
+// numLoading is the number of captures currently being decoded.
+var numLoading int32
+
+// NumLoading returns the number of captures currently being decoded.
+func NumLoading() int {
+	return int(atomic.LoadInt32(&numLoading))
 }
 
 func fromProto(ctx context.Context, r *Record) (Capture, error) {
 	ctx = status.Start(ctx, "Loading capture '%v'", r.Name)
 	defer status.Finish(ctx)
 
+	atomic.AddInt32(&numLoading, 1)
+	defer atomic.AddInt32(&numLoading, -1)
+
 	var dataID id.ID
 	copy(dataID[:], r.Data)
 	data, err := database.Resolve(ctx, dataID)
@@ -273,7 +316,7 @@ func fromProto(ctx context.Context, r *Record) (Capture, error) {
 		return nil, fmt.Errorf("Unable to load capture data source: Failed to resolve capture.Source")
 	}
 
-	in, close, err := open(ctx, src)
+	in, close, err := open(ctx, src, r.KeyId)
 	if err != nil {
 		return nil, err
 	}
@@ -16,6 +16,10 @@ package capture_test
 
 import (
 	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/gapid/core/assert"
@@ -26,6 +30,8 @@ import (
 	"github.com/google/gapid/gapis/api/test"
 	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
 )
 
 func TestCaptureExportImport(t *testing.T) {
@@ -62,3 +68,338 @@ func TestCaptureExportImport(t *testing.T) {
 
 	assert.For(ctx, "got").That(ic.(*capture.GraphicsCapture).Commands).CustomDeepEquals(cmds, test.Cmds.IgnoreArena)
 }
+
+func TestCaptureUnload(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{test.Cmds.A, test.Cmds.B}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "unload", header, nil, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+	p, err := c.Path(ctx)
+	if !assert.For(ctx, "capture.Path").ThatError(err).Succeeded() {
+		return
+	}
+	resident, err := capture.ResolveGraphicsFromPath(ctx, p)
+	if !assert.For(ctx, "capture.ResolveGraphicsFromPath").ThatError(err).Succeeded() {
+		return
+	}
+	derivedID, err := database.Store(ctx, p.Command(1))
+	if !assert.For(ctx, "database.Store").ThatError(err).Succeeded() {
+		return
+	}
+	derived, err := database.Resolve(ctx, derivedID)
+	if !assert.For(ctx, "database.Resolve").ThatError(err).Succeeded() {
+		return
+	}
+
+	isLoaded := func() bool {
+		for _, l := range capture.Loaded(ctx) {
+			if l.Path.ID.ID() == p.ID.ID() {
+				return true
+			}
+		}
+		return false
+	}
+	unloaded := 0
+	capture.OnUnload(func(ctx context.Context, u *path.Capture) {
+		if u.ID.ID() == p.ID.ID() {
+			unloaded++
+		}
+	})
+
+	assert.For(ctx, "loaded before unload").That(isLoaded()).Equals(true)
+	err = capture.Unload(ctx, p)
+	assert.For(ctx, "capture.Unload").ThatError(err).Succeeded()
+	assert.For(ctx, "loaded after unload").That(isLoaded()).Equals(false)
+	err = capture.Unload(ctx, p)
+	assert.For(ctx, "capture.Unload again").ThatError(err).Failed()
+	assert.For(ctx, "unload handler calls").That(unloaded).Equals(1)
+
+	// The capture should still be resolvable from its path.
+	rc, err := capture.ResolveGraphicsFromPath(ctx, p)
+	if !assert.For(ctx, "capture.ResolveGraphicsFromPath").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "got").That(rc.Commands).CustomDeepEquals(cmds, test.Cmds.IgnoreArena)
+	assert.For(ctx, "rebuilt").That(rc != resident).Equals(true)
+
+	// The entries derived from the capture should be rebuilt too.
+	rebuilt, err := database.Resolve(ctx, derivedID)
+	if !assert.For(ctx, "database.Resolve").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "derived rebuilt").That(rebuilt != derived).Equals(true)
+}
+
+func TestCaptureReadMetadata(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{test.Cmds.A, test.Cmds.B}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "test", header, nil, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err = c.Export(ctx, buf)
+	if !assert.For(ctx, "capture.Export").ThatError(err).Succeeded() {
+		return
+	}
+
+	h, m, err := capture.ReadMetadata(ctx, &capture.Blob{Data: buf.Bytes()})
+	if !assert.For(ctx, "capture.ReadMetadata").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "header.ABI").That(h.ABI).DeepEquals(device.WindowsX86_64)
+	if !assert.For(ctx, "metadata").That(m).IsNotNil() {
+		return
+	}
+	assert.For(ctx, "metadata.NumCommands").That(m.NumCommands).Equals(uint64(len(cmds)))
+	expected, err := c.Metadata(ctx)
+	if !assert.For(ctx, "c.Metadata").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "metadata.APIs").That(m.APIs).DeepEquals(expected.APIs)
+}
+
+func TestCaptureEncryption(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{test.Cmds.A, test.Cmds.B}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "test", header, nil, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+
+	key := bytes.Repeat([]byte{0x42}, 32)
+	if !assert.For(ctx, "capture.SetKey").ThatError(capture.SetKey(key)).Succeeded() {
+		return
+	}
+	defer capture.SetKey(nil)
+
+	buf := &bytes.Buffer{}
+	w, err := capture.EncryptingWriter(buf, "")
+	if !assert.For(ctx, "capture.EncryptingWriter").ThatError(err).Succeeded() {
+		return
+	}
+	err = c.Export(ctx, w)
+	if !assert.For(ctx, "capture.Export").ThatError(err).Succeeded() {
+		return
+	}
+	if !assert.For(ctx, "Close").ThatError(w.Close()).Succeeded() {
+		return
+	}
+
+	src := &capture.Blob{Data: buf.Bytes()}
+	h, _, err := capture.ReadMetadata(ctx, src)
+	if assert.For(ctx, "capture.ReadMetadata").ThatError(err).Succeeded() {
+		assert.For(ctx, "header.ABI").That(h.ABI).DeepEquals(device.WindowsX86_64)
+	}
+
+	// A chunk larger than any written is rejected without being allocated.
+	huge := append([]byte("gapidenc"), make([]byte, 12)...)
+	huge = append(huge, 0xff, 0xff, 0xff, 0xff)
+	_, _, err = capture.ReadMetadata(ctx, &capture.Blob{Data: huge})
+	assert.For(ctx, "huge chunk").ThatError(err).Failed()
+
+	capture.SetKey(bytes.Repeat([]byte{0x24}, 32))
+	_, _, err = capture.ReadMetadata(ctx, src)
+	assert.For(ctx, "wrong key").ThatError(err).Failed()
+
+	capture.SetKey(nil)
+	_, _, err = capture.ReadMetadata(ctx, src)
+	assert.For(ctx, "no key").ThatError(err).Failed()
+}
+
+func TestCaptureKey(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{test.Cmds.A, test.Cmds.B}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "test", header, nil, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+
+	keyID, err := capture.AddKey(bytes.Repeat([]byte{0x42}, 32))
+	if !assert.For(ctx, "capture.AddKey").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "current key").That(capture.KeyID()).Equals("")
+
+	buf := &bytes.Buffer{}
+	w, err := capture.EncryptingWriter(buf, keyID)
+	if !assert.For(ctx, "capture.EncryptingWriter").ThatError(err).Succeeded() {
+		return
+	}
+	if !assert.For(ctx, "capture.Export").ThatError(c.Export(ctx, w)).Succeeded() {
+		return
+	}
+	if !assert.For(ctx, "Close").ThatError(w.Close()).Succeeded() {
+		return
+	}
+
+	// The capture is only read with the key it was given.
+	src := &capture.Blob{Data: buf.Bytes()}
+	_, _, err = capture.ReadMetadata(ctx, src)
+	assert.For(ctx, "current key").ThatError(err).Failed()
+
+	p, err := capture.ImportEncrypted(ctx, "key", "test", keyID, src)
+	if !assert.For(ctx, "capture.ImportEncrypted").ThatError(err).Succeeded() {
+		return
+	}
+	got, err := capture.ResolveGraphicsFromPath(ctx, p)
+	if assert.For(ctx, "capture.ResolveGraphicsFromPath").ThatError(err).Succeeded() {
+		assert.For(ctx, "commands").That(len(got.Commands)).Equals(len(cmds))
+	}
+
+	_, err = capture.EncryptingWriter(&bytes.Buffer{}, "unknown")
+	assert.For(ctx, "unknown key").ThatError(err).Failed()
+}
+
+func TestCaptureIndex(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{test.Cmds.A, test.Cmds.B}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "test", header, nil, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+	buf := &bytes.Buffer{}
+	if !assert.For(ctx, "capture.Export").ThatError(c.Export(ctx, buf)).Succeeded() {
+		return
+	}
+
+	root, err := ioutil.TempDir("", "capture_index")
+	if !assert.For(ctx, "TempDir").ThatError(err).Succeeded() {
+		return
+	}
+	defer os.RemoveAll(root)
+	files := map[string][]byte{
+		"a.gfxtrace":          buf.Bytes(),
+		"sub/b.gfxtrace":      buf.Bytes(),
+		"sub/b.gfxtrace.tags": []byte("nightly\n\nperf\n"),
+		"notes.txt":           []byte("not a capture"),
+	}
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if !assert.For(ctx, "WriteFile").ThatError(ioutil.WriteFile(path, data, 0644)).Succeeded() {
+			return
+		}
+	}
+
+	idx, err := capture.BuildIndex(ctx, root, nil)
+	if !assert.For(ctx, "capture.BuildIndex").ThatError(err).Succeeded() {
+		return
+	}
+	search := func(q *service.CaptureQuery) []string {
+		out := []string{}
+		for _, c := range idx.Search(root, q) {
+			rel, _ := filepath.Rel(root, c.Path)
+			out = append(out, filepath.ToSlash(rel))
+		}
+		return out
+	}
+	assert.For(ctx, "all").That(len(search(&service.CaptureQuery{}))).Equals(2)
+	assert.For(ctx, "tags").That(search(&service.CaptureQuery{Tags: []string{"Nightly"}})).DeepEquals([]string{"sub/b.gfxtrace"})
+	assert.For(ctx, "text").That(search(&service.CaptureQuery{Text: "perf"})).DeepEquals([]string{"sub/b.gfxtrace"})
+	metadata, err := c.Metadata(ctx)
+	if !assert.For(ctx, "c.Metadata").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "frames").That(search(&service.CaptureQuery{MinFrames: metadata.NumFrames + 1})).DeepEquals([]string{})
+	assert.For(ctx, "limit").That(len(search(&service.CaptureQuery{Limit: 1}))).Equals(1)
+
+	for _, e := range idx.Entries {
+		assert.For(ctx, "entry.Metadata.NumCommands").That(e.Metadata.GetNumCommands()).Equals(uint64(len(cmds)))
+	}
+
+	rebuilt, err := capture.BuildIndex(ctx, root, idx)
+	if assert.For(ctx, "capture.BuildIndex").ThatError(err).Succeeded() {
+		assert.For(ctx, "reused").That(rebuilt.Entries[0] == idx.Entries[0]).Equals(true)
+	}
+
+	a := filepath.Join(root, "a.gfxtrace")
+	tags, err := capture.UpdateTags(ctx, a, []string{"bug-1234", "perf"}, nil)
+	if assert.For(ctx, "capture.UpdateTags").ThatError(err).Succeeded() {
+		assert.For(ctx, "tags").That(tags).DeepEquals([]string{"bug-1234", "perf"})
+	}
+	tags, err = capture.UpdateTags(ctx, a, []string{"PERF"}, []string{"Bug-1234"})
+	if assert.For(ctx, "capture.UpdateTags").ThatError(err).Succeeded() {
+		assert.For(ctx, "tags").That(tags).DeepEquals([]string{"perf"})
+	}
+	err = capture.SetAnnotation(ctx, a, "Regressed on device X\n")
+	if !assert.For(ctx, "capture.SetAnnotation").ThatError(err).Succeeded() {
+		return
+	}
+	idx, err = capture.BuildIndex(ctx, root, idx)
+	if !assert.For(ctx, "capture.BuildIndex").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "annotated").That(search(&service.CaptureQuery{Text: "device x"})).DeepEquals([]string{"a.gfxtrace"})
+	assert.For(ctx, "tagged").That(len(search(&service.CaptureQuery{Tags: []string{"perf"}}))).Equals(2)
+}
+
+func TestCaptureStableIDs(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	a := arena.New()
+	cb := test.CommandBuilder{Arena: a}
+	cmds := []api.Cmd{
+		cb.CmdTypeMix(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, false, test.Voidᵖ(0x10), 1),
+		cb.CmdTypeMix(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, false, test.Voidᵖ(0x20), 2),
+		cb.CmdTypeMix(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, false, test.Voidᵖ(0x30), 3),
+	}
+	c, err := capture.NewGraphicsCapture(ctx, a, "stable", header, nil, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+	for i, cmd := range c.Commands {
+		assert.For(ctx, "cmd[%d].StableID", i).That(cmd.Extras().StableID().GetId()).Equals(uint64(i + 1))
+	}
+
+	// Removing a command must not change the identifiers of the others, and
+	// new commands are given identifiers that have not been used before.
+	edited := []api.Cmd{
+		cmds[0],
+		cmds[2],
+		cb.CmdTypeMix(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, false, test.Voidᵖ(0x40), 4),
+	}
+	e, err := capture.NewGraphicsCapture(ctx, a, "edited", header, nil, edited)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+	for i, expected := range []uint64{1, 3, 4} {
+		assert.For(ctx, "edited[%d].StableID", i).That(e.Commands[i].Extras().StableID().GetId()).Equals(expected)
+	}
+	id, ok := e.CommandForStableID(3)
+	assert.For(ctx, "CommandForStableID(3)").That(ok).Equals(true)
+	assert.For(ctx, "CommandForStableID(3)").That(id).Equals(api.CmdID(1))
+	_, ok = e.CommandForStableID(2)
+	assert.For(ctx, "CommandForStableID(2)").That(ok).Equals(false)
+
+	// The identifiers are preserved when the capture is saved and reloaded.
+	buf := &bytes.Buffer{}
+	if err := e.Export(ctx, buf); !assert.For(ctx, "capture.Export").ThatError(err).Succeeded() {
+		return
+	}
+	ip, err := capture.Import(ctx, "stable", "imported", &capture.Blob{Data: buf.Bytes()})
+	if !assert.For(ctx, "capture.Import").ThatError(err).Succeeded() {
+		return
+	}
+	ic, err := capture.ResolveGraphicsFromPath(ctx, ip)
+	if !assert.For(ctx, "capture.Resolve").ThatError(err).Succeeded() {
+		return
+	}
+	for i, expected := range []uint64{1, 3, 4} {
+		assert.For(ctx, "imported[%d].StableID", i).That(ic.Commands[i].Extras().StableID().GetId()).Equals(expected)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"github.com/google/gapid/core/context/keys"
+	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/gapis/service/path"
 )
 
@@ -26,7 +27,11 @@ type contextKeyTy string
 const contextKey = contextKeyTy("captureID")
 
 // Put attaches a capture path to a Context.
+// Messages logged to the returned context carry the capture identifier.
 func Put(ctx context.Context, c *path.Capture) context.Context {
+	if old, ok := ctx.Value(contextKey).(*path.Capture); !ok || old.ID.ID() != c.ID.ID() {
+		ctx = log.V{"capture": c.ID.ID()}.Bind(ctx)
+	}
 	return keys.WithValue(ctx, contextKey, c)
 }
 
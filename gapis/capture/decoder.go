@@ -204,6 +204,14 @@ func (d *decoder) decode(ctx context.Context, in proto.Message) (interface{}, er
 		d.builder.addMessage(ctx, obj)
 		return in, nil
 
+	case *InputEvent:
+		d.builder.addInputEvent(ctx, obj)
+		return in, nil
+
+	case *DeviceSample:
+		d.builder.addDeviceSample(ctx, obj)
+		return in, nil
+
 	case api.Cmd:
 		return &cmdGroup{cmd: obj}, nil
 
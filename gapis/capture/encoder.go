@@ -48,6 +48,15 @@ func (e *encoder) encode(ctx context.Context) error {
 		return err
 	}
 
+	// Write the capture metadata.
+	metadata, err := e.c.Metadata(ctx)
+	if err != nil {
+		return err
+	}
+	if err := e.w.Object(ctx, metadata); err != nil {
+		return err
+	}
+
 	if e.c.InitialState != nil {
 		if err := e.initialState(ctx); err != nil {
 			return err
@@ -66,6 +75,24 @@ func (e *encoder) encode(ctx context.Context) error {
 			return err
 		}
 	}
+
+	// Write the messages, input events and device samples recorded alongside
+	// the commands.
+	for _, m := range e.c.Messages {
+		if err := e.w.Object(ctx, m); err != nil {
+			return err
+		}
+	}
+	for _, ev := range e.c.InputEvents {
+		if err := e.w.Object(ctx, ev); err != nil {
+			return err
+		}
+	}
+	for _, s := range e.c.DeviceSamples {
+		if err := e.w.Object(ctx, s); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
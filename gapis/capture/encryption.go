@@ -0,0 +1,296 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+)
+
+// An encrypted capture starts with encryptedMagic, followed by the random
+// nonce of the capture. The rest of the capture is a sequence of chunks, each
+// holding the little-endian uint32 size of the chunk's sealed data, followed
+// by the sealed data. Each chunk holds at most encryptedChunkSize bytes of the
+// capture, sealed with AES-GCM using the capture's nonce combined with the
+// chunk's index. The last chunk is sealed with additional data that marks it
+// as such, so that a truncated capture cannot be mistaken for a complete one.
+var encryptedMagic = []byte("gapidenc")
+
+const encryptedChunkSize = 1 << 20
+
+var (
+	encryptionLock sync.RWMutex
+	encryptionAEAD cipher.AEAD
+	encryptionKey  string
+	captureKeys    = map[string]cipher.AEAD{} // The keys added with AddKey, by ID.
+)
+
+// SetKey sets the AES key used to decrypt encrypted captures, and to encrypt
+// the captures written with EncryptingWriter. The key must be 16, 24 or 32
+// bytes long. An empty key clears the current key.
+// The key applies to all the captures of the server, so it is only set by the
+// owner of the server when it is started, and not by its clients.
+func SetKey(key []byte) error {
+	var aead cipher.AEAD
+	id := ""
+	if len(key) > 0 {
+		var err error
+		if aead, id, err = newAEAD(key); err != nil {
+			return err
+		}
+	}
+
+	encryptionLock.Lock()
+	defer encryptionLock.Unlock()
+	encryptionAEAD, encryptionKey = aead, id
+	return nil
+}
+
+// AddKey adds the AES key given for individual captures, leaving the key set
+// with SetKey unchanged. It returns the identifier of the key, which is passed
+// to ImportEncrypted and EncryptingWriter to use the key for a capture. The
+// key must be 16, 24 or 32 bytes long.
+func AddKey(key []byte) (string, error) {
+	aead, id, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	encryptionLock.Lock()
+	defer encryptionLock.Unlock()
+	captureKeys[id] = aead
+	return id, nil
+}
+
+// KeyID returns a string identifying the current key, or an empty string if
+// no key is set. The key cannot be recovered from the identifier.
+func KeyID() string {
+	encryptionLock.RLock()
+	defer encryptionLock.RUnlock()
+	return encryptionKey
+}
+
+func newAEAD(key []byte) (cipher.AEAD, string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, "", err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(key)
+	return aead, hex.EncodeToString(sum[:8]), nil
+}
+
+// keyAEAD returns the cipher of the key with the given identifier, or of the
+// current key if keyID is empty.
+func keyAEAD(keyID string) (cipher.AEAD, error) {
+	encryptionLock.RLock()
+	defer encryptionLock.RUnlock()
+	if keyID == "" {
+		return encryptionAEAD, nil
+	}
+	aead, ok := captureKeys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("Unknown capture key '%v'", keyID)
+	}
+	return aead, nil
+}
+
+// EncryptingWriter returns a writer that encrypts the capture written to it
+// with the key added with the identifier keyID, or with the current key if
+// keyID is empty, and writes it to w. If keyID is empty and no key is set, the
+// capture is written to w unencrypted. The returned writer must be closed to
+// complete the capture, which does not close w.
+func EncryptingWriter(w io.Writer, keyID string) (io.WriteCloser, error) {
+	aead, err := keyAEAD(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if aead == nil {
+		return nopWriteCloser{w}, nil
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(encryptedMagic); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(nonce); err != nil {
+		return nil, err
+	}
+	return &encryptingWriter{
+		w:     w,
+		aead:  aead,
+		nonce: nonce,
+		buf:   make([]byte, 0, encryptedChunkSize),
+	}, nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+type encryptingWriter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	nonce []byte
+	buf   []byte
+	chunk uint64
+}
+
+func (e *encryptingWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if len(e.buf) == encryptedChunkSize {
+			if err := e.flush(false); err != nil {
+				return n, err
+			}
+		}
+		c := copy(e.buf[len(e.buf):encryptedChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+c]
+		p, n = p[c:], n+c
+	}
+	return n, nil
+}
+
+func (e *encryptingWriter) Close() error {
+	return e.flush(true)
+}
+
+func (e *encryptingWriter) flush(last bool) error {
+	sealed := e.aead.Seal(nil, chunkNonce(e.nonce, e.chunk), e.buf, chunkData(last))
+	size := [4]byte{}
+	binary.LittleEndian.PutUint32(size[:], uint32(len(sealed)))
+	if _, err := e.w.Write(size[:]); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(sealed); err != nil {
+		return err
+	}
+	e.buf, e.chunk = e.buf[:0], e.chunk+1
+	return nil
+}
+
+// isEncrypted returns true if the capture read by in is encrypted.
+func isEncrypted(in *bufio.Reader) bool {
+	magic, err := in.Peek(len(encryptedMagic))
+	return err == nil && bytes.Equal(magic, encryptedMagic)
+}
+
+// decryptingReader returns a reader of the capture read by in, decrypted with
+// the key with the identifier keyID, or with the current key if keyID is
+// empty. The capture must start with encryptedMagic.
+func decryptingReader(in io.Reader, keyID string) (io.Reader, error) {
+	aead, err := keyAEAD(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if aead == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrCaptureEncrypted()}
+	}
+	header := make([]byte, len(encryptedMagic)+aead.NonceSize())
+	if _, err := io.ReadFull(in, header); err != nil {
+		return nil, err
+	}
+	return &decryptor{
+		in:    in,
+		aead:  aead,
+		nonce: header[len(encryptedMagic):],
+	}, nil
+}
+
+type decryptor struct {
+	in    io.Reader
+	aead  cipher.AEAD
+	nonce []byte
+	buf   []byte
+	chunk uint64
+	done  bool
+}
+
+func (d *decryptor) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decryptor) next() error {
+	size := [4]byte{}
+	if _, err := io.ReadFull(d.in, size[:]); err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("Encrypted capture is truncated")
+		}
+		return err
+	}
+	n := binary.LittleEndian.Uint32(size[:])
+	if max := uint32(encryptedChunkSize + d.aead.Overhead()); n > max {
+		return fmt.Errorf("Encrypted capture chunk of %d bytes is larger than the maximum of %d bytes", n, max)
+	}
+	sealed := make([]byte, n)
+	if _, err := io.ReadFull(d.in, sealed); err != nil {
+		return err
+	}
+	nonce := chunkNonce(d.nonce, d.chunk)
+	if data, err := d.aead.Open(nil, nonce, sealed, chunkData(false)); err == nil {
+		d.buf = data
+	} else if data, err := d.aead.Open(nil, nonce, sealed, chunkData(true)); err == nil {
+		d.buf, d.done = data, true
+	} else {
+		return &service.ErrDataUnavailable{Reason: messages.ErrCaptureKeyMismatch()}
+	}
+	d.chunk++
+	return nil
+}
+
+// chunkNonce returns the nonce used to seal the chunk with the given index of
+// the capture with the given nonce.
+func chunkNonce(nonce []byte, chunk uint64) []byte {
+	out := make([]byte, len(nonce))
+	copy(out, nonce)
+	tail := out[len(out)-8:]
+	binary.LittleEndian.PutUint64(tail, binary.LittleEndian.Uint64(tail)^chunk)
+	return out
+}
+
+// chunkData returns the additional data used to seal a chunk.
+func chunkData(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
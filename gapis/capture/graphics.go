@@ -19,6 +19,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/google/gapid/core/app/analytics"
 	"github.com/google/gapid/core/app/status"
@@ -51,14 +52,25 @@ func (e ErrUnsupportedVersion) Error() string {
 }
 
 type GraphicsCapture struct {
-	name         string
-	Header       *Header
-	Commands     []api.Cmd
-	APIs         []api.API
-	Observed     interval.U64RangeList
-	InitialState *InitialState
-	Arena        arena.Arena
-	Messages     []*TraceMessage
+	name          string
+	Header        *Header
+	Commands      []api.Cmd
+	APIs          []api.API
+	Observed      interval.U64RangeList
+	InitialState  *InitialState
+	Arena         arena.Arena
+	Messages      []*TraceMessage
+	InputEvents   []*InputEvent
+	DeviceSamples []*DeviceSample
+
+	stableIDsOnce sync.Once
+	stableIDs     map[uint64]api.CmdID
+
+	stateEditsOnce sync.Once
+	stateEdits     map[api.Cmd][]*api.StateEdit
+
+	metadataMutex sync.Mutex
+	metadata      *Metadata
 }
 
 // Name returns the capture's name.
@@ -66,6 +78,30 @@ func (g *GraphicsCapture) Name() string {
 	return g.name
 }
 
+// CommandForStableID returns the index of the command with the given stable
+// identifier, and true, or false if the capture has no such command.
+func (g *GraphicsCapture) CommandForStableID(stableID uint64) (api.CmdID, bool) {
+	g.stableIDsOnce.Do(func() {
+		g.stableIDs = make(map[uint64]api.CmdID, len(g.Commands))
+		for i, cmd := range g.Commands {
+			if s := cmd.Extras().StableID(); s != nil {
+				g.stableIDs[s.Id] = api.CmdID(i)
+			}
+		}
+	})
+	id, ok := g.stableIDs[stableID]
+	return id, ok
+}
+
+// StateEdits returns the state edits of the commands of the capture, keyed by
+// command, or nil if the capture has no state edits.
+func (g *GraphicsCapture) StateEdits() map[api.Cmd][]*api.StateEdit {
+	g.stateEditsOnce.Do(func() {
+		g.stateEdits = api.StateEditsOf(g.Commands)
+	})
+	return g.stateEdits
+}
+
 // Path returns the path of this capture in the database.
 func (g *GraphicsCapture) Path(ctx context.Context) (*path.Capture, error) {
 	return New(ctx, g)
@@ -127,6 +163,7 @@ func (c *GraphicsCapture) NewUninitializedState(ctx context.Context) *api.Global
 		memory.NewBasicAllocator(freeList),
 		c.Header.ABI.MemoryLayout,
 	)
+	s.StateEdits = c.StateEdits()
 	return s
 }
 
@@ -286,14 +323,18 @@ func deserializeGFXTrace(ctx context.Context, r *Record, in io.Reader) (out *Gra
 }
 
 type builder struct {
-	apis         []api.API
-	seenAPIs     map[api.ID]struct{}
-	observed     interval.U64RangeList
-	cmds         []api.Cmd
-	resIDs       []id.ID
-	initialState *InitialState
-	arena        arena.Arena
-	messages     []*TraceMessage
+	apis          []api.API
+	seenAPIs      map[api.ID]struct{}
+	observed      interval.U64RangeList
+	cmds          []api.Cmd
+	resIDs        []id.ID
+	initialState  *InitialState
+	arena         arena.Arena
+	messages      []*TraceMessage
+	inputEvents   []*InputEvent
+	deviceSamples []*DeviceSample
+	maxStableID   uint64
+	unstable      []api.Cmd // Commands without a stable identifier.
 }
 
 func newBuilder(a arena.Arena) *builder {
@@ -318,6 +359,13 @@ func (b *builder) addCmd(ctx context.Context, cmd api.Cmd) api.CmdID {
 			b.addObservation(ctx, &observations.Writes[i])
 		}
 	}
+	if s := cmd.Extras().StableID(); s != nil {
+		if s.Id > b.maxStableID {
+			b.maxStableID = s.Id
+		}
+	} else {
+		b.unstable = append(b.unstable, cmd)
+	}
 	id := api.CmdID(len(b.cmds))
 	b.cmds = append(b.cmds, cmd)
 	return id
@@ -327,6 +375,14 @@ func (b *builder) addMessage(ctx context.Context, t *TraceMessage) {
 	b.messages = append(b.messages, &TraceMessage{Timestamp: t.Timestamp, Message: t.Message})
 }
 
+func (b *builder) addInputEvent(ctx context.Context, e *InputEvent) {
+	b.inputEvents = append(b.inputEvents, e)
+}
+
+func (b *builder) addDeviceSample(ctx context.Context, s *DeviceSample) {
+	b.deviceSamples = append(b.deviceSamples, s)
+}
+
 func (b *builder) addAPI(ctx context.Context, api api.API) {
 	if api != nil {
 		apiID := api.ID()
@@ -374,15 +430,24 @@ func (b *builder) build(name string, header *Header) *GraphicsCapture {
 	for _, api := range b.apis {
 		analytics.SendEvent("capture", "uses-api", api.Name())
 	}
+	// Give the commands that don't have a stable identifier a new one. For a
+	// capture that was just taken, this is the command's index plus one.
+	for _, cmd := range b.unstable {
+		b.maxStableID++
+		cmd.Extras().Add(&api.StableID{Id: b.maxStableID})
+	}
+	b.unstable = nil
 	// TODO: Mark the arena as read-only.
 	return &GraphicsCapture{
-		name:         name,
-		Header:       header,
-		Commands:     b.cmds,
-		Observed:     b.observed,
-		APIs:         b.apis,
-		InitialState: b.initialState,
-		Arena:        b.arena,
-		Messages:     b.messages,
+		name:          name,
+		Header:        header,
+		Commands:      b.cmds,
+		Observed:      b.observed,
+		APIs:          b.apis,
+		InitialState:  b.initialState,
+		Arena:         b.arena,
+		Messages:      b.messages,
+		InputEvents:   b.inputEvents,
+		DeviceSamples: b.deviceSamples,
 	}
 }
@@ -0,0 +1,325 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+const (
+	// IndexFile is the name of the file, held in the root of an indexed
+	// directory tree, that stores the index of the tree.
+	IndexFile = ".gapid-index"
+
+	// TagsSuffix is appended to the path of a capture file to form the path of
+	// the file holding the capture's tags, one per line.
+	TagsSuffix = ".tags"
+
+	// AnnotationSuffix is appended to the path of a capture file to form the
+	// path of the file holding the capture's free-text annotation.
+	AnnotationSuffix = ".annotation"
+
+	// captureExt is the extension of the capture files that are indexed.
+	captureExt = ".gfxtrace"
+)
+
+// LoadIndex returns the index stored in the directory root, or an empty index
+// if there is no stored index or it cannot be read.
+func LoadIndex(ctx context.Context, root string) *Index {
+	data, err := ioutil.ReadFile(filepath.Join(root, IndexFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.W(ctx, "Couldn't read capture index: %v", err)
+		}
+		return &Index{}
+	}
+	idx := &Index{}
+	if err := proto.Unmarshal(data, idx); err != nil {
+		log.W(ctx, "Couldn't decode capture index: %v", err)
+		return &Index{}
+	}
+	return idx
+}
+
+// SaveIndex stores idx in the directory root.
+func SaveIndex(ctx context.Context, root string, idx *Index) error {
+	data, err := proto.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(root, IndexFile), data, 0644)
+}
+
+// BuildIndex walks the directory tree at root and returns an index of the
+// captures found within it. Entries of prev for files with an unchanged size
+// and modification time are reused instead of reading the captures again.
+// Captures that do not hold Metadata are decoded to produce it, which can be
+// slow for large captures.
+func BuildIndex(ctx context.Context, root string, prev *Index) (*Index, error) {
+	known := map[string]*IndexEntry{}
+	for _, e := range prev.GetEntries() {
+		known[e.Path] = e
+	}
+
+	out := &Index{}
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			log.W(ctx, "Couldn't index %v: %v", path, err)
+			return nil
+		}
+		if fi.IsDir() || !strings.HasSuffix(path, captureExt) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		e, ok := known[rel]
+		if !ok || e.Size != uint64(fi.Size()) || e.Modified != fi.ModTime().UnixNano() {
+			e = &IndexEntry{
+				Path:     rel,
+				Size:     uint64(fi.Size()),
+				Modified: fi.ModTime().UnixNano(),
+			}
+			indexCapture(ctx, path, e)
+		}
+		e.Tags = readTags(ctx, path)
+		e.Annotation = readAnnotation(ctx, path)
+		out.Entries = append(out.Entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateIndex builds the index of the directory tree at root, reusing the
+// entries of the stored index, and then stores the new index.
+// A failure to store the index is logged, but does not fail the update.
+func UpdateIndex(ctx context.Context, root string) (*Index, error) {
+	idx, err := BuildIndex(ctx, root, LoadIndex(ctx, root))
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveIndex(ctx, root, idx); err != nil {
+		log.W(ctx, "Couldn't store capture index: %v", err)
+	}
+	return idx, nil
+}
+
+// indexCapture reads the header and metadata of the capture file at path into
+// e. Failures are recorded in e, so that the capture is not read again until
+// it changes.
+func indexCapture(ctx context.Context, path string, e *IndexEntry) {
+	src := &File{Path: path}
+	header, metadata, err := ReadMetadata(ctx, src)
+	if err == nil && metadata == nil {
+		metadata, err = decodeMetadata(ctx, src, fmt.Sprintf("index:%v:%v", path, e.Modified))
+	}
+	if err != nil {
+		log.W(ctx, "Couldn't index %v: %v", path, err)
+		e.Error = err.Error()
+		return
+	}
+	metadata.Thumbnail = nil
+	e.Header, e.Metadata = header, metadata
+}
+
+// decodeMetadata decodes the capture held by src to build its Metadata. The
+// decoded capture is unloaded afterwards.
+func decodeMetadata(ctx context.Context, src *File, key string) (*Metadata, error) {
+	p, err := Import(ctx, filepath.Base(src.Path), key, src)
+	if err != nil {
+		return nil, err
+	}
+	defer Unload(ctx, p)
+	c, err := ResolveGraphicsFromPath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return c.Metadata(ctx)
+}
+
+// readTags returns the tags of the capture file at path, held one per line
+// by the tags file.
+func readTags(ctx context.Context, path string) []string {
+	out := []string{}
+	for _, line := range strings.Split(readSidecar(ctx, path+TagsSuffix), "\n") {
+		if tag := strings.TrimSpace(line); tag != "" {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// readAnnotation returns the annotation of the capture file at path.
+func readAnnotation(ctx context.Context, path string) string {
+	return strings.TrimSpace(readSidecar(ctx, path+AnnotationSuffix))
+}
+
+// readSidecar returns the contents of the file at path, or an empty string if
+// the file does not exist or cannot be read.
+func readSidecar(ctx context.Context, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.W(ctx, "Couldn't read %v: %v", path, err)
+		}
+		return ""
+	}
+	return string(data)
+}
+
+// writeSidecar replaces the contents of the file at path with data, or
+// removes the file if data is empty.
+func writeSidecar(path, data string) error {
+	if data == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return ioutil.WriteFile(path, []byte(data), 0644)
+}
+
+// UpdateTags gives the capture file at path the tags add and removes the tags
+// remove, ignoring case, and returns the resulting tags.
+func UpdateTags(ctx context.Context, path string, add, remove []string) ([]string, error) {
+	tags := []string{}
+	for _, t := range readTags(ctx, path) {
+		if !anyEqual(remove, t) {
+			tags = append(tags, t)
+		}
+	}
+	for _, t := range add {
+		if t = strings.TrimSpace(t); t != "" && !anyEqual(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	data := ""
+	if len(tags) > 0 {
+		data = strings.Join(tags, "\n") + "\n"
+	}
+	if err := writeSidecar(path+TagsSuffix, data); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// SetAnnotation replaces the annotation of the capture file at path. An empty
+// annotation removes the annotation.
+func SetAnnotation(ctx context.Context, path, annotation string) error {
+	return writeSidecar(path+AnnotationSuffix, strings.TrimSpace(annotation))
+}
+
+// Service returns the service description of the indexed capture, where root
+// is the root of the index holding e.
+func (e *IndexEntry) Service(root string) *service.IndexedCapture {
+	return &service.IndexedCapture{
+		Path:        filepath.Join(root, e.Path),
+		Size:        e.Size,
+		Modified:    e.Modified,
+		Device:      e.Header.GetDevice(),
+		APIs:        e.Metadata.GetAPIs(),
+		Application: e.Metadata.GetApplication(),
+		NumCommands: e.Metadata.GetNumCommands(),
+		NumFrames:   e.Metadata.GetNumFrames(),
+		Duration:    e.Metadata.GetDuration(),
+		Tags:        e.Tags,
+		Error:       e.Error,
+		Annotation:  e.Annotation,
+	}
+}
+
+// Search returns the entries of idx that match q, most recently modified
+// first, where root is the root of the index.
+func (idx *Index) Search(root string, q *service.CaptureQuery) []*service.IndexedCapture {
+	out := []*service.IndexedCapture{}
+	for _, e := range idx.GetEntries() {
+		if c := e.Service(root); matches(c, q) {
+			out = append(out, c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Modified > out[j].Modified })
+	if limit := int(q.GetLimit()); limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// matches returns true if the capture c matches every condition of q.
+func matches(c *service.IndexedCapture, q *service.CaptureQuery) bool {
+	d := c.Device
+	device := []string{d.GetName(), d.GetSerial(), d.GetConfiguration().GetHardware().GetName()}
+	if q.GetText() != "" {
+		fields := append([]string{c.Path, c.Application, c.Annotation}, device...)
+		if !anyContains(append(fields, c.Tags...), q.Text) {
+			return false
+		}
+	}
+	if q.GetDevice() != "" && !anyContains(device, q.Device) {
+		return false
+	}
+	for _, t := range q.GetTags() {
+		if !anyEqual(c.Tags, t) {
+			return false
+		}
+	}
+	for _, a := range q.GetAPIs() {
+		if !anyEqual(c.APIs, a) {
+			return false
+		}
+	}
+	switch {
+	case c.NumFrames < q.GetMinFrames(),
+		q.GetModifiedAfter() != 0 && c.Modified <= q.ModifiedAfter,
+		q.GetModifiedBefore() != 0 && c.Modified >= q.ModifiedBefore:
+		return false
+	}
+	return true
+}
+
+// anyContains returns true if any of list contains substr, ignoring case.
+func anyContains(list []string, substr string) bool {
+	substr = strings.ToLower(substr)
+	for _, s := range list {
+		if strings.Contains(strings.ToLower(s), substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyEqual returns true if any of list is equal to s, ignoring case.
+func anyEqual(list []string, s string) bool {
+	for _, e := range list {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
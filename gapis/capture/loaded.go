@@ -0,0 +1,116 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Loaded returns a description of each of the captures currently imported,
+// in the order they were imported.
+// Loaded does not decode any captures. Fields that require the decoded
+// capture are only populated for captures that are already resident.
+func Loaded(ctx context.Context) []*service.LoadedCapture {
+	capturesLock.RLock()
+	list := make([]imported, len(captures))
+	copy(list, captures)
+	capturesLock.RUnlock()
+
+	db := database.Get(ctx)
+	out := make([]*service.LoadedCapture, len(list))
+	for i, c := range list {
+		p := &path.Capture{ID: path.NewID(c.id)}
+		lc := &service.LoadedCapture{Path: p, Name: c.name}
+		if f, ok := c.src.(*File); ok {
+			lc.FilePath = f.Path
+		}
+		if c.src != nil {
+			if size, err := c.src.Size(); err == nil {
+				lc.Size = size
+			}
+		}
+		if db.IsResolved(ctx, c.id) {
+			if capture, err := ResolveFromID(ctx, c.id); err == nil {
+				lc.Resident = true
+				lc.Capture = capture.Service(ctx, &path.Capture{
+					ID:                  p.ID,
+					ExcludeMemoryRanges: true,
+				})
+				if gc, ok := capture.(*GraphicsCapture); ok {
+					lc.MemoryUsage = uint64(gc.Arena.Stats().NumBytesAllocated)
+				}
+			}
+		}
+		out[i] = lc
+	}
+	return out
+}
+
+var (
+	unloadHandlersLock sync.RWMutex
+	unloadHandlers     []func(ctx context.Context, p *path.Capture)
+)
+
+// OnUnload registers f to be called each time a capture is unloaded, so that
+// state held outside of the database for the capture can be discarded.
+func OnUnload(f func(ctx context.Context, p *path.Capture)) {
+	unloadHandlersLock.Lock()
+	defer unloadHandlersLock.Unlock()
+	unloadHandlers = append(unloadHandlers, f)
+}
+
+// Unload removes the capture from the list of imported captures, and discards
+// the decoded capture and the objects derived from it held by the database.
+// The capture's data is retained, so existing paths to the capture remain
+// valid, but will need to decode the capture again when next resolved.
+func Unload(ctx context.Context, p *path.Capture) error {
+	id := p.ID.ID()
+
+	capturesLock.Lock()
+	found := false
+	for i := 0; i < len(captures); i++ {
+		if captures[i].id == id {
+			captures = append(captures[:i], captures[i+1:]...)
+			found = true
+			i--
+		}
+	}
+	capturesLock.Unlock()
+
+	if !found {
+		return &service.ErrInvalidPath{
+			Reason: messages.ErrCaptureNotLoaded(),
+			Path:   p.Path(),
+		}
+	}
+
+	// The arena is not disposed here, as in-flight resolves and other users
+	// of the capture may still be holding on to its commands.
+	database.EvictDerived(ctx, id)
+	database.Evict(ctx, id)
+
+	unloadHandlersLock.RLock()
+	defer unloadHandlersLock.RUnlock()
+	for _, f := range unloadHandlers {
+		f(ctx, p)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/data/pack"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/pkg/errors"
+)
+
+// Metadata returns a summary of the capture.
+// Counting the frames of the capture requires mutating all of its commands, so
+// the summary is only built once.
+func (c *GraphicsCapture) Metadata(ctx context.Context) (*Metadata, error) {
+	c.metadataMutex.Lock()
+	defer c.metadataMutex.Unlock()
+	if c.metadata != nil {
+		return c.metadata, nil
+	}
+
+	out := &Metadata{
+		APIs:        make([]string, len(c.APIs)),
+		NumCommands: uint64(len(c.Commands)),
+	}
+	for i, a := range c.APIs {
+		out.APIs[i] = a.Name()
+	}
+
+	var first, last uint64
+	s := c.NewState(ctx)
+	err := api.ForeachCmd(ctx, c.Commands, true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		for _, e := range cmd.Extras().All() {
+			switch e := e.(type) {
+			case *api.TimeStamp:
+				if first == 0 {
+					first = e.Nanoseconds
+				}
+				last = e.Nanoseconds
+			case *FramebufferObservation:
+				out.Thumbnail = e
+			}
+		}
+		// Commands that fail to mutate are still considered for the frame
+		// count, as they are elsewhere.
+		cmd.Mutate(ctx, id, s, nil, nil)
+		if cmd.CmdFlags(ctx, id, s).IsEndOfFrame() {
+			out.NumFrames++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.Duration = last - first
+	for _, a := range c.APIs {
+		if n, ok := a.(api.ApplicationNamer); ok {
+			if out.Application = n.ApplicationName(ctx, s); out.Application != "" {
+				break
+			}
+		}
+	}
+	c.metadata = out
+	return out, nil
+}
+
+// errStopRead is returned by metadataReader to end the read of the capture
+// once the header and metadata have been read.
+var errStopRead = errors.New("stop read")
+
+// metadataReader is a pack.Events implementation that reads the Header and
+// Metadata from the start of a capture.
+type metadataReader struct {
+	header   *Header
+	metadata *Metadata
+}
+
+func (r *metadataReader) BeginGroup(ctx context.Context, msg proto.Message, id uint64) error {
+	return errStopRead
+}
+
+func (r *metadataReader) BeginChildGroup(ctx context.Context, msg proto.Message, id, parentID uint64) error {
+	return errStopRead
+}
+
+func (r *metadataReader) EndGroup(ctx context.Context, id uint64) error {
+	return errStopRead
+}
+
+func (r *metadataReader) Object(ctx context.Context, msg proto.Message) error {
+	switch msg := msg.(type) {
+	case *Header:
+		if msg.Version != CurrentCaptureVersion {
+			return ErrUnsupportedVersion{Version: msg.Version}
+		}
+		r.header = msg
+		return nil
+	case *Metadata:
+		r.metadata = msg
+	}
+	return errStopRead
+}
+
+func (r *metadataReader) ChildObject(ctx context.Context, msg proto.Message, parentID uint64) error {
+	return errStopRead
+}
+
+// ReadMetadata reads the header and metadata of the graphics capture held by
+// src, without decoding the rest of the capture. The returned Metadata is nil
+// if the capture does not contain any. An encrypted capture is decrypted with
+// the current key.
+func ReadMetadata(ctx context.Context, src Source) (*Header, *Metadata, error) {
+	in, close, err := open(ctx, src, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer close()
+
+	if !isGFXTraceFormat(in) {
+		return nil, nil, fmt.Errorf("Not a graphics capture")
+	}
+
+	r := &metadataReader{}
+	if err := pack.Read(ctx, in, r, false); err != nil && errors.Cause(err) != errStopRead {
+		return nil, nil, err
+	}
+	if r.header == nil {
+		return nil, nil, log.Err(ctx, nil, "Capture was missing header chunk")
+	}
+	return r.header, r.metadata, nil
+}
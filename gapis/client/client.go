@@ -27,6 +27,7 @@ import (
 	"github.com/google/gapid/gapis/api"
 	perfetto "github.com/google/gapid/gapis/perfetto/service"
 	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
 	"github.com/google/gapid/gapis/service/path"
 	"github.com/google/gapid/gapis/stringtable"
 	"github.com/pkg/errors"
@@ -74,6 +75,17 @@ func (c *client) GetServerInfo(ctx context.Context) (*service.ServerInfo, error)
 	return res.GetInfo(), nil
 }
 
+func (c *client) GetHealth(ctx context.Context) (*service.Health, error) {
+	res, err := c.client.GetHealth(ctx, &service.GetHealthRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetHealth(), nil
+}
+
 func (c *client) CheckForUpdates(ctx context.Context, includeDevReleases bool) (*service.Release, error) {
 	res, err := c.client.CheckForUpdates(ctx, &service.CheckForUpdatesRequest{
 		IncludeDevReleases: includeDevReleases,
@@ -116,6 +128,21 @@ func (c *client) Set(ctx context.Context, p *path.Any, v interface{}, r *path.Re
 	return res.GetPath(), nil
 }
 
+func (c *client) GetShaderDiagnostics(ctx context.Context, p *path.ResourceData, shader *api.Shader, r *path.ResolveConfig) ([]*service.ShaderDiagnostic, error) {
+	res, err := c.client.GetShaderDiagnostics(ctx, &service.GetShaderDiagnosticsRequest{
+		Resource: p,
+		Shader:   shader,
+		Config:   r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetDiagnostics().GetList(), nil
+}
+
 func (c *client) Delete(ctx context.Context, p *path.Any, r *path.ResolveConfig) (*path.Any, error) {
 	res, err := c.client.Delete(ctx, &service.DeleteRequest{
 		Path:   p,
@@ -144,6 +171,232 @@ func (c *client) Follow(ctx context.Context, p *path.Any, r *path.ResolveConfig)
 	return res.GetPath(), nil
 }
 
+func (c *client) GetCommandTreeStats(ctx context.Context, p *path.CommandTreeNode, r *path.ResolveConfig) (*service.CommandTreeStats, error) {
+	res, err := c.client.GetCommandTreeStats(ctx, &service.GetCommandTreeStatsRequest{
+		Node:   p,
+		Config: r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetStats(), nil
+}
+
+func (c *client) GetCommandTreeRow(ctx context.Context, req *service.GetCommandTreeRowRequest) (*service.CommandTreeRow, error) {
+	res, err := c.client.GetCommandTreeRow(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetRow(), nil
+}
+
+func (c *client) FindStateChange(ctx context.Context, p *path.Any, backwards bool, equals *box.Value, r *path.ResolveConfig) (*path.Command, error) {
+	res, err := c.client.FindStateChange(ctx, &service.FindStateChangeRequest{
+		Value:     p,
+		Backwards: backwards,
+		Equals:    equals,
+		Config:    r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetCommand(), nil
+}
+
+func (c *client) StepToBreakpoint(ctx context.Context, from *path.Command, breakpoints []*service.CommandBreakpoint, r *path.ResolveConfig) (*path.Command, error) {
+	res, err := c.client.StepToBreakpoint(ctx, &service.StepToBreakpointRequest{
+		From:        from,
+		Breakpoints: breakpoints,
+		Config:      r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetCommand(), nil
+}
+
+func (c *client) CompareState(ctx context.Context, paths []*path.Any, cmds []*path.Command, r *path.ResolveConfig) (*service.StateTable, error) {
+	res, err := c.client.CompareState(ctx, &service.CompareStateRequest{
+		Paths:    paths,
+		Commands: cmds,
+		Config:   r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetTable(), nil
+}
+
+func (c *client) ExportState(ctx context.Context, after *path.Command, maxMemorySize uint64, r *path.ResolveConfig) ([]byte, error) {
+	res, err := c.client.ExportState(ctx, &service.ExportStateRequest{
+		After:         after,
+		MaxMemorySize: maxMemorySize,
+		Config:        r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetState(), nil
+}
+
+func (c *client) DiffReports(ctx context.Context, before, after *path.Report, r *path.ResolveConfig) (*service.ReportDiff, error) {
+	res, err := c.client.DiffReports(ctx, &service.DiffReportsRequest{
+		Before: before,
+		After:  after,
+		Config: r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetDiff(), nil
+}
+
+type stateScrubHandler struct {
+	conn service.Gapid_ScrubStateClient
+}
+
+func (c *client) ScrubState(ctx context.Context, paths []*path.Any, r *path.ResolveConfig) (service.StateScrubHandler, error) {
+	conn, err := c.client.ScrubState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = conn.Send(&service.ScrubStateRequest{
+		Req: &service.ScrubStateRequest_Subscribe{
+			Subscribe: &service.ScrubStateSubscription{
+				Paths:  paths,
+				Config: r,
+			},
+		},
+	})
+	if err != nil {
+		conn.CloseSend()
+		return nil, err
+	}
+	return &stateScrubHandler{conn}, nil
+}
+
+func (h *stateScrubHandler) Move(ctx context.Context, cmd *path.Command) (*service.StateDelta, error) {
+	err := h.conn.Send(&service.ScrubStateRequest{
+		Req: &service.ScrubStateRequest_Move{Move: cmd},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := h.conn.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetDelta(), nil
+}
+
+func (h *stateScrubHandler) Dispose(ctx context.Context) {
+	h.conn.CloseSend()
+}
+
+func (c *client) GetStateSnippet(ctx context.Context, p *path.StateTreeNode, format service.SnippetFormat, depth int32, r *path.ResolveConfig) (string, error) {
+	res, err := c.client.GetStateSnippet(ctx, &service.GetStateSnippetRequest{
+		Node:   p,
+		Format: format,
+		Depth:  depth,
+		Config: r,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := res.GetError(); err != nil {
+		return "", err.Get()
+	}
+	return res.GetSnippet(), nil
+}
+
+func (c *client) GetCommandListWindow(ctx context.Context, p *path.Capture, offset uint64, count uint32, tree *path.ID, r *path.ResolveConfig) (*service.CommandListWindow, error) {
+	res, err := c.client.GetCommandListWindow(ctx, &service.GetCommandListWindowRequest{
+		Capture: p,
+		Offset:  offset,
+		Count:   count,
+		Tree:    tree,
+		Config:  r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetWindow(), nil
+}
+
+func (c *client) GetStateTreeChildren(ctx context.Context, p *path.StateTreeNode, offset uint64, count uint32, r *path.ResolveConfig) (*service.StateTreeChildren, error) {
+	res, err := c.client.GetStateTreeChildren(ctx, &service.GetStateTreeChildrenRequest{
+		Node:   p,
+		Offset: offset,
+		Count:  count,
+		Config: r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetChildren(), nil
+}
+
+func (c *client) FindStateTreeNodes(ctx context.Context, tree *path.ID, ids []string, r *path.ResolveConfig) (*service.StateTreeNodeMatches, error) {
+	res, err := c.client.FindStateTreeNodes(ctx, &service.FindStateTreeNodesRequest{
+		Tree:      tree,
+		StableIds: ids,
+		Config:    r,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetMatches(), nil
+}
+
+func (c *client) GetProfileTimeline(ctx context.Context, p *path.Capture, start, end uint64, buckets uint32) (*service.ProfileTimeline, error) {
+	res, err := c.client.GetProfileTimeline(ctx, &service.GetProfileTimelineRequest{
+		Capture: p,
+		Start:   start,
+		End:     end,
+		Buckets: buckets,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetTimeline(), nil
+}
+
 func (c *client) Profile(
 	ctx context.Context,
 	pprof, trace io.Writer,
@@ -311,9 +564,10 @@ func (c *client) ExportCapture(ctx context.Context, p *path.Capture) ([]byte, er
 	return res.GetData(), nil
 }
 
-func (c *client) LoadCapture(ctx context.Context, path string) (*path.Capture, error) {
+func (c *client) LoadCapture(ctx context.Context, path string, key []byte) (*path.Capture, error) {
 	res, err := c.client.LoadCapture(ctx, &service.LoadCaptureRequest{
 		Path: path,
+		Key:  key,
 	})
 	if err != nil {
 		return nil, err
@@ -324,10 +578,48 @@ func (c *client) LoadCapture(ctx context.Context, path string) (*path.Capture, e
 	return res.GetCapture(), nil
 }
 
-func (c *client) SaveCapture(ctx context.Context, capture *path.Capture, path string) error {
+func (c *client) GetCaptureMetadata(ctx context.Context, path string) (*service.CaptureMetadata, error) {
+	res, err := c.client.GetCaptureMetadata(ctx, &service.GetCaptureMetadataRequest{
+		Path: path,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetMetadata(), nil
+}
+
+func (c *client) GetLoadedCaptures(ctx context.Context) ([]*service.LoadedCapture, error) {
+	res, err := c.client.GetLoadedCaptures(ctx, &service.GetLoadedCapturesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetCaptures().List, nil
+}
+
+func (c *client) UnloadCapture(ctx context.Context, capture *path.Capture) error {
+	res, err := c.client.UnloadCapture(ctx, &service.UnloadCaptureRequest{
+		Capture: capture,
+	})
+	if err != nil {
+		return err
+	}
+	if err := res.GetError(); err != nil {
+		return err.Get()
+	}
+	return nil
+}
+
+func (c *client) SaveCapture(ctx context.Context, capture *path.Capture, path string, key []byte) error {
 	res, err := c.client.SaveCapture(ctx, &service.SaveCaptureRequest{
 		Capture: capture,
 		Path:    path,
+		Key:     key,
 	})
 	if err != nil {
 		return err
@@ -403,8 +695,8 @@ func (c *client) GetFramebufferAttachment(
 	return res.GetImage(), nil
 }
 
-func (c *client) GetLogStream(ctx context.Context, handler log.Handler) error {
-	stream, err := c.client.GetLogStream(ctx, &service.GetLogStreamRequest{})
+func (c *client) GetLogStream(ctx context.Context, req *service.GetLogStreamRequest, handler log.Handler) error {
+	stream, err := c.client.GetLogStream(ctx, req)
 	if err != nil {
 		return err
 	}
@@ -415,6 +707,22 @@ func (c *client) GetLogStream(ctx context.Context, handler log.Handler) error {
 	return event.Feed(ctx, event.AsHandler(ctx, h), grpcutil.ToProducer(stream))
 }
 
+func (c *client) GetRecentLogs(ctx context.Context, req *service.GetRecentLogsRequest) ([]*log.Message, error) {
+	res, err := c.client.GetRecentLogs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	list := res.GetMessages().List
+	out := make([]*log.Message, len(list))
+	for i, m := range list {
+		out[i] = m.Message()
+	}
+	return out, nil
+}
+
 func (c *client) Find(ctx context.Context, req *service.FindRequest, handler service.FindHandler) error {
 	stream, err := c.client.Find(ctx, req)
 	if err != nil {
@@ -424,6 +732,26 @@ func (c *client) Find(ctx context.Context, req *service.FindRequest, handler ser
 	return event.Feed(ctx, event.AsHandler(ctx, h), grpcutil.ToProducer(stream))
 }
 
+func (c *client) SetSelection(ctx context.Context, sel *service.Selection) error {
+	res, err := c.client.SetSelection(ctx, &service.SetSelectionRequest{Selection: sel})
+	if err != nil {
+		return err
+	}
+	if err := res.GetError(); err != nil {
+		return err.Get()
+	}
+	return nil
+}
+
+func (c *client) FollowSelection(ctx context.Context, handler service.SelectionHandler) error {
+	stream, err := c.client.FollowSelection(ctx, &service.FollowSelectionRequest{})
+	if err != nil {
+		return err
+	}
+	h := func(ctx context.Context, s *service.Selection) error { return handler(s) }
+	return event.Feed(ctx, event.AsHandler(ctx, h), grpcutil.ToProducer(stream))
+}
+
 func (c *client) ClientEvent(ctx context.Context, req *service.ClientEventRequest) error {
 	_, err := c.client.ClientEvent(ctx, req)
 	return err
@@ -518,6 +846,107 @@ func (c *client) DCECapture(ctx context.Context, capture *path.Capture, commands
 	return res.GetCapture(), nil
 }
 
+func (c *client) ScrubCapture(ctx context.Context, capture *path.Capture) (*path.Capture, error) {
+	res, err := c.client.ScrubCapture(ctx, &service.ScrubCaptureRequest{
+		Capture: capture,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetCapture(), nil
+}
+
+func (c *client) SearchCaptures(ctx context.Context, root string, query *service.CaptureQuery, refresh bool) ([]*service.IndexedCapture, error) {
+	res, err := c.client.SearchCaptures(ctx, &service.SearchCapturesRequest{
+		Root:    root,
+		Query:   query,
+		Refresh: refresh,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetCaptures().GetList(), nil
+}
+
+func (c *client) AnnotateCapture(ctx context.Context, path string, addTags, removeTags []string, setAnnotation bool, annotation string) error {
+	res, err := c.client.AnnotateCapture(ctx, &service.AnnotateCaptureRequest{
+		Path:          path,
+		AddTags:       addTags,
+		RemoveTags:    removeTags,
+		SetAnnotation: setAnnotation,
+		Annotation:    annotation,
+	})
+	if err != nil {
+		return err
+	}
+	if err := res.GetError(); err != nil {
+		return err.Get()
+	}
+	return nil
+}
+
+func (c *client) UploadCapture(ctx context.Context, req *service.UploadCaptureRequest) (*service.CaptureTransfer, error) {
+	res, err := c.client.UploadCapture(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetTransfer(), nil
+}
+
+func (c *client) DownloadCapture(ctx context.Context, capture *path.Capture, offset, maxSize uint64) (*service.CaptureChunk, error) {
+	res, err := c.client.DownloadCapture(ctx, &service.DownloadCaptureRequest{
+		Capture: capture,
+		Offset:  offset,
+		MaxSize: maxSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetChunk(), nil
+}
+
+func (c *client) PushCapture(ctx context.Context, capture *path.Capture, remote, remoteToken string) (*path.Capture, error) {
+	res, err := c.client.PushCapture(ctx, &service.PushCaptureRequest{
+		Capture:     capture,
+		Remote:      remote,
+		RemoteToken: remoteToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetCapture(), nil
+}
+
+func (c *client) PullCapture(ctx context.Context, remote, remoteToken string, capture *path.Capture) (*path.Capture, error) {
+	res, err := c.client.PullCapture(ctx, &service.PullCaptureRequest{
+		Remote:      remote,
+		RemoteToken: remoteToken,
+		Capture:     capture,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetCapture(), nil
+}
+
 func (c *client) UpdateSettings(ctx context.Context, req *service.UpdateSettingsRequest) error {
 	res, err := c.client.UpdateSettings(ctx, req)
 	if err != nil {
@@ -563,6 +992,22 @@ func (c *client) GetGraphVisualization(ctx context.Context, capture *path.Captur
 	return res.GetGraphVisualization(), nil
 }
 
+func (c *client) ExportDependencyGraph(ctx context.Context, capture *path.Capture, around *path.Command, radius uint32, format service.GraphFormat) ([]byte, error) {
+	res, err := c.client.ExportDependencyGraph(ctx, &service.ExportDependencyGraphRequest{
+		Capture: capture,
+		Around:  around,
+		Radius:  radius,
+		Format:  format,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := res.GetError(); err != nil {
+		return nil, err.Get()
+	}
+	return res.GetGraph(), nil
+}
+
 func (c *client) PerfettoQuery(ctx context.Context, capture *path.Capture, query string) (*perfetto.QueryResult, error) {
 	res, err := c.client.PerfettoQuery(ctx, &service.PerfettoQueryRequest{
 		Capture: capture,
@@ -34,6 +34,7 @@ const (
 
 type Config struct {
 	Path  *file.Path
+	Host  string // The host of the GAPIS server when Port is not zero. Defaults to localhost.
 	Port  int
 	Args  []string
 	Token auth.Token
@@ -41,16 +42,15 @@ type Config struct {
 
 // Connect attempts to connect to a GAPIS process.
 // If port is zero, a new GAPIS server will be started, otherwise a connection
-// will be made to the specified port.
+// will be made to the specified host and port.
 func Connect(ctx context.Context, cfg Config) (Client, error) {
 	var err error
-	if cfg.Path == nil {
-		if cfg.Path, err = findGapis(ctx); err != nil {
-			return nil, err
-		}
-	}
-
 	if cfg.Port == 0 {
+		if cfg.Path == nil {
+			if cfg.Path, err = findGapis(ctx); err != nil {
+				return nil, err
+			}
+		}
 		cfg.Args = append(cfg.Args,
 			"--log-level", logLevel(ctx).String(),
 			"--log-style", log.Brief.String(),
@@ -69,7 +69,11 @@ func Connect(ctx context.Context, cfg Config) (Client, error) {
 		}
 	}
 
-	target := fmt.Sprintf("localhost:%d", cfg.Port)
+	host := cfg.Host
+	if host == "" {
+		host = "localhost"
+	}
+	target := fmt.Sprintf("%s:%d", host, cfg.Port)
 
 	conn, err := grpcutil.Dial(ctx, target,
 		grpc.WithInsecure(),
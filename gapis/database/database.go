@@ -31,6 +31,8 @@ type Database interface {
 	Store(context.Context, interface{}) (id.ID, error)
 	// Resolve attempts to resolve the final value associated with an id.
 	// It will traverse all Resolvable objects, blocking until they are ready.
+	// Errors that report themselves as transient, such as a replay timing
+	// out, are not kept and the next call to Resolve tries again.
 	Resolve(context.Context, id.ID) (interface{}, error)
 	// IsResolved returns true if the object is in the database,
 	// and has already been resolved, and false if either the object
@@ -38,6 +40,17 @@ type Database interface {
 	IsResolved(context.Context, id.ID) bool
 	// Contains returns true if the database has an entry for the specified id.
 	Contains(context.Context, id.ID) bool
+	// Evict discards the resolved object associated with the id, so that it
+	// is rebuilt from its stored data the next time it is resolved.
+	// Evict returns false if the id is not in the database, is still being
+	// resolved, or was stored without data it can be rebuilt from.
+	Evict(context.Context, id.ID) bool
+	// EvictDerived discards the resolved objects of the entries whose stored
+	// data refers to the id, such as the resolvables of paths to a capture, so
+	// that they are rebuilt the next time they are resolved. Entries that are
+	// still being resolved are not evicted.
+	// EvictDerived returns the number of entries evicted.
+	EvictDerived(context.Context, id.ID) int
 }
 
 // Store stores v to the database held by the context.
@@ -50,6 +63,18 @@ func Resolve(ctx context.Context, id id.ID) (interface{}, error) {
 	return Get(ctx).Resolve(ctx, id)
 }
 
+// Evict discards the resolved object for id in the database held by the
+// context.
+func Evict(ctx context.Context, id id.ID) bool {
+	return Get(ctx).Evict(ctx, id)
+}
+
+// EvictDerived discards the resolved objects derived from id in the database
+// held by the context.
+func EvictDerived(ctx context.Context, id id.ID) int {
+	return Get(ctx).EvictDerived(ctx, id)
+}
+
 // Build stores resolvable into d, and then resolves and returns the resolved
 // object.
 func Build(ctx context.Context, r Resolvable) (interface{}, error) {
@@ -15,6 +15,7 @@
 package database
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha1"
 	"fmt"
@@ -244,6 +245,11 @@ func (d *memory) resolveLocked(ctx context.Context, id id.ID) (interface{}, erro
 			d.mutex.Lock()
 			close(rs.finished)
 			rs.err, rs.finished = err, nil
+			if isTransient(err) && r.resolveState == rs {
+				// Don't hold on to the error, so that the next resolve tries
+				// again. Those waiting on this resolve still get the error.
+				r.resolveState = nil
+			}
 			d.mutex.Unlock()
 		})
 	}
@@ -300,6 +306,29 @@ func (d *memory) resolveLocked(ctx context.Context, id id.ID) (interface{}, erro
 	return r.object, nil // Done.
 }
 
+// isTransient returns true if err, or one of the errors it was caused by,
+// reports itself as transient, meaning that the same resolve may succeed if
+// attempted again later.
+func isTransient(err error) bool {
+	type transient interface {
+		GetTransient() bool
+	}
+	type causer interface {
+		Cause() error
+	}
+	for i := 0; i < 64 && err != nil; i++ {
+		if t, ok := err.(transient); ok && t.GetTransient() {
+			return true
+		}
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+	return false
+}
+
 // Implements Database
 func (d *memory) Contains(ctx context.Context, id id.ID) (res bool) {
 	d.mutex.Lock()
@@ -317,8 +346,44 @@ func (d *memory) IsResolved(ctx context.Context, id id.ID) (res bool) {
 		return false
 	}
 	rs := r.resolveState
+	if rs == nil {
+		return false
+	}
 	if rs.finished == nil {
 		return true
 	}
 	return false
 }
+
+// Implements Database
+func (d *memory) Evict(ctx context.Context, id id.ID) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	r, got := d.records[id]
+	if !got || r.data == nil {
+		return false
+	}
+	if rs := r.resolveState; rs != nil && rs.finished != nil {
+		return false // Still resolving.
+	}
+	r.object, r.resolveState = nil, nil
+	return true
+}
+
+// Implements Database
+func (d *memory) EvictDerived(ctx context.Context, id id.ID) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	count := 0
+	for _, r := range d.records {
+		if r.data == nil || r.ty == blob || r.ty == blobFunc || !bytes.Contains(r.data, id[:]) {
+			continue
+		}
+		if rs := r.resolveState; rs != nil && rs.finished != nil {
+			continue // Still resolving.
+		}
+		r.object, r.resolveState = nil, nil
+		count++
+	}
+	return count
+}
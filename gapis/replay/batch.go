@@ -16,23 +16,31 @@ package replay
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/google/gapid/core/app/analytics"
 	"github.com/google/gapid/core/app/benchmark"
 	"github.com/google/gapid/core/app/status"
 	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/os/device"
 	"github.com/google/gapid/core/os/device/bind"
 	"github.com/google/gapid/gapir"
+	gapirClient "github.com/google/gapid/gapir/client"
 	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/transform"
 	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/config"
 	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/messages"
 	"github.com/google/gapid/gapis/replay/builder"
 	"github.com/google/gapid/gapis/replay/scheduler"
 	"github.com/google/gapid/gapis/resolve/initialcmds"
+	"github.com/google/gapid/gapis/service"
 	"github.com/google/gapid/gapis/service/path"
+	"github.com/pkg/errors"
 )
 
 var (
@@ -60,14 +68,22 @@ func (m *manager) batch(ctx context.Context, r *status.Replay, e []scheduler.Exe
 
 	d := bind.GetRegistry(ctx).Device(batch.device)
 
-	requests := make([]RequestAndResult, len(e))
+	requests := make([]*pendingRequest, len(e))
 	for i, e := range e {
-		requests[i] = RequestAndResult{
-			Request: e.Task,
-			Result:  Result(e.Result),
+		requests[i] = &pendingRequest{
+			request:   e.Task,
+			result:    Result(e.Result),
+			cancelled: e.Cancelled,
+			done:      make(chan struct{}),
 		}
 	}
 
+	// Abandon the replay if all the requests still waiting on a result are
+	// cancelled or time out. Requests that already got a result keep it.
+	ctx, cancel := task.WithCancel(ctx)
+	defer cancel()
+	go cancelWhenAbandoned(ctx, cancel, requests)
+
 	err := func() error {
 		if d == nil {
 			return log.Errf(ctx, nil, "Unknown device %v", batch.device)
@@ -83,15 +99,45 @@ func (m *manager) batch(ctx context.Context, r *status.Replay, e []scheduler.Exe
 		}.Bind(ctx)
 		log.I(ctx, "Replay for %d requests", len(e))
 
-		return m.execute(ctx, d, r, batch.device, batch.capture, batch.config, batch.generator, batch.forceNonSplitReplay, requests)
+		for attempt := 1; ; attempt++ {
+			pending := undelivered(requests)
+			if attempt > 1 && len(pending) == 0 {
+				return nil
+			}
+			err := m.execute(ctx, d, r, batch.device, batch.capture, batch.config, batch.generator, batch.forceNonSplitReplay, batch.memoryLimit, pending)
+			if errors.Cause(err) != gapirClient.ErrConnectionLost {
+				return err
+			}
+			if attempt == maxReplayAttempts {
+				log.E(ctx, "Connection to replay device lost: %v", err)
+				return errReplayDeviceLost(attempt)
+			}
+
+			// The device may have dropped, as on an adb restart. Wait for it to
+			// come back and re-issue the requests that have no result yet.
+			log.W(ctx, "Connection to replay device lost, retrying (attempt %d of %d): %v", attempt, maxReplayAttempts, err)
+			r.Reconnect(ctx)
+			select {
+			case <-task.ShouldStop(ctx):
+				return task.StopReason(ctx)
+			case <-time.After(reconnectDelay):
+			}
+			if d = bind.GetRegistry(ctx).Device(batch.device); d == nil {
+				log.E(ctx, "Replay device %v did not reconnect", batch.device)
+				return errReplayDeviceLost(attempt)
+			}
+			r.Start(ctx)
+		}
 	}()
 
 	if err != nil {
-		analytics.SendEvent("replay", "batch", "failure",
-			analytics.TargetDevice(d.Instance().GetConfiguration()),
-		)
+		if d != nil {
+			analytics.SendEvent("replay", "batch", "failure",
+				analytics.TargetDevice(d.Instance().GetConfiguration()),
+			)
+		}
 		for _, e := range requests {
-			e.Result(nil, err)
+			e.deliver(nil, err)
 		}
 	} else {
 		analytics.SendEvent("replay", "batch", "success",
@@ -100,6 +146,72 @@ func (m *manager) batch(ctx context.Context, r *status.Replay, e []scheduler.Exe
 	}
 }
 
+// errReplayDeviceLost returns the error reported to the requests of a batch
+// when the connection to the replay device was lost on each of the attempts.
+func errReplayDeviceLost(attempts int) error {
+	return &service.ErrDataUnavailable{
+		Reason:    messages.ErrReplayDeviceLost(uint32(attempts)),
+		Transient: true,
+	}
+}
+
+// pendingRequest is a replay request of a batch that may be re-issued if the
+// connection to the replay device is lost.
+type pendingRequest struct {
+	request   Request
+	result    Result
+	cancelled task.Signal
+	done      chan struct{} // closed once the request got a result
+	mutex     sync.Mutex
+	delivered bool
+}
+
+// deliver passes the result to the request, unless it already got one.
+func (p *pendingRequest) deliver(val interface{}, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.delivered {
+		p.delivered = true
+		p.result(val, err)
+		close(p.done)
+	}
+}
+
+// cancelWhenAbandoned calls cancel once each of the requests of l has either
+// got a result or been cancelled, if at least one of them was cancelled.
+func cancelWhenAbandoned(ctx context.Context, cancel task.CancelFunc, l []*pendingRequest) {
+	abandoned := false
+	for _, p := range l {
+		select {
+		case <-p.done:
+		case <-p.cancelled:
+			select {
+			case <-p.done:
+			default:
+				abandoned = true
+			}
+		case <-task.ShouldStop(ctx):
+			return
+		}
+	}
+	if abandoned {
+		cancel()
+	}
+}
+
+// undelivered returns the requests of l that have not got a result yet.
+func undelivered(l []*pendingRequest) []RequestAndResult {
+	out := []RequestAndResult{}
+	for _, p := range l {
+		p.mutex.Lock()
+		if !p.delivered {
+			out = append(out, RequestAndResult{Request: p.request, Result: p.deliver})
+		}
+		p.mutex.Unlock()
+	}
+	return out
+}
+
 type InitialPayloadResult struct {
 	prerunID   string
 	cleanupID  string
@@ -233,6 +345,7 @@ func (m *manager) execute(
 	cfg Config,
 	generator Generator,
 	forceNonSplitReplay bool,
+	memoryLimit uint64,
 	requests []RequestAndResult) error {
 
 	capturePath := path.NewCapture(captureID)
@@ -246,11 +359,12 @@ func (m *manager) execute(
 
 	executeCounter.Increment()
 
+	// The connection to the replay device is shared between captures, so it
+	// is made without the capture bound to the context.
+	connectCtx := ctx
+
 	ctx = capture.Put(ctx, capturePath)
-	ctx = log.V{
-		"capture": captureID,
-		"device":  d.Instance().GetName(),
-	}.Bind(ctx)
+	ctx = log.V{"device": d.Instance().GetName()}.Bind(ctx)
 
 	intent := Intent{path.NewDevice(deviceID), capturePath}
 
@@ -269,7 +383,7 @@ func (m *manager) execute(
 	}
 	ctx = log.V{"replay target ABI": replayABI}.Bind(ctx)
 
-	conn, err := m.connect(ctx, d, replayABI)
+	conn, err := m.connect(connectCtx, d, replayABI)
 	if err != nil {
 		return log.Err(ctx, err, "Failed to connect to device")
 	}
@@ -359,6 +473,14 @@ func (m *manager) execute(
 	if err != nil {
 		return log.Err(ctx, err, "Failed to build replay payload")
 	}
+	handleNotification = handleFailures(ctx, b.OpcodeMap(), replayABI.MemoryLayout.GetEndian(), handleNotification)
+
+	if required := payloadMemory(payload); memoryLimit > 0 && required > memoryLimit {
+		log.W(ctx, "Replay requires %d bytes, exceeding the limit of %d bytes", required, memoryLimit)
+		return &service.ErrDataUnavailable{
+			Reason: messages.ErrReplayMemoryLimit(required, memoryLimit),
+		}
+	}
 
 	err = b.RegisterReplayStatusReader(ctx, r)
 	if err != nil {
@@ -385,6 +507,16 @@ func (m *manager) execute(
 	return err
 }
 
+// payloadMemory returns an estimate of the memory in bytes the replay device
+// requires to execute payload.
+func payloadMemory(payload gapir.Payload) uint64 {
+	size := uint64(payload.VolatileMemorySize) + uint64(len(payload.Constants))
+	for _, r := range payload.Resources {
+		size += uint64(r.Size)
+	}
+	return size
+}
+
 // adapter conforms to the the transformer.Writer interface, performing replay
 // writes on each command.
 type adapter struct {
@@ -397,6 +529,7 @@ func (w *adapter) State() *api.GlobalState {
 }
 
 func (w *adapter) MutateAndWrite(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+	w.builder.SetTransform(transform.Origin(ctx))
 	w.builder.BeginCommand(uint64(id), cmd.Thread())
 	err := cmd.Mutate(ctx, id, w.state, w.builder, nil)
 	if err == nil {
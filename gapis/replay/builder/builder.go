@@ -119,6 +119,9 @@ type Builder struct {
 	pendingLabel        uint64 // label passed to BeginCommand written
 	lastLabel           uint64 // label of last CommitCommand written
 	volatileSpace       uint64 // Amount of volatile space already used
+	transform           string // Transform of the commands passed to BeginCommand
+	origins             []cmdOrigin
+	opcodeMap           OpcodeMap
 
 	// Remappings is a map of a arbitrary keys to pointers. Typically, this is
 	// used as a map of observed values to values that are only known at replay
@@ -254,6 +257,13 @@ func (b *Builder) AllocateTemporaryMemory(size uint64) value.Pointer {
 	return value.TemporaryPointer(b.temp.alloc(size))
 }
 
+// SetTransform sets the name of the transform that emitted the commands
+// subsequently passed to BeginCommand. An empty name is used for commands that
+// are replayed as they were captured.
+func (b *Builder) SetTransform(name string) {
+	b.transform = name
+}
+
 // BeginCommand should be called before building any replay instructions.
 func (b *Builder) BeginCommand(cmdID, threadID uint64) {
 	if b.inCmd {
@@ -261,6 +271,10 @@ func (b *Builder) BeginCommand(cmdID, threadID uint64) {
 	}
 	b.inCmd = true
 	b.cmdStart = len(b.instructions)
+	b.origins = append(b.origins, cmdOrigin{
+		instruction: b.cmdStart,
+		origin:      Origin{Command: cmdID, Transform: b.transform},
+	})
 
 	cmdID &= 0x3ffffff // Labels have 26 bit values.
 	if b.lastLabel != cmdID {
@@ -446,6 +460,7 @@ func (b *Builder) RevertCommand(err error) {
 	// TODO: Revert calls to: AllocateMemory, Buffer, String, ReserveMemory, MapMemory, UnmapMemory, Write.
 	b.temp.reset()
 	b.stack = b.stack[:0]
+	b.origins = b.origins[:len(b.origins)-1]
 	if len(b.instructions) > 0 {
 		for i := len(b.instructions) - 1; i >= b.cmdStart; i-- {
 			switch b.instructions[i].(type) {
@@ -886,6 +901,8 @@ func (b *Builder) Build(ctx context.Context) (gapir.Payload, PostDataHandler, No
 
 	vml := b.layoutVolatileMemory(ctx, w)
 
+	origins := b.origins
+	b.opcodeMap = make(OpcodeMap, 0, len(origins))
 	for index, i := range b.instructions {
 		if (index%10000 == 9999) || (index == len(b.instructions)-1) {
 			status.UpdateProgress(ctx, uint64(index), uint64(len(b.instructions)))
@@ -893,6 +910,12 @@ func (b *Builder) Build(ctx context.Context) (gapir.Payload, PostDataHandler, No
 		if label, ok := i.(asm.Label); ok {
 			id = label.Value
 		}
+		for len(origins) > 0 && origins[0].instruction <= index {
+			o := origins[0].origin
+			o.Opcode = uint32(opcodes.Len() / 4)
+			b.opcodeMap.add(o)
+			origins = origins[1:]
+		}
 		if err := i.Encode(vml, w); err != nil {
 			err = fmt.Errorf("Encode %T failed for command with id %v: %v", i, id, err)
 			return gapir.Payload{}, nil, nil, nil, err
@@ -0,0 +1,73 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Origin describes the command that emitted a range of replay opcodes.
+type Origin struct {
+	// Opcode is the index of the first opcode emitted for the command.
+	Opcode uint32
+	// Command is the identifier of the command, as passed to BeginCommand.
+	Command uint64
+	// Transform is the name of the transform that emitted the command, or
+	// empty if the command is replayed as it was captured.
+	Transform string
+}
+
+func (o Origin) String() string {
+	if o.Transform == "" {
+		return fmt.Sprintf("command %v", o.Command)
+	}
+	return fmt.Sprintf("command %v emitted by %v", o.Command, o.Transform)
+}
+
+// cmdOrigin is the Origin of the command starting at a given instruction.
+type cmdOrigin struct {
+	instruction int
+	origin      Origin
+}
+
+// OpcodeMap maps the opcodes of a replay payload back to the commands that
+// emitted them. The origins are sorted by their first opcode.
+type OpcodeMap []Origin
+
+// add appends o to the map. An earlier origin starting at the same opcode,
+// which did not emit any opcodes, is replaced.
+func (m *OpcodeMap) add(o Origin) {
+	if n := len(*m); n > 0 && (*m)[n-1].Opcode == o.Opcode {
+		(*m)[n-1] = o
+		return
+	}
+	*m = append(*m, o)
+}
+
+// Lookup returns the origin of the opcode at the given index of the payload.
+func (m OpcodeMap) Lookup(opcode uint32) (Origin, bool) {
+	i := sort.Search(len(m), func(i int) bool { return m[i].Opcode > opcode })
+	if i == 0 {
+		return Origin{}, false
+	}
+	return m[i-1], true
+}
+
+// OpcodeMap returns the map of the opcodes of the payload returned by the last
+// call to Build back to the commands that emitted them.
+func (b *Builder) OpcodeMap() OpcodeMap {
+	return b.opcodeMap
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/replay/protocol"
+)
+
+func TestOpcodeMapLookup(t *testing.T) {
+	ctx := log.Testing(t)
+	m := OpcodeMap{}
+	m.add(Origin{Opcode: 2, Command: 10})
+	m.add(Origin{Opcode: 5, Command: 11})
+	m.add(Origin{Opcode: 5, Command: 12, Transform: "injector"})
+	m.add(Origin{Opcode: 9, Command: 13})
+
+	assert.For(ctx, "len").That(len(m)).Equals(3)
+	for _, test := range []struct {
+		opcode   uint32
+		found    bool
+		expected Origin
+	}{
+		{0, false, Origin{}},
+		{2, true, Origin{Opcode: 2, Command: 10}},
+		{4, true, Origin{Opcode: 2, Command: 10}},
+		{5, true, Origin{Opcode: 5, Command: 12, Transform: "injector"}},
+		{8, true, Origin{Opcode: 5, Command: 12, Transform: "injector"}},
+		{100, true, Origin{Opcode: 9, Command: 13}},
+	} {
+		o, found := m.Lookup(test.opcode)
+		assert.For(ctx, "found %v", test.opcode).That(found).Equals(test.found)
+		assert.For(ctx, "origin %v", test.opcode).That(o).Equals(test.expected)
+	}
+}
+
+func TestOpcodeMapBuild(t *testing.T) {
+	ctx := log.Testing(t)
+	b := New(device.Little32, nil)
+
+	b.BeginCommand(10, 0)
+	b.Call(FunctionInfo{0, 123, protocol.Type_Void, 0})
+	b.CommitCommand(ctx, false)
+
+	b.SetTransform("injector")
+	b.BeginCommand(20, 0)
+	b.Call(FunctionInfo{0, 124, protocol.Type_Void, 0})
+	b.CommitCommand(ctx, false)
+
+	b.BeginCommand(30, 0)
+	b.Call(FunctionInfo{0, 125, protocol.Type_Void, 0})
+	b.RevertCommand(nil)
+
+	_, _, _, _, err := b.Build(ctx)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+
+	m := b.OpcodeMap()
+	assert.For(ctx, "len").That(len(m)).Equals(2)
+	assert.For(ctx, "command").That(m[0].Command).Equals(uint64(10))
+	assert.For(ctx, "transform").That(m[0].Transform).Equals("")
+	assert.For(ctx, "command").That(m[1].Command).Equals(uint64(20))
+	assert.For(ctx, "transform").That(m[1].Transform).Equals("injector")
+	assert.For(ctx, "order").That(m[1].Opcode > m[0].Opcode).Equals(true)
+}
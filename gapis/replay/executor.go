@@ -19,6 +19,7 @@ import (
 
 	"github.com/google/gapid/core/app/status"
 	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/os/device"
 	"github.com/google/gapid/gapir"
@@ -69,7 +70,7 @@ func Execute(
 		fenceReadyCallback: fenceReadyCallback,
 		memoryLayout:       memoryLayout,
 		OS:                 os,
-		finished:           make(chan error),
+		finished:           make(chan error, 1),
 	}.execute(ctx, m.(*manager), conn)
 }
 
@@ -89,9 +90,17 @@ func (e executor) execute(ctx context.Context, m *manager, conn *gapirClient.Con
 	log.I(ctx, "Beginning replay %v", plid)
 	// Start replay with id
 	m.BeginReplay(ctx, conn, plid.String(), e.dependent)
-	// Wait for finished
-	err = <-e.finished
-	return err
+	// Wait for finished, or abandon the replay if the requests have been
+	// cancelled or timed out. The connection is dropped so that the replay
+	// device is freed up for other requests.
+	select {
+	case err = <-e.finished:
+		return err
+	case <-task.ShouldStop(ctx):
+		log.W(ctx, "Abandoning replay %v: %v", plid, task.StopReason(ctx))
+		m.Disconnect(ctx, conn)
+		return task.StopReason(ctx)
+	}
 }
 
 func (e executor) HandleFinished(ctx context.Context, err error) error {
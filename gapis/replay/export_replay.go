@@ -66,10 +66,7 @@ func (m *exportManager) Export(ctx context.Context, waitRequests int) (*gapir.Pa
 	}
 
 	ctx = capture.Put(ctx, capturePath)
-	ctx = log.V{
-		"capture": m.key.capture,
-		"device":  d.Instance().GetName(),
-	}.Bind(ctx)
+	ctx = log.V{"device": d.Instance().GetName()}.Bind(ctx)
 
 	intent := Intent{path.NewDevice(m.key.device), capturePath}
 
@@ -0,0 +1,67 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapir"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/replay/builder"
+)
+
+// interpreterAPIIndex is the API index used by GAPIR to report the errors of
+// the replay interpreter itself. It needs to be kept in sync with
+// Interpreter::GLOBAL_INDEX defined in `gapir/cc/interpreter.h`.
+const interpreterAPIIndex = 0
+
+// resolveFailure returns a description of the command, and of the transform
+// that emitted it, at which the replay interpreter stopped as reported by n.
+// It returns false if n does not report an interpreter error.
+func resolveFailure(m builder.OpcodeMap, n *gapir.Notification, byteOrder device.Endian) (string, bool) {
+	e := n.GetErrorMsg()
+	if e == nil || e.GetApiIndex() != interpreterAPIIndex || len(e.GetData()) < 4 {
+		return "", false
+	}
+	opcode := endian.Reader(bytes.NewReader(e.GetData()), byteOrder).Uint32()
+	o, ok := m.Lookup(opcode)
+	switch {
+	case !ok:
+		return fmt.Sprintf("Replay failed at opcode %d, before the first command", opcode), true
+	case api.CmdID(o.Command) == api.CmdNoID && o.Transform != "":
+		return fmt.Sprintf("Replay failed at opcode %d of a command emitted by %v", opcode, o.Transform), true
+	case api.CmdID(o.Command) == api.CmdNoID:
+		return fmt.Sprintf("Replay failed at opcode %d of a command with no identifier", opcode), true
+	default:
+		return fmt.Sprintf("Replay failed at opcode %d of command %v", opcode, api.CmdID(o.Command)), true
+	}
+}
+
+// handleFailures returns a NotificationHandler that logs the command at which
+// the replay interpreter stopped, if it does, before passing the notification
+// on to h.
+func handleFailures(ctx context.Context, m builder.OpcodeMap, byteOrder device.Endian, h builder.NotificationHandler) builder.NotificationHandler {
+	return func(n *gapir.Notification) {
+		if msg, ok := resolveFailure(m, n, byteOrder); ok {
+			log.E(ctx, "%v: %v", msg, n.GetErrorMsg().GetMsg())
+		}
+		h(n)
+	}
+}
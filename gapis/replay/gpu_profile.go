@@ -107,6 +107,8 @@ func GpuProfile(ctx context.Context, capturePath *path.Capture, device *path.Dev
 					continue
 				}
 				log.I(ctx, "Replay profiling finished.")
+				data.QueueOverlap = QueueOverlap(data.Slices)
+				StoreProfile(capturePath, data)
 				return data, nil
 			}
 		}
@@ -22,6 +22,7 @@ import (
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
 )
 
 // Support is the optional interface implemented by APIs that can describe
@@ -57,6 +58,7 @@ type QueryTimestamps interface {
 		intent Intent,
 		mgr Manager,
 		loopCount int32,
+		subCommands bool,
 		handler service.TimeStampsHandler,
 		hints *service.UsageHints) error
 }
@@ -79,6 +81,72 @@ type QueryFramebufferAttachment interface {
 		hints *service.UsageHints) (*image.Data, error)
 }
 
+// TextureUsageDraw is a draw call measured by QueryTextureUsage, and the color
+// attachment it writes.
+type TextureUsageDraw struct {
+	Command          []uint64
+	Width, Height    uint32
+	FramebufferIndex uint32
+}
+
+// QueryTextureUsage is the interface implemented by types that can measure how
+// much of the detail of the textures sampled by draw calls contributes to the
+// color attachments they write, by replaying with the finest mip levels of
+// each texture dropped.
+type QueryTextureUsage interface {
+	QueryTextureUsage(
+		ctx context.Context,
+		intent Intent,
+		mgr Manager,
+		draws []TextureUsageDraw,
+		maxTextures uint32,
+		config *path.ResolveConfig,
+		hints *service.UsageHints) (*service.TextureUsage, error)
+}
+
+// QueryDepthTestCost is the interface implemented by types that can count the
+// samples rasterized by each draw call of the commands in the range
+// [begin, end) of a capture, and the samples that pass the depth test.
+// Only the command, samples and passed_samples fields of the returned draws
+// are populated.
+type QueryDepthTestCost interface {
+	QueryDepthTestCost(
+		ctx context.Context,
+		intent Intent,
+		mgr Manager,
+		begin, end api.CmdID,
+		hints *service.UsageHints) (*service.DepthTestCost, error)
+}
+
+// QueryPipelineStatistics is the interface implemented by types that can
+// count the primitives, clipped primitives and shader invocations of each
+// draw call of the commands in the range [begin, end) of a capture.
+// Only the command and count fields of the returned draws are populated.
+type QueryPipelineStatistics interface {
+	QueryPipelineStatistics(
+		ctx context.Context,
+		intent Intent,
+		mgr Manager,
+		begin, end api.CmdID,
+		hints *service.UsageHints) (*service.PipelineStatistics, error)
+}
+
+// QueryShaderInputs is the interface implemented by types that can capture
+// the inputs of a single shader invocation of the draw call at draw. If pixel
+// is not nil, the inputs of the fragment shader invocation writing to pixel
+// are captured, otherwise the inputs of the vertex shader invocation of the
+// vertex with index vertex are captured.
+type QueryShaderInputs interface {
+	QueryShaderInputs(
+		ctx context.Context,
+		intent Intent,
+		mgr Manager,
+		draw api.CmdID,
+		vertex uint64,
+		pixel *path.ShaderInputs_Position,
+		hints *service.UsageHints) (*service.ShaderInputs, error)
+}
+
 // Profiler is the interface implemented by replays that can be performed
 // in a profiling mode while capturing profiling data.
 type Profiler interface {
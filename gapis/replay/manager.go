@@ -20,13 +20,16 @@ import (
 	"time"
 
 	"github.com/google/gapid/core/app/status"
+	"github.com/google/gapid/core/context/keys"
 	"github.com/google/gapid/core/data/id"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/os/device"
 	"github.com/google/gapid/core/os/device/bind"
 	gapir "github.com/google/gapid/gapir/client"
+	"github.com/google/gapid/gapis/messages"
 	"github.com/google/gapid/gapis/replay/scheduler"
 	"github.com/google/gapid/gapis/service"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -36,6 +39,13 @@ const (
 	highPriorty          = 3
 	backgroundBatchDelay = time.Millisecond * 500
 	defaultBatchDelay    = time.Millisecond * 100
+
+	// maxReplayAttempts is the number of times a batch is replayed when the
+	// connection to the replay device is lost.
+	maxReplayAttempts = 3
+	// reconnectDelay is the time to wait for the replay device to come back
+	// before replaying a batch again.
+	reconnectDelay = time.Second * 2
 )
 
 // Manager executes replay requests.
@@ -69,6 +79,7 @@ type batchKey struct {
 	config              Config
 	generator           Generator
 	forceNonSplitReplay bool
+	memoryLimit         uint64
 }
 
 // New returns a new Manager instance using the database db.
@@ -133,8 +144,26 @@ func (m *manager) Replay(
 			b.Priority = lowestPriority
 			b.Precondition = backgroundBatchDelay
 		}
+		if hints.MemoryLimit > 0 {
+			key := b.Key.(batchKey)
+			key.memoryLimit = hints.MemoryLimit
+			b.Key = key
+		}
+		if hints.TimeoutMs > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(hints.TimeoutMs)*time.Millisecond)
+			defer cancel()
+		}
+	}
+
+	val, err = s.Schedule(ctx, req, b)
+	if hints.GetTimeoutMs() > 0 && errors.Cause(err) == context.DeadlineExceeded {
+		return nil, &service.ErrDataUnavailable{
+			Reason:    messages.ErrReplayTimeout(hints.TimeoutMs),
+			Transient: true,
+		}
 	}
-	return s.Schedule(ctx, req, b)
+	return val, err
 }
 
 func (m *manager) scheduler(ctx context.Context, deviceID id.ID) (*scheduler.Scheduler, error) {
@@ -164,7 +193,8 @@ func (m *manager) destroyScheduler(ctx context.Context, device bind.Device) {
 }
 
 func (m *manager) connect(ctx context.Context, device bind.Device, replayABI *device.ABI) (*gapir.ConnectionKey, error) {
-	return m.gapir.Connect(ctx, device, replayABI)
+	// The connection outlives the batch that opens it, which may be abandoned.
+	return m.gapir.Connect(keys.Clone(context.Background(), ctx), device, replayABI)
 }
 
 func (m *manager) BeginReplay(ctx context.Context, conn *gapir.ConnectionKey, payload string, dependent string) error {
@@ -175,6 +205,10 @@ func (m *manager) SetReplayExecutor(ctx context.Context, conn *gapir.ConnectionK
 	return m.gapir.SetReplayExecutor(ctx, conn, executor)
 }
 
+func (m *manager) Disconnect(ctx context.Context, conn *gapir.ConnectionKey) {
+	m.gapir.Disconnect(ctx, conn)
+}
+
 func (m *manager) PrewarmReplay(ctx context.Context, conn *gapir.ConnectionKey, payload string, cleanup string) error {
 	return m.gapir.PrewarmReplay(ctx, conn, payload, cleanup)
 }
@@ -0,0 +1,58 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+var (
+	profilesLock sync.RWMutex
+	profiles     = map[id.ID]*service.ProfilingData{}
+)
+
+func init() {
+	capture.OnUnload(forgetProfile)
+}
+
+// LatestProfile returns the profiling data of the most recent successful
+// GpuProfile of the capture c, or nil if the capture has not been profiled.
+func LatestProfile(c *path.Capture) *service.ProfilingData {
+	profilesLock.RLock()
+	defer profilesLock.RUnlock()
+	return profiles[c.ID.ID()]
+}
+
+// StoreProfile records data as the profiling data of the most recent
+// successful GpuProfile of the capture c. It is called by GpuProfile, and by
+// servers that profile c on another server.
+func StoreProfile(c *path.Capture, data *service.ProfilingData) {
+	profilesLock.Lock()
+	defer profilesLock.Unlock()
+	profiles[c.ID.ID()] = data
+}
+
+// forgetProfile discards the profiling data of the unloaded capture c.
+func forgetProfile(ctx context.Context, c *path.Capture) {
+	profilesLock.Lock()
+	defer profilesLock.Unlock()
+	delete(profiles, c.ID.ID())
+}
@@ -0,0 +1,178 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/gapid/gapis/service"
+)
+
+// serializationSlackNs is the longest time, in ns, between the end of the work
+// of one queue and the start of the work of another idle queue, for the idle
+// time to be considered as waiting on the first queue.
+const serializationSlackNs = 50000
+
+// interval is a range of time, in ns, from start up to end.
+type interval struct{ start, end uint64 }
+
+// mergeIntervals returns the union of the intervals, sorted by start.
+func mergeIntervals(l []interval) []interval {
+	sort.Slice(l, func(i, j int) bool { return l[i].start < l[j].start })
+	out := []interval{}
+	for _, i := range l {
+		if n := len(out); n > 0 && i.start <= out[n-1].end {
+			if i.end > out[n-1].end {
+				out[n-1].end = i.end
+			}
+			continue
+		}
+		out = append(out, i)
+	}
+	return out
+}
+
+// intersection returns the total time covered by both a and b, which must be
+// sorted and merged.
+func intersection(a, b []interval) uint64 {
+	total := uint64(0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start, end := a[i].start, a[i].end
+		if b[j].start > start {
+			start = b[j].start
+		}
+		if b[j].end < end {
+			end = b[j].end
+		}
+		if end > start {
+			total += end - start
+		}
+		if a[i].end < b[j].end {
+			i++
+		} else {
+			j++
+		}
+	}
+	return total
+}
+
+// sliceFrame returns the identifier of the frame of the slice, or 0 if the
+// profiler did not report one.
+func sliceFrame(s *service.ProfilingData_GpuSlices_Slice) uint64 {
+	for _, e := range s.Extras {
+		if e.Name == "frame_id" {
+			return e.GetIntValue()
+		}
+	}
+	return 0
+}
+
+// QueueOverlap returns the overlap, idle gaps and serialization of the work
+// of the GPU queues of each frame of the profiling data. Each track of the
+// slices is considered a queue, and tracks with names containing "compute" are
+// considered compute queues.
+func QueueOverlap(data *service.ProfilingData_GpuSlices) *service.QueueOverlap {
+	tracks := map[int32]*service.ProfilingData_GpuSlices_Track{}
+	for _, t := range data.GetTracks() {
+		tracks[t.Id] = t
+	}
+
+	// Top-level slices of each track of each frame.
+	frames := map[uint64]map[int32][]interval{}
+	for _, s := range data.GetSlices() {
+		if s.Depth != 0 {
+			continue
+		}
+		f := sliceFrame(s)
+		if frames[f] == nil {
+			frames[f] = map[int32][]interval{}
+		}
+		frames[f][s.TrackId] = append(frames[f][s.TrackId], interval{s.Ts, s.Ts + s.Dur})
+	}
+
+	out := &service.QueueOverlap{Frames: []*service.FrameQueueOverlap{}}
+	for f, queues := range frames {
+		frame := &service.FrameQueueOverlap{Frame: f, Queues: []*service.QueueActivity{}}
+		ids := make([]int32, 0, len(queues))
+		for id, l := range queues {
+			queues[id] = mergeIntervals(l)
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		first := true
+		for _, l := range queues {
+			if first || l[0].start < frame.Start {
+				frame.Start = l[0].start
+			}
+			if end := l[len(l)-1].end; end > frame.End {
+				frame.End = end
+			}
+			first = false
+		}
+
+		var graphics, compute []interval
+		for _, id := range ids {
+			l := queues[id]
+			q := &service.QueueActivity{TrackId: id}
+			if t, ok := tracks[id]; ok {
+				q.Name = t.Name
+				q.Compute = strings.Contains(strings.ToLower(t.Name), "compute")
+			}
+			for i, busy := range l {
+				q.Busy += busy.end - busy.start
+				if i == 0 {
+					continue
+				}
+				gap := interval{l[i-1].end, busy.start}
+				q.Gaps++
+				if d := gap.end - gap.start; d > q.LongestGap {
+					q.LongestGap = d
+				}
+				if waitsOnOtherQueue(queues, id, gap) {
+					frame.Serialized += gap.end - gap.start
+				}
+			}
+			q.Idle = (frame.End - frame.Start) - q.Busy
+			if q.Compute {
+				compute = append(compute, l...)
+			} else {
+				graphics = append(graphics, l...)
+			}
+			frame.Queues = append(frame.Queues, q)
+		}
+		frame.GraphicsComputeOverlap = intersection(mergeIntervals(graphics), mergeIntervals(compute))
+		out.Frames = append(out.Frames, frame)
+	}
+	sort.Slice(out.Frames, func(i, j int) bool { return out.Frames[i].Frame < out.Frames[j].Frame })
+	return out
+}
+
+// waitsOnOtherQueue returns true if the idle gap of the queue id ends shortly
+// after the end of the work of another queue during the gap.
+func waitsOnOtherQueue(queues map[int32][]interval, id int32, gap interval) bool {
+	for other, l := range queues {
+		if other == id {
+			continue
+		}
+		for _, i := range l {
+			if i.end > gap.start && i.end <= gap.end && gap.end-i.end <= serializationSlackNs {
+				return true
+			}
+		}
+	}
+	return false
+}
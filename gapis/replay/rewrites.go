@@ -0,0 +1,52 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/context/keys"
+)
+
+type contextRewritesKeyTy string
+
+const contextRewritesKey = contextRewritesKeyTy("replayRewrites")
+
+// LoadRewrites loads the text format Rewrites held by the file at path.
+func LoadRewrites(path string) (*Rewrites, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	out := &Rewrites{}
+	if err := proto.UnmarshalText(string(data), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PutRewrites attaches the rewrites applied to all replays to a Context.
+func PutRewrites(ctx context.Context, r *Rewrites) context.Context {
+	return keys.WithValue(ctx, contextRewritesKey, r)
+}
+
+// GetRewrites returns the rewrites applied to all replays attached to a
+// Context by PutRewrites, or nil if there are none.
+func GetRewrites(ctx context.Context) *Rewrites {
+	r, _ := ctx.Value(contextRewritesKey).(*Rewrites)
+	return r
+}
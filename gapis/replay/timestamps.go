@@ -24,7 +24,8 @@ import (
 )
 
 // GetTimestamps replays the trace and return the start and end timestamps for each commandbuffers
-func GetTimestamps(ctx context.Context, capturePath *path.Capture, device *path.Device, loopCount int32, handler service.TimeStampsHandler) error {
+// If subCommands is true, the commands recorded into the command buffers are also timed.
+func GetTimestamps(ctx context.Context, capturePath *path.Capture, device *path.Device, loopCount int32, subCommands bool, handler service.TimeStampsHandler) error {
 	c, err := capture.ResolveGraphicsFromPath(ctx, capturePath)
 	if err != nil {
 		return err
@@ -40,7 +41,7 @@ func GetTimestamps(ctx context.Context, capturePath *path.Capture, device *path.
 		hints := &service.UsageHints{Background: true}
 		for _, a := range c.APIs {
 			if qi, ok := a.(QueryTimestamps); ok {
-				err = qi.QueryTimestamps(ctx, intent, mgr, loopCount, handler, hints)
+				err = qi.QueryTimestamps(ctx, intent, mgr, loopCount, subCommands, handler, hints)
 				if err != nil {
 					log.E(ctx, "Query timestamps failed.")
 					continue
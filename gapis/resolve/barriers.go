@@ -0,0 +1,64 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Barriers resolves and returns the pipeline barriers and events recorded in
+// the frame of p, in execution order. Only commands of APIs implementing
+// api.BarrierLister are listed.
+func Barriers(ctx context.Context, p *path.Barriers, r *path.ResolveConfig) (*api.Barriers, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := frameCommandRange(ctx, p.Capture, p.Frame, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.Barriers{Barriers: []*api.Barrier{}}
+	err = api.ForeachCmd(ctx, cmds[:end], true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if l, ok := cmd.API().(api.BarrierLister); ok && uint64(id) >= start {
+			if err := l.ListBarriers(ctx, id, cmd, st, out); err != nil {
+				return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+			}
+		} else if err := cmd.Mutate(ctx, id, st, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range out.Barriers {
+		b.Command.Capture = p.Capture
+	}
+	return out, nil
+}
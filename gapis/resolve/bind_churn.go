@@ -0,0 +1,79 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// BindChurn resolves and returns the pipeline binds, descriptor set binds and
+// push constant updates of the render passes of the capture of p, ordered by
+// the number of binds that could be avoided, worst first.
+// Only commands of APIs implementing api.BindChurnAnalyzer are analyzed.
+// If p.Frames is set, only the render passes begun in those frames are
+// returned.
+func BindChurn(ctx context.Context, p *path.BindChurn, r *path.ResolveConfig) (*api.BindChurn, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := framesCommandRange(ctx, p.Capture, p.Frames, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.BindChurn{RenderPasses: []*api.RenderPassChurn{}}
+	err = api.ForeachCmd(ctx, cmds[:end], true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if a, ok := cmd.API().(api.BindChurnAnalyzer); ok {
+			if err := a.AnalyzeBindChurn(ctx, id, cmd, st, out); err != nil {
+				return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+			}
+		} else if err := cmd.Mutate(ctx, id, st, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	passes := out.RenderPasses[:0]
+	for _, rp := range out.RenderPasses {
+		if rp.Begin.Indices[0] >= start {
+			rp.Begin.Capture = p.Capture
+			passes = append(passes, rp)
+		}
+	}
+	out.RenderPasses = passes
+	sort.SliceStable(out.RenderPasses, func(i, j int) bool {
+		return out.RenderPasses[i].AvoidableBinds() > out.RenderPasses[j].AvoidableBinds()
+	})
+	if max := int(p.MaxRenderPasses); max > 0 && len(out.RenderPasses) > max {
+		out.RenderPasses = out.RenderPasses[:max]
+	}
+	return out, nil
+}
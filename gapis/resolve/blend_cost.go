@@ -0,0 +1,188 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// BlendCost resolves and returns the draw calls of the frame of p that blend
+// with their color attachment, ranked by the number of pixels they change.
+// The pixels changed by a draw call are found by comparing the replayed color
+// attachment after it with the attachment after the previous draw call, when
+// both draw to an attachment of the same size and format.
+// Only commands of APIs implementing api.BlendAnalyzer are analyzed.
+func BlendCost(ctx context.Context, p *path.BlendCost, r *path.ResolveConfig) (*api.BlendCost, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := frameCommandRange(ctx, p.Capture, p.Frame, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type draw struct {
+		cmd     *path.Command
+		blended bool
+	}
+	draws := []draw{}
+	err = api.ForeachCmd(ctx, cmds[:end], true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if a, ok := cmd.API().(api.BlendAnalyzer); ok && uint64(id) >= start {
+			err := a.AnalyzeBlending(ctx, id, cmd, st, func(indices []uint64, blended bool) {
+				draws = append(draws, draw{p.Capture.Command(indices[0], indices[1:]...), blended})
+			})
+			if err != nil {
+				return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+			}
+		} else if err := cmd.Mutate(ctx, id, st, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(draws) == 0 {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+
+	changes, err := FramebufferChanges(ctx, p.Capture, r)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*FramebufferAttachmentInfo, len(draws))
+	for i, d := range draws {
+		if info, err := changes.Get(ctx, d.cmd, api.FramebufferAttachment_Color0); err == nil {
+			infos[i] = &info
+		}
+	}
+
+	// Read the attachments after each blended draw call and the draw call
+	// before it. The reads are made concurrently so that they can be batched
+	// into as few replays as possible.
+	images := make([][]byte, len(draws))
+	needed := make([]bool, len(draws))
+	for i, d := range draws {
+		if d.blended && i > 0 && infos[i] != nil && infos[i-1] != nil && infos[i].equal(*infos[i-1]) {
+			needed[i-1], needed[i] = true, true
+		}
+	}
+	var wg sync.WaitGroup
+	for i := range draws {
+		if !needed[i] {
+			continue
+		}
+		i := i
+		wg.Add(1)
+		crash.Go(func() {
+			defer wg.Done()
+			data, err := colorAttachmentPixels(ctx, draws[i].cmd, infos[i], r)
+			if err != nil {
+				log.W(ctx, "Could not read the color attachment after %v: %v", draws[i].cmd, err)
+				return
+			}
+			images[i] = data
+		})
+	}
+	wg.Wait()
+
+	out := &api.BlendCost{
+		Draws:        uint32(len(draws)),
+		BlendedDraws: []*api.BlendedDraw{},
+	}
+	if last := infos[len(infos)-1]; last != nil {
+		out.FramePixels = uint64(last.Width) * uint64(last.Height)
+	}
+	for i, d := range draws {
+		if !d.blended {
+			continue
+		}
+		bd := &api.BlendedDraw{Command: d.cmd}
+		if i > 0 && images[i] != nil && images[i-1] != nil {
+			bd.ChangedPixels = changedPixels(images[i-1], images[i])
+			bd.Measured = true
+			out.BlendedPixels += bd.ChangedPixels
+		}
+		out.BlendedDraws = append(out.BlendedDraws, bd)
+	}
+	if out.FramePixels > 0 {
+		out.TransparentOverdraw = float32(out.BlendedPixels) / float32(out.FramePixels)
+	}
+	sort.SliceStable(out.BlendedDraws, func(i, j int) bool {
+		return out.BlendedDraws[i].ChangedPixels > out.BlendedDraws[j].ChangedPixels
+	})
+	return out, nil
+}
+
+// colorAttachmentPixels returns the full size color attachment after the
+// command p, described by info, as RGBA_U8_NORM pixels.
+func colorAttachmentPixels(ctx context.Context, p *path.Command, info *FramebufferAttachmentInfo, r *path.ResolveConfig) ([]byte, error) {
+	imageInfoPath, err := FramebufferAttachment(ctx,
+		&service.ReplaySettings{Device: r.GetReplayDevice()},
+		p,
+		api.FramebufferAttachment_Color0,
+		&service.RenderSettings{
+			MaxWidth:  info.Width,
+			MaxHeight: info.Height,
+			DrawMode:  service.DrawMode_NORMAL,
+		},
+		&service.UsageHints{Background: true},
+		r,
+	)
+	if err != nil {
+		return nil, err
+	}
+	boxedImageInfo, err := Get(ctx, imageInfoPath.As(image.RGBA_U8_NORM).Path(), r)
+	if err != nil {
+		return nil, err
+	}
+	data, err := boxedImageInfo.(*image.Info).Data(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return data.Bytes, nil
+}
+
+// changedPixels returns the number of RGBA_U8_NORM pixels that differ between
+// a and b, which are expected to be of the same size.
+func changedPixels(a, b []byte) uint64 {
+	count := uint64(0)
+	for i := 0; i+4 <= len(a) && i+4 <= len(b); i += 4 {
+		if !bytes.Equal(a[i:i+4], b[i:i+4]) {
+			count++
+		}
+	}
+	return count
+}
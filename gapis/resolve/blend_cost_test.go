@@ -0,0 +1,36 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+)
+
+func TestChangedPixels(t *testing.T) {
+	ctx := log.Testing(t)
+	a := []byte{
+		0, 0, 0, 255, 10, 20, 30, 255,
+		0, 0, 0, 255, 10, 20, 30, 255,
+	}
+	b := []byte{
+		0, 0, 0, 255, 10, 20, 31, 255,
+		0, 0, 0, 254, 10, 20, 30, 255,
+	}
+	assert.For(ctx, "unchanged").That(changedPixels(a, a)).Equals(uint64(0))
+	assert.For(ctx, "changed").That(changedPixels(a, b)).Equals(uint64(2))
+}
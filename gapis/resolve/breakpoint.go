@@ -0,0 +1,178 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// breakpoint is the compiled form of a service.CommandBreakpoint.
+type breakpoint struct {
+	name   *regexp.Regexp
+	params []*service.ParameterCondition
+	state  []*service.StateCondition
+}
+
+// StepToBreakpoint returns the path to the first command after from that
+// satisfies any of the breakpoints.
+// The state is only mutated if one of the breakpoints has a state condition.
+func StepToBreakpoint(ctx context.Context, from *path.Command, breakpoints []*service.CommandBreakpoint, r *path.ResolveConfig) (*path.Command, error) {
+	ctx = SetupContext(ctx, from.Capture, r)
+
+	bps := make([]breakpoint, len(breakpoints))
+	needsState := false
+	for i, b := range breakpoints {
+		if b.CommandName != "" {
+			re, err := regexp.Compile(b.CommandName)
+			if err != nil {
+				return nil, log.Err(ctx, err, "Couldn't compile regular expression")
+			}
+			bps[i].name = re
+		}
+		for _, c := range b.State {
+			if c.Path == nil || c.Path.Node() == nil {
+				return nil, fmt.Errorf("Breakpoint state condition has no path")
+			}
+		}
+		bps[i].params, bps[i].state = b.Parameters, b.State
+		needsState = needsState || len(b.State) > 0
+	}
+
+	cmds, err := Cmds(ctx, from.Capture)
+	if err != nil {
+		return nil, err
+	}
+	start := api.CmdID(from.Indices[0])
+
+	var s *api.GlobalState
+	if needsState {
+		if s, err = capture.NewState(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var found *path.Command
+	err = api.ForeachCmd(ctx, cmds, true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if s != nil {
+			if err := cmd.Mutate(ctx, id, s, nil, nil); err != nil {
+				return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+			}
+		}
+		if id <= start {
+			return nil
+		}
+		after := from.Capture.Command(uint64(id))
+		for _, b := range bps {
+			if b.matches(ctx, after, cmd, s, r) {
+				found = after
+				return api.Break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoBreakpointHit()}
+	}
+	return found, nil
+}
+
+// matches returns true if the command cmd, and the state s after it, satisfy
+// all the conditions of the breakpoint.
+func (b breakpoint) matches(ctx context.Context, after *path.Command, cmd api.Cmd, s *api.GlobalState, r *path.ResolveConfig) bool {
+	if b.name != nil && !b.name.MatchString(cmd.CmdName()) {
+		return false
+	}
+	for _, c := range b.params {
+		var v *box.Value
+		if p := cmd.CmdParams().Find(c.Name); p != nil {
+			v = box.NewValue(p.Get())
+		}
+		if !conditionHolds(c.Op, v, c.Value) {
+			return false
+		}
+	}
+	for _, c := range b.state {
+		var v *box.Value
+		if obj, ok := stateObjectAfter(ctx, c.Path.Node(), after, cmd.API(), s, r); ok {
+			v = box.NewValue(obj)
+		}
+		if !conditionHolds(c.Op, v, c.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// stateObjectAfter returns the value of the state s at p, with the command of
+// p replaced with after, and true. If p is rooted at an API state, then the
+// state of the API a is used. false is returned if the value does not exist.
+func stateObjectAfter(ctx context.Context, p path.Node, after *path.Command, a api.API, s *api.GlobalState, r *path.ResolveConfig) (interface{}, bool) {
+	if st := findState(p); st != nil {
+		if a == nil {
+			return nil, false
+		}
+		apiState := s.APIs[a.ID()]
+		if apiState == nil {
+			return nil, false
+		}
+		root, err := apiState.Root(ctx, &path.State{After: after, Context: st.Context}, r)
+		if err != nil || root == nil {
+			return nil, false
+		}
+		root = path.Transform(root, func(n path.Node) path.Node {
+			if _, ok := n.(*path.State); ok {
+				return APIStateAfter(after, a.ID())
+			}
+			return n
+		})
+		p = replaceState(p, root)
+	}
+	nodes, g := stateNodes(p)
+	if g == nil {
+		return nil, false
+	}
+	return stateObject(ctx, s, nodes)
+}
+
+// conditionHolds returns true if the value v compares to expected as required
+// by op. Values that do not exist never satisfy a condition.
+func conditionHolds(op service.ConditionOp, v, expected *box.Value) bool {
+	if v == nil {
+		return false
+	}
+	switch op {
+	case service.ConditionOp_Equal:
+		return proto.Equal(v, expected)
+	case service.ConditionOp_NotEqual:
+		return !proto.Equal(v, expected)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestStepToBreakpoint(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	p := newPathTest(ctx)
+
+	param := func(name string, op service.ConditionOp, v interface{}) *service.ParameterCondition {
+		return &service.ParameterCondition{Name: name, Op: op, Value: box.NewValue(v)}
+	}
+
+	for _, test := range []struct {
+		name     string
+		from     *path.Command
+		bp       *service.CommandBreakpoint
+		expected *path.Command
+	}{
+		{
+			"name",
+			p.Command(0),
+			&service.CommandBreakpoint{CommandName: "^primeState$"},
+			p.Command(2),
+		}, {
+			"parameter",
+			p.Command(0),
+			&service.CommandBreakpoint{
+				Parameters: []*service.ParameterCondition{
+					param("Bool", service.ConditionOp_Equal, false),
+				},
+			},
+			p.Command(1),
+		}, {
+			"parameter not equal",
+			p.Command(0),
+			&service.CommandBreakpoint{
+				CommandName: "cmdTypeMix",
+				Parameters: []*service.ParameterCondition{
+					param("U8", service.ConditionOp_NotEqual, uint8(15)),
+				},
+			},
+			nil,
+		}, {
+			"state",
+			p.Command(0),
+			&service.CommandBreakpoint{
+				State: []*service.StateCondition{{
+					Path:  p.Command(0).StateAfter().Field("Str").Path(),
+					Op:    service.ConditionOp_Equal,
+					Value: box.NewValue("aaa"),
+				}},
+			},
+			p.Command(2),
+		}, {
+			"not after from",
+			p.Command(1),
+			&service.CommandBreakpoint{
+				Parameters: []*service.ParameterCondition{
+					param("U8", service.ConditionOp_Equal, uint8(10)),
+				},
+			},
+			nil,
+		},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		got, err := StepToBreakpoint(ctx, test.from, []*service.CommandBreakpoint{test.bp}, nil)
+		if test.expected == nil {
+			assert.For(ctx, "err").ThatError(err).Failed()
+			continue
+		}
+		if assert.For(ctx, "err").ThatError(err).Succeeded() {
+			assert.For(ctx, "got").That(got.Indices).DeepEquals(test.expected.Indices)
+		}
+	}
+}
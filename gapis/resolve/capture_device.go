@@ -0,0 +1,117 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// CaptureDevice resolves and returns the description of the device used to
+// make the capture referenced by p.
+func CaptureDevice(ctx context.Context, p *path.CaptureDevice, r *path.ResolveConfig) (*service.CaptureDevice, error) {
+	c, err := capture.ResolveGraphicsFromPath(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	d := c.Header.Device
+	out := &service.CaptureDevice{
+		Device:  d,
+		ABI:     c.Header.ABI,
+		GPU:     d.GetConfiguration().GetHardware().GetGPU(),
+		Drivers: d.GetConfiguration().GetDrivers(),
+	}
+
+	if p.ReplayDevice != nil {
+		replay, err := Device(ctx, p.ReplayDevice, r)
+		if err != nil {
+			return nil, err
+		}
+		out.Mismatches = deviceMismatches(d, replay)
+	}
+
+	return out, nil
+}
+
+// deviceMismatches returns the differences between the capture device c and
+// the replay device r that may cause the replay to behave differently to the
+// application at capture time.
+func deviceMismatches(c, r *device.Instance) []*service.DeviceMismatch {
+	out := []*service.DeviceMismatch{}
+	check := func(property, captureValue, replayValue string) {
+		if captureValue != replayValue {
+			out = append(out, &service.DeviceMismatch{
+				Property:     property,
+				CaptureValue: captureValue,
+				ReplayValue:  replayValue,
+			})
+		}
+	}
+	missing := func(property string, capture, replay []string) {
+		has := make(map[string]bool, len(replay))
+		for _, e := range replay {
+			has[e] = true
+		}
+		for _, e := range capture {
+			if !has[e] {
+				check(property, e, "")
+			}
+		}
+	}
+
+	cGPU := c.GetConfiguration().GetHardware().GetGPU()
+	rGPU := r.GetConfiguration().GetHardware().GetGPU()
+	check("GPU", cGPU.GetName(), rGPU.GetName())
+	check("GPU vendor", cGPU.GetVendor(), rGPU.GetVendor())
+
+	if cGL := c.GetConfiguration().GetDrivers().GetOpengl(); cGL != nil {
+		rGL := r.GetConfiguration().GetDrivers().GetOpengl()
+		check("OpenGL renderer", cGL.GetRenderer(), rGL.GetRenderer())
+		check("OpenGL version", cGL.GetVersion(), rGL.GetVersion())
+		missing("OpenGL extension", cGL.GetExtensions(), rGL.GetExtensions())
+	}
+
+	if cVk := c.GetConfiguration().GetDrivers().GetVulkan(); cVk != nil {
+		rVk := r.GetConfiguration().GetDrivers().GetVulkan()
+		if cDevs := cVk.GetPhysicalDevices(); len(cDevs) > 0 {
+			cDev := cDevs[0]
+			rDev := &device.VulkanPhysicalDevice{}
+			if rDevs := rVk.GetPhysicalDevices(); len(rDevs) > 0 {
+				rDev = rDevs[0]
+			}
+			check("Vulkan device", cDev.GetDeviceName(), rDev.GetDeviceName())
+			check("Vulkan driver version",
+				fmt.Sprint(cDev.GetDriverVersion()), fmt.Sprint(rDev.GetDriverVersion()))
+			check("Vulkan API version",
+				vkVersion(cDev.GetApiVersion()), vkVersion(rDev.GetApiVersion()))
+		}
+		missing("Vulkan extension",
+			cVk.GetIcdAndImplicitLayerExtensions(), rVk.GetIcdAndImplicitLayerExtensions())
+	}
+
+	return out
+}
+
+// vkVersion returns the Vulkan version v, encoded as described by the
+// Vulkan specification, as a string.
+func vkVersion(v uint32) string {
+	return fmt.Sprintf("%d.%d.%d", v>>22, (v>>12)&0x3ff, v&0xfff)
+}
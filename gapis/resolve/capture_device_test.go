@@ -0,0 +1,68 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/service"
+)
+
+func TestDeviceMismatches(t *testing.T) {
+	ctx := log.Testing(t)
+
+	dev := func(gpu string, exts ...string) *device.Instance {
+		return &device.Instance{Configuration: &device.Configuration{
+			Hardware: &device.Hardware{GPU: &device.GPU{Name: gpu}},
+			Drivers: &device.Drivers{
+				Opengl: &device.OpenGLDriver{Version: "3.0", Extensions: exts},
+			},
+		}}
+	}
+
+	for _, test := range []struct {
+		name     string
+		capture  *device.Instance
+		replay   *device.Instance
+		expected []*service.DeviceMismatch
+	}{
+		{
+			"same",
+			dev("Adreno", "GL_KHR_debug"),
+			dev("Adreno", "GL_KHR_debug", "GL_EXT_sRGB"),
+			[]*service.DeviceMismatch{},
+		}, {
+			"gpu",
+			dev("Adreno"),
+			dev("Mali"),
+			[]*service.DeviceMismatch{
+				{Property: "GPU", CaptureValue: "Adreno", ReplayValue: "Mali"},
+			},
+		}, {
+			"extension",
+			dev("Adreno", "GL_KHR_debug", "GL_EXT_sRGB"),
+			dev("Adreno", "GL_KHR_debug"),
+			[]*service.DeviceMismatch{
+				{Property: "OpenGL extension", CaptureValue: "GL_EXT_sRGB"},
+			},
+		},
+	} {
+		got := deviceMismatches(test.capture, test.replay)
+		assert.For(ctx, test.name).That(got).DeepEquals(test.expected)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// CaptureView resolves and returns the description of the view of the frames
+// of the capture referenced by p. The view's command tree, report and stats
+// are resolved from the paths returned by the path.CaptureView helpers, which
+// restrict the commands of the whole capture to those of the view.
+func CaptureView(ctx context.Context, p *path.CaptureView, r *path.ResolveConfig) (*service.CaptureView, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := Events(ctx, &path.Events{
+		Capture:     p.Capture,
+		LastInFrame: true,
+	}, r)
+	if err != nil {
+		return nil, err
+	}
+
+	numFrames := uint64(len(events.List))
+	if numFrames == 0 {
+		numFrames = 1
+	}
+	first, last, err := frameRangeBounds(p.Frames, numFrames, p)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := framesCommandRange(ctx, p.Capture, p.Frames, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &service.CaptureView{
+		NumCommands: end - start,
+		NumFrames:   last - first + 1,
+		Filter:      p.Filter(),
+	}
+	if end > start {
+		out.FirstCommand = p.Capture.Command(start)
+		out.LastCommand = p.Capture.Command(end - 1)
+	}
+
+	var firstTime, lastTime uint64
+	for _, cmd := range cmds[start:end] {
+		if t := captureTime(cmd); t != 0 {
+			if firstTime == 0 {
+				firstTime = t
+			}
+			lastTime = t
+		}
+	}
+	out.Duration = lastTime - firstTime
+	return out, nil
+}
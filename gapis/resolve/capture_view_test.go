@@ -0,0 +1,108 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// newFramesTest returns the path to a capture of three frames, ending at the
+// commands 2, 4 and 6, with a command recorded every 100ns.
+func newFramesTest(ctx context.Context) *path.Capture {
+	h := &capture.Header{ABI: device.WindowsX86_64}
+	a := arena.New()
+	cb := test.CommandBuilder{Arena: a}
+	cmds := []api.Cmd{
+		cb.CmdVoid(),
+		cb.CmdVoid(),
+		cb.CmdEndOfFrame(),
+		cb.CmdVoid(),
+		cb.CmdEndOfFrame(),
+		cb.CmdVoid(),
+		cb.CmdEndOfFrame(),
+	}
+	for i, cmd := range cmds {
+		cmd.Extras().Add(&api.TimeStamp{Nanoseconds: uint64(i+1) * 100})
+	}
+	p, err := capture.NewGraphicsCapture(ctx, a, "frames", h, nil, cmds)
+	if err != nil {
+		log.F(ctx, true, "Couldn't create capture: %v", err)
+	}
+	path, err := p.Path(ctx)
+	if err != nil {
+		log.F(ctx, true, "Couldn't get capture path: %v", err)
+	}
+	return path
+}
+
+func TestCaptureView(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	c := newFramesTest(ctx)
+	ctx = capture.Put(ctx, c)
+
+	for _, test := range []struct {
+		name     string
+		frames   *path.FrameRange
+		expected *service.CaptureView
+	}{
+		{"all", nil, &service.CaptureView{
+			NumCommands:  7,
+			NumFrames:    3,
+			FirstCommand: c.Command(0),
+			LastCommand:  c.Command(6),
+			Duration:     600,
+		}},
+		{"middle", &path.FrameRange{First: 1, Count: 1}, &service.CaptureView{
+			NumCommands:  2,
+			NumFrames:    1,
+			FirstCommand: c.Command(3),
+			LastCommand:  c.Command(4),
+			Duration:     100,
+		}},
+		{"clamped", &path.FrameRange{First: 1, Count: 5}, &service.CaptureView{
+			NumCommands:  4,
+			NumFrames:    2,
+			FirstCommand: c.Command(3),
+			LastCommand:  c.Command(6),
+			Duration:     300,
+		}},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		test.expected.Filter = &path.CommandFilter{Frames: test.frames}
+		got, err := CaptureView(ctx, c.View(test.frames), nil)
+		if assert.For(ctx, "err").ThatError(err).Succeeded() {
+			assert.For(ctx, "view").That(got).DeepEquals(test.expected)
+		}
+	}
+
+	_, err := CaptureView(ctx, c.View(&path.FrameRange{First: 3}), nil)
+	assert.For(ctx, "out of range").ThatError(err).Failed()
+}
@@ -0,0 +1,127 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// maxParameterLength is the length, in runes, above which the formatted
+// values of command list entries are truncated.
+const maxParameterLength = 64
+
+// CommandListWindow resolves and returns the formatted entries of count
+// commands of the capture p, starting at the command offset. If tree is not
+// nil, it is the identifier of the command tree that the groups of each entry
+// are taken from.
+func CommandListWindow(ctx context.Context, p *path.Capture, offset uint64, count uint32, tree *path.ID, r *path.ResolveConfig) (*service.CommandListWindow, error) {
+	cmds, err := Cmds(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmdTree *commandTree
+	if tree != nil {
+		boxed, err := database.Resolve(ctx, tree.ID())
+		if err != nil {
+			return nil, err
+		}
+		t, ok := boxed.(*commandTree)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a command tree", tree.ID())
+		}
+		cmdTree = t
+	}
+
+	out := &service.CommandListWindow{
+		Total:   uint64(len(cmds)),
+		Entries: []*service.CommandListEntry{},
+	}
+	if offset >= uint64(len(cmds)) {
+		return out, nil
+	}
+	end := offset + uint64(count)
+	if end > uint64(len(cmds)) {
+		end = uint64(len(cmds))
+	}
+
+	type constantSetKey struct {
+		api   api.ID
+		index int
+	}
+	constants := map[constantSetKey]*service.ConstantSet{}
+	format := func(a api.API, p *api.Property) (string, error) {
+		v := p.Get()
+		s := fmt.Sprint(v)
+		if p.Constants >= 0 && a != nil {
+			key := constantSetKey{a.ID(), p.Constants}
+			set, ok := constants[key]
+			if !ok {
+				var err error
+				set, err = ConstantSet(ctx, (&path.API{ID: path.NewID(id.ID(a.ID()))}).ConstantSet(p.Constants), r)
+				if err != nil {
+					return "", err
+				}
+				constants[key] = set
+			}
+			s = set.Sprint(v)
+		}
+		return truncate(s, maxParameterLength), nil
+	}
+
+	for i := offset; i < end; i++ {
+		cmd := cmds[i]
+		entry := &service.CommandListEntry{
+			Command:    p.Command(i),
+			Name:       cmd.CmdName(),
+			Parameters: make([]string, 0, len(cmd.CmdParams())),
+			Groups:     []string{},
+		}
+		for _, param := range cmd.CmdParams() {
+			s, err := format(cmd.API(), param)
+			if err != nil {
+				return nil, err
+			}
+			entry.Parameters = append(entry.Parameters, fmt.Sprintf("%v: %v", param.Name, s))
+		}
+		if res := cmd.CmdResult(); res != nil {
+			s, err := format(cmd.API(), res)
+			if err != nil {
+				return nil, err
+			}
+			entry.Result = s
+		}
+		if cmdTree != nil {
+			entry.Groups = cmdTree.groups(api.CmdID(i))
+		}
+		out.Entries = append(out.Entries, entry)
+	}
+	return out, nil
+}
+
+// truncate returns s, shortened to n runes with an ellipsis if longer.
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
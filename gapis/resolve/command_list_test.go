@@ -0,0 +1,57 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+)
+
+func TestCommandListWindow(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	p := newPathTest(ctx)
+	ctx = capture.Put(ctx, p)
+
+	w, err := CommandListWindow(ctx, p, 1, 5, nil, nil)
+	if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "total").That(w.Total).Equals(uint64(3))
+	if assert.For(ctx, "entries").That(len(w.Entries)).Equals(2) {
+		assert.For(ctx, "command").That(w.Entries[0].Command.Indices).DeepEquals([]uint64{1})
+		assert.For(ctx, "name").That(w.Entries[0].Name).Equals("cmdTypeMix")
+		assert.For(ctx, "name").That(w.Entries[1].Name).Equals("primeState")
+	}
+
+	w, err = CommandListWindow(ctx, p, 3, 5, nil, nil)
+	if assert.For(ctx, "err").ThatError(err).Succeeded() {
+		assert.For(ctx, "entries").That(len(w.Entries)).Equals(0)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	ctx := log.Testing(t)
+	assert.For(ctx, "short").That(truncate("abc", 4)).Equals("abc")
+	assert.For(ctx, "exact").That(truncate("abcd", 4)).Equals("abcd")
+	assert.For(ctx, "long").That(truncate("abcde", 4)).Equals("abc…")
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/database"
 	"github.com/google/gapid/gapis/extensions"
+	"github.com/google/gapid/gapis/messages"
 	"github.com/google/gapid/gapis/resolve/cmdgrouper"
 	"github.com/google/gapid/gapis/service"
 	"github.com/google/gapid/gapis/service/path"
@@ -75,6 +76,17 @@ func (t *commandTree) index(indices []uint64) (api.SpanItem, api.SubCmdIdx) {
 	return group, subCmdRootID
 }
 
+// children returns the number of children of the node with the indices.
+func (t *commandTree) children(indices []uint64) uint64 {
+	switch item, _ := t.index(indices); item := item.(type) {
+	case api.CmdIDGroup:
+		return item.Count()
+	case api.SubCmdRoot:
+		return item.SubGroup.Count()
+	}
+	return 0
+}
+
 func (t *commandTree) indices(id api.CmdID) []uint64 {
 	out := []uint64{}
 	group := t.root
@@ -90,6 +102,21 @@ func (t *commandTree) indices(id api.CmdID) []uint64 {
 	}
 }
 
+// groups returns the names of the groups of the tree that contain the command
+// id, outermost first.
+func (t *commandTree) groups(id api.CmdID) []string {
+	out := []string{}
+	group := t.root
+	for {
+		item, ok := group.Index(group.IndexOf(id)).(api.CmdIDGroup)
+		if !ok || !item.Range.Contains(id) {
+			return out
+		}
+		out = append(out, item.Name)
+		group = item
+	}
+}
+
 // CommandTreeNode resolves the specified command tree node path.
 func CommandTreeNode(ctx context.Context, c *path.CommandTreeNode, r *path.ResolveConfig) (*service.CommandTreeNode, error) {
 	boxed, err := database.Resolve(ctx, c.Tree.ID())
@@ -98,6 +125,34 @@ func CommandTreeNode(ctx context.Context, c *path.CommandTreeNode, r *path.Resol
 	}
 
 	cmdTree := boxed.(*commandTree)
+	node, err := commandTreeNode(cmdTree, c)
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// gpuTime returns the sum of the durations of the top-level slices whose
+// group is linked to commands within the range cmds.
+func gpuTime(slices *service.ProfilingData_GpuSlices, cmds *path.Commands) uint64 {
+	from, to := api.SubCmdIdx(cmds.From), api.SubCmdIdx(cmds.To)
+	within := map[int32]bool{}
+	for _, g := range slices.GetGroups() {
+		if g.Link != nil {
+			first := api.SubCmdIdx(g.Link.From)
+			within[g.Id] = from.LEQ(first) && first.LEQ(to)
+		}
+	}
+	total := uint64(0)
+	for _, s := range slices.GetSlices() {
+		if s.Depth == 0 && within[s.Group] {
+			total += s.Dur
+		}
+	}
+	return total
+}
+
+func commandTreeNode(cmdTree *commandTree, c *path.CommandTreeNode) (*service.CommandTreeNode, error) {
 
 	rawItem, absID := cmdTree.index(c.Indices)
 	switch item := rawItem.(type) {
@@ -155,6 +210,226 @@ func CommandTreeNode(ctx context.Context, c *path.CommandTreeNode, r *path.Resol
 	}
 }
 
+// CommandTreeStats returns the number of descendants of the command tree node
+// p, counted per depth below p.
+func CommandTreeStats(ctx context.Context, p *path.CommandTreeNode, r *path.ResolveConfig) (*service.CommandTreeStats, error) {
+	boxed, err := database.Resolve(ctx, p.Tree.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	cmdTree := boxed.(*commandTree)
+
+	for i, idx := range p.Indices {
+		switch count := cmdTree.children(p.Indices[:i]); {
+		case count == 0:
+			return nil, &service.ErrInvalidPath{
+				Reason: messages.ErrNodeHasNoChildren(),
+				Path:   p.Path(),
+			}
+		case idx >= count:
+			return nil, errPathOOB(idx, "Indices", 0, count-1, p)
+		}
+	}
+
+	out := &service.CommandTreeStats{}
+	var count func(g api.CmdIDGroup, depth int)
+	count = func(g api.CmdIDGroup, depth int) {
+		if len(out.NodesPerDepth) <= depth {
+			out.NodesPerDepth = append(out.NodesPerDepth, 0)
+		}
+		out.NodesPerDepth[depth] += g.Count()
+		for _, s := range g.Spans {
+			switch s := s.(type) {
+			case *api.CmdIDGroup:
+				count(*s, depth+1)
+			case *api.SubCmdRoot:
+				count(s.SubGroup, depth+1)
+			}
+		}
+	}
+
+	switch item, _ := cmdTree.index(p.Indices); item := item.(type) {
+	case api.CmdIDGroup:
+		count(item, 0)
+	case api.SubCmdRoot:
+		count(item.SubGroup, 0)
+	}
+
+	// Trim levels holding no nodes, left by empty groups.
+	for len(out.NodesPerDepth) > 0 && out.NodesPerDepth[len(out.NodesPerDepth)-1] == 0 {
+		out.NodesPerDepth = out.NodesPerDepth[:len(out.NodesPerDepth)-1]
+	}
+	for _, n := range out.NodesPerDepth {
+		out.NumDescendants += n
+	}
+	out.MaxDepth = uint32(len(out.NodesPerDepth))
+	return out, nil
+}
+
+// CommandTreeRow returns the row of node, or if node is nil, the node at row,
+// among the visible descendants of the command tree node root, given the
+// expanded nodes. A descendant of root is visible if all its ancestors below
+// root are expanded, and the rows are in depth-first order.
+func CommandTreeRow(ctx context.Context, root *path.CommandTreeNode, expanded []*path.CommandTreeNode, node *path.CommandTreeNode, row uint64, r *path.ResolveConfig) (*service.CommandTreeRow, error) {
+	boxed, err := database.Resolve(ctx, root.Tree.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	rows := newCommandTreeRows(boxed.(*commandTree), root, expanded)
+	out := &service.CommandTreeRow{NumRows: rows.count(root.Indices)}
+	if node != nil {
+		if node.Tree.ID() != root.Tree.ID() || !hasIndicesPrefix(node.Indices, root.Indices) || len(node.Indices) == len(root.Indices) {
+			return nil, &service.ErrInvalidArgument{Reason: messages.ErrNodeNotBelowRoot()}
+		}
+		indices, row := rows.rowOf(root.Indices, node.Indices)
+		out.Node, out.Row = &path.CommandTreeNode{Tree: root.Tree, Indices: indices}, row
+		return out, nil
+	}
+	if row >= out.NumRows {
+		return nil, &service.ErrInvalidArgument{
+			Reason: messages.ErrValueOutOfBounds(row, "Row", uint64(0), out.NumRows-1),
+		}
+	}
+	out.Node, out.Row = &path.CommandTreeNode{Tree: root.Tree, Indices: rows.nodeAt(root.Indices, row)}, row
+	return out, nil
+}
+
+// commandTreeRows maps between the nodes of a command tree and their rows
+// among the visible nodes of the tree.
+type commandTreeRows struct {
+	tree     *commandTree
+	expanded map[string][]uint64 // The sorted indices of the expanded children, by parent.
+	counts   map[string]uint64   // The number of visible descendants, by node.
+}
+
+func newCommandTreeRows(tree *commandTree, root *path.CommandTreeNode, expanded []*path.CommandTreeNode) *commandTreeRows {
+	out := &commandTreeRows{tree: tree, expanded: map[string][]uint64{}, counts: map[string]uint64{}}
+	for _, e := range expanded {
+		if len(e.Indices) == 0 || e.Tree.ID() != root.Tree.ID() {
+			continue
+		}
+		parent := indicesKey(e.Indices[:len(e.Indices)-1])
+		out.expanded[parent] = append(out.expanded[parent], e.Indices[len(e.Indices)-1])
+	}
+	for k, children := range out.expanded {
+		sort.Slice(children, func(i, j int) bool { return children[i] < children[j] })
+		unique := children[:0]
+		for i, c := range children {
+			if i == 0 || c != children[i-1] {
+				unique = append(unique, c)
+			}
+		}
+		out.expanded[k] = unique
+	}
+	return out
+}
+
+// children returns the number of children of the node with the indices.
+func (r *commandTreeRows) children(indices []uint64) uint64 {
+	return r.tree.children(indices)
+}
+
+// isExpanded returns true if the node with the indices is expanded.
+func (r *commandTreeRows) isExpanded(indices []uint64) bool {
+	last := indices[len(indices)-1]
+	for _, e := range r.expanded[indicesKey(indices[:len(indices)-1])] {
+		if e == last {
+			return true
+		}
+	}
+	return false
+}
+
+// count returns the number of visible descendants of the node with the
+// indices, if it is expanded.
+func (r *commandTreeRows) count(indices []uint64) uint64 {
+	key := indicesKey(indices)
+	if c, ok := r.counts[key]; ok {
+		return c
+	}
+	n := r.children(indices)
+	c := n
+	for _, e := range r.expanded[key] {
+		if e < n {
+			c += r.count(append(append([]uint64{}, indices...), e))
+		}
+	}
+	r.counts[key] = c
+	return c
+}
+
+// rowOf returns the row of the node with the indices below the root with the
+// indices root, or of its closest visible ancestor, and the indices of the
+// node at the row.
+func (r *commandTreeRows) rowOf(root, indices []uint64) ([]uint64, uint64) {
+	row := uint64(0)
+	for d := len(root); d < len(indices); d++ {
+		parent, i := indices[:d], indices[d]
+		if d > len(root) && !r.isExpanded(parent) {
+			return parent, row - 1
+		}
+		row += i
+		for _, e := range r.expanded[indicesKey(parent)] {
+			if e < i {
+				row += r.count(append(append([]uint64{}, parent...), e))
+			}
+		}
+		row++
+	}
+	return indices, row - 1
+}
+
+// nodeAt returns the indices of the node at the row below the root with the
+// indices root. row must be less than the count of root.
+func (r *commandTreeRows) nodeAt(root []uint64, row uint64) []uint64 {
+	indices := append([]uint64{}, root...)
+	for {
+		next := uint64(0) // The index of the child at the remaining row 0.
+		descended := false
+		for _, e := range r.expanded[indicesKey(indices)] {
+			if row < e-next {
+				break
+			}
+			row -= e - next
+			child := append(append([]uint64{}, indices...), e)
+			if row == 0 {
+				return child
+			}
+			row--
+			if c := r.count(child); row < c {
+				indices, descended = child, true
+				break
+			} else {
+				row -= c
+			}
+			next = e + 1
+		}
+		if !descended {
+			return append(indices, next+row)
+		}
+	}
+}
+
+// indicesKey returns a map key for the node indices.
+func indicesKey(indices []uint64) string {
+	return fmt.Sprint(indices)
+}
+
+// hasIndicesPrefix returns true if the indices start with prefix.
+func hasIndicesPrefix(indices, prefix []uint64) bool {
+	if len(indices) < len(prefix) {
+		return false
+	}
+	for i, p := range prefix {
+		if indices[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
 // CommandTreeNodeForCommand returns the path to the CommandTreeNode that
 // represents the specified command.
 func CommandTreeNodeForCommand(ctx context.Context, p *path.CommandTreeNodeForCommand, r *path.ResolveConfig) (*path.CommandTreeNode, error) {
@@ -0,0 +1,117 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestGpuTime(t *testing.T) {
+	ctx := log.Testing(t)
+	c := &path.Capture{}
+	slices := &service.ProfilingData_GpuSlices{
+		Groups: []*service.ProfilingData_GpuSlices_Group{
+			{Id: 1, Link: c.SubCommandRange([]uint64{2, 0, 0}, []uint64{2, 0, 5})},
+			{Id: 2, Link: c.SubCommandRange([]uint64{4, 0, 0}, []uint64{4, 0, 3})},
+			{Id: 3, Parent: 2},
+		},
+		Slices: []*service.ProfilingData_GpuSlices_Slice{
+			{Dur: 100, Group: 1},
+			{Dur: 10, Group: 1, Depth: 1}, // Nested, already counted by its parent.
+			{Dur: 200, Group: 2},
+			{Dur: 400, Group: 3}, // Group without link.
+		},
+	}
+
+	for _, test := range []struct {
+		name     string
+		cmds     *path.Commands
+		expected uint64
+	}{
+		{"all", c.CommandRange(0, 10), 300},
+		{"command", c.CommandRange(2, 2), 100},
+		{"range", c.CommandRange(3, 4), 200},
+		{"subcommand", c.SubCommandRange([]uint64{4, 0}, []uint64{4, 0}), 200},
+		{"none", c.CommandRange(5, 10), 0},
+	} {
+		assert.For(ctx, test.name).That(gpuTime(slices, test.cmds)).Equals(test.expected)
+	}
+}
+
+func TestCommandTreeRows(t *testing.T) {
+	ctx := log.Testing(t)
+	// The root holds the commands 0, 1, the group A of 2-4, then 5-9.
+	group := api.CmdIDGroup{Name: "root", Range: api.CmdIDRange{Start: 0, End: 10}}
+	group.AddGroup(2, 5, "A")
+	for i := api.CmdID(0); i < 10; i++ {
+		group.AddCommand(i)
+	}
+	tree := &commandTree{root: group}
+	treeID := path.NewID(id.ID{1})
+	root := &path.CommandTreeNode{Tree: treeID}
+
+	collapsed := newCommandTreeRows(tree, root, nil)
+	assert.For(ctx, "collapsed count").That(collapsed.count(nil)).Equals(uint64(8))
+	indices, row := collapsed.rowOf(nil, []uint64{2, 1})
+	assert.For(ctx, "hidden node").ThatSlice(indices).Equals([]uint64{2})
+	assert.For(ctx, "hidden row").That(row).Equals(uint64(2))
+
+	expanded := newCommandTreeRows(tree, root, []*path.CommandTreeNode{{Tree: treeID, Indices: []uint64{2}}})
+	assert.For(ctx, "expanded count").That(expanded.count(nil)).Equals(uint64(11))
+	for _, test := range []struct {
+		indices []uint64
+		row     uint64
+	}{
+		{[]uint64{0}, 0},
+		{[]uint64{2}, 2},
+		{[]uint64{2, 0}, 3},
+		{[]uint64{2, 2}, 5},
+		{[]uint64{3}, 6},
+		{[]uint64{7}, 10},
+	} {
+		indices, row := expanded.rowOf(nil, test.indices)
+		assert.For(ctx, "rowOf(%v) node", test.indices).ThatSlice(indices).Equals(test.indices)
+		assert.For(ctx, "rowOf(%v) row", test.indices).That(row).Equals(test.row)
+		assert.For(ctx, "nodeAt(%v)", test.row).ThatSlice(expanded.nodeAt(nil, test.row)).Equals(test.indices)
+	}
+}
+
+func TestCommandTreeChildren(t *testing.T) {
+	ctx := log.Testing(t)
+	group := api.CmdIDGroup{Name: "root", Range: api.CmdIDRange{Start: 0, End: 10}}
+	group.AddGroup(2, 5, "A")
+	for i := api.CmdID(0); i < 10; i++ {
+		group.AddCommand(i)
+	}
+	tree := &commandTree{root: group}
+	for _, test := range []struct {
+		indices  []uint64
+		expected uint64
+	}{
+		{nil, 8},
+		{[]uint64{2}, 3},
+		{[]uint64{0}, 0},
+		{[]uint64{8}, 0}, // Out of range.
+	} {
+		assert.For(ctx, "children(%v)", test.indices).That(tree.children(test.indices)).Equals(test.expected)
+	}
+}
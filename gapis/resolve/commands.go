@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/math/u64"
 	"github.com/google/gapid/gapis/api"
@@ -79,6 +80,13 @@ func NCmds(ctx context.Context, p *path.Capture, n uint64) ([]api.Cmd, error) {
 
 // Cmd resolves and returns the command from the path p.
 func Cmd(ctx context.Context, p *path.Command, r *path.ResolveConfig) (api.Cmd, error) {
+	if p.StableId != 0 {
+		n, err := resolveStableCommand(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		p = n.(*path.Command)
+	}
 	cmdIdx := p.Indices[0]
 	if len(p.Indices) > 1 {
 		snc, err := SyncData(ctx, p.Capture)
@@ -172,3 +180,60 @@ func Result(ctx context.Context, p *path.Result, r *path.ResolveConfig) (interfa
 		return nil, err
 	}
 }
+
+// resolveStableCommand returns p with any command path node that is
+// identified by its stable identifier replaced with one identified by the
+// command's current index. If p does not contain a command path node with a
+// stable identifier then p is returned unaltered.
+func resolveStableCommand(ctx context.Context, p path.Node) (path.Node, error) {
+	cmd := path.FindCommand(p)
+	if cmd == nil || cmd.StableId == 0 {
+		return p, nil
+	}
+	id, err := stableCommandIndex(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	p = proto.Clone(p.(proto.Message)).(path.Node)
+	cmd = path.FindCommand(p)
+	cmd.StableId = 0
+	cmd.Indices = []uint64{uint64(id)}
+	return p, nil
+}
+
+// StableCommands replaces, in place, the stable identifier of the command path
+// node of each of the nodes with the command's current index. Server RPCs call
+// it on the paths of their requests, as most resolvers only handle command
+// paths identified by their indices.
+func StableCommands(ctx context.Context, nodes ...path.Node) error {
+	for _, n := range nodes {
+		cmd := path.FindCommand(n)
+		if cmd == nil || cmd.StableId == 0 {
+			continue
+		}
+		id, err := stableCommandIndex(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		cmd.StableId = 0
+		cmd.Indices = []uint64{uint64(id)}
+	}
+	return nil
+}
+
+// stableCommandIndex returns the current index of the command identified by
+// the stable identifier of cmd.
+func stableCommandIndex(ctx context.Context, cmd *path.Command) (api.CmdID, error) {
+	c, err := capture.ResolveGraphicsFromPath(ctx, cmd.Capture)
+	if err != nil {
+		return 0, err
+	}
+	id, ok := c.CommandForStableID(cmd.StableId)
+	if !ok {
+		return 0, &service.ErrInvalidPath{
+			Reason: messages.ErrStableIdNotFound(cmd.StableId),
+			Path:   cmd.Path(),
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,44 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestStableCommands(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	c := newFramesTest(ctx)
+
+	byIndex := c.Command(2)
+	byStableID := &path.Command{Capture: c, StableId: 4}
+	state := (&path.Command{Capture: c, StableId: 6}).StateAfter()
+	err := StableCommands(ctx, byIndex, byStableID, state)
+	if assert.For(ctx, "err").ThatError(err).Succeeded() {
+		assert.For(ctx, "by index").ThatSlice(byIndex.Indices).Equals([]uint64{2})
+		assert.For(ctx, "by stable id").ThatSlice(byStableID.Indices).Equals([]uint64{3})
+		assert.For(ctx, "by stable id").That(byStableID.StableId).Equals(uint64(0))
+		assert.For(ctx, "state").ThatSlice(path.FindCommand(state).Indices).Equals([]uint64{5})
+	}
+
+	err = StableCommands(ctx, &path.Command{Capture: c, StableId: 100})
+	assert.For(ctx, "unknown stable id").ThatError(err).Failed()
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// CompareState resolves each of the state value paths in paths after each of
+// the commands in cmds, returning the values as a table with a row per path
+// and a column per command. The command each path is rooted at is ignored.
+// The commands must all belong to the same capture, and are mutated once.
+func CompareState(ctx context.Context, paths []*path.Any, cmds []*path.Command, r *path.ResolveConfig) (*service.StateTable, error) {
+	if len(cmds) == 0 {
+		return nil, fmt.Errorf("No commands to compare the state at")
+	}
+	c := cmds[0].Capture
+	ctx = SetupContext(ctx, c, r)
+	ids := make([]api.CmdID, len(cmds))
+	for i, cmd := range cmds {
+		if len(cmd.Indices) != 1 {
+			return nil, fmt.Errorf("Subcommands currently not supported for CompareState") // TODO: Subcommands
+		}
+		if !cmd.Capture.ID.SameAs(c.ID) {
+			return nil, fmt.Errorf("Commands to compare the state at belong to different captures")
+		}
+		ids[i] = api.CmdID(cmd.Indices[0])
+	}
+
+	nodes := make([]path.Node, len(paths))
+	for i, p := range paths {
+		nodes[i] = p.Node()
+		if findState(nodes[i]) == nil {
+			if _, g := stateNodes(nodes[i]); g == nil {
+				return nil, &service.ErrInvalidPath{
+					Reason: messages.ErrNotAStatePath(),
+					Path:   p,
+				}
+			}
+		}
+	}
+
+	all, err := Cmds(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	// columns maps each command identifier to the table columns it fills.
+	columns := map[api.CmdID][]int{}
+	last := api.CmdID(0)
+	for i, id := range ids {
+		if int(id) >= len(all) {
+			return nil, fmt.Errorf("Command %v is out of range", id)
+		}
+		columns[id] = append(columns[id], i)
+		if id > last {
+			last = id
+		}
+	}
+
+	out := &service.StateTable{
+		Commands: cmds,
+		Rows:     make([]*service.StateTableRow, len(paths)),
+	}
+	for i, p := range paths {
+		out.Rows[i] = &service.StateTableRow{
+			Path:  p,
+			Cells: make([]*service.StateTableCell, len(cmds)),
+		}
+	}
+
+	s, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = api.ForeachCmd(ctx, all[:last+1], true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if err := cmd.Mutate(ctx, id, s, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		cols, ok := columns[id]
+		if !ok {
+			return nil
+		}
+		after := c.Command(uint64(id))
+		for i, n := range nodes {
+			cell := &service.StateTableCell{}
+			if v, ok := stateObjectAfter(ctx, n, after, cmd.API(), s, r); ok {
+				cell.Value = box.NewValue(v)
+			}
+			for _, col := range cols {
+				out.Rows[i].Cells[col] = cell
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
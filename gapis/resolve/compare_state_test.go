@@ -0,0 +1,71 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestCompareState(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	p := newPathTest(ctx)
+	s := p.Command(0).StateAfter()
+
+	paths := []*path.Any{
+		s.Field("Str").Path(),
+		s.Field("Ref").Field("RefObject").Field("value").Path(),
+	}
+	cmds := []*path.Command{p.Command(2), p.Command(0), p.Command(2)}
+
+	got, err := CompareState(ctx, paths, cmds, nil)
+	if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "commands").That(got.Commands).DeepEquals(cmds)
+	assert.For(ctx, "rows").That(len(got.Rows)).Equals(len(paths))
+
+	for i, expected := range [][]interface{}{
+		{"aaa", "", "aaa"},
+		{uint32(555), nil, uint32(555)},
+	} {
+		ctx := log.V{"row": i}.Bind(ctx)
+		row := got.Rows[i]
+		assert.For(ctx, "path").That(row.Path).Equals(paths[i])
+		if !assert.For(ctx, "cells").That(len(row.Cells)).Equals(len(cmds)) {
+			continue
+		}
+		for j, v := range expected {
+			ctx := log.V{"column": j}.Bind(ctx)
+			if v == nil {
+				assert.For(ctx, "value").That(row.Cells[j].Value).IsNil()
+			} else {
+				assert.For(ctx, "value").That(row.Cells[j].Value).DeepEquals(box.NewValue(v))
+			}
+		}
+	}
+
+	_, err = CompareState(ctx, []*path.Any{p.Command(0).Result().Path()}, cmds, nil)
+	assert.For(ctx, "non-state path").ThatError(err).Failed()
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph_visualization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/resolve/dependencygraph2"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// exportedNode is a node of an exported dependency graph.
+type exportedNode struct {
+	ID      dependencygraph2.NodeID `json:"id"`
+	Label   string                  `json:"label"`
+	Command []uint64                `json:"command,omitempty"`
+}
+
+// exportedEdge is a dependency of an exported dependency graph, from the node
+// that depends on the node To.
+type exportedEdge struct {
+	From dependencygraph2.NodeID `json:"from"`
+	To   dependencygraph2.NodeID `json:"to"`
+}
+
+// exportedGraph is an exported dependency graph.
+type exportedGraph struct {
+	Nodes []exportedNode `json:"nodes"`
+	Edges []exportedEdge `json:"edges"`
+}
+
+// ExportDependencyGraph returns the dependency graph of the capture p in the
+// given format. If around is not nil, only the nodes at most radius
+// dependencies away from the node of the command around, in either
+// direction, are exported.
+func ExportDependencyGraph(ctx context.Context, p *path.Capture, around *path.Command, radius uint32, format service.GraphFormat) ([]byte, error) {
+	config := dependencygraph2.DependencyGraphConfig{
+		ReverseDependencies: true,
+	}
+	g, err := dependencygraph2.GetDependencyGraph(ctx, p, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var included map[dependencygraph2.NodeID]bool
+	if around != nil {
+		start := g.GetCmdNodeID(api.CmdID(around.Indices[0]), around.Indices[1:])
+		if start == dependencygraph2.NodeNoID {
+			return nil, fmt.Errorf("Command %v has no dependency graph node", around.Indices)
+		}
+		included = neighbourhood(start, radius, func(id dependencygraph2.NodeID, cb func(dependencygraph2.NodeID) error) {
+			g.ForeachDependencyFrom(id, cb)
+			g.ForeachDependencyTo(id, cb)
+		})
+	}
+
+	out := exportedGraph{Nodes: []exportedNode{}, Edges: []exportedEdge{}}
+	g.ForeachNode(func(id dependencygraph2.NodeID, node dependencygraph2.Node) error {
+		if included != nil && !included[id] {
+			return nil
+		}
+		out.Nodes = append(out.Nodes, exportNode(g, id, node))
+		return nil
+	})
+	g.ForeachDependency(func(from, to dependencygraph2.NodeID) error {
+		if included == nil || (included[from] && included[to]) {
+			out.Edges = append(out.Edges, exportedEdge{from, to})
+		}
+		return nil
+	})
+
+	switch format {
+	case service.GraphFormat_DOT:
+		return out.dot(), nil
+	case service.GraphFormat_JSON:
+		return json.MarshalIndent(out, "", "  ")
+	default:
+		return nil, fmt.Errorf("Unsupported dependency graph format: %v", format)
+	}
+}
+
+// exportNode returns the exported node of the dependency graph node.
+func exportNode(g dependencygraph2.DependencyGraph, id dependencygraph2.NodeID, node dependencygraph2.Node) exportedNode {
+	switch node := node.(type) {
+	case dependencygraph2.CmdNode:
+		label := "subcommand"
+		if len(node.Index) == 1 {
+			label = g.GetCommand(api.CmdID(node.Index[0])).CmdName()
+		}
+		return exportedNode{ID: id, Label: label, Command: node.Index}
+	case dependencygraph2.ObsNode:
+		kind := "read"
+		if node.IsWrite {
+			kind = "write"
+		}
+		return exportedNode{
+			ID:      id,
+			Label:   fmt.Sprintf("%s %v", kind, node.CmdObservation.Range),
+			Command: []uint64{uint64(node.CmdID)},
+		}
+	default:
+		return exportedNode{ID: id, Label: fmt.Sprintf("%T", node)}
+	}
+}
+
+// neighbourhood returns the nodes at most radius edges away from start, where
+// foreachNeighbour calls cb with each neighbour of a node.
+func neighbourhood(start dependencygraph2.NodeID, radius uint32,
+	foreachNeighbour func(dependencygraph2.NodeID, func(dependencygraph2.NodeID) error)) map[dependencygraph2.NodeID]bool {
+
+	out := map[dependencygraph2.NodeID]bool{start: true}
+	frontier := []dependencygraph2.NodeID{start}
+	for i := uint32(0); i < radius && len(frontier) > 0; i++ {
+		next := []dependencygraph2.NodeID{}
+		for _, id := range frontier {
+			foreachNeighbour(id, func(n dependencygraph2.NodeID) error {
+				if !out[n] {
+					out[n] = true
+					next = append(next, n)
+				}
+				return nil
+			})
+		}
+		frontier = next
+	}
+	return out
+}
+
+// dot returns the graph in the Graphviz DOT format.
+func (g exportedGraph) dot() []byte {
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	var output bytes.Buffer
+	output.WriteString("digraph g {\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&output, "%d[label=\"%v %s\"];\n", n.ID, n.Command, n.Label)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&output, "%d -> %d;\n", e.From, e.To)
+	}
+	output.WriteString("}\n")
+	return output.Bytes()
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph_visualization
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/gapid/gapis/resolve/dependencygraph2"
+)
+
+func TestNeighbourhood(t *testing.T) {
+	// 0 - 1 - 2 - 3
+	//     |
+	//     4 - 5
+	edges := map[dependencygraph2.NodeID][]dependencygraph2.NodeID{
+		0: {1},
+		1: {0, 2, 4},
+		2: {1, 3},
+		3: {2},
+		4: {1, 5},
+		5: {4},
+	}
+	foreachNeighbour := func(id dependencygraph2.NodeID, cb func(dependencygraph2.NodeID) error) {
+		for _, n := range edges[id] {
+			cb(n)
+		}
+	}
+
+	for _, test := range []struct {
+		start    dependencygraph2.NodeID
+		radius   uint32
+		expected map[dependencygraph2.NodeID]bool
+	}{
+		{2, 0, map[dependencygraph2.NodeID]bool{2: true}},
+		{2, 1, map[dependencygraph2.NodeID]bool{1: true, 2: true, 3: true}},
+		{2, 2, map[dependencygraph2.NodeID]bool{0: true, 1: true, 2: true, 3: true, 4: true}},
+		{0, 10, map[dependencygraph2.NodeID]bool{0: true, 1: true, 2: true, 3: true, 4: true, 5: true}},
+	} {
+		got := neighbourhood(test.start, test.radius, foreachNeighbour)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("neighbourhood(%v, %v) returned %v, expected %v", test.start, test.radius, got, test.expected)
+		}
+	}
+}
+
+func TestExportedGraphDot(t *testing.T) {
+	g := exportedGraph{
+		Nodes: []exportedNode{
+			{ID: 1, Label: "vkQueueSubmit", Command: []uint64{4}},
+			{ID: 0, Label: "vkCreateBuffer", Command: []uint64{2}},
+		},
+		Edges: []exportedEdge{{From: 1, To: 0}},
+	}
+	expected := "digraph g {\n" +
+		"0[label=\"[2] vkCreateBuffer\"];\n" +
+		"1[label=\"[4] vkQueueSubmit\"];\n" +
+		"1 -> 0;\n" +
+		"}\n"
+	if got := string(g.dot()); got != expected {
+		t.Errorf("dot() returned %q, expected %q", got, expected)
+	}
+}
@@ -139,6 +139,8 @@ func GetGraphVisualizationFromCapture(ctx context.Context, p *path.Capture, form
 		output = currentGraph.getGraphInPbtxtFormat()
 	} else if format == service.GraphFormat_DOT {
 		output = currentGraph.getGraphInDotFormat()
+	} else {
+		return []byte{}, fmt.Errorf("Unsupported graph visualization format: %v", format)
 	}
 
 	return output, err
@@ -0,0 +1,140 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/replay/devices"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+const (
+	// reorderThreshold is the fraction of the samples of a draw call failing
+	// the depth test above which drawing front to back is suggested.
+	reorderThreshold = 0.1
+	// depthPrePassThreshold is the fraction of the samples of a draw call
+	// failing the depth test above which a depth pre-pass is suggested.
+	depthPrePassThreshold = 0.5
+)
+
+// DepthTestCost resolves and returns the number of samples rasterized by each
+// draw call of the frame of p that fail the depth test, measured by replaying
+// the frame with an occlusion query around each draw call, with and without
+// the depth test. Draw calls with many hidden samples are suggested to be
+// drawn front to back, or after a depth pre-pass.
+func DepthTestCost(ctx context.Context, p *path.DepthTestCost, r *path.ResolveConfig) (*service.DepthTestCost, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := frameCommandRange(ctx, p.Capture, p.Frame, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	draws, err := drawCalls(ctx, p.Capture, cmds, start, end, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(draws) == 0 {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+
+	cmd, err := Cmd(ctx, draws[0], r)
+	if err != nil {
+		return nil, err
+	}
+	query, ok := cmd.API().(replay.QueryDepthTestCost)
+	if !ok {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrDepthTestCostNotAvailable()}
+	}
+
+	device := r.GetReplayDevice()
+	if device == nil {
+		devices, err := devices.ForReplay(ctx, p.Capture)
+		if err != nil {
+			return nil, err
+		}
+		if len(devices) == 0 {
+			return nil, fmt.Errorf("No compatible replay devices found")
+		}
+		device = devices[0]
+	}
+
+	ctx = SetupContext(ctx, p.Capture, r)
+	intent := replay.Intent{
+		Device:  device,
+		Capture: p.Capture,
+	}
+	out, err := query.QueryDepthTestCost(
+		ctx,
+		intent,
+		replay.GetManager(ctx),
+		api.CmdID(start),
+		api.CmdID(end),
+		&service.UsageHints{Background: true},
+	)
+	if err != nil || out == nil {
+		return out, err
+	}
+
+	for _, d := range out.Draws {
+		failed := failedSamples(d)
+		out.Samples += d.Samples
+		out.FailedSamples += failed
+		if d.Samples > 0 {
+			d.FailedFraction = float32(failed) / float32(d.Samples)
+		}
+		d.Suggestion = depthTestSuggestion(d.FailedFraction)
+	}
+	sort.SliceStable(out.Draws, func(i, j int) bool {
+		a, b := out.Draws[i], out.Draws[j]
+		return failedSamples(a) > failedSamples(b)
+	})
+	if len(draws) > len(out.Draws) {
+		out.Skipped = uint32(len(draws) - len(out.Draws))
+	}
+	return out, nil
+}
+
+// failedSamples returns the samples of the draw call d that failed the depth
+// test. The samples are counted by separate replays, so it is clamped to 0.
+func failedSamples(d *service.DrawDepthTest) uint64 {
+	if d.PassedSamples >= d.Samples {
+		return 0
+	}
+	return d.Samples - d.PassedSamples
+}
+
+// depthTestSuggestion returns the suggestion for a draw call with the given
+// fraction of its samples failing the depth test.
+func depthTestSuggestion(failed float32) service.DepthTestSuggestion {
+	switch {
+	case failed >= depthPrePassThreshold:
+		return service.DepthTestSuggestion_DepthPrePass
+	case failed >= reorderThreshold:
+		return service.DepthTestSuggestion_ReorderDraws
+	default:
+		return service.DepthTestSuggestion_NoDepthTestSuggestion
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+func TestDepthTestSuggestion(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		failed   float32
+		expected service.DepthTestSuggestion
+	}{
+		{0, service.DepthTestSuggestion_NoDepthTestSuggestion},
+		{0.05, service.DepthTestSuggestion_NoDepthTestSuggestion},
+		{0.1, service.DepthTestSuggestion_ReorderDraws},
+		{0.3, service.DepthTestSuggestion_ReorderDraws},
+		{0.5, service.DepthTestSuggestion_DepthPrePass},
+		{1, service.DepthTestSuggestion_DepthPrePass},
+	} {
+		assert.For(ctx, "failed %v", test.failed).
+			That(depthTestSuggestion(test.failed)).Equals(test.expected)
+	}
+}
+
+func TestFailedSamples(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		samples, passed, expected uint64
+	}{
+		{0, 0, 0},
+		{10, 10, 0},
+		{10, 4, 6},
+		{10, 12, 0},
+	} {
+		d := &service.DrawDepthTest{Samples: test.samples, PassedSamples: test.passed}
+		assert.For(ctx, "failed %v/%v", test.passed, test.samples).
+			That(failedSamples(d)).Equals(test.expected)
+	}
+}
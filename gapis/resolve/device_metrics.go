@@ -0,0 +1,124 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// frameBoundaryMessage is the prefix of the trace messages recorded at each
+// frame boundary when the trace times are recorded.
+const frameBoundaryMessage = "Frame Number: "
+
+// DeviceMetrics resolves and returns the time series of the device state
+// sampled while capturing the capture of p. Each sample is aligned with the
+// frame it was taken in, using the times of the frame boundaries recorded with
+// the trace times.
+func DeviceMetrics(ctx context.Context, p *path.DeviceMetrics, r *path.ResolveConfig) (*service.DeviceMetrics, error) {
+	c, err := capture.ResolveGraphicsFromPath(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.DeviceSamples) == 0 {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoDeviceMetrics()}
+	}
+	out := deviceMetrics(c.DeviceSamples, frameEnds(c.Messages))
+	if len(out.Frames) > 0 && out.Frames[0] < 0 {
+		out.FramesUnavailable = messages.ErrNoFrameTimings()
+	}
+	return out, nil
+}
+
+// frameEnds returns the sorted times of the frame boundaries in the trace
+// messages.
+func frameEnds(msgs []*capture.TraceMessage) []uint64 {
+	out := []uint64{}
+	for _, m := range msgs {
+		if strings.HasPrefix(m.Message, frameBoundaryMessage) {
+			out = append(out, m.Timestamp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// deviceMetrics returns the time series of the samples, ordered by time, with
+// each sample aligned with the frames ending at the sorted times frameEnds.
+func deviceMetrics(samples []*capture.DeviceSample, frameEnds []uint64) *service.DeviceMetrics {
+	samples = append([]*capture.DeviceSample{}, samples...)
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Timestamp < samples[j].Timestamp
+	})
+
+	out := &service.DeviceMetrics{
+		Timestamps: make([]uint64, len(samples)),
+		Frames:     make([]int64, len(samples)),
+	}
+	series := map[string]*service.DeviceMetricSeries{}
+	add := func(i int, name, unit string, value float64) {
+		s, ok := series[name]
+		if !ok {
+			s = &service.DeviceMetricSeries{Name: name, Unit: unit, Values: make([]float64, len(samples))}
+			for j := range s.Values {
+				s.Values[j] = math.NaN()
+			}
+			series[name] = s
+		}
+		s.Values[i] = value
+	}
+
+	for i, s := range samples {
+		out.Timestamps[i] = s.Timestamp
+		out.Frames[i] = -1
+		if len(frameEnds) > 0 {
+			out.Frames[i] = int64(sort.Search(len(frameEnds), func(j int) bool {
+				return frameEnds[j] >= s.Timestamp
+			}))
+		}
+		for zone, temp := range s.ThermalZones {
+			add(i, "thermal/"+zone, "C", float64(temp)/1000)
+		}
+		for cpu, freq := range s.CpuFrequencies {
+			add(i, fmt.Sprintf("cpu%d/frequency", cpu), "MHz", float64(freq)/1000)
+		}
+		if s.GpuFrequency != 0 {
+			add(i, "gpu/frequency", "MHz", float64(s.GpuFrequency)/1000000)
+		}
+		if b := s.Battery; b != nil {
+			add(i, "battery/level", "%", float64(b.Level))
+			add(i, "battery/temperature", "C", float64(b.Temperature)/10)
+			add(i, "battery/current", "mA", float64(b.Current)/1000)
+			add(i, "battery/voltage", "V", float64(b.Voltage)/1000000)
+		}
+	}
+
+	out.Series = make([]*service.DeviceMetricSeries, 0, len(series))
+	for _, s := range series {
+		out.Series = append(out.Series, s)
+	}
+	sort.Slice(out.Series, func(i, j int) bool {
+		return out.Series[i].Name < out.Series[j].Name
+	})
+	return out
+}
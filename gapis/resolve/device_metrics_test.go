@@ -0,0 +1,83 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/capture"
+)
+
+func TestDeviceMetrics(t *testing.T) {
+	ctx := log.Testing(t)
+	samples := []*capture.DeviceSample{
+		{
+			Timestamp:      2500,
+			ThermalZones:   map[string]int64{"cpu": 45500},
+			CpuFrequencies: []uint64{1800000, 0},
+		}, {
+			Timestamp:      500,
+			ThermalZones:   map[string]int64{"cpu": 40000, "gpu": 38000},
+			CpuFrequencies: []uint64{2400000, 1200000},
+			GpuFrequency:   585000000,
+			Battery:        &capture.BatteryState{Level: 80, Temperature: 312, Current: -450000, Voltage: 3900000},
+		},
+	}
+	got := deviceMetrics(samples, []uint64{1000, 2000, 3000})
+
+	assert.For(ctx, "timestamps").ThatSlice(got.Timestamps).Equals([]uint64{500, 2500})
+	assert.For(ctx, "frames").ThatSlice(got.Frames).Equals([]int64{0, 2})
+
+	names := []string{}
+	for _, s := range got.Series {
+		names = append(names, s.Name)
+	}
+	assert.For(ctx, "names").ThatSlice(names).Equals([]string{
+		"battery/current",
+		"battery/level",
+		"battery/temperature",
+		"battery/voltage",
+		"cpu0/frequency",
+		"cpu1/frequency",
+		"gpu/frequency",
+		"thermal/cpu",
+		"thermal/gpu",
+	})
+	values := func(i int) []float64 { return got.Series[i].Values }
+	assert.For(ctx, "battery/current").ThatSlice(values(0)[:1]).Equals([]float64{-450})
+	assert.For(ctx, "cpu0/frequency").ThatSlice(values(4)).Equals([]float64{2400, 1800})
+	assert.For(ctx, "cpu1/frequency").ThatSlice(values(5)).Equals([]float64{1200, 0})
+	assert.For(ctx, "gpu/frequency").That(values(6)[0]).Equals(585.0)
+	assert.For(ctx, "gpu/frequency missing").That(math.IsNaN(values(6)[1])).Equals(true)
+	assert.For(ctx, "thermal/cpu").ThatSlice(values(7)).Equals([]float64{40, 45.5})
+	assert.For(ctx, "thermal/gpu missing").That(math.IsNaN(values(8)[1])).Equals(true)
+
+	unaligned := deviceMetrics(samples, nil)
+	assert.For(ctx, "unaligned frames").ThatSlice(unaligned.Frames).Equals([]int64{-1, -1})
+}
+
+func TestFrameEnds(t *testing.T) {
+	ctx := log.Testing(t)
+	msgs := []*capture.TraceMessage{
+		{Timestamp: 300, Message: "Frame Number: 2"},
+		{Timestamp: 50, Message: "State serialization started"},
+		{Timestamp: 100, Message: "Frame Number: 1"},
+	}
+	assert.For(ctx, "frame ends").ThatSlice(frameEnds(msgs)).Equals([]uint64{100, 300})
+	assert.For(ctx, "no frames").ThatSlice(frameEnds(msgs[1:2])).IsEmpty()
+}
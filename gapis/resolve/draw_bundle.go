@@ -0,0 +1,56 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// DrawBundle resolves and returns the state used by the draw call at p, as a
+// self-contained bundle from which the draw call can be reproduced.
+func DrawBundle(ctx context.Context, p *path.DrawBundle, r *path.ResolveConfig) (*api.DrawBundle, error) {
+	cmd, err := Cmd(ctx, p.Command, r)
+	if err != nil {
+		return nil, err
+	}
+
+	dbp, ok := cmd.API().(api.DrawBundleProvider)
+	if !ok {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrDrawBundleNotAvailable()}
+	}
+	out, err := dbp.DrawBundle(ctx, cmd, p, r)
+	switch {
+	case err != nil:
+		return nil, err
+	case out == nil:
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrDrawBundleNotAvailable()}
+	}
+
+	if out.Command, err = api.CmdToService(cmd); err != nil {
+		return nil, err
+	}
+
+	pipelines, err := Pipelines(ctx, &path.Pipelines{After: p.Command}, r)
+	if err != nil {
+		return nil, err
+	}
+	out.Pipelines = pipelines.(*api.MultiResourceData).Resources
+	return out, nil
+}
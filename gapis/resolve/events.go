@@ -68,6 +68,7 @@ func Events(ctx context.Context, p *path.Events, r *path.ResolveConfig) (*servic
 	s := c.NewState(ctx)
 	lastCmd := api.CmdID(0)
 	var pending []service.EventKind
+	var lastIO *capture.IOCounters
 
 	getTime := func(cmd api.Cmd) uint64 {
 		if !p.IncludeTiming {
@@ -85,6 +86,20 @@ func Events(ctx context.Context, p *path.Events, r *path.ResolveConfig) (*servic
 			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
 		}
 
+		// The I/O counters are tracked before filtering, so that the
+		// activity always covers the time since the previous sample.
+		var io *service.FrameIOActivity
+		if p.IoActivity {
+			for _, e := range cmd.Extras().All() {
+				if counters, ok := e.(*capture.IOCounters); ok {
+					if lastIO != nil {
+						io = ioActivity(lastIO, counters)
+					}
+					lastIO = counters
+				}
+			}
+		}
+
 		// TODO: Add event generation to the API files.
 		if !filter(id, cmd, s) {
 			return nil
@@ -217,6 +232,14 @@ func Events(ctx context.Context, p *path.Events, r *path.ResolveConfig) (*servic
 		if p.LastInFrame {
 			events = append(events, epLastInFrame...)
 		}
+		if io != nil {
+			events = append(events, &service.Event{
+				Kind:       service.EventKind_IOActivity,
+				Command:    p.Capture.Command(uint64(id)),
+				Timestamp:  getTime(cmd),
+				IoActivity: io,
+			})
+		}
 		if p.FramebufferObservations {
 			// NOTE: gapit SxS video depends on FBO events coming after
 			// all other event types.
@@ -241,3 +264,23 @@ func Events(ctx context.Context, p *path.Events, r *path.ResolveConfig) (*servic
 
 	return &service.Events{List: events}, nil
 }
+
+// ioActivity returns the I/O activity between the two samples of the I/O
+// counters. Counters that went backwards, e.g. because a network interface
+// was reset, are treated as having no activity.
+func ioActivity(prev, cur *capture.IOCounters) *service.FrameIOActivity {
+	delta := func(prev, cur uint64) uint64 {
+		if cur < prev {
+			return 0
+		}
+		return cur - prev
+	}
+	return &service.FrameIOActivity{
+		ReadChars:           delta(prev.ReadChars, cur.ReadChars),
+		WriteChars:          delta(prev.WriteChars, cur.WriteChars),
+		ReadBytes:           delta(prev.ReadBytes, cur.ReadBytes),
+		WriteBytes:          delta(prev.WriteBytes, cur.WriteBytes),
+		NetReceivedBytes:    delta(prev.NetReceivedBytes, cur.NetReceivedBytes),
+		NetTransmittedBytes: delta(prev.NetTransmittedBytes, cur.NetTransmittedBytes),
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service"
+)
+
+func TestIOActivity(t *testing.T) {
+	ctx := log.Testing(t)
+	prev := &capture.IOCounters{
+		ReadChars:           100,
+		WriteChars:          200,
+		ReadBytes:           4096,
+		WriteBytes:          0,
+		NetReceivedBytes:    1000,
+		NetTransmittedBytes: 500,
+	}
+	cur := &capture.IOCounters{
+		ReadChars:           150,
+		WriteChars:          200,
+		ReadBytes:           12288,
+		WriteBytes:          4096,
+		NetReceivedBytes:    200, // The interface was reset.
+		NetTransmittedBytes: 600,
+	}
+	assert.For(ctx, "activity").That(ioActivity(prev, cur)).DeepEquals(&service.FrameIOActivity{
+		ReadChars:           50,
+		WriteChars:          0,
+		ReadBytes:           8192,
+		WriteBytes:          4096,
+		NetReceivedBytes:    0,
+		NetTransmittedBytes: 100,
+	})
+}
@@ -0,0 +1,207 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/google/gapid/core/data/dictionary"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// ExportState returns the state of every API after the command after,
+// serialized as a JSON object with a member per API name.
+// Values with a constant set are written as the names of their constants.
+// The elements of memory slices are only written for slices of at most
+// maxMemorySize bytes. Values reachable through more than one reference are
+// written once, and as a {"$ref": <JSON pointer>} object everywhere else.
+func ExportState(ctx context.Context, after *path.Command, maxMemorySize uint64, r *path.ResolveConfig) ([]byte, error) {
+	if len(after.Indices) != 1 {
+		return nil, fmt.Errorf("Subcommands currently not supported for ExportState") // TODO: Subcommands
+	}
+	s, err := GlobalState(ctx, after.GlobalStateAfter(), r)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]interface{}{}
+	e := &stateExporter{
+		state:         s,
+		maxMemorySize: maxMemorySize,
+		r:             r,
+		seen:          map[interface{}]string{},
+	}
+	for apiID, state := range s.APIs {
+		a := api.Find(apiID)
+		if a == nil {
+			return nil, fmt.Errorf("Unknown API: %v", apiID)
+		}
+		e.api = &path.API{ID: path.NewID(id.ID(apiID))}
+		e.constants = map[int32]*service.ConstantSet{}
+		out[a.Name()] = e.value(ctx, reflect.ValueOf(state), "/"+jsonPointerEscape(a.Name()), nil)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// stateExporter converts state values into values that can be marshalled to
+// JSON.
+type stateExporter struct {
+	state         *api.GlobalState
+	api           *path.API
+	maxMemorySize uint64
+	r             *path.ResolveConfig
+	// seen maps the identities of the references already written to the JSON
+	// pointers of where they were written.
+	seen map[interface{}]string
+	// constants caches the constant sets of the API by index.
+	constants map[int32]*service.ConstantSet
+}
+
+// value returns the JSON value of v, which is written at the JSON pointer at
+// and has the constant set consts, which may be nil.
+func (e *stateExporter) value(ctx context.Context, v reflect.Value, at string, consts *path.ConstantSet) interface{} {
+	if !v.IsValid() || isNil(v) {
+		return nil
+	}
+
+	if key := referenceKey(v); key != nil {
+		if ref, ok := e.seen[key]; ok {
+			return map[string]interface{}{"$ref": ref}
+		}
+		e.seen[key] = at
+	}
+	v = deref(v)
+
+	t := v.Type()
+	switch {
+	case box.IsMemoryPointer(t):
+		return v.Interface().(memory.Pointer).Address()
+	case box.IsMemorySlice(t):
+		return e.slice(ctx, box.AsMemorySlice(v), at)
+	}
+
+	if dict := dictionary.From(v.Interface()); dict != nil {
+		out := map[string]interface{}{}
+		for _, k := range dict.Keys() {
+			name := fmt.Sprint(k)
+			out[name] = e.value(ctx, reflect.ValueOf(dict.Get(k)), at+"/"+jsonPointerEscape(name), nil)
+		}
+		return out
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if set := e.constantSet(ctx, consts); set != nil {
+			if name := set.Sprint(v.Interface()); name != fmt.Sprint(v.Interface()) {
+				return name
+			}
+		}
+		return v.Interface()
+	case reflect.Float32, reflect.Float64:
+		// NaNs and infinities cannot be represented by JSON numbers.
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Sprint(f)
+		}
+		return v.Interface()
+	case reflect.Bool, reflect.String:
+		return v.Interface()
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = e.value(ctx, v.Index(i), fmt.Sprintf("%v/%d", at, i), nil)
+		}
+		return out
+	}
+
+	if pp, ok := v.Interface().(api.PropertyProvider); ok {
+		out := map[string]interface{}{}
+		for _, p := range pp.Properties() {
+			var consts *path.ConstantSet
+			if p.Constants >= 0 {
+				consts = &path.ConstantSet{API: e.api, Index: int32(p.Constants)}
+			}
+			out[p.Name] = e.value(ctx, reflect.ValueOf(p.Get()), at+"/"+jsonPointerEscape(p.Name), consts)
+		}
+		return out
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+// slice returns the JSON value of the memory slice s, holding its elements if
+// it is no larger than the memory size limit.
+func (e *stateExporter) slice(ctx context.Context, s memory.Slice, at string) interface{} {
+	out := map[string]interface{}{
+		"base":  s.Base(),
+		"size":  s.Size(),
+		"count": s.Count(),
+		"pool":  uint32(s.Pool()),
+	}
+	if s.Size() > e.maxMemorySize {
+		return out
+	}
+	els, err := memory.LoadSlice(ctx, s, e.state.Memory, e.state.MemoryLayout)
+	if err != nil {
+		out["error"] = err.Error()
+		return out
+	}
+	out["elements"] = e.value(ctx, reflect.ValueOf(els), at+"/elements", nil)
+	return out
+}
+
+// constantSet returns the constant set at p, or nil if p is nil or the set
+// cannot be resolved.
+func (e *stateExporter) constantSet(ctx context.Context, p *path.ConstantSet) *service.ConstantSet {
+	if p == nil {
+		return nil
+	}
+	set, ok := e.constants[p.Index]
+	if !ok {
+		set, _ = ConstantSet(ctx, p, e.r)
+		e.constants[p.Index] = set
+	}
+	return set
+}
+
+// referenceKey returns the identity of the object referenced by v, or nil if v
+// is not a reference.
+func referenceKey(v reflect.Value) interface{} {
+	if r, ok := v.Interface().(api.Reference); ok {
+		if id := r.RefID(); id != api.NilRefID {
+			return id
+		}
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		return v.Pointer()
+	}
+	return nil
+}
+
+// jsonPointerEscape escapes s for use as a JSON pointer reference token.
+func jsonPointerEscape(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/memory"
+)
+
+func TestExportStateValue(t *testing.T) {
+	ctx := log.Testing(t)
+
+	s := &TestStruct{
+		Bool:   true,
+		Int:    3,
+		Float:  float32(math.Inf(1)),
+		String: "str",
+		Map:    map[int]string{1: "one", 2: "two"},
+		Array:  []int{4, 5},
+		Slice:  memory.NewSlice(0x1000, 0x1000, 0x100, 0x100, memory.ApplicationPool, intType),
+	}
+	s.Reference = s
+
+	e := &stateExporter{maxMemorySize: 0x10, seen: map[interface{}]string{}}
+	data, err := json.Marshal(e.value(ctx, reflect.ValueOf(s), "/test", nil))
+	if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "json").ThatString(string(data)).Equals(
+		`{"Array":[4,5],"Bool":true,"Float":"+Inf","Int":3,"Interface":null,` +
+			`"Map":{"1":"one","2":"two"},"Pointer":null,"Reference":{"$ref":"/test"},` +
+			`"Slice":{"base":4096,"count":256,"pool":0,"size":256},"String":"str"}`)
+}
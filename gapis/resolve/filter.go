@@ -66,6 +66,19 @@ func buildFilter(
 			return false
 		})
 	}
+	if fr := f.GetFrames(); fr != nil {
+		cmds, err := Cmds(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		start, end, err := framesCommandRange(ctx, p, fr, uint64(len(cmds)), p, r)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, func(id api.CmdID, cmd api.Cmd, s *api.GlobalState) bool {
+			return uint64(id) >= start && uint64(id) < end
+		})
+	}
 	if len(f.GetThreads()) > 0 {
 		filters = append(filters, func(id api.CmdID, cmd api.Cmd, s *api.GlobalState) bool {
 			thread := cmd.Thread()
@@ -19,11 +19,14 @@ import (
 	"fmt"
 
 	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
 	"github.com/google/gapid/gapis/service"
 	"github.com/google/gapid/gapis/service/path"
 )
 
 // Follow resolves the path to the object that the value at Path links to.
+// Memory pointers and slices link to the memory they reference, after the
+// command of Path.
 // If the value at Path does not link to anything then nil is returned.
 func Follow(ctx context.Context, p *path.Any, r *path.ResolveConfig) (*path.Any, error) {
 	obj, err := database.Build(ctx, &FollowResolvable{Path: p, Config: r})
@@ -35,17 +38,29 @@ func Follow(ctx context.Context, p *path.Any, r *path.ResolveConfig) (*path.Any,
 
 // Resolve implements the database.Resolver interface.
 func (r *FollowResolvable) Resolve(ctx context.Context) (interface{}, error) {
-	obj, err := ResolveInternal(ctx, r.Path.Node(), r.Config)
+	p, err := resolveStableCommand(ctx, r.Path.Node())
 	if err != nil {
 		return nil, err
 	}
 
+	obj, err := ResolveInternal(ctx, p, r.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if link, err := memoryLink(ctx, p, obj); link != nil || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		return link.Path(), nil
+	}
+
 	linker, ok := obj.(path.Linker)
 	if !ok {
 		return nil, &service.ErrPathNotFollowable{Path: r.Path}
 	}
 
-	link, err := linker.Link(ctx, r.Path.Node(), r.Config)
+	link, err := linker.Link(ctx, p, r.Config)
 	if err != nil {
 		return link, err
 	}
@@ -58,3 +73,35 @@ func (r *FollowResolvable) Resolve(ctx context.Context) (interface{}, error) {
 	}
 	return link.Path(), nil
 }
+
+// memoryLink returns the path to the memory referenced by obj, the value at p,
+// if obj is a non-null memory pointer or a non-empty memory slice and p is a
+// path below a command. Pointers link to the single element they point to, or
+// to a single byte if the size of the element is unknown.
+func memoryLink(ctx context.Context, p path.Node, obj interface{}) (*path.Memory, error) {
+	after := path.FindCommand(p)
+	if after == nil {
+		return nil, nil
+	}
+	switch obj := obj.(type) {
+	case memory.Slice:
+		if obj.Size() == 0 {
+			return nil, nil
+		}
+		return after.MemoryAfter(uint32(obj.Pool()), obj.Base(), obj.Size()), nil
+	case memory.Pointer:
+		if obj.IsNullptr() {
+			return nil, nil
+		}
+		layout, err := memoryLayout(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		size := obj.ElementSize(layout)
+		if size == 0 {
+			size = 1
+		}
+		return after.MemoryAfter(uint32(memory.ApplicationPool), obj.Address(), size), nil
+	}
+	return nil, nil
+}
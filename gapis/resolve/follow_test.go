@@ -0,0 +1,53 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestMemoryLink(t *testing.T) {
+	ctx := log.Testing(t)
+	cmd := path.NewCapture(id.ID{}).Command(3)
+	p := cmd.StateAfter().Field("Buffer")
+	u8 := reflect.TypeOf(uint8(0))
+
+	for _, test := range []struct {
+		name     string
+		path     path.Node
+		obj      interface{}
+		expected *path.Memory
+	}{
+		{"slice", p, memory.NewSlice(0x1000, 0x1010, 16, 16, memory.ApplicationPool, u8),
+			cmd.MemoryAfter(uint32(memory.ApplicationPool), 0x1010, 16)},
+		{"other pool", p, memory.NewSlice(0, 0x20, 4, 4, memory.PoolID(2), u8),
+			cmd.MemoryAfter(2, 0x20, 4)},
+		{"empty slice", p, memory.NewSlice(0x1000, 0x1000, 0, 0, memory.ApplicationPool, u8), nil},
+		{"no command", cmd.Capture, memory.NewSlice(0x1000, 0x1000, 4, 4, memory.ApplicationPool, u8), nil},
+		{"not memory", p, uint32(42), nil},
+	} {
+		got, err := memoryLink(ctx, test.path, test.obj)
+		if assert.For(ctx, "memoryLink(%v) err", test.name).ThatError(err).Succeeded() {
+			assert.For(ctx, "memoryLink(%v)", test.name).That(got).DeepEquals(test.expected)
+		}
+	}
+}
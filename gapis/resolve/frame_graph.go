@@ -0,0 +1,148 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// FrameGraph resolves and returns the graph of the passes of the frame of p,
+// and of the resources they access. Only commands of APIs implementing
+// api.FrameGraphProvider contribute passes. Commands after the last frame
+// boundary are considered part of the last frame.
+func FrameGraph(ctx context.Context, p *path.FrameGraph, r *path.ResolveConfig) (*api.FrameGraph, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := frameCommandRange(ctx, p.Capture, p.Frame, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b := api.NewFrameGraphBuilder()
+	err = api.ForeachCmd(ctx, cmds[:end], true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if g, ok := cmd.API().(api.FrameGraphProvider); ok && uint64(id) >= start {
+			if err := g.AddFrameGraphPasses(ctx, id, cmd, st, b); err != nil {
+				return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+			}
+		} else if err := cmd.Mutate(ctx, id, st, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := b.Graph()
+	for _, pass := range out.Passes {
+		pass.Command.Capture = p.Capture
+	}
+	return out, nil
+}
+
+// frameCommandRange returns the range [start, end) of the commands of the
+// given frame of the capture c, which has count commands. Commands after the
+// last frame boundary are considered part of the last frame. p is the path
+// reported if the frame is out of range.
+func frameCommandRange(ctx context.Context, c *path.Capture, frame uint32, count uint64, p path.Node, r *path.ResolveConfig) (start, end uint64, err error) {
+	return framesCommandRange(ctx, c, &path.FrameRange{First: frame, Count: 1}, count, p, r)
+}
+
+// framesCommandRange returns the range [start, end) of the commands of the
+// frames fr of the capture c, which has count commands. If fr is nil, all of
+// the commands are returned. Commands after the last frame boundary are
+// considered part of the last frame. p is the path reported if the frames are
+// out of range.
+func framesCommandRange(ctx context.Context, c *path.Capture, fr *path.FrameRange, count uint64, p path.Node, r *path.ResolveConfig) (start, end uint64, err error) {
+	if fr.GetFirst() == 0 && fr.GetCount() == 0 {
+		return 0, count, nil
+	}
+
+	events, err := Events(ctx, &path.Events{
+		Capture:     c,
+		LastInFrame: true,
+	}, r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	frames := uint64(len(events.List))
+	if frames == 0 {
+		frames = 1
+	}
+	first, last, err := frameRangeBounds(fr, frames, p)
+	if err != nil {
+		return 0, 0, err
+	}
+	start, end = 0, count
+	if first > 0 {
+		start = events.List[first-1].Command.Indices[0] + 1
+	}
+	if last < frames-1 {
+		end = events.List[last].Command.Indices[0] + 1
+	}
+	return start, end, nil
+}
+
+// frameRangeBounds returns the indices of the first and last of the frames fr
+// out of the given number of frames. p is the path reported if the frames are
+// out of range.
+func frameRangeBounds(fr *path.FrameRange, frames uint64, p path.Node) (first, last uint64, err error) {
+	first, last = uint64(fr.GetFirst()), frames-1
+	if first >= frames {
+		return 0, 0, errPathOOB(first, "First", 0, frames-1, p)
+	}
+	if n := uint64(fr.GetCount()); n > 0 && first+n-1 < last {
+		last = first + n - 1
+	}
+	return first, last, nil
+}
+
+// frameEnds returns the index of the last command of each of the frames
+// ended by the events.
+func frameEnds(events *service.Events) []uint64 {
+	out := make([]uint64, len(events.List))
+	for i, e := range events.List {
+		out[i] = e.Command.Indices[0]
+	}
+	return out
+}
+
+// frameOf returns the index of the frame holding the command id, given the
+// index of the last command of each frame. Commands after the last frame
+// boundary are part of the last frame. As the frame only depends on id,
+// commands may be skipped, including the last command of a frame.
+func frameOf(ends []uint64, id api.CmdID) int {
+	i := sort.Search(len(ends), func(i int) bool { return ends[i] >= uint64(id) })
+	if i == len(ends) && i > 0 {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestFrameRangeBounds(t *testing.T) {
+	ctx := log.Testing(t)
+	p := &path.Capture{}
+	for _, test := range []struct {
+		name        string
+		fr          *path.FrameRange
+		first, last uint64
+		fails       bool
+	}{
+		{"nil", nil, 0, 9, false},
+		{"all", &path.FrameRange{}, 0, 9, false},
+		{"from", &path.FrameRange{First: 4}, 4, 9, false},
+		{"inside", &path.FrameRange{First: 2, Count: 3}, 2, 4, false},
+		{"clamped", &path.FrameRange{First: 8, Count: 5}, 8, 9, false},
+		{"last", &path.FrameRange{First: 9, Count: 1}, 9, 9, false},
+		{"out of range", &path.FrameRange{First: 10}, 0, 0, true},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		first, last, err := frameRangeBounds(test.fr, 10, p)
+		if test.fails {
+			assert.For(ctx, "err").ThatError(err).Failed()
+			continue
+		}
+		if assert.For(ctx, "err").ThatError(err).Succeeded() {
+			assert.For(ctx, "first").That(first).Equals(test.first)
+			assert.For(ctx, "last").That(last).Equals(test.last)
+		}
+	}
+}
+
+func TestFrameOf(t *testing.T) {
+	ctx := log.Testing(t)
+	ends := []uint64{2, 4, 6}
+	for _, test := range []struct {
+		id       api.CmdID
+		expected int
+	}{
+		{0, 0},
+		{2, 0},
+		{3, 1},
+		{4, 1},
+		{6, 2},
+		{9, 2}, // After the last frame boundary.
+	} {
+		assert.For(ctx, "frameOf(%v)", test.id).That(frameOf(ends, test.id)).Equals(test.expected)
+	}
+	assert.For(ctx, "no frames").That(frameOf(nil, 5)).Equals(0)
+}
@@ -0,0 +1,167 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// defaultVsyncPeriod is the vsync period, in ns, of a 60Hz display.
+const defaultVsyncPeriod = 16666667
+
+// FramePacing resolves and returns the swapchain presents of the capture of p,
+// with the time spent acquiring each presented image and the interval between
+// the presents of each swapchain, flagging the presents that missed a vertical
+// sync or stalled acquiring their image. The times are taken from the
+// timestamps recorded at capture time. Only commands of APIs implementing
+// api.PresentAnalyzer are analyzed. If p.Frames is set, only the presents of
+// those frames are returned, though their intervals and acquires may span the
+// previous frame.
+func FramePacing(ctx context.Context, p *path.FramePacing, r *path.ResolveConfig) (*api.FramePacing, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := framesCommandRange(ctx, p.Capture, p.Frames, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	period := p.VsyncPeriod
+	if period == 0 {
+		period = defaultVsyncPeriod
+	}
+	fp := newFramePacing(period)
+	fp.from = api.CmdID(start)
+	err = api.ForeachCmd(ctx, cmds[:end], true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		ts := captureTime(cmd)
+		fp.command(cmd.Thread(), ts)
+		if a, ok := cmd.API().(api.PresentAnalyzer); ok {
+			acquire := func(swapchain uint64, image uint32) {
+				fp.acquire(id, cmd.Thread(), ts, swapchainImage{swapchain, image})
+			}
+			present := func(sp *api.SwapchainPresent) {
+				fp.present(id, ts, sp)
+			}
+			if err := a.AnalyzePresents(ctx, id, cmd, st, acquire, present); err != nil {
+				return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+			}
+		} else if err := cmd.Mutate(ctx, id, st, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sp := range fp.out.Presents {
+		sp.Command.Capture = p.Capture
+		if sp.Acquire != nil {
+			sp.Acquire.Capture = p.Capture
+		}
+	}
+	return fp.out, nil
+}
+
+// swapchainImage identifies a single image of a swapchain.
+type swapchainImage struct {
+	swapchain uint64
+	image     uint32
+}
+
+// acquiredImage is the acquire of a swapchain image that is yet to be
+// presented.
+type acquiredImage struct {
+	cmd  api.CmdID
+	ts   uint64
+	wait uint64
+}
+
+// framePacing builds an api.FramePacing from the acquires and presents of the
+// commands of a capture, in command order.
+type framePacing struct {
+	out         *api.FramePacing
+	acquired    map[swapchainImage]*acquiredImage
+	pending     map[uint64]*acquiredImage // By thread, awaiting the next command.
+	lastPresent map[uint64]uint64         // By swapchain.
+	from        api.CmdID                 // Earlier presents are not reported.
+}
+
+func newFramePacing(period uint64) *framePacing {
+	return &framePacing{
+		out: &api.FramePacing{
+			Presents:    []*api.SwapchainPresent{},
+			VsyncPeriod: period,
+		},
+		acquired:    map[swapchainImage]*acquiredImage{},
+		pending:     map[uint64]*acquiredImage{},
+		lastPresent: map[uint64]uint64{},
+	}
+}
+
+// command ends the wait of the acquire made by the previous command of the
+// thread, if any, with the time of the thread's next command.
+func (f *framePacing) command(thread, ts uint64) {
+	if a, ok := f.pending[thread]; ok {
+		if a.ts != 0 && ts >= a.ts {
+			a.wait = ts - a.ts
+		}
+		delete(f.pending, thread)
+	}
+}
+
+func (f *framePacing) acquire(id api.CmdID, thread, ts uint64, img swapchainImage) {
+	a := &acquiredImage{cmd: id, ts: ts}
+	f.acquired[img] = a
+	f.pending[thread] = a
+}
+
+func (f *framePacing) present(id api.CmdID, ts uint64, sp *api.SwapchainPresent) {
+	sp.Command = &path.Command{Indices: []uint64{uint64(id)}}
+	img := swapchainImage{sp.Swapchain, sp.ImageIndex}
+	if a, ok := f.acquired[img]; ok {
+		sp.Acquire = &path.Command{Indices: []uint64{uint64(a.cmd)}}
+		sp.AcquireWait = a.wait
+		delete(f.acquired, img)
+	}
+	if last, ok := f.lastPresent[sp.Swapchain]; ok && last != 0 && ts >= last {
+		sp.PresentInterval = ts - last
+	}
+	f.lastPresent[sp.Swapchain] = ts
+	if id < f.from {
+		return
+	}
+
+	period := f.out.VsyncPeriod
+	if sp.MissedVsync = sp.PresentInterval > period+period/2; sp.MissedVsync {
+		f.out.MissedVsyncs++
+	}
+	if sp.AcquireStall = sp.AcquireWait > period/2; sp.AcquireStall {
+		f.out.AcquireStalls++
+	}
+	f.out.Presents = append(f.out.Presents, sp)
+}
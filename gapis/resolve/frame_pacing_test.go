@@ -0,0 +1,68 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+func TestFramePacing(t *testing.T) {
+	ctx := log.Testing(t)
+	const ms = 1000000
+	f := newFramePacing(defaultVsyncPeriod)
+	present := func(id api.CmdID, ts uint64, image uint32) *api.SwapchainPresent {
+		sp := &api.SwapchainPresent{Swapchain: 1, ImageIndex: image}
+		f.present(id, ts, sp)
+		return sp
+	}
+
+	// Frame 0: a short acquire.
+	f.command(1, 1*ms)
+	f.acquire(0, 1, 1*ms, swapchainImage{1, 0})
+	f.command(1, 2*ms)
+	p0 := present(1, 10*ms, 0)
+
+	// Frame 1: on time, with an acquire stall.
+	f.command(1, 11*ms)
+	f.acquire(2, 1, 11*ms, swapchainImage{1, 1})
+	f.command(2, 12*ms) // Another thread does not end the wait.
+	f.command(1, 21*ms)
+	p1 := present(3, 26*ms, 1)
+
+	// Frame 2: misses a vsync, presenting an image acquired before the capture.
+	f.command(1, 60*ms)
+	p2 := present(4, 60*ms, 2)
+
+	assert.For(ctx, "p0.Acquire").That(p0.Acquire.Indices).DeepEquals([]uint64{0})
+	assert.For(ctx, "p0.AcquireWait").That(p0.AcquireWait).Equals(uint64(1 * ms))
+	assert.For(ctx, "p0.PresentInterval").That(p0.PresentInterval).Equals(uint64(0))
+	assert.For(ctx, "p0.AcquireStall").That(p0.AcquireStall).Equals(false)
+
+	assert.For(ctx, "p1.AcquireWait").That(p1.AcquireWait).Equals(uint64(10 * ms))
+	assert.For(ctx, "p1.PresentInterval").That(p1.PresentInterval).Equals(uint64(16 * ms))
+	assert.For(ctx, "p1.AcquireStall").That(p1.AcquireStall).Equals(true)
+	assert.For(ctx, "p1.MissedVsync").That(p1.MissedVsync).Equals(false)
+
+	assert.For(ctx, "p2.Acquire").That(p2.Acquire).IsNil()
+	assert.For(ctx, "p2.PresentInterval").That(p2.PresentInterval).Equals(uint64(34 * ms))
+	assert.For(ctx, "p2.MissedVsync").That(p2.MissedVsync).Equals(true)
+
+	assert.For(ctx, "MissedVsyncs").That(f.out.MissedVsyncs).Equals(uint32(1))
+	assert.For(ctx, "AcquireStalls").That(f.out.AcquireStalls).Equals(uint32(1))
+}
@@ -0,0 +1,169 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// FrameRedundancy resolves and returns the counts of the commands, binding
+// commands and command buffer recordings of each frame of the capture of p
+// that are identical to those of the previous frame. Commands are compared by
+// name, parameters and observed data, ignoring their order within the frame.
+// Bindings and command buffers are only counted for APIs implementing
+// api.FrameRedundancyClassifier. Commands after the last frame boundary are
+// reported as part of the last frame. If p.Frames is set, only those frames
+// are reported.
+func FrameRedundancy(ctx context.Context, p *path.FrameRedundancy, r *path.ResolveConfig) (*service.FrameRedundancy, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := Events(ctx, &path.Events{
+		Capture:     p.Capture,
+		LastInFrame: true,
+	}, r)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &service.FrameRedundancy{Frames: []*service.FrameRedundancyStats{}}
+	prev, curr := newFrameWork(), newFrameWork()
+	endFrame := func() {
+		curr.endRecordings()
+		out.Frames = append(out.Frames, curr.stats(prev))
+		prev, curr = curr, newFrameWork()
+	}
+
+	ends := frameEnds(events)
+	frame := 0
+	for i, cmd := range cmds {
+		cmdID := api.CmdID(i)
+		for ; frame < frameOf(ends, cmdID); frame++ {
+			endFrame()
+		}
+		sig := cmdSignature(cmd)
+		curr.commands[sig]++
+		if c, ok := cmd.API().(api.FrameRedundancyClassifier); ok {
+			if c.IsBinding(cmd) {
+				curr.bindings[sig]++
+			}
+			if buffer, begin, ok := c.RecordsInto(cmd); ok {
+				curr.record(buffer, begin, sig)
+			}
+		}
+	}
+	for ; frame < len(ends)-1; frame++ {
+		endFrame()
+	}
+	endFrame()
+
+	first, last, err := frameRangeBounds(p.Frames, uint64(len(out.Frames)), p)
+	if err != nil {
+		return nil, err
+	}
+	out.Frames = out.Frames[first : last+1]
+	return out, nil
+}
+
+// frameWork holds the signatures of the work of a single frame.
+type frameWork struct {
+	commands   map[id.ID]uint64
+	bindings   map[id.ID]uint64
+	recordings map[id.ID]uint64
+	// The signatures of the commands recorded so far into each command buffer
+	// being recorded.
+	recording map[uint64][]byte
+}
+
+func newFrameWork() *frameWork {
+	return &frameWork{
+		commands:   map[id.ID]uint64{},
+		bindings:   map[id.ID]uint64{},
+		recordings: map[id.ID]uint64{},
+		recording:  map[uint64][]byte{},
+	}
+}
+
+// record adds the command with the signature sig to the recording of the
+// command buffer buffer, first ending the buffer's recording if begin is true.
+func (f *frameWork) record(buffer uint64, begin bool, sig id.ID) {
+	if begin {
+		f.endRecording(buffer)
+	}
+	f.recording[buffer] = append(f.recording[buffer], sig[:]...)
+}
+
+// endRecording ends the recording of the command buffer buffer, if any.
+func (f *frameWork) endRecording(buffer uint64) {
+	if sigs, ok := f.recording[buffer]; ok {
+		f.recordings[id.OfBytes(sigs)]++
+		delete(f.recording, buffer)
+	}
+}
+
+// endRecordings ends the recordings of all the command buffers.
+func (f *frameWork) endRecordings() {
+	for buffer := range f.recording {
+		f.endRecording(buffer)
+	}
+}
+
+// stats returns the counts of the work of f, and of the work identical to
+// that of the previous frame prev.
+func (f *frameWork) stats(prev *frameWork) *service.FrameRedundancyStats {
+	out := &service.FrameRedundancyStats{}
+	out.Commands, out.IdenticalCommands = countIdentical(f.commands, prev.commands)
+	out.Bindings, out.IdenticalBindings = countIdentical(f.bindings, prev.bindings)
+	out.RecordedCommandBuffers, out.RerecordedCommandBuffers = countIdentical(f.recordings, prev.recordings)
+	return out
+}
+
+// countIdentical returns the total number of signatures of curr, and the
+// number of them that are matched by a signature of prev.
+func countIdentical(curr, prev map[id.ID]uint64) (total, identical uint64) {
+	for sig, count := range curr {
+		total += count
+		if p := prev[sig]; p < count {
+			identical += p
+		} else {
+			identical += count
+		}
+	}
+	return total, identical
+}
+
+// cmdSignature returns an identifier of the command's name, parameters and
+// observed data.
+func cmdSignature(cmd api.Cmd) id.ID {
+	parts := []string{cmd.CmdName()}
+	for _, p := range cmd.CmdParams() {
+		parts = append(parts, fmt.Sprint(p.Get()))
+	}
+	if o := cmd.Extras().Observations(); o != nil {
+		for _, read := range o.Reads {
+			parts = append(parts, read.ID.String())
+		}
+	}
+	return id.OfString(strings.Join(parts, "\x00"))
+}
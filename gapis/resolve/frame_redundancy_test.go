@@ -0,0 +1,76 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+)
+
+func TestFrameRedundancy(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	p := newPathTest(ctx)
+	ctx = capture.Put(ctx, p)
+
+	got, err := FrameRedundancy(ctx, p.FrameRedundancy(), nil)
+	if assert.For(ctx, "err").ThatError(err).Succeeded() {
+		assert.For(ctx, "frames").That(got.Frames).DeepEquals([]*service.FrameRedundancyStats{
+			{Commands: 3},
+		})
+	}
+}
+
+func TestFrameWorkStats(t *testing.T) {
+	ctx := log.Testing(t)
+	a, b, c := id.OfString("a"), id.OfString("b"), id.OfString("c")
+
+	prev := newFrameWork()
+	prev.commands[a] = 2
+	prev.commands[b] = 1
+	prev.bindings[b] = 1
+	prev.record(1, true, a)
+	prev.record(1, false, b)
+	prev.record(2, true, c)
+	prev.endRecordings()
+
+	curr := newFrameWork()
+	curr.commands[a] = 3
+	curr.commands[c] = 1
+	curr.bindings[b] = 2
+	curr.record(3, true, a)
+	curr.record(3, false, b)
+	// Re-recording the same command buffer ends the previous recording.
+	curr.record(3, true, a)
+	curr.endRecordings()
+
+	assert.For(ctx, "stats").That(curr.stats(prev)).DeepEquals(&service.FrameRedundancyStats{
+		Commands:                 4,
+		IdenticalCommands:        2,
+		Bindings:                 2,
+		IdenticalBindings:        1,
+		RecordedCommandBuffers:   2,
+		RerecordedCommandBuffers: 1,
+	})
+}
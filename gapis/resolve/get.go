@@ -17,18 +17,61 @@ package resolve
 import (
 	"context"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/service"
 	"github.com/google/gapid/gapis/service/path"
 )
 
 // Get resolves the object, value or memory at p.
 func Get(ctx context.Context, p *path.Any, r *path.ResolveConfig) (interface{}, error) {
-	return database.Build(ctx, &GetResolvable{Path: p, Config: r})
+	v, err := database.Build(ctx, &GetResolvable{Path: p, Config: r})
+	if err != nil {
+		return nil, err
+	}
+	return withLatestProfile(path.FindCapture(p.Node()), v), nil
+}
+
+// withLatestProfile returns a copy of v with the GPU times of the latest
+// profile of the capture c added, or v itself if it has no GPU times or the
+// capture has not been profiled. The capture can be profiled again after v is
+// built, so the GPU times are added on each Get instead of being memoized.
+func withLatestProfile(c *path.Capture, v interface{}) interface{} {
+	if c == nil {
+		return v
+	}
+	profile := replay.LatestProfile(c)
+	if profile == nil {
+		return v
+	}
+	switch v := v.(type) {
+	case *service.PipelineStatistics:
+		out := proto.Clone(v).(*service.PipelineStatistics)
+		out.HasGpuTimes = addDrawGpuTimes(out.Draws, profile.Slices)
+		return out
+	case *service.CommandTreeNode:
+		out := proto.Clone(v).(*service.CommandTreeNode)
+		out.GpuTime = gpuTime(profile.Slices, out.Commands)
+		return out
+	case *api.SyncTimeline:
+		out := proto.Clone(v).(*api.SyncTimeline)
+		addSyncEventGpuTimes(out.Events, profile.Slices)
+		return out
+	}
+	return v
 }
 
 // Resolve implements the database.Resolver interface.
 func (r *GetResolvable) Resolve(ctx context.Context) (interface{}, error) {
 	c := path.FindCapture(r.Path.Node())
 	ctx = SetupContext(ctx, c, r.Config)
-	return ResolveService(ctx, r.Path.Node(), r.Config)
+	ctx = log.V{"path": r.Path.Node()}.Bind(ctx)
+	p, err := resolveStableCommand(ctx, r.Path.Node())
+	if err != nil {
+		return nil, err
+	}
+	return ResolveService(ctx, p, r.Config)
 }
@@ -216,8 +216,12 @@ func TestSet(t *testing.T) {
 		{cB.Parameter("Ptr"), test.Voidᵖ(0x2222222), nil},
 		// {cB.Result(), uint32(7), nil}, // TODO: 'Unknown path type *path.Result'
 
-		// Test the state cannot be mutated (current restriction)
-		{cA.StateAfter(), nil, fmt.Errorf("State can not currently be mutated")},
+		// Test the state can be mutated one value at a time
+		{sA.Field("Str"), "edited", nil},
+		{sB.Field("Ref").Field("Strings").MapIndex("new"), uint32(9), nil},
+		{cA.StateAfter(), nil, fmt.Errorf("State can only be mutated one value at a time")},
+		{(&path.GlobalState{After: cA}).Field("APIs").MapIndex(api.ID{1}).Field("Str"), "edited",
+			&service.ErrDataUnavailable{Reason: messages.ErrStateEditNotSupported()}},
 
 		// Test invalid paths
 		{p.Command(5), nil, &service.ErrInvalidPath{
@@ -0,0 +1,305 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/core/stream/fmts"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/vertex"
+)
+
+// glTF constants, as defined by the glTF 2.0 specification.
+const (
+	gltfFloat        = 5126
+	gltfUnsignedInt  = 5125
+	gltfArrayBuffer  = 34962
+	gltfElementArray = 34963
+)
+
+// gltfModes maps the draw primitives to the glTF primitive modes.
+var gltfModes = map[api.DrawPrimitive]int{
+	api.DrawPrimitive_Points:        0,
+	api.DrawPrimitive_Lines:         1,
+	api.DrawPrimitive_LineLoop:      2,
+	api.DrawPrimitive_LineStrip:     3,
+	api.DrawPrimitive_Triangles:     4,
+	api.DrawPrimitive_TriangleStrip: 5,
+	api.DrawPrimitive_TriangleFan:   6,
+}
+
+// gltfBuilder builds a glTF 2.0 document. The data of all the buffer views is
+// held by a single buffer, embedded in the document as a data URI.
+type gltfBuilder struct {
+	doc       gltfDocument
+	data      bytes.Buffer
+	materials map[uint64]int // Image handle to material index.
+}
+
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes,omitempty"`
+	Meshes      []gltfMesh       `json:"meshes,omitempty"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors,omitempty"`
+	BufferViews []gltfBufferView `json:"bufferViews,omitempty"`
+	Buffers     []gltfBuffer     `json:"buffers,omitempty"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes,omitempty"`
+}
+
+type gltfNode struct {
+	Name string `json:"name"`
+	Mesh int    `json:"mesh"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices,omitempty"`
+	Material   *int           `json:"material,omitempty"`
+	Mode       int            `json:"mode"`
+}
+
+type gltfMaterial struct {
+	Name string  `json:"name"`
+	PBR  gltfPBR `json:"pbrMetallicRoughness"`
+}
+
+type gltfPBR struct {
+	BaseColorTexture gltfTextureInfo `json:"baseColorTexture"`
+	MetallicFactor   float32         `json:"metallicFactor"`
+}
+
+type gltfTextureInfo struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Source int `json:"source"`
+}
+
+type gltfImage struct {
+	URI string `json:"uri"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target"`
+}
+
+type gltfBuffer struct {
+	ByteLength int    `json:"byteLength"`
+	URI        string `json:"uri"`
+}
+
+func newGLTFBuilder() *gltfBuilder {
+	return &gltfBuilder{
+		doc: gltfDocument{
+			Asset:  gltfAsset{Version: "2.0", Generator: "GAPID"},
+			Scenes: []gltfScene{{}},
+		},
+		materials: map[uint64]int{},
+	}
+}
+
+// addBufferView appends data to the buffer and returns the index of a new
+// buffer view of it.
+func (b *gltfBuilder) addBufferView(data []byte, target int) int {
+	// Keep each view 4-byte aligned, as required for float and uint accessors.
+	for b.data.Len()%4 != 0 {
+		b.data.WriteByte(0)
+	}
+	b.doc.BufferViews = append(b.doc.BufferViews, gltfBufferView{
+		ByteOffset: b.data.Len(),
+		ByteLength: len(data),
+		Target:     target,
+	})
+	b.data.Write(data)
+	return len(b.doc.BufferViews) - 1
+}
+
+// addAccessor returns the index of a new accessor of the buffer view.
+func (b *gltfBuilder) addAccessor(a gltfAccessor) int {
+	b.doc.Accessors = append(b.doc.Accessors, a)
+	return len(b.doc.Accessors) - 1
+}
+
+// material returns the index of the material using the image with the given
+// handle as its base color, or -1 if no material uses the image.
+func (b *gltfBuilder) material(handle uint64) int {
+	if i, ok := b.materials[handle]; ok {
+		return i
+	}
+	return -1
+}
+
+// addMaterial returns the index of a new material using the PNG image png,
+// with the given handle, as its base color.
+func (b *gltfBuilder) addMaterial(handle uint64, png []byte) int {
+	b.doc.Images = append(b.doc.Images, gltfImage{
+		URI: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	})
+	b.doc.Textures = append(b.doc.Textures, gltfTexture{Source: len(b.doc.Images) - 1})
+	b.doc.Materials = append(b.doc.Materials, gltfMaterial{
+		Name: fmt.Sprintf("image 0x%x", handle),
+		PBR:  gltfPBR{BaseColorTexture: gltfTextureInfo{Index: len(b.doc.Textures) - 1}},
+	})
+	b.materials[handle] = len(b.doc.Materials) - 1
+	return b.materials[handle]
+}
+
+// addMesh adds the mesh m to the scene, as a node with the given name. The
+// mesh uses the material with the given index, or no material if -1.
+// Only the position, normal and first texture coordinate streams of m are
+// added.
+func (b *gltfBuilder) addMesh(name string, m *api.Mesh, material int) error {
+	mode, ok := gltfModes[m.DrawPrimitive]
+	if !ok {
+		return fmt.Errorf("Unsupported draw primitive %v", m.DrawPrimitive)
+	}
+
+	prim := gltfPrimitive{Attributes: map[string]int{}, Mode: mode}
+	for _, s := range m.GetVertexBuffer().GetStreams() {
+		var attr, ty string
+		var f *stream.Format
+		switch s.GetSemantic().GetType() {
+		case vertex.Semantic_Position:
+			attr, ty, f = "POSITION", "VEC3", fmts.XYZ_F32
+		case vertex.Semantic_Normal:
+			attr, ty, f = "NORMAL", "VEC3", fmts.XYZ_F32
+		case vertex.Semantic_Texcoord:
+			attr, ty, f = "TEXCOORD_0", "VEC2", fmts.XY_F32
+		default:
+			continue
+		}
+		if _, ok := prim.Attributes[attr]; ok {
+			continue
+		}
+		data, err := stream.Convert(f, s.Format, s.Data)
+		if err != nil {
+			if attr == "POSITION" {
+				return err
+			}
+			continue
+		}
+		a := gltfAccessor{
+			ComponentType: gltfFloat,
+			Count:         len(data) / f.Stride(),
+			Type:          ty,
+		}
+		if attr == "POSITION" {
+			a.Min, a.Max = gltfBounds(data)
+		}
+		a.BufferView = b.addBufferView(data, gltfArrayBuffer)
+		prim.Attributes[attr] = b.addAccessor(a)
+	}
+	pos, ok := prim.Attributes["POSITION"]
+	if !ok {
+		return fmt.Errorf("Mesh has no position stream")
+	}
+	if b.doc.Accessors[pos].Count == 0 {
+		return fmt.Errorf("Mesh has no vertices")
+	}
+
+	if indices := m.GetIndexBuffer().GetIndices(); len(indices) > 0 {
+		buf := &bytes.Buffer{}
+		w := endian.Writer(buf, device.LittleEndian)
+		for _, i := range indices {
+			w.Uint32(i)
+		}
+		i := b.addAccessor(gltfAccessor{
+			BufferView:    b.addBufferView(buf.Bytes(), gltfElementArray),
+			ComponentType: gltfUnsignedInt,
+			Count:         len(indices),
+			Type:          "SCALAR",
+		})
+		prim.Indices = &i
+	}
+	if material >= 0 {
+		prim.Material = &material
+	}
+
+	b.doc.Meshes = append(b.doc.Meshes, gltfMesh{Name: name, Primitives: []gltfPrimitive{prim}})
+	b.doc.Nodes = append(b.doc.Nodes, gltfNode{Name: name, Mesh: len(b.doc.Meshes) - 1})
+	b.doc.Scenes[0].Nodes = append(b.doc.Scenes[0].Nodes, len(b.doc.Nodes)-1)
+	return nil
+}
+
+// build returns the glTF document as JSON.
+func (b *gltfBuilder) build() ([]byte, error) {
+	b.doc.Buffers = nil
+	if b.data.Len() > 0 {
+		b.doc.Buffers = []gltfBuffer{{
+			ByteLength: b.data.Len(),
+			URI:        "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(b.data.Bytes()),
+		}}
+	}
+	return json.Marshal(&b.doc)
+}
+
+// gltfBounds returns the per-component minimum and maximum of the XYZ_F32
+// vertices of data.
+func gltfBounds(data []byte) (min, max []float32) {
+	min = []float32{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+	max = []float32{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	r := endian.Reader(bytes.NewReader(data), device.LittleEndian)
+	for i := 0; i < len(data)/12; i++ {
+		for j := 0; j < 3; j++ {
+			v := r.Float32()
+			if v < min[j] {
+				min[j] = v
+			}
+			if v > max[j] {
+				max[j] = v
+			}
+		}
+	}
+	return min, max
+}
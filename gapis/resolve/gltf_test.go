@@ -0,0 +1,100 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/stream/fmts"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/vertex"
+)
+
+func TestGLTFBuilder(t *testing.T) {
+	ctx := log.Testing(t)
+
+	positions := float32sToBytes([]float32{
+		0, 0, 0,
+		1, 0, -2,
+		0, 3, 0,
+	})
+	mesh := &api.Mesh{
+		DrawPrimitive: api.DrawPrimitive_Triangles,
+		VertexBuffer: &vertex.Buffer{Streams: []*vertex.Stream{{
+			Name:     "position",
+			Data:     positions,
+			Format:   fmts.XYZ_F32,
+			Semantic: &vertex.Semantic{Type: vertex.Semantic_Position},
+		}}},
+		IndexBuffer: &api.IndexBuffer{Indices: []uint32{0, 1, 2}},
+	}
+
+	b := newGLTFBuilder()
+	material := b.addMaterial(0x10, []byte{1, 2, 3})
+	assert.For(ctx, "material").That(b.material(0x10)).Equals(material)
+	assert.For(ctx, "missing material").That(b.material(0x20)).Equals(-1)
+
+	assert.For(ctx, "err").ThatError(b.addMesh("draw", mesh, material)).Succeeded()
+	assert.For(ctx, "no positions").ThatError(b.addMesh("empty", &api.Mesh{
+		DrawPrimitive: api.DrawPrimitive_Triangles,
+	}, -1)).Failed()
+
+	data, err := b.build()
+	if !assert.For(ctx, "build").ThatError(err).Succeeded() {
+		return
+	}
+	doc := gltfDocument{}
+	if !assert.For(ctx, "unmarshal").ThatError(json.Unmarshal(data, &doc)).Succeeded() {
+		return
+	}
+
+	assert.For(ctx, "version").That(doc.Asset.Version).Equals("2.0")
+	assert.For(ctx, "scene nodes").That(doc.Scenes[0].Nodes).DeepEquals([]int{0})
+	assert.For(ctx, "meshes").That(len(doc.Meshes)).Equals(1)
+	prim := doc.Meshes[0].Primitives[0]
+	assert.For(ctx, "mode").That(prim.Mode).Equals(4)
+	assert.For(ctx, "material").That(*prim.Material).Equals(material)
+
+	pos := doc.Accessors[prim.Attributes["POSITION"]]
+	assert.For(ctx, "position count").That(pos.Count).Equals(3)
+	assert.For(ctx, "position min").That(pos.Min).DeepEquals([]float32{0, 0, -2})
+	assert.For(ctx, "position max").That(pos.Max).DeepEquals([]float32{1, 3, 0})
+
+	indices := doc.Accessors[*prim.Indices]
+	assert.For(ctx, "index count").That(indices.Count).Equals(3)
+	assert.For(ctx, "index type").That(indices.ComponentType).Equals(gltfUnsignedInt)
+
+	// 36 bytes of positions, followed by 12 bytes of indices.
+	assert.For(ctx, "index view").That(doc.BufferViews[indices.BufferView]).Equals(gltfBufferView{
+		ByteOffset: 36,
+		ByteLength: 12,
+		Target:     gltfElementArray,
+	})
+	assert.For(ctx, "buffer length").That(doc.Buffers[0].ByteLength).Equals(48)
+}
+
+// float32sToBytes returns the little-endian encoding of v.
+func float32sToBytes(v []float32) []byte {
+	out := make([]byte, 0, len(v)*4)
+	for _, f := range v {
+		bits := math.Float32bits(f)
+		out = append(out, byte(bits), byte(bits>>8), byte(bits>>16), byte(bits>>24))
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// handleCreators maps each object handle to the ascending identifiers of the
+// commands that created an object with the handle. A handle has more than one
+// creator if it is reused once its object is destroyed.
+// Handles are matched by their type and value, so handles that are only
+// unique within a context, such as the GLES object names, may have creators
+// from several contexts.
+type handleCreators map[interface{}][]api.CmdID
+
+// resolveHandleCreators resolves the commands that created the object handles
+// of the capture c.
+func resolveHandleCreators(ctx context.Context, c *path.Capture, r *path.ResolveConfig) (handleCreators, error) {
+	obj, err := database.Build(ctx, &HandleCreatorsResolvable{Capture: c, Config: r})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(handleCreators), nil
+}
+
+// creator returns the identifier of the last command, up to and including the
+// command after, that created an object with the handle h, and true, or false
+// if no such command exists, as is the case for the objects of the initial
+// state.
+func (c handleCreators) creator(h interface{}, after api.CmdID) (api.CmdID, bool) {
+	ids := c[h]
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] > after })
+	if i == 0 {
+		return 0, false
+	}
+	return ids[i-1], true
+}
+
+// Resolve implements the database.Resolver interface.
+func (r *HandleCreatorsResolvable) Resolve(ctx context.Context) (interface{}, error) {
+	ctx = SetupContext(ctx, r.Capture, r.Config)
+
+	c, err := capture.ResolveGraphics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &handleCreatorsWatcher{creators: handleCreators{}}
+	s := c.NewState(ctx)
+	err = api.ForeachCmd(ctx, c.Commands, true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if err := cmd.Mutate(ctx, id, s, nil, w); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w.creators, nil
+}
+
+// handleCreatorsWatcher is an api.StateWatcher that records the command that
+// creates each object handle. The state maps of handles open a forward
+// dependency, identified by the handle, whenever a handle is added to them.
+type handleCreatorsWatcher struct {
+	creators handleCreators
+	cmd      api.CmdID
+}
+
+func (w *handleCreatorsWatcher) OnBeginCmd(ctx context.Context, id api.CmdID, cmd api.Cmd) {
+	w.cmd = id
+}
+
+func (w *handleCreatorsWatcher) OpenForwardDependency(ctx context.Context, dependencyID interface{}) {
+	ids := w.creators[dependencyID]
+	if n := len(ids); n == 0 || ids[n-1] != w.cmd {
+		w.creators[dependencyID] = append(ids, w.cmd)
+	}
+}
+
+func (w *handleCreatorsWatcher) OnEndCmd(ctx context.Context, id api.CmdID, cmd api.Cmd) {
+}
+func (w *handleCreatorsWatcher) OnBeginSubCmd(ctx context.Context, subIdx api.SubCmdIdx, recordIdx api.RecordIdx) {
+}
+func (w *handleCreatorsWatcher) OnRecordSubCmd(ctx context.Context, recordIdx api.RecordIdx) {
+}
+func (w *handleCreatorsWatcher) OnEndSubCmd(ctx context.Context) {
+}
+func (w *handleCreatorsWatcher) OnReadFrag(ctx context.Context, owner api.RefObject, frag api.Fragment, valueRef api.RefObject, track bool) {
+}
+func (w *handleCreatorsWatcher) OnWriteFrag(ctx context.Context, owner api.RefObject, frag api.Fragment, oldValueRef api.RefObject, newValueRef api.RefObject, track bool) {
+}
+func (w *handleCreatorsWatcher) OnWriteSlice(ctx context.Context, slice memory.Slice) {
+}
+func (w *handleCreatorsWatcher) OnReadSlice(ctx context.Context, slice memory.Slice) {
+}
+func (w *handleCreatorsWatcher) OnWriteObs(ctx context.Context, observations []api.CmdObservation) {
+}
+func (w *handleCreatorsWatcher) OnReadObs(ctx context.Context, observations []api.CmdObservation) {
+}
+func (w *handleCreatorsWatcher) CloseForwardDependency(ctx context.Context, dependencyID interface{}) {
+}
+func (w *handleCreatorsWatcher) DropForwardDependency(ctx context.Context, dependencyID interface{}) {
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+)
+
+func TestHandleCreators(t *testing.T) {
+	ctx := log.Testing(t)
+	type image uint64
+	type buffer uint64
+
+	w := &handleCreatorsWatcher{creators: handleCreators{}}
+	for _, c := range []struct {
+		id      api.CmdID
+		handles []interface{}
+	}{
+		{2, []interface{}{image(1), buffer(1)}},
+		{5, []interface{}{image(2), image(2)}},
+		{9, []interface{}{image(1)}}, // Reused once destroyed.
+	} {
+		w.OnBeginCmd(ctx, c.id, nil)
+		for _, h := range c.handles {
+			w.OpenForwardDependency(ctx, h)
+		}
+	}
+	assert.For(ctx, "image(2) creators").ThatSlice(w.creators[image(2)]).Equals([]api.CmdID{5})
+
+	for _, test := range []struct {
+		handle   interface{}
+		after    api.CmdID
+		expected api.CmdID
+		ok       bool
+	}{
+		{image(1), 1, 0, false},
+		{image(1), 2, 2, true},
+		{image(1), 8, 2, true},
+		{image(1), 9, 9, true},
+		{image(1), 20, 9, true},
+		{buffer(1), 20, 2, true},
+		{image(2), 4, 0, false},
+		{image(2), 5, 5, true},
+		{image(3), 20, 0, false},
+		{uint64(1), 20, 0, false},
+	} {
+		id, ok := w.creators.creator(test.handle, test.after)
+		assert.For(ctx, "creator(%T(%v), %v)", test.handle, test.handle, test.after).That(ok).Equals(test.ok)
+		assert.For(ctx, "creator(%T(%v), %v)", test.handle, test.handle, test.after).That(id).Equals(test.expected)
+	}
+}
+
+func TestResolveHandleCreators(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	a := arena.New()
+	cb := test.CommandBuilder{Arena: a}
+	cmds := []api.Cmd{
+		cb.CmdCreateHandle(1),
+		cb.CmdVoid(),
+		cb.CmdCreateHandle(2),
+		cb.CmdDestroyHandle(1),
+		cb.CmdCreateHandle(1),
+		cb.CmdCreateHandle(2), // Already created, not a new creator.
+	}
+	h := &capture.Header{ABI: device.WindowsX86_64}
+	c, err := capture.NewGraphicsCapture(ctx, a, "handles", h, nil, cmds)
+	if err != nil {
+		log.F(ctx, true, "Couldn't create capture: %v", err)
+	}
+	p, err := c.Path(ctx)
+	if err != nil {
+		log.F(ctx, true, "Couldn't get capture path: %v", err)
+	}
+
+	creators, err := resolveHandleCreators(ctx, p, nil)
+	if assert.For(ctx, "err").ThatError(err).Succeeded() {
+		assert.For(ctx, "creators").That(creators).DeepEquals(handleCreators{
+			uint32(1): []api.CmdID{0, 4},
+			uint32(2): []api.CmdID{2},
+		})
+	}
+}
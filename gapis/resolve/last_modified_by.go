@@ -0,0 +1,200 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/sync"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// LastModifiedBy resolves and returns the path to the most recent command that
+// modified the state value at p.Value.
+// The commands are mutated in order, and the value is compared after each
+// command to the value before it. Memory referenced by the value is not
+// considered, only the value itself.
+func LastModifiedBy(ctx context.Context, p *path.LastModifiedBy, r *path.ResolveConfig) (*path.Command, error) {
+	nodes, after, err := globalStateRelative(ctx, p.Value.Node(), r)
+	if err != nil {
+		return nil, err
+	}
+
+	var last *path.Command
+	err = foreachStateChange(ctx, after, nodes, r, func(id api.CmdID, v *box.Value) error {
+		last = after.Capture.Command(uint64(id))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if last == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrValueNotModified()}
+	}
+	return last, nil
+}
+
+// globalStateRelative returns the list of path nodes that lead from the
+// GlobalState to the value at p, along with the command the state is after.
+// If p is rooted at an API State, then the State node is replaced with the
+// path to the API state in the GlobalState.
+func globalStateRelative(ctx context.Context, p path.Node, r *path.ResolveConfig) ([]path.Node, *path.Command, error) {
+	if s := findState(p); s != nil {
+		_, root, _, err := state(ctx, s, r)
+		if err != nil {
+			return nil, nil, err
+		}
+		p = replaceState(p, root)
+	}
+
+	nodes, g := stateNodes(p)
+	if g == nil {
+		return nil, nil, &service.ErrInvalidPath{
+			Reason: messages.ErrNotAStatePath(),
+			Path:   p.Path(),
+		}
+	}
+	return nodes, g.After, nil
+}
+
+// stateNodes returns the list of path nodes that lead from the GlobalState to
+// the value at p, along with the GlobalState path node. If p is not a path to
+// a value in a GlobalState, then stateNodes returns nil.
+func stateNodes(p path.Node) ([]path.Node, *path.GlobalState) {
+	nodes := []path.Node{}
+	for n := p; n != nil; n = n.Parent() {
+		switch n := n.(type) {
+		case *path.GlobalState:
+			for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+				nodes[i], nodes[j] = nodes[j], nodes[i]
+			}
+			return nodes, n
+		case *path.Field, *path.ArrayIndex, *path.MapIndex:
+			nodes = append(nodes, n)
+		default:
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+// foreachStateChange mutates the commands of the capture up to and including
+// the command after, calling cb with the command identifier and new value
+// each time a command changes the value found by following nodes from the
+// global state. cb may return api.Break to stop the iteration early.
+func foreachStateChange(ctx context.Context, after *path.Command, nodes []path.Node, r *path.ResolveConfig, cb func(id api.CmdID, v *box.Value) error) error {
+	ctx = SetupContext(ctx, after.Capture, r)
+
+	allCmds, err := Cmds(ctx, after.Capture)
+	if err != nil {
+		return err
+	}
+	cmdIdx := after.Indices[0]
+	if count := uint64(len(allCmds)); cmdIdx >= count {
+		return errPathOOB(cmdIdx, "Index", 0, count-1, after)
+	}
+
+	sd, err := SyncData(ctx, after.Capture)
+	if err != nil {
+		return err
+	}
+	cmds, err := sync.MutationCmdsFor(ctx, after.Capture, sd, allCmds, api.CmdID(cmdIdx), after.Indices[1:], false)
+	if err != nil {
+		return err
+	}
+
+	s, err := capture.NewState(ctx)
+	if err != nil {
+		return err
+	}
+
+	prev := stateValue(ctx, s, nodes)
+	return api.ForeachCmd(ctx, cmds, true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if err := cmd.Mutate(ctx, id, s, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		if v := stateValue(ctx, s, nodes); !proto.Equal(v, prev) {
+			prev = v
+			return cb(id, v)
+		}
+		return nil
+	})
+}
+
+// replaceState returns a copy of p with the State path node replaced with
+// root.
+func replaceState(p, root path.Node) path.Node {
+	return path.Transform(proto.Clone(p.(proto.Message)).(path.Node), func(n path.Node) path.Node {
+		if _, ok := n.(*path.State); ok {
+			return root
+		}
+		return n
+	})
+}
+
+// findState returns the State path node that p is rooted at, or nil if p is
+// not rooted at a State.
+func findState(p path.Node) *path.State {
+	for ; p != nil; p = p.Parent() {
+		if s, ok := p.(*path.State); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+// stateValue returns the boxed value found by following the nodes from the
+// global state s. If the value cannot be found, for example if a map key does
+// not exist yet, then nil is returned.
+func stateValue(ctx context.Context, s *api.GlobalState, nodes []path.Node) *box.Value {
+	obj, ok := stateObject(ctx, s, nodes)
+	if !ok {
+		return nil
+	}
+	return box.NewValue(obj)
+}
+
+// stateObject returns the value found by following the nodes from the global
+// state s, and true, or false if the value cannot be found.
+func stateObject(ctx context.Context, s *api.GlobalState, nodes []path.Node) (interface{}, bool) {
+	var obj interface{} = s
+	for _, n := range nodes {
+		var err error
+		switch n := n.(type) {
+		case *path.Field:
+			var v reflect.Value
+			if v, err = field(ctx, reflect.ValueOf(obj), n.Name, n); err == nil {
+				obj = v.Interface()
+			}
+		case *path.ArrayIndex:
+			obj, err = arrayIndex(obj, n)
+		case *path.MapIndex:
+			obj, err = mapIndex(obj, n)
+		}
+		if err != nil {
+			return nil, false
+		}
+	}
+	return obj, true
+}
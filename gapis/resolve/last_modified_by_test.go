@@ -0,0 +1,67 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestGlobalStateRelative(t *testing.T) {
+	ctx := log.Testing(t)
+	c := &path.Capture{ID: path.NewID(id.ID{1})}
+	after := c.Command(10)
+
+	p := after.GlobalStateAfter().Field("APIs").MapIndex(api.ID{2}).Field("Foo")
+	nodes, cmd, err := globalStateRelative(ctx, p, nil)
+	if assert.For(ctx, "err").ThatError(err).Succeeded() {
+		assert.For(ctx, "cmd").That(cmd).Equals(after)
+		assert.For(ctx, "len(nodes)").That(len(nodes)).Equals(3)
+		assert.For(ctx, "nodes[0]").That(nodes[0].(*path.Field).Name).Equals("APIs")
+		assert.For(ctx, "nodes[2]").That(nodes[2].(*path.Field).Name).Equals("Foo")
+	}
+
+	_, _, err = globalStateRelative(ctx, after.Result(), nil)
+	assert.For(ctx, "err").ThatError(err).Failed()
+}
+
+func TestStateValue(t *testing.T) {
+	ctx := log.Testing(t)
+	state := func(e device.Endian) *api.GlobalState {
+		return &api.GlobalState{
+			MemoryLayout: &device.MemoryLayout{Endian: e},
+			APIs:         map[api.ID]api.State{},
+		}
+	}
+	g := &path.GlobalState{}
+	endian := []path.Node{g.Field("MemoryLayout"), g.Field("MemoryLayout").Field("Endian")}
+
+	little := stateValue(ctx, state(device.LittleEndian), endian)
+	big := stateValue(ctx, state(device.BigEndian), endian)
+	assert.For(ctx, "little").That(little).IsNotNil()
+	assert.For(ctx, "little == little").That(
+		proto.Equal(little, stateValue(ctx, state(device.LittleEndian), endian))).Equals(true)
+	assert.For(ctx, "little == big").That(proto.Equal(little, big)).Equals(false)
+
+	missing := []path.Node{g.Field("APIs"), g.Field("APIs").MapIndex(api.ID{1})}
+	assert.For(ctx, "missing").That(stateValue(ctx, state(device.LittleEndian), missing)).IsNil()
+}
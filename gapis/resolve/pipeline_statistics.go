@@ -0,0 +1,125 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/replay/devices"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// PipelineStatistics resolves and returns the number of primitives, clipped
+// primitives and shader invocations of each draw call of the frame of p,
+// measured by replaying the frame with a pipeline statistics query around
+// each draw call. The GPU time of each draw call is added by Get from the
+// latest profile of the capture, if any.
+func PipelineStatistics(ctx context.Context, p *path.PipelineStatistics, r *path.ResolveConfig) (*service.PipelineStatistics, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := frameCommandRange(ctx, p.Capture, p.Frame, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	draws, err := drawCalls(ctx, p.Capture, cmds, start, end, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(draws) == 0 {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+
+	cmd, err := Cmd(ctx, draws[0], r)
+	if err != nil {
+		return nil, err
+	}
+	query, ok := cmd.API().(replay.QueryPipelineStatistics)
+	if !ok {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrPipelineStatisticsNotAvailable()}
+	}
+
+	device := r.GetReplayDevice()
+	if device == nil {
+		devices, err := devices.ForReplay(ctx, p.Capture)
+		if err != nil {
+			return nil, err
+		}
+		if len(devices) == 0 {
+			return nil, fmt.Errorf("No compatible replay devices found")
+		}
+		device = devices[0]
+	}
+
+	ctx = SetupContext(ctx, p.Capture, r)
+	intent := replay.Intent{
+		Device:  device,
+		Capture: p.Capture,
+	}
+	out, err := query.QueryPipelineStatistics(
+		ctx,
+		intent,
+		replay.GetManager(ctx),
+		api.CmdID(start),
+		api.CmdID(end),
+		&service.UsageHints{Background: true},
+	)
+	if err != nil || out == nil {
+		return out, err
+	}
+
+	if len(draws) > len(out.Draws) {
+		out.Skipped = uint32(len(draws) - len(out.Draws))
+	}
+	return out, nil
+}
+
+// addDrawGpuTimes sets the GpuTime of each of draws to the total duration of
+// the profile slices of the groups linked to the draw call alone, returning
+// true if any draw call was linked.
+func addDrawGpuTimes(draws []*service.DrawPipelineStatistics, slices *service.ProfilingData_GpuSlices) bool {
+	key := func(indices []uint64) string { return fmt.Sprint(indices) }
+
+	groups := map[int32]string{}
+	for _, g := range slices.GetGroups() {
+		from, to := g.GetLink().GetFrom(), g.GetLink().GetTo()
+		if len(from) > 0 && key(from) == key(to) {
+			groups[g.Id] = key(from)
+		}
+	}
+	times := map[string]uint64{}
+	for _, s := range slices.GetSlices() {
+		if k, ok := groups[s.Group]; ok && s.Depth == 0 {
+			times[k] += s.Dur
+		}
+	}
+
+	found := false
+	for _, d := range draws {
+		if t, ok := times[key(d.Command.Indices)]; ok {
+			d.GpuTime = t
+			found = true
+		}
+	}
+	return found
+}
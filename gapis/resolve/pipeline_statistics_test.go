@@ -0,0 +1,49 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestAddDrawGpuTimes(t *testing.T) {
+	ctx := log.Testing(t)
+	c := &path.Capture{}
+	draws := []*service.DrawPipelineStatistics{
+		{Command: c.Command(2, 0, 0, 3)},
+		{Command: c.Command(2, 0, 0, 5)},
+	}
+	slices := &service.ProfilingData_GpuSlices{
+		Groups: []*service.ProfilingData_GpuSlices_Group{
+			{Id: 1, Link: c.SubCommandRange([]uint64{2, 0, 0, 3}, []uint64{2, 0, 0, 3})},
+			{Id: 2, Link: c.SubCommandRange([]uint64{2, 0, 0}, []uint64{2, 0, 0, 9})}, // Not a single draw.
+		},
+		Slices: []*service.ProfilingData_GpuSlices_Slice{
+			{Ts: 100, Dur: 20, Group: 1},
+			{Ts: 130, Dur: 5, Group: 1},
+			{Ts: 100, Dur: 10, Group: 1, Depth: 1}, // Nested, ignored.
+			{Ts: 90, Dur: 100, Group: 2},
+		},
+	}
+
+	assert.For(ctx, "found").That(addDrawGpuTimes(draws, slices)).Equals(true)
+	assert.For(ctx, "draws[0].GpuTime").That(draws[0].GpuTime).Equals(uint64(25))
+	assert.For(ctx, "draws[1].GpuTime").That(draws[1].GpuTime).Equals(uint64(0))
+}
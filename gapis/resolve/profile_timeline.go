@@ -0,0 +1,117 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/math/u64"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// defaultTimelineBuckets is the number of buckets used by ProfileTimeline if
+// none are requested.
+const defaultTimelineBuckets = 1000
+
+// ProfileTimeline returns the top-level GPU slices of the latest profile of
+// the capture c that overlap the window [start, end), aggregated into count
+// buckets of equal duration per track. If end is 0, then the window ends at
+// the end of the last slice of the profile.
+func ProfileTimeline(ctx context.Context, c *path.Capture, start, end uint64, count uint32) (*service.ProfileTimeline, error) {
+	profile := replay.LatestProfile(c)
+	if profile == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoProfile()}
+	}
+	if count == 0 {
+		count = defaultTimelineBuckets
+	}
+	if end != 0 && end < start {
+		return nil, log.Errf(ctx, nil, "Timeline window end %v is before its start %v", end, start)
+	}
+	return profileTimeline(profile.Slices, start, end, count), nil
+}
+
+func profileTimeline(slices *service.ProfilingData_GpuSlices, start, end uint64, count uint32) *service.ProfileTimeline {
+	if end == 0 {
+		for _, s := range slices.GetSlices() {
+			end = u64.Max(end, s.Ts+s.Dur)
+		}
+	}
+	out := &service.ProfileTimeline{Start: start, End: end}
+	if end <= start {
+		return out
+	}
+	width := (end - start + uint64(count) - 1) / uint64(count)
+	out.BucketDuration = width
+
+	// buckets holds the buckets of each track, by bucket index.
+	buckets := map[int32]map[uint32]*service.ProfileTimelineBucket{}
+	for _, s := range slices.GetSlices() {
+		overlaps := s.Ts < end && (s.Ts+s.Dur > start || s.Ts == start)
+		if s.Depth != 0 || !overlaps {
+			continue
+		}
+		from, to := u64.Max(s.Ts, start), u64.Min(s.Ts+s.Dur, end)
+		track := buckets[s.TrackId]
+		if track == nil {
+			track = map[uint32]*service.ProfileTimelineBucket{}
+			buckets[s.TrackId] = track
+		}
+		// Every bucket overlapped by the slice is updated, along with the
+		// bucket holding the slice's start for slices of zero duration.
+		first := uint32((from - start) / width)
+		for i := first; i < count && (i == first || start+uint64(i)*width < to); i++ {
+			b := track[i]
+			if b == nil {
+				b = &service.ProfileTimelineBucket{Index: i}
+				track[i] = b
+			}
+			bStart := start + uint64(i)*width
+			if e := u64.Min(to, bStart+width); e > from {
+				b.Total += e - u64.Max(from, bStart)
+			}
+			b.Count++
+			b.Max = u64.Max(b.Max, s.Dur)
+		}
+	}
+
+	for _, t := range slices.GetTracks() {
+		track := buckets[t.Id]
+		if len(track) == 0 {
+			continue
+		}
+		out.Tracks = append(out.Tracks, &service.ProfileTimelineTrack{
+			TrackId: t.Id,
+			Name:    t.Name,
+			Buckets: sortedTimelineBuckets(track),
+		})
+	}
+	return out
+}
+
+// sortedTimelineBuckets returns the buckets of m in increasing index order.
+func sortedTimelineBuckets(m map[uint32]*service.ProfileTimelineBucket) []*service.ProfileTimelineBucket {
+	out := make([]*service.ProfileTimelineBucket, 0, len(m))
+	for _, b := range m {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Index < out[j].Index })
+	return out
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+)
+
+func TestProfileTimeline(t *testing.T) {
+	ctx := log.Testing(t)
+	slices := &service.ProfilingData_GpuSlices{
+		Tracks: []*service.ProfilingData_GpuSlices_Track{
+			{Id: 1, Name: "queue 1"},
+			{Id: 2, Name: "queue 2"},
+			{Id: 3, Name: "queue 3"},
+		},
+		Slices: []*service.ProfilingData_GpuSlices_Slice{
+			{Ts: 0, Dur: 25, TrackId: 1},
+			{Ts: 0, Dur: 10, TrackId: 1, Depth: 1}, // Nested, ignored.
+			{Ts: 30, Dur: 5, TrackId: 1},
+			{Ts: 50, Dur: 0, TrackId: 2},
+			{Ts: 200, Dur: 10, TrackId: 3},
+		},
+	}
+
+	for _, test := range []struct {
+		name       string
+		start, end uint64
+		count      uint32
+		expected   *service.ProfileTimeline
+	}{
+		{"window", 0, 100, 10, &service.ProfileTimeline{
+			Start: 0, End: 100, BucketDuration: 10,
+			Tracks: []*service.ProfileTimelineTrack{
+				{TrackId: 1, Name: "queue 1", Buckets: []*service.ProfileTimelineBucket{
+					{Index: 0, Count: 1, Total: 10, Max: 25},
+					{Index: 1, Count: 1, Total: 10, Max: 25},
+					{Index: 2, Count: 1, Total: 5, Max: 25},
+					{Index: 3, Count: 1, Total: 5, Max: 5},
+				}},
+				{TrackId: 2, Name: "queue 2", Buckets: []*service.ProfileTimelineBucket{
+					{Index: 5, Count: 1},
+				}},
+			},
+		}},
+		{"all", 0, 0, 3, &service.ProfileTimeline{
+			Start: 0, End: 210, BucketDuration: 70,
+			Tracks: []*service.ProfileTimelineTrack{
+				{TrackId: 1, Name: "queue 1", Buckets: []*service.ProfileTimelineBucket{
+					{Index: 0, Count: 2, Total: 30, Max: 25},
+				}},
+				{TrackId: 2, Name: "queue 2", Buckets: []*service.ProfileTimelineBucket{
+					{Index: 0, Count: 1},
+				}},
+				{TrackId: 3, Name: "queue 3", Buckets: []*service.ProfileTimelineBucket{
+					{Index: 2, Count: 1, Total: 10, Max: 10},
+				}},
+			},
+		}},
+		{"empty", 300, 400, 10, &service.ProfileTimeline{
+			Start: 300, End: 400, BucketDuration: 10,
+		}},
+	} {
+		got := profileTimeline(slices, test.start, test.end, test.count)
+		assert.For(ctx, test.name).That(got).DeepEquals(test.expected)
+	}
+}
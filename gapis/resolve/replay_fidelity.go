@@ -0,0 +1,169 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// defaultFidelityThreshold is the mean difference of the color channels above
+// which a frame is reported as diverged, if the path has no threshold.
+const defaultFidelityThreshold = 0.02
+
+// ReplayFidelity resolves and returns the comparison of the framebuffer
+// replayed at the end of each frame of the capture of p against the
+// framebuffer observed at the end of the frame while capturing it. As the
+// framebuffer is undefined once presented, it is replayed after the last draw
+// call or clear of the frame. Frames without an observation at their end, or
+// without draw calls or clears, are not compared. If p.Frames is set, only
+// those frames are compared.
+func ReplayFidelity(ctx context.Context, p *path.ReplayFidelity, r *path.ResolveConfig) (*service.ReplayFidelity, error) {
+	events, err := Events(ctx, &path.Events{
+		Capture:                 p.Capture,
+		DrawCalls:               true,
+		Clears:                  true,
+		LastInFrame:             true,
+		FramebufferObservations: true,
+	}, r)
+	if err != nil {
+		return nil, err
+	}
+
+	frames, count := fidelityFrames(events.List)
+	if len(frames) == 0 {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoFramebufferObservations()}
+	}
+	first, last, err := frameRangeBounds(p.Frames, count, p)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := p.Threshold
+	if threshold == 0 {
+		threshold = defaultFidelityThreshold
+	}
+
+	out := &service.ReplayFidelity{Frames: []*service.FrameFidelity{}}
+	for _, f := range frames {
+		if uint64(f.Frame) < first || uint64(f.Frame) > last {
+			continue
+		}
+		if err := task.StopReason(ctx); err != nil {
+			return nil, err
+		}
+		if diff, err := framebufferDifference(ctx, f.Observation, f.Replayed, r); err != nil {
+			f.Error, f.Diverged = err.Error(), true
+		} else {
+			f.Difference, f.Diverged = diff, diff > threshold
+		}
+		if f.Diverged {
+			out.Diverged++
+		}
+		out.Frames = append(out.Frames, f)
+	}
+	return out, nil
+}
+
+// fidelityFrames returns the frames of events that have a framebuffer
+// observation at their end and a draw call or clear, and the number of frames
+// of events.
+func fidelityFrames(events []*service.Event) ([]*service.FrameFidelity, uint64) {
+	frames := []*service.FrameFidelity{}
+	count := uint32(0)
+	var lastWrite, frameEnd, frameWrite *path.Command
+	for _, e := range events {
+		switch e.Kind {
+		case service.EventKind_DrawCall, service.EventKind_Clear:
+			lastWrite = e.Command
+		case service.EventKind_LastInFrame:
+			frameEnd, frameWrite, lastWrite = e.Command, lastWrite, nil
+			count++
+		case service.EventKind_FramebufferObservation:
+			// The observations of the ends of frames follow the frames' last
+			// events on the same command. Observations of draw calls are
+			// ignored.
+			if frameWrite != nil && proto.Equal(e.Command, frameEnd) {
+				frames = append(frames, &service.FrameFidelity{
+					Frame:       count - 1,
+					Observation: e.Command,
+					Replayed:    frameWrite,
+				})
+				frameWrite = nil
+			}
+		}
+	}
+	return frames, uint64(count)
+}
+
+// framebufferDifference returns the mean difference of the color channels of
+// the framebuffer observed at the command observation and the framebuffer
+// replayed after the command replayed, scaled to the size of the observation.
+func framebufferDifference(ctx context.Context, observation, replayed *path.Command, r *path.ResolveConfig) (float32, error) {
+	observed, err := FramebufferObservation(ctx, observation.FramebufferObservation(), r)
+	if err != nil {
+		return 0, err
+	}
+	rendered, err := CommandThumbnail(ctx, observed.Width, observed.Height, image.RGBA_U8_NORM, false, replayed, r)
+	if err != nil {
+		return 0, err
+	}
+	if rendered.Width != observed.Width || rendered.Height != observed.Height {
+		if rendered, err = rendered.Resize(ctx, observed.Width, observed.Height, 1); err != nil {
+			return 0, err
+		}
+	}
+
+	a, err := observed.Data(ctx)
+	if err != nil {
+		return 0, err
+	}
+	b, err := rendered.Data(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return meanDifference(a.Bytes, b.Bytes), nil
+}
+
+// meanDifference returns the mean absolute difference, from 0 to 1, of the
+// red, green and blue channels of the RGBA8 pixels a and b. Alpha is ignored,
+// as presented framebuffers are opaque.
+func meanDifference(a, b []byte) float32 {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	sum, count := uint64(0), uint64(0)
+	for i := 0; i+4 <= n; i += 4 {
+		for c := i; c < i+3; c++ {
+			if a[c] > b[c] {
+				sum += uint64(a[c] - b[c])
+			} else {
+				sum += uint64(b[c] - a[c])
+			}
+		}
+		count += 3
+	}
+	if count == 0 {
+		return 0
+	}
+	return float32(sum) / float32(count*255)
+}
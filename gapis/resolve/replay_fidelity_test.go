@@ -0,0 +1,76 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestFidelityFrames(t *testing.T) {
+	ctx := log.Testing(t)
+	c := path.NewCapture(id.ID{})
+	event := func(kind service.EventKind, cmd uint64) *service.Event {
+		return &service.Event{Kind: kind, Command: c.Command(cmd)}
+	}
+	events := []*service.Event{
+		// Frame 0: observed.
+		event(service.EventKind_DrawCall, 1),
+		event(service.EventKind_DrawCall, 2),
+		event(service.EventKind_LastInFrame, 3),
+		event(service.EventKind_FramebufferObservation, 3),
+		// Frame 1: not observed.
+		event(service.EventKind_Clear, 4),
+		event(service.EventKind_LastInFrame, 5),
+		// Frame 2: observed, but nothing drawn.
+		event(service.EventKind_LastInFrame, 6),
+		event(service.EventKind_FramebufferObservation, 6),
+		// Frame 3: a draw call observation, and an observed end.
+		event(service.EventKind_DrawCall, 7),
+		event(service.EventKind_FramebufferObservation, 7),
+		event(service.EventKind_LastInFrame, 8),
+		event(service.EventKind_FramebufferObservation, 8),
+	}
+
+	frames, count := fidelityFrames(events)
+	assert.For(ctx, "count").That(count).Equals(uint64(4))
+	assert.For(ctx, "frames").That(frames).DeepEquals([]*service.FrameFidelity{
+		{Frame: 0, Observation: c.Command(3), Replayed: c.Command(2)},
+		{Frame: 3, Observation: c.Command(8), Replayed: c.Command(7)},
+	})
+}
+
+func TestMeanDifference(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		name     string
+		a, b     []byte
+		expected float32
+	}{
+		{"identical", []byte{1, 2, 3, 4}, []byte{1, 2, 3, 4}, 0},
+		{"alpha only", []byte{1, 2, 3, 4}, []byte{1, 2, 3, 255}, 0},
+		{"opposite", []byte{0, 255, 0, 255}, []byte{255, 0, 255, 255}, 1},
+		{"half", []byte{0, 0, 0, 0, 10, 20, 30, 0}, []byte{255, 255, 255, 0, 10, 20, 30, 0}, 0.5},
+		{"empty", []byte{}, []byte{}, 0},
+	} {
+		assert.For(ctx, "meanDifference(%v)", test.name).
+			That(meanDifference(test.a, test.b)).Equals(test.expected)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"github.com/google/gapid/core/app/analytics"
+	"github.com/google/gapid/core/image"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/capture"
@@ -53,6 +54,13 @@ func (r *ReportResolvable) newReportItem(s log.Severity, c uint64, m *stringtabl
 func (r *ReportResolvable) Resolve(ctx context.Context) (interface{}, error) {
 	ctx = SetupContext(ctx, r.Path.Capture, r.Config)
 
+	if err := checkReportConfig(r.Path.Config); err != nil {
+		return nil, err
+	}
+	if err := checkReportSuppressions(r.Path.Suppressions); err != nil {
+		return nil, err
+	}
+
 	c, err := capture.ResolveGraphics(ctx)
 	if err != nil {
 		return nil, err
@@ -70,7 +78,25 @@ func (r *ReportResolvable) Resolve(ctx context.Context) (interface{}, error) {
 		return nil, err
 	}
 
+	config := r.Path.Config
 	builder := service.NewReportBuilder()
+	suppressions := r.Path.Suppressions
+	suppressed := make([]uint32, len(suppressions))
+	add := func(item *service.ReportItemRaw, id api.CmdID, name string) {
+		if i := suppression(suppressions, item.Message.Identifier, id, name); i >= 0 {
+			suppressed[i]++
+			return
+		}
+		if s, ok := config.GetSeverities()[item.Message.Identifier]; ok {
+			item.Item.Severity = s
+		}
+		builder.Add(ctx, item)
+	}
+
+	budgets, err := r.drawsPerFrameBudget(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	var currentCmd uint64
 	items := []*service.ReportItemRaw{}
@@ -82,10 +108,26 @@ func (r *ReportResolvable) Resolve(ctx context.Context) (interface{}, error) {
 	state.AddTag = func(i uint32, t *stringtable.Msg) {
 		items[i].Tags = append(items[i].Tags, t)
 	}
+	if budget := config.GetMaxTextureSize(); budget > 0 && analyzerEnabled(config, textureSizeAnalyzer) {
+		// Check each texture once, the first time it is accessed with images,
+		// as reading the data of a texture is too expensive to do on every
+		// access.
+		checked := map[api.Resource]bool{}
+		state.OnResourceAccessed = func(res api.Resource) {
+			if checked[res] || res.ResourceType(ctx) != api.ResourceType_TextureResource {
+				return
+			}
+			item, ok := r.textureSizeBudget(ctx, state, res, currentCmd, budget)
+			checked[res] = ok
+			if item != nil {
+				budgets[api.CmdID(currentCmd)] = append(budgets[api.CmdID(currentCmd)], item)
+			}
+		}
+	}
 
 	issues := map[api.CmdID][]replay.Issue{}
 
-	if r.Path.Device != nil {
+	if r.Path.Device != nil && analyzerEnabled(config, replayAnalyzer) {
 		// Request is for a replay report too.
 		intent := replay.Intent{
 			Capture: r.Path.Capture,
@@ -134,24 +176,182 @@ func (r *ReportResolvable) Resolve(ctx context.Context) (interface{}, error) {
 			}
 		}
 
+		if !analyzerEnabled(config, stateAnalyzer) {
+			items = items[:0]
+		}
 		if filter(id, cmd, state) {
 			for _, item := range items {
 				item.Tags = append(item.Tags, getCommandNameTag(cmd))
-				builder.Add(ctx, item)
+				add(item, id, cmd.CmdName())
+			}
+			for _, item := range budgets[id] {
+				item.Tags = append(item.Tags, getCommandNameTag(cmd))
+				add(item, id, cmd.CmdName())
 			}
 			for _, issue := range issues[id] {
 				item := r.newReportItem(log.Severity(issue.Severity), uint64(issue.Command),
 					messages.ErrReplayDriver(issue.Error.Error()))
+				name := ""
 				if int(issue.Command) < len(c.Commands) {
+					name = c.Commands[issue.Command].CmdName()
 					item.Tags = append(item.Tags, getCommandNameTag(c.Commands[issue.Command]))
 				}
-				builder.Add(ctx, item)
+				add(item, issue.Command, name)
 			}
 		}
 		return nil
 	})
 
-	return builder.Build(), nil
+	report := builder.Build()
+	if report != nil && len(suppressions) > 0 {
+		report.Suppressed = suppressed
+	}
+	return report, nil
+}
+
+// The names of the analyzers of a report, as disabled by a path.ReportConfig.
+const (
+	stateAnalyzer         = "state"
+	replayAnalyzer        = "replay"
+	drawsPerFrameAnalyzer = "draws_per_frame"
+	textureSizeAnalyzer   = "texture_size"
+)
+
+// analyzers are the names of all the analyzers of a report.
+var analyzers = []string{stateAnalyzer, replayAnalyzer, drawsPerFrameAnalyzer, textureSizeAnalyzer}
+
+// checkReportConfig returns an error if the config disables an analyzer that
+// does not exist.
+func checkReportConfig(config *path.ReportConfig) error {
+	for _, d := range config.GetDisabled() {
+		known := false
+		for _, a := range analyzers {
+			known = known || a == d
+		}
+		if !known {
+			return &service.ErrInvalidArgument{Reason: messages.ErrUnknownReportAnalyzer(d)}
+		}
+	}
+	return nil
+}
+
+// checkReportSuppressions returns an error if any of the rules has no fields
+// set, as it would suppress every item of the report.
+func checkReportSuppressions(rules []*path.ReportSuppression) error {
+	for i, rule := range rules {
+		if rule.Message == "" && rule.CommandName == "" && len(rule.Commands) == 0 {
+			return &service.ErrInvalidArgument{Reason: messages.ErrEmptyReportSuppression(uint32(i))}
+		}
+	}
+	return nil
+}
+
+// analyzerEnabled returns true if the config does not disable the named
+// analyzer.
+func analyzerEnabled(config *path.ReportConfig, name string) bool {
+	for _, d := range config.GetDisabled() {
+		if d == name {
+			return false
+		}
+	}
+	return true
+}
+
+// drawsPerFrameBudget returns the report items of the frames with more draw
+// calls than the budget of the config, keyed by the last command of the frame.
+func (r *ReportResolvable) drawsPerFrameBudget(ctx context.Context) (map[api.CmdID][]*service.ReportItemRaw, error) {
+	out := map[api.CmdID][]*service.ReportItemRaw{}
+	budget := r.Path.Config.GetMaxDrawsPerFrame()
+	if budget == 0 || !analyzerEnabled(r.Path.Config, drawsPerFrameAnalyzer) {
+		return out, nil
+	}
+	stats, err := Stats(ctx, &path.Stats{Capture: r.Path.Capture, DrawCall: true}, r.Config)
+	if err != nil {
+		return nil, err
+	}
+	events, err := Events(ctx, &path.Events{Capture: r.Path.Capture, LastInFrame: true}, r.Config)
+	if err != nil {
+		return nil, err
+	}
+	for i, draws := range stats.DrawCalls {
+		if draws <= uint64(budget) || i >= len(events.List) {
+			continue
+		}
+		id := events.List[i].Command.Indices[0]
+		out[api.CmdID(id)] = append(out[api.CmdID(id)], r.newReportItem(log.Warning, id,
+			messages.ErrDrawsPerFrameOverBudget(draws, budget)))
+	}
+	return out, nil
+}
+
+// textureSizeBudget returns the report item of the texture res if its
+// largest image is wider, higher or deeper than budget, or nil if it is not.
+// It returns false if the texture has no images yet, so it needs to be checked
+// again later.
+func (r *ReportResolvable) textureSizeBudget(ctx context.Context, s *api.GlobalState, res api.Resource, cmd uint64, budget uint32) (*service.ReportItemRaw, bool) {
+	data, err := res.ResourceData(ctx, s, r.Path.Capture.Command(cmd))
+	if err != nil || data.GetTexture() == nil {
+		return nil, true
+	}
+	images := data.GetTexture().Images()
+	if len(images) == 0 {
+		return nil, false
+	}
+	width, height, depth := maxImageSize(images)
+	if width <= budget && height <= budget && depth <= budget {
+		return nil, true
+	}
+	name := res.ResourceLabel()
+	if name == "" {
+		name = res.ResourceHandle()
+	}
+	return r.newReportItem(log.Warning, cmd,
+		messages.ErrTextureSizeOverBudget(name, width, height, depth, budget)), true
+}
+
+// maxImageSize returns the largest width, height and depth of the images.
+func maxImageSize(images []*image.Info) (width, height, depth uint32) {
+	for _, i := range images {
+		if i.Width > width {
+			width = i.Width
+		}
+		if i.Height > height {
+			height = i.Height
+		}
+		if i.Depth > depth {
+			depth = i.Depth
+		}
+	}
+	return width, height, depth
+}
+
+// suppression returns the index of the first of the rules that matches the
+// report item with the message identifier msg, of the command id with the
+// given name, or -1 if none match.
+func suppression(rules []*path.ReportSuppression, msg string, id api.CmdID, name string) int {
+	for i, rule := range rules {
+		if rule.Message != "" && rule.Message != msg {
+			continue
+		}
+		if rule.CommandName != "" && rule.CommandName != name {
+			continue
+		}
+		if len(rule.Commands) > 0 && !containsCmd(rule.Commands, id) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// containsCmd returns true if the command indices contain the command id.
+func containsCmd(indices []uint64, id api.CmdID) bool {
+	for _, i := range indices {
+		if api.CmdID(i) == id {
+			return true
+		}
+	}
+	return false
 }
 
 func getCommandNameTag(cmd api.Cmd) *stringtable.Msg {
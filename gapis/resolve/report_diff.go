@@ -0,0 +1,122 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// DiffReports resolves the reports before and after and compares their items,
+// matching them by their fingerprints.
+func DiffReports(ctx context.Context, before, after *path.Report, r *path.ResolveConfig) (*service.ReportDiff, error) {
+	b, err := Report(ctx, before, r)
+	if err != nil {
+		return nil, err
+	}
+	a, err := Report(ctx, after, r)
+	if err != nil {
+		return nil, err
+	}
+	return diffReports(b, a), nil
+}
+
+func diffReports(before, after *service.Report) *service.ReportDiff {
+	items := map[string]*service.ReportDiffItem{}
+	order := []string{}
+	get := func(report *service.Report, item *service.ReportItem) *service.ReportDiffItem {
+		f := fingerprint(report, item)
+		d, ok := items[f]
+		if !ok {
+			d = &service.ReportDiffItem{
+				Fingerprint: f,
+				Severity:    item.Severity,
+				Message:     report.Msg(item.Message),
+			}
+			items[f] = d
+			order = append(order, f)
+		}
+		return d
+	}
+	for _, item := range before.Items {
+		d := get(before, item)
+		if d.BeforeCount == 0 {
+			d.Before = item.Command
+		}
+		d.BeforeCount++
+	}
+	for _, item := range after.Items {
+		d := get(after, item)
+		if d.AfterCount == 0 {
+			d.After = item.Command
+		}
+		d.AfterCount++
+	}
+
+	out := &service.ReportDiff{Items: make([]*service.ReportDiffItem, 0, len(order))}
+	for _, f := range order {
+		d := items[f]
+		switch {
+		case d.BeforeCount == 0:
+			d.Status = service.ReportDiffStatus_NewIssue
+			out.New++
+		case d.AfterCount == 0:
+			d.Status = service.ReportDiffStatus_FixedIssue
+			out.Fixed++
+		default:
+			d.Status = service.ReportDiffStatus_UnchangedIssue
+			out.Unchanged++
+		}
+		out.Items = append(out.Items, d)
+	}
+	rank := func(s service.ReportDiffStatus) int {
+		switch s {
+		case service.ReportDiffStatus_NewIssue:
+			return 0
+		case service.ReportDiffStatus_FixedIssue:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(out.Items, func(i, j int) bool {
+		return rank(out.Items[i].Status) < rank(out.Items[j].Status)
+	})
+	return out
+}
+
+// commandNameTag is the identifier of the tag holding the name of the command
+// of a report item.
+const commandNameTag = "TAG_COMMAND_NAME"
+
+// fingerprint returns an identifier of the issue reported by the item that is
+// stable between captures. It hashes the identifier of the message of the
+// item and the name of its command, but neither the arguments of the message,
+// such as handles and addresses, nor the command index, which vary between
+// captures of the same issue.
+func fingerprint(report *service.Report, item *service.ReportItem) string {
+	name := ""
+	for _, tag := range item.Tags {
+		if m := report.Msg(tag); m.Identifier == commandNameTag {
+			name = fmt.Sprint(m.Arguments["command"].Unpack())
+		}
+	}
+	return id.OfString(report.Msg(item.Message).Identifier + "\x00" + name).String()
+}
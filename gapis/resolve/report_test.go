@@ -0,0 +1,193 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/stringtable"
+)
+
+func TestReportSuppression(t *testing.T) {
+	ctx := log.Testing(t)
+	rules := []*path.ReportSuppression{
+		{Message: "ERR_A", Commands: []uint64{1, 3}},
+		{Message: "ERR_B", CommandName: "glDrawArrays"},
+		{CommandName: "vkQueueSubmit"},
+	}
+	for _, test := range []struct {
+		msg      string
+		id       api.CmdID
+		name     string
+		expected int
+	}{
+		{"ERR_A", 1, "glClear", 0},
+		{"ERR_A", 3, "glDrawArrays", 0},
+		{"ERR_A", 2, "glClear", -1},
+		{"ERR_A", api.CmdNoID, "", -1},
+		{"ERR_B", 2, "glDrawArrays", 1},
+		{"ERR_B", 2, "glDrawElements", -1},
+		{"ERR_C", 7, "vkQueueSubmit", 2},
+		{"ERR_C", 7, "glClear", -1},
+	} {
+		got := suppression(rules, test.msg, test.id, test.name)
+		assert.For(ctx, "suppression(%v, %v, %v)", test.msg, test.id, test.name).
+			That(got).Equals(test.expected)
+	}
+}
+
+func TestDiffReports(t *testing.T) {
+	ctx := log.Testing(t)
+	build := func(items ...string) *service.Report {
+		b := service.NewReportBuilder()
+		for i, identifier := range items {
+			b.Add(ctx, service.WrapReportItem(&service.ReportItem{
+				Severity: service.Severity_ErrorLevel,
+				Command:  &path.Command{Indices: []uint64{uint64(i)}},
+			}, &stringtable.Msg{
+				Identifier: identifier,
+				Arguments:  map[string]*stringtable.Value{"value": stringtable.ToValue(1)},
+			}))
+		}
+		return b.Build()
+	}
+
+	// The matching issues are reported at different commands in each report.
+	diff := diffReports(build("ERR_A", "ERR_B", "ERR_B"), build("ERR_C", "ERR_B"))
+	assert.For(ctx, "new").That(diff.New).Equals(uint32(1))
+	assert.For(ctx, "fixed").That(diff.Fixed).Equals(uint32(1))
+	assert.For(ctx, "unchanged").That(diff.Unchanged).Equals(uint32(1))
+
+	expected := []struct {
+		identifier string
+		status     service.ReportDiffStatus
+		before     uint32
+		after      uint32
+	}{
+		{"ERR_C", service.ReportDiffStatus_NewIssue, 0, 1},
+		{"ERR_A", service.ReportDiffStatus_FixedIssue, 1, 0},
+		{"ERR_B", service.ReportDiffStatus_UnchangedIssue, 2, 1},
+	}
+	if !assert.For(ctx, "items").ThatSlice(diff.Items).IsLength(len(expected)) {
+		return
+	}
+	for i, e := range expected {
+		got := diff.Items[i]
+		ctx := log.V{"identifier": e.identifier}.Bind(ctx)
+		assert.For(ctx, "identifier").That(got.Message.Identifier).Equals(e.identifier)
+		assert.For(ctx, "status").That(got.Status).Equals(e.status)
+		assert.For(ctx, "before").That(got.BeforeCount).Equals(e.before)
+		assert.For(ctx, "after").That(got.AfterCount).Equals(e.after)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	ctx := log.Testing(t)
+	b := service.NewReportBuilder()
+	add := func(identifier string, value int, cmd string) {
+		item := service.WrapReportItem(&service.ReportItem{
+			Severity: service.Severity_ErrorLevel,
+		}, &stringtable.Msg{
+			Identifier: identifier,
+			Arguments:  map[string]*stringtable.Value{"value": stringtable.ToValue(value)},
+		})
+		item.Tags = append(item.Tags, &stringtable.Msg{
+			Identifier: commandNameTag,
+			Arguments:  map[string]*stringtable.Value{"command": stringtable.ToValue(cmd)},
+		})
+		b.Add(ctx, item)
+	}
+	add("ERR_A", 1, "vkCreateImage")
+	add("ERR_A", 2, "vkCreateImage")
+	add("ERR_A", 1, "vkCreateBuffer")
+	add("ERR_B", 1, "vkCreateImage")
+	report := b.Build()
+
+	f := func(i int) string { return fingerprint(report, report.Items[i]) }
+	assert.For(ctx, "other arguments").That(f(1)).Equals(f(0))
+	assert.For(ctx, "other command").That(f(2)).NotEquals(f(0))
+	assert.For(ctx, "other message").That(f(3)).NotEquals(f(0))
+}
+
+func TestAnalyzerEnabled(t *testing.T) {
+	ctx := log.Testing(t)
+	config := &path.ReportConfig{Disabled: []string{replayAnalyzer, textureSizeAnalyzer}}
+	for _, test := range []struct {
+		config   *path.ReportConfig
+		name     string
+		expected bool
+	}{
+		{nil, replayAnalyzer, true},
+		{config, stateAnalyzer, true},
+		{config, replayAnalyzer, false},
+		{config, drawsPerFrameAnalyzer, true},
+		{config, textureSizeAnalyzer, false},
+	} {
+		assert.For(ctx, "analyzerEnabled(%v, %v)", test.config, test.name).
+			That(analyzerEnabled(test.config, test.name)).Equals(test.expected)
+	}
+}
+
+func TestCheckReportConfig(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		config *path.ReportConfig
+		valid  bool
+	}{
+		{nil, true},
+		{&path.ReportConfig{}, true},
+		{&path.ReportConfig{Disabled: analyzers}, true},
+		{&path.ReportConfig{Disabled: []string{stateAnalyzer, "texture-size"}}, false},
+	} {
+		err := checkReportConfig(test.config)
+		assert.For(ctx, "checkReportConfig(%v)", test.config).That(err == nil).Equals(test.valid)
+	}
+}
+
+func TestCheckReportSuppressions(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		rules []*path.ReportSuppression
+		valid bool
+	}{
+		{nil, true},
+		{[]*path.ReportSuppression{{Message: "ERR_A"}, {CommandName: "glClear"}, {Commands: []uint64{1}}}, true},
+		{[]*path.ReportSuppression{{Message: "ERR_A"}, {}}, false},
+	} {
+		err := checkReportSuppressions(test.rules)
+		assert.For(ctx, "checkReportSuppressions(%v)", test.rules).That(err == nil).Equals(test.valid)
+	}
+}
+
+func TestMaxImageSize(t *testing.T) {
+	ctx := log.Testing(t)
+	width, height, depth := maxImageSize([]*image.Info{
+		{Width: 512, Height: 256, Depth: 1},
+		{Width: 256, Height: 1024, Depth: 1},
+		{Width: 128, Height: 64, Depth: 4},
+	})
+	assert.For(ctx, "width").That(width).Equals(uint32(512))
+	assert.For(ctx, "height").That(height).Equals(uint32(1024))
+	assert.For(ctx, "depth").That(depth).Equals(uint32(4))
+
+	width, height, depth = maxImageSize(nil)
+	assert.For(ctx, "empty").That([]uint32{width, height, depth}).DeepEquals([]uint32{0, 0, 0})
+}
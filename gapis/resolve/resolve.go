@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 
 	"github.com/google/gapid/core/app/status"
 	"github.com/google/gapid/core/data/dictionary"
@@ -119,6 +120,25 @@ func Messages(ctx context.Context, p *path.Messages) (interface{}, error) {
 			Message:   message.Message,
 		})
 	}
+	if len(c.InputEvents) > 0 {
+		// Interleave the input events with the trace messages, so that they
+		// can be correlated with the frame boundaries.
+		for _, e := range c.InputEvents {
+			m.List = append(m.List, &service.Message{
+				Timestamp: e.Timestamp,
+				Message:   fmt.Sprintf("Input Event: %v %v %v %v", e.Device, e.Type, e.Code, e.Value),
+				InputEvent: &service.InputEvent{
+					Device: e.Device,
+					Type:   e.Type,
+					Code:   e.Code,
+					Value:  e.Value,
+				},
+			})
+		}
+		sort.SliceStable(m.List, func(i, j int) bool {
+			return m.List[i].Timestamp < m.List[j].Timestamp
+		})
+	}
 	return m, nil
 }
 
@@ -164,7 +184,11 @@ func ArrayIndex(ctx context.Context, p *path.ArrayIndex, r *path.ResolveConfig)
 	if err != nil {
 		return nil, err
 	}
+	return arrayIndex(obj, p)
+}
 
+// arrayIndex returns the element of the array or slice obj at the index of p.
+func arrayIndex(obj interface{}, p *path.ArrayIndex) (interface{}, error) {
 	a := reflect.ValueOf(obj)
 	switch {
 	case box.IsMemorySlice(a.Type()):
@@ -229,7 +253,11 @@ func MapIndex(ctx context.Context, p *path.MapIndex, r *path.ResolveConfig) (int
 	if err != nil {
 		return nil, err
 	}
+	return mapIndex(obj, p)
+}
 
+// mapIndex returns the value of the map obj at the key of p.
+func mapIndex(obj interface{}, p *path.MapIndex) (interface{}, error) {
 	d := dictionary.From(obj)
 	if d == nil {
 		return nil, &service.ErrInvalidPath{
@@ -299,6 +327,10 @@ func ResolveInternal(ctx context.Context, p path.Node, r *path.ResolveConfig) (i
 		return Blob(ctx, p, r)
 	case *path.Capture:
 		return Capture(ctx, p, r)
+	case *path.CaptureDevice:
+		return CaptureDevice(ctx, p, r)
+	case *path.CaptureView:
+		return CaptureView(ctx, p, r)
 	case *path.Command:
 		return Cmd(ctx, p, r)
 	case *path.Commands:
@@ -329,6 +361,8 @@ func ResolveInternal(ctx context.Context, p path.Node, r *path.ResolveConfig) (i
 		return GlobalState(ctx, p, r)
 	case *path.ImageInfo:
 		return ImageInfo(ctx, p, r)
+	case *path.LastModifiedBy:
+		return LastModifiedBy(ctx, p, r)
 	case *path.MapIndex:
 		return MapIndex(ctx, p, r)
 	case *path.Memory:
@@ -363,12 +397,54 @@ func ResolveInternal(ctx context.Context, p path.Node, r *path.ResolveConfig) (i
 		return StateTreeNode(ctx, p, r)
 	case *path.StateTreeNodeForPath:
 		return StateTreeNodeForPath(ctx, p, r)
+	case *path.StateSearch:
+		return StateSearch(ctx, p, r)
 	case *path.Thumbnail:
 		return Thumbnail(ctx, p, r)
 	case *path.Stats:
 		return Stats(ctx, p, r)
 	case *path.Type:
 		return Type(ctx, p, r)
+	case *path.Uploads:
+		return Uploads(ctx, p, r)
+	case *path.Barriers:
+		return Barriers(ctx, p, r)
+	case *path.BindChurn:
+		return BindChurn(ctx, p, r)
+	case *path.DrawBundle:
+		return DrawBundle(ctx, p, r)
+	case *path.ShaderInputs:
+		return ShaderInputs(ctx, p, r)
+	case *path.ShaderDebugTrace:
+		return ShaderDebugTrace(ctx, p, r)
+	case *path.FrameGraph:
+		return FrameGraph(ctx, p, r)
+	case *path.Scene:
+		return Scene(ctx, p, r)
+	case *path.TextureUsage:
+		return TextureUsage(ctx, p, r)
+	case *path.UniformUsage:
+		return UniformUsage(ctx, p, r)
+	case *path.BlendCost:
+		return BlendCost(ctx, p, r)
+	case *path.DepthTestCost:
+		return DepthTestCost(ctx, p, r)
+	case *path.PipelineStatistics:
+		return PipelineStatistics(ctx, p, r)
+	case *path.ReplayFidelity:
+		return ReplayFidelity(ctx, p, r)
+	case *path.DeviceMetrics:
+		return DeviceMetrics(ctx, p, r)
+	case *path.SyncTimeline:
+		return SyncTimeline(ctx, p, r)
+	case *path.FramePacing:
+		return FramePacing(ctx, p, r)
+	case *path.FrameRedundancy:
+		return FrameRedundancy(ctx, p, r)
+	case *path.ShaderClusters:
+		return ShaderClusters(ctx, p, r)
+	case *path.ValueSeries:
+		return ValueSeries(ctx, p, r)
 	default:
 		return nil, fmt.Errorf("Unknown path type %T", p)
 	}
@@ -145,6 +145,40 @@ func (r *ResourcesResolvable) Resolve(ctx context.Context) (interface{}, error)
 	return out, nil
 }
 
+// resourceHandles returns the identifiers of the resources of the capture c
+// that exist after the command at index after, keyed by their handle.
+func resourceHandles(ctx context.Context, c *path.Capture, after uint64, r *path.ResolveConfig) (map[string]*path.ID, error) {
+	resources, err := Resources(ctx, c, r)
+	if err != nil {
+		return nil, err
+	}
+	return resourcesByHandle(resources, after), nil
+}
+
+// resourcesByHandle returns the identifiers of the resources that exist after
+// the command at index after, keyed by their handle. Handles shared by more
+// than one of the resources, such as the object names of different GL share
+// groups, map to nil.
+func resourcesByHandle(resources *service.Resources, after uint64) map[string]*path.ID {
+	out := map[string]*path.ID{}
+	for _, t := range resources.GetTypes() {
+		for _, res := range t.Resources {
+			if res.Created.GetIndices()[0] > after {
+				continue
+			}
+			if res.Deleted != nil && res.Deleted.Indices[0] <= after {
+				continue
+			}
+			if _, ok := out[res.Handle]; ok {
+				out[res.Handle] = nil
+			} else {
+				out[res.Handle] = res.ID
+			}
+		}
+	}
+	return out
+}
+
 type trackedResource struct {
 	resource api.Resource
 	id       id.ID
@@ -0,0 +1,70 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestResourcesByHandle(t *testing.T) {
+	ctx := log.Testing(t)
+	c := &path.Capture{}
+	resource := func(handle string, created, deleted uint64) *service.Resource {
+		out := &service.Resource{
+			ID:      path.NewID(genResourceID(created, 1)),
+			Handle:  handle,
+			Created: c.Command(created),
+		}
+		if deleted > 0 {
+			out.Deleted = c.Command(deleted)
+		}
+		return out
+	}
+	texture := resource("Texture<1>", 2, 0)
+	shader := resource("Shader<1>", 3, 6)
+	shared := []*service.Resource{resource("Texture<2>", 1, 0), resource("Texture<2>", 4, 0)}
+	resources := &service.Resources{Types: []*service.ResourcesByType{
+		{Resources: append([]*service.Resource{texture}, shared...)},
+		{Resources: []*service.Resource{shader}},
+	}}
+
+	for _, test := range []struct {
+		after    uint64
+		expected map[string]*path.ID
+	}{
+		{0, map[string]*path.ID{}},
+		{2, map[string]*path.ID{
+			"Texture<1>": texture.ID,
+			"Texture<2>": shared[0].ID,
+		}},
+		{5, map[string]*path.ID{
+			"Texture<1>": texture.ID,
+			"Texture<2>": nil,
+			"Shader<1>":  shader.ID,
+		}},
+		{6, map[string]*path.ID{
+			"Texture<1>": texture.ID,
+			"Texture<2>": nil,
+		}},
+	} {
+		got := resourcesByHandle(resources, test.after)
+		assert.For(ctx, "resourcesByHandle(%v)", test.after).That(got).DeepEquals(test.expected)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Scene resolves and returns an experimental reconstruction of the geometry
+// drawn by the frame of p, as a glTF scene with one mesh per draw call.
+// The vertices are those received by the vertex shaders, so the meshes are
+// not transformed into a common space. Meshes are only given a material, from
+// the first image they sample, for APIs implementing api.DrawBundleProvider.
+// Draw calls whose meshes cannot be resolved are skipped.
+func Scene(ctx context.Context, p *path.Scene, r *path.ResolveConfig) (*api.Scene, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := frameCommandRange(ctx, p.Capture, p.Frame, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	draws, err := drawCalls(ctx, p.Capture, cmds, start, end, r)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.Scene{}
+	b := newGLTFBuilder()
+	for _, draw := range draws {
+		mesh, err := Mesh(ctx, draw.Mesh(&path.MeshOptions{}), r)
+		if err == nil {
+			err = b.addMesh(fmt.Sprint(draw), mesh, sceneMaterial(ctx, b, draw, r))
+		}
+		if err != nil {
+			log.W(ctx, "Skipping mesh of %v: %v", draw, err)
+			out.SkippedDraws++
+			continue
+		}
+		out.Draws++
+	}
+
+	if out.Gltf, err = b.build(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// drawCalls returns the paths to the draw calls of the commands in the range
+// [start, end) of the capture c, including the draw calls executed as
+// subcommands.
+func drawCalls(ctx context.Context, c *path.Capture, cmds []api.Cmd, start, end uint64, r *path.ResolveConfig) ([]*path.Command, error) {
+	snc, err := SyncData(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []*path.Command{}
+	err = api.ForeachCmd(ctx, cmds[:end], true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if err := cmd.Mutate(ctx, id, st, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		if uint64(id) < start {
+			return nil
+		}
+		refs, ok := snc.SubcommandReferences[id]
+		if !ok {
+			if cmd.CmdFlags(ctx, id, st).IsDrawCall() {
+				out = append(out, c.Command(uint64(id)))
+			}
+			return nil
+		}
+		for _, ref := range refs {
+			p := c.Command(uint64(id), ref.Index...)
+			sub, err := Cmd(ctx, p, r)
+			if err != nil {
+				return err
+			}
+			// As for the draw call stats, the state is not used by the flags
+			// of subcommands.
+			if sub.CmdFlags(ctx, id, nil).IsExecutedDraw() {
+				out = append(out, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// sceneMaterial returns the index of the material of b for the first image
+// sampled by the draw call p that can be converted to PNG, adding the material
+// if needed. -1 is returned if the draw call has no such image.
+func sceneMaterial(ctx context.Context, b *gltfBuilder, p *path.Command, r *path.ResolveConfig) int {
+	bundle, err := DrawBundle(ctx, p.DrawBundle(), r)
+	if err != nil {
+		return -1
+	}
+	for _, img := range bundle.Images {
+		if i := b.material(img.Handle); i >= 0 {
+			return i
+		}
+		if img.ImageFormat == nil || img.Depth > 1 {
+			continue
+		}
+		png, err := image.Convert(img.Data, int(img.Width), int(img.Height), 1, img.ImageFormat, image.PNG)
+		if err != nil {
+			continue
+		}
+		return b.addMaterial(img.Handle, png)
+	}
+	return -1
+}
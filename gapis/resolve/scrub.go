@@ -0,0 +1,183 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// ScrubCapture returns a new capture holding the commands of the capture p,
+// with the application data identified by the api.Scrubber of each command's
+// API replaced by placeholders of the same size. The contents of textures and
+// buffers are replaced with zeros, names are replaced with 'x' characters,
+// and shader sources are replaced with empty shaders. The framebuffer
+// observations are removed, as they are images of the frames rendered by the
+// application, and so is the thumbnail of the capture's metadata made from
+// them. The name and serial of the capture device are removed.
+func ScrubCapture(ctx context.Context, p *path.Capture) (*path.Capture, error) {
+	c, err := capture.ResolveGraphicsFromPath(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if is := c.InitialState; is != nil && (len(is.Memory) > 0 || len(is.APIs) > 0) {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrScrubInitialState()}
+	}
+
+	a := arena.New()
+	s := c.NewState(ctx)
+	cmds := make([]api.Cmd, len(c.Commands))
+	zeros := map[uint64]id.ID{}
+	for i, cmd := range c.Commands {
+		cmds[i] = cmd
+		if hasFramebufferObservation(cmd) {
+			clone := cmd.Clone(a)
+			*clone.Extras() = api.CmdExtras{}
+			for _, e := range cmd.Extras().All() {
+				if _, ok := e.(*capture.FramebufferObservation); !ok {
+					clone.Extras().Add(e)
+				}
+			}
+			cmds[i] = clone
+		}
+		o := cmd.Extras().Observations()
+		if scrubber, ok := cmd.API().(api.Scrubber); ok && o != nil {
+			o.ApplyReads(s.Memory.ApplicationPool())
+			if reads := scrubber.ScrubbableReads(ctx, cmd, s); len(reads) > 0 {
+				scrubbed := &api.CmdObservations{
+					Reads:  append([]api.CmdObservation{}, o.Reads...),
+					Writes: o.Writes,
+				}
+				for _, r := range reads {
+					read := &scrubbed.Reads[r.Index]
+					switch r.Kind {
+					case api.ScrubAsset:
+						data, ok := zeros[read.Range.Size]
+						if !ok {
+							if data, err = database.Store(ctx, make([]byte, read.Range.Size)); err != nil {
+								return nil, err
+							}
+							zeros[read.Range.Size] = data
+						}
+						read.ID = data
+					case api.ScrubName:
+						if read.ID, err = scrubData(ctx, read.ID, placeholderName); err != nil {
+							return nil, err
+						}
+					case api.ScrubShader:
+						if read.ID, err = scrubData(ctx, read.ID, placeholderShader); err != nil {
+							return nil, err
+						}
+					}
+				}
+				if cmds[i] == cmd {
+					clone := cmd.Clone(a)
+					*clone.Extras() = append(api.CmdExtras{}, cmd.Extras().All()...)
+					cmds[i] = clone
+				}
+				cmds[i].Extras().Replace(o, scrubbed)
+			}
+		}
+		// Commands that fail to mutate are kept, as they are elsewhere.
+		cmd.Mutate(ctx, api.CmdID(i), s, nil, nil)
+	}
+
+	header := proto.Clone(c.Header).(*capture.Header)
+	if header.Device != nil {
+		header.Device.Serial = ""
+		header.Device.Name = ""
+	}
+
+	gc, err := capture.NewGraphicsCapture(ctx, a, "scrubbed", header, c.InitialState, cmds)
+	if err != nil {
+		return nil, err
+	}
+	return capture.New(ctx, gc)
+}
+
+// hasFramebufferObservation returns true if cmd has a framebuffer observation.
+func hasFramebufferObservation(cmd api.Cmd) bool {
+	for _, e := range cmd.Extras().All() {
+		if _, ok := e.(*capture.FramebufferObservation); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubData stores and returns the identifier of the placeholder returned by
+// placeholder for the data identified by data.
+func scrubData(ctx context.Context, data id.ID, placeholder func([]byte) []byte) (id.ID, error) {
+	obj, err := database.Resolve(ctx, data)
+	if err != nil {
+		return id.ID{}, err
+	}
+	b, ok := obj.([]byte)
+	if !ok {
+		return id.ID{}, fmt.Errorf("Observed data %v is %T, not bytes", data, obj)
+	}
+	return database.Store(ctx, placeholder(b))
+}
+
+// placeholderName returns a copy of the string data b, with each non-zero byte
+// replaced by an 'x'.
+func placeholderName(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i, c := range b {
+		if c != 0 {
+			out[i] = 'x'
+		}
+	}
+	return out
+}
+
+// placeholderShader returns a copy of the shader source string b of the same
+// size, holding only the #version directive of b and, if b holds the main
+// function, an empty main function. The rest of b is replaced with spaces,
+// keeping its zero bytes.
+func placeholderShader(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i, c := range b {
+		if c != 0 {
+			out[i] = ' '
+		}
+	}
+	end := bytes.IndexByte(b, 0)
+	if end < 0 {
+		end = len(b)
+	}
+	n := 0
+	if bytes.HasPrefix(b, []byte("#version")) {
+		if n = bytes.IndexByte(b[:end], '\n') + 1; n == 0 {
+			n = end
+		}
+		copy(out, b[:n])
+	}
+	if main := []byte("void main(){}"); bytes.Contains(b[:end], []byte("main")) && n+len(main) <= end {
+		copy(out[n:], main)
+	}
+	return out
+}
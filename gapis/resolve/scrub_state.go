@@ -0,0 +1,138 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// StateScrubber resolves a set of state values after a cursor that moves
+// between the commands of a capture, reporting the values that change with
+// each move. Moving the cursor forwards only mutates the commands between the
+// previous and the new cursor.
+type StateScrubber struct {
+	paths  []*path.Any
+	nodes  []path.Node
+	r      *path.ResolveConfig
+	values []*box.Value // The values after the previous move.
+	moved  bool
+
+	capture *path.Capture
+	cmds    []api.Cmd
+	state   *api.GlobalState
+	next    api.CmdID // The identifier of the next command to mutate.
+}
+
+// NewStateScrubber returns a StateScrubber of the state values of paths. The
+// command each path is rooted at is ignored.
+func NewStateScrubber(ctx context.Context, paths []*path.Any, r *path.ResolveConfig) (*StateScrubber, error) {
+	nodes := make([]path.Node, len(paths))
+	for i, p := range paths {
+		nodes[i] = p.Node()
+		if findState(nodes[i]) == nil {
+			if _, g := stateNodes(nodes[i]); g == nil {
+				return nil, &service.ErrInvalidPath{
+					Reason: messages.ErrNotAStatePath(),
+					Path:   p,
+				}
+			}
+		}
+	}
+	return &StateScrubber{
+		paths:  paths,
+		nodes:  nodes,
+		r:      r,
+		values: make([]*box.Value, len(paths)),
+	}, nil
+}
+
+// Move moves the cursor to the state after cmd, returning the values that
+// changed since the previous move. The first move returns all the values.
+func (s *StateScrubber) Move(ctx context.Context, cmd *path.Command) (*service.StateDelta, error) {
+	if len(cmd.Indices) != 1 {
+		return nil, fmt.Errorf("Subcommands currently not supported for ScrubState") // TODO: Subcommands
+	}
+	ctx = SetupContext(ctx, cmd.Capture, s.r)
+	if s.capture == nil || !s.capture.ID.SameAs(cmd.Capture.ID) {
+		cmds, err := Cmds(ctx, cmd.Capture)
+		if err != nil {
+			return nil, err
+		}
+		s.capture, s.cmds, s.state = cmd.Capture, cmds, nil
+	}
+
+	id := api.CmdID(cmd.Indices[0])
+	if int(id) >= len(s.cmds) {
+		return nil, errPathOOB(uint64(id), "Index", 0, uint64(len(s.cmds))-1, cmd)
+	}
+	if s.state == nil || id < s.next {
+		state, err := capture.NewState(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.state, s.next = state, 0
+	}
+	first := s.next
+	err := api.ForeachCmd(ctx, s.cmds[first:id+1], true, func(ctx context.Context, i api.CmdID, c api.Cmd) error {
+		if err := c.Mutate(ctx, first+i, s.state, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", c, err)
+		}
+		return nil
+	})
+	if err != nil {
+		// The state is only partially mutated, so start again next move.
+		s.state = nil
+		return nil, err
+	}
+	s.next = id + 1
+
+	out := &service.StateDelta{Command: cmd, Changes: []*service.StateChange{}}
+	a := s.cmds[id].API()
+	for i, n := range s.nodes {
+		var value *box.Value
+		if v, ok := stateObjectAfter(ctx, n, cmd, a, s.state, s.r); ok {
+			value = box.NewValue(v)
+		}
+		if s.moved && sameValue(s.values[i], value) {
+			continue
+		}
+		s.values[i] = value
+		out.Changes = append(out.Changes, &service.StateChange{Index: uint32(i), Value: value})
+	}
+	s.moved = true
+	return out, nil
+}
+
+// Dispose releases the state held by the StateScrubber.
+func (s *StateScrubber) Dispose(ctx context.Context) {
+	s.capture, s.cmds, s.state = nil, nil, nil
+}
+
+// sameValue returns true if the boxed values a and b are equal, or both nil.
+func sameValue(a, b *box.Value) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return proto.Equal(a, b)
+}
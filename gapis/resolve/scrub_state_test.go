@@ -0,0 +1,73 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestStateScrubber(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	p := newPathTest(ctx)
+	s := p.Command(0).StateAfter()
+
+	paths := []*path.Any{
+		s.Field("Str").Path(),
+		s.Field("Ref").Field("RefObject").Field("value").Path(),
+	}
+	scrubber, err := NewStateScrubber(ctx, paths, nil)
+	if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+		return
+	}
+	defer scrubber.Dispose(ctx)
+
+	change := func(i uint32, v interface{}) *service.StateChange {
+		if v == nil {
+			return &service.StateChange{Index: i}
+		}
+		return &service.StateChange{Index: i, Value: box.NewValue(v)}
+	}
+	for _, test := range []struct {
+		cmd      uint64
+		expected []*service.StateChange
+	}{
+		{0, []*service.StateChange{change(0, ""), change(1, nil)}},
+		{2, []*service.StateChange{change(0, "aaa"), change(1, uint32(555))}},
+		{2, []*service.StateChange{}},
+		{0, []*service.StateChange{change(0, ""), change(1, nil)}},
+	} {
+		ctx := log.V{"cmd": test.cmd}.Bind(ctx)
+		got, err := scrubber.Move(ctx, p.Command(test.cmd))
+		if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+			continue
+		}
+		assert.For(ctx, "command").That(got.Command).DeepEquals(p.Command(test.cmd))
+		assert.For(ctx, "changes").That(got.Changes).DeepEquals(test.expected)
+	}
+
+	_, err = NewStateScrubber(ctx, []*path.Any{p.Command(0).Result().Path()}, nil)
+	assert.For(ctx, "non-state path").ThatError(err).Failed()
+}
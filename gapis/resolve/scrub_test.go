@@ -0,0 +1,129 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+)
+
+func TestScrubCapture(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	p := newPathTest(ctx)
+	ctx = capture.Put(ctx, p)
+
+	scrubbed, err := ScrubCapture(ctx, p)
+	if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+		return
+	}
+	cmds, err := Cmds(ctx, scrubbed)
+	if assert.For(ctx, "err").ThatError(err).Succeeded() {
+		assert.For(ctx, "cmds").That(len(cmds)).Equals(3)
+	}
+}
+
+func TestScrubFramebufferObservations(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	h := &capture.Header{ABI: device.WindowsX86_64}
+	a := arena.New()
+	cb := test.CommandBuilder{Arena: a}
+	cmds := []api.Cmd{cb.CmdVoid(), cb.CmdEndOfFrame()}
+	pixels := []byte{
+		0x3c, 0x91, 0xe7, 0x05, 0x5a, 0xb2, 0x6f, 0xd8,
+		0x14, 0xa3, 0xc6, 0x7e, 0x29, 0xf0, 0x88, 0x4d,
+	}
+	cmds[1].Extras().Add(&capture.FramebufferObservation{
+		OriginalWidth:  2,
+		OriginalHeight: 2,
+		DataWidth:      2,
+		DataHeight:     2,
+		Data:           pixels,
+	})
+	c, err := capture.NewGraphicsCapture(ctx, a, "observed", h, nil, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+	p, err := c.Path(ctx)
+	if !assert.For(ctx, "capture.Path").ThatError(err).Succeeded() {
+		return
+	}
+
+	scrubbed, err := ScrubCapture(ctx, p)
+	if !assert.For(ctx, "ScrubCapture").ThatError(err).Succeeded() {
+		return
+	}
+	sc, err := capture.ResolveGraphicsFromPath(ctx, scrubbed)
+	if !assert.For(ctx, "resolve").ThatError(err).Succeeded() {
+		return
+	}
+	for i, cmd := range sc.Commands {
+		for _, e := range cmd.Extras().All() {
+			_, observed := e.(*capture.FramebufferObservation)
+			assert.For(ctx, "cmd[%d] observation", i).That(observed).Equals(false)
+		}
+	}
+	metadata, err := sc.Metadata(ctx)
+	if assert.For(ctx, "metadata").ThatError(err).Succeeded() {
+		assert.For(ctx, "thumbnail").That(metadata.Thumbnail == nil).Equals(true)
+	}
+
+	// The exported capture, including its metadata, holds no observed pixels.
+	buf := bytes.Buffer{}
+	if err := capture.Export(ctx, scrubbed, &buf); assert.For(ctx, "export").ThatError(err).Succeeded() {
+		assert.For(ctx, "pixels exported").That(bytes.Contains(buf.Bytes(), pixels)).Equals(false)
+	}
+}
+
+func TestPlaceholderShader(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			"versioned",
+			"#version 300 es\nout vec4 c;\nvoid main() { c = vec4(1.0); }\x00",
+			"#version 300 es\nvoid main(){}" + strings.Repeat(" ", 29) + "\x00",
+		},
+		{"no main", "uniform vec4 u;\x00", "               \x00"},
+		{"shortest main", "void main(){}", "void main(){}"},
+		{"version only", "#version 100", "#version 100"},
+		{"empty", "", ""},
+	} {
+		assert.For(ctx, test.name).That(string(placeholderShader([]byte(test.source)))).Equals(test.expected)
+	}
+}
+
+func TestPlaceholderName(t *testing.T) {
+	ctx := log.Testing(t)
+	assert.For(ctx, "name").That(string(placeholderName([]byte("app\x00")))).Equals("xxx\x00")
+	assert.For(ctx, "empty").That(len(placeholderName(nil))).Equals(0)
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/google/gapid/core/data/deep"
 	"github.com/google/gapid/core/data/dictionary"
 	"github.com/google/gapid/core/memory/arena"
@@ -52,7 +53,12 @@ func (r *SetResolvable) Resolve(ctx context.Context) (interface{}, error) {
 		return nil, err
 	}
 
-	p, err := change(ctx, a, r.Path.Node(), v, r.Config)
+	target, err := resolveStableCommand(ctx, r.Path.Node())
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := change(ctx, a, target, v, r.Config)
 	if err != nil {
 		return nil, err
 	}
@@ -153,10 +159,18 @@ func change(ctx context.Context, a arena.Arena, p path.Node, val interface{}, r
 	case *path.Commands:
 		return nil, fmt.Errorf("Commands can not be changed directly")
 
-	case *path.State:
-		return nil, fmt.Errorf("State can not currently be mutated")
+	case *path.State, *path.GlobalState:
+		return nil, fmt.Errorf("State can only be mutated one value at a time")
 
 	case *path.Field, *path.Parameter, *path.ArrayIndex, *path.MapIndex:
+		after, nodes, err := stateValueNodes(ctx, p, r)
+		if err != nil {
+			return nil, err
+		}
+		if after != nil {
+			return changeState(ctx, a, p, after, nodes, val, r)
+		}
+
 		oldObj, err := ResolveInternal(ctx, p.Parent(), r)
 		if err != nil {
 			return nil, err
@@ -355,6 +369,126 @@ func changeResources(ctx context.Context, a arena.Arena, after *path.Command, id
 	}
 }
 
+// stateValueNodes returns the command that the state of p is after, along with
+// the field, array index and map index nodes to follow from the global state
+// to the value at p, or a nil command if p is not a path to a value of the
+// state.
+func stateValueNodes(ctx context.Context, p path.Node, r *path.ResolveConfig) (*path.Command, []path.Node, error) {
+	nodes := []path.Node{}
+	for n := p; n != nil; n = n.Parent() {
+		switch n := n.(type) {
+		case *path.Field, *path.ArrayIndex, *path.MapIndex:
+			nodes = append([]path.Node{n}, nodes...)
+		case *path.GlobalState:
+			return n.After, nodes, nil
+		case *path.State:
+			cmd, err := Cmd(ctx, n.After, r)
+			if err != nil {
+				return nil, nil, err
+			}
+			if cmd.API() == nil {
+				return nil, nil, &service.ErrDataUnavailable{Reason: messages.ErrStateUnavailable()}
+			}
+			root, _ := stateNodes(APIStateAfter(n.After, cmd.API().ID()))
+			return n.After, append(root, nodes...), nil
+		default:
+			return nil, nil, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// changeState returns the path p in a copy of the capture of after, in which
+// the command after sets the value found by following nodes from the global
+// state to val, once the command has been mutated.
+func changeState(ctx context.Context, a arena.Arena, p path.Node, after *path.Command, nodes []path.Node, val interface{}, r *path.ResolveConfig) (path.Node, error) {
+	if len(after.Indices) > 1 {
+		return nil, fmt.Errorf("Cannot modify the state after subcommands") // TODO: Subcommands
+	}
+	cmdIdx := after.Indices[0]
+
+	oldCmds, err := NCmds(ctx, after.Capture, cmdIdx+1)
+	if err != nil {
+		return nil, err
+	}
+	oldCmd := oldCmds[cmdIdx]
+
+	edit, err := api.NewStateEdit(nodes, val)
+	if err != nil {
+		return nil, err
+	}
+	if editor, ok := oldCmd.API().(api.StateEditor); !ok || !editor.CanEditState(oldCmd, edit) {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrStateEditNotSupported()}
+	}
+
+	if err := checkStateValue(ctx, p, val, r); err != nil {
+		return nil, err
+	}
+
+	// Clone the command list, replacing the command with an edited clone
+	cmds := make([]api.Cmd, len(oldCmds))
+	copy(cmds, oldCmds)
+	cmd := oldCmd.Clone(a)
+	*cmd.Extras() = append(api.CmdExtras{}, oldCmd.Extras().All()...)
+	cmd.Extras().Add(edit)
+	cmds[cmdIdx] = cmd
+
+	c, err := changeCommands(ctx, a, after.Capture, cmds)
+	if err != nil {
+		return nil, err
+	}
+
+	out := proto.Clone(p.Path()).(*path.Any).Node()
+	return path.Transform(out, func(n path.Node) path.Node {
+		if _, ok := n.(*path.Capture); ok {
+			return c
+		}
+		return n
+	}), nil
+}
+
+// checkStateValue returns an error if the value of the state at p cannot be
+// set to val. New map entries may be added, like with any other map.
+func checkStateValue(ctx context.Context, p path.Node, val interface{}, r *path.ResolveConfig) error {
+	if p, ok := p.(*path.MapIndex); ok {
+		obj, err := ResolveInternal(ctx, p.Parent(), r)
+		if err != nil {
+			return err
+		}
+		d := dictionary.From(obj)
+		if d == nil {
+			return &service.ErrInvalidPath{
+				Reason: messages.ErrTypeNotMapIndexable(typename(reflect.TypeOf(obj))),
+				Path:   p.Path(),
+			}
+		}
+		if _, ok := convert(reflect.ValueOf(p.KeyValue()), d.KeyTy()); !ok {
+			return &service.ErrInvalidPath{
+				Reason: messages.ErrIncorrectMapKeyType(
+					typename(reflect.TypeOf(p.KeyValue())), // got
+					typename(d.KeyTy())),                   // expected
+				Path: p.Path(),
+			}
+		}
+		if _, ok := convert(reflect.ValueOf(val), d.ValTy()); !ok {
+			return fmt.Errorf("Map at %s has value of type %v, got type %T",
+				p.Parent(), d.ValTy(), val)
+		}
+		return nil
+	}
+
+	old, err := ResolveInternal(ctx, p, r)
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		if _, ok := convert(reflect.ValueOf(val), reflect.TypeOf(old)); !ok {
+			return fmt.Errorf("State value at %v has type %T, got type %T", p, old, val)
+		}
+	}
+	return nil
+}
+
 func changeCommands(ctx context.Context, a arena.Arena, p *path.Capture, newCmds []api.Cmd) (*path.Capture, error) {
 	old, err := capture.ResolveGraphicsFromPath(ctx, p)
 	if err != nil {
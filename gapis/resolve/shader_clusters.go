@@ -0,0 +1,242 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"sort"
+	"unicode"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// defaultMinShaderSimilarity is the minimum similarity used when the path
+// does not specify one.
+const defaultMinShaderSimilarity = 0.9
+
+// ShaderClusters resolves and returns the groups of near-duplicate shaders of
+// the capture of p. The source of each shader is resolved at the command that
+// created it. Shaders whose source cannot be resolved are ignored.
+func ShaderClusters(ctx context.Context, p *path.ShaderClusters, r *path.ResolveConfig) (*service.ShaderClusters, error) {
+	resources, err := Resources(ctx, p.Capture, r)
+	if err != nil {
+		return nil, err
+	}
+
+	shaders := []*clusterShader{}
+	for _, t := range resources.Types {
+		if t.Type != api.ResourceType_ShaderResource {
+			continue
+		}
+		for _, res := range t.Resources {
+			at := res.Created
+			if at == nil && len(res.Accesses) > 0 {
+				at = res.Accesses[0]
+			}
+			if at == nil {
+				continue
+			}
+			data, err := ResourceData(ctx, at.ResourceAfter(res.ID), r)
+			if err != nil {
+				log.W(ctx, "Could not get the source of shader %v: %v", res.Handle, err)
+				continue
+			}
+			shader := data.(*api.ResourceData).GetShader()
+			if shader == nil {
+				continue
+			}
+			shaders = append(shaders, &clusterShader{
+				id:     res.ID,
+				handle: res.Handle,
+				ty:     shader.Type,
+				tokens: shaderTokens(shader.Source),
+			})
+		}
+	}
+
+	min := p.MinSimilarity
+	if min == 0 {
+		min = defaultMinShaderSimilarity
+	}
+	return &service.ShaderClusters{Clusters: clusterShaders(shaders, min)}, nil
+}
+
+// clusterShader is a shader considered for clustering.
+type clusterShader struct {
+	id     *path.ID
+	handle string
+	ty     api.ShaderType
+	tokens []string
+}
+
+// clusterShaders groups the shaders of the same type where each shader is at
+// least min similar to another shader of its group. Only groups of two or more
+// shaders are returned, largest first.
+func clusterShaders(shaders []*clusterShader, min float32) []*service.ShaderCluster {
+	// Union-find of the shader indices.
+	parent := make([]int, len(shaders))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	best := make([]float32, len(shaders))
+	for i, a := range shaders {
+		for j := i + 1; j < len(shaders); j++ {
+			b := shaders[j]
+			if a.ty != b.ty {
+				continue
+			}
+			// The similarity can not exceed the ratio of the lengths.
+			if maxTokenSimilarity(len(a.tokens), len(b.tokens)) < min {
+				continue
+			}
+			s := tokenSimilarity(a.tokens, b.tokens, min)
+			if s < min {
+				continue
+			}
+			if s > best[i] {
+				best[i] = s
+			}
+			if s > best[j] {
+				best[j] = s
+			}
+			parent[find(j)] = find(i)
+		}
+	}
+
+	byRoot := map[int]*service.ShaderCluster{}
+	out := []*service.ShaderCluster{}
+	for i, s := range shaders {
+		if best[i] == 0 {
+			continue
+		}
+		root := find(i)
+		c, ok := byRoot[root]
+		if !ok {
+			c = &service.ShaderCluster{Type: s.ty}
+			byRoot[root] = c
+			out = append(out, c)
+		}
+		c.Shaders = append(c.Shaders, &service.ShaderClusterMember{
+			ID:         s.id,
+			Handle:     s.handle,
+			Similarity: best[i],
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return len(out[i].Shaders) > len(out[j].Shaders)
+	})
+	return out
+}
+
+// shaderTokens splits the shader source into its identifiers, numbers and
+// punctuation, ignoring whitespace and comments.
+func shaderTokens(src string) []string {
+	out := []string{}
+	runes := []rune(src)
+	isWord := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '%'
+	}
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case isWord(r):
+			start := i
+			for i < len(runes) && isWord(runes[i]) {
+				i++
+			}
+			out = append(out, string(runes[start:i]))
+		default:
+			out = append(out, string(r))
+			i++
+		}
+	}
+	return out
+}
+
+// maxTokenSimilarity returns the highest similarity possible between token
+// sequences of the lengths a and b.
+func maxTokenSimilarity(a, b int) float32 {
+	if a+b == 0 {
+		return 1
+	}
+	if a > b {
+		a, b = b, a
+	}
+	return float32(2*a) / float32(a+b)
+}
+
+// tokenSimilarity returns the similarity, from 0 to 1, of the token sequences
+// a and b, as the fraction of the tokens of both that are part of their
+// longest common subsequence, or 0 if the similarity is less than min.
+// The subsequence is found with Myers' algorithm, which takes O((N+M)D) time
+// for D tokens not in the subsequence, and gives up once D is too large for
+// the similarity to reach min, so near-duplicates are compared quickly and
+// other shaders are rejected early.
+func tokenSimilarity(a, b []string, min float32) float32 {
+	n, m := len(a), len(b)
+	if n+m == 0 {
+		return 1
+	}
+	similarity := func(d int) float32 { return float32(n+m-d) / float32(n+m) }
+	maxD := n + m
+	for maxD > 0 && similarity(maxD) < min {
+		maxD--
+	}
+
+	// v holds the furthest index of a reached on each diagonal k = x - y,
+	// offset by maxD+1.
+	v := make([]int, 2*maxD+3)
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			i := k + maxD + 1
+			x := v[i-1] + 1
+			if k == -d || (k != d && v[i-1] < v[i+1]) {
+				x = v[i+1]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[i] = x
+			if x >= n && y >= m {
+				return similarity(d)
+			}
+		}
+	}
+	return 0
+}
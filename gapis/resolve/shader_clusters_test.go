@@ -0,0 +1,84 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+func TestShaderTokens(t *testing.T) {
+	ctx := log.Testing(t)
+	src := "// header\nvoid main() { /* body */ gl_Position = vec4(0.5); }"
+	assert.For(ctx, "tokens").That(shaderTokens(src)).DeepEquals([]string{
+		"void", "main", "(", ")", "{", "gl_Position", "=", "vec4", "(", "0.5", ")", ";", "}",
+	})
+}
+
+func TestClusterShaders(t *testing.T) {
+	ctx := log.Testing(t)
+	shader := func(handle string, ty api.ShaderType, src string) *clusterShader {
+		return &clusterShader{handle: handle, ty: ty, tokens: shaderTokens(src)}
+	}
+	base := "void main() { color = texture(tex, uv) * tint; gl_FragDepth = 0.5; }"
+	shaders := []*clusterShader{
+		shader("a", api.ShaderType_Fragment, base),
+		shader("b", api.ShaderType_Vertex, base),
+		shader("c", api.ShaderType_Fragment, "void main() { color = vec4(1); }"),
+		shader("d", api.ShaderType_Fragment, base),
+		shader("e", api.ShaderType_Fragment, "void main() { color = texture(tex, uv) * tint; gl_FragDepth = 1.0; }"),
+	}
+
+	clusters := clusterShaders(shaders, 0.9)
+	assert.For(ctx, "clusters").That(len(clusters)).Equals(1)
+	c := clusters[0]
+	assert.For(ctx, "type").That(c.Type).Equals(api.ShaderType_Fragment)
+	handles := []string{}
+	for _, s := range c.Shaders {
+		handles = append(handles, s.Handle)
+	}
+	assert.For(ctx, "handles").That(handles).DeepEquals([]string{"a", "d", "e"})
+	assert.For(ctx, "identical").That(c.Shaders[0].Similarity).Equals(float32(1))
+	assert.For(ctx, "near").That(c.Shaders[2].Similarity < 1).Equals(true)
+
+	assert.For(ctx, "strict").That(len(clusterShaders(shaders[:4], 1))).Equals(1)
+}
+
+func TestTokenSimilarity(t *testing.T) {
+	ctx := log.Testing(t)
+	tokens := func(s string) []string { return strings.Fields(s) }
+	for _, test := range []struct {
+		a, b     string
+		min      float32
+		expected float32
+	}{
+		{"", "", 0.9, 1},
+		{"a b c", "a b c", 1, 1},
+		{"a b c", "", 0, 0},
+		{"a b c d", "a c d", 0, 6.0 / 7},
+		{"a b c d", "b a d c", 0, 0.5},
+		{"a b c d", "b a d c", 0.6, 0},
+		{"a b c d e f g h i j", "a b c d e f g h i k", 0.9, 0.9},
+		{"a b c d e f g h i j", "a b c d e f g h k l", 0.9, 0},
+	} {
+		got := tokenSimilarity(tokens(test.a), tokens(test.b), test.min)
+		assert.For(ctx, "tokenSimilarity(%v, %v, %v)", test.a, test.b, test.min).
+			That(got).Equals(test.expected)
+	}
+}
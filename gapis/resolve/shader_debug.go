@@ -0,0 +1,122 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/shadertools"
+	"github.com/google/gapid/gapis/shadertools/spirv"
+)
+
+// defaultShaderDebugSteps is the maximum number of instructions executed by a
+// shader emulation, if not specified by the path.
+const defaultShaderDebugSteps = 10000
+
+// maxShaderDebugSteps is the upper bound on the number of instructions executed
+// by a shader emulation, as each step of the trace is held in memory.
+const maxShaderDebugSteps = 1000000
+
+// ShaderDebugTrace resolves and returns the trace of the emulation of the
+// shader invocation at p. The shader is compiled to SPIR-V and interpreted
+// one instruction at a time, starting with its captured inputs.
+func ShaderDebugTrace(ctx context.Context, p *path.ShaderDebugTrace, r *path.ResolveConfig) (*service.ShaderDebugTrace, error) {
+	inputs, err := ShaderInputs(ctx, p.Inputs, r)
+	if err != nil {
+		return nil, err
+	}
+
+	shaderType := shadertools.TypeVertex
+	if p.Inputs.GetFragment() != nil {
+		shaderType = shadertools.TypeFragment
+	}
+	words, err := shadertools.CompileGlsl(inputs.Source, shadertools.CompileOptions{
+		ShaderType: shaderType,
+		ClientType: shadertools.OpenGLES,
+	})
+	if err != nil {
+		return nil, err
+	}
+	m, err := spirv.Parse(words)
+	if err != nil {
+		return nil, err
+	}
+	in, err := spirv.NewInvocation(m, "main")
+	if err != nil {
+		return nil, err
+	}
+	for _, input := range inputs.Inputs {
+		name := strings.TrimSuffix(input.Name, "[0]")
+		if err := in.Set(name, input.Value.Get()); err != nil {
+			log.W(ctx, "Failed to set shader input %v: %v", input.Name, err)
+		}
+	}
+
+	out := &service.ShaderDebugTrace{}
+	for _, inst := range m.Instructions {
+		out.Instructions = append(out.Instructions, inst.String())
+	}
+
+	breakpoints := map[uint32]bool{}
+	for _, b := range p.Breakpoints {
+		breakpoints[b] = true
+	}
+	maxSteps := int(p.MaxSteps)
+	switch {
+	case maxSteps == 0:
+		maxSteps = defaultShaderDebugSteps
+	case maxSteps > maxShaderDebugSteps:
+		maxSteps = maxShaderDebugSteps
+	}
+
+	for !in.Done() {
+		if len(out.Steps) == maxSteps {
+			out.Truncated = true
+			break
+		}
+		step, err := in.Step()
+		if err != nil {
+			out.Error = err.Error()
+			break
+		}
+		if step == nil {
+			break
+		}
+		inst := m.Instructions[step.Instruction]
+		s := &service.ShaderDebugStep{
+			Instruction: uint32(step.Instruction),
+			Opcode:      spirv.OpcodeName(inst.Opcode),
+			Line:        step.Line,
+			Name:        step.Name,
+			Note:        step.Note,
+		}
+		if step.Value != nil {
+			if v := step.Value.Interface(); v != nil {
+				s.Value = box.NewValue(v)
+			}
+		}
+		if breakpoints[s.Instruction] {
+			out.BreakpointHits = append(out.BreakpointHits, uint32(len(out.Steps)))
+		}
+		out.Steps = append(out.Steps, s)
+	}
+	out.Discarded = in.Discarded
+	return out, nil
+}
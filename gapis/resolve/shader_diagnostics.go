@@ -0,0 +1,136 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/shadertools"
+)
+
+// ShaderDiagnostics compiles the edited shader that is to replace the shader
+// resource p and returns the errors and warnings reported by the compiler,
+// without modifying the capture. If the replay device in r uses desktop
+// OpenGL, the shader is also checked to be translatable for that device, as
+// it would be during replay.
+func ShaderDiagnostics(ctx context.Context, p *path.ResourceData, shader *api.Shader, r *path.ResolveConfig) ([]*service.ShaderDiagnostic, error) {
+	resources, err := Resources(ctx, p.After.Capture, r)
+	if err != nil {
+		return nil, err
+	}
+	if ty, ok := resources.ResourcesToTypes[p.ID.ID().String()]; !ok || ty != api.ResourceType_ShaderResource {
+		return nil, &service.ErrInvalidPath{
+			Reason: messages.ErrNotAShader(),
+			Path:   p.Path(),
+		}
+	}
+
+	switch shader.GetType() {
+	case api.ShaderType_Spirv:
+		if shadertools.AssembleSpirvText(shader.GetSource()) == nil {
+			return []*service.ShaderDiagnostic{{
+				Severity: service.Severity_ErrorLevel,
+				Message:  "Failed to assemble SPIR-V",
+			}}, nil
+		}
+		return []*service.ShaderDiagnostic{}, nil
+	case api.ShaderType_SpirvBinary:
+		if len(shader.GetSource())%4 != 0 {
+			return []*service.ShaderDiagnostic{{
+				Severity: service.Severity_ErrorLevel,
+				Message:  "Invalid SPIR-V, number of bytes is not a multiple of 4",
+			}}, nil
+		}
+		return []*service.ShaderDiagnostic{}, nil
+	}
+
+	ty, err := glslShaderType(shader.GetType())
+	if err != nil {
+		return nil, err
+	}
+
+	diags := shadertools.CheckGlsl(shader.GetSource(), shadertools.CompileOptions{
+		ShaderType: ty,
+		ClientType: shadertools.OpenGLES,
+	})
+
+	compiled := true
+	for _, d := range diags {
+		compiled = compiled && d.Warning
+	}
+
+	if compiled && r.GetReplayDevice() != nil {
+		d, err := Device(ctx, r.GetReplayDevice(), r)
+		if err != nil {
+			return nil, err
+		}
+		version := d.GetConfiguration().GetDrivers().GetOpengl().GetVersion()
+		if version != "" && !strings.HasPrefix(version, "OpenGL ES") {
+			// Check we are able to convert this GLES shader to desktop GL,
+			// as the replay will.
+			opts := shadertools.ConvertOptions{
+				ShaderType:        ty,
+				CheckAfterChanges: true,
+				TargetGLSLVersion: 430,
+			}
+			if _, err := shadertools.ConvertGlsl(shader.GetSource(), &opts); err != nil {
+				diags = append(diags, shadertools.Diagnostic{
+					Message: "The shader cannot be translated for the replay device: " +
+						strings.SplitN(err.Error(), "\n", 2)[0],
+				})
+			}
+		}
+	}
+
+	out := make([]*service.ShaderDiagnostic, len(diags))
+	for i, d := range diags {
+		out[i] = &service.ShaderDiagnostic{
+			Severity: service.Severity_ErrorLevel,
+			Line:     uint32(d.Line),
+			Column:   uint32(d.Column),
+			Message:  d.Message,
+		}
+		if d.Warning {
+			out[i].Severity = service.Severity_WarningLevel
+		}
+	}
+	return out, nil
+}
+
+// glslShaderType returns the shadertools.ShaderType for the GLSL shader type
+// t.
+func glslShaderType(t api.ShaderType) (shadertools.ShaderType, error) {
+	switch t {
+	case api.ShaderType_Vertex:
+		return shadertools.TypeVertex, nil
+	case api.ShaderType_Geometry:
+		return shadertools.TypeGeometry, nil
+	case api.ShaderType_TessControl:
+		return shadertools.TypeTessControl, nil
+	case api.ShaderType_TessEvaluation:
+		return shadertools.TypeTessEvaluation, nil
+	case api.ShaderType_Fragment:
+		return shadertools.TypeFragment, nil
+	case api.ShaderType_Compute:
+		return shadertools.TypeCompute, nil
+	}
+	return 0, &service.ErrInvalidArgument{Reason: messages.ErrNotAShader()}
+}
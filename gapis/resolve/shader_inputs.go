@@ -0,0 +1,76 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/replay/devices"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// ShaderInputs resolves and returns the attributes, varyings and uniform
+// values seen by the single vertex or fragment shader invocation of the draw
+// call at p, captured by replaying the draw call with instrumented shaders.
+func ShaderInputs(ctx context.Context, p *path.ShaderInputs, r *path.ResolveConfig) (*service.ShaderInputs, error) {
+	if len(p.Command.Indices) != 1 {
+		return nil, fmt.Errorf("Shader inputs of subcommands are not currently supported")
+	}
+
+	cmd, err := Cmd(ctx, p.Command, r)
+	if err != nil {
+		return nil, err
+	}
+	id := api.CmdID(p.Command.Indices[0])
+
+	// Whether the command is a draw call is checked by the replay, which has
+	// the state of the capture at the command anyway.
+	query, ok := cmd.API().(replay.QueryShaderInputs)
+	if !ok {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrShaderInputsNotAvailable()}
+	}
+
+	device := r.GetReplayDevice()
+	if device == nil {
+		devices, err := devices.ForReplay(ctx, p.Command.Capture)
+		if err != nil {
+			return nil, err
+		}
+		if len(devices) == 0 {
+			return nil, fmt.Errorf("No compatible replay devices found")
+		}
+		device = devices[0]
+	}
+
+	ctx = SetupContext(ctx, p.Command.Capture, r)
+	intent := replay.Intent{
+		Device:  device,
+		Capture: p.Command.Capture,
+	}
+	return query.QueryShaderInputs(
+		ctx,
+		intent,
+		replay.GetManager(ctx),
+		id,
+		p.GetVertex(),
+		p.GetFragment(),
+		&service.UsageHints{Background: true},
+	)
+}
@@ -108,18 +108,28 @@ func state(ctx context.Context, p *path.State, r *path.ResolveConfig) (interface
 		return nil, nil, api.ID{}, err
 	}
 
-	state := g.APIs[a.ID()]
+	obj, abs, err := apiState(ctx, p, g.APIs[a.ID()], r)
+	if err != nil {
+		return nil, nil, api.ID{}, err
+	}
+	return obj, abs, a.ID(), nil
+}
+
+// apiState returns the root object, and its absolute path, of the API state
+// for the state path p.
+func apiState(ctx context.Context, p *path.State, state api.State, r *path.ResolveConfig) (interface{}, path.Node, error) {
 	if state == nil {
-		return nil, nil, api.ID{}, &service.ErrDataUnavailable{Reason: messages.ErrStateUnavailable()}
+		return nil, nil, &service.ErrDataUnavailable{Reason: messages.ErrStateUnavailable()}
 	}
 
 	root, err := state.Root(ctx, p, r)
 	if err != nil {
-		return nil, nil, api.ID{}, err
+		return nil, nil, err
 	}
 	if root == nil {
-		return nil, nil, api.ID{}, &service.ErrDataUnavailable{Reason: messages.ErrStateUnavailable()}
+		return nil, nil, &service.ErrDataUnavailable{Reason: messages.ErrStateUnavailable()}
 	}
+	a := state.API()
 
 	// Transform the State path node to a GlobalState node to prevent the
 	// object load recursing back into this function.
@@ -134,10 +144,10 @@ func state(ctx context.Context, p *path.State, r *path.ResolveConfig) (interface
 
 	obj, err := Get(ctx, abs.Path(), r)
 	if err != nil {
-		return nil, nil, api.ID{}, err
+		return nil, nil, err
 	}
 
-	return obj, abs, a.ID(), nil
+	return obj, abs, nil
 }
 
 // APIStateAfter returns an absolute path to the API state after c.
@@ -0,0 +1,76 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// FindStateChange returns the path to the nearest command after (or before, if
+// backwards is true) the command that p is rooted at, which changes the state
+// value at p. If equals is not nil, then only commands that change the value
+// to equals are considered.
+func FindStateChange(ctx context.Context, p path.Node, backwards bool, equals *box.Value, r *path.ResolveConfig) (*path.Command, error) {
+	nodes, start, err := globalStateRelative(ctx, p, r)
+	if err != nil {
+		return nil, err
+	}
+	startIdx := api.CmdID(start.Indices[0])
+
+	matches := func(v *box.Value) bool {
+		return equals == nil || proto.Equal(v, equals)
+	}
+
+	var found *path.Command
+	if backwards {
+		if startIdx > 0 {
+			end := start.Capture.Command(uint64(startIdx - 1))
+			err = foreachStateChange(ctx, end, nodes, r, func(id api.CmdID, v *box.Value) error {
+				if matches(v) {
+					found = start.Capture.Command(uint64(id))
+				}
+				return nil
+			})
+		}
+	} else {
+		var cmds []api.Cmd
+		if cmds, err = Cmds(ctx, start.Capture); err != nil {
+			return nil, err
+		}
+		end := start.Capture.Command(uint64(len(cmds) - 1))
+		err = foreachStateChange(ctx, end, nodes, r, func(id api.CmdID, v *box.Value) error {
+			if id > startIdx && matches(v) {
+				found = start.Capture.Command(uint64(id))
+				return api.Break
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoStateChange()}
+	}
+	return found, nil
+}
@@ -0,0 +1,198 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// maxStateSearchDepth is the deepest level of the state tree visited by a
+// state search. Deeper members are not searched.
+const maxStateSearchDepth = 32
+
+// StateSearch resolves and returns the members of the state referenced by p
+// whose names or values match the search of p.
+func StateSearch(ctx context.Context, p *path.StateSearch, r *path.ResolveConfig) (*service.StateSearchResults, error) {
+	var re *regexp.Regexp
+	if p.Pattern != "" {
+		var err error
+		if re, err = regexp.Compile(p.Pattern); err != nil {
+			return nil, &service.ErrInvalidArgument{
+				Reason: messages.ErrInvalidSearchPattern(p.Pattern, err.Error()),
+			}
+		}
+	}
+	if re == nil && p.Range == nil && !p.NonFinite {
+		return nil, &service.ErrInvalidArgument{Reason: messages.ErrEmptyStateSearch()}
+	}
+
+	// The tree is not stored in the database, as the search visits all of its
+	// nodes, which are not otherwise needed once the search is done.
+	obj, err := (&StateTreeResolvable{Path: p.State, Config: r}).Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return searchStateTree(ctx, obj.(*stateTree), p, re)
+}
+
+// stateSearch holds the progress of a search of a state tree.
+type stateSearch struct {
+	tree    *stateTree
+	p       *path.StateSearch
+	re      *regexp.Regexp
+	visited map[interface{}]bool
+	out     *service.StateSearchResults
+}
+
+// searchStateTree returns the members of tree that match the search of p,
+// using re as the compiled pattern of p.
+func searchStateTree(ctx context.Context, tree *stateTree, p *path.StateSearch, re *regexp.Regexp) (*service.StateSearchResults, error) {
+	s := &stateSearch{
+		tree:    tree,
+		p:       p,
+		re:      re,
+		visited: map[interface{}]bool{},
+		out:     &service.StateSearchResults{},
+	}
+	if _, err := s.walk(ctx, tree.root, 0); err != nil {
+		return nil, err
+	}
+	return s.out, nil
+}
+
+// walk searches the children of n, and their descendants, returning false
+// once the search has found the maximum number of results.
+func (s *stateSearch) walk(ctx context.Context, n *stn, depth int) (bool, error) {
+	if err := task.StopReason(ctx); err != nil {
+		return false, err
+	}
+
+	n.buildChildren(ctx, s.tree)
+	for _, c := range n.children {
+		if !c.value.IsValid() {
+			continue
+		}
+		if !c.isSubgroup && s.matches(c) {
+			if max := s.p.MaxResults; max > 0 && uint32(len(s.out.Results)) == max {
+				s.out.Truncated = true
+				return false, nil
+			}
+			preview, _ := stateValuePreview(c.value, nil)
+			s.out.Results = append(s.out.Results, &service.StateSearchResult{
+				Path:    c.path.Path(),
+				Name:    c.name,
+				Preview: preview,
+			})
+		}
+		if depth+1 < maxStateSearchDepth && s.enter(c) {
+			if more, err := s.walk(ctx, c, depth+1); !more || err != nil {
+				return more, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// enter returns true if the children of n should be searched.
+// Memory is not searched, and references are only searched the first time
+// they are visited, so that cycles in the state are not followed.
+func (s *stateSearch) enter(n *stn) bool {
+	if n.isSubgroup {
+		return true
+	}
+	v := n.value
+	if t := v.Type(); box.IsMemoryPointer(t) || box.IsMemorySlice(t) {
+		return false
+	}
+	if isNil(v) {
+		return false
+	}
+	if _, ok := v.Interface().(interface{ IsNil() bool }); ok && v.Type().Comparable() {
+		key := v.Interface()
+		if s.visited[key] {
+			return false
+		}
+		s.visited[key] = true
+	}
+	return true
+}
+
+// matches returns true if the name or value of n match the search.
+func (s *stateSearch) matches(n *stn) bool {
+	if s.p.NonFinite && !isNonFinite(n.value) {
+		return false
+	}
+	if r := s.p.Range; r != nil {
+		f, ok := numericValue(n.value)
+		if !ok || f < r.Min || f > r.Max {
+			return false
+		}
+	}
+	if s.re == nil {
+		return true
+	}
+
+	// If neither is requested, both names and values are matched.
+	names, values := s.p.MatchNames, s.p.MatchValues
+	if !names && !values {
+		names, values = true, true
+	}
+	if names && s.re.MatchString(n.name) {
+		return true
+	}
+	if values {
+		if _, isValue := stateValuePreview(n.value, nil); isValue {
+			return s.re.MatchString(fmt.Sprint(deref(n.value).Interface()))
+		}
+	}
+	return false
+}
+
+// isNonFinite returns true if v holds a NaN or infinite float.
+func isNonFinite(v reflect.Value) bool {
+	v = deref(v)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return math.IsNaN(f) || math.IsInf(f, 0)
+	default:
+		return false
+	}
+}
+
+// numericValue returns the value of v as a float64, if v holds a number.
+func numericValue(v reflect.Value) (float64, bool) {
+	v = deref(v)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// StateSnippet renders the state tree node p, and depth levels of its
+// children, as a text snippet in the given format. Values with a constant set
+// are printed using the constant names. A negative depth renders all of the
+// node's descendants.
+func StateSnippet(ctx context.Context, p *path.StateTreeNode, format service.SnippetFormat, depth int32, r *path.ResolveConfig) (string, error) {
+	boxed, err := database.Resolve(ctx, p.Tree.ID())
+	if err != nil {
+		return "", err
+	}
+	return stateSnippet(ctx, boxed.(*stateTree), p, format, depth, r)
+}
+
+func stateSnippet(ctx context.Context, tree *stateTree, p *path.StateTreeNode, format service.SnippetFormat, depth int32, r *path.ResolveConfig) (string, error) {
+	node, err := findStateTreeNode(ctx, tree, p)
+	if err != nil {
+		return "", err
+	}
+
+	constants := map[string]*service.ConstantSet{}
+	value := func(n *service.StateTreeNode) string {
+		if n.Preview == nil {
+			return ""
+		}
+		v := n.Preview.Get()
+		out := fmt.Sprint(v)
+		if n.Constants != nil {
+			key := fmt.Sprint(n.Constants)
+			cs, ok := constants[key]
+			if !ok {
+				cs, _ = ConstantSet(ctx, n.Constants, r)
+				constants[key] = cs
+			}
+			if cs != nil {
+				out = cs.Sprint(v)
+			}
+		}
+		if _, isString := v.(string); !n.PreviewIsValue && !isString {
+			out += "…"
+		}
+		return out
+	}
+
+	buf := &bytes.Buffer{}
+	var render func(n *stn, level int32)
+	render = func(n *stn, level int32) {
+		s := n.service(ctx, tree)
+		indent := strings.Repeat("  ", int(level))
+		v := value(s)
+		switch format {
+		case service.SnippetFormat_Markdown:
+			if v != "" {
+				fmt.Fprintf(buf, "%v- **%v**: `%v`\n", indent, s.Name, v)
+			} else {
+				fmt.Fprintf(buf, "%v- **%v**\n", indent, s.Name)
+			}
+		default:
+			if v != "" {
+				fmt.Fprintf(buf, "%v%v: %v\n", indent, s.Name, v)
+			} else {
+				fmt.Fprintf(buf, "%v%v:\n", indent, s.Name)
+			}
+		}
+		if depth >= 0 && level >= depth {
+			return
+		}
+		n.mutex.Lock()
+		children := n.children
+		n.mutex.Unlock()
+		for _, c := range children {
+			render(c, level+1)
+		}
+	}
+	render(node, 0)
+	return buf.String(), nil
+}
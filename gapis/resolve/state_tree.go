@@ -15,15 +15,25 @@
 package resolve
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/context/keys"
 	"github.com/google/gapid/core/data/dictionary"
 	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/math/u64"
+	"github.com/google/gapid/core/text"
 	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/database"
 	"github.com/google/gapid/gapis/memory"
 	"github.com/google/gapid/gapis/service"
@@ -33,11 +43,30 @@ import (
 
 // StateTree resolves the specified state tree path.
 func StateTree(ctx context.Context, c *path.StateTree, r *path.ResolveConfig) (*service.StateTree, error) {
-	id, err := database.Store(ctx, &StateTreeResolvable{
+	resolvable := &StateTreeResolvable{
 		Path:           c.State,
 		ArrayGroupSize: c.ArrayGroupSize,
 		Config:         r,
-	})
+		Preview:        c.Preview,
+		KeyOrder:       c.KeyOrder,
+		HideDefaults:   c.HideDefaults,
+		AllApis:        c.AllApis,
+		CreatedBy:      c.CreatedBy,
+	}
+	if c.Pinned != nil {
+		// Build the tree like the pinned tree, so the nodes share indices.
+		pinned, err := database.Resolve(ctx, c.Pinned.ID())
+		if err != nil {
+			return nil, err
+		}
+		p := pinned.(*stateTree)
+		resolvable.ArrayGroupSize = int32(p.groupLimit)
+		resolvable.KeyOrder = p.keyOrder
+		resolvable.HideDefaults = p.hideDefs
+		resolvable.AllApis = p.allAPIs
+		resolvable.Pinned = c.Pinned
+	}
+	id, err := database.Store(ctx, resolvable)
 	if err != nil {
 		return nil, err
 	}
@@ -48,10 +77,21 @@ func StateTree(ctx context.Context, c *path.StateTree, r *path.ResolveConfig) (*
 
 type stateTree struct {
 	globalState *api.GlobalState
+	prevOnce    sync.Once
+	prevState   *api.GlobalState // The state before the command, or nil. Use before.
 	state       interface{}
 	root        *stn
 	api         *path.API
 	groupLimit  uint64
+	preview     *path.StatePreviewOptions
+	after       *path.Command       // The command the state is after.
+	resources   map[string]*path.ID // The resources after the command, by handle.
+	keyOrder    path.StateTree_KeyOrder
+	hideDefs    bool       // Whether fields holding default values are omitted.
+	allAPIs     bool       // Whether the root has a child per API.
+	pinned      *stateTree // The tree the nodes are compared against, or nil.
+	createdBy   bool       // Whether map entries link to their handle's creator.
+	config      *path.ResolveConfig
 }
 
 // needsSubgrouping returns true if the child count exceeds the group limit and
@@ -105,6 +145,41 @@ func StateTreeNode(ctx context.Context, p *path.StateTreeNode, r *path.ResolveCo
 	return stateTreeNode(ctx, boxed.(*stateTree), p)
 }
 
+// StateTreeChildren resolves and returns count children of the state tree
+// node p, starting at the child index offset.
+func StateTreeChildren(ctx context.Context, p *path.StateTreeNode, offset uint64, count uint32, r *path.ResolveConfig) (*service.StateTreeChildren, error) {
+	boxed, err := database.Resolve(ctx, p.Tree.ID())
+	if err != nil {
+		return nil, err
+	}
+	return stateTreeChildren(ctx, boxed.(*stateTree), p, offset, count)
+}
+
+func stateTreeChildren(ctx context.Context, tree *stateTree, p *path.StateTreeNode, offset uint64, count uint32) (*service.StateTreeChildren, error) {
+	node, err := findStateTreeNode(ctx, tree, p)
+	if err != nil {
+		return nil, err
+	}
+	node.buildChildren(ctx, tree)
+
+	total := uint64(len(node.children))
+	out := &service.StateTreeChildren{
+		Total:    total,
+		Children: []*service.StateTreeNode{},
+	}
+	if offset >= total {
+		return out, nil
+	}
+	end := offset + uint64(count)
+	if end > total {
+		end = total
+	}
+	for _, c := range node.children[offset:end] {
+		out.Children = append(out.Children, c.service(ctx, tree))
+	}
+	return out, nil
+}
+
 // StateTreeNodeForPath returns the path to the StateTreeNode representing the
 // path p.
 func StateTreeNodeForPath(ctx context.Context, p *path.StateTreeNodeForPath, r *path.ResolveConfig) (*path.StateTreeNode, error) {
@@ -122,7 +197,44 @@ func StateTreeNodeForPath(ctx context.Context, p *path.StateTreeNodeForPath, r *
 	}, nil
 }
 
+// FindStateTreeNodes returns the paths to the nodes of the state tree with
+// the given stable identifiers, such as the identifiers of the expanded and
+// selected nodes of the tree of the state after another command.
+func FindStateTreeNodes(ctx context.Context, tree *path.ID, ids []string, r *path.ResolveConfig) (*service.StateTreeNodeMatches, error) {
+	boxed, err := database.Resolve(ctx, tree.ID())
+	if err != nil {
+		return nil, err
+	}
+	return findStateTreeNodes(ctx, boxed.(*stateTree), tree, ids), nil
+}
+
+func findStateTreeNodes(ctx context.Context, tree *stateTree, treeID *path.ID, ids []string) *service.StateTreeNodeMatches {
+	out := &service.StateTreeNodeMatches{Matches: make([]*service.StateTreeNodeMatch, len(ids))}
+	for i, id := range ids {
+		out.Matches[i] = &service.StateTreeNodeMatch{StableId: id}
+		if id == tree.root.id {
+			out.Matches[i].Node = &path.StateTreeNode{Tree: treeID, Indices: []uint64{}}
+		} else if indices := tree.root.findByID(ctx, id, tree); indices != nil {
+			out.Matches[i].Node = &path.StateTreeNode{Tree: treeID, Indices: indices}
+		}
+	}
+	return out
+}
+
 func stateTreeNode(ctx context.Context, tree *stateTree, p *path.StateTreeNode) (*service.StateTreeNode, error) {
+	node, err := findStateTreeNode(ctx, tree, p)
+	if err != nil {
+		return nil, err
+	}
+	out := node.service(ctx, tree)
+	if p.Prefetch {
+		node.prefetch(ctx, tree)
+	}
+	return out, nil
+}
+
+// findStateTreeNode returns the node of tree at the indices of p.
+func findStateTreeNode(ctx context.Context, tree *stateTree, p *path.StateTreeNode) (*stn, error) {
 	node := tree.root
 	for i, idx64 := range p.Indices {
 		var err error
@@ -136,7 +248,7 @@ func stateTreeNode(ctx context.Context, tree *stateTree, p *path.StateTreeNode)
 			return nil, err
 		}
 	}
-	return node.service(ctx, tree), nil
+	return node, nil
 }
 
 func stateTreeNodePath(ctx context.Context, tree *stateTree, p path.Node) ([]uint64, error) {
@@ -162,13 +274,21 @@ func (e errIndexOOB) Error() string { return fmt.Sprintf("index %d out of bounds
 
 type stn struct {
 	mutex          sync.Mutex
+	id             string // The stable identifier of the node. See stableNodeID.
 	name           string
 	value          reflect.Value
 	path           path.Node
 	consts         *path.ConstantSet
+	keyConsts      *path.ConstantSet // The constant set of the keys of a map.
+	docs           string
+	units          string
 	children       []*stn
 	isSubgroup     bool
 	subgroupOffset uint64
+	isFlag         bool        // A single flag of the bitfield value of the parent.
+	isPointee      bool        // A part of the value pointed to by a memory pointer.
+	api            *path.API   // The API of the value, if not that of the tree.
+	key            interface{} // The key of the map entry held by the node, or nil.
 }
 
 func (n *stn) index(ctx context.Context, i uint64, tree *stateTree) (*stn, error) {
@@ -179,9 +299,30 @@ func (n *stn) index(ctx context.Context, i uint64, tree *stateTree) (*stn, error
 	return n.children[i], nil
 }
 
+// prefetch builds the children of each of the node's children in the
+// background. n must have already built its children.
+func (n *stn) prefetch(ctx context.Context, tree *stateTree) {
+	n.mutex.Lock()
+	children := n.children
+	n.mutex.Unlock()
+
+	ctx = keys.Clone(context.Background(), ctx)
+	crash.Go(func() {
+		for _, c := range children {
+			c.buildChildren(ctx, tree)
+		}
+	})
+}
+
 func (n *stn) findByPath(ctx context.Context, p path.Node, tree *stateTree) []uint64 {
 	n.buildChildren(ctx, tree)
 	for i, c := range n.children {
+		// The subgroups of a map, the groups of a struct's fields, and the
+		// flags of a bitfield, share the path of their parent, so only the
+		// children of the subgroups can match.
+		if c.path == n.path {
+			continue
+		}
 		if path.HasRoot(p, c.path) {
 			return []uint64{uint64(i)}
 		}
@@ -206,16 +347,59 @@ func (n *stn) buildChildren(ctx context.Context, tree *stateTree) {
 
 	v, t, children := n.value, n.value.Type(), []*stn{}
 
+	if set := n.bitfield(ctx); set != nil {
+		n.children = bitfieldFlags(set, v, n.path)
+		assignIDs(n, n.children)
+		return
+	}
+
+	// The children of a memory pointer are those of its pointee.
+	pointer := box.IsMemoryPointer(t)
+	if pointer {
+		if v = n.pointee(ctx, tree); !v.IsValid() {
+			n.children = children
+			return
+		}
+		t = v.Type()
+	}
+
+	var keys []interface{}
 	dict := dictionary.From(v.Interface())
+	if r, ok := v.Interface().(stateMapRange); ok {
+		dict, keys = r.dict, r.keys
+	} else if dict != nil {
+		keys = orderKeys(dict, tree.keyOrder)
+	}
+
+	cols, nested := matrixColumns(v)
 
 	switch {
+	case cols != nil:
+		children = matrixRows(cols, nested, n.path)
+
 	case dict != nil:
-		for _, key := range dict.Keys() {
-			children = append(children, &stn{
-				name:  fmt.Sprint(key),
-				value: deref(reflect.ValueOf(dict.Get(key))),
-				path:  path.NewMapIndex(key, n.path),
-			})
+		keyName := n.keyNamer(ctx)
+		count := uint64(len(keys))
+		if needsSubgrouping(tree.groupLimit, count) {
+			for i, c := uint64(0), subgroupCount(tree.groupLimit, count); i < c; i++ {
+				s, e := subgroupRange(tree.groupLimit, count, i)
+				children = append(children, &stn{
+					name:       fmt.Sprintf("[%v - %v]", keyName(keys[s]), keyName(keys[e-1])),
+					value:      reflect.ValueOf(stateMapRange{dict, keys[s:e]}),
+					path:       n.path,
+					keyConsts:  n.keyConsts,
+					isSubgroup: true,
+				})
+			}
+		} else {
+			for _, key := range keys {
+				children = append(children, &stn{
+					name:  keyName(key),
+					value: deref(reflect.ValueOf(dict.Get(key))),
+					path:  path.NewMapIndex(key, n.path),
+					key:   key,
+				})
+			}
 		}
 
 	case box.IsMemorySlice(t):
@@ -281,24 +465,350 @@ func (n *stn) buildChildren(ctx context.Context, tree *stateTree) {
 			if !ok {
 				break
 			}
+			// Properties with a group are placed under a synthesized node
+			// for the group, positioned at the group's first property.
+			groups := map[string]*stn{}
 			for _, p := range pp.Properties() {
-				var consts *path.ConstantSet
+				var consts, keyConsts *path.ConstantSet
 				if p.Constants >= 0 {
-					consts = tree.api.ConstantSet(p.Constants)
+					consts = n.apiPath(tree).ConstantSet(p.Constants)
 				}
-				children = append(children, &stn{
-					name:   p.Name,
-					value:  deref(reflect.ValueOf(p.Get())),
-					path:   path.NewField(p.Name, n.path),
-					consts: consts,
-				})
+				if p.KeyConstants >= 0 {
+					keyConsts = n.apiPath(tree).ConstantSet(p.KeyConstants)
+				}
+				value := deref(reflect.ValueOf(p.Get()))
+				if tree.hideDefs && isDefault(value) {
+					continue
+				}
+				child := &stn{
+					name:      p.Name,
+					value:     value,
+					path:      path.NewField(p.Name, n.path),
+					consts:    consts,
+					keyConsts: keyConsts,
+					docs:      p.Docs,
+					units:     p.Units,
+				}
+				if p.Group == "" {
+					children = append(children, child)
+					continue
+				}
+				group, ok := groups[p.Group]
+				if !ok {
+					group = &stn{
+						name:       p.Group,
+						value:      v,
+						path:       n.path,
+						children:   []*stn{},
+						isSubgroup: true,
+					}
+					groups[p.Group] = group
+					children = append(children, group)
+				}
+				group.children = append(group.children, child)
 			}
 		}
 	}
 
+	if pointer || n.isPointee {
+		markPointee(children)
+	}
+	if n.api != nil {
+		inheritAPI(children, n.api)
+	}
+	assignIDs(n, children)
 	n.children = children
 }
 
+// stableNodeID returns the stable identifier of a state tree node with the
+// value path p. The identifier is derived from the members of the path below
+// the command, so the nodes for the same member of the states after different
+// commands share the same identifier.
+func stableNodeID(p path.Node) string {
+	parts := []string{}
+walk:
+	for n := p; n != nil; n = n.Parent() {
+		switch n := n.(type) {
+		case *path.Command:
+			break walk
+		case *path.Field:
+			parts = append(parts, "."+n.Name)
+		case *path.ArrayIndex:
+			parts = append(parts, fmt.Sprintf("[%v]", n.Index))
+		case *path.MapIndex:
+			parts = append(parts, fmt.Sprintf("[%v]", n.KeyValue()))
+		case *path.Slice:
+			parts = append(parts, fmt.Sprintf("[%v:%v]", n.Start, n.End))
+		default:
+			parts = append(parts, reflect.TypeOf(n).Elem().Name())
+		}
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, "")
+}
+
+// assignIDs sets the stable identifiers of the nodes, the children of parent,
+// and of the children of the groups among them. Nodes that share the path of
+// their parent, such as subgroups and the flags of bitfields, are identified
+// by their name below their parent.
+func assignIDs(parent *stn, nodes []*stn) {
+	for _, c := range nodes {
+		if c.path == parent.path {
+			c.id = parent.id + "/" + c.name
+		} else {
+			c.id = stableNodeID(c.path)
+		}
+		assignIDs(c, c.children)
+	}
+}
+
+// findByID returns the child indices of the descendant of n with the stable
+// identifier id, or nil if n has no such descendant. Only the children whose
+// identifier prefixes id, or that share the path of n, are searched.
+func (n *stn) findByID(ctx context.Context, id string, tree *stateTree) []uint64 {
+	n.buildChildren(ctx, tree)
+	for i, c := range n.children {
+		if c.id == id {
+			return []uint64{uint64(i)}
+		}
+	}
+	for i, c := range n.children {
+		if c.path == n.path || isIDPrefix(c.id, id) {
+			if ci := c.findByID(ctx, id, tree); ci != nil {
+				return append([]uint64{uint64(i)}, ci...)
+			}
+		}
+	}
+	return nil
+}
+
+// isIDPrefix returns true if the stable identifier prefix is that of an
+// ancestor of the node with the identifier id.
+func isIDPrefix(prefix, id string) bool {
+	return len(id) > len(prefix) && strings.HasPrefix(id, prefix) &&
+		strings.ContainsRune(".[/", rune(id[len(prefix)]))
+}
+
+// apiPath returns the path to the API of the value of n.
+func (n *stn) apiPath(tree *stateTree) *path.API {
+	if n.api != nil {
+		return n.api
+	}
+	return tree.api
+}
+
+// inheritAPI sets the API of the nodes, and of the children of the groups
+// among them, to a.
+func inheritAPI(nodes []*stn, a *path.API) {
+	for _, c := range nodes {
+		c.api = a
+		inheritAPI(c.children, a)
+	}
+}
+
+// pointee returns the value pointed to by the memory pointer held by n, or an
+// invalid value if the pointer is null, points to untyped bytes as pointers
+// to void do, or if the pointee cannot be loaded.
+func (n *stn) pointee(ctx context.Context, tree *stateTree) reflect.Value {
+	p := box.AsMemoryPointer(n.value)
+	if p.IsNullptr() || p.ElementType().Kind() == reflect.Uint8 {
+		return reflect.Value{}
+	}
+	s := tree.globalState
+	el, err := memory.LoadPointer(ctx, p, s.Memory, s.MemoryLayout)
+	if err != nil {
+		log.D(ctx, "Could not load the pointee of %v: %v", n.path, err)
+		return reflect.Value{}
+	}
+	return deref(reflect.ValueOf(el))
+}
+
+// markPointee marks the nodes, and the children of the groups among them, as
+// parts of the value pointed to by a memory pointer. Their paths are relative
+// to the pointer, so they cannot be resolved from the state.
+func markPointee(nodes []*stn) {
+	for _, c := range nodes {
+		c.isPointee = true
+		markPointee(c.children)
+	}
+}
+
+// matrixTypeName matches the names of the GLSL-like matrix types of the APIs,
+// such as Mat4f and Mat3x4f, whose elements are their column vectors.
+var matrixTypeName = regexp.MustCompile(`^Mat[2-4](x[2-4])?f$`)
+
+// matrixColumns returns the columns of the elements of v if v is a matrix,
+// or nil if it is not. A matrix is either a static array of a matrix type,
+// which is nested, or a flat array of 16 floats in column-major order.
+func matrixColumns(v reflect.Value) (cols [][]reflect.Value, nested bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	t := v.Type()
+	switch {
+	case box.IsBoxedArray(t) && matrixTypeName.MatchString(t.Name()):
+		arr := reflect.ValueOf(v.Interface().(box.BoxedArray).GetArrayValues())
+		for c := 0; c < arr.Len(); c++ {
+			if !box.IsBoxedArray(arr.Index(c).Type()) {
+				return nil, false
+			}
+			col := reflect.ValueOf(arr.Index(c).Interface().(box.BoxedArray).GetArrayValues())
+			els := make([]reflect.Value, col.Len())
+			for r := range els {
+				els[r] = col.Index(r)
+			}
+			cols = append(cols, els)
+		}
+		return cols, true
+	case t.Kind() == reflect.Array && v.Len() == 16:
+		if k := t.Elem().Kind(); k != reflect.Float32 && k != reflect.Float64 {
+			return nil, false
+		}
+		for c := 0; c < 4; c++ {
+			cols = append(cols, []reflect.Value{v.Index(c * 4), v.Index(c*4 + 1), v.Index(c*4 + 2), v.Index(c*4 + 3)})
+		}
+		return cols, false
+	}
+	return nil, false
+}
+
+// matrixRows returns the nodes of the rows of the matrix with the columns
+// cols, held by the value at p. Each row holds the nodes of its elements, one
+// per column, with the paths of the elements in the matrix.
+func matrixRows(cols [][]reflect.Value, nested bool, p path.Node) []*stn {
+	rows := []*stn{}
+	for r := range cols[0] {
+		row := reflect.MakeSlice(reflect.SliceOf(cols[0][r].Type()), 0, len(cols))
+		els := []*stn{}
+		for c, col := range cols {
+			elPath := path.NewArrayIndex(uint64(c*len(col)+r), p)
+			if nested {
+				elPath = path.NewArrayIndex(uint64(r), path.NewArrayIndex(uint64(c), p))
+			}
+			row = reflect.Append(row, col[r])
+			els = append(els, &stn{
+				name:  fmt.Sprint(c),
+				value: col[r],
+				path:  elPath,
+			})
+		}
+		rows = append(rows, &stn{
+			name:       fmt.Sprintf("row %d", r),
+			value:      row,
+			path:       p,
+			children:   els,
+			isSubgroup: true,
+		})
+	}
+	return rows
+}
+
+// matrixPreview returns the matrix with the columns cols formatted row by
+// row, such as "[[1 0] [0 1]]".
+func matrixPreview(cols [][]reflect.Value) string {
+	rows := make([]string, len(cols[0]))
+	for r := range rows {
+		els := make([]string, len(cols))
+		for c, col := range cols {
+			els[c] = fmt.Sprint(col[r].Interface())
+		}
+		rows[r] = "[" + strings.Join(els, " ") + "]"
+	}
+	return "[" + strings.Join(rows, " ") + "]"
+}
+
+// keyNamer returns a function returning the name of a key of the map held by
+// n, which is the name of its constant if n has a constant set for its keys.
+func (n *stn) keyNamer(ctx context.Context) func(key interface{}) string {
+	if n.keyConsts != nil {
+		if set, err := ConstantSet(ctx, n.keyConsts, nil); err == nil {
+			return func(key interface{}) string { return set.Sprint(key) }
+		}
+	}
+	return func(key interface{}) string { return fmt.Sprint(key) }
+}
+
+// bitfield returns the constant set of n if n holds an integer with a bitfield
+// constant set, otherwise nil.
+func (n *stn) bitfield(ctx context.Context) *service.ConstantSet {
+	if n.consts == nil {
+		return nil
+	}
+	switch n.value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil
+	}
+	set, err := ConstantSet(ctx, n.consts, nil)
+	if err != nil || !set.IsBitfield {
+		return nil
+	}
+	return set
+}
+
+// bitfieldFlags returns a node for each flag of set that is set in the value
+// v, followed by a node for any remaining bits without a flag. The nodes share
+// the path p of the bitfield value.
+func bitfieldFlags(set *service.ConstantSet, v reflect.Value, p path.Node) []*stn {
+	var bits uint64
+	if k := v.Kind(); k >= reflect.Int && k <= reflect.Int64 {
+		bits = uint64(v.Int())
+	} else {
+		bits = v.Uint()
+	}
+	flag := func(name string, value uint64) *stn {
+		return &stn{
+			name:   name,
+			value:  reflect.ValueOf(value).Convert(v.Type()),
+			path:   p,
+			isFlag: true,
+		}
+	}
+	out := []*stn{}
+	rest := bits
+	for _, c := range set.Constants {
+		// Flags of multiple bits are only listed when all their bits are set.
+		if c.Value != 0 && bits&c.Value == c.Value {
+			out = append(out, flag(c.Name, c.Value))
+			rest &^= c.Value
+		}
+	}
+	if rest != 0 {
+		out = append(out, flag(fmt.Sprintf("0x%x", rest), rest))
+	}
+	return out
+}
+
+// orderKeys returns the keys of the map wrapped by the dictionary d, in the
+// order o.
+func orderKeys(d dictionary.I, o path.StateTree_KeyOrder) []interface{} {
+	switch o {
+	case path.StateTree_Natural:
+		keys := d.Keys()
+		sort.SliceStable(keys, func(i, j int) bool {
+			return text.NaturalLess(fmt.Sprint(keys[i]), fmt.Sprint(keys[j]))
+		})
+		return keys
+	case path.StateTree_Reverse:
+		keys := d.Keys()
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+		return keys
+	}
+	return d.Keys()
+}
+
+// stateMapRange is the value of a state tree node grouping the entries of a
+// map with the sorted keys keys.
+type stateMapRange struct {
+	dict dictionary.I
+	keys []interface{}
+}
+
 // isNil returns true if v is a nil pointer or interface, or is a type that
 // implements the method:
 //   IsNil() bool
@@ -315,24 +825,323 @@ func isNil(v reflect.Value) bool {
 	return false
 }
 
+// Bounds of the estimates of the number of descendants of state tree nodes.
+const (
+	descendantsDepth   = 4 // The levels of descendants counted.
+	descendantsSamples = 8 // The elements of a collection counted.
+)
+
+// estimateDescendants returns an estimate of the number of descendants of
+// the value v, counted to depth levels below v. The descendants of the
+// elements of collections are extrapolated from those of their first
+// elements. Memory pointers, whose pointees are loaded lazily, have none.
+func estimateDescendants(v reflect.Value, depth int) uint64 {
+	if depth == 0 || !v.IsValid() || isNil(v) {
+		return 0
+	}
+	t := v.Type()
+	switch {
+	case box.IsMemoryPointer(t):
+		return 0
+	case box.IsMemorySlice(t):
+		return box.AsMemorySlice(v).Count()
+	case box.IsBoxedArray(t):
+		return estimateDescendants(reflect.ValueOf(v.Interface().(box.BoxedArray).GetArrayValues()), depth)
+	}
+	if r, ok := v.Interface().(stateMapRange); ok {
+		return sampleDescendants(len(r.keys), func(i int) reflect.Value {
+			return deref(reflect.ValueOf(r.dict.Get(r.keys[i])))
+		}, depth)
+	}
+	if d := dictionary.From(v.Interface()); d != nil {
+		keys := d.Keys()
+		return sampleDescendants(len(keys), func(i int) reflect.Value {
+			return deref(reflect.ValueOf(d.Get(keys[i])))
+		}, depth)
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return sampleDescendants(v.Len(), func(i int) reflect.Value { return deref(v.Index(i)) }, depth)
+	case reflect.Ptr, reflect.Interface:
+		return estimateDescendants(v.Elem(), depth)
+	}
+	if pp, ok := v.Interface().(api.PropertyProvider); ok {
+		props := pp.Properties()
+		return sampleDescendants(len(props), func(i int) reflect.Value {
+			return deref(reflect.ValueOf(props[i].Get()))
+		}, depth)
+	}
+	return 0
+}
+
+// sampleDescendants returns an estimate of the number of descendants of a
+// collection of count children, returned by child, counted to depth levels.
+func sampleDescendants(count int, child func(i int) reflect.Value, depth int) uint64 {
+	samples := count
+	if samples > descendantsSamples {
+		samples = descendantsSamples
+	}
+	if samples == 0 {
+		return 0
+	}
+	sum := uint64(0)
+	for i := 0; i < samples; i++ {
+		sum += estimateDescendants(child(i), depth-1)
+	}
+	return uint64(count) + sum*uint64(count)/uint64(samples)
+}
+
+// isDefault returns true if v holds the default value of its type: zero,
+// null, empty, or a class with only default fields. References that are not
+// null are never default, as they may form cycles.
+func isDefault(v reflect.Value) bool {
+	if !v.IsValid() || isNil(v) {
+		return true
+	}
+	t := v.Type()
+	switch {
+	case box.IsMemoryPointer(t):
+		return box.AsMemoryPointer(v).IsNullptr()
+	case box.IsMemorySlice(t):
+		return box.AsMemorySlice(v).Count() == 0
+	case box.IsBoxedArray(t):
+		return isDefault(reflect.ValueOf(v.Interface().(box.BoxedArray).GetArrayValues()))
+	}
+	if d := dictionary.From(v.Interface()); d != nil {
+		return d.Len() == 0
+	}
+	switch v.Kind() {
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !isDefault(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return false
+	case reflect.Struct:
+		if _, ok := v.Interface().(interface{ IsNil() bool }); ok {
+			return false
+		}
+		if pp, ok := v.Interface().(api.PropertyProvider); ok {
+			for _, p := range pp.Properties() {
+				if !isDefault(deref(reflect.ValueOf(p.Get()))) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(t).Interface())
+}
+
 func (n *stn) service(ctx context.Context, tree *stateTree) *service.StateTreeNode {
 	n.buildChildren(ctx, tree)
-	preview, previewIsValue := stateValuePreview(n.value)
+	preview, previewIsValue := stateValuePreview(n.value, tree.preview)
+	if set := n.bitfield(ctx); set != nil {
+		if flags := set.Sprint(n.value.Interface()); flags != "" {
+			preview, previewIsValue = box.NewValue(flags), false
+		}
+	}
+	if cols, _ := matrixColumns(n.value); cols != nil {
+		preview, previewIsValue = box.NewValue(matrixPreview(cols)), false
+	}
+	if p := n.memoryBytesPreview(ctx, tree); p != nil {
+		preview, previewIsValue = p, false
+	}
+	differs, pinned := n.comparePinned(ctx, tree)
+	valuePath := n.path.Path()
+	if n.isPointee {
+		// The paths of pointees are relative to their pointer, and cannot be
+		// resolved.
+		valuePath = nil
+	}
 	return &service.StateTreeNode{
-		NumChildren:    uint64(len(n.children)),
-		Name:           n.name,
-		ValuePath:      n.path.Path(),
-		Preview:        preview,
-		PreviewIsValue: previewIsValue,
-		Constants:      n.consts,
+		NumChildren:       uint64(len(n.children)),
+		Name:              n.name,
+		ValuePath:         valuePath,
+		StableId:          n.id,
+		Preview:           preview,
+		PreviewIsValue:    previewIsValue,
+		Constants:         n.consts,
+		Docs:              n.docs,
+		Changed:           n.changed(ctx, tree),
+		Units:             n.units,
+		Resource:          n.resource(tree),
+		TotalDescendants:  u64.Max(uint64(len(n.children)), estimateDescendants(n.value, descendantsDepth)),
+		HasNonFinite:      hasNonFinite(n.value),
+		DiffersFromPinned: differs,
+		PinnedPreview:     pinned,
+		CreatedBy:         n.creator(ctx, tree),
 	}
 }
 
+// creator returns the path to the command that created the object whose
+// handle is the map key of n, or nil if n does not hold a map entry, if its
+// key is not the handle of an object created by a command of the capture, if
+// the tree is of the state after a subcommand, or if the tree was not built to
+// link the creators.
+func (n *stn) creator(ctx context.Context, tree *stateTree) *path.Command {
+	if !tree.createdBy || n.key == nil || n.isPointee || tree.after == nil || len(tree.after.Indices) != 1 {
+		return nil
+	}
+	creators, err := resolveHandleCreators(ctx, tree.after.Capture, tree.config)
+	if err != nil {
+		log.W(ctx, "Could not resolve the creators of the handles: %v", err)
+		return nil
+	}
+	if id, ok := creators.creator(n.key, api.CmdID(tree.after.Indices[0])); ok {
+		return tree.after.Capture.Command(uint64(id))
+	}
+	return nil
+}
+
+// comparePinned returns whether the value of n differs from, or is missing
+// from, the member at the same path in the tree's pinned tree, and the
+// preview of that member. It returns false and nil if the tree is not
+// compared against a pinned tree, or if n does not hold a leaf value, as the
+// differences of any other value are shown by its descendants.
+func (n *stn) comparePinned(ctx context.Context, tree *stateTree) (bool, *box.Value) {
+	if tree.pinned == nil || n.isSubgroup || n.isFlag || n.isPointee || !isLeaf(n.value) {
+		return false, nil
+	}
+	nodes, g := stateNodes(n.path)
+	if g == nil {
+		return false, nil
+	}
+	obj, ok := stateObject(ctx, tree.pinned.globalState, nodes)
+	if !ok {
+		return true, nil
+	}
+	v := deref(reflect.ValueOf(obj))
+	if !v.IsValid() {
+		return true, nil
+	}
+	preview, _ := stateValuePreview(v, tree.preview)
+	return !leafEqual(n.value, v), preview
+}
+
+// hasNonFinite returns true if v holds a NaN or infinite float, or is an
+// array, such as a vector or matrix, holding one.
+func hasNonFinite(v reflect.Value) bool {
+	if !v.IsValid() || isNil(v) {
+		return false
+	}
+	v = deref(v)
+	t := v.Type()
+	switch {
+	case box.IsBoxedArray(t):
+		return hasNonFinite(reflect.ValueOf(v.Interface().(box.BoxedArray).GetArrayValues()))
+	case (t.Kind() == reflect.Array || t.Kind() == reflect.Slice) && holdsFloats(t.Elem()):
+		for i := 0; i < v.Len(); i++ {
+			if hasNonFinite(v.Index(i)) {
+				return true
+			}
+		}
+		return false
+	default:
+		return isNonFinite(v)
+	}
+}
+
+// holdsFloats returns true if t is a float type, or an array of them.
+func holdsFloats(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Array, reflect.Slice:
+		return holdsFloats(t.Elem())
+	default:
+		return box.IsBoxedArray(t)
+	}
+}
+
+// resource returns the path to the resource data of the value of n, if the
+// value is, or references, a resource with a handle unique to the tree's
+// state.
+func (n *stn) resource(tree *stateTree) *path.ResourceData {
+	if n.isSubgroup || n.isFlag || isNil(n.value) {
+		return nil
+	}
+	res, ok := n.value.Interface().(api.Resource)
+	if !ok {
+		return nil
+	}
+	if id := tree.resources[res.ResourceHandle()]; id != nil {
+		return tree.after.ResourceAfter(id)
+	}
+	return nil
+}
+
+// changed returns true if the value of n differs from, or is missing from, its
+// value in the state before the command that the tree's state is after. Only
+// leaf values are compared, the changes of any other value are shown by its
+// descendants.
+func (n *stn) changed(ctx context.Context, tree *stateTree) bool {
+	if n.isSubgroup || n.isFlag || n.isPointee || !isLeaf(n.value) {
+		return false
+	}
+	nodes, g := stateNodes(n.path)
+	if g == nil {
+		return false
+	}
+	prev := tree.before(ctx)
+	if prev == nil {
+		return false
+	}
+	obj, ok := stateObject(ctx, prev, nodes)
+	return !ok || !leafEqual(n.value, reflect.ValueOf(obj))
+}
+
+// isLeaf returns true if v is a value with no children in a state tree other
+// than those of a pointee, such as a number, a string or a memory pointer.
+func isLeaf(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	if box.IsMemoryPointer(v.Type()) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// leafEqual returns true if the leaf value a equals b. Memory pointers are
+// equal if they hold the same address.
+func leafEqual(a, b reflect.Value) bool {
+	b = deref(b)
+	if !b.IsValid() || a.Type() != b.Type() {
+		return false
+	}
+	if box.IsMemoryPointer(a.Type()) {
+		return box.AsMemoryPointer(a).Address() == box.AsMemoryPointer(b).Address()
+	}
+	return a.Interface() == b.Interface()
+}
+
 func isFieldVisible(f reflect.StructField) bool {
 	return f.PkgPath == "" && f.Tag.Get("hidden") != "true"
 }
 
-func stateValuePreview(v reflect.Value) (*box.Value, bool) {
+// Default limits of the state value previews.
+const (
+	defaultPreviewElements     = 4
+	defaultPreviewStringLength = 64
+)
+
+// stateValuePreview returns the preview of the value v, and whether the
+// preview is the complete value, using the options o, which may be nil.
+func stateValuePreview(v reflect.Value, o *path.StatePreviewOptions) (*box.Value, bool) {
 	t := v.Type()
 	switch {
 	case box.IsMemoryPointer(t), box.IsMemorySlice(t):
@@ -340,21 +1149,38 @@ func stateValuePreview(v reflect.Value) (*box.Value, bool) {
 	}
 
 	switch v.Kind() {
-	case reflect.Bool,
-		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-		reflect.Float32, reflect.Float64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if o.GetHexIntegers() {
+			return box.NewValue(fmt.Sprintf("%#x", v.Int())), false
+		}
+		return box.NewValue(v.Interface()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if o.GetHexIntegers() {
+			return box.NewValue(fmt.Sprintf("%#x", v.Uint())), false
+		}
+		return box.NewValue(v.Interface()), true
+	case reflect.Bool, reflect.Float32, reflect.Float64:
 		return box.NewValue(v.Interface()), true
 	case reflect.Array, reflect.Slice:
-		const maxLen = 4
+		if o.GetByteDecoding() != path.StatePreviewOptions_Numbers && isByte(t.Elem()) {
+			b := bytesOf(v)
+			return bytesPreview(b, uint64(len(b)), o), false
+		}
+		maxLen := int(o.GetMaxElements())
+		if maxLen == 0 {
+			maxLen = defaultPreviewElements
+		}
 		if v.Len() > maxLen {
 			return box.NewValue(v.Slice(0, maxLen).Interface()), false
 		}
 		return box.NewValue(v.Interface()), true
 	case reflect.String:
-		const maxLen = 64
+		maxLen := int(o.GetMaxStringLength())
+		if maxLen == 0 {
+			maxLen = defaultPreviewStringLength
+		}
 		runes := []rune(v.Interface().(string))
-		if len(runes) > maxLen {
+		if !o.GetFullStrings() && len(runes) > maxLen {
 			return box.NewValue(string(append(runes[:maxLen-1], '…'))), false
 		}
 		return box.NewValue(v.Interface()), true
@@ -362,12 +1188,83 @@ func stateValuePreview(v reflect.Value) (*box.Value, bool) {
 		if isNil(v) {
 			return box.NewValue(v.Interface()), true
 		}
-		return stateValuePreview(v.Elem())
+		return stateValuePreview(v.Elem(), o)
 	default:
 		return nil, false
 	}
 }
 
+// isByte returns true if t is a byte or char type.
+func isByte(t reflect.Type) bool {
+	return t.Kind() == reflect.Uint8 || t.Kind() == reflect.Int8
+}
+
+// bytesOf returns the elements of the array or slice of bytes v.
+func bytesOf(v reflect.Value) []byte {
+	out := make([]byte, v.Len())
+	signed := v.Type().Elem().Kind() == reflect.Int8
+	for i := range out {
+		if signed {
+			out[i] = byte(v.Index(i).Int())
+		} else {
+			out[i] = byte(v.Index(i).Uint())
+		}
+	}
+	return out
+}
+
+// bytesPreview returns the string preview of the bytes b, the first of total
+// bytes, decoded as specified by the options o.
+func bytesPreview(b []byte, total uint64, o *path.StatePreviewOptions) *box.Value {
+	var s string
+	switch o.GetByteDecoding() {
+	case path.StatePreviewOptions_Utf8:
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			b, total = b[:i], uint64(i)
+		}
+		s = string([]rune(string(b))) // Replaces invalid sequences with U+FFFD.
+	case path.StatePreviewOptions_Hex:
+		s = fmt.Sprintf("% x", b)
+	case path.StatePreviewOptions_Base64:
+		s = base64.StdEncoding.EncodeToString(b)
+	}
+	if uint64(len(b)) < total {
+		s += "…"
+	}
+	preview, _ := stateValuePreview(reflect.ValueOf(s), o)
+	return preview
+}
+
+// memoryBytesPreview returns the decoded preview of the memory slice of bytes
+// held by n, or nil if n does not hold one or the bytes are previewed as
+// numbers. Unless full strings are requested, only as many bytes as can be
+// shown in the preview are loaded.
+func (n *stn) memoryBytesPreview(ctx context.Context, tree *stateTree) *box.Value {
+	o := tree.preview
+	if o.GetByteDecoding() == path.StatePreviewOptions_Numbers || !box.IsMemorySlice(n.value.Type()) {
+		return nil
+	}
+	slice := box.AsMemorySlice(n.value)
+	if !isByte(slice.ElementType()) {
+		return nil
+	}
+	count := slice.Count()
+	if !o.GetFullStrings() {
+		maxLen := uint64(o.GetMaxStringLength())
+		if maxLen == 0 {
+			maxLen = defaultPreviewStringLength
+		}
+		count = u64.Min(count, maxLen)
+	}
+	s := tree.globalState
+	els, err := memory.LoadSlice(ctx, slice.ISlice(0, count), s.Memory, s.MemoryLayout)
+	if err != nil {
+		log.D(ctx, "Could not load the bytes of %v: %v", n.path, err)
+		return nil
+	}
+	return bytesPreview(bytesOf(reflect.ValueOf(els)), slice.Count(), o)
+}
+
 // Resolve builds and returns a *StateTree for the path.StateTreeNode.
 // Resolve implements the database.Resolver interface.
 func (r *StateTreeResolvable) Resolve(ctx context.Context) (interface{}, error) {
@@ -376,17 +1273,128 @@ func (r *StateTreeResolvable) Resolve(ctx context.Context) (interface{}, error)
 		return nil, err
 	}
 
-	rootObj, rootPath, apiID, err := state(ctx, r.Path, r.Config)
-	if err != nil {
-		return nil, err
+	var rootObj interface{}
+	var apiPath *path.API
+	var root *stn
+	if r.AllApis {
+		root, err = allAPIsRoot(ctx, r.Path, globalState, r.Config)
+		if err != nil {
+			return nil, err
+		}
+		rootObj = globalState
+	} else {
+		obj, rootPath, apiID, err := state(ctx, r.Path, r.Config)
+		if err != nil {
+			return nil, err
+		}
+		rootObj, apiPath = obj, &path.API{ID: path.NewID(id.ID(apiID))}
+		root = &stn{
+			name:  "root",
+			value: deref(reflect.ValueOf(rootObj)),
+			path:  rootPath,
+		}
+	}
+	root.id = stableNodeID(root.path)
+	assignIDs(root, root.children)
+
+	// The resources are only linked from the state of a command.
+	var resources map[string]*path.ID
+	if after := r.Path.After; len(after.Indices) == 1 {
+		resources, err = resourceHandles(ctx, after.Capture, after.Indices[0], r.Config)
+		if err != nil {
+			log.W(ctx, "Could not resolve the resources of the state: %v", err)
+		}
+	}
+
+	var pinned *stateTree
+	if r.Pinned != nil {
+		boxed, err := database.Resolve(ctx, r.Pinned.ID())
+		if err != nil {
+			return nil, err
+		}
+		pinned = boxed.(*stateTree)
 	}
 
-	apiPath := &path.API{ID: path.NewID(id.ID(apiID))}
+	return &stateTree{
+		globalState: globalState,
+		state:       rootObj,
+		root:        root,
+		api:         apiPath,
+		groupLimit:  uint64(r.ArrayGroupSize),
+		preview:     r.Preview,
+		after:       r.Path.After,
+		resources:   resources,
+		keyOrder:    r.KeyOrder,
+		hideDefs:    r.HideDefaults,
+		allAPIs:     r.AllApis,
+		pinned:      pinned,
+		createdBy:   r.CreatedBy,
+		config:      r.Config,
+	}, nil
+}
+
+// allAPIsRoot returns the root node of a state tree with a child for the
+// state of each API of the global state g, ordered by API name.
+func allAPIsRoot(ctx context.Context, p *path.State, g *api.GlobalState, r *path.ResolveConfig) (*stn, error) {
+	states := make([]api.State, 0, len(g.APIs))
+	for _, s := range g.APIs {
+		states = append(states, s)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].API().Name() < states[j].API().Name()
+	})
 
 	root := &stn{
-		name:  "root",
-		value: deref(reflect.ValueOf(rootObj)),
-		path:  rootPath,
+		name:       "root",
+		value:      reflect.ValueOf(g),
+		path:       p.After.GlobalStateAfter(),
+		children:   []*stn{},
+		isSubgroup: true,
+	}
+	for _, s := range states {
+		obj, abs, err := apiState(ctx, p, s, r)
+		if err != nil {
+			if _, ok := err.(*service.ErrDataUnavailable); ok {
+				continue // The API has no state to show at this point.
+			}
+			return nil, err
+		}
+		root.children = append(root.children, &stn{
+			name:  s.API().Name(),
+			value: deref(reflect.ValueOf(obj)),
+			path:  abs,
+			api:   &path.API{ID: path.NewID(id.ID(s.API().ID()))},
+		})
+	}
+	return root, nil
+}
+
+// before returns the global state before the command that the tree's state is
+// after, loading it on the first call, or nil if the command is a subcommand or
+// the state cannot be loaded.
+func (t *stateTree) before(ctx context.Context) *api.GlobalState {
+	t.prevOnce.Do(func() {
+		if t.after == nil {
+			return
+		}
+		s, err := stateBefore(ctx, t.after, t.config)
+		if err != nil {
+			log.W(ctx, "Could not resolve the state before the command: %v", err)
+		}
+		t.prevState = s
+	})
+	return t.prevState
+}
+
+// stateBefore returns the global state before the command c, or nil if c is a
+// subcommand.
+func stateBefore(ctx context.Context, c *path.Command, r *path.ResolveConfig) (*api.GlobalState, error) {
+	switch {
+	case len(c.Indices) != 1:
+		return nil, nil
+	case c.Indices[0] > 0:
+		return GlobalState(ctx, c.Capture.Command(c.Indices[0]-1).GlobalStateAfter(), r)
+	default:
+		return capture.NewState(SetupContext(ctx, c.Capture, r))
 	}
-	return &stateTree{globalState, rootObj, root, apiPath, uint64(r.ArrayGroupSize)}, nil
 }
@@ -15,14 +15,19 @@
 package resolve
 
 import (
+	"context"
+	"math"
 	"reflect"
+	"regexp"
 	"testing"
 
 	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/dictionary"
 	"github.com/google/gapid/core/data/id"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/memory/arena"
 	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/os/device/bind"
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/api/test"
 	"github.com/google/gapid/gapis/capture"
@@ -83,7 +88,7 @@ type TestState struct {
 // Properties returns the field properties for the state.
 func (s TestState) Properties() api.Properties {
 	return api.Properties{
-		/* 0 */ api.NewProperty("Bool", func() bool { return s.Bool }, nil),
+		/* 0 */ api.NewProperty("Bool", func() bool { return s.Bool }, nil).SetDocs("A boolean."),
 		/* 1 */ api.NewProperty("Int", func() int { return s.Int }, nil),
 		/* 2 */ api.NewProperty("Float", func() float32 { return s.Float }, nil),
 		/* 3 */ api.NewProperty("String", func() string { return s.String }, nil),
@@ -93,6 +98,23 @@ func (s TestState) Properties() api.Properties {
 	}
 }
 
+var _ api.PropertyProvider = TestGroupedStruct{}
+
+type TestGroupedStruct struct {
+	Offset int
+	Width  int
+	Height int
+}
+
+// Properties returns the field properties for the struct.
+func (s TestGroupedStruct) Properties() api.Properties {
+	return api.Properties{
+		/* 0 */ api.NewProperty("Offset", func() int { return s.Offset }, nil).SetUnits("bytes"),
+		/* 1 */ api.NewProperty("Width", func() int { return s.Width }, nil).SetGroup("Extent"),
+		/* 2 */ api.NewProperty("Height", func() int { return s.Height }, nil).SetGroup("Extent"),
+	}
+}
+
 var testState = TestState{
 	Bool:   true,
 	Int:    42,
@@ -193,9 +215,10 @@ func TestSubgroupRange(t *testing.T) {
 			That(R{s, e}).Equals(R{test.s, test.e})
 	}
 }
-func TestStateTreeNode(t *testing.T) {
-	ctx := log.Testing(t)
-	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+// newTestStateTree returns a stateTree for testState, along with the context
+// bound to the empty capture the tree's state belongs to.
+func newTestStateTree(ctx context.Context) (context.Context, *stateTree) {
 	header := capture.Header{ABI: device.AndroidARM64v8a}
 	cap, err := capture.NewGraphicsCapture(ctx, arena.New(), "test-capture", &header, nil, []api.Cmd{})
 	if err != nil {
@@ -214,6 +237,7 @@ func TestStateTreeNode(t *testing.T) {
 	tree := &stateTree{
 		globalState: gs,
 		root: &stn{
+			id:    stableNodeID(rootPath),
 			name:  "root",
 			value: reflect.ValueOf(testState),
 			path:  rootPath,
@@ -221,13 +245,21 @@ func TestStateTreeNode(t *testing.T) {
 		api:        &path.API{ID: path.NewID(id.ID(test.API{}.ID()))},
 		groupLimit: 10,
 	}
-	root := &path.StateTreeNode{Indices: []uint64{}}
 
 	// Write some data to 0x1000.
 	e := gs.MemoryEncoder(memory.ApplicationPool, memory.Range{Base: 0x1000, Size: 0x8000})
 	for i := 0; i < 0x1000; i++ {
 		e.I64(int64(i * 10))
 	}
+	return ctx, tree
+}
+
+func TestStateTreeNode(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	ctx, tree := newTestStateTree(ctx)
+	rootPath := tree.root.path.(*path.State)
+	root := &path.StateTreeNode{Indices: []uint64{}}
 
 	for _, test := range []struct {
 		path     *path.StateTreeNode
@@ -239,6 +271,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren: 7,
 				Name:        "root",
 				ValuePath:   rootPath.Path(),
+				StableId:    "State",
 			},
 		}, {
 			root.Index(0), // 0
@@ -246,8 +279,10 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "Bool",
 				ValuePath:      rootPath.Field("Bool").Path(),
+				StableId:       "State.Bool",
 				Preview:        box.NewValue(true),
 				PreviewIsValue: true,
+				Docs:           "A boolean.",
 			},
 		}, {
 			root.Index(1), // 1
@@ -255,6 +290,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "Int",
 				ValuePath:      rootPath.Field("Int").Path(),
+				StableId:       "State.Int",
 				Preview:        box.NewValue(42),
 				PreviewIsValue: true,
 			},
@@ -264,6 +300,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "Float",
 				ValuePath:      rootPath.Field("Float").Path(),
+				StableId:       "State.Float",
 				Preview:        box.NewValue(float32(123.456)),
 				PreviewIsValue: true,
 			},
@@ -273,6 +310,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "String",
 				ValuePath:      rootPath.Field("String").Path(),
+				StableId:       "State.String",
 				Preview:        box.NewValue("meow"),
 				PreviewIsValue: true,
 			},
@@ -284,6 +322,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren: 10,
 				Name:        "ReferenceA",
 				ValuePath:   rootPath.Field("ReferenceA").Path(),
+				StableId:    "State.ReferenceA",
 			},
 		}, {
 			root.Index(4, 0), // [4.0]
@@ -291,6 +330,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "Bool",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Bool").Path(),
+				StableId:       "State.ReferenceA.Bool",
 				Preview:        box.NewValue(true),
 				PreviewIsValue: true,
 			},
@@ -300,6 +340,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "Int",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Int").Path(),
+				StableId:       "State.ReferenceA.Int",
 				Preview:        box.NewValue(7),
 				PreviewIsValue: true,
 			},
@@ -309,6 +350,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "Float",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Float").Path(),
+				StableId:       "State.ReferenceA.Float",
 				Preview:        box.NewValue(float32(0.25)),
 				PreviewIsValue: true,
 			},
@@ -318,6 +360,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "String",
 				ValuePath:      rootPath.Field("ReferenceA").Field("String").Path(),
+				StableId:       "State.ReferenceA.String",
 				Preview:        box.NewValue("hello cat"),
 				PreviewIsValue: true,
 			},
@@ -327,6 +370,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "Reference",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Reference").Path(),
+				StableId:       "State.ReferenceA.Reference",
 				Preview:        box.NewValue((*TestStruct)(nil)),
 				PreviewIsValue: true,
 			},
@@ -336,6 +380,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    3,
 				Name:           "Map",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Map").Path(),
+				StableId:       "State.ReferenceA.Map",
 				PreviewIsValue: false,
 			},
 		}, {
@@ -344,6 +389,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "1",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Map").MapIndex(1).Path(),
+				StableId:       "State.ReferenceA.Map[1]",
 				Preview:        box.NewValue("one"),
 				PreviewIsValue: true,
 			},
@@ -353,6 +399,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "5",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Map").MapIndex(5).Path(),
+				StableId:       "State.ReferenceA.Map[5]",
 				Preview:        box.NewValue("five"),
 				PreviewIsValue: true,
 			},
@@ -362,6 +409,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "9",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Map").MapIndex(9).Path(),
+				StableId:       "State.ReferenceA.Map[9]",
 				Preview:        box.NewValue("nine"),
 				PreviewIsValue: true,
 			},
@@ -371,6 +419,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    5,
 				Name:           "Array",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Array").Path(),
+				StableId:       "State.ReferenceA.Array",
 				Preview:        box.NewValue([]int{0, 10, 20, 30}),
 				PreviewIsValue: false,
 			},
@@ -380,6 +429,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "3",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Array").ArrayIndex(3).Path(),
+				StableId:       "State.ReferenceA.Array[3]",
 				Preview:        box.NewValue(30),
 				PreviewIsValue: true,
 			},
@@ -389,6 +439,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    5,
 				Name:           "Slice",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Slice").Path(),
+				StableId:       "State.ReferenceA.Slice",
 				Preview:        box.NewValue(memory.NewSlice(0x1000, 0x1000, 5*intSize, 5, memory.ApplicationPool, intType)),
 				PreviewIsValue: true,
 			},
@@ -398,6 +449,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "0",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Slice").ArrayIndex(0).Path(),
+				StableId:       "State.ReferenceA.Slice[0]",
 				Preview:        box.NewValue(memory.Int(0)),
 				PreviewIsValue: true,
 			},
@@ -407,6 +459,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "2",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Slice").ArrayIndex(2).Path(),
+				StableId:       "State.ReferenceA.Slice[2]",
 				Preview:        box.NewValue(memory.Int(20)),
 				PreviewIsValue: true,
 			},
@@ -416,6 +469,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "4",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Slice").ArrayIndex(4).Path(),
+				StableId:       "State.ReferenceA.Slice[4]",
 				Preview:        box.NewValue(memory.Int(40)),
 				PreviewIsValue: true,
 			},
@@ -425,6 +479,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "Pointer",
 				ValuePath:      rootPath.Field("ReferenceA").Field("Pointer").Path(),
+				StableId:       "State.ReferenceA.Pointer",
 				Preview:        box.NewValue(memory.NewPtr(0x1010, intType)),
 				PreviewIsValue: true,
 			},
@@ -434,6 +489,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren: 10,
 				Name:        "Interface",
 				ValuePath:   rootPath.Field("ReferenceA").Field("Interface").Path(),
+				StableId:    "State.ReferenceA.Interface",
 			},
 		},
 		// testState.ReferenceB
@@ -443,6 +499,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren: 10,
 				Name:        "ReferenceB",
 				ValuePath:   rootPath.Field("ReferenceB").Path(),
+				StableId:    "State.ReferenceB",
 			},
 		}, {
 			root.Index(5, 3), // [5.3]
@@ -450,41 +507,46 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "String",
 				ValuePath:      rootPath.Field("ReferenceB").Field("String").Path(),
+				StableId:       "State.ReferenceB.String",
 				Preview:        box.NewValue("this is a really, really, really, really, really, really, reall…"),
 				PreviewIsValue: false,
 			},
 		}, {
 			root.Index(5, 5), // [5.5]
 			&service.StateTreeNode{
-				NumChildren:    36,
+				NumChildren:    4,
 				Name:           "Map",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Map").Path(),
+				StableId:       "State.ReferenceB.Map",
 				PreviewIsValue: false,
 			},
 		}, {
-			root.Index(5, 5, 0), // [5.5.0]
+			root.Index(5, 5, 0, 0), // [5.5.0.0]
 			&service.StateTreeNode{
 				NumChildren:    0,
 				Name:           "0",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Map").MapIndex(0).Path(),
+				StableId:       "State.ReferenceB.Map[0]",
 				Preview:        box.NewValue("0.0"),
 				PreviewIsValue: true,
 			},
 		}, {
-			root.Index(5, 5, 5), // [5.5.5]
+			root.Index(5, 5, 0, 5), // [5.5.0.5]
 			&service.StateTreeNode{
 				NumChildren:    0,
 				Name:           "5",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Map").MapIndex(5).Path(),
+				StableId:       "State.ReferenceB.Map[5]",
 				Preview:        box.NewValue("0.5"),
 				PreviewIsValue: true,
 			},
 		}, {
-			root.Index(5, 5, 15), // [5.5.15]
+			root.Index(5, 5, 1, 5), // [5.5.1.5]
 			&service.StateTreeNode{
 				NumChildren:    0,
 				Name:           "15",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Map").MapIndex(15).Path(),
+				StableId:       "State.ReferenceB.Map[15]",
 				Preview:        box.NewValue("1.5"),
 				PreviewIsValue: true,
 			},
@@ -494,6 +556,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    4,
 				Name:           "Array",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Array").Path(),
+				StableId:       "State.ReferenceB.Array",
 				Preview:        box.NewValue([]int{0, 1, 2, 3}),
 				PreviewIsValue: false,
 			},
@@ -503,6 +566,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    10,
 				Name:           "[10 - 19]",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Array").Slice(10, 19).Path(),
+				StableId:       "State.ReferenceB.Array[10:19]",
 				Preview:        box.NewValue([]int{10, 11, 12, 13}),
 				PreviewIsValue: false,
 			},
@@ -512,6 +576,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "12",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Array").Slice(10, 19).ArrayIndex(2).Path(),
+				StableId:       "State.ReferenceB.Array[10:19][2]",
 				Preview:        box.NewValue(12),
 				PreviewIsValue: true,
 			},
@@ -521,6 +586,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    4,
 				Name:           "[30 - 33]",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Array").Slice(30, 33).Path(),
+				StableId:       "State.ReferenceB.Array[30:33]",
 				Preview:        box.NewValue([]int{30, 31, 32, 33}),
 				PreviewIsValue: true,
 			},
@@ -530,6 +596,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "32",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Array").Slice(30, 33).ArrayIndex(2).Path(),
+				StableId:       "State.ReferenceB.Array[30:33][2]",
 				Preview:        box.NewValue(32),
 				PreviewIsValue: true,
 			},
@@ -539,6 +606,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    2,
 				Name:           "Slice",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Slice").Path(),
+				StableId:       "State.ReferenceB.Slice",
 				Preview:        box.NewValue(memory.NewSlice(0x1000, 0x1000, 1005*intSize, 1005, memory.ApplicationPool, intType)),
 				PreviewIsValue: true,
 			},
@@ -548,6 +616,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    10,
 				Name:           "[0 - 999]",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Slice").Slice(0, 999).Path(),
+				StableId:       "State.ReferenceB.Slice[0:999]",
 				Preview:        box.NewValue(memory.NewSlice(0x1000, 0x1000, 1000*intSize, 1000, memory.ApplicationPool, intType)),
 				PreviewIsValue: true,
 			},
@@ -557,6 +626,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    10,
 				Name:           "[400 - 499]",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Slice").Slice(0, 999).Slice(400, 499).Path(),
+				StableId:       "State.ReferenceB.Slice[0:999][400:499]",
 				Preview:        box.NewValue(memory.NewSlice(0x1000, 0x1C80, 100*intSize, 100, memory.ApplicationPool, intType)),
 				PreviewIsValue: true,
 			},
@@ -566,6 +636,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    10,
 				Name:           "[430 - 439]",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Slice").Slice(0, 999).Slice(400, 499).Slice(30, 39).Path(),
+				StableId:       "State.ReferenceB.Slice[0:999][400:499][30:39]",
 				Preview:        box.NewValue(memory.NewSlice(0x1000, 0x1D70, 10*intSize, 10, memory.ApplicationPool, intType)),
 				PreviewIsValue: true,
 			},
@@ -575,6 +646,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "435",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Slice").Slice(0, 999).Slice(400, 499).Slice(30, 39).ArrayIndex(5).Path(),
+				StableId:       "State.ReferenceB.Slice[0:999][400:499][30:39][5]",
 				Preview:        box.NewValue(memory.Int(4350)),
 				PreviewIsValue: true,
 			},
@@ -584,6 +656,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    5,
 				Name:           "[1000 - 1004]",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Slice").Slice(1000, 1004).Path(),
+				StableId:       "State.ReferenceB.Slice[1000:1004]",
 				Preview:        box.NewValue(memory.NewSlice(0x1000, 0x2F40, 5*intSize, 5, memory.ApplicationPool, intType)),
 				PreviewIsValue: true,
 			},
@@ -593,6 +666,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "1003",
 				ValuePath:      rootPath.Field("ReferenceB").Field("Slice").Slice(1000, 1004).ArrayIndex(3).Path(),
+				StableId:       "State.ReferenceB.Slice[1000:1004][3]",
 				Preview:        box.NewValue(memory.Int(10030)),
 				PreviewIsValue: true,
 			},
@@ -604,6 +678,7 @@ func TestStateTreeNode(t *testing.T) {
 				NumChildren:    0,
 				Name:           "ReferenceC",
 				ValuePath:      rootPath.Field("ReferenceC").Path(),
+				StableId:       "State.ReferenceC",
 				Preview:        box.NewValue((*TestStruct)(nil)),
 				PreviewIsValue: true,
 			},
@@ -625,5 +700,477 @@ func TestStateTreeNode(t *testing.T) {
 			assert.For(ctx, "stateTreeNodePath(%v)", p).
 				ThatSlice(indices).Equals(test.path.Indices)
 		}
+
+		matches := findStateTreeNodes(ctx, tree, nil, []string{test.expected.StableId})
+		if node := matches.Matches[0].Node; assert.For(ctx, "findStateTreeNodes(%v)", test.expected.StableId).
+			That(node).IsNotNil() {
+			assert.For(ctx, "findStateTreeNodes(%v)", test.expected.StableId).
+				ThatSlice(node.Indices).Equals(test.path.Indices)
+		}
+	}
+}
+
+func TestMemoryBytesPreview(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	ctx, tree := newTestStateTree(ctx)
+
+	chars := func(base, count uint64) memory.Slice {
+		return memory.NewSlice(base, base, count, count, memory.ApplicationPool, reflect.TypeOf(memory.Char(0)))
+	}
+	ints := memory.NewSlice(0x1008, 0x1008, 8, 1, memory.ApplicationPool, intType)
+	hex := &path.StatePreviewOptions{ByteDecoding: path.StatePreviewOptions_Hex}
+
+	for _, test := range []struct {
+		name     string
+		slice    memory.Slice
+		options  *path.StatePreviewOptions
+		expected *box.Value
+	}{
+		{"numbers", chars(0x1008, 4), nil, nil},
+		{"not bytes", ints, hex, nil},
+		{"hex", chars(0x1008, 4), hex, box.NewValue("0a 00 00 00")},
+		{"truncated", chars(0x1008, 100), &path.StatePreviewOptions{ByteDecoding: path.StatePreviewOptions_Hex, MaxStringLength: 8}, box.NewValue("0a 00 0…")},
+		{"utf8", chars(0x1008, 100), &path.StatePreviewOptions{ByteDecoding: path.StatePreviewOptions_Utf8}, box.NewValue("\n")},
+	} {
+		tree.preview = test.options
+		n := &stn{name: test.name, value: reflect.ValueOf(test.slice), path: tree.root.path}
+		assert.For(ctx, "%v preview", test.name).That(n.memoryBytesPreview(ctx, tree)).DeepEquals(test.expected)
+	}
+}
+
+func TestStateTreePointee(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	ctx, tree := newTestStateTree(ctx)
+
+	for _, test := range []struct {
+		name     string
+		pointer  memory.Pointer
+		expected []interface{}
+	}{
+		{"null", memory.NewPtr(0, intType), []interface{}{}},
+		{"void", memory.BytePtr(0x1000), []interface{}{}},
+		{"scalar", memory.NewPtr(0x1000, intType), []interface{}{}},
+		{"array", memory.NewPtr(0x1008, reflect.TypeOf([3]memory.Int{})),
+			[]interface{}{memory.Int(10), memory.Int(20), memory.Int(30)}},
+	} {
+		n := &stn{name: test.name, value: reflect.ValueOf(test.pointer), path: tree.root.path}
+		n.buildChildren(ctx, tree)
+		got := []interface{}{}
+		for _, c := range n.children {
+			got = append(got, c.value.Interface())
+			assert.For(ctx, "%v isPointee", test.name).That(c.isPointee).Equals(true)
+			assert.For(ctx, "%v ValuePath", test.name).That(c.service(ctx, tree).ValuePath).IsNil()
+		}
+		assert.For(ctx, "%v children", test.name).ThatSlice(got).Equals(test.expected)
+	}
+}
+
+func TestOrderKeys(t *testing.T) {
+	ctx := log.Testing(t)
+	m := map[string]int{"1": 1, "10": 10, "100": 100, "2": 2}
+	for _, test := range []struct {
+		order    path.StateTree_KeyOrder
+		expected []interface{}
+	}{
+		{path.StateTree_Sorted, []interface{}{"1", "10", "100", "2"}},
+		{path.StateTree_Natural, []interface{}{"1", "2", "10", "100"}},
+		{path.StateTree_Reverse, []interface{}{"2", "100", "10", "1"}},
+	} {
+		got := orderKeys(dictionary.From(m), test.order)
+		assert.For(ctx, "orderKeys(%v)", test.order).ThatSlice(got).Equals(test.expected)
+	}
+}
+
+func TestStateSnippet(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	ctx, tree := newTestStateTree(ctx)
+	root := &path.StateTreeNode{Indices: []uint64{}}
+
+	for _, test := range []struct {
+		path     *path.StateTreeNode
+		format   service.SnippetFormat
+		depth    int32
+		expected string
+	}{
+		{root, service.SnippetFormat_Text, 0, "root:\n"},
+		{root.Index(0), service.SnippetFormat_Text, 0, "Bool: true\n"},
+		{root.Index(0), service.SnippetFormat_Markdown, 0, "- **Bool**: `true`\n"},
+		{root.Index(4, 3), service.SnippetFormat_Text, -1, "String: hello cat\n"},
+		{root.Index(4, 5), service.SnippetFormat_Text, 0, "Map:\n"},
+		{root.Index(4, 5), service.SnippetFormat_Markdown, -1, "- **Map**\n" +
+			"  - **1**: `one`\n" +
+			"  - **5**: `five`\n" +
+			"  - **9**: `nine`\n"},
+	} {
+		got, err := stateSnippet(ctx, tree, test.path, test.format, test.depth, nil)
+		if assert.For(ctx, "stateSnippet(%v)", test.path).ThatError(err).Succeeded() {
+			assert.For(ctx, "stateSnippet(%v)", test.path).That(got).Equals(test.expected)
+		}
+	}
+}
+
+func TestStateSearch(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	ctx, tree := newTestStateTree(ctx)
+	rootPath := tree.root.path.(*path.State)
+
+	for _, test := range []struct {
+		name      string
+		search    *path.StateSearch
+		expected  []path.Node
+		truncated bool
+	}{
+		{
+			"value",
+			&path.StateSearch{Pattern: "cat", MatchValues: true},
+			[]path.Node{
+				rootPath.Field("ReferenceA").Field("String"),
+			},
+			false,
+		}, {
+			"name",
+			&path.StateSearch{Pattern: "^Int$", MatchNames: true},
+			[]path.Node{
+				rootPath.Field("Int"),
+				rootPath.Field("ReferenceA").Field("Int"),
+				rootPath.Field("ReferenceA").Field("Interface").Field("Int"),
+				rootPath.Field("ReferenceB").Field("Int"),
+			},
+			false,
+		}, {
+			"range",
+			&path.StateSearch{Range: &path.ValueRange{Min: 20, Max: 30}, MaxResults: 2},
+			[]path.Node{
+				rootPath.Field("ReferenceA").Field("Array").ArrayIndex(2),
+				rootPath.Field("ReferenceA").Field("Array").ArrayIndex(3),
+			},
+			true,
+		},
+	} {
+		var re *regexp.Regexp
+		if test.search.Pattern != "" {
+			re = regexp.MustCompile(test.search.Pattern)
+		}
+		got, err := searchStateTree(ctx, tree, test.search, re)
+		if !assert.For(ctx, "searchStateTree(%v)", test.name).ThatError(err).Succeeded() {
+			continue
+		}
+		paths := make([]path.Node, len(got.Results))
+		for i, r := range got.Results {
+			paths[i] = r.Path.Node()
+		}
+		assert.For(ctx, "searchStateTree(%v) paths", test.name).
+			ThatSlice(paths).DeepEquals(test.expected)
+		assert.For(ctx, "searchStateTree(%v) truncated", test.name).
+			That(got.Truncated).Equals(test.truncated)
+	}
+}
+
+func TestStateTreeChildren(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	ctx, tree := newTestStateTree(ctx)
+	root := &path.StateTreeNode{Indices: []uint64{}}
+
+	for _, test := range []struct {
+		path          *path.StateTreeNode
+		offset        uint64
+		count         uint32
+		expectedTotal uint64
+		expected      []string
+	}{
+		{root, 0, 3, 7, []string{"Bool", "Int", "Float"}},
+		{root, 4, 2, 7, []string{"ReferenceA", "ReferenceB"}},
+		{root, 6, 5, 7, []string{"ReferenceC"}},
+		{root, 7, 5, 7, []string{}},
+		{root.Index(4, 5), 1, 10, 3, []string{"5", "9"}},
+	} {
+		got, err := stateTreeChildren(ctx, tree, test.path, test.offset, test.count)
+		if !assert.For(ctx, "stateTreeChildren(%v, %v, %v)", test.path, test.offset, test.count).
+			ThatError(err).Succeeded() {
+			continue
+		}
+		names := make([]string, len(got.Children))
+		for i, c := range got.Children {
+			names[i] = c.Name
+			// Each child must match the node resolved on its own.
+			p := test.path.Index(test.offset + uint64(i))
+			node, err := stateTreeNode(ctx, tree, p)
+			if assert.For(ctx, "stateTreeNode(%v)", p).ThatError(err).Succeeded() {
+				assert.For(ctx, "child %v", p).That(c).DeepEquals(node)
+			}
+		}
+		assert.For(ctx, "stateTreeChildren(%v, %v, %v) total", test.path, test.offset, test.count).
+			That(got.Total).Equals(test.expectedTotal)
+		assert.For(ctx, "stateTreeChildren(%v, %v, %v) names", test.path, test.offset, test.count).
+			ThatSlice(names).Equals(test.expected)
+	}
+}
+
+func TestStateValuePreview(t *testing.T) {
+	ctx := log.Testing(t)
+	long := "this is a really, really, really, really, really, really, really long string"
+	utf8 := &path.StatePreviewOptions{ByteDecoding: path.StatePreviewOptions_Utf8}
+
+	for _, test := range []struct {
+		name     string
+		value    interface{}
+		options  *path.StatePreviewOptions
+		expected *box.Value
+		isValue  bool
+	}{
+		{"int", 42, nil, box.NewValue(42), true},
+		{"hex int", 42, &path.StatePreviewOptions{HexIntegers: true}, box.NewValue("0x2a"), false},
+		{"hex uint", uint8(255), &path.StatePreviewOptions{HexIntegers: true}, box.NewValue("0xff"), false},
+		{"slice", []int{1, 2, 3, 4, 5, 6}, nil, box.NewValue([]int{1, 2, 3, 4}), false},
+		{"long slice", []int{1, 2, 3, 4, 5, 6}, &path.StatePreviewOptions{MaxElements: 6}, box.NewValue([]int{1, 2, 3, 4, 5, 6}), true},
+		{"string", long, &path.StatePreviewOptions{MaxStringLength: 10}, box.NewValue("this is a…"), false},
+		{"full string", long, &path.StatePreviewOptions{FullStrings: true}, box.NewValue(long), true},
+		{"utf8 chars", [8]memory.Char{'g', 'a', 'p', 'i', 'd', 0, 'x'}, utf8, box.NewValue("gapid"), false},
+		{"utf8 invalid", []uint8{'a', 0xff}, utf8, box.NewValue("a\uFFFD"), false},
+		{"utf8 long", []byte(long), &path.StatePreviewOptions{ByteDecoding: path.StatePreviewOptions_Utf8, MaxStringLength: 10}, box.NewValue("this is a…"), false},
+		{"hex bytes", []uint8{0x0a, 0xff}, &path.StatePreviewOptions{ByteDecoding: path.StatePreviewOptions_Hex}, box.NewValue("0a ff"), false},
+		{"base64 bytes", []uint8("gapid"), &path.StatePreviewOptions{ByteDecoding: path.StatePreviewOptions_Base64}, box.NewValue("Z2FwaWQ="), false},
+		{"numbers bytes", []uint8{1, 2}, nil, box.NewValue([]uint8{1, 2}), true},
+	} {
+		preview, isValue := stateValuePreview(reflect.ValueOf(test.value), test.options)
+		assert.For(ctx, "%v preview", test.name).That(preview).DeepEquals(test.expected)
+		assert.For(ctx, "%v isValue", test.name).That(isValue).Equals(test.isValue)
+	}
+}
+
+func TestMatrixRows(t *testing.T) {
+	ctx := log.Testing(t)
+	m := [16]float32{}
+	for i := range m {
+		m[i] = float32(i)
+	}
+	cols, nested := matrixColumns(reflect.ValueOf(m))
+	if !assert.For(ctx, "cols").ThatSlice(cols).IsLength(4) {
+		return
+	}
+	assert.For(ctx, "nested").That(nested).Equals(false)
+	assert.For(ctx, "preview").That(matrixPreview(cols)).Equals(
+		"[[0 4 8 12] [1 5 9 13] [2 6 10 14] [3 7 11 15]]")
+
+	root := &path.Field{Name: "M"}
+	rows := matrixRows(cols, nested, root)
+	assert.For(ctx, "rows").ThatSlice(rows).IsLength(4)
+	row := rows[1]
+	assert.For(ctx, "row name").That(row.name).Equals("row 1")
+	assert.For(ctx, "row value").That(row.value.Interface()).DeepEquals([]float32{1, 5, 9, 13})
+	assert.For(ctx, "row element path").That(row.children[2].path).DeepEquals(path.NewArrayIndex(9, root))
+
+	cols, _ = matrixColumns(reflect.ValueOf([16]int{}))
+	assert.For(ctx, "int array").That(cols == nil).Equals(true)
+}
+
+func TestIsDefault(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		name     string
+		value    interface{}
+		expected bool
+	}{
+		{"zero int", 0, true},
+		{"int", 3, false},
+		{"empty string", "", true},
+		{"string", "x", false},
+		{"zero array", [3]float32{}, true},
+		{"array", [3]float32{0, 1, 0}, false},
+		{"empty slice", []int{}, true},
+		{"slice", []int{0}, false},
+		{"empty map", map[int]int{}, true},
+		{"map", map[int]int{0: 0}, false},
+		{"nil pointer", (*int)(nil), true},
+		{"pointer", new(int), false},
+	} {
+		assert.For(ctx, "isDefault(%v)", test.name).
+			That(isDefault(reflect.ValueOf(test.value))).Equals(test.expected)
+	}
+}
+
+func TestEstimateDescendants(t *testing.T) {
+	ctx := log.Testing(t)
+	nested := make([][]int, 100)
+	for i := range nested {
+		nested[i] = make([]int, 5)
+	}
+	for _, test := range []struct {
+		name     string
+		value    interface{}
+		expected uint64
+	}{
+		{"int", 42, 0},
+		{"nil", (*int)(nil), 0},
+		{"slice", make([]int, 1000), 1000},
+		{"nested slice", nested, 600},
+		{"map", map[int][]int{1: {1, 2}, 2: {3, 4}}, 6},
+	} {
+		assert.For(ctx, "estimateDescendants(%v)", test.name).
+			That(estimateDescendants(reflect.ValueOf(test.value), descendantsDepth)).Equals(test.expected)
+	}
+}
+
+func TestHasNonFinite(t *testing.T) {
+	ctx := log.Testing(t)
+	nan, inf := float32(math.NaN()), math.Inf(-1)
+	for _, test := range []struct {
+		name     string
+		value    interface{}
+		expected bool
+	}{
+		{"finite float", float32(1.5), false},
+		{"NaN", nan, true},
+		{"infinity", inf, true},
+		{"int", 42, false},
+		{"nil", (*float32)(nil), false},
+		{"finite array", [4]float32{0, 1, 2, 3}, false},
+		{"NaN array", [4]float32{0, 1, nan, 3}, true},
+		{"nested array", [2][2]float64{{0, 1}, {inf, 0}}, true},
+		{"pointer", &inf, true},
+	} {
+		assert.For(ctx, "hasNonFinite(%v)", test.name).
+			That(hasNonFinite(reflect.ValueOf(test.value))).Equals(test.expected)
+	}
+}
+
+func TestBitfieldFlags(t *testing.T) {
+	ctx := log.Testing(t)
+	set := &service.ConstantSet{
+		IsBitfield: true,
+		Constants: []*service.Constant{
+			{Name: "NONE", Value: 0x0},
+			{Name: "COLOR_BIT", Value: 0x1},
+			{Name: "DEPTH_BIT", Value: 0x2},
+			{Name: "STENCIL_BIT", Value: 0x4},
+			{Name: "DEPTH_STENCIL_BITS", Value: 0x6},
+		},
+	}
+	p := path.NewField("Aspect", nil)
+	flags := bitfieldFlags(set, reflect.ValueOf(uint32(0x13)), p)
+
+	expected := []struct {
+		name  string
+		value uint32
+	}{
+		{"COLOR_BIT", 0x1},
+		{"DEPTH_BIT", 0x2},
+		{"0x10", 0x10},
+	}
+	if assert.For(ctx, "flags").ThatSlice(flags).IsLength(len(expected)) {
+		for i, e := range expected {
+			assert.For(ctx, "flags[%v].name", i).That(flags[i].name).Equals(e.name)
+			assert.For(ctx, "flags[%v].value", i).That(flags[i].value.Interface()).Equals(e.value)
+			assert.For(ctx, "flags[%v].path", i).That(flags[i].path).Equals(p)
+		}
+	}
+}
+
+func TestStateFieldGroups(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	ctx, tree := newTestStateTree(ctx)
+	p := tree.root.path
+	n := &stn{
+		name:  "grouped",
+		value: reflect.ValueOf(TestGroupedStruct{Offset: 16, Width: 640, Height: 480}),
+		path:  p,
+	}
+	n.buildChildren(ctx, tree)
+
+	if assert.For(ctx, "children").ThatSlice(n.children).IsLength(2) {
+		offset, extent := n.children[0], n.children[1]
+		assert.For(ctx, "offset.name").That(offset.name).Equals("Offset")
+		assert.For(ctx, "offset.units").That(offset.units).Equals("bytes")
+		assert.For(ctx, "extent.name").That(extent.name).Equals("Extent")
+		assert.For(ctx, "extent.isSubgroup").That(extent.isSubgroup).Equals(true)
+		assert.For(ctx, "extent.path").That(extent.path).Equals(p)
+		if assert.For(ctx, "extent.children").ThatSlice(extent.children).IsLength(2) {
+			assert.For(ctx, "width.name").That(extent.children[0].name).Equals("Width")
+			assert.For(ctx, "height.name").That(extent.children[1].name).Equals("Height")
+		}
+	}
+	assert.For(ctx, "findByPath").That(n.findByPath(ctx, path.NewField("Height", p), tree)).
+		DeepEquals([]uint64{1, 1})
+}
+
+func TestStateTreeChanged(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	p := newPathTest(ctx)
+	after := p.Command(2)
+	gs, err := GlobalState(ctx, after.GlobalStateAfter(), nil)
+	if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+		return
+	}
+	tree := &stateTree{globalState: gs, after: after}
+	s := APIStateAfter(after, test.API{}.ID())
+
+	// Only leaves are compared, so the previous state is not loaded for others.
+	nodes, _ := stateNodes(path.NewField("Ref", s))
+	ref, _ := stateObject(ctx, gs, nodes)
+	n := &stn{value: deref(reflect.ValueOf(ref)), path: path.NewField("Ref", s)}
+	assert.For(ctx, "non-leaf changed").That(n.changed(ctx, tree)).Equals(false)
+	assert.For(ctx, "previous state").That(tree.prevState).IsNil()
+
+	for _, test := range []struct {
+		name     string
+		path     path.Node
+		value    interface{}
+		expected bool
+	}{
+		{"changed", path.NewField("Str", s), "aaa", true},
+		{"unchanged", path.NewField("Str", s), "", false},
+		{"added", path.NewField("Ref", s).Field("Strings").MapIndex("123"), uint32(123), true},
+	} {
+		n := &stn{value: reflect.ValueOf(test.value), path: test.path}
+		assert.For(ctx, "%v changed", test.name).That(n.changed(ctx, tree)).Equals(test.expected)
+	}
+	assert.For(ctx, "previous state").That(tree.prevState).IsNotNil()
+}
+
+func TestStateTreeComparePinned(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	p := newPathTest(ctx)
+	gs, err := GlobalState(ctx, p.Command(2).GlobalStateAfter(), nil)
+	if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+		return
+	}
+	pinned, err := GlobalState(ctx, p.Command(0).GlobalStateAfter(), nil)
+	if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+		return
+	}
+	tree := &stateTree{globalState: gs, pinned: &stateTree{globalState: pinned}}
+	s := APIStateAfter(p.Command(2), test.API{}.ID())
+
+	nodes, _ := stateNodes(path.NewField("Ref", s))
+	ref, _ := stateObject(ctx, gs, nodes)
+
+	for _, test := range []struct {
+		name     string
+		path     path.Node
+		value    interface{}
+		differs  bool
+		expected *box.Value
+	}{
+		{"non-leaf", path.NewField("Ref", s), deref(reflect.ValueOf(ref)).Interface(), false, nil},
+		{"differs", path.NewField("Str", s), "aaa", true, box.NewValue("")},
+		{"same", path.NewField("Str", s), "", false, box.NewValue("")},
+		{"missing", path.NewField("Ref", s).Field("Strings").MapIndex("123"), uint32(123), true, nil},
+	} {
+		n := &stn{value: reflect.ValueOf(test.value), path: test.path}
+		differs, preview := n.comparePinned(ctx, tree)
+		assert.For(ctx, "%v differs", test.name).That(differs).Equals(test.differs)
+		assert.For(ctx, "%v preview", test.name).That(preview).DeepEquals(test.expected)
 	}
 }
@@ -26,6 +26,7 @@ import (
 )
 
 // Stats resolves and returns the stats list from the path p.
+// If p.Frames is set, the per-frame stats only cover those frames.
 func Stats(ctx context.Context, p *path.Stats, r *path.ResolveConfig) (*service.Stats, error) {
 	stats := &service.Stats{}
 	if p.DrawCall {
@@ -34,6 +35,30 @@ func Stats(ctx context.Context, p *path.Stats, r *path.ResolveConfig) (*service.
 			return nil, err
 		}
 	}
+	if p.Bandwidth {
+		err := bandwidthStats(ctx, p.Capture, stats, r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.Frames != nil && (p.DrawCall || p.Bandwidth) {
+		frames := uint64(len(stats.DrawCalls))
+		if p.Bandwidth {
+			frames = uint64(len(stats.Bandwidth))
+		}
+		if frames > 0 {
+			first, last, err := frameRangeBounds(p.Frames, frames, p)
+			if err != nil {
+				return nil, err
+			}
+			if p.DrawCall {
+				stats.DrawCalls = stats.DrawCalls[first : last+1]
+			}
+			if p.Bandwidth {
+				stats.Bandwidth = stats.Bandwidth[first : last+1]
+			}
+		}
+	}
 	c, err := capture.ResolveGraphicsFromPath(ctx, p.Capture)
 	if err != nil {
 		return nil, err
@@ -173,3 +198,61 @@ func drawCallStats(ctx context.Context, capt *path.Capture, stats *service.Stats
 	stats.DrawCalls = drawsPerFrame
 	return nil
 }
+
+// bandwidthStats estimates the memory bandwidth used by each frame of the
+// capture. APIs that implement api.BandwidthEstimator provide the estimates
+// for their commands. The memory read from application memory by the commands
+// flagged as api.Upload is counted as written by an upload for all APIs.
+// Commands after the last frame boundary are added to the last frame.
+func bandwidthStats(ctx context.Context, capt *path.Capture, stats *service.Stats, r *path.ResolveConfig) error {
+	cmds, err := Cmds(ctx, capt)
+	if err != nil {
+		return err
+	}
+
+	st, err := capture.NewState(ctx)
+	if err != nil {
+		return err
+	}
+
+	events, err := Events(ctx, &path.Events{
+		Capture:     capt,
+		LastInFrame: true,
+	}, r)
+	if err != nil {
+		return err
+	}
+
+	frames := make([]*api.FrameBandwidth, len(events.List))
+	for i := range frames {
+		frames[i] = &api.FrameBandwidth{}
+	}
+	if len(frames) == 0 {
+		stats.Bandwidth = frames
+		return nil
+	}
+
+	ends := frameEnds(events)
+	err = api.ForeachCmd(ctx, cmds, true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		b := frames[frameOf(ends, id)]
+		if e, ok := cmd.API().(api.BandwidthEstimator); ok {
+			if err := e.EstimateBandwidth(ctx, id, cmd, st, b); err != nil {
+				return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+			}
+		} else if err := cmd.Mutate(ctx, id, st, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		if o := cmd.Extras().Observations(); o != nil && cmd.CmdFlags(ctx, id, st).IsUpload() {
+			for _, read := range o.Reads {
+				b.Add(api.BandwidthCategory_UPLOADS, 0, read.Range.Size)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	stats.Bandwidth = frames
+	return nil
+}
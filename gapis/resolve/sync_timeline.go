@@ -0,0 +1,176 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/math/u64"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// SyncTimeline resolves and returns the semaphore and fence events of the
+// capture of p, in command order, with each wait linked to the event it waits
+// upon. Only commands of APIs implementing api.SyncEventLister are listed.
+// The GPU times of the events of queue submissions are added by Get from the
+// latest profile of the capture, if any. If p.Frames is set, only
+// the events of those frames are listed, though waits are still linked to
+// signals of earlier frames if they are.
+func SyncTimeline(ctx context.Context, p *path.SyncTimeline, r *path.ResolveConfig) (*api.SyncTimeline, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := framesCommandRange(ctx, p.Capture, p.Frames, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.SyncTimeline{Events: []*api.SyncEvent{}}
+	err = api.ForeachCmd(ctx, cmds[:end], true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		first := len(out.Events)
+		if l, ok := cmd.API().(api.SyncEventLister); ok {
+			if err := l.ListSyncEvents(ctx, id, cmd, st, out); err != nil {
+				return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+			}
+		} else if err := cmd.Mutate(ctx, id, st, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		if t := captureTime(cmd); t != 0 {
+			for _, e := range out.Events[first:] {
+				e.CaptureTime = t
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	linkSyncEvents(out.Events)
+	out.Events = syncEventsFrom(out.Events, start)
+	for _, e := range out.Events {
+		e.Command.Capture = p.Capture
+	}
+	return out, nil
+}
+
+// captureTime returns the timestamp recorded for cmd at capture time, or 0 if
+// the command has none.
+func captureTime(cmd api.Cmd) uint64 {
+	for _, e := range cmd.Extras().All() {
+		if t, ok := e.(*api.TimeStamp); ok {
+			return t.Nanoseconds
+		}
+	}
+	return 0
+}
+
+// linkSyncEvents sets the Signal of each wait of events to the index of the
+// latest event that signaled the semaphore or fence it waits upon. Semaphores
+// are unsignaled by a wait, and fences by a reset.
+func linkSyncEvents(events []*api.SyncEvent) {
+	semaphores, fences := map[uint64]int32{}, map[uint64]int32{}
+	signal := func(m map[uint64]int32, handle uint64) int32 {
+		if i, ok := m[handle]; ok {
+			return i
+		}
+		return -1
+	}
+	for i, e := range events {
+		e.Signal = -1
+		switch e.Type {
+		case api.SyncEvent_SEMAPHORE_SIGNAL:
+			semaphores[e.Handle] = int32(i)
+		case api.SyncEvent_SEMAPHORE_WAIT:
+			e.Signal = signal(semaphores, e.Handle)
+			delete(semaphores, e.Handle)
+		case api.SyncEvent_FENCE_SIGNAL:
+			fences[e.Handle] = int32(i)
+		case api.SyncEvent_FENCE_WAIT:
+			e.Signal = signal(fences, e.Handle)
+		case api.SyncEvent_FENCE_RESET:
+			delete(fences, e.Handle)
+		}
+	}
+}
+
+// syncEventsFrom returns the events of events for the commands from start
+// onwards, with the Signal of each wait updated to the index of its signal in
+// the returned list, or -1 if its signal is dropped.
+func syncEventsFrom(events []*api.SyncEvent, start uint64) []*api.SyncEvent {
+	out := []*api.SyncEvent{}
+	index := make([]int32, len(events))
+	for i, e := range events {
+		index[i] = -1
+		if e.Command.Indices[0] < start {
+			continue
+		}
+		index[i] = int32(len(out))
+		if e.Signal >= 0 {
+			e.Signal = index[e.Signal]
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// addSyncEventGpuTimes sets the GpuTime of the queue events of events whose
+// command is linked to top-level slices of the profile slices.
+func addSyncEventGpuTimes(events []*api.SyncEvent, slices *service.ProfilingData_GpuSlices) {
+	type span struct{ start, end uint64 }
+
+	groups := map[int32]uint64{}
+	for _, g := range slices.GetGroups() {
+		if len(g.GetLink().GetFrom()) > 0 {
+			groups[g.Id] = g.Link.From[0]
+		}
+	}
+	spans := map[uint64]*span{}
+	for _, s := range slices.GetSlices() {
+		id, ok := groups[s.Group]
+		if !ok || s.Depth != 0 {
+			continue
+		}
+		if sp := spans[id]; sp != nil {
+			sp.start, sp.end = u64.Min(sp.start, s.Ts), u64.Max(sp.end, s.Ts+s.Dur)
+		} else {
+			spans[id] = &span{s.Ts, s.Ts + s.Dur}
+		}
+	}
+
+	for _, e := range events {
+		sp := spans[e.Command.Indices[0]]
+		if sp == nil || e.Queue == 0 {
+			continue
+		}
+		switch e.Type {
+		case api.SyncEvent_SEMAPHORE_WAIT:
+			e.GpuTime = sp.start
+		case api.SyncEvent_SEMAPHORE_SIGNAL, api.SyncEvent_FENCE_SIGNAL:
+			e.GpuTime = sp.end
+		}
+	}
+}
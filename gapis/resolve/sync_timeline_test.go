@@ -0,0 +1,82 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestSyncTimeline(t *testing.T) {
+	ctx := log.Testing(t)
+	c := &path.Capture{}
+	event := func(ty api.SyncEvent_Type, cmd, handle, queue uint64) *api.SyncEvent {
+		return &api.SyncEvent{Type: ty, Command: c.Command(cmd), Handle: handle, Queue: queue}
+	}
+	events := []*api.SyncEvent{
+		/* 0 */ event(api.SyncEvent_SEMAPHORE_SIGNAL, 1, 10, 0), // Acquire.
+		/* 1 */ event(api.SyncEvent_SEMAPHORE_WAIT, 2, 10, 5), // Submit.
+		/* 2 */ event(api.SyncEvent_SEMAPHORE_SIGNAL, 2, 11, 5),
+		/* 3 */ event(api.SyncEvent_FENCE_SIGNAL, 2, 20, 5),
+		/* 4 */ event(api.SyncEvent_SEMAPHORE_WAIT, 3, 11, 5), // Present.
+		/* 5 */ event(api.SyncEvent_FENCE_WAIT, 4, 20, 0),
+		/* 6 */ event(api.SyncEvent_FENCE_RESET, 5, 20, 0),
+		/* 7 */ event(api.SyncEvent_FENCE_WAIT, 6, 20, 0),
+		/* 8 */ event(api.SyncEvent_SEMAPHORE_WAIT, 7, 11, 5),
+	}
+	slices := &service.ProfilingData_GpuSlices{
+		Groups: []*service.ProfilingData_GpuSlices_Group{
+			{Id: 1, Link: c.SubCommandRange([]uint64{2, 0, 0}, []uint64{2, 0, 5})},
+		},
+		Slices: []*service.ProfilingData_GpuSlices_Slice{
+			{Ts: 100, Dur: 50, Group: 1},
+			{Ts: 120, Dur: 100, Group: 1, Depth: 1}, // Nested, ignored.
+			{Ts: 160, Dur: 40, Group: 1},
+		},
+	}
+
+	linkSyncEvents(events)
+	addSyncEventGpuTimes(events, slices)
+
+	for i, expected := range []struct {
+		signal  int32
+		gpuTime uint64
+	}{
+		{-1, 0},
+		{0, 100},
+		{-1, 200},
+		{-1, 200},
+		{2, 0},
+		{3, 0},
+		{-1, 0},
+		{-1, 0},
+		{-1, 0},
+	} {
+		assert.For(ctx, "events[%v].Signal", i).That(events[i].Signal).Equals(expected.signal)
+		assert.For(ctx, "events[%v].GpuTime", i).That(events[i].GpuTime).Equals(expected.gpuTime)
+	}
+
+	// Dropping the events before command 2 drops the acquire's signal.
+	events = syncEventsFrom(events, 2)
+	assert.For(ctx, "len(events)").That(len(events)).Equals(8)
+	for i, expected := range []int32{-1, -1, -1, 1, 2, -1, -1, -1} {
+		assert.For(ctx, "events[%v].Signal", i).That(events[i].Signal).Equals(expected)
+	}
+}
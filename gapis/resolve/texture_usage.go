@@ -0,0 +1,112 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/replay/devices"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// TextureUsage resolves and returns the utilization of the mip levels of the
+// textures sampled by the frame of p. The color attachment written by each
+// draw call of the frame is replayed unchanged, and then with the finest mip
+// levels of each texture sampled by the draw dropped. The finest level whose
+// removal changes the attachment of any draw sampling a texture is the finest
+// level the frame needs, so textures whose finer levels can all be dropped are
+// larger than the frame requires. Draw calls that write no color attachment
+// are not measured.
+func TextureUsage(ctx context.Context, p *path.TextureUsage, r *path.ResolveConfig) (*service.TextureUsage, error) {
+	ctx = SetupContext(ctx, p.Capture, r)
+
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := frameCommandRange(ctx, p.Capture, p.Frame, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	draws, err := drawCalls(ctx, p.Capture, cmds, start, end, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(draws) == 0 {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+
+	cmd, err := Cmd(ctx, draws[0], r)
+	if err != nil {
+		return nil, err
+	}
+	query, ok := cmd.API().(replay.QueryTextureUsage)
+	if !ok {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrTextureUsageNotAvailable()}
+	}
+
+	changes, err := FramebufferChanges(ctx, p.Capture, r)
+	if err != nil {
+		return nil, err
+	}
+	measured := []replay.TextureUsageDraw{}
+	for _, d := range draws {
+		fbInfo, err := changes.Get(ctx, d, api.FramebufferAttachment_Color0)
+		if _, ok := err.(*service.ErrDataUnavailable); ok {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		measured = append(measured, replay.TextureUsageDraw{
+			Command:          d.Indices,
+			Width:            fbInfo.Width,
+			Height:           fbInfo.Height,
+			FramebufferIndex: fbInfo.Index,
+		})
+	}
+
+	device := r.GetReplayDevice()
+	if device == nil {
+		devices, err := devices.ForReplay(ctx, p.Capture)
+		if err != nil {
+			return nil, err
+		}
+		if len(devices) == 0 {
+			return nil, fmt.Errorf("No compatible replay devices found")
+		}
+		device = devices[0]
+	}
+
+	intent := replay.Intent{
+		Device:  device,
+		Capture: p.Capture,
+	}
+	return query.QueryTextureUsage(
+		ctx,
+		intent,
+		replay.GetManager(ctx),
+		measured,
+		p.MaxTextures,
+		r,
+		&service.UsageHints{Background: true},
+	)
+}
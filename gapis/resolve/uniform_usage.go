@@ -0,0 +1,68 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// UniformUsage resolves and returns the uses of the uniforms and push
+// constants of the shader programs of the capture of p, suggesting the
+// uniforms that could be removed or made constant.
+// Only commands of APIs implementing api.UniformUsageAnalyzer are analyzed.
+// If p.Frames is set, only the commands of those frames are analyzed.
+func UniformUsage(ctx context.Context, p *path.UniformUsage, r *path.ResolveConfig) (*api.UniformUsage, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := framesCommandRange(ctx, p.Capture, p.Frames, uint64(len(cmds)), p, r)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b := api.NewUniformUsageBuilder()
+	err = api.ForeachCmd(ctx, cmds[:end], true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if a, ok := cmd.API().(api.UniformUsageAnalyzer); ok && uint64(id) >= start {
+			if err := a.AnalyzeUniformUsage(ctx, id, cmd, st, b); err != nil {
+				return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+			}
+		} else if err := cmd.Mutate(ctx, id, st, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := b.Usage()
+	for _, u := range out.Uniforms {
+		if u.FirstSet != nil {
+			u.FirstSet.Capture = p.Capture
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Uploads resolves and returns the report of the data read from application
+// memory by the upload commands of the capture of p, those flagged with
+// api.Upload, such as glTexImage2D or vkQueueSubmit. The reads of other
+// commands, such as the parameters of draw calls, are not uploads of texture
+// or buffer data and are ignored. Observed reads are identified
+// by the hash of their content, so a read is redundant if an earlier command
+// read identical content, regardless of the address it was read from.
+// Commands after the last frame boundary are reported as part of the last
+// frame. If p.Frames is set, only those frames are reported, though reads are
+// still considered redundant if they repeat a read of an earlier frame.
+func Uploads(ctx context.Context, p *path.Uploads, r *path.ResolveConfig) (*service.UploadReport, error) {
+	cmds, err := Cmds(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := Events(ctx, &path.Events{
+		Capture:     p.Capture,
+		LastInFrame: true,
+	}, r)
+	if err != nil {
+		return nil, err
+	}
+
+	numFrames := len(events.List)
+	if numFrames == 0 {
+		numFrames = 1
+	}
+	out := &service.UploadReport{Frames: make([]*service.FrameUploads, numFrames)}
+	for i := range out.Frames {
+		out.Frames[i] = &service.FrameUploads{Redundant: []*service.RedundantUpload{}}
+	}
+
+	st, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	first := map[id.ID]api.CmdID{}
+	ends := frameEnds(events)
+	err = api.ForeachCmd(ctx, cmds, true, func(ctx context.Context, cmdID api.CmdID, cmd api.Cmd) error {
+		if err := cmd.Mutate(ctx, cmdID, st, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		f := out.Frames[frameOf(ends, cmdID)]
+		if o := cmd.Extras().Observations(); o != nil && cmd.CmdFlags(ctx, cmdID, st).IsUpload() {
+			for _, read := range o.Reads {
+				size := read.Range.Size
+				if size == 0 || size < p.MinSize {
+					continue
+				}
+				f.TotalBytes += size
+				if orig, ok := first[read.ID]; ok {
+					f.RedundantBytes += size
+					f.Redundant = append(f.Redundant, &service.RedundantUpload{
+						Command:  p.Capture.Command(uint64(cmdID)),
+						Original: p.Capture.Command(uint64(orig)),
+						Size:     size,
+					})
+				} else {
+					first[read.ID] = cmdID
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	firstFrame, lastFrame, err := frameRangeBounds(p.Frames, uint64(numFrames), p)
+	if err != nil {
+		return nil, err
+	}
+	out.Frames = out.Frames[firstFrame : lastFrame+1]
+
+	for _, f := range out.Frames {
+		out.TotalBytes += f.TotalBytes
+		out.RedundantBytes += f.RedundantBytes
+	}
+	return out, nil
+}
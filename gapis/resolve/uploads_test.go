@@ -0,0 +1,107 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestUploads(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	a := arena.New()
+	cb := test.CommandBuilder{Arena: a}
+	dataA, dataB := id.ID{1}, id.ID{2}
+	reads := func(cmd api.Cmd, rngs map[memory.Range]id.ID) api.Cmd {
+		o := &api.CmdObservations{}
+		for rng, id := range rngs {
+			o.AddRead(rng, id)
+		}
+		cmd.Extras().Add(o)
+		return cmd
+	}
+	cmds := []api.Cmd{
+		reads(cb.CmdUpload(test.Voidᵖ(0x1000)),
+			map[memory.Range]id.ID{{Base: 0x1000, Size: 16}: dataA}),
+		reads(cb.CmdUpload(test.Voidᵖ(0x2000)),
+			map[memory.Range]id.ID{{Base: 0x2000, Size: 16}: dataA, {Base: 0x3000, Size: 4}: dataB}),
+		// Not an upload command, so its reads are ignored.
+		reads(cb.CmdTypeMix(1, 15, 25, 35, 45, 55, 65, 75, 85, 95, 105, false, test.Voidᵖ(0x1000), 3),
+			map[memory.Range]id.ID{{Base: 0x1000, Size: 16}: dataA}),
+		cb.PrimeState(test.U8ᵖ(0x4000)),
+	}
+	h := &capture.Header{ABI: device.WindowsX86_64}
+	c, err := capture.NewGraphicsCapture(ctx, a, "test", h, nil, cmds)
+	if err != nil {
+		log.F(ctx, true, "Couldn't create capture: %v", err)
+	}
+	p, err := c.Path(ctx)
+	if err != nil {
+		log.F(ctx, true, "Couldn't get capture path: %v", err)
+	}
+	ctx = capture.Put(ctx, p)
+
+	for _, test := range []struct {
+		name     string
+		minSize  uint64
+		expected *service.UploadReport
+	}{
+		{
+			"all",
+			0,
+			&service.UploadReport{
+				TotalBytes:     36,
+				RedundantBytes: 16,
+				Frames: []*service.FrameUploads{{
+					TotalBytes:     36,
+					RedundantBytes: 16,
+					Redundant: []*service.RedundantUpload{
+						{Command: p.Command(1), Original: p.Command(0), Size: 16},
+					},
+				}},
+			},
+		}, {
+			"min size",
+			32,
+			&service.UploadReport{
+				Frames: []*service.FrameUploads{{
+					Redundant: []*service.RedundantUpload{},
+				}},
+			},
+		},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		got, err := Uploads(ctx, p.Uploads(test.minSize), nil)
+		if assert.For(ctx, "err").ThatError(err).Succeeded() {
+			assert.For(ctx, "report").That(got).DeepEquals(test.expected)
+		}
+	}
+}
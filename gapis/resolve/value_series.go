@@ -0,0 +1,116 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/google/gapid/core/math/u64"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// ValueSeries resolves and returns the numeric values of the state value or
+// command parameter of p, sampled across the range of commands of p.
+// Commands at which the value does not exist, or is not numeric, are not
+// included in the series.
+func ValueSeries(ctx context.Context, p *path.ValueSeries, r *path.ResolveConfig) (*service.ValueSeries, error) {
+	c := p.Commands.Capture
+	cmds, err := Cmds(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	out := &service.ValueSeries{Samples: []*service.ValueSample{}}
+	if len(cmds) == 0 {
+		return out, nil
+	}
+	if len(p.Commands.From) > 1 || len(p.Commands.To) > 1 {
+		return nil, fmt.Errorf("Subcommands currently not supported for ValueSeries") // TODO: Subcommands
+	}
+	from := api.CmdID(u64.Min(p.Commands.From[0], uint64(len(cmds)-1)))
+	to := api.CmdID(u64.Min(p.Commands.To[0], uint64(len(cmds)-1)))
+	if from > to {
+		return nil, fmt.Errorf("Invalid command boundaries")
+	}
+
+	sample := func(id api.CmdID, v interface{}) {
+		if f, ok := numericValue(v); ok {
+			out.Samples = append(out.Samples, &service.ValueSample{
+				Command: c.Command(uint64(id)),
+				Value:   f,
+			})
+		}
+	}
+
+	if p.State == nil {
+		for id, cmd := range cmds[from : to+1] {
+			if cmd.CmdName() != p.CommandName {
+				continue
+			}
+			if param := cmd.CmdParams().Find(p.Parameter); param != nil {
+				sample(from+api.CmdID(id), param.Get())
+			}
+		}
+		return out, nil
+	}
+
+	s, err := capture.NewState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = api.ForeachCmd(ctx, cmds[:to+1], true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		if err := cmd.Mutate(ctx, id, s, nil, nil); err != nil {
+			return fmt.Errorf("Fail to mutate command %v: %v", cmd, err)
+		}
+		if id >= from {
+			if v, ok := stateObjectAfter(ctx, p.State.Node(), c.Command(uint64(id)), cmd.API(), s, r); ok {
+				sample(id, v)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// numericValue returns v as a float64, and true, if v is a boolean or numeric
+// value. Otherwise numericValue returns false.
+func numericValue(v interface{}) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Bool:
+		if r.Bool() {
+			return 1, true
+		}
+		return 0, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(r.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(r.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return r.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestValueSeries(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	p := newPathTest(ctx)
+	ctx = capture.Put(ctx, p)
+	all := p.CommandRange(0, 2)
+
+	sample := func(id uint64, v float64) *service.ValueSample {
+		return &service.ValueSample{Command: p.Command(id), Value: v}
+	}
+
+	for _, test := range []struct {
+		name     string
+		path     *path.ValueSeries
+		expected []*service.ValueSample
+	}{
+		{
+			"parameter",
+			all.ParameterSeries("cmdTypeMix", "U8"),
+			[]*service.ValueSample{sample(0, 10), sample(1, 15)},
+		}, {
+			"bool parameter",
+			all.ParameterSeries("cmdTypeMix", "Bool"),
+			[]*service.ValueSample{sample(0, 1), sample(1, 0)},
+		}, {
+			"missing parameter",
+			all.ParameterSeries("cmdTypeMix", "DoesNotExist"),
+			[]*service.ValueSample{},
+		}, {
+			"state",
+			all.StateSeries(p.Command(0).StateAfter().Field("Ref").Field("RefObject").Field("value")),
+			[]*service.ValueSample{sample(2, 555)},
+		}, {
+			"sub-range",
+			p.CommandRange(1, 1).ParameterSeries("cmdTypeMix", "U8"),
+			[]*service.ValueSample{sample(1, 15)},
+		},
+	} {
+		ctx := log.Enter(ctx, test.name)
+		got, err := ValueSeries(ctx, test.path, nil)
+		if assert.For(ctx, "err").ThatError(err).Succeeded() {
+			assert.For(ctx, "samples").That(got.Samples).DeepEquals(test.expected)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+)
+
+// captureIndexes holds the indexes of the directory trees of captures that
+// have been searched. Each tree is indexed when first searched, and only
+// scanned for changes again when a refresh is requested.
+type captureIndexes struct {
+	dir     string // The directory tree searched when no root is given.
+	mutex   sync.Mutex
+	indexes map[string]*capture.Index
+}
+
+// newCaptureIndexes returns a captureIndexes that searches dir when no root
+// is given.
+func newCaptureIndexes(dir string) *captureIndexes {
+	return &captureIndexes{dir: dir, indexes: map[string]*capture.Index{}}
+}
+
+// search returns the captures of the directory tree root that match q. If save
+// is true, the index of the tree is stored in root when it is updated.
+func (i *captureIndexes) search(ctx context.Context, root string, q *service.CaptureQuery, refresh, save bool) ([]*service.IndexedCapture, error) {
+	if root == "" {
+		root = i.dir
+	}
+	if root == "" {
+		return nil, &service.ErrInvalidArgument{Reason: messages.ErrNoCaptureIndex()}
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	i.mutex.Lock()
+	_, ok := i.indexes[root]
+	i.mutex.Unlock()
+	if !ok || refresh {
+		// The tree is indexed without holding the lock, as it can take a long
+		// time to decode the captures without metadata.
+		var idx *capture.Index
+		if save {
+			idx, err = capture.UpdateIndex(ctx, root)
+		} else {
+			idx, err = capture.BuildIndex(ctx, root, capture.LoadIndex(ctx, root))
+		}
+		if err != nil {
+			return nil, err
+		}
+		i.mutex.Lock()
+		i.indexes[root] = idx
+		i.mutex.Unlock()
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.indexes[root].Search(root, q), nil
+}
+
+// annotate updates the tags and annotation of the capture file at path, and
+// of the entries for the file in the held indexes.
+func (i *captureIndexes) annotate(ctx context.Context, path string, add, remove []string, setAnnotation bool, annotation string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+		return &service.ErrDataUnavailable{Reason: messages.ErrFileCannotBeRead()}
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	tags, err := capture.UpdateTags(ctx, path, add, remove)
+	if err != nil {
+		return err
+	}
+	if setAnnotation {
+		if err := capture.SetAnnotation(ctx, path, annotation); err != nil {
+			return err
+		}
+	}
+	for root, idx := range i.indexes {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		for _, e := range idx.Entries {
+			if e.Path == rel {
+				e.Tags = tags
+				if setAnnotation {
+					e.Annotation = strings.TrimSpace(annotation)
+				}
+			}
+		}
+	}
+	return nil
+}
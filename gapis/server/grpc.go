@@ -72,6 +72,12 @@ func NewWithListener(ctx context.Context, l net.Listener, cfg Config, srvChan ch
 			if srvChan != nil {
 				srvChan <- server
 			}
+			if cfg.HealthAddr != "" {
+				crash.Go(func() { s.serveHealth(ctx, cfg.HealthAddr) })
+			}
+			if cfg.WebAddr != "" {
+				crash.Go(func() { s.serveWeb(ctx, cfg.WebAddr, cfg.AuthToken, cfg.WebOrigin) })
+			}
 			if cfg.IdleTimeout != 0 {
 				crash.Go(func() { s.stopIfIdle(ctx, server, cfg.IdleTimeout, stop) })
 			} else {
@@ -203,6 +209,27 @@ func (s *grpcServer) GetServerInfo(ctx xctx.Context, req *service.GetServerInfoR
 	return &service.GetServerInfoResponse{Res: &service.GetServerInfoResponse_Info{Info: info}}, nil
 }
 
+func (s *grpcServer) GetHealth(ctx xctx.Context, req *service.GetHealthRequest) (*service.GetHealthResponse, error) {
+	// Health checks are not considered inflight RPCs, so that they neither
+	// count themselves nor keep an idle server alive.
+	health, err := s.health(s.bindCtx(ctx))
+	if err := service.NewError(err); err != nil {
+		return &service.GetHealthResponse{Res: &service.GetHealthResponse_Error{Error: err}}, nil
+	}
+	return &service.GetHealthResponse{Res: &service.GetHealthResponse_Health{Health: health}}, nil
+}
+
+// health returns the health of the server, including the number of RPCs in
+// flight.
+func (s *grpcServer) health(ctx context.Context) (*service.Health, error) {
+	health, err := s.handler.GetHealth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	health.InFlightRpcs = uint32(atomic.LoadInt64(&s.inFlightRPCs))
+	return health, nil
+}
+
 func (s *grpcServer) CheckForUpdates(ctx xctx.Context, req *service.CheckForUpdatesRequest) (*service.CheckForUpdatesResponse, error) {
 	defer s.inRPC()()
 	release, err := s.handler.CheckForUpdates(s.bindCtx(ctx), req.IncludeDevReleases)
@@ -231,6 +258,17 @@ func (s *grpcServer) Set(ctx xctx.Context, req *service.SetRequest) (*service.Se
 	return &service.SetResponse{Res: &service.SetResponse_Path{Path: res}}, nil
 }
 
+func (s *grpcServer) GetShaderDiagnostics(ctx xctx.Context, req *service.GetShaderDiagnosticsRequest) (*service.GetShaderDiagnosticsResponse, error) {
+	defer s.inRPC()()
+	list, err := s.handler.GetShaderDiagnostics(s.bindCtx(ctx), req.Resource, req.Shader, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.GetShaderDiagnosticsResponse{Res: &service.GetShaderDiagnosticsResponse_Error{Error: err}}, nil
+	}
+	return &service.GetShaderDiagnosticsResponse{
+		Res: &service.GetShaderDiagnosticsResponse_Diagnostics{Diagnostics: &service.ShaderDiagnostics{List: list}},
+	}, nil
+}
+
 func (s *grpcServer) Delete(ctx xctx.Context, req *service.DeleteRequest) (*service.DeleteResponse, error) {
 	defer s.inRPC()()
 	res, err := s.handler.Delete(s.bindCtx(ctx), req.Path, req.Config)
@@ -249,6 +287,164 @@ func (s *grpcServer) Follow(ctx xctx.Context, req *service.FollowRequest) (*serv
 	return &service.FollowResponse{Res: &service.FollowResponse_Path{Path: res}}, nil
 }
 
+func (s *grpcServer) GetCommandTreeRow(ctx xctx.Context, req *service.GetCommandTreeRowRequest) (*service.GetCommandTreeRowResponse, error) {
+	defer s.inRPC()()
+	row, err := s.handler.GetCommandTreeRow(s.bindCtx(ctx), req)
+	if err := service.NewError(err); err != nil {
+		return &service.GetCommandTreeRowResponse{Res: &service.GetCommandTreeRowResponse_Error{Error: err}}, nil
+	}
+	return &service.GetCommandTreeRowResponse{Res: &service.GetCommandTreeRowResponse_Row{Row: row}}, nil
+}
+
+func (s *grpcServer) GetCommandTreeStats(ctx xctx.Context, req *service.GetCommandTreeStatsRequest) (*service.GetCommandTreeStatsResponse, error) {
+	defer s.inRPC()()
+	res, err := s.handler.GetCommandTreeStats(s.bindCtx(ctx), req.Node, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.GetCommandTreeStatsResponse{Res: &service.GetCommandTreeStatsResponse_Error{Error: err}}, nil
+	}
+	return &service.GetCommandTreeStatsResponse{Res: &service.GetCommandTreeStatsResponse_Stats{Stats: res}}, nil
+}
+
+func (s *grpcServer) FindStateChange(ctx xctx.Context, req *service.FindStateChangeRequest) (*service.FindStateChangeResponse, error) {
+	defer s.inRPC()()
+	res, err := s.handler.FindStateChange(s.bindCtx(ctx), req.Value, req.Backwards, req.Equals, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.FindStateChangeResponse{Res: &service.FindStateChangeResponse_Error{Error: err}}, nil
+	}
+	return &service.FindStateChangeResponse{Res: &service.FindStateChangeResponse_Command{Command: res}}, nil
+}
+
+func (s *grpcServer) StepToBreakpoint(ctx xctx.Context, req *service.StepToBreakpointRequest) (*service.StepToBreakpointResponse, error) {
+	defer s.inRPC()()
+	res, err := s.handler.StepToBreakpoint(s.bindCtx(ctx), req.From, req.Breakpoints, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.StepToBreakpointResponse{Res: &service.StepToBreakpointResponse_Error{Error: err}}, nil
+	}
+	return &service.StepToBreakpointResponse{Res: &service.StepToBreakpointResponse_Command{Command: res}}, nil
+}
+
+func (s *grpcServer) CompareState(ctx xctx.Context, req *service.CompareStateRequest) (*service.CompareStateResponse, error) {
+	defer s.inRPC()()
+	res, err := s.handler.CompareState(s.bindCtx(ctx), req.Paths, req.Commands, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.CompareStateResponse{Res: &service.CompareStateResponse_Error{Error: err}}, nil
+	}
+	return &service.CompareStateResponse{Res: &service.CompareStateResponse_Table{Table: res}}, nil
+}
+
+func (s *grpcServer) ExportState(ctx xctx.Context, req *service.ExportStateRequest) (*service.ExportStateResponse, error) {
+	defer s.inRPC()()
+	state, err := s.handler.ExportState(s.bindCtx(ctx), req.After, req.MaxMemorySize, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.ExportStateResponse{Res: &service.ExportStateResponse_Error{Error: err}}, nil
+	}
+	return &service.ExportStateResponse{Res: &service.ExportStateResponse_State{State: state}}, nil
+}
+
+func (s *grpcServer) DiffReports(ctx xctx.Context, req *service.DiffReportsRequest) (*service.DiffReportsResponse, error) {
+	defer s.inRPC()()
+	diff, err := s.handler.DiffReports(s.bindCtx(ctx), req.Before, req.After, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.DiffReportsResponse{Res: &service.DiffReportsResponse_Error{Error: err}}, nil
+	}
+	return &service.DiffReportsResponse{Res: &service.DiffReportsResponse_Diff{Diff: diff}}, nil
+}
+
+func (s *grpcServer) ScrubState(conn service.Gapid_ScrubStateServer) error {
+	defer s.inRPC()()
+	ctx := s.bindCtx(conn.Context())
+	var h service.StateScrubHandler
+	defer func() {
+		if h != nil {
+			h.Dispose(ctx)
+		}
+	}()
+
+	sendError := func(err *service.Error) error {
+		return conn.Send(&service.ScrubStateResponse{Res: &service.ScrubStateResponse_Error{Error: err}})
+	}
+	for {
+		req, err := conn.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch r := req.Req.(type) {
+		case *service.ScrubStateRequest_Subscribe:
+			if h != nil {
+				h.Dispose(ctx)
+			}
+			h, err = s.handler.ScrubState(ctx, r.Subscribe.Paths, r.Subscribe.Config)
+			if err := service.NewError(err); err != nil {
+				// The error is reported in response to the next move.
+				return sendError(err)
+			}
+		case *service.ScrubStateRequest_Move:
+			if h == nil {
+				return sendError(service.NewError(fmt.Errorf("ScrubState moved before subscribing")))
+			}
+			res, err := h.Move(ctx, r.Move)
+			if err := service.NewError(err); err != nil {
+				if err := sendError(err); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := conn.Send(&service.ScrubStateResponse{Res: &service.ScrubStateResponse_Delta{Delta: res}}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *grpcServer) GetStateSnippet(ctx xctx.Context, req *service.GetStateSnippetRequest) (*service.GetStateSnippetResponse, error) {
+	defer s.inRPC()()
+	res, err := s.handler.GetStateSnippet(s.bindCtx(ctx), req.Node, req.Format, req.Depth, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.GetStateSnippetResponse{Res: &service.GetStateSnippetResponse_Error{Error: err}}, nil
+	}
+	return &service.GetStateSnippetResponse{Res: &service.GetStateSnippetResponse_Snippet{Snippet: res}}, nil
+}
+
+func (s *grpcServer) GetCommandListWindow(ctx xctx.Context, req *service.GetCommandListWindowRequest) (*service.GetCommandListWindowResponse, error) {
+	defer s.inRPC()()
+	res, err := s.handler.GetCommandListWindow(s.bindCtx(ctx), req.Capture, req.Offset, req.Count, req.Tree, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.GetCommandListWindowResponse{Res: &service.GetCommandListWindowResponse_Error{Error: err}}, nil
+	}
+	return &service.GetCommandListWindowResponse{Res: &service.GetCommandListWindowResponse_Window{Window: res}}, nil
+}
+
+func (s *grpcServer) GetStateTreeChildren(ctx xctx.Context, req *service.GetStateTreeChildrenRequest) (*service.GetStateTreeChildrenResponse, error) {
+	defer s.inRPC()()
+	res, err := s.handler.GetStateTreeChildren(s.bindCtx(ctx), req.Node, req.Offset, req.Count, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.GetStateTreeChildrenResponse{Res: &service.GetStateTreeChildrenResponse_Error{Error: err}}, nil
+	}
+	return &service.GetStateTreeChildrenResponse{Res: &service.GetStateTreeChildrenResponse_Children{Children: res}}, nil
+}
+
+func (s *grpcServer) FindStateTreeNodes(ctx xctx.Context, req *service.FindStateTreeNodesRequest) (*service.FindStateTreeNodesResponse, error) {
+	defer s.inRPC()()
+	res, err := s.handler.FindStateTreeNodes(s.bindCtx(ctx), req.Tree, req.StableIds, req.Config)
+	if err := service.NewError(err); err != nil {
+		return &service.FindStateTreeNodesResponse{Res: &service.FindStateTreeNodesResponse_Error{Error: err}}, nil
+	}
+	return &service.FindStateTreeNodesResponse{Res: &service.FindStateTreeNodesResponse_Matches{Matches: res}}, nil
+}
+
+func (s *grpcServer) GetProfileTimeline(ctx xctx.Context, req *service.GetProfileTimelineRequest) (*service.GetProfileTimelineResponse, error) {
+	defer s.inRPC()()
+	res, err := s.handler.GetProfileTimeline(s.bindCtx(ctx), req.Capture, req.Start, req.End, req.Buckets)
+	if err := service.NewError(err); err != nil {
+		return &service.GetProfileTimelineResponse{Res: &service.GetProfileTimelineResponse_Error{Error: err}}, nil
+	}
+	return &service.GetProfileTimelineResponse{Res: &service.GetProfileTimelineResponse_Timeline{Timeline: res}}, nil
+}
+
 type syncBuffer struct {
 	bytes.Buffer
 	sync.Mutex
@@ -462,16 +658,43 @@ func (s *grpcServer) ExportCapture(ctx xctx.Context, req *service.ExportCaptureR
 
 func (s *grpcServer) LoadCapture(ctx xctx.Context, req *service.LoadCaptureRequest) (*service.LoadCaptureResponse, error) {
 	defer s.inRPC()()
-	capture, err := s.handler.LoadCapture(s.bindCtx(ctx), req.Path)
+	capture, err := s.handler.LoadCapture(s.bindCtx(ctx), req.Path, req.Key)
 	if err := service.NewError(err); err != nil {
 		return &service.LoadCaptureResponse{Res: &service.LoadCaptureResponse_Error{Error: err}}, nil
 	}
 	return &service.LoadCaptureResponse{Res: &service.LoadCaptureResponse_Capture{Capture: capture}}, nil
 }
 
+func (s *grpcServer) GetCaptureMetadata(ctx xctx.Context, req *service.GetCaptureMetadataRequest) (*service.GetCaptureMetadataResponse, error) {
+	defer s.inRPC()()
+	metadata, err := s.handler.GetCaptureMetadata(s.bindCtx(ctx), req.Path)
+	if err := service.NewError(err); err != nil {
+		return &service.GetCaptureMetadataResponse{Res: &service.GetCaptureMetadataResponse_Error{Error: err}}, nil
+	}
+	return &service.GetCaptureMetadataResponse{Res: &service.GetCaptureMetadataResponse_Metadata{Metadata: metadata}}, nil
+}
+
+func (s *grpcServer) GetLoadedCaptures(ctx xctx.Context, req *service.GetLoadedCapturesRequest) (*service.GetLoadedCapturesResponse, error) {
+	defer s.inRPC()()
+	captures, err := s.handler.GetLoadedCaptures(s.bindCtx(ctx))
+	if err := service.NewError(err); err != nil {
+		return &service.GetLoadedCapturesResponse{Res: &service.GetLoadedCapturesResponse_Error{Error: err}}, nil
+	}
+	return &service.GetLoadedCapturesResponse{Res: &service.GetLoadedCapturesResponse_Captures{Captures: &service.LoadedCaptures{List: captures}}}, nil
+}
+
+func (s *grpcServer) UnloadCapture(ctx xctx.Context, req *service.UnloadCaptureRequest) (*service.UnloadCaptureResponse, error) {
+	defer s.inRPC()()
+	err := s.handler.UnloadCapture(s.bindCtx(ctx), req.Capture)
+	if err := service.NewError(err); err != nil {
+		return &service.UnloadCaptureResponse{Error: err}, nil
+	}
+	return &service.UnloadCaptureResponse{}, nil
+}
+
 func (s *grpcServer) SaveCapture(ctx xctx.Context, req *service.SaveCaptureRequest) (*service.SaveCaptureResponse, error) {
 	defer s.inRPC()()
-	err := s.handler.SaveCapture(s.bindCtx(ctx), req.Capture, req.Path)
+	err := s.handler.SaveCapture(s.bindCtx(ctx), req.Capture, req.Path, req.Key)
 	if err := service.NewError(err); err != nil {
 		return &service.SaveCaptureResponse{Error: err}, nil
 	}
@@ -496,6 +719,69 @@ func (s *grpcServer) DCECapture(ctx xctx.Context, req *service.DCECaptureRequest
 	return &service.DCECaptureResponse{Res: &service.DCECaptureResponse_Capture{Capture: capture}}, nil
 }
 
+func (s *grpcServer) ScrubCapture(ctx xctx.Context, req *service.ScrubCaptureRequest) (*service.ScrubCaptureResponse, error) {
+	defer s.inRPC()()
+	capture, err := s.handler.ScrubCapture(s.bindCtx(ctx), req.Capture)
+	if err := service.NewError(err); err != nil {
+		return &service.ScrubCaptureResponse{Res: &service.ScrubCaptureResponse_Error{Error: err}}, nil
+	}
+	return &service.ScrubCaptureResponse{Res: &service.ScrubCaptureResponse_Capture{Capture: capture}}, nil
+}
+
+func (s *grpcServer) SearchCaptures(ctx xctx.Context, req *service.SearchCapturesRequest) (*service.SearchCapturesResponse, error) {
+	defer s.inRPC()()
+	captures, err := s.handler.SearchCaptures(s.bindCtx(ctx), req.Root, req.Query, req.Refresh)
+	if err := service.NewError(err); err != nil {
+		return &service.SearchCapturesResponse{Res: &service.SearchCapturesResponse_Error{Error: err}}, nil
+	}
+	return &service.SearchCapturesResponse{Res: &service.SearchCapturesResponse_Captures{Captures: &service.IndexedCaptures{List: captures}}}, nil
+}
+
+func (s *grpcServer) AnnotateCapture(ctx xctx.Context, req *service.AnnotateCaptureRequest) (*service.AnnotateCaptureResponse, error) {
+	defer s.inRPC()()
+	err := s.handler.AnnotateCapture(s.bindCtx(ctx), req.Path, req.AddTags, req.RemoveTags, req.SetAnnotation, req.Annotation)
+	if err := service.NewError(err); err != nil {
+		return &service.AnnotateCaptureResponse{Error: err}, nil
+	}
+	return &service.AnnotateCaptureResponse{}, nil
+}
+
+func (s *grpcServer) UploadCapture(ctx xctx.Context, req *service.UploadCaptureRequest) (*service.UploadCaptureResponse, error) {
+	defer s.inRPC()()
+	transfer, err := s.handler.UploadCapture(s.bindCtx(ctx), req)
+	if err := service.NewError(err); err != nil {
+		return &service.UploadCaptureResponse{Res: &service.UploadCaptureResponse_Error{Error: err}}, nil
+	}
+	return &service.UploadCaptureResponse{Res: &service.UploadCaptureResponse_Transfer{Transfer: transfer}}, nil
+}
+
+func (s *grpcServer) DownloadCapture(ctx xctx.Context, req *service.DownloadCaptureRequest) (*service.DownloadCaptureResponse, error) {
+	defer s.inRPC()()
+	chunk, err := s.handler.DownloadCapture(s.bindCtx(ctx), req.Capture, req.Offset, req.MaxSize)
+	if err := service.NewError(err); err != nil {
+		return &service.DownloadCaptureResponse{Res: &service.DownloadCaptureResponse_Error{Error: err}}, nil
+	}
+	return &service.DownloadCaptureResponse{Res: &service.DownloadCaptureResponse_Chunk{Chunk: chunk}}, nil
+}
+
+func (s *grpcServer) PushCapture(ctx xctx.Context, req *service.PushCaptureRequest) (*service.PushCaptureResponse, error) {
+	defer s.inRPC()()
+	capture, err := s.handler.PushCapture(s.bindCtx(ctx), req.Capture, req.Remote, req.RemoteToken)
+	if err := service.NewError(err); err != nil {
+		return &service.PushCaptureResponse{Res: &service.PushCaptureResponse_Error{Error: err}}, nil
+	}
+	return &service.PushCaptureResponse{Res: &service.PushCaptureResponse_Capture{Capture: capture}}, nil
+}
+
+func (s *grpcServer) PullCapture(ctx xctx.Context, req *service.PullCaptureRequest) (*service.PullCaptureResponse, error) {
+	defer s.inRPC()()
+	capture, err := s.handler.PullCapture(s.bindCtx(ctx), req.Remote, req.RemoteToken, req.Capture)
+	if err := service.NewError(err); err != nil {
+		return &service.PullCaptureResponse{Res: &service.PullCaptureResponse_Error{Error: err}}, nil
+	}
+	return &service.PullCaptureResponse{Res: &service.PullCaptureResponse_Capture{Capture: capture}}, nil
+}
+
 func (s *grpcServer) GetGraphVisualization(ctx xctx.Context, req *service.GraphVisualizationRequest) (*service.GraphVisualizationResponse, error) {
 	defer s.inRPC()()
 	graphVisualization, err := s.handler.GetGraphVisualization(s.bindCtx(ctx), req.Capture, req.Format)
@@ -505,6 +791,15 @@ func (s *grpcServer) GetGraphVisualization(ctx xctx.Context, req *service.GraphV
 	return &service.GraphVisualizationResponse{Res: &service.GraphVisualizationResponse_GraphVisualization{GraphVisualization: graphVisualization}}, nil
 }
 
+func (s *grpcServer) ExportDependencyGraph(ctx xctx.Context, req *service.ExportDependencyGraphRequest) (*service.ExportDependencyGraphResponse, error) {
+	defer s.inRPC()()
+	graph, err := s.handler.ExportDependencyGraph(s.bindCtx(ctx), req.Capture, req.Around, req.Radius, req.Format)
+	if err := service.NewError(err); err != nil {
+		return &service.ExportDependencyGraphResponse{Res: &service.ExportDependencyGraphResponse_Error{Error: err}}, nil
+	}
+	return &service.ExportDependencyGraphResponse{Res: &service.ExportDependencyGraphResponse_Graph{Graph: graph}}, nil
+}
+
 func (s *grpcServer) GetDevices(ctx xctx.Context, req *service.GetDevicesRequest) (*service.GetDevicesResponse, error) {
 	defer s.inRPC()()
 	devices, err := s.handler.GetDevices(s.bindCtx(ctx))
@@ -553,7 +848,20 @@ func (s *grpcServer) GetLogStream(req *service.GetLogStreamRequest, server servi
 	defer s.addInterrupter(cancel)()
 
 	h := log.NewHandler(func(m *log.Message) { server.Send(log_pb.From(m)) }, nil)
-	return s.handler.GetLogStream(s.bindCtx(ctx), h)
+	return s.handler.GetLogStream(s.bindCtx(ctx), req, h)
+}
+
+func (s *grpcServer) GetRecentLogs(ctx xctx.Context, req *service.GetRecentLogsRequest) (*service.GetRecentLogsResponse, error) {
+	defer s.inRPC()()
+	msgs, err := s.handler.GetRecentLogs(s.bindCtx(ctx), req)
+	if err := service.NewError(err); err != nil {
+		return &service.GetRecentLogsResponse{Res: &service.GetRecentLogsResponse_Error{Error: err}}, nil
+	}
+	list := make([]*log_pb.Message, len(msgs))
+	for i, m := range msgs {
+		list[i] = log_pb.From(m)
+	}
+	return &service.GetRecentLogsResponse{Res: &service.GetRecentLogsResponse_Messages{Messages: &service.LogMessages{List: list}}}, nil
 }
 
 func (s *grpcServer) Find(req *service.FindRequest, server service.Gapid_FindServer) error {
@@ -562,6 +870,22 @@ func (s *grpcServer) Find(req *service.FindRequest, server service.Gapid_FindSer
 	return s.handler.Find(s.bindCtx(ctx), req, server.Send)
 }
 
+func (s *grpcServer) SetSelection(ctx xctx.Context, req *service.SetSelectionRequest) (*service.SetSelectionResponse, error) {
+	defer s.inRPC()()
+	err := s.handler.SetSelection(s.bindCtx(ctx), req.Selection)
+	if err := service.NewError(err); err != nil {
+		return &service.SetSelectionResponse{Error: err}, nil
+	}
+	return &service.SetSelectionResponse{}, nil
+}
+
+func (s *grpcServer) FollowSelection(req *service.FollowSelectionRequest, server service.Gapid_FollowSelectionServer) error {
+	// defer s.inRPC()() -- don't consider the selection stream an inflight RPC.
+	ctx, cancel := task.WithCancel(server.Context())
+	defer s.addInterrupter(cancel)()
+	return s.handler.FollowSelection(s.bindCtx(ctx), server.Send)
+}
+
 func (s *grpcServer) GpuProfile(ctx xctx.Context, req *service.GpuProfileRequest) (*service.GpuProfileResponse, error) {
 	defer s.inRPC()()
 	res, err := s.handler.GpuProfile(s.bindCtx(ctx), req)
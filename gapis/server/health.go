@@ -0,0 +1,60 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+)
+
+// serveHealth serves the health of the server over HTTP on addr until ctx is
+// stopped, for tools that can't use the GetHealth RPC. /healthz responds with
+// 200 while the server is alive, /readyz with 200 once it is ready to serve
+// requests and 503 before. Both respond with the service.Health as JSON.
+func (s *grpcServer) serveHealth(ctx context.Context, addr string) {
+	handler := func(requireReady bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			health, err := s.health(s.bindCtx(r.Context()))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if requireReady && !health.Ready {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			json.NewEncoder(w).Encode(health)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", handler(false))
+	mux.HandleFunc("/readyz", handler(true))
+	server := &http.Server{Addr: addr, Handler: mux}
+	crash.Go(func() {
+		<-task.ShouldStop(ctx)
+		server.Close()
+	})
+
+	log.I(ctx, "Serving health endpoints on %v", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.E(ctx, "Health endpoints stopped: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/log/log_pb"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// logFilter selects the log messages returned by the log RPCs.
+type logFilter struct {
+	severity  log.Severity
+	processes []string
+	capture   id.ID // Matches all messages if invalid.
+}
+
+func newLogFilter(severity log_pb.Severity, processes []string, c *path.Capture) logFilter {
+	f := logFilter{severity: log.Severity(severity), processes: processes}
+	if c != nil {
+		f.capture = c.ID.ID()
+	}
+	return f
+}
+
+// matches returns true if m passes the filter.
+func (f logFilter) matches(m *log.Message) bool {
+	if m.Severity < f.severity {
+		return false
+	}
+	if len(f.processes) > 0 {
+		found := false
+		for _, p := range f.processes {
+			if p == m.Process {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.capture.IsValid() {
+		for _, v := range m.Values {
+			if v.Name == "capture" && v.Value == f.capture {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// handler returns a log.Handler that passes the messages matching f on to h.
+func (f logFilter) handler(h log.Handler) log.Handler {
+	return log.NewHandler(func(m *log.Message) {
+		if f.matches(m) {
+			h.Handle(m)
+		}
+	}, h.Close)
+}
+
+// filter returns the messages of l that match f.
+func (f logFilter) filter(l []*log.Message) []*log.Message {
+	out := []*log.Message{}
+	for _, m := range l {
+		if f.matches(m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
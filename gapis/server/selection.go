@@ -0,0 +1,65 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+
+	"github.com/google/gapid/gapis/service"
+)
+
+// selectionBroadcaster holds the selection shared between the clients of the
+// server, and notifies the clients following the selection of each change.
+type selectionBroadcaster struct {
+	mutex     sync.Mutex
+	current   *service.Selection
+	listeners map[chan *service.Selection]struct{}
+}
+
+// set changes the shared selection to s, notifying all the listeners.
+func (b *selectionBroadcaster) set(s *service.Selection) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.current = s
+	for l := range b.listeners {
+		// Listeners only care about the latest selection, so replace any
+		// selection the listener has not received yet.
+		select {
+		case <-l:
+		default:
+		}
+		l <- s
+	}
+}
+
+// listen returns a channel that receives the current selection, if any, and
+// each following change of selection, until unlisten is called.
+func (b *selectionBroadcaster) listen() (c <-chan *service.Selection, unlisten func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	l := make(chan *service.Selection, 1)
+	if b.current != nil {
+		l <- b.current
+	}
+	if b.listeners == nil {
+		b.listeners = map[chan *service.Selection]struct{}{}
+	}
+	b.listeners[l] = struct{}{}
+	return l, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		delete(b.listeners, l)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 	"sync"
@@ -38,6 +39,7 @@ import (
 	"github.com/google/gapid/core/app/benchmark"
 	"github.com/google/gapid/core/app/status"
 	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/image"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/os/android/adb"
 	"github.com/google/gapid/core/os/device/bind"
@@ -45,6 +47,7 @@ import (
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/config"
+	"github.com/google/gapid/gapis/database"
 	"github.com/google/gapid/gapis/messages"
 	perfetto "github.com/google/gapid/gapis/perfetto/service"
 	"github.com/google/gapid/gapis/replay"
@@ -53,6 +56,7 @@ import (
 	"github.com/google/gapid/gapis/resolve/dependencygraph2"
 	"github.com/google/gapid/gapis/resolve/dependencygraph2/graph_visualization"
 	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
 	"github.com/google/gapid/gapis/service/path"
 	"github.com/google/gapid/gapis/stringtable"
 	"github.com/google/gapid/gapis/trace"
@@ -72,10 +76,19 @@ type Config struct {
 	Info             *service.ServerInfo
 	StringTables     []*stringtable.StringTable
 	EnableLocalFiles bool
+	ReadOnly         bool   // Disallows edits and exports of captures.
+	MaxPayloadSize   uint64 // The largest blob returned if ReadOnly, 0 for no limit.
 	AuthToken        auth.Token
 	DeviceScanDone   task.Signal
 	LogBroadcaster   *log.Broadcaster
+	LogRing          *log.Ring
 	IdleTimeout      time.Duration
+	HealthAddr       string     // Serves HTTP health endpoints if not empty.
+	Workers          []string   // The host:port of worker servers that replays are dispatched to.
+	WorkerAuthToken  auth.Token // The connection authorization token for the workers.
+	CaptureIndexDir  string     // The directory tree searched by default by SearchCaptures.
+	WebAddr          string     // Serves a subset of the RPCs as HTTP/JSON for browsers if not empty.
+	WebOrigin        string     // The origin of the web pages allowed to use the web bridge.
 }
 
 // Server is the server interface to GAPIS.
@@ -89,8 +102,16 @@ func New(ctx context.Context, cfg Config) Server {
 		cfg.Info,
 		cfg.StringTables,
 		cfg.EnableLocalFiles,
+		cfg.ReadOnly,
+		cfg.MaxPayloadSize,
+		cfg.AuthToken,
 		cfg.DeviceScanDone,
 		cfg.LogBroadcaster,
+		cfg.LogRing,
+		&selectionBroadcaster{},
+		newWorkerPool(cfg.Workers, cfg.WorkerAuthToken),
+		newCaptureIndexes(cfg.CaptureIndexDir),
+		newTransfers(),
 	}
 }
 
@@ -98,8 +119,74 @@ type server struct {
 	info             *service.ServerInfo
 	stbs             []*stringtable.StringTable
 	enableLocalFiles bool
+	readOnly         bool
+	maxPayloadSize   uint64
+	authToken        auth.Token
 	deviceScanDone   task.Signal
 	logBroadcaster   *log.Broadcaster
+	logRing          *log.Ring
+	selection        *selectionBroadcaster
+	workers          *workerPool // nil if replays are not dispatched to workers.
+	captureIndexes   *captureIndexes
+	transfers        *transfers
+}
+
+// checkWritable returns an error if the server is read-only, and so does not
+// permit the RPC op, which edits, exports or imports captures, or otherwise
+// changes the state shared by the clients of the server or its devices.
+func (s *server) checkWritable(op string) error {
+	if s.readOnly {
+		return &service.ErrInvalidArgument{Reason: messages.ErrServerReadOnly(op)}
+	}
+	return nil
+}
+
+// addCaptureKey adds the key given to the RPC op for a single capture, and
+// returns its identifier, or an empty string if no key was given. Keys are
+// only accepted by a server that requires an auth token, so that they can only
+// be given by the owner of the server.
+func (s *server) addCaptureKey(op string, key []byte) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+	if s.authToken == auth.NoAuth {
+		return "", &service.ErrInvalidArgument{Reason: messages.ErrCaptureKeyUnauthenticated(op)}
+	}
+	return capture.AddKey(key)
+}
+
+// checkPayload returns an error if the server is read-only, and the raw data
+// of size bytes is larger than it returns.
+func (s *server) checkPayload(size uint64) error {
+	if s.readOnly && s.maxPayloadSize > 0 && size > s.maxPayloadSize {
+		return &service.ErrDataUnavailable{Reason: messages.ErrPayloadTooLarge(size, s.maxPayloadSize)}
+	}
+	return nil
+}
+
+// checkResult returns an error if the server is read-only, and the value v
+// resolved by Get holds raw data larger than it returns.
+func (s *server) checkResult(v interface{}) error {
+	switch v := v.(type) {
+	case []byte:
+		return s.checkPayload(uint64(len(v)))
+	case *service.Memory:
+		return s.checkPayload(uint64(len(v.Data)))
+	case *api.DrawBundle:
+		for _, b := range v.Buffers {
+			if err := s.checkPayload(uint64(len(b.Data))); err != nil {
+				return err
+			}
+		}
+		for _, i := range v.Images {
+			if err := s.checkPayload(uint64(len(i.Data))); err != nil {
+				return err
+			}
+		}
+	case *api.Scene:
+		return s.checkPayload(uint64(len(v.Gltf)))
+	}
+	return nil
 }
 
 func (s *server) Ping(ctx context.Context) error {
@@ -113,6 +200,35 @@ func (s *server) GetServerInfo(ctx context.Context) (*service.ServerInfo, error)
 	return s.info, nil
 }
 
+func (s *server) GetHealth(ctx context.Context) (*service.Health, error) {
+	// Not a status task, as health checks are frequent and should not show up
+	// as server activity.
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	out := &service.Health{
+		Ready:           s.deviceScanDone.Fired(),
+		LoadingCaptures: uint32(capture.NumLoading()),
+		ActiveReplays:   uint32(status.ActiveReplays()),
+		HeapInUse:       mem.HeapInuse,
+		MemorySys:       mem.Sys,
+	}
+	for _, c := range capture.Loaded(ctx) {
+		if c.Resident {
+			out.ResidentCaptures++
+		}
+	}
+	switch {
+	case out.ActiveReplays > 0:
+		out.State = service.ServerState_SERVER_REPLAYING
+	case out.LoadingCaptures > 0:
+		out.State = service.ServerState_SERVER_LOADING
+	default:
+		out.State = service.ServerState_SERVER_IDLE
+	}
+	return out, nil
+}
+
 func (s *server) CheckForUpdates(ctx context.Context, includeDevReleases bool) (*service.Release, error) {
 	const (
 		githubOrg     = "google"
@@ -212,24 +328,28 @@ func (s *server) ImportCapture(ctx context.Context, name string, data []uint8) (
 	ctx = status.Start(ctx, "RPC ImportCapture")
 	defer status.Finish(ctx)
 	ctx = log.Enter(ctx, "ImportCapture")
-	src := &capture.Blob{Data: data}
-	p, err := capture.Import(ctx, name, name, src)
-	if err != nil {
+	if err := s.checkWritable("ImportCapture"); err != nil {
 		return nil, err
 	}
-	// Ensure the capture can be read by resolving it now.
-	if _, err = capture.ResolveFromPath(ctx, p); err != nil {
-		return nil, err
-	}
-	return p, nil
+	return importCapture(ctx, name, data)
 }
 
 func (s *server) ExportCapture(ctx context.Context, c *path.Capture) ([]byte, error) {
 	ctx = status.Start(ctx, "RPC ExportCapture")
 	defer status.Finish(ctx)
 	ctx = log.Enter(ctx, "ExportCapture")
+	if err := s.checkWritable("ExportCapture"); err != nil {
+		return nil, err
+	}
 	b := bytes.Buffer{}
-	if err := capture.Export(ctx, c, &b); err != nil {
+	w, err := capture.EncryptingWriter(&b, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := capture.Export(ctx, c, w); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
 		return nil, err
 	}
 	return b.Bytes(), nil
@@ -253,13 +373,21 @@ func ReadFile(f *os.File) ([]byte, error) {
 	return in, nil
 }
 
-func (s *server) LoadCapture(ctx context.Context, path string) (*path.Capture, error) {
+func (s *server) LoadCapture(ctx context.Context, path string, key []byte) (*path.Capture, error) {
 	ctx = status.Start(ctx, "RPC LoadCapture")
 	defer status.Finish(ctx)
 	ctx = log.Enter(ctx, "LoadCapture")
 	if !s.enableLocalFiles {
 		return nil, fmt.Errorf("Server not configured to allow reading of local files")
 	}
+	keyID, err := s.addCaptureKey("LoadCapture", key)
+	if err != nil {
+		return nil, err
+	}
+	cacheKeyID := keyID
+	if cacheKeyID == "" {
+		cacheKeyID = capture.KeyID()
+	}
 
 	fileInfo, err := os.Stat(path)
 	if err != nil {
@@ -268,10 +396,13 @@ func (s *server) LoadCapture(ctx context.Context, path string) (*path.Capture, e
 
 	name := fileInfo.Name()
 	// Create a key to prevent name collusion between traces.
-	key := fmt.Sprintf("%v%v%v", name, fileInfo.Size(), fileInfo.ModTime().Unix())
+	// The encryption key is included, so that an encrypted capture that
+	// failed to load can be loaded again with the right key, and so that
+	// loading it without the key does not return the decrypted capture.
+	dbKey := fmt.Sprintf("%v%v%v%v", name, fileInfo.Size(), fileInfo.ModTime().Unix(), cacheKeyID)
 	src := &capture.File{Path: path}
 
-	p, err := capture.Import(ctx, key, name, src)
+	p, err := capture.ImportEncrypted(ctx, dbKey, name, keyID, src)
 	if err != nil {
 		return nil, err
 	}
@@ -301,24 +432,116 @@ func (s *server) LoadCapture(ctx context.Context, path string) (*path.Capture, e
 	return p, nil
 }
 
-func (s *server) SaveCapture(ctx context.Context, c *path.Capture, path string) error {
+func (s *server) GetCaptureMetadata(ctx context.Context, path string) (*service.CaptureMetadata, error) {
+	ctx = status.Start(ctx, "RPC GetCaptureMetadata")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetCaptureMetadata")
+	if !s.enableLocalFiles {
+		return nil, fmt.Errorf("Server not configured to allow reading of local files")
+	}
+
+	header, metadata, err := capture.ReadMetadata(ctx, &capture.File{Path: path})
+	if err != nil {
+		return nil, err
+	}
+	out := &service.CaptureMetadata{
+		Name:      filepath.Base(path),
+		Device:    header.Device,
+		ABI:       header.ABI,
+		StartTime: header.StartTime,
+	}
+	if metadata == nil {
+		return out, nil
+	}
+	out.HasSummary = true
+	out.APIs = metadata.APIs
+	out.NumCommands = metadata.NumCommands
+	out.NumFrames = metadata.NumFrames
+	out.Duration = metadata.Duration
+	if o := metadata.Thumbnail; o != nil {
+		data, err := database.Store(ctx, o.Data)
+		if err != nil {
+			return nil, err
+		}
+		out.Thumbnail = &image.Info{
+			Format: image.RGBA_U8_NORM,
+			Width:  o.DataWidth,
+			Height: o.DataHeight,
+			Depth:  1,
+			Bytes:  image.NewID(data),
+		}
+	}
+	return out, nil
+}
+
+func (s *server) GetLoadedCaptures(ctx context.Context) ([]*service.LoadedCapture, error) {
+	ctx = status.Start(ctx, "RPC GetLoadedCaptures")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetLoadedCaptures")
+	captures := capture.Loaded(ctx)
+	for _, c := range captures {
+		if !c.Resident || c.Capture.Type != service.TraceType_Graphics {
+			continue
+		}
+		events, err := resolve.Events(ctx, &path.Events{
+			Capture:     c.Path,
+			LastInFrame: true,
+		}, nil)
+		if err != nil {
+			log.W(ctx, "Couldn't count frames of capture %v: %v", c.Name, err)
+			continue
+		}
+		c.NumFrames = uint64(len(events.List))
+	}
+	return captures, nil
+}
+
+func (s *server) UnloadCapture(ctx context.Context, c *path.Capture) error {
+	ctx = status.Start(ctx, "RPC UnloadCapture")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "UnloadCapture")
+	if err := s.checkWritable("UnloadCapture"); err != nil {
+		return err
+	}
+	return capture.Unload(ctx, c)
+}
+
+func (s *server) SaveCapture(ctx context.Context, c *path.Capture, path string, key []byte) error {
 	ctx = status.Start(ctx, "RPC SaveCapture")
 	defer status.Finish(ctx)
 	ctx = log.Enter(ctx, "SaveCapture")
+	if err := s.checkWritable("SaveCapture"); err != nil {
+		return err
+	}
 	if !s.enableLocalFiles {
 		return fmt.Errorf("Server not configured to allow writing of local files")
 	}
+	keyID, err := s.addCaptureKey("SaveCapture", key)
+	if err != nil {
+		return err
+	}
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0666)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	return capture.Export(ctx, c, f)
+	w, err := capture.EncryptingWriter(f, keyID)
+	if err != nil {
+		return err
+	}
+	if err := capture.Export(ctx, c, w); err != nil {
+		return err
+	}
+	return w.Close()
 }
+
 func (s *server) ExportReplay(ctx context.Context, c *path.Capture, d *path.Device, out string, opts *service.ExportReplayOptions) error {
 	ctx = status.Start(ctx, "RPC ExportReplay")
 	defer status.Finish(ctx)
 	ctx = log.Enter(ctx, "ExportReplay")
+	if err := s.checkWritable("ExportReplay"); err != nil {
+		return err
+	}
 	if !s.enableLocalFiles {
 		return fmt.Errorf("Server not configured to allow writing of local files")
 	}
@@ -327,10 +550,18 @@ func (s *server) ExportReplay(ctx context.Context, c *path.Capture, d *path.Devi
 
 func (s *server) DCECapture(ctx context.Context, p *path.Capture, requested []*path.Command) (*path.Capture, error) {
 	ctx = log.Enter(ctx, "DCECapture")
+	if err := s.checkWritable("DCECapture"); err != nil {
+		return nil, err
+	}
 	c, err := capture.ResolveFromPath(ctx, p)
 	if err != nil {
 		return nil, err
 	}
+	for _, cmd := range requested {
+		if err := resolve.StableCommands(ctx, cmd); err != nil {
+			return nil, err
+		}
+	}
 	trimmed, err := dependencygraph2.DCECapture(ctx, c.Name()+"_dce", p, requested)
 	if err != nil {
 		return nil, err
@@ -338,6 +569,89 @@ func (s *server) DCECapture(ctx context.Context, p *path.Capture, requested []*p
 	return trimmed, nil
 }
 
+func (s *server) ScrubCapture(ctx context.Context, p *path.Capture) (*path.Capture, error) {
+	ctx = status.Start(ctx, "RPC ScrubCapture")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "ScrubCapture")
+	if err := s.checkWritable("ScrubCapture"); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+	}
+	return resolve.ScrubCapture(ctx, p)
+}
+
+func (s *server) SearchCaptures(ctx context.Context, root string, q *service.CaptureQuery, refresh bool) ([]*service.IndexedCapture, error) {
+	ctx = status.Start(ctx, "RPC SearchCaptures")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "SearchCaptures")
+	if root != "" && !s.enableLocalFiles {
+		return nil, fmt.Errorf("Server not configured to allow reading of local files")
+	}
+	// A read-only server searches the captures, but does not store the index.
+	return s.captureIndexes.search(ctx, root, q, refresh, !s.readOnly)
+}
+
+func (s *server) AnnotateCapture(ctx context.Context, path string, addTags, removeTags []string, setAnnotation bool, annotation string) error {
+	ctx = status.Start(ctx, "RPC AnnotateCapture")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "AnnotateCapture")
+	if err := s.checkWritable("AnnotateCapture"); err != nil {
+		return err
+	}
+	if !s.enableLocalFiles {
+		return fmt.Errorf("Server not configured to allow writing of local files")
+	}
+	return s.captureIndexes.annotate(ctx, path, addTags, removeTags, setAnnotation, annotation)
+}
+
+func (s *server) UploadCapture(ctx context.Context, req *service.UploadCaptureRequest) (*service.CaptureTransfer, error) {
+	ctx = status.Start(ctx, "RPC UploadCapture")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "UploadCapture")
+	if err := s.checkWritable("UploadCapture"); err != nil {
+		return nil, err
+	}
+	return s.transfers.upload(ctx, req)
+}
+
+func (s *server) DownloadCapture(ctx context.Context, c *path.Capture, offset, maxSize uint64) (*service.CaptureChunk, error) {
+	ctx = status.Start(ctx, "RPC DownloadCapture")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "DownloadCapture")
+	if err := s.checkWritable("DownloadCapture"); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", c)
+	}
+	return s.transfers.download(ctx, c, offset, maxSize)
+}
+
+func (s *server) PushCapture(ctx context.Context, c *path.Capture, remote, remoteToken string) (*path.Capture, error) {
+	ctx = status.Start(ctx, "RPC PushCapture")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "PushCapture")
+	if err := s.checkWritable("PushCapture"); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", c)
+	}
+	return s.transfers.push(ctx, c, remote, auth.Token(remoteToken))
+}
+
+func (s *server) PullCapture(ctx context.Context, remote, remoteToken string, c *path.Capture) (*path.Capture, error) {
+	ctx = status.Start(ctx, "RPC PullCapture")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "PullCapture")
+	if err := s.checkWritable("PullCapture"); err != nil {
+		return nil, err
+	}
+	return s.transfers.pull(ctx, remote, auth.Token(remoteToken), c)
+}
+
 func (s *server) GetGraphVisualization(ctx context.Context, p *path.Capture, format service.GraphFormat) ([]byte, error) {
 	ctx = status.Start(ctx, "RPC GetGraphVisualization")
 	defer status.Finish(ctx)
@@ -353,6 +667,31 @@ func (s *server) GetGraphVisualization(ctx context.Context, p *path.Capture, for
 	return graphVisualization, nil
 }
 
+func (s *server) ExportDependencyGraph(ctx context.Context, p *path.Capture, around *path.Command, radius uint32, format service.GraphFormat) ([]byte, error) {
+	ctx = status.Start(ctx, "RPC ExportDependencyGraph")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "ExportDependencyGraph")
+	if err := p.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+	}
+	if around != nil {
+		if err := around.Validate(); err != nil {
+			return nil, log.Errf(ctx, err, "Invalid path: %v", around)
+		}
+		if err := resolve.StableCommands(ctx, around); err != nil {
+			return nil, err
+		}
+	}
+	graph, err := graph_visualization.ExportDependencyGraph(ctx, p, around, radius, format)
+	if err != nil {
+		return nil, err
+	}
+	if len(graph) > FILE_SIZE_LIMIT_IN_BYTES {
+		return nil, log.Errf(ctx, nil, "The dependency graph size exceeds %d bytes", FILE_SIZE_LIMIT_IN_BYTES)
+	}
+	return graph, nil
+}
+
 func (s *server) GetDevices(ctx context.Context) ([]*path.Device, error) {
 	ctx = status.Start(ctx, "RPC GetDevices")
 	defer status.Finish(ctx)
@@ -409,6 +748,12 @@ func (s *server) GetFramebufferAttachment(
 	if err := after.Validate(); err != nil {
 		return nil, log.Errf(ctx, err, "Invalid path: %v", after)
 	}
+	if err := resolve.StableCommands(ctx, after); err != nil {
+		return nil, err
+	}
+	if s.workers != nil {
+		return s.workers.framebufferAttachment(ctx, replaySettings, after, attachment, settings, hints)
+	}
 	r := &path.ResolveConfig{
 		ReplayDevice: replaySettings.Device,
 	}
@@ -422,10 +767,19 @@ func (s *server) Get(ctx context.Context, p *path.Any, c *path.ResolveConfig) (i
 	if err := p.Validate(); err != nil {
 		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
 	}
-	v, err := resolve.Get(ctx, p, c)
+	var v interface{}
+	var err error
+	if t := p.GetThumbnail(); t != nil && s.workers != nil {
+		v, err = s.workers.thumbnail(ctx, t)
+	} else {
+		v, err = resolve.Get(ctx, p, c)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkResult(v); err != nil {
+		return nil, err
+	}
 	return v, nil
 }
 
@@ -433,16 +787,35 @@ func (s *server) Set(ctx context.Context, p *path.Any, v interface{}, r *path.Re
 	ctx = status.Start(ctx, "RPC Set<%v>", p)
 	defer status.Finish(ctx)
 	ctx = log.Enter(ctx, "Set")
+	if err := s.checkWritable("Set"); err != nil {
+		return nil, err
+	}
 	if err := p.Validate(); err != nil {
 		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
 	}
 	return resolve.Set(ctx, p, v, r)
 }
 
+func (s *server) GetShaderDiagnostics(ctx context.Context, p *path.ResourceData, shader *api.Shader, r *path.ResolveConfig) ([]*service.ShaderDiagnostic, error) {
+	ctx = status.Start(ctx, "RPC GetShaderDiagnostics<%v>", p)
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetShaderDiagnostics")
+	if err := p.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+	}
+	if err := resolve.StableCommands(ctx, p); err != nil {
+		return nil, err
+	}
+	return resolve.ShaderDiagnostics(ctx, p, shader, r)
+}
+
 func (s *server) Delete(ctx context.Context, p *path.Any, r *path.ResolveConfig) (*path.Any, error) {
 	ctx = status.Start(ctx, "RPC Delete<%v>", p)
 	defer status.Finish(ctx)
 	ctx = log.Enter(ctx, "Delete")
+	if err := s.checkWritable("Delete"); err != nil {
+		return nil, err
+	}
 	if err := p.Validate(); err != nil {
 		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
 	}
@@ -459,13 +832,192 @@ func (s *server) Follow(ctx context.Context, p *path.Any, r *path.ResolveConfig)
 	return resolve.Follow(ctx, p, r)
 }
 
-func (s *server) GetLogStream(ctx context.Context, handler log.Handler) error {
+func (s *server) GetCommandTreeStats(ctx context.Context, p *path.CommandTreeNode, r *path.ResolveConfig) (*service.CommandTreeStats, error) {
+	ctx = status.Start(ctx, "RPC GetCommandTreeStats")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetCommandTreeStats")
+	if err := p.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+	}
+	return resolve.CommandTreeStats(ctx, p, r)
+}
+
+func (s *server) FindStateChange(ctx context.Context, p *path.Any, backwards bool, equals *box.Value, r *path.ResolveConfig) (*path.Command, error) {
+	ctx = status.Start(ctx, "RPC FindStateChange")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "FindStateChange")
+	if err := p.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+	}
+	if err := resolve.StableCommands(ctx, p.Node()); err != nil {
+		return nil, err
+	}
+	return resolve.FindStateChange(ctx, p.Node(), backwards, equals, r)
+}
+
+func (s *server) StepToBreakpoint(ctx context.Context, from *path.Command, breakpoints []*service.CommandBreakpoint, r *path.ResolveConfig) (*path.Command, error) {
+	ctx = status.Start(ctx, "RPC StepToBreakpoint")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "StepToBreakpoint")
+	if err := from.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", from)
+	}
+	if err := resolve.StableCommands(ctx, from); err != nil {
+		return nil, err
+	}
+	return resolve.StepToBreakpoint(ctx, from, breakpoints, r)
+}
+
+func (s *server) CompareState(ctx context.Context, paths []*path.Any, cmds []*path.Command, r *path.ResolveConfig) (*service.StateTable, error) {
+	ctx = status.Start(ctx, "RPC CompareState")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "CompareState")
+	for _, p := range paths {
+		if err := p.Validate(); err != nil {
+			return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+		}
+		if err := resolve.StableCommands(ctx, p.Node()); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range cmds {
+		if err := p.Validate(); err != nil {
+			return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+		}
+		if err := resolve.StableCommands(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+	return resolve.CompareState(ctx, paths, cmds, r)
+}
+
+func (s *server) ExportState(ctx context.Context, after *path.Command, maxMemorySize uint64, r *path.ResolveConfig) ([]byte, error) {
+	ctx = status.Start(ctx, "RPC ExportState")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "ExportState")
+	if err := after.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", after)
+	}
+	if err := resolve.StableCommands(ctx, after); err != nil {
+		return nil, err
+	}
+	state, err := resolve.ExportState(ctx, after, maxMemorySize, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPayload(uint64(len(state))); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *server) DiffReports(ctx context.Context, before, after *path.Report, r *path.ResolveConfig) (*service.ReportDiff, error) {
+	ctx = status.Start(ctx, "RPC DiffReports")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "DiffReports")
+	for _, p := range []*path.Report{before, after} {
+		if err := p.Validate(); err != nil {
+			return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+		}
+	}
+	return resolve.DiffReports(ctx, before, after, r)
+}
+
+func (s *server) ScrubState(ctx context.Context, paths []*path.Any, r *path.ResolveConfig) (service.StateScrubHandler, error) {
+	ctx = status.Start(ctx, "RPC ScrubState")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "ScrubState")
+	for _, p := range paths {
+		if err := p.Validate(); err != nil {
+			return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+		}
+		if err := resolve.StableCommands(ctx, p.Node()); err != nil {
+			return nil, err
+		}
+	}
+	return resolve.NewStateScrubber(ctx, paths, r)
+}
+
+func (s *server) GetStateSnippet(ctx context.Context, p *path.StateTreeNode, format service.SnippetFormat, depth int32, r *path.ResolveConfig) (string, error) {
+	ctx = status.Start(ctx, "RPC GetStateSnippet")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetStateSnippet")
+	if err := p.Validate(); err != nil {
+		return "", log.Errf(ctx, err, "Invalid path: %v", p)
+	}
+	return resolve.StateSnippet(ctx, p, format, depth, r)
+}
+
+func (s *server) GetCommandListWindow(ctx context.Context, p *path.Capture, offset uint64, count uint32, tree *path.ID, r *path.ResolveConfig) (*service.CommandListWindow, error) {
+	ctx = status.Start(ctx, "RPC GetCommandListWindow")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetCommandListWindow")
+	if err := p.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+	}
+	return resolve.CommandListWindow(ctx, p, offset, count, tree, r)
+}
+
+func (s *server) GetStateTreeChildren(ctx context.Context, p *path.StateTreeNode, offset uint64, count uint32, r *path.ResolveConfig) (*service.StateTreeChildren, error) {
+	ctx = status.Start(ctx, "RPC GetStateTreeChildren")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetStateTreeChildren")
+	if err := p.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+	}
+	return resolve.StateTreeChildren(ctx, p, offset, count, r)
+}
+
+func (s *server) FindStateTreeNodes(ctx context.Context, tree *path.ID, ids []string, r *path.ResolveConfig) (*service.StateTreeNodeMatches, error) {
+	ctx = status.Start(ctx, "RPC FindStateTreeNodes")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "FindStateTreeNodes")
+	if !tree.IsValid() {
+		return nil, log.Errf(ctx, nil, "Invalid state tree: %v", tree)
+	}
+	return resolve.FindStateTreeNodes(ctx, tree, ids, r)
+}
+
+func (s *server) GetProfileTimeline(ctx context.Context, p *path.Capture, start, end uint64, buckets uint32) (*service.ProfileTimeline, error) {
+	ctx = status.Start(ctx, "RPC GetProfileTimeline")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetProfileTimeline")
+	if err := p.Validate(); err != nil {
+		return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+	}
+	return resolve.ProfileTimeline(ctx, p, start, end, buckets)
+}
+
+func (s *server) GetCommandTreeRow(ctx context.Context, req *service.GetCommandTreeRowRequest) (*service.CommandTreeRow, error) {
+	ctx = status.Start(ctx, "RPC GetCommandTreeRow")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetCommandTreeRow")
+	paths := append([]*path.CommandTreeNode{req.Root}, req.Expanded...)
+	if node := req.GetNode(); node != nil {
+		paths = append(paths, node)
+	}
+	for _, p := range paths {
+		if err := p.Validate(); err != nil {
+			return nil, log.Errf(ctx, err, "Invalid path: %v", p)
+		}
+	}
+	return resolve.CommandTreeRow(ctx, req.Root, req.Expanded, req.GetNode(), req.GetRow(), req.Config)
+}
+
+func (s *server) GetLogStream(ctx context.Context, req *service.GetLogStreamRequest, handler log.Handler) error {
 	ctx = status.StartBackground(ctx, "RPC GetLogStream")
 	defer status.Finish(ctx)
 	ctx = log.Enter(ctx, "GetLogStream")
 	closed := make(chan struct{})
 	handler = log.OnClosed(handler, func() { close(closed) })
 	handler = log.Channel(handler, 64)
+	f := newLogFilter(req.GetSeverity(), req.GetProcesses(), req.GetCapture())
+	handler = f.handler(handler)
+	if req.GetRecent() && s.logRing != nil {
+		for _, m := range f.filter(s.logRing.Messages()) {
+			handler.Handle(m)
+		}
+	}
 	unregister := s.logBroadcaster.Listen(handler)
 	defer unregister()
 	select {
@@ -477,6 +1029,17 @@ func (s *server) GetLogStream(ctx context.Context, handler log.Handler) error {
 	return task.StopReason(ctx)
 }
 
+func (s *server) GetRecentLogs(ctx context.Context, req *service.GetRecentLogsRequest) ([]*log.Message, error) {
+	ctx = status.Start(ctx, "RPC GetRecentLogs")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "GetRecentLogs")
+	if s.logRing == nil {
+		return []*log.Message{}, nil
+	}
+	f := newLogFilter(req.GetSeverity(), req.GetProcesses(), req.GetCapture())
+	return f.filter(s.logRing.Messages()), nil
+}
+
 func (s *server) Find(ctx context.Context, req *service.FindRequest, handler service.FindHandler) error {
 	ctx = status.Start(ctx, "RPC Find")
 	defer status.Finish(ctx)
@@ -484,6 +1047,48 @@ func (s *server) Find(ctx context.Context, req *service.FindRequest, handler ser
 	return resolve.Find(ctx, req, handler)
 }
 
+func (s *server) SetSelection(ctx context.Context, sel *service.Selection) error {
+	ctx = status.Start(ctx, "RPC SetSelection")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "SetSelection")
+	if err := s.checkWritable("SetSelection"); err != nil {
+		return err
+	}
+	if sel == nil {
+		return log.Err(ctx, nil, "Selection must not be nil")
+	}
+	if sel.Command != nil {
+		if err := sel.Command.Validate(); err != nil {
+			return log.Errf(ctx, err, "Invalid path: %v", sel.Command)
+		}
+	}
+	if sel.State != nil {
+		if err := sel.State.Validate(); err != nil {
+			return log.Errf(ctx, err, "Invalid path: %v", sel.State)
+		}
+	}
+	s.selection.set(sel)
+	return nil
+}
+
+func (s *server) FollowSelection(ctx context.Context, handler service.SelectionHandler) error {
+	ctx = status.StartBackground(ctx, "RPC FollowSelection")
+	defer status.Finish(ctx)
+	ctx = log.Enter(ctx, "FollowSelection")
+	c, unlisten := s.selection.listen()
+	defer unlisten()
+	for {
+		select {
+		case sel := <-c:
+			if err := handler(sel); err != nil {
+				return err
+			}
+		case <-task.ShouldStop(ctx):
+			return task.StopReason(ctx)
+		}
+	}
+}
+
 func (s *server) Profile(ctx context.Context, pprofW, traceW io.Writer, memorySnapshotInterval uint32) (stop func() error, err error) {
 	ctx = status.Start(ctx, "RPC Profile")
 	defer status.Finish(ctx)
@@ -658,6 +1263,8 @@ func (l *statusListener) OnReplayStatusUpdate(ctx context.Context, r *status.Rep
 	switch {
 	case finished:
 		status = service.ReplayStatus_REPLAY_FINISHED
+	case r.Reconnecting():
+		status = service.ReplayStatus_REPLAY_RECONNECTING
 	case totalInstrs > 0:
 		status = service.ReplayStatus_REPLAY_EXECUTING
 	case started:
@@ -673,6 +1280,7 @@ func (l *statusListener) OnReplayStatusUpdate(ctx context.Context, r *status.Rep
 		Label:          label,
 		TotalInstrs:    totalInstrs,
 		FinishedInstrs: finishedInstrs,
+		Attempt:        r.Attempt(),
 	})
 }
 
@@ -874,6 +1482,9 @@ func (r *traceHandler) Dispose(ctx context.Context) {
 }
 
 func (s *server) Trace(ctx context.Context) (service.TraceHandler, error) {
+	if err := s.checkWritable("Trace"); err != nil {
+		return nil, err
+	}
 	startSignal, startFunc := task.NewSignal()
 	doneSignal, doneSigFunc := task.NewSignal()
 	return &traceHandler{
@@ -886,6 +1497,9 @@ func (s *server) Trace(ctx context.Context) (service.TraceHandler, error) {
 }
 
 func (s *server) UpdateSettings(ctx context.Context, settings *service.UpdateSettingsRequest) error {
+	if err := s.checkWritable("UpdateSettings"); err != nil {
+		return err
+	}
 	if settings.EnableAnalytics {
 		analytics.Enable(ctx, settings.ClientId, analytics.AppVersion{
 			Name: app.Name, Build: app.Version.Build,
@@ -911,13 +1525,16 @@ func (s *server) GetTimestamps(ctx context.Context, req *service.GetTimestampsRe
 	ctx = status.Start(ctx, "RPC GetTimestamps")
 	defer status.Finish(ctx)
 	ctx = log.Enter(ctx, "GetTimestamps")
-	return replay.GetTimestamps(ctx, req.Capture, req.Device, req.LoopCount, h)
+	return replay.GetTimestamps(ctx, req.Capture, req.Device, req.LoopCount, req.SubCommands, h)
 }
 
 func (s *server) GpuProfile(ctx context.Context, req *service.GpuProfileRequest) (*service.ProfilingData, error) {
 	ctx = status.Start(ctx, "RPC GpuProfile")
 	defer status.Finish(ctx)
 	ctx = log.Enter(ctx, "GpuProfile")
+	if s.workers != nil {
+		return s.workers.gpuProfile(ctx, req)
+	}
 	res, err := replay.GpuProfile(ctx, req.Capture, req.Device)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,112 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+)
+
+func TestReadOnlyServer(t *testing.T) {
+	ctx := log.Testing(t)
+	s := &server{readOnly: true}
+
+	for _, test := range []struct {
+		rpc  string
+		call func() error
+	}{
+		{"ImportCapture", func() error { _, err := s.ImportCapture(ctx, "", nil); return err }},
+		{"ExportCapture", func() error { _, err := s.ExportCapture(ctx, nil); return err }},
+		{"UnloadCapture", func() error { return s.UnloadCapture(ctx, nil) }},
+		{"SaveCapture", func() error { return s.SaveCapture(ctx, nil, "", nil) }},
+		{"ExportReplay", func() error { return s.ExportReplay(ctx, nil, nil, "", nil) }},
+		{"DCECapture", func() error { _, err := s.DCECapture(ctx, nil, nil); return err }},
+		{"ScrubCapture", func() error { _, err := s.ScrubCapture(ctx, nil); return err }},
+		{"AnnotateCapture", func() error { return s.AnnotateCapture(ctx, "", nil, nil, false, "") }},
+		{"UploadCapture", func() error { _, err := s.UploadCapture(ctx, nil); return err }},
+		{"DownloadCapture", func() error { _, err := s.DownloadCapture(ctx, nil, 0, 0); return err }},
+		{"PushCapture", func() error { _, err := s.PushCapture(ctx, nil, "", ""); return err }},
+		{"PullCapture", func() error { _, err := s.PullCapture(ctx, "", "", nil); return err }},
+		{"Set", func() error { _, err := s.Set(ctx, nil, nil, nil); return err }},
+		{"Delete", func() error { _, err := s.Delete(ctx, nil, nil); return err }},
+		{"SetSelection", func() error { return s.SetSelection(ctx, nil) }},
+		{"Trace", func() error { _, err := s.Trace(ctx); return err }},
+		{"UpdateSettings", func() error { return s.UpdateSettings(ctx, nil) }},
+	} {
+		err := test.call()
+		_, rejected := err.(*service.ErrInvalidArgument)
+		assert.For(ctx, "%v rejected", test.rpc).That(rejected).Equals(true)
+	}
+}
+
+func TestCaptureKeyAuth(t *testing.T) {
+	ctx := log.Testing(t)
+	key := bytes.Repeat([]byte{0x42}, 32)
+
+	// Keys are rejected by a server that does not require an auth token.
+	s := &server{enableLocalFiles: true}
+	_, err := s.LoadCapture(ctx, "capture.gfxtrace", key)
+	_, rejected := err.(*service.ErrInvalidArgument)
+	assert.For(ctx, "LoadCapture rejected").That(rejected).Equals(true)
+	err = s.SaveCapture(ctx, nil, "capture.gfxtrace", key)
+	_, rejected = err.(*service.ErrInvalidArgument)
+	assert.For(ctx, "SaveCapture rejected").That(rejected).Equals(true)
+
+	s = &server{enableLocalFiles: true, authToken: auth.Token("secret")}
+	id, err := s.addCaptureKey("LoadCapture", key)
+	assert.For(ctx, "addCaptureKey").ThatError(err).Succeeded()
+	assert.For(ctx, "key id").That(id).NotEquals("")
+	id, err = s.addCaptureKey("LoadCapture", nil)
+	assert.For(ctx, "addCaptureKey(nil)").ThatError(err).Succeeded()
+	assert.For(ctx, "no key id").That(id).Equals("")
+	_, err = s.addCaptureKey("LoadCapture", key[:5])
+	assert.For(ctx, "short key").ThatError(err).Failed()
+}
+
+func TestReadOnlyPayload(t *testing.T) {
+	ctx := log.Testing(t)
+	s := &server{readOnly: true, maxPayloadSize: 4}
+	small, large := make([]byte, 4), make([]byte, 5)
+
+	for _, test := range []struct {
+		name     string
+		value    interface{}
+		rejected bool
+	}{
+		{"small bytes", small, false},
+		{"large bytes", large, true},
+		{"large memory", &service.Memory{Data: large}, true},
+		{"small draw bundle", &api.DrawBundle{
+			Buffers: []*api.DrawBundleBuffer{{Data: small}},
+			Images:  []*api.DrawBundleImage{{Data: small}},
+		}, false},
+		{"large draw bundle buffer", &api.DrawBundle{Buffers: []*api.DrawBundleBuffer{{Data: large}}}, true},
+		{"large draw bundle image", &api.DrawBundle{Images: []*api.DrawBundleImage{{Data: small}, {Data: large}}}, true},
+		{"large scene", &api.Scene{Gltf: large}, true},
+		{"other", &service.Capture{}, false},
+	} {
+		_, rejected := s.checkResult(test.value).(*service.ErrDataUnavailable)
+		assert.For(ctx, "%v rejected", test.name).That(rejected).Equals(test.rejected)
+	}
+
+	s.readOnly = false
+	assert.For(ctx, "writable").ThatError(s.checkResult(large)).Succeeded()
+}
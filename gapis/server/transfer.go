@@ -0,0 +1,342 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/app/status"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+const (
+	// transferChunkSize is the largest chunk of capture data sent by a single
+	// UploadCapture or DownloadCapture call, kept well below the gRPC message
+	// size limit.
+	transferChunkSize = 1 << 20
+
+	// maxTransferRetries is the number of consecutive failed calls after which
+	// a push or pull is abandoned.
+	maxTransferRetries = 3
+
+	// maxTransferSize is the largest capture that can be uploaded, as the
+	// whole capture is held in memory until it is loaded.
+	maxTransferSize = 4 << 30
+
+	// transferTTL is the duration after its last call for which an
+	// incomplete upload or download is held, and for which a completed upload
+	// is reported to the uploader.
+	transferTTL = 10 * time.Minute
+)
+
+// transfers holds the state of the capture uploads to, and downloads from,
+// the server.
+type transfers struct {
+	mutex     sync.Mutex
+	uploads   map[string]*upload         // Incomplete uploads by hash.
+	completed map[string]*completed      // Completed uploads by hash.
+	exports   map[id.ID]*exportedCapture // Downloads in progress by capture.
+}
+
+// upload is a partially received capture.
+type upload struct {
+	size uint64
+	data []byte
+	last time.Time // The time of the last call for the upload.
+}
+
+// completed is a capture that has been uploaded.
+type completed struct {
+	capture *path.Capture
+	last    time.Time // The time of the last call for the upload.
+}
+
+// exportedCapture is the exported data of a capture being downloaded.
+type exportedCapture struct {
+	name string
+	data []byte
+	hash []byte
+	last time.Time // The time of the last call for the download.
+}
+
+func newTransfers() *transfers {
+	return &transfers{
+		uploads:   map[string]*upload{},
+		completed: map[string]*completed{},
+		exports:   map[id.ID]*exportedCapture{},
+	}
+}
+
+// expire discards the transfers that have not been used for transferTTL, so
+// that abandoned transfers do not hold on to their data. expire must be called
+// with the mutex locked.
+func (t *transfers) expire(now time.Time) {
+	for k, u := range t.uploads {
+		if now.Sub(u.last) > transferTTL {
+			delete(t.uploads, k)
+		}
+	}
+	for k, c := range t.completed {
+		if now.Sub(c.last) > transferTTL {
+			delete(t.completed, k)
+		}
+	}
+	for k, e := range t.exports {
+		if now.Sub(e.last) > transferTTL {
+			delete(t.exports, k)
+		}
+	}
+}
+
+// upload appends the chunk of req to the upload it belongs to, and loads the
+// capture once all of its data has been received. Chunks that do not start at
+// the end of the data received so far are ignored, and the returned progress
+// tells the sender where to resume from.
+func (t *transfers) upload(ctx context.Context, req *service.UploadCaptureRequest) (*service.CaptureTransfer, error) {
+	if len(req.Hash) != sha256.Size {
+		return nil, &service.ErrInvalidArgument{Reason: messages.ErrInvalidTransferHash()}
+	}
+	if req.Size == 0 || req.Size > maxTransferSize {
+		return nil, &service.ErrInvalidArgument{Reason: messages.ErrTransferSizeInvalid(req.Size, uint64(maxTransferSize))}
+	}
+	key := hex.EncodeToString(req.Hash)
+
+	data, c, err := t.receive(key, req)
+	if err != nil || data == nil {
+		return c, err
+	}
+
+	if sum := sha256.Sum256(data); !bytes.Equal(sum[:], req.Hash) {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrTransferHashMismatch()}
+	}
+	p, err := importCapture(ctx, req.Name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	t.completed[key] = &completed{capture: p, last: time.Now()}
+	t.mutex.Unlock()
+	return &service.CaptureTransfer{Received: req.Size, Capture: p}, nil
+}
+
+// receive appends the chunk of req to the upload with the given key. If the
+// upload is complete, receive removes the upload and returns its data,
+// otherwise it returns the progress of the upload.
+func (t *transfers) receive(key string, req *service.UploadCaptureRequest) ([]byte, *service.CaptureTransfer, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	t.expire(now)
+	if c, ok := t.completed[key]; ok {
+		c.last = now
+		return nil, &service.CaptureTransfer{Received: req.Size, Capture: c.capture}, nil
+	}
+	u, ok := t.uploads[key]
+	if !ok {
+		u = &upload{size: req.Size}
+		t.uploads[key] = u
+	}
+	u.last = now
+	if u.size != req.Size {
+		return nil, nil, &service.ErrInvalidArgument{Reason: messages.ErrTransferSizeMismatch(req.Size, u.size)}
+	}
+	if len(req.Data) > 0 && req.Offset == uint64(len(u.data)) {
+		if req.Offset+uint64(len(req.Data)) > u.size {
+			return nil, nil, &service.ErrInvalidArgument{
+				Reason: messages.ErrTransferSizeMismatch(req.Offset+uint64(len(req.Data)), u.size),
+			}
+		}
+		u.data = append(u.data, req.Data...)
+	}
+	if received := uint64(len(u.data)); received < u.size {
+		return nil, &service.CaptureTransfer{Received: received}, nil
+	}
+	delete(t.uploads, key)
+	return u.data, nil, nil
+}
+
+// download returns the chunk of the exported data of the capture c at offset,
+// of at most maxSize bytes. The exported data is held until its last chunk
+// has been downloaded, or until it expires.
+func (t *transfers) download(ctx context.Context, c *path.Capture, offset, maxSize uint64) (*service.CaptureChunk, error) {
+	if maxSize == 0 || maxSize > transferChunkSize {
+		maxSize = transferChunkSize
+	}
+
+	t.mutex.Lock()
+	t.expire(time.Now())
+	e, ok := t.exports[c.ID.ID()]
+	t.mutex.Unlock()
+
+	if !ok {
+		// The capture is exported without holding the mutex, so that other
+		// transfers are not blocked by the export.
+		gc, err := capture.ResolveFromPath(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		data := bytes.Buffer{}
+		if err := capture.Export(ctx, c, &data); err != nil {
+			return nil, err
+		}
+		hash := sha256.Sum256(data.Bytes())
+		e = &exportedCapture{name: gc.Name(), data: data.Bytes(), hash: hash[:]}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if existing, ok := t.exports[c.ID.ID()]; ok {
+		e = existing // Exported by a concurrent call.
+	} else {
+		t.exports[c.ID.ID()] = e
+	}
+	e.last = time.Now()
+
+	size := uint64(len(e.data))
+	if offset > size {
+		return nil, fmt.Errorf("Offset %v is beyond the end of the %v bytes of capture data", offset, size)
+	}
+	end := offset + maxSize
+	if end >= size {
+		end = size
+		delete(t.exports, c.ID.ID())
+	}
+	return &service.CaptureChunk{
+		Data: e.data[offset:end],
+		Size: size,
+		Hash: e.hash,
+		Name: e.name,
+	}, nil
+}
+
+// push uploads the capture c to the server listening at remote, resuming the
+// upload after failed calls.
+func (t *transfers) push(ctx context.Context, c *path.Capture, remote string, token auth.Token) (*path.Capture, error) {
+	gc, err := capture.ResolveFromPath(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.Buffer{}
+	if err := capture.Export(ctx, c, &buf); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	hash := sha256.Sum256(data)
+
+	cl, err := connect(ctx, remote, token)
+	if err != nil {
+		return nil, err
+	}
+	defer cl.Close()
+
+	req := &service.UploadCaptureRequest{Name: gc.Name(), Size: uint64(len(data)), Hash: hash[:]}
+	for failures := 0; ; {
+		res, err := cl.UploadCapture(ctx, req)
+		if err != nil {
+			if failures++; failures > maxTransferRetries || isRemoteError(err) {
+				return nil, err
+			}
+			log.W(ctx, "Upload to %v failed, resuming: %v", remote, err)
+			req.Offset, req.Data = 0, nil
+			continue
+		}
+		failures = 0
+		if res.Capture != nil {
+			return res.Capture, nil
+		}
+		status.UpdateProgress(ctx, res.Received, req.Size)
+		end := res.Received + transferChunkSize
+		if end > req.Size {
+			end = req.Size
+		}
+		req.Offset, req.Data = res.Received, data[res.Received:end]
+	}
+}
+
+// pull downloads and loads the capture c from the server listening at remote,
+// resuming the download after failed calls.
+func (t *transfers) pull(ctx context.Context, remote string, token auth.Token, c *path.Capture) (*path.Capture, error) {
+	cl, err := connect(ctx, remote, token)
+	if err != nil {
+		return nil, err
+	}
+	defer cl.Close()
+
+	data := []byte{}
+	for failures := 0; ; {
+		chunk, err := cl.DownloadCapture(ctx, c, uint64(len(data)), transferChunkSize)
+		if err != nil {
+			if failures++; failures > maxTransferRetries || isRemoteError(err) {
+				return nil, err
+			}
+			log.W(ctx, "Download from %v failed, resuming: %v", remote, err)
+			continue
+		}
+		failures = 0
+		data = append(data, chunk.Data...)
+		status.UpdateProgress(ctx, uint64(len(data)), chunk.Size)
+		if uint64(len(data)) >= chunk.Size {
+			if sum := sha256.Sum256(data); !bytes.Equal(sum[:], chunk.Hash) {
+				return nil, &service.ErrDataUnavailable{Reason: messages.ErrTransferHashMismatch()}
+			}
+			return importCapture(ctx, chunk.Name, data)
+		}
+		if len(chunk.Data) == 0 {
+			return nil, fmt.Errorf("Download from %v stopped at %v of %v bytes", remote, len(data), chunk.Size)
+		}
+	}
+}
+
+// isRemoteError returns true if err was returned by the remote server, rather
+// than raised by the connection to it. Such errors are not retried.
+func isRemoteError(err error) bool {
+	switch err.(type) {
+	case *service.ErrDataUnavailable,
+		*service.ErrInvalidPath,
+		*service.ErrInvalidArgument,
+		*service.ErrPathNotFollowable,
+		*service.ErrInternal,
+		*service.ErrUnsupportedVersion:
+		return true
+	}
+	return false
+}
+
+// importCapture imports the capture data, and resolves the capture to ensure
+// that it can be read.
+func importCapture(ctx context.Context, name string, data []byte) (*path.Capture, error) {
+	p, err := capture.Import(ctx, name, name, &capture.Blob{Data: data})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = capture.ResolveFromPath(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,223 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// newTransferCapture returns the path to a new capture, and its exported data.
+func newTransferCapture(ctx context.Context, t *testing.T) (*path.Capture, []byte) {
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "transfer", header, nil, []api.Cmd{test.Cmds.A, test.Cmds.B})
+	if err != nil {
+		t.Fatalf("capture.New failed: %v", err)
+	}
+	p, err := c.Path(ctx)
+	if err != nil {
+		t.Fatalf("capture.Path failed: %v", err)
+	}
+	buf := bytes.Buffer{}
+	if err := capture.Export(ctx, p, &buf); err != nil {
+		t.Fatalf("capture.Export failed: %v", err)
+	}
+	return p, buf.Bytes()
+}
+
+func TestTransferUpload(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	_, data := newTransferCapture(ctx, t)
+	hash := sha256.Sum256(data)
+	size := uint64(len(data))
+	half := size / 2
+
+	tr := newTransfers()
+	chunk := func(offset, end uint64) *service.UploadCaptureRequest {
+		return &service.UploadCaptureRequest{
+			Name:   "uploaded",
+			Size:   size,
+			Hash:   hash[:],
+			Offset: offset,
+			Data:   data[offset:end],
+		}
+	}
+
+	// The first call only asks for the progress of the upload.
+	res, err := tr.upload(ctx, chunk(0, 0))
+	if assert.For(ctx, "start").ThatError(err).Succeeded() {
+		assert.For(ctx, "start received").That(res.Received).Equals(uint64(0))
+	}
+
+	// Chunks that do not start where the data received so far ends are
+	// ignored, and the progress tells the sender where to resume.
+	res, err = tr.upload(ctx, chunk(half, size))
+	if assert.For(ctx, "out of order").ThatError(err).Succeeded() {
+		assert.For(ctx, "out of order received").That(res.Received).Equals(uint64(0))
+		assert.For(ctx, "out of order capture").That(res.Capture == nil).Equals(true)
+	}
+	res, err = tr.upload(ctx, chunk(0, half))
+	if assert.For(ctx, "first half").ThatError(err).Succeeded() {
+		assert.For(ctx, "first half received").That(res.Received).Equals(half)
+	}
+
+	// A chunk sent again after a failed call is ignored too.
+	res, err = tr.upload(ctx, chunk(0, half))
+	if assert.For(ctx, "resent").ThatError(err).Succeeded() {
+		assert.For(ctx, "resent received").That(res.Received).Equals(half)
+	}
+
+	// The size of an upload cannot change.
+	req := chunk(half, size)
+	req.Size++
+	_, err = tr.upload(ctx, req)
+	_, invalid := err.(*service.ErrInvalidArgument)
+	assert.For(ctx, "size changed").That(invalid).Equals(true)
+
+	res, err = tr.upload(ctx, chunk(half, size))
+	if !assert.For(ctx, "second half").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "second half received").That(res.Received).Equals(size)
+	if !assert.For(ctx, "capture").That(res.Capture != nil).Equals(true) {
+		return
+	}
+	c, err := capture.ResolveGraphicsFromPath(ctx, res.Capture)
+	if assert.For(ctx, "resolve").ThatError(err).Succeeded() {
+		assert.For(ctx, "commands").That(len(c.Commands)).Equals(2)
+	}
+
+	// Calls made after the upload completed, such as the retry of a call
+	// whose response was lost, report the capture.
+	again, err := tr.upload(ctx, chunk(half, size))
+	if assert.For(ctx, "completed").ThatError(err).Succeeded() {
+		assert.For(ctx, "completed capture").That(again.Capture).DeepEquals(res.Capture)
+	}
+}
+
+func TestTransferUploadInvalid(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	_, data := newTransferCapture(ctx, t)
+	hash := sha256.Sum256(data)
+	size := uint64(len(data))
+	other := sha256.Sum256([]byte("other"))
+	tr := newTransfers()
+
+	// The data received is checked against the hash before being loaded.
+	_, err := tr.upload(ctx, &service.UploadCaptureRequest{Size: size, Hash: other[:], Data: data})
+	_, unavailable := err.(*service.ErrDataUnavailable)
+	assert.For(ctx, "hash mismatch").That(unavailable).Equals(true)
+	assert.For(ctx, "hash mismatch discarded").That(len(tr.uploads)).Equals(0)
+
+	for _, test := range []struct {
+		name string
+		req  *service.UploadCaptureRequest
+	}{
+		{"short hash", &service.UploadCaptureRequest{Size: size, Hash: hash[:4], Data: data}},
+		{"empty", &service.UploadCaptureRequest{Size: 0, Hash: hash[:]}},
+		{"too large", &service.UploadCaptureRequest{Size: maxTransferSize + 1, Hash: hash[:]}},
+		{"past the end", &service.UploadCaptureRequest{Size: 4, Hash: other[:], Data: data[:8]}},
+	} {
+		_, err := tr.upload(ctx, test.req)
+		_, invalid := err.(*service.ErrInvalidArgument)
+		assert.For(ctx, "%v rejected", test.name).That(invalid).Equals(true)
+	}
+}
+
+func TestTransferExpire(t *testing.T) {
+	ctx := log.Testing(t)
+	now := time.Now()
+	old, recent := now.Add(-transferTTL-time.Second), now.Add(-time.Second)
+	tr := newTransfers()
+	tr.uploads["old"] = &upload{last: old}
+	tr.uploads["recent"] = &upload{last: recent}
+	tr.completed["old"] = &completed{last: old}
+	tr.exports[id.ID{1}] = &exportedCapture{last: old}
+	tr.exports[id.ID{2}] = &exportedCapture{last: recent}
+
+	tr.expire(now)
+	assert.For(ctx, "uploads").That(len(tr.uploads)).Equals(1)
+	assert.For(ctx, "recent upload").That(tr.uploads["recent"] != nil).Equals(true)
+	assert.For(ctx, "completed").That(len(tr.completed)).Equals(0)
+	assert.For(ctx, "exports").That(len(tr.exports)).Equals(1)
+}
+
+func TestTransferDownload(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	p, _ := newTransferCapture(ctx, t)
+	tr := newTransfers()
+
+	// Concurrent downloads of the same capture share a single export.
+	const clients = 4
+	first := make([]*service.CaptureChunk, clients)
+	wg := sync.WaitGroup{}
+	for i := range first {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first[i], _ = tr.download(ctx, p, 0, 16)
+		}()
+	}
+	wg.Wait()
+	for i, chunk := range first {
+		if !assert.For(ctx, "first chunk %d", i).That(chunk != nil).Equals(true) {
+			return
+		}
+		assert.For(ctx, "first chunk %d", i).ThatSlice(chunk.Data).Equals(first[0].Data)
+	}
+	assert.For(ctx, "exports").That(len(tr.exports)).Equals(1)
+
+	// Downloads resume from any offset, such as after a failed call.
+	data := []byte{}
+	var chunk *service.CaptureChunk
+	for {
+		var err error
+		chunk, err = tr.download(ctx, p, uint64(len(data)), 16)
+		if !assert.For(ctx, "download").ThatError(err).Succeeded() {
+			return
+		}
+		data = append(data, chunk.Data...)
+		if uint64(len(data)) >= chunk.Size {
+			break
+		}
+	}
+	sum := sha256.Sum256(data)
+	assert.For(ctx, "hash").ThatSlice(chunk.Hash).Equals(sum[:])
+	assert.For(ctx, "name").That(chunk.Name).Equals("transfer")
+	assert.For(ctx, "released").That(len(tr.exports)).Equals(0)
+
+	_, err := tr.download(ctx, p, chunk.Size+1, 16)
+	assert.For(ctx, "past the end").ThatError(err).Failed()
+}
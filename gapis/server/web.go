@@ -0,0 +1,195 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// webAuthHeader is the HTTP header holding the connection authorization token
+// of requests made to the web bridge.
+const webAuthHeader = "Gapis-Auth-Token"
+
+// webMethod is an RPC served by the web bridge.
+type webMethod struct {
+	newRequest func() proto.Message
+	call       func(s *grpcServer, ctx context.Context, req proto.Message) (proto.Message, error)
+}
+
+// webMethods are the RPCs served by the web bridge, by name. They are the
+// read-only RPCs needed to view the command tree, state tree, thumbnails and
+// report of a loaded capture.
+var webMethods = map[string]webMethod{
+	"Ping": {
+		func() proto.Message { return &service.PingRequest{} },
+		func(s *grpcServer, ctx context.Context, req proto.Message) (proto.Message, error) {
+			return s.Ping(ctx, req.(*service.PingRequest))
+		},
+	},
+	"GetServerInfo": {
+		func() proto.Message { return &service.GetServerInfoRequest{} },
+		func(s *grpcServer, ctx context.Context, req proto.Message) (proto.Message, error) {
+			return s.GetServerInfo(ctx, req.(*service.GetServerInfoRequest))
+		},
+	},
+	"GetLoadedCaptures": {
+		func() proto.Message { return &service.GetLoadedCapturesRequest{} },
+		func(s *grpcServer, ctx context.Context, req proto.Message) (proto.Message, error) {
+			return s.GetLoadedCaptures(ctx, req.(*service.GetLoadedCapturesRequest))
+		},
+	},
+	"GetCommandListWindow": {
+		func() proto.Message { return &service.GetCommandListWindowRequest{} },
+		func(s *grpcServer, ctx context.Context, req proto.Message) (proto.Message, error) {
+			return s.GetCommandListWindow(ctx, req.(*service.GetCommandListWindowRequest))
+		},
+	},
+	"GetStateTreeChildren": {
+		func() proto.Message { return &service.GetStateTreeChildrenRequest{} },
+		func(s *grpcServer, ctx context.Context, req proto.Message) (proto.Message, error) {
+			return s.GetStateTreeChildren(ctx, req.(*service.GetStateTreeChildrenRequest))
+		},
+	},
+	"FindStateTreeNodes": {
+		func() proto.Message { return &service.FindStateTreeNodesRequest{} },
+		func(s *grpcServer, ctx context.Context, req proto.Message) (proto.Message, error) {
+			return s.FindStateTreeNodes(ctx, req.(*service.FindStateTreeNodesRequest))
+		},
+	},
+	"GetProfileTimeline": {
+		func() proto.Message { return &service.GetProfileTimelineRequest{} },
+		func(s *grpcServer, ctx context.Context, req proto.Message) (proto.Message, error) {
+			return s.GetProfileTimeline(ctx, req.(*service.GetProfileTimelineRequest))
+		},
+	},
+	"Get": {
+		func() proto.Message { return &service.GetRequest{} },
+		func(s *grpcServer, ctx context.Context, req proto.Message) (proto.Message, error) {
+			get := req.(*service.GetRequest)
+			if !isWebPath(get.Path) {
+				err := &service.ErrInvalidPath{Reason: messages.ErrPathNotServed(), Path: get.Path}
+				return &service.GetResponse{Res: &service.GetResponse_Error{Error: service.NewError(err)}}, nil
+			}
+			return s.Get(ctx, get)
+		},
+	},
+}
+
+// isWebPath returns true if the web bridge serves the resolution of p.
+func isWebPath(p *path.Any) bool {
+	switch p.Node().(type) {
+	case *path.Blob,
+		*path.Capture,
+		*path.Command,
+		*path.CommandTree,
+		*path.CommandTreeNode,
+		*path.CommandTreeNodeForCommand,
+		*path.Report,
+		*path.StateTree,
+		*path.StateTreeNode,
+		*path.StateTreeNodeForPath,
+		*path.Thumbnail:
+		return true
+	}
+	return false
+}
+
+// serveWeb serves the webMethods over HTTP on addr until ctx is stopped, so
+// that clients running in a browser can use them. See webHandler for the
+// protocol.
+func (s *grpcServer) serveWeb(ctx context.Context, addr string, token auth.Token, origin string) {
+	server := &http.Server{Addr: addr, Handler: s.webHandler(ctx, token, origin)}
+	crash.Go(func() {
+		<-task.ShouldStop(ctx)
+		server.Close()
+	})
+
+	log.I(ctx, "Serving the web bridge on %v", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.E(ctx, "Web bridge stopped: %v", err)
+	}
+}
+
+// webHandler returns the handler of the webMethods. Each RPC is called with a
+// POST to /gapis/<RPC name>, with the request as the JSON body. The response
+// is returned as JSON. Both use the protobuf JSON mapping. Requests must hold
+// the server's auth token in the Gapis-Auth-Token header. origin is the
+// origin of the pages allowed to make cross-origin requests, or empty to
+// disallow them.
+func (s *grpcServer) webHandler(ctx context.Context, token auth.Token, origin string) http.Handler {
+	marshaler := jsonpb.Marshaler{}
+	unmarshaler := jsonpb.Unmarshaler{AllowUnknownFields: true}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+webAuthHeader)
+			w.Header().Set("Access-Control-Allow-Methods", "POST")
+		}
+		switch {
+		case r.Method == http.MethodOptions:
+			return
+		case r.Method != http.MethodPost:
+			http.Error(w, "Only POST is supported", http.StatusMethodNotAllowed)
+			return
+		case token != auth.NoAuth && !validWebToken(r.Header.Get(webAuthHeader), token):
+			http.Error(w, auth.ErrInvalidToken.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		m, ok := webMethods[strings.TrimPrefix(r.URL.Path, "/gapis/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		req := m.newRequest()
+		if err := unmarshaler.Unmarshal(r.Body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res, err := m.call(s, r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := marshaler.Marshal(w, res); err != nil {
+			log.W(ctx, "Couldn't write web bridge response: %v", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gapis/", handler)
+	return mux
+}
+
+// validWebToken returns true if got is the token, comparing them in constant
+// time.
+func validWebToken(got string, token auth.Token) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
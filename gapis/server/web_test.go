@@ -0,0 +1,123 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestWebHandler(t *testing.T) {
+	ctx := log.Testing(t)
+	s := &grpcServer{
+		handler: &server{info: &service.ServerInfo{Name: "test"}},
+		bindCtx: func(ctx context.Context) context.Context { return ctx },
+	}
+	const token = auth.Token("secret")
+	web := httptest.NewServer(s.webHandler(ctx, token, "http://localhost:8080"))
+	defer web.Close()
+
+	call := func(method, rpc, tok, body string) *http.Response {
+		req, err := http.NewRequest(method, web.URL+"/gapis/"+rpc, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok != "" {
+			req.Header.Set(webAuthHeader, tok)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	for _, test := range []struct {
+		name     string
+		method   string
+		rpc      string
+		token    string
+		expected int
+	}{
+		{"no token", http.MethodPost, "Ping", "", http.StatusUnauthorized},
+		{"wrong token", http.MethodPost, "Ping", "secreT", http.StatusUnauthorized},
+		{"token prefix", http.MethodPost, "Ping", "secre", http.StatusUnauthorized},
+		{"get", http.MethodGet, "Ping", string(token), http.StatusMethodNotAllowed},
+		{"unknown rpc", http.MethodPost, "ImportCapture", string(token), http.StatusNotFound},
+		{"ping", http.MethodPost, "Ping", string(token), http.StatusOK},
+	} {
+		res := call(test.method, test.rpc, test.token, "{}")
+		res.Body.Close()
+		assert.For(ctx, "%v status", test.name).That(res.StatusCode).Equals(test.expected)
+	}
+
+	// Preflight requests of browsers carry no token.
+	res := call(http.MethodOptions, "Ping", "", "")
+	res.Body.Close()
+	assert.For(ctx, "preflight status").That(res.StatusCode).Equals(http.StatusOK)
+	assert.For(ctx, "preflight origin").That(res.Header.Get("Access-Control-Allow-Origin")).Equals("http://localhost:8080")
+	assert.For(ctx, "preflight headers").That(res.Header.Get("Access-Control-Allow-Headers")).Equals("Content-Type, " + webAuthHeader)
+
+	res = call(http.MethodPost, "GetServerInfo", string(token), "{}")
+	defer res.Body.Close()
+	assert.For(ctx, "content type").That(res.Header.Get("Content-Type")).Equals("application/json")
+	info := &service.GetServerInfoResponse{}
+	err := jsonpb.Unmarshal(res.Body, info)
+	if assert.For(ctx, "unmarshal").ThatError(err).Succeeded() {
+		assert.For(ctx, "server name").That(info.GetInfo().GetName()).Equals("test")
+	}
+
+	res = call(http.MethodPost, "Get", string(token), `{"path": {"device": {"ID": {}}}}`)
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	get := &service.GetResponse{}
+	err = jsonpb.UnmarshalString(string(body), get)
+	if assert.For(ctx, "unmarshal get").ThatError(err).Succeeded() {
+		_, rejected := get.GetError().Get().(*service.ErrInvalidPath)
+		assert.For(ctx, "device path rejected").That(rejected).Equals(true)
+	}
+}
+
+func TestIsWebPath(t *testing.T) {
+	ctx := log.Testing(t)
+	c := &path.Capture{ID: path.NewID(id.ID{1})}
+	for _, test := range []struct {
+		name     string
+		path     path.Node
+		expected bool
+	}{
+		{"capture", c, true},
+		{"command", c.Command(1), true},
+		{"command tree", &path.CommandTree{Capture: c}, true},
+		{"report", c.Report(nil, nil, false), true},
+		{"state", c.Command(1).StateAfter(), false},
+		{"memory", c.Command(1).MemoryAfter(0, 0, 16), false},
+		{"device", &path.Device{ID: path.NewID(id.ID{2})}, false},
+	} {
+		assert.For(ctx, test.name).That(isWebPath(test.path.Path())).Equals(test.expected)
+	}
+}
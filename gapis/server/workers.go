@@ -0,0 +1,332 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/client"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// workerPool dispatches replay-backed resolutions to a pool of worker GAPIS
+// servers, each with its own replay devices. Each capture is assigned to the
+// worker with the fewest resolutions in flight when the capture is first
+// dispatched, and all later resolutions of the capture are dispatched to the
+// same worker, so that the worker's decoded capture and caches are reused.
+// Captures assigned to a worker that fails are reassigned to another worker.
+type workerPool struct {
+	workers  []*worker
+	mutex    sync.Mutex
+	assigned map[id.ID]*worker
+}
+
+// worker is a connection to a worker GAPIS server.
+type worker struct {
+	addr     string
+	token    auth.Token
+	active   int32 // The number of resolutions in flight.
+	mutex    sync.Mutex
+	client   client.Client
+	captures map[id.ID]*path.Capture // Worker capture by coordinator capture.
+	devices  map[id.ID]*path.Device  // Replay device by coordinator capture.
+}
+
+// newWorkerPool returns a pool of the workers listening at the given
+// host:port addresses, or nil if there are none. The workers are connected to
+// when first used.
+func newWorkerPool(addrs []string, token auth.Token) *workerPool {
+	if len(addrs) == 0 {
+		return nil
+	}
+	p := &workerPool{assigned: map[id.ID]*worker{}}
+	for _, addr := range addrs {
+		p.workers = append(p.workers, &worker{
+			addr:     addr,
+			token:    token,
+			captures: map[id.ID]*path.Capture{},
+			devices:  map[id.ID]*path.Device{},
+		})
+	}
+	return p
+}
+
+// connectWorker returns a client connected to the worker GAPIS server
+// listening at the host:port address addr. It is replaced by tests.
+var connectWorker = connect
+
+// assign returns the worker assigned to the capture c. If the capture is not
+// assigned to a worker, or is assigned to one of the failed workers, it is
+// assigned to the worker with the fewest resolutions in flight that has not
+// failed. assign returns nil if all the workers have failed.
+func (p *workerPool) assign(c *path.Capture, failed map[*worker]bool) *worker {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if w, ok := p.assigned[c.ID.ID()]; ok && !failed[w] {
+		return w
+	}
+	var w *worker
+	for _, candidate := range p.workers {
+		if failed[candidate] {
+			continue
+		}
+		if w == nil || atomic.LoadInt32(&candidate.active) < atomic.LoadInt32(&w.active) {
+			w = candidate
+		}
+	}
+	if w != nil {
+		p.assigned[c.ID.ID()] = w
+	}
+	return w
+}
+
+// dispatch calls f with the client of the worker assigned to the capture c,
+// and the capture and replay device on the worker. If the worker cannot be
+// reached, or fails with an error that was not returned by the worker's
+// server, the capture is reassigned and f is retried on another worker, until
+// all the workers have failed.
+func (p *workerPool) dispatch(ctx context.Context, c *path.Capture, f func(cl client.Client, wc *path.Capture, d *path.Device) error) error {
+	failed := map[*worker]bool{}
+	for {
+		w := p.assign(c, failed)
+		if w == nil {
+			return fmt.Errorf("All %d workers failed", len(p.workers))
+		}
+
+		atomic.AddInt32(&w.active, 1)
+		cl, wc, d, err := w.prepare(ctx, c)
+		if err == nil {
+			err = f(cl, wc, d)
+		}
+		atomic.AddInt32(&w.active, -1)
+
+		if err == nil || isRemoteError(err) || ctx.Err() != nil {
+			return err
+		}
+		log.W(ctx, "Worker %v failed, retrying on another worker: %v", w.addr, err)
+		w.reset(cl)
+		failed[w] = true
+	}
+}
+
+// prepare connects to the worker, if not already connected, and imports the
+// capture c into the worker, if not already imported. prepare returns the
+// client of the worker, and the capture on the worker and the worker's replay
+// device for the capture. The worker's mutex is not held while connecting to
+// the worker or importing the capture, so that resolutions of other captures
+// are not blocked.
+func (w *worker) prepare(ctx context.Context, c *path.Capture) (client.Client, *path.Capture, *path.Device, error) {
+	key := c.ID.ID()
+	w.mutex.Lock()
+	cl, wc, d := w.client, w.captures[key], w.devices[key]
+	w.mutex.Unlock()
+	if wc != nil {
+		return cl, wc, d, nil
+	}
+
+	if cl == nil {
+		connected, err := connectWorker(ctx, w.addr, w.token)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		w.mutex.Lock()
+		if w.client == nil {
+			w.client = connected
+		} else {
+			connected.Close() // Connected concurrently.
+		}
+		cl = w.client
+		w.mutex.Unlock()
+	}
+
+	gc, err := capture.ResolveFromPath(ctx, c)
+	if err != nil {
+		return cl, nil, nil, err
+	}
+	data := bytes.Buffer{}
+	if err := capture.Export(ctx, c, &data); err != nil {
+		return cl, nil, nil, err
+	}
+	wc, err = cl.ImportCapture(ctx, gc.Name(), data.Bytes())
+	if err != nil {
+		return cl, nil, nil, err
+	}
+	devices, err := cl.GetDevicesForReplay(ctx, wc)
+	if err != nil {
+		return cl, nil, nil, err
+	}
+	if len(devices) == 0 {
+		return cl, nil, nil, fmt.Errorf("No replay device for capture %v", gc.Name())
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.client == cl {
+		w.captures[key], w.devices[key] = wc, devices[0]
+	}
+	return cl, wc, devices[0], nil
+}
+
+// reset closes the connection cl to the worker after the worker failed, and
+// forgets the captures imported into it. The next resolution dispatched to the
+// worker connects to it again.
+func (w *worker) reset(cl client.Client) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.client != cl {
+		return // Already reset.
+	}
+	if cl != nil {
+		cl.Close()
+	}
+	w.client = nil
+	w.captures = map[id.ID]*path.Capture{}
+	w.devices = map[id.ID]*path.Device{}
+}
+
+// connect returns a client connected to the GAPIS server listening at the
+// host:port address addr.
+func connect(ctx context.Context, addr string, token auth.Token) (client.Client, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, err
+	}
+	return client.Connect(ctx, client.Config{Host: host, Port: portNum, Token: token})
+}
+
+// rebase returns a copy of the path p, with its capture replaced by c.
+func rebase(p *path.Any, c *path.Capture) *path.Any {
+	out := proto.Clone(p).(*path.Any)
+	if oc := path.FindCapture(out.Node()); oc != nil {
+		oc.ID = c.ID
+	}
+	return out
+}
+
+// fetchImage returns a copy of the image.Info resolved by the worker of the
+// client cl at p, with its data copied into the coordinator's database.
+func fetchImage(ctx context.Context, cl client.Client, p *path.Any, r *path.ResolveConfig) (*image.Info, error) {
+	obj, err := cl.Get(ctx, p, r)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := obj.(*image.Info)
+	if !ok {
+		return nil, fmt.Errorf("Worker resolved %v to %T, expected image info", p, obj)
+	}
+	data, err := cl.Get(ctx, (&path.Blob{ID: path.NewID(info.Bytes.ID())}).Path(), nil)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := data.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("Worker resolved image data to %T, expected bytes", data)
+	}
+	dataID, err := database.Store(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	out := proto.Clone(info).(*image.Info)
+	out.Bytes = image.NewID(dataID)
+	return out, nil
+}
+
+// framebufferAttachment dispatches GetFramebufferAttachment to the worker
+// assigned to the capture of after.
+func (p *workerPool) framebufferAttachment(
+	ctx context.Context,
+	replaySettings *service.ReplaySettings,
+	after *path.Command,
+	attachment api.FramebufferAttachment,
+	settings *service.RenderSettings,
+	hints *service.UsageHints,
+) (*path.ImageInfo, error) {
+	var info *image.Info
+	err := p.dispatch(ctx, after.Capture, func(cl client.Client, wc *path.Capture, d *path.Device) error {
+		ws := proto.Clone(replaySettings).(*service.ReplaySettings)
+		ws.Device = d
+		wp, err := cl.GetFramebufferAttachment(ctx, ws, rebase(after.Path(), wc).GetCommand(), attachment, settings, hints)
+		if err != nil {
+			return err
+		}
+		info, err = fetchImage(ctx, cl, wp.Path(), nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	infoID, err := database.Store(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+	return path.NewImageInfo(infoID), nil
+}
+
+// thumbnail dispatches the resolution of the thumbnail t to the worker
+// assigned to its capture.
+func (p *workerPool) thumbnail(ctx context.Context, t *path.Thumbnail) (*image.Info, error) {
+	var info *image.Info
+	err := p.dispatch(ctx, path.FindCapture(t), func(cl client.Client, wc *path.Capture, d *path.Device) error {
+		var err error
+		info, err = fetchImage(ctx, cl, rebase(t.Path(), wc), &path.ResolveConfig{ReplayDevice: d})
+		return err
+	})
+	return info, err
+}
+
+// gpuProfile dispatches GpuProfile to the worker assigned to the capture of
+// req. The profile is recorded as the latest profile of the capture, as if it
+// had been profiled locally.
+func (p *workerPool) gpuProfile(ctx context.Context, req *service.GpuProfileRequest) (*service.ProfilingData, error) {
+	var res *service.ProfilingData
+	err := p.dispatch(ctx, req.Capture, func(cl client.Client, wc *path.Capture, d *path.Device) error {
+		wreq := proto.Clone(req).(*service.GpuProfileRequest)
+		wreq.Capture, wreq.Device = wc, d
+		var err error
+		res, err = cl.GpuProfile(ctx, wreq)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The command links of the profile refer to the worker's capture.
+	for _, g := range res.GetSlices().GetGroups() {
+		if g.Link != nil {
+			g.Link.Capture = req.Capture
+		}
+	}
+	replay.StoreProfile(req.Capture, res)
+	return res, nil
+}
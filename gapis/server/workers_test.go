@@ -0,0 +1,110 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/gapid/core/app/auth"
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/id"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/client"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+var errWorkerDied = fmt.Errorf("connection refused")
+
+// fakeWorker is the client of a worker that profiles captures, or that fails
+// every call as if it had died.
+type fakeWorker struct {
+	client.Client
+	dead     bool
+	imported int
+	closed   bool
+}
+
+func (f *fakeWorker) ImportCapture(ctx context.Context, name string, data []uint8) (*path.Capture, error) {
+	if f.dead {
+		return nil, errWorkerDied
+	}
+	f.imported++
+	return &path.Capture{ID: path.NewID(id.ID{byte(f.imported)})}, nil
+}
+
+func (f *fakeWorker) GetDevicesForReplay(ctx context.Context, p *path.Capture) ([]*path.Device, error) {
+	return []*path.Device{{ID: path.NewID(id.ID{1})}}, nil
+}
+
+func (f *fakeWorker) GpuProfile(ctx context.Context, req *service.GpuProfileRequest) (*service.ProfilingData, error) {
+	if f.dead {
+		return nil, errWorkerDied
+	}
+	return &service.ProfilingData{}, nil
+}
+
+func (f *fakeWorker) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestWorkerPoolRetry(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "test", header, nil, []api.Cmd{test.Cmds.A})
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+	p, err := c.Path(ctx)
+	if !assert.For(ctx, "capture.Path").ThatError(err).Succeeded() {
+		return
+	}
+
+	fakes := map[string]*fakeWorker{"a:1": {dead: true}, "b:2": {}}
+	defer func(old func(context.Context, string, auth.Token) (client.Client, error)) { connectWorker = old }(connectWorker)
+	connectWorker = func(ctx context.Context, addr string, token auth.Token) (client.Client, error) {
+		return fakes[addr], nil
+	}
+	pool := newWorkerPool([]string{"a:1", "b:2"}, "")
+	req := &service.GpuProfileRequest{Capture: p}
+
+	// The capture is first assigned to the dead worker, and then retried on
+	// the other worker.
+	_, err = pool.gpuProfile(ctx, req)
+	assert.For(ctx, "gpuProfile").ThatError(err).Succeeded()
+	assert.For(ctx, "dead worker closed").That(fakes["a:1"].closed).Equals(true)
+	assert.For(ctx, "imported").That(fakes["b:2"].imported).Equals(1)
+	assert.For(ctx, "profile stored").That(replay.LatestProfile(p) != nil).Equals(true)
+
+	// Later resolutions stay on the worker the capture was reassigned to.
+	_, err = pool.gpuProfile(ctx, req)
+	assert.For(ctx, "gpuProfile again").ThatError(err).Succeeded()
+	assert.For(ctx, "imported again").That(fakes["b:2"].imported).Equals(1)
+
+	fakes["b:2"].dead = true
+	_, err = pool.gpuProfile(ctx, req)
+	assert.For(ctx, "all workers dead").ThatError(err).Failed()
+}
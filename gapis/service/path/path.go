@@ -62,6 +62,8 @@ func (n *ArrayIndex) Path() *Any                { return &Any{Path: &Any_ArrayIn
 func (n *As) Path() *Any                        { return &Any{Path: &Any_As{n}} }
 func (n *Blob) Path() *Any                      { return &Any{Path: &Any_Blob{n}} }
 func (n *Capture) Path() *Any                   { return &Any{Path: &Any_Capture{n}} }
+func (n *CaptureDevice) Path() *Any             { return &Any{Path: &Any_CaptureDevice{n}} }
+func (n *CaptureView) Path() *Any               { return &Any{Path: &Any_CaptureView{n}} }
 func (n *ConstantSet) Path() *Any               { return &Any{Path: &Any_ConstantSet{n}} }
 func (n *Command) Path() *Any                   { return &Any{Path: &Any_Command{n}} }
 func (n *Commands) Path() *Any                  { return &Any{Path: &Any_Commands{n}} }
@@ -77,6 +79,7 @@ func (n *FramebufferObservation) Path() *Any    { return &Any{Path: &Any_FBO{n}}
 func (n *Field) Path() *Any                     { return &Any{Path: &Any_Field{n}} }
 func (n *GlobalState) Path() *Any               { return &Any{Path: &Any_GlobalState{n}} }
 func (n *ImageInfo) Path() *Any                 { return &Any{Path: &Any_ImageInfo{n}} }
+func (n *LastModifiedBy) Path() *Any            { return &Any{Path: &Any_LastModifiedBy{n}} }
 func (n *MapIndex) Path() *Any                  { return &Any{Path: &Any_MapIndex{n}} }
 func (n *Memory) Path() *Any                    { return &Any{Path: &Any_Memory{n}} }
 func (n *MemoryAsType) Path() *Any              { return &Any{Path: &Any_MemoryAsType{n}} }
@@ -98,12 +101,35 @@ func (n *StateTreeNodeForPath) Path() *Any      { return &Any{Path: &Any_StateTr
 func (n *Stats) Path() *Any                     { return &Any{Path: &Any_Stats{n}} }
 func (n *Thumbnail) Path() *Any                 { return &Any{Path: &Any_Thumbnail{n}} }
 func (n *Type) Path() *Any                      { return &Any{Path: &Any_Type{n}} }
+func (n *Uploads) Path() *Any                   { return &Any{Path: &Any_Uploads{n}} }
+func (n *Barriers) Path() *Any                  { return &Any{Path: &Any_Barriers{n}} }
+func (n *BindChurn) Path() *Any                 { return &Any{Path: &Any_BindChurn{n}} }
+func (n *BlendCost) Path() *Any                 { return &Any{Path: &Any_BlendCost{n}} }
+func (n *DepthTestCost) Path() *Any             { return &Any{Path: &Any_DepthTestCost{n}} }
+func (n *DeviceMetrics) Path() *Any             { return &Any{Path: &Any_DeviceMetrics{n}} }
+func (n *PipelineStatistics) Path() *Any        { return &Any{Path: &Any_PipelineStatistics{n}} }
+func (n *ReplayFidelity) Path() *Any            { return &Any{Path: &Any_ReplayFidelity{n}} }
+func (n *StateSearch) Path() *Any               { return &Any{Path: &Any_StateSearch{n}} }
+func (n *SyncTimeline) Path() *Any              { return &Any{Path: &Any_SyncTimeline{n}} }
+func (n *FramePacing) Path() *Any               { return &Any{Path: &Any_FramePacing{n}} }
+func (n *DrawBundle) Path() *Any                { return &Any{Path: &Any_DrawBundle{n}} }
+func (n *ShaderInputs) Path() *Any              { return &Any{Path: &Any_ShaderInputs{n}} }
+func (n *ShaderDebugTrace) Path() *Any          { return &Any{Path: &Any_ShaderDebugTrace{n}} }
+func (n *FrameGraph) Path() *Any                { return &Any{Path: &Any_FrameGraph{n}} }
+func (n *FrameRedundancy) Path() *Any           { return &Any{Path: &Any_FrameRedundancy{n}} }
+func (n *Scene) Path() *Any                     { return &Any{Path: &Any_Scene{n}} }
+func (n *ShaderClusters) Path() *Any            { return &Any{Path: &Any_ShaderClusters{n}} }
+func (n *TextureUsage) Path() *Any              { return &Any{Path: &Any_TextureUsage{n}} }
+func (n *UniformUsage) Path() *Any              { return &Any{Path: &Any_UniformUsage{n}} }
+func (n *ValueSeries) Path() *Any               { return &Any{Path: &Any_ValueSeries{n}} }
 
 func (n API) Parent() Node                       { return nil }
 func (n ArrayIndex) Parent() Node                { return oneOfNode(n.Array) }
 func (n As) Parent() Node                        { return oneOfNode(n.From) }
 func (n Blob) Parent() Node                      { return nil }
 func (n Capture) Parent() Node                   { return nil }
+func (n CaptureDevice) Parent() Node             { return n.Capture }
+func (n CaptureView) Parent() Node               { return n.Capture }
 func (n ConstantSet) Parent() Node               { return n.API }
 func (n Command) Parent() Node                   { return n.Capture }
 func (n Commands) Parent() Node                  { return n.Capture }
@@ -119,6 +145,7 @@ func (n FramebufferObservation) Parent() Node    { return n.Command }
 func (n Field) Parent() Node                     { return oneOfNode(n.Struct) }
 func (n GlobalState) Parent() Node               { return n.After }
 func (n ImageInfo) Parent() Node                 { return nil }
+func (n LastModifiedBy) Parent() Node            { return nil }
 func (n MapIndex) Parent() Node                  { return oneOfNode(n.Map) }
 func (n Memory) Parent() Node                    { return n.After }
 func (n MemoryAsType) Parent() Node              { return n.After }
@@ -140,10 +167,33 @@ func (n StateTreeNodeForPath) Parent() Node      { return nil }
 func (n Stats) Parent() Node                     { return n.Capture }
 func (n Thumbnail) Parent() Node                 { return oneOfNode(n.Object) }
 func (n Type) Parent() Node                      { return nil }
+func (n Uploads) Parent() Node                   { return n.Capture }
+func (n Barriers) Parent() Node                  { return n.Capture }
+func (n BindChurn) Parent() Node                 { return n.Capture }
+func (n BlendCost) Parent() Node                 { return n.Capture }
+func (n DepthTestCost) Parent() Node             { return n.Capture }
+func (n DeviceMetrics) Parent() Node             { return n.Capture }
+func (n PipelineStatistics) Parent() Node        { return n.Capture }
+func (n ReplayFidelity) Parent() Node            { return n.Capture }
+func (n StateSearch) Parent() Node               { return n.State }
+func (n SyncTimeline) Parent() Node              { return n.Capture }
+func (n FramePacing) Parent() Node               { return n.Capture }
+func (n DrawBundle) Parent() Node                { return n.Command }
+func (n ShaderInputs) Parent() Node              { return n.Command }
+func (n ShaderDebugTrace) Parent() Node          { return n.Inputs }
+func (n FrameGraph) Parent() Node                { return n.Capture }
+func (n FrameRedundancy) Parent() Node           { return n.Capture }
+func (n Scene) Parent() Node                     { return n.Capture }
+func (n ShaderClusters) Parent() Node            { return n.Capture }
+func (n TextureUsage) Parent() Node              { return n.Capture }
+func (n UniformUsage) Parent() Node              { return n.Capture }
+func (n ValueSeries) Parent() Node               { return n.Commands }
 
 func (n *API) SetParent(p Node)                       {}
 func (n *Blob) SetParent(p Node)                      {}
 func (n *Capture) SetParent(p Node)                   {}
+func (n *CaptureDevice) SetParent(p Node)             { n.Capture, _ = p.(*Capture) }
+func (n *CaptureView) SetParent(p Node)               { n.Capture, _ = p.(*Capture) }
 func (n *ConstantSet) SetParent(p Node)               { n.API, _ = p.(*API) }
 func (n *Command) SetParent(p Node)                   { n.Capture, _ = p.(*Capture) }
 func (n *Commands) SetParent(p Node)                  { n.Capture, _ = p.(*Capture) }
@@ -158,6 +208,7 @@ func (n *Events) SetParent(p Node)                    { n.Capture, _ = p.(*Captu
 func (n *FramebufferObservation) SetParent(p Node)    { n.Command, _ = p.(*Command) }
 func (n *GlobalState) SetParent(p Node)               { n.After, _ = p.(*Command) }
 func (n *ImageInfo) SetParent(p Node)                 {}
+func (n *LastModifiedBy) SetParent(p Node)            {}
 func (n *Memory) SetParent(p Node)                    { n.After, _ = p.(*Command) }
 func (n *MemoryAsType) SetParent(p Node)              { n.After, _ = p.(*Command) }
 func (n *Metrics) SetParent(p Node)                   { n.Command, _ = p.(*Command) }
@@ -175,6 +226,27 @@ func (n *StateTreeNode) SetParent(p Node)             {}
 func (n *StateTreeNodeForPath) SetParent(p Node)      {}
 func (n *Stats) SetParent(p Node)                     { n.Capture, _ = p.(*Capture) }
 func (n *Type) SetParent(p Node)                      {}
+func (n *Uploads) SetParent(p Node)                   { n.Capture, _ = p.(*Capture) }
+func (n *Barriers) SetParent(p Node)                  { n.Capture, _ = p.(*Capture) }
+func (n *BindChurn) SetParent(p Node)                 { n.Capture, _ = p.(*Capture) }
+func (n *BlendCost) SetParent(p Node)                 { n.Capture, _ = p.(*Capture) }
+func (n *DepthTestCost) SetParent(p Node)             { n.Capture, _ = p.(*Capture) }
+func (n *DeviceMetrics) SetParent(p Node)             { n.Capture, _ = p.(*Capture) }
+func (n *PipelineStatistics) SetParent(p Node)        { n.Capture, _ = p.(*Capture) }
+func (n *ReplayFidelity) SetParent(p Node)            { n.Capture, _ = p.(*Capture) }
+func (n *StateSearch) SetParent(p Node)               { n.State, _ = p.(*State) }
+func (n *SyncTimeline) SetParent(p Node)              { n.Capture, _ = p.(*Capture) }
+func (n *FramePacing) SetParent(p Node)               { n.Capture, _ = p.(*Capture) }
+func (n *DrawBundle) SetParent(p Node)                { n.Command, _ = p.(*Command) }
+func (n *ShaderInputs) SetParent(p Node)              { n.Command, _ = p.(*Command) }
+func (n *ShaderDebugTrace) SetParent(p Node)          { n.Inputs, _ = p.(*ShaderInputs) }
+func (n *FrameGraph) SetParent(p Node)                { n.Capture, _ = p.(*Capture) }
+func (n *FrameRedundancy) SetParent(p Node)           { n.Capture, _ = p.(*Capture) }
+func (n *Scene) SetParent(p Node)                     { n.Capture, _ = p.(*Capture) }
+func (n *ShaderClusters) SetParent(p Node)            { n.Capture, _ = p.(*Capture) }
+func (n *TextureUsage) SetParent(p Node)              { n.Capture, _ = p.(*Capture) }
+func (n *UniformUsage) SetParent(p Node)              { n.Capture, _ = p.(*Capture) }
+func (n *ValueSeries) SetParent(p Node)               { n.Commands, _ = p.(*Commands) }
 
 // Format implements fmt.Formatter to print the path.
 func (n ArrayIndex) Format(f fmt.State, c rune) {
@@ -195,6 +267,14 @@ func (n Blob) Format(f fmt.State, c rune) { fmt.Fprintf(f, "blob<%x>", n.ID) }
 // Format implements fmt.Formatter to print the path.
 func (n Capture) Format(f fmt.State, c rune) { fmt.Fprintf(f, "capture<%x>", n.ID) }
 
+// Format implements fmt.Formatter to print the path.
+func (n CaptureDevice) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.device", n.Parent()) }
+
+// Format implements fmt.Formatter to print the path.
+func (n CaptureView) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.view<%v+%v>", n.Parent(), n.Frames.GetFirst(), n.Frames.GetCount())
+}
+
 // Format implements fmt.Formatter to print the path.
 func (n ConstantSet) Format(f fmt.State, c rune) {
 	fmt.Fprintf(f, "%v.constant-set<%v>", n.Parent(), n.Index)
@@ -202,6 +282,10 @@ func (n ConstantSet) Format(f fmt.State, c rune) {
 
 // Format implements fmt.Formatter to print the path.
 func (n Command) Format(f fmt.State, c rune) {
+	if n.StableId != 0 {
+		fmt.Fprintf(f, "%v.commands<%v>", n.Parent(), n.StableId)
+		return
+	}
 	fmt.Fprintf(f, "%v.commands[%v]", n.Parent(), printIndices(n.Indices))
 }
 
@@ -244,6 +328,11 @@ func (n GlobalState) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.global-sta
 // Format implements fmt.Formatter to print the path.
 func (n ImageInfo) Format(f fmt.State, c rune) { fmt.Fprintf(f, "image-info<%x>", n.ID) }
 
+// Format implements fmt.Formatter to print the path.
+func (n LastModifiedBy) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "last-modified-by<%v>", n.Value)
+}
+
 // Format implements fmt.Formatter to print the path.
 func (n MapIndex) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v[%x]", n.Parent(), n.Key) }
 
@@ -299,6 +388,11 @@ func (n State) Format(f fmt.State, c rune) {
 // Format implements fmt.Formatter to print the path.
 func (n StateTree) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.tree", n.State) }
 
+// Format implements fmt.Formatter to print the path.
+func (n StateSearch) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.search<%q>", n.Parent(), n.Pattern)
+}
+
 // Format implements fmt.Formatter to print the path.
 func (n StateTreeNode) Format(f fmt.State, c rune) {
 	fmt.Fprintf(f, "state-tree<%v>[%v]", n.Tree, printIndices(n.Indices))
@@ -317,6 +411,99 @@ func (n Thumbnail) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.thumbnail",
 
 func (n Type) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.type", n.TypeIndex) }
 
+// Format implements fmt.Formatter to print the path.
+func (n Uploads) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.uploads", n.Parent()) }
+
+// Format implements fmt.Formatter to print the path.
+func (n Barriers) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.barriers<%v>", n.Parent(), n.Frame) }
+
+// Format implements fmt.Formatter to print the path.
+func (n BindChurn) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.bind-churn", n.Parent()) }
+
+// Format implements fmt.Formatter to print the path.
+func (n DrawBundle) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.draw-bundle", n.Parent()) }
+
+// Format implements fmt.Formatter to print the path.
+func (n ShaderInputs) Format(f fmt.State, c rune) {
+	if p := n.GetFragment(); p != nil {
+		fmt.Fprintf(f, "%v.shader-inputs<fragment %v,%v>", n.Parent(), p.X, p.Y)
+	} else {
+		fmt.Fprintf(f, "%v.shader-inputs<vertex %v>", n.Parent(), n.GetVertex())
+	}
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n ShaderDebugTrace) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.debug-trace", n.Parent())
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n FrameGraph) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.frame-graph<%v>", n.Parent(), n.Frame)
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n BlendCost) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.blend-cost<%v>", n.Parent(), n.Frame)
+}
+
+func (n DepthTestCost) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.depth-test-cost<%v>", n.Parent(), n.Frame)
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n PipelineStatistics) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.pipeline-statistics<%v>", n.Parent(), n.Frame)
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n ReplayFidelity) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.replay-fidelity", n.Parent())
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n DeviceMetrics) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.device-metrics", n.Parent()) }
+
+// Format implements fmt.Formatter to print the path.
+func (n SyncTimeline) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.sync-timeline", n.Parent()) }
+
+// Format implements fmt.Formatter to print the path.
+func (n FramePacing) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.frame-pacing", n.Parent()) }
+
+// Format implements fmt.Formatter to print the path.
+func (n FrameRedundancy) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.frame-redundancy", n.Parent())
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n Scene) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.scene<%v>", n.Parent(), n.Frame)
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n ShaderClusters) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.shader-clusters<%v>", n.Parent(), n.MinSimilarity)
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n TextureUsage) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.texture-usage<%v>", n.Parent(), n.Frame)
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n UniformUsage) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.uniform-usage", n.Parent())
+}
+
+// Format implements fmt.Formatter to print the path.
+func (n ValueSeries) Format(f fmt.State, c rune) {
+	if n.State != nil {
+		fmt.Fprintf(f, "%v.series<%v>", n.Parent(), n.State)
+	} else {
+		fmt.Fprintf(f, "%v.series<%v.%v>", n.Parent(), n.CommandName, n.Parameter)
+	}
+}
+
 func (n *As) SetParent(p Node) {
 	switch p := p.(type) {
 	case nil:
@@ -552,6 +739,40 @@ func (n *API) ConstantSet(i int) *ConstantSet {
 	}
 }
 
+// Device returns the path node to the description of the device used to make
+// the capture. If replay is not nil, the capture device is compared against it.
+func (n *Capture) Device(replay *Device) *CaptureDevice {
+	return &CaptureDevice{Capture: n, ReplayDevice: replay}
+}
+
+// View returns the path node to the view of the given frames of the capture.
+// If frames is nil, the view covers the whole capture.
+func (n *Capture) View(frames *FrameRange) *CaptureView {
+	return &CaptureView{Capture: n, Frames: frames}
+}
+
+// Filter returns the command filter restricting commands to those of the view.
+func (n *CaptureView) Filter() *CommandFilter {
+	return &CommandFilter{Frames: n.Frames}
+}
+
+// CommandTree returns the path to the root node of the command tree of the
+// view.
+func (n *CaptureView) CommandTree() *CommandTree {
+	return n.Capture.CommandTree(n.Filter())
+}
+
+// Report returns the path node to the report of the view.
+func (n *CaptureView) Report(d *Device, display bool) *Report {
+	return n.Capture.Report(d, n.Filter(), display)
+}
+
+// Stats returns the path node to the per-frame draw call and bandwidth stats
+// of the frames of the view.
+func (n *CaptureView) Stats() *Stats {
+	return &Stats{Capture: n.Capture, DrawCall: true, Bandwidth: true, Frames: n.Frames}
+}
+
 // Resources returns the path node to the capture's resources.
 func (n *Capture) Resources() *Resources {
 	return &Resources{Capture: n}
@@ -599,6 +820,114 @@ func (n *Capture) SubCommandRange(from, to []uint64) *Commands {
 	}
 }
 
+// StateSeries returns the path node to the values of the state at p, sampled
+// after each of the commands.
+func (n *Commands) StateSeries(p Node) *ValueSeries {
+	return &ValueSeries{Commands: n, State: p.Path()}
+}
+
+// ParameterSeries returns the path node to the values of the parameter of each
+// of the commands with the given name.
+func (n *Commands) ParameterSeries(cmdName, param string) *ValueSeries {
+	return &ValueSeries{Commands: n, CommandName: cmdName, Parameter: param}
+}
+
+// Uploads returns the path node to the report of the capture's uploads,
+// ignoring reads of fewer than minSize bytes.
+func (n *Capture) Uploads(minSize uint64) *Uploads {
+	return &Uploads{Capture: n, MinSize: minSize}
+}
+
+// Barriers returns the path node to the pipeline barriers and events of the
+// given frame of the capture.
+func (n *Capture) Barriers(frame uint32) *Barriers {
+	return &Barriers{Capture: n, Frame: frame}
+}
+
+// BindChurn returns the path node to the bind churn of the capture's render
+// passes, limited to the max render passes with the most avoidable binds.
+func (n *Capture) BindChurn(max uint32) *BindChurn {
+	return &BindChurn{Capture: n, MaxRenderPasses: max}
+}
+
+// FrameGraph returns the path node to the graph of the passes of the given
+// frame of the capture.
+func (n *Capture) FrameGraph(frame uint32) *FrameGraph {
+	return &FrameGraph{Capture: n, Frame: frame}
+}
+
+// Scene returns the path node to the reconstructed geometry of the given
+// frame of the capture.
+func (n *Capture) Scene(frame uint32) *Scene {
+	return &Scene{Capture: n, Frame: frame}
+}
+
+// BlendCost returns the path node to the ranking of the blended draw calls of
+// the given frame of the capture.
+func (n *Capture) BlendCost(frame uint32) *BlendCost {
+	return &BlendCost{Capture: n, Frame: frame}
+}
+
+// DepthTestCost returns the path node to the measured depth test failures of
+// the draw calls of the given frame of the capture.
+func (n *Capture) DepthTestCost(frame uint32) *DepthTestCost {
+	return &DepthTestCost{Capture: n, Frame: frame}
+}
+
+// PipelineStatistics returns the path node to the measured pipeline
+// statistics of the draw calls of the given frame of the capture.
+func (n *Capture) PipelineStatistics(frame uint32) *PipelineStatistics {
+	return &PipelineStatistics{Capture: n, Frame: frame}
+}
+
+// ReplayFidelity returns the path node to the comparison of the replayed and
+// observed framebuffers of the frames of the capture.
+func (n *Capture) ReplayFidelity() *ReplayFidelity {
+	return &ReplayFidelity{Capture: n}
+}
+
+// DeviceMetrics returns the path node to the device state sampled while
+// capturing.
+func (n *Capture) DeviceMetrics() *DeviceMetrics {
+	return &DeviceMetrics{Capture: n}
+}
+
+// SyncTimeline returns the path node to the semaphore and fence events of the
+// capture.
+func (n *Capture) SyncTimeline() *SyncTimeline {
+	return &SyncTimeline{Capture: n}
+}
+
+// FramePacing returns the path node to the swapchain presents of the capture,
+// compared against a display with the given vsync period in ns.
+func (n *Capture) FramePacing(vsyncPeriod uint64) *FramePacing {
+	return &FramePacing{Capture: n, VsyncPeriod: vsyncPeriod}
+}
+
+// TextureUsage returns the path node to the utilization of the textures
+// sampled by the given frame of the capture.
+func (n *Capture) TextureUsage(frame, maxTextures uint32) *TextureUsage {
+	return &TextureUsage{Capture: n, Frame: frame, MaxTextures: maxTextures}
+}
+
+// UniformUsage returns the path node to the uses of the uniforms of the
+// capture's shader programs.
+func (n *Capture) UniformUsage() *UniformUsage {
+	return &UniformUsage{Capture: n}
+}
+
+// FrameRedundancy returns the path node to the counts of the work each frame
+// of the capture repeats from the previous frame.
+func (n *Capture) FrameRedundancy() *FrameRedundancy {
+	return &FrameRedundancy{Capture: n}
+}
+
+// ShaderClusters returns the path node to the groups of the capture's shaders
+// whose sources are at least minSimilarity similar.
+func (n *Capture) ShaderClusters(minSimilarity float32) *ShaderClusters {
+	return &ShaderClusters{Capture: n, MinSimilarity: minSimilarity}
+}
+
 // CommandTree returns the path to the root node of a capture's command tree
 // optionally filtered by f.
 func (n *Capture) CommandTree(f *CommandFilter) *CommandTree {
@@ -619,6 +948,12 @@ func (n *Capture) Command(i uint64, subidx ...uint64) *Command {
 	return &Command{Capture: n, Indices: indices}
 }
 
+// StableCommand returns the path node to the command in the capture with the
+// given stable identifier.
+func (n *Capture) StableCommand(stableID uint64) *Command {
+	return &Command{Capture: n, StableId: stableID}
+}
+
 // Context returns the path node to the a context with the given ID.
 func (n *Capture) Context(id id.ID) *Context {
 	return &Context{Capture: n, ID: NewID(id)}
@@ -686,6 +1021,29 @@ func (o *MeshOptions) Hints() map[string]vertex.Semantic_Type {
 	return m
 }
 
+// DrawBundle returns the path node to the state used by this draw call.
+func (n *Command) DrawBundle() *DrawBundle {
+	return &DrawBundle{Command: n}
+}
+
+// VertexShaderInputs returns the path node to the inputs of the vertex shader
+// invocation of the vertex with the given index of this draw call.
+func (n *Command) VertexShaderInputs(vertex uint64) *ShaderInputs {
+	return &ShaderInputs{Command: n, Invocation: &ShaderInputs_Vertex{vertex}}
+}
+
+// FragmentShaderInputs returns the path node to the inputs of the fragment
+// shader invocation of this draw call at the pixel (x, y).
+func (n *Command) FragmentShaderInputs(x, y uint32) *ShaderInputs {
+	return &ShaderInputs{Command: n, Invocation: &ShaderInputs_Fragment{&ShaderInputs_Position{X: x, Y: y}}}
+}
+
+// DebugTrace returns the path node to the trace of the emulation of this
+// shader invocation, reporting the hits of the given breakpoints.
+func (n *ShaderInputs) DebugTrace(breakpoints ...uint32) *ShaderDebugTrace {
+	return &ShaderDebugTrace{Inputs: n, Breakpoints: breakpoints}
+}
+
 // GlobalStateAfter returns the path node to the state after this command.
 func (n *Command) GlobalStateAfter() *GlobalState {
 	return &GlobalState{After: n}
@@ -729,6 +1087,33 @@ func (n *State) Tree() *StateTree {
 	return &StateTree{State: n}
 }
 
+// ComparedTo returns the path node to the state tree for this state, with the
+// nodes compared against those of the pinned state tree.
+func (n *StateTree) ComparedTo(pinned *ID) *StateTree {
+	return &StateTree{
+		State:          n.State,
+		ArrayGroupSize: n.ArrayGroupSize,
+		Preview:        n.Preview,
+		KeyOrder:       n.KeyOrder,
+		HideDefaults:   n.HideDefaults,
+		AllApis:        n.AllApis,
+		Pinned:         pinned,
+		CreatedBy:      n.CreatedBy,
+	}
+}
+
+// Search returns the path node to the members of this state whose names or
+// values match the regular expression pattern.
+func (n *State) Search(pattern string) *StateSearch {
+	return &StateSearch{State: n, Pattern: pattern, MatchNames: true, MatchValues: true}
+}
+
+// SearchNonFinite returns the path node to the members of this state that
+// hold NaN or infinite floats.
+func (n *State) SearchNonFinite() *StateSearch {
+	return &StateSearch{State: n, NonFinite: true}
+}
+
 func (n *GlobalState) Field(name string) *Field       { return NewField(name, n) }
 func (n *State) Field(name string) *Field             { return NewField(name, n) }
 func (n *Parameter) ArrayIndex(i uint64) *ArrayIndex  { return NewArrayIndex(i, n) }
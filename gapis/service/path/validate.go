@@ -118,7 +118,27 @@ func (n *Capture) Validate() error {
 }
 
 // Validate checks the path is valid.
+func (n *CaptureView) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *CaptureDevice) Validate() error {
+	if n != nil && n.ReplayDevice != nil {
+		if err := n.ReplayDevice.Validate(); err != nil {
+			return err
+		}
+	}
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+// Commands identified by their stable identifier are valid without indices,
+// as the server replaces the identifier with the command's index.
 func (n *Command) Validate() error {
+	if n != nil && n.StableId != 0 {
+		return checkNotNilAndValidate(n, n.Capture, "capture")
+	}
 	return anyErr(
 		checkNotNilAndValidate(n, n.Capture, "capture"),
 		checkGreaterThan(n, len(n.Indices), 0, "length(index)"),
@@ -210,6 +230,11 @@ func (n *ImageInfo) Validate() error {
 	return checkNotNilAndValidate(n, n.ID, "id")
 }
 
+// Validate checks the path is valid.
+func (n *LastModifiedBy) Validate() error {
+	return checkNotNilAndValidate(n, n.Value.Node(), "value")
+}
+
 // Validate checks the path is valid.
 func (n *MapIndex) Validate() error {
 	return anyErr(
@@ -307,6 +332,11 @@ func (n *StateTree) Validate() error {
 	return checkNotNilAndValidate(n, n.State, "state")
 }
 
+// Validate checks the path is valid.
+func (n *StateSearch) Validate() error {
+	return checkNotNilAndValidate(n, n.State, "state")
+}
+
 // Validate checks the path is valid.
 func (n *StateTreeNode) Validate() error {
 	return checkIsValid(n, n.Tree, "tree")
@@ -337,3 +367,118 @@ func (n *Type) Validate() error {
 	}
 	return fmt.Errorf("Invalid path '%v': type must not be nil", n)
 }
+
+// Validate checks the path is valid.
+func (n *Barriers) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *BindChurn) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *DrawBundle) Validate() error {
+	return checkNotNilAndValidate(n, n.Command, "command")
+}
+
+// Validate checks the path is valid.
+func (n *ShaderInputs) Validate() error {
+	return anyErr(
+		checkNotNilAndValidate(n, n.Command, "command"),
+		checkNotNilAndValidate(n, n.Invocation, "invocation"),
+	)
+}
+
+// Validate checks the path is valid.
+func (n *ShaderDebugTrace) Validate() error {
+	return checkNotNilAndValidate(n, n.Inputs, "inputs")
+}
+
+// Validate checks the path is valid.
+func (n *FrameGraph) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *FrameRedundancy) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *BlendCost) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+func (n *DepthTestCost) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *PipelineStatistics) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *ReplayFidelity) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *DeviceMetrics) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *SyncTimeline) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *FramePacing) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *Scene) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *TextureUsage) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *UniformUsage) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *ShaderClusters) Validate() error {
+	if n != nil && (n.MinSimilarity < 0 || n.MinSimilarity > 1) {
+		return fmt.Errorf("Invalid path '%v': min_similarity must be between 0 and 1", n)
+	}
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *Uploads) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
+// Validate checks the path is valid.
+func (n *ValueSeries) Validate() error {
+	if n != nil && n.State != nil {
+		return anyErr(
+			checkNotNilAndValidate(n, n.Commands, "commands"),
+			checkNotNilAndValidate(n, n.State.Node(), "state"),
+		)
+	}
+	return anyErr(
+		checkNotNilAndValidate(n, n.Commands, "commands"),
+		checkNotEmptyString(n, n.CommandName, "command_name"),
+		checkNotEmptyString(n, n.Parameter, "parameter"),
+	)
+}
@@ -56,6 +56,10 @@ type Service interface {
 	// GetServerInfo returns information about the running server.
 	GetServerInfo(ctx context.Context) (*ServerInfo, error)
 
+	// GetHealth returns whether the server is ready, what it is busy doing and
+	// its memory usage.
+	GetHealth(ctx context.Context) (*Health, error)
+
 	// CheckForUpdates checks for a new build of GAPID on the hosting server.
 	// Care should be taken to call this infrequently to avoid reaching the
 	// server's maximum unauthenticated request limits.
@@ -76,11 +80,27 @@ type Service interface {
 	ExportCapture(ctx context.Context, c *path.Capture) ([]byte, error)
 
 	// LoadCapture imports capture data from a local file, returning the new
-	// capture identifier.
-	LoadCapture(ctx context.Context, path string) (*path.Capture, error)
+	// capture identifier. If key is not empty, the capture is decrypted with
+	// it instead of the server's key. Keys are only accepted by servers that
+	// require an auth token.
+	LoadCapture(ctx context.Context, path string, key []byte) (*path.Capture, error)
+
+	// GetCaptureMetadata returns the summary held at the start of a local
+	// capture file, without loading the capture.
+	GetCaptureMetadata(ctx context.Context, path string) (*CaptureMetadata, error)
+
+	// GetLoadedCaptures returns the list of captures currently loaded by the
+	// server.
+	GetLoadedCaptures(ctx context.Context) ([]*LoadedCapture, error)
+
+	// UnloadCapture removes the capture from the list of loaded captures and
+	// frees the memory held by the decoded capture.
+	UnloadCapture(ctx context.Context, c *path.Capture) error
 
-	// SaveCapture saves the capture to a local file.
-	SaveCapture(ctx context.Context, c *path.Capture, path string) error
+	// SaveCapture saves the capture to a local file. If key is not empty, the
+	// capture is encrypted with it instead of the server's key. Keys are only
+	// accepted by servers that require an auth token.
+	SaveCapture(ctx context.Context, c *path.Capture, path string, key []byte) error
 
 	// ExportReplay saves replay commands and assets to file.
 	ExportReplay(ctx context.Context, c *path.Capture, d *path.Device, path string, opts *ExportReplayOptions) error
@@ -88,8 +108,40 @@ type Service interface {
 	// DCECapture returns a new capture containing only the requested commands and their dependencies.
 	DCECapture(ctx context.Context, capture *path.Capture, commands []*path.Command) (*path.Capture, error)
 
+	// ScrubCapture returns a new capture with the application data of the
+	// capture replaced by placeholders of the same size.
+	ScrubCapture(ctx context.Context, capture *path.Capture) (*path.Capture, error)
+
+	// SearchCaptures returns the captures of the indexed directory tree root
+	// that match the query, most recently modified first.
+	SearchCaptures(ctx context.Context, root string, query *CaptureQuery, refresh bool) ([]*IndexedCapture, error)
+
+	// AnnotateCapture gives the capture file at path the tags addTags, removes
+	// the tags removeTags, and replaces its annotation if setAnnotation is true.
+	AnnotateCapture(ctx context.Context, path string, addTags, removeTags []string, setAnnotation bool, annotation string) error
+
+	// UploadCapture receives the next chunk of a capture's data, returning
+	// the progress of the upload.
+	UploadCapture(ctx context.Context, req *UploadCaptureRequest) (*CaptureTransfer, error)
+
+	// DownloadCapture returns the chunk of a capture's exported data at
+	// offset, of at most maxSize bytes.
+	DownloadCapture(ctx context.Context, c *path.Capture, offset, maxSize uint64) (*CaptureChunk, error)
+
+	// PushCapture uploads a capture to the remote server, returning the
+	// capture on the remote server.
+	PushCapture(ctx context.Context, c *path.Capture, remote, remoteToken string) (*path.Capture, error)
+
+	// PullCapture downloads and loads the capture c from the remote server.
+	PullCapture(ctx context.Context, remote, remoteToken string, c *path.Capture) (*path.Capture, error)
+
 	GetGraphVisualization(ctx context.Context, capture *path.Capture, format GraphFormat) ([]byte, error)
 
+	// ExportDependencyGraph returns the dependency graph of the capture in the
+	// given format. If around is not nil, only the nodes at most radius
+	// dependencies away from the command around are returned.
+	ExportDependencyGraph(ctx context.Context, capture *path.Capture, around *path.Command, radius uint32, format GraphFormat) ([]byte, error)
+
 	// GetDevices returns the full list of replay devices available to the server.
 	// These include local replay devices and any connected Android devices.
 	// This list may change over time, as devices are connected and disconnected.
@@ -126,6 +178,11 @@ type Service interface {
 	// the base changed to refer to the new capture.
 	Set(ctx context.Context, p *path.Any, v interface{}, c *path.ResolveConfig) (*path.Any, error)
 
+	// GetShaderDiagnostics compiles the edited shader that is to replace the
+	// shader resource p, and returns the errors and warnings reported by the
+	// compiler. The capture is not modified.
+	GetShaderDiagnostics(ctx context.Context, p *path.ResourceData, shader *api.Shader, c *path.ResolveConfig) ([]*ShaderDiagnostic, error)
+
 	// Delete creates a copy of the capture referenced by p, but without the object, value
 	// or memory at p. The path returned is identical to p, but with
 	// the base changed to refer to the new capture.
@@ -135,6 +192,63 @@ type Service interface {
 	// If the value at p does not link to anything then nil is returned.
 	Follow(ctx context.Context, p *path.Any, c *path.ResolveConfig) (*path.Any, error)
 
+	// GetCommandTreeStats returns the number of descendants of the command
+	// tree node p, counted per depth.
+	GetCommandTreeStats(ctx context.Context, p *path.CommandTreeNode, c *path.ResolveConfig) (*CommandTreeStats, error)
+
+	// GetCommandTreeRow returns the row of the node, or the node of the row,
+	// of the request among the visible descendants of its root node.
+	GetCommandTreeRow(ctx context.Context, req *GetCommandTreeRowRequest) (*CommandTreeRow, error)
+
+	// FindStateChange returns the nearest command after, or before if
+	// backwards is true, the command the state value path p is rooted at that
+	// changes the value. If equals is not nil then only commands that change
+	// the value to equals are found.
+	FindStateChange(ctx context.Context, p *path.Any, backwards bool, equals *box.Value, c *path.ResolveConfig) (*path.Command, error)
+
+	// StepToBreakpoint returns the first command after from that satisfies
+	// any of the breakpoints.
+	StepToBreakpoint(ctx context.Context, from *path.Command, breakpoints []*CommandBreakpoint, c *path.ResolveConfig) (*path.Command, error)
+
+	// CompareState returns the values of each of the state value paths after
+	// each of the commands, as a table with a row per path.
+	CompareState(ctx context.Context, paths []*path.Any, cmds []*path.Command, c *path.ResolveConfig) (*StateTable, error)
+
+	// ExportState returns the state of every API after the command, serialized
+	// as JSON. The elements of memory slices larger than maxMemorySize bytes
+	// are omitted.
+	ExportState(ctx context.Context, after *path.Command, maxMemorySize uint64, c *path.ResolveConfig) ([]byte, error)
+
+	// DiffReports compares the reports before and after, matching their items
+	// by fingerprint.
+	DiffReports(ctx context.Context, before, after *path.Report, c *path.ResolveConfig) (*ReportDiff, error)
+
+	// ScrubState returns a handler that resolves the state values of paths
+	// after a cursor moved between the commands of a capture, returning the
+	// values that change with each move.
+	ScrubState(ctx context.Context, paths []*path.Any, c *path.ResolveConfig) (StateScrubHandler, error)
+
+	// GetStateSnippet returns the state tree node p, and depth levels of its
+	// children, rendered as text in the given format.
+	GetStateSnippet(ctx context.Context, p *path.StateTreeNode, format SnippetFormat, depth int32, c *path.ResolveConfig) (string, error)
+
+	// GetCommandListWindow returns the formatted entries of count commands of
+	// the capture starting at offset, with the groups of the command tree
+	// tree, if not nil, that contain them.
+	GetCommandListWindow(ctx context.Context, p *path.Capture, offset uint64, count uint32, tree *path.ID, c *path.ResolveConfig) (*CommandListWindow, error)
+
+	// GetStateTreeChildren returns count children of the state tree node p,
+	// starting at the child index offset.
+	GetStateTreeChildren(ctx context.Context, p *path.StateTreeNode, offset uint64, count uint32, c *path.ResolveConfig) (*StateTreeChildren, error)
+
+	// FindStateTreeNodes returns the nodes of the state tree with the given
+	// stable identifiers.
+	FindStateTreeNodes(ctx context.Context, tree *path.ID, ids []string, c *path.ResolveConfig) (*StateTreeNodeMatches, error)
+
+	// GetProfileTimeline returns the GPU slices of the latest profile of the
+	// capture p within the window [start, end), aggregated into buckets.
+	GetProfileTimeline(ctx context.Context, p *path.Capture, start, end uint64, buckets uint32) (*ProfileTimeline, error)
+
 	// Profile starts self-profiling of the server.
 	// If pprof is not nil then CPU pprof data will be written to this writer
 	// until stop is called.
@@ -158,13 +272,25 @@ type Service interface {
 	// GetProfile returns the pprof profile with the given name.
 	GetProfile(ctx context.Context, name string, debug int32) ([]byte, error)
 
-	// GetLogStream calls the handler with each log record raised until the
-	// context is cancelled.
-	GetLogStream(context.Context, log.Handler) error
+	// GetLogStream calls the handler with each log record matching req raised
+	// until the context is cancelled.
+	GetLogStream(ctx context.Context, req *GetLogStreamRequest, h log.Handler) error
+
+	// GetRecentLogs returns the most recent log records matching req retained
+	// by the server, oldest first.
+	GetRecentLogs(ctx context.Context, req *GetRecentLogsRequest) ([]*log.Message, error)
 
 	// Find performs a search using req, streaming the results to h.
 	Find(ctx context.Context, req *FindRequest, h FindHandler) error
 
+	// SetSelection sets the selection shared by all the clients of the
+	// server, notifying the clients following the selection.
+	SetSelection(ctx context.Context, s *Selection) error
+
+	// FollowSelection calls h with the current selection, if any, and then
+	// with each change of the selection, until the context is cancelled.
+	FollowSelection(ctx context.Context, h SelectionHandler) error
+
 	// ClientEvent records a client event action, used for analytics.
 	// If the user has not opted-in for analytics then this call does nothing.
 	ClientEvent(ctx context.Context, req *ClientEventRequest) error
@@ -201,9 +327,21 @@ type TraceHandler interface {
 	Dispose(context.Context)
 }
 
+// StateScrubHandler is the handler of the cursor of Service.ScrubState.
+type StateScrubHandler interface {
+	// Move moves the cursor to the state after cmd, returning the values that
+	// changed since the previous move.
+	Move(ctx context.Context, cmd *path.Command) (*StateDelta, error)
+	// Dispose releases the resources held by the handler.
+	Dispose(ctx context.Context)
+}
+
 // FindHandler is the handler of found items using Service.Find.
 type FindHandler func(*FindResponse) error
 
+// SelectionHandler is the handler of selections using Service.FollowSelection.
+type SelectionHandler func(*Selection) error
+
 // TimeStampsHandler is the handler of queried timestamps suing Service.GetTimestamps.
 type TimeStampsHandler func(*GetTimestampsResponse) error
 
@@ -285,6 +423,38 @@ func NewValue(v interface{}) *Value {
 		return &Value{Val: &Value_StateTreeNode{v}}
 	case *Stats:
 		return &Value{Val: &Value_Stats{v}}
+	case *CaptureDevice:
+		return &Value{Val: &Value_CaptureDevice{v}}
+	case *CaptureView:
+		return &Value{Val: &Value_CaptureView{v}}
+	case *ValueSeries:
+		return &Value{Val: &Value_ValueSeries{v}}
+	case *UploadReport:
+		return &Value{Val: &Value_UploadReport{v}}
+	case *FrameRedundancy:
+		return &Value{Val: &Value_FrameRedundancy{v}}
+	case *ShaderClusters:
+		return &Value{Val: &Value_ShaderClusters{v}}
+	case *TextureUsage:
+		return &Value{Val: &Value_TextureUsage{v}}
+	case *DepthTestCost:
+		return &Value{Val: &Value_DepthTestCost{v}}
+	case *PipelineStatistics:
+		return &Value{Val: &Value_PipelineStatistics{v}}
+	case *ShaderInputs:
+		return &Value{Val: &Value_ShaderInputs{v}}
+	case *ShaderDebugTrace:
+		return &Value{Val: &Value_ShaderDebugTrace{v}}
+	case *ReplayFidelity:
+		return &Value{Val: &Value_ReplayFidelity{v}}
+	case *DeviceMetrics:
+		return &Value{Val: &Value_DeviceMetrics{v}}
+	case *StateSearchResults:
+		return &Value{Val: &Value_StateSearchResults{v}}
+	case *api.SyncTimeline:
+		return &Value{Val: &Value_SyncTimeline{v}}
+	case *api.FramePacing:
+		return &Value{Val: &Value_FramePacing{v}}
 	case *api.Command:
 		return &Value{Val: &Value_Command{v}}
 	case *api.Mesh:
@@ -299,6 +469,20 @@ func NewValue(v interface{}) *Value {
 		return &Value{Val: &Value_Device{v}}
 	case *api.MultiResourceData:
 		return &Value{Val: &Value_MultiResourceData{v}}
+	case *api.BindChurn:
+		return &Value{Val: &Value_BindChurn{v}}
+	case *api.FrameGraph:
+		return &Value{Val: &Value_FrameGraph{v}}
+	case *api.Barriers:
+		return &Value{Val: &Value_Barriers{v}}
+	case *api.DrawBundle:
+		return &Value{Val: &Value_DrawBundle{v}}
+	case *api.Scene:
+		return &Value{Val: &Value_Scene{v}}
+	case *api.UniformUsage:
+		return &Value{Val: &Value_UniformUsage{v}}
+	case *api.BlendCost:
+		return &Value{Val: &Value_BlendCost{v}}
 	case *DeviceTraceConfiguration:
 		return &Value{Val: &Value_TraceConfig{v}}
 	case *types.Type:
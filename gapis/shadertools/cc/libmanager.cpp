@@ -135,7 +135,8 @@ std::vector<unsigned int> parseGlslang(const char* code, const char* preamble,
                                        std::string* err_msg,
                                        shader_type shader_ty,
                                        client_type client_ty,
-                                       bool relaxed_errs) {
+                                       bool relaxed_errs,
+                                       std::string* warnings = nullptr) {
   std::vector<unsigned int> spirv;
 
   EShMessages messages = relaxed_errs ? EShMsgRelaxedErrors : EShMsgDefault;
@@ -243,6 +244,8 @@ std::vector<unsigned int> parseGlslang(const char* code, const char* preamble,
     bool linked = program.link(messages);
     if (!linked) {
       *err_msg += "Linking failed:\n" + std::string(program.getInfoLog());
+    } else if (warnings != nullptr) {
+      *warnings = shader.getInfoLog();
     }
     std::string warningsErrors;
     glslang::GlslangToSpv(*program.getIntermediate(lang), spirv);
@@ -322,7 +325,7 @@ code_with_debug_info_t* convertGlsl(const char* input, size_t length,
   try {
     std::string source =
         spirv2glsl(std::move(spirv_new), options->target_glsl_version,
-                   options->strip_optimizations);
+                   options->target_es, options->strip_optimizations);
 
     result->source_code = new char[source.length() + 1];
     strcpy(result->source_code, source.c_str());
@@ -331,7 +334,7 @@ code_with_debug_info_t* convertGlsl(const char* input, size_t length,
     // check if changed source code compiles again
     if (options->check_after_changes) {
       parseGlslang(result->source_code, nullptr, &err_msg, options->shader_type,
-                   OPENGL, false);
+                   options->target_es ? OPENGLES : OPENGL, false);
     }
 
     if (!err_msg.empty()) {
@@ -441,13 +444,18 @@ glsl_compile_result_t* compileGlsl(const char* code,
       new glsl_compile_result_t{true, nullptr, spirv_binary_t{nullptr, 0}};
 
   std::string err_msg;
+  std::string warnings;
   std::vector<unsigned int> spirv =
       parseGlslang(code, options->preamble, &err_msg, options->shader_type,
-                   options->client_type, false);
+                   options->client_type, false, &warnings);
   if (!err_msg.empty()) {
     result->ok = false;
     result->message = new char[err_msg.length() + 1];
     strcpy(result->message, err_msg.c_str());
+  } else if (!warnings.empty()) {
+    // The warnings of a successful compilation are returned in the message.
+    result->message = new char[warnings.length() + 1];
+    strcpy(result->message, warnings.c_str());
   }
   if (spirv.size() > 0) {
     result->binary.words_num = spirv.size();
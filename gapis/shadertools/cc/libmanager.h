@@ -77,6 +77,7 @@ typedef struct convert_options_t {
   bool relaxed;
   bool strip_optimizations;
   int target_glsl_version;
+  bool target_es;
 } convert_options_t;
 
 typedef struct compile_options_t {
@@ -92,6 +93,7 @@ typedef struct spirv_binary_t {
 
 typedef struct glsl_compile_result_t {
   bool ok;
+  // The errors if the compilation failed, otherwise its warnings, if any.
   char* message;
   spirv_binary_t binary;
 } glsl_compile_result_t;
@@ -22,11 +22,11 @@
 #include "third_party/SPIRV-Cross/spirv_glsl.hpp"
 
 std::string spirv2glsl(std::vector<uint32_t> spirv, int glsl_version,
-                       bool strip_optimizations) {
+                       bool es, bool strip_optimizations) {
   spirv_cross::CompilerGLSL glsl(std::move(spirv));
   spirv_cross::CompilerGLSL::Options cross_options;
-  cross_options.version = glsl_version == 0 ? 330 : glsl_version;
-  cross_options.es = false;
+  cross_options.version = glsl_version == 0 ? (es ? 300 : 330) : glsl_version;
+  cross_options.es = es;
   cross_options.force_temporary = false;
   cross_options.vertex.fixup_clipspace = false;
   glsl.set_common_options(cross_options);
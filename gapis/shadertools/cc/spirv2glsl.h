@@ -18,4 +18,4 @@
 #include <vector>
 
 std::string spirv2glsl(std::vector<uint32_t> spirv, int glsl_version,
-                       bool strip_optimizations);
+                       bool es, bool strip_optimizations);
@@ -23,7 +23,9 @@ import "C"
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"unsafe"
@@ -116,8 +118,10 @@ func (t ShaderType) String() string {
 type ConvertOptions struct {
 	// The type of shader.
 	ShaderType ShaderType
-	// The target GLSL version (default 330).
+	// The target GLSL version (default 330, or 300 for GLSL ES).
 	TargetGLSLVersion int
+	// Whether to target GLSL ES rather than desktop GLSL.
+	TargetES bool
 	// Shader source preamble.
 	Preamble string
 	// Whether to add prefix to all non-builtin symbols.
@@ -177,6 +181,7 @@ func ConvertGlsl(source string, o *ConvertOptions) (CodeWithDebugInfo, error) {
 		relaxed:                C.bool(o.Relaxed),
 		strip_optimizations:    C.bool(o.StripOptimizations),
 		target_glsl_version:    C.int(o.TargetGLSLVersion),
+		target_es:              C.bool(o.TargetES),
 	}
 	result := C.convertGlsl(cstr(source), C.size_t(len(source)), &opts)
 	defer C.deleteGlslCodeWithDebug(result)
@@ -272,6 +277,45 @@ type CompileOptions struct {
 
 // CompileGlsl compiles GLSL source code to SPIR-V binary words.
 func CompileGlsl(source string, o CompileOptions) ([]uint32, error) {
+	words, message, ok := compileGlsl(source, o)
+	if ok {
+		return words, nil
+	}
+	msg := []string{
+		fmt.Sprintf("Failed to compile %v shader.", o.ShaderType),
+	}
+	if len(message) > 0 {
+		msg = append(msg, message)
+	}
+	msg = append(msg, "Source:", text.LineNumber(source))
+	if len(o.Preamble) > 0 {
+		msg = append(msg, "Preamble:", text.LineNumber(o.Preamble))
+	}
+	return words, fault.Const(strings.Join(msg, "\n"))
+}
+
+// CheckGlsl compiles GLSL source code and returns the errors and warnings
+// reported by the compiler, without producing a SPIR-V binary. Only the
+// warnings, if any, are returned if the source compiles successfully.
+func CheckGlsl(source string, o CompileOptions) []Diagnostic {
+	_, message, ok := compileGlsl(source, o)
+	if ok {
+		warnings := []Diagnostic{}
+		for _, d := range ParseDiagnostics(message) {
+			if d.Warning {
+				warnings = append(warnings, d)
+			}
+		}
+		return warnings
+	}
+	out := ParseDiagnostics(message)
+	if len(out) == 0 {
+		out = append(out, Diagnostic{Message: strings.TrimSpace(message)})
+	}
+	return out
+}
+
+func compileGlsl(source string, o CompileOptions) (words []uint32, message string, ok bool) {
 	toFree := []unsafe.Pointer{}
 	defer func() {
 		for _, ptr := range toFree {
@@ -297,24 +341,70 @@ func CompileGlsl(source string, o CompileOptions) ([]uint32, error) {
 	defer C.deleteCompileResult(result)
 
 	count := uint64(result.binary.words_num)
-	words := make([]uint32, count)
+	words = make([]uint32, count)
 	if result.ok {
 		// TODO: Remove the following hack and encoding the data without using unsafe.
 		data := (*[1 << 30]uint32)(unsafe.Pointer(result.binary.words))[:count:count]
 		copy(words, data)
-		return words, nil
+		return words, C.GoString(result.message), true
 	}
-	msg := []string{
-		fmt.Sprintf("Failed to compile %v shader.", o.ShaderType),
+	return words, C.GoString(result.message), false
+}
+
+// Diagnostic is an error or warning reported by the compiler for a shader.
+type Diagnostic struct {
+	Warning bool   // True if the diagnostic is a warning, false for an error.
+	Line    int    // 1-based line in the source, or 0 if not known.
+	Column  int    // 1-based column in the line, or 0 if not known.
+	Message string // The compiler's description of the problem.
+}
+
+func (d Diagnostic) String() string {
+	kind := "error"
+	if d.Warning {
+		kind = "warning"
 	}
-	if m := C.GoString(result.message); len(m) > 0 {
-		msg = append(msg, m)
+	switch {
+	case d.Column > 0:
+		return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, kind, d.Message)
+	case d.Line > 0:
+		return fmt.Sprintf("%d: %s: %s", d.Line, kind, d.Message)
+	default:
+		return fmt.Sprintf("%s: %s", kind, d.Message)
 	}
-	msg = append(msg, "Source:", text.LineNumber(source))
-	if len(o.Preamble) > 0 {
-		msg = append(msg, "Preamble:", text.LineNumber(o.Preamble))
+}
+
+// diagnosticRE matches a glslang info log line of the form
+// "ERROR: <string>:<line>[:<column>]: <message>".
+var diagnosticRE = regexp.MustCompile(`^(ERROR|WARNING): (-?\d+):(\d+)(?::(\d+))?: (.*)$`)
+
+// ParseDiagnostics parses the errors and warnings from the glslang info log
+// msg. Diagnostics that are not for the shader source, such as those in the
+// preamble, are returned without a line. Summary lines such as
+// "ERROR: 1 compilation errors." are skipped.
+func ParseDiagnostics(msg string) []Diagnostic {
+	out := []Diagnostic{}
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.TrimSpace(line)
+		if m := diagnosticRE.FindStringSubmatch(line); m != nil {
+			d := Diagnostic{Warning: m[1] == "WARNING", Message: m[5]}
+			if m[2] == "0" {
+				d.Line, _ = strconv.Atoi(m[3])
+				d.Column, _ = strconv.Atoi(m[4])
+			}
+			out = append(out, d)
+			continue
+		}
+		for _, prefix := range []string{"ERROR: ", "WARNING: "} {
+			if strings.HasPrefix(line, prefix) {
+				rest := strings.TrimPrefix(line, prefix)
+				if !strings.Contains(rest, "compilation errors") && !strings.Contains(rest, "compilation warnings") {
+					out = append(out, Diagnostic{Warning: prefix == "WARNING: ", Message: rest})
+				}
+			}
+		}
 	}
-	return words, fault.Const(strings.Join(msg, "\n"))
+	return out
 }
 
 type DescriptorSets map[uint32]DescriptorSet
@@ -232,6 +232,53 @@ void main() {
 	}
 }
 
+func TestCheckGlsl(t *testing.T) {
+	ctx := log.Testing(t)
+	opts := shadertools.CompileOptions{
+		ShaderType: shadertools.TypeFragment,
+		ClientType: shadertools.OpenGLES,
+	}
+	check := func(body string) []shadertools.Diagnostic {
+		return shadertools.CheckGlsl(`#version 300 es
+precision mediump float;
+out vec4 color;
+`+body, opts)
+	}
+
+	assert.For(ctx, "valid").ThatSlice(check(`void main() { color = vec4(1.0); }`)).IsEmpty()
+
+	warnings := check(`#extension GL_GAPID_unknown : enable
+void main() { color = vec4(1.0); }`)
+	if assert.For(ctx, "warnings").ThatSlice(warnings).IsLength(1) {
+		assert.For(ctx, "warning").That(warnings[0].Warning).Equals(true)
+		assert.For(ctx, "warning line").That(warnings[0].Line).Equals(4)
+		assert.For(ctx, "warning message").ThatString(warnings[0].Message).Contains("GL_GAPID_unknown")
+	}
+
+	errors := check(`void main() { color = foo; }`)
+	if assert.For(ctx, "errors").ThatSlice(errors).IsNotEmpty() {
+		assert.For(ctx, "error").That(errors[0].Warning).Equals(false)
+		assert.For(ctx, "error line").That(errors[0].Line).Equals(4)
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	ctx := log.Testing(t)
+	msg := `Compilation failed:
+ERROR: 0:3: 'foo' : undeclared identifier
+WARNING: 0:5:12: 'bar' : unused variable
+ERROR: -1:1: '' : preamble error
+ERROR: Linking vertex stage: Missing entry point
+ERROR: 2 compilation errors.  No code generated.
+`
+	assert.For(ctx, "diagnostics").ThatSlice(shadertools.ParseDiagnostics(msg)).Equals([]shadertools.Diagnostic{
+		{Line: 3, Message: "'foo' : undeclared identifier"},
+		{Warning: true, Line: 5, Column: 12, Message: "'bar' : unused variable"},
+		{Message: "'' : preamble error"},
+		{Message: "Linking vertex stage: Missing entry point"},
+	})
+}
+
 func TestParseDescriptorSets(t *testing.T) {
 	for _, test := range []struct {
 		desc       string
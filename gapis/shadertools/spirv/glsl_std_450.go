@@ -0,0 +1,287 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spirv
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// The GLSL.std.450 extended instructions known to the interpreter.
+const (
+	glslRound       = 1
+	glslRoundEven   = 2
+	glslTrunc       = 3
+	glslFAbs        = 4
+	glslSAbs        = 5
+	glslFSign       = 6
+	glslSSign       = 7
+	glslFloor       = 8
+	glslCeil        = 9
+	glslFract       = 10
+	glslRadians     = 11
+	glslDegrees     = 12
+	glslSin         = 13
+	glslCos         = 14
+	glslTan         = 15
+	glslAsin        = 16
+	glslAcos        = 17
+	glslAtan        = 18
+	glslSinh        = 19
+	glslCosh        = 20
+	glslTanh        = 21
+	glslAsinh       = 22
+	glslAcosh       = 23
+	glslAtanh       = 24
+	glslAtan2       = 25
+	glslPow         = 26
+	glslExp         = 27
+	glslLog         = 28
+	glslExp2        = 29
+	glslLog2        = 30
+	glslSqrt        = 31
+	glslInverseSqrt = 32
+	glslDeterminant = 33
+	glslFMin        = 37
+	glslUMin        = 38
+	glslSMin        = 39
+	glslFMax        = 40
+	glslUMax        = 41
+	glslSMax        = 42
+	glslFClamp      = 43
+	glslUClamp      = 44
+	glslSClamp      = 45
+	glslFMix        = 46
+	glslStep        = 48
+	glslSmoothStep  = 49
+	glslFma         = 50
+	glslLdexp       = 53
+	glslLength      = 66
+	glslDistance    = 67
+	glslCross       = 68
+	glslNormalize   = 69
+	glslFaceForward = 70
+	glslReflect     = 71
+	glslRefract     = 72
+	glslFindILsb    = 73
+	glslFindSMsb    = 74
+	glslFindUMsb    = 75
+	glslNMin        = 79
+	glslNMax        = 80
+	glslNClamp      = 81
+)
+
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+func clamp(x, lo, hi float64) float64 { return math.Min(math.Max(x, lo), hi) }
+
+// glslComponentwise are the GLSL.std.450 instructions applied to each
+// component of their operands.
+var glslComponentwise = map[uint32]scalarOp{
+	glslRound:     floatOp(func(c ...float64) float64 { return math.Round(c[0]) }),
+	glslRoundEven: floatOp(func(c ...float64) float64 { return math.RoundToEven(c[0]) }),
+	glslTrunc:     floatOp(func(c ...float64) float64 { return math.Trunc(c[0]) }),
+	glslFAbs:      floatOp(func(c ...float64) float64 { return math.Abs(c[0]) }),
+	glslSAbs: func(c ...uint32) uint32 {
+		if int32(c[0]) < 0 {
+			return -c[0]
+		}
+		return c[0]
+	},
+	glslFSign:       floatOp(func(c ...float64) float64 { return sign(c[0]) }),
+	glslSSign:       func(c ...uint32) uint32 { return uint32(int32(sign(float64(int32(c[0]))))) },
+	glslFloor:       floatOp(func(c ...float64) float64 { return math.Floor(c[0]) }),
+	glslCeil:        floatOp(func(c ...float64) float64 { return math.Ceil(c[0]) }),
+	glslFract:       floatOp(func(c ...float64) float64 { return c[0] - math.Floor(c[0]) }),
+	glslRadians:     floatOp(func(c ...float64) float64 { return c[0] * math.Pi / 180 }),
+	glslDegrees:     floatOp(func(c ...float64) float64 { return c[0] * 180 / math.Pi }),
+	glslSin:         floatOp(func(c ...float64) float64 { return math.Sin(c[0]) }),
+	glslCos:         floatOp(func(c ...float64) float64 { return math.Cos(c[0]) }),
+	glslTan:         floatOp(func(c ...float64) float64 { return math.Tan(c[0]) }),
+	glslAsin:        floatOp(func(c ...float64) float64 { return math.Asin(c[0]) }),
+	glslAcos:        floatOp(func(c ...float64) float64 { return math.Acos(c[0]) }),
+	glslAtan:        floatOp(func(c ...float64) float64 { return math.Atan(c[0]) }),
+	glslSinh:        floatOp(func(c ...float64) float64 { return math.Sinh(c[0]) }),
+	glslCosh:        floatOp(func(c ...float64) float64 { return math.Cosh(c[0]) }),
+	glslTanh:        floatOp(func(c ...float64) float64 { return math.Tanh(c[0]) }),
+	glslAsinh:       floatOp(func(c ...float64) float64 { return math.Asinh(c[0]) }),
+	glslAcosh:       floatOp(func(c ...float64) float64 { return math.Acosh(c[0]) }),
+	glslAtanh:       floatOp(func(c ...float64) float64 { return math.Atanh(c[0]) }),
+	glslAtan2:       floatOp(func(c ...float64) float64 { return math.Atan2(c[0], c[1]) }),
+	glslPow:         floatOp(func(c ...float64) float64 { return math.Pow(c[0], c[1]) }),
+	glslExp:         floatOp(func(c ...float64) float64 { return math.Exp(c[0]) }),
+	glslLog:         floatOp(func(c ...float64) float64 { return math.Log(c[0]) }),
+	glslExp2:        floatOp(func(c ...float64) float64 { return math.Exp2(c[0]) }),
+	glslLog2:        floatOp(func(c ...float64) float64 { return math.Log2(c[0]) }),
+	glslSqrt:        floatOp(func(c ...float64) float64 { return math.Sqrt(c[0]) }),
+	glslInverseSqrt: floatOp(func(c ...float64) float64 { return 1 / math.Sqrt(c[0]) }),
+	glslFMin:        floatOp(func(c ...float64) float64 { return math.Min(c[0], c[1]) }),
+	glslNMin:        floatOp(func(c ...float64) float64 { return math.Min(c[0], c[1]) }),
+	glslFMax:        floatOp(func(c ...float64) float64 { return math.Max(c[0], c[1]) }),
+	glslNMax:        floatOp(func(c ...float64) float64 { return math.Max(c[0], c[1]) }),
+	glslFClamp:      floatOp(func(c ...float64) float64 { return clamp(c[0], c[1], c[2]) }),
+	glslNClamp:      floatOp(func(c ...float64) float64 { return clamp(c[0], c[1], c[2]) }),
+	glslUMin: func(c ...uint32) uint32 {
+		if c[0] < c[1] {
+			return c[0]
+		}
+		return c[1]
+	},
+	glslSMin: func(c ...uint32) uint32 {
+		if int32(c[0]) < int32(c[1]) {
+			return c[0]
+		}
+		return c[1]
+	},
+	glslUMax: func(c ...uint32) uint32 {
+		if c[0] > c[1] {
+			return c[0]
+		}
+		return c[1]
+	},
+	glslSMax: func(c ...uint32) uint32 {
+		if int32(c[0]) > int32(c[1]) {
+			return c[0]
+		}
+		return c[1]
+	},
+	glslUClamp: func(c ...uint32) uint32 {
+		switch {
+		case c[0] < c[1]:
+			return c[1]
+		case c[0] > c[2]:
+			return c[2]
+		}
+		return c[0]
+	},
+	glslSClamp: func(c ...uint32) uint32 {
+		switch {
+		case int32(c[0]) < int32(c[1]):
+			return c[1]
+		case int32(c[0]) > int32(c[2]):
+			return c[2]
+		}
+		return c[0]
+	},
+	glslFMix: floatOp(func(c ...float64) float64 { return c[0]*(1-c[2]) + c[1]*c[2] }),
+	glslStep: floatOp(func(c ...float64) float64 {
+		if c[1] < c[0] {
+			return 0
+		}
+		return 1
+	}),
+	glslSmoothStep: floatOp(func(c ...float64) float64 {
+		t := clamp((c[2]-c[0])/(c[1]-c[0]), 0, 1)
+		return t * t * (3 - 2*t)
+	}),
+	glslFma:   floatOp(func(c ...float64) float64 { return c[0]*c[1] + c[2] }),
+	glslLdexp: func(c ...uint32) uint32 { return fromFloat(math.Ldexp(toFloat(c[0]), int(int32(c[1])))) },
+	glslFindILsb: func(c ...uint32) uint32 {
+		if c[0] == 0 {
+			return math.MaxUint32
+		}
+		return uint32(bits.TrailingZeros32(c[0]))
+	},
+	glslFindSMsb: func(c ...uint32) uint32 {
+		v := c[0]
+		if int32(v) < 0 {
+			v = ^v
+		}
+		return uint32(31 - bits.LeadingZeros32(v))
+	},
+	glslFindUMsb: func(c ...uint32) uint32 { return uint32(31 - bits.LeadingZeros32(c[0])) },
+}
+
+// glslStd450 returns the result of type ty of the GLSL.std.450 extended
+// instruction inst applied to args.
+func glslStd450(ty *Type, inst uint32, args []Value) (Value, error) {
+	if f, ok := glslComponentwise[inst]; ok {
+		return componentwise(ty, f, args...), nil
+	}
+	switch inst {
+	case glslLength:
+		return vector(ty, func(int) float64 { return math.Sqrt(dot(args[0], args[0])) }), nil
+	case glslDistance:
+		d := componentwise(args[0].Type, componentwiseOps[OpFSub], args[0], args[1])
+		return vector(ty, func(int) float64 { return math.Sqrt(dot(d, d)) }), nil
+	case glslNormalize:
+		l := math.Sqrt(dot(args[0], args[0]))
+		return vector(ty, func(i int) float64 { return toFloat(args[0].component(i)) / l }), nil
+	case glslCross:
+		a, b := func(i int) float64 { return toFloat(args[0].component(i)) }, func(i int) float64 { return toFloat(args[1].component(i)) }
+		return vector(ty, func(i int) float64 {
+			j, k := (i+1)%3, (i+2)%3
+			return a(j)*b(k) - a(k)*b(j)
+		}), nil
+	case glslFaceForward:
+		n, s := args[0], 1.0
+		if dot(args[2], args[1]) >= 0 {
+			s = -1
+		}
+		return vector(ty, func(i int) float64 { return s * toFloat(n.component(i)) }), nil
+	case glslReflect:
+		i, n := args[0], args[1]
+		d := dot(n, i)
+		return vector(ty, func(c int) float64 { return toFloat(i.component(c)) - 2*d*toFloat(n.component(c)) }), nil
+	case glslRefract:
+		i, n, eta := args[0], args[1], toFloat(args[2].Bits)
+		d := dot(n, i)
+		k := 1 - eta*eta*(1-d*d)
+		if k < 0 {
+			return vector(ty, func(int) float64 { return 0 }), nil
+		}
+		return vector(ty, func(c int) float64 {
+			return eta*toFloat(i.component(c)) - (eta*d+math.Sqrt(k))*toFloat(n.component(c))
+		}), nil
+	case glslDeterminant:
+		return Value{Type: ty, Bits: fromFloat(determinant(args[0]))}, nil
+	}
+	return Value{}, fmt.Errorf("Unsupported GLSL.std.450 instruction %d", inst)
+}
+
+// determinant returns the determinant of the square float matrix m.
+func determinant(m Value) float64 {
+	n := len(m.Elements)
+	at := func(c, r int) float64 { return toFloat(m.Elements[c].component(r)) }
+	if n == 1 {
+		return at(0, 0)
+	}
+	// Expand along the first row.
+	det := 0.0
+	for c := 0; c < n; c++ {
+		minor := Value{Elements: make([]Value, 0, n-1)}
+		for mc := 0; mc < n; mc++ {
+			if mc == c {
+				continue
+			}
+			column := Value{Elements: make([]Value, n-1)}
+			for r := 1; r < n; r++ {
+				column.Elements[r-1] = Value{Bits: fromFloat(at(mc, r))}
+			}
+			minor.Elements = append(minor.Elements, column)
+		}
+		det += math.Pow(-1, float64(c)) * at(c, 0) * determinant(minor)
+	}
+	return det
+}
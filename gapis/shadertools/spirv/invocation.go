@@ -0,0 +1,611 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spirv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Step is an instruction executed by an Invocation.
+type Step struct {
+	// Instruction is the index of the instruction in the module.
+	Instruction int
+	// Line is the source line of the instruction, or 0 if it is not known.
+	Line uint32
+	// Result is the result id of the instruction, or the id of the pointer
+	// stored to by stores. It is 0 if the instruction has neither.
+	Result uint32
+	// Name is the debug name of Result.
+	Name string
+	// Value is the result of the instruction, or the value stored by stores.
+	Value *Value
+	// Note describes the approximation made to emulate the instruction, if
+	// any.
+	Note string
+}
+
+// frame is a function call of an invocation.
+type frame struct {
+	// pc is the index of the next instruction to execute.
+	pc int
+	// block and prev are the labels of the current and previous blocks.
+	block, prev uint32
+	// result is the id that the value returned by the function is assigned
+	// to in the calling function.
+	result uint32
+}
+
+// Invocation is a single invocation of an entry point of a module.
+type Invocation struct {
+	// Discarded is true if the invocation was discarded by OpKill.
+	Discarded bool
+
+	m      *Module
+	values map[uint32]Value
+	names  map[uint32]string
+	frames []*frame
+	line   uint32
+}
+
+// NewInvocation returns an invocation of the entry point of m with the given
+// name, with all the variables without initializers set to zero.
+func NewInvocation(m *Module, entryPoint string) (*Invocation, error) {
+	fn, ok := m.entryPoints[entryPoint]
+	if !ok {
+		return nil, fmt.Errorf("No entry point '%v'", entryPoint)
+	}
+	in := &Invocation{m: m, values: map[uint32]Value{}, names: map[uint32]string{}}
+	for id, c := range m.constants {
+		in.values[id] = c
+	}
+	for id, n := range m.names {
+		in.names[id] = n
+	}
+	for _, i := range m.globals {
+		if _, err := in.variable(m.Instructions[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := in.call(fn, nil, 0); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// Done returns true if the invocation has returned from its entry point or
+// has been discarded.
+func (in *Invocation) Done() bool {
+	return len(in.frames) == 0
+}
+
+// Set sets the module scope variable with the given name, or the member with
+// the given name of a module scope variable of struct type, to the Go value v.
+// v can be a bool, integer or float scalar or a possibly nested slice of
+// those, and its scalars are assigned to the scalars of the variable in order.
+func (in *Invocation) Set(name string, v interface{}) error {
+	ptr := in.lookup(name)
+	if ptr == nil {
+		return fmt.Errorf("No variable '%v'", name)
+	}
+	bits, err := scalarBits(reflect.ValueOf(v))
+	if err != nil {
+		return err
+	}
+	ptr.set(bits)
+	return nil
+}
+
+// Get returns the value of the module scope variable, or struct member of a
+// module scope variable, with the given name.
+func (in *Invocation) Get(name string) (Value, bool) {
+	if ptr := in.lookup(name); ptr != nil {
+		return *ptr, true
+	}
+	return Value{}, false
+}
+
+func (in *Invocation) lookup(name string) *Value {
+	for _, i := range in.m.globals {
+		if id := in.m.Instructions[i].Result(); in.m.names[id] == name {
+			return in.values[id].Pointer
+		}
+	}
+	for _, i := range in.m.globals {
+		inst := in.m.Instructions[i]
+		ptr := in.values[inst.Result()].Pointer
+		for member, n := range in.m.memberNames[in.m.pointees[inst.ResultType()]] {
+			if n == name && ptr.Type.Kind == KindStruct && int(member) < len(ptr.Elements) {
+				return &ptr.Elements[member]
+			}
+		}
+	}
+	return nil
+}
+
+// Step executes the next instruction of the invocation, skipping the
+// instructions that only carry debug or structural information. It returns
+// nil if the invocation is done.
+func (in *Invocation) Step() (*Step, error) {
+	for !in.Done() {
+		f := in.frames[len(in.frames)-1]
+		if f.pc >= len(in.m.Instructions) {
+			return nil, fmt.Errorf("Execution ran past the end of the module")
+		}
+		index, inst := f.pc, in.m.Instructions[f.pc]
+		f.pc++
+		switch inst.Opcode {
+		case OpLine:
+			if len(inst.Operands) > 1 {
+				in.line = inst.Operands[1]
+			}
+			continue
+		case OpNoLine:
+			in.line = 0
+			continue
+		case OpLabel:
+			f.prev, f.block = f.block, inst.Operands[0]
+			continue
+		case OpNop, OpSelectionMerge, OpLoopMerge:
+			continue
+		}
+		step := &Step{Instruction: index, Line: in.line}
+		if err := in.execute(f, inst, step); err != nil {
+			return nil, fmt.Errorf("Instruction %d (%v): %v", index, inst, err)
+		}
+		return step, nil
+	}
+	return nil, nil
+}
+
+func (in *Invocation) value(id uint32) (Value, error) {
+	if v, ok := in.values[id]; ok {
+		return v, nil
+	}
+	return Value{}, fmt.Errorf("Undefined value %%%d", id)
+}
+
+func (in *Invocation) operands(ids []uint32) ([]Value, error) {
+	out := make([]Value, len(ids))
+	for i, id := range ids {
+		v, err := in.value(id)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// variable allocates the variable declared by the OpVariable instruction.
+func (in *Invocation) variable(inst Instruction) (Value, error) {
+	ops := inst.Operands
+	t, ok := in.m.types[ops[0]]
+	if !ok || t.Kind != KindPointer {
+		return Value{}, fmt.Errorf("Variable %%%d is not of a pointer type", ops[1])
+	}
+	pointee := Zero(t.Element)
+	if len(ops) > 3 {
+		init, err := in.value(ops[3])
+		if err != nil {
+			return Value{}, err
+		}
+		pointee = init.clone()
+	}
+	v := Value{Type: t, Pointer: &pointee}
+	in.values[ops[1]] = v
+	return v, nil
+}
+
+// call starts the call of the function fn with the arguments args, whose
+// return value is assigned to result.
+func (in *Invocation) call(fn uint32, args []Value, result uint32) error {
+	at, ok := in.m.functions[fn]
+	if !ok {
+		return fmt.Errorf("Undefined function %%%d", fn)
+	}
+	pc := at + 1
+	for _, arg := range args {
+		if pc >= len(in.m.Instructions) || in.m.Instructions[pc].Opcode != OpFunctionParameter {
+			return fmt.Errorf("Too many arguments to function %%%d", fn)
+		}
+		in.values[in.m.Instructions[pc].Result()] = arg
+		pc++
+	}
+	in.frames = append(in.frames, &frame{pc: pc, result: result})
+	return nil
+}
+
+// ret returns from the current function call, with the value v if not nil.
+func (in *Invocation) ret(v *Value) {
+	f := in.frames[len(in.frames)-1]
+	in.frames = in.frames[:len(in.frames)-1]
+	if v != nil && len(in.frames) > 0 {
+		in.values[f.result] = *v
+	}
+}
+
+// branch continues the execution at the block with the label id.
+func (in *Invocation) branch(f *frame, label uint32) error {
+	at, ok := in.m.labels[label]
+	if !ok {
+		return fmt.Errorf("Undefined label %%%d", label)
+	}
+	f.pc = at
+	return nil
+}
+
+// index returns the element of the composite v at the index i.
+func index(v *Value, i int) (*Value, error) {
+	if i < 0 || i >= len(v.Elements) {
+		return nil, fmt.Errorf("Index %d out of bounds of %v", i, v.Type)
+	}
+	return &v.Elements[i], nil
+}
+
+func (in *Invocation) execute(f *frame, inst Instruction, step *Step) error {
+	ops := inst.Operands
+	if r := inst.Result(); r != 0 {
+		step.Result, step.Name = r, in.names[r]
+	}
+	ty := in.m.types[inst.ResultType()]
+	result := func(v Value) error {
+		in.values[ops[1]] = v
+		step.Value = &v
+		return nil
+	}
+
+	if op, ok := componentwiseOps[inst.Opcode]; ok {
+		args, err := in.operands(ops[2:])
+		if err != nil {
+			return err
+		}
+		return result(componentwise(ty, op, args...))
+	}
+
+	switch inst.Opcode {
+	case OpUndef:
+		return result(Zero(ty))
+
+	case OpVariable:
+		v, err := in.variable(inst)
+		if err != nil {
+			return err
+		}
+		step.Value = v.Pointer
+		return nil
+
+	case OpLoad:
+		ptr, err := in.value(ops[2])
+		if err != nil {
+			return err
+		}
+		return result(ptr.Pointer.clone())
+
+	case OpStore, OpCopyMemory:
+		ptr, err := in.value(ops[0])
+		if err != nil {
+			return err
+		}
+		v, err := in.value(ops[1])
+		if err != nil {
+			return err
+		}
+		if inst.Opcode == OpCopyMemory {
+			v = *v.Pointer
+		}
+		ptr.Pointer.assign(v)
+		step.Result, step.Name, step.Value = ops[0], in.names[ops[0]], ptr.Pointer
+		return nil
+
+	case OpAccessChain, OpInBoundsAccessChain:
+		base, err := in.value(ops[2])
+		if err != nil {
+			return err
+		}
+		ptr, name := base.Pointer, in.names[ops[2]]
+		for _, id := range ops[3:] {
+			i, err := in.value(id)
+			if err != nil {
+				return err
+			}
+			if ptr.Type.Kind == KindStruct {
+				member := fmt.Sprint(i.Bits)
+				if n, ok := in.memberName(ptr.Type, i.Bits); ok {
+					member = n
+				}
+				name = fmt.Sprintf("%v.%v", name, member)
+			} else {
+				name = fmt.Sprintf("%v[%d]", name, int32(i.Bits))
+			}
+			if ptr, err = index(ptr, int(int32(i.Bits))); err != nil {
+				return err
+			}
+		}
+		in.names[ops[1]], step.Name = name, name
+		return result(Value{Type: ty, Pointer: ptr})
+
+	case OpCopyObject:
+		v, err := in.value(ops[2])
+		if err != nil {
+			return err
+		}
+		return result(v.clone())
+
+	case OpCompositeConstruct:
+		args, err := in.operands(ops[2:])
+		if err != nil {
+			return err
+		}
+		out := Value{Type: ty}
+		if ty.Kind == KindVector {
+			for _, a := range args {
+				for i := 0; i < size(a); i++ {
+					out.Elements = append(out.Elements, Value{Type: ty.Element, Bits: a.component(i)})
+				}
+			}
+		} else {
+			for _, a := range args {
+				out.Elements = append(out.Elements, a.clone())
+			}
+		}
+		return result(out)
+
+	case OpCompositeExtract:
+		v, err := in.value(ops[2])
+		if err != nil {
+			return err
+		}
+		el := &v
+		for _, i := range ops[3:] {
+			if el, err = index(el, int(i)); err != nil {
+				return err
+			}
+		}
+		return result(el.clone())
+
+	case OpCompositeInsert:
+		obj, err := in.value(ops[2])
+		if err != nil {
+			return err
+		}
+		v, err := in.value(ops[3])
+		if err != nil {
+			return err
+		}
+		out := v.clone()
+		el := &out
+		for _, i := range ops[4:] {
+			if el, err = index(el, int(i)); err != nil {
+				return err
+			}
+		}
+		*el = obj.clone()
+		return result(out)
+
+	case OpVectorShuffle:
+		args, err := in.operands(ops[2:4])
+		if err != nil {
+			return err
+		}
+		components := append(args[0].clone().Elements, args[1].clone().Elements...)
+		out := Value{Type: ty}
+		for _, i := range ops[4:] {
+			if int(i) < len(components) {
+				out.Elements = append(out.Elements, components[i])
+			} else {
+				out.Elements = append(out.Elements, Zero(ty.Element)) // Undefined component.
+			}
+		}
+		return result(out)
+
+	case OpVectorExtractDynamic:
+		args, err := in.operands(ops[2:4])
+		if err != nil {
+			return err
+		}
+		el, err := index(&args[0], int(int32(args[1].Bits)))
+		if err != nil {
+			return err
+		}
+		return result(*el)
+
+	case OpVectorInsertDynamic:
+		args, err := in.operands(ops[2:5])
+		if err != nil {
+			return err
+		}
+		out := args[0].clone()
+		el, err := index(&out, int(int32(args[2].Bits)))
+		if err != nil {
+			return err
+		}
+		*el = args[1]
+		return result(out)
+
+	case OpTranspose:
+		m, err := in.value(ops[2])
+		if err != nil {
+			return err
+		}
+		return result(matrix(ty, func(c, r int) float64 { return toFloat(m.Elements[r].component(c)) }))
+
+	case OpMatrixTimesScalar:
+		args, err := in.operands(ops[2:4])
+		if err != nil {
+			return err
+		}
+		s := toFloat(args[1].Bits)
+		return result(matrix(ty, func(c, r int) float64 { return toFloat(args[0].Elements[c].component(r)) * s }))
+
+	case OpVectorTimesMatrix:
+		args, err := in.operands(ops[2:4])
+		if err != nil {
+			return err
+		}
+		return result(vector(ty, func(c int) float64 { return dot(args[0], args[1].Elements[c]) }))
+
+	case OpMatrixTimesVector:
+		args, err := in.operands(ops[2:4])
+		if err != nil {
+			return err
+		}
+		return result(matrixTimesVector(ty, args[0], args[1]))
+
+	case OpMatrixTimesMatrix:
+		args, err := in.operands(ops[2:4])
+		if err != nil {
+			return err
+		}
+		out := Value{Type: ty, Elements: make([]Value, ty.Count)}
+		for c := range out.Elements {
+			out.Elements[c] = matrixTimesVector(ty.Element, args[0], args[1].Elements[c])
+		}
+		return result(out)
+
+	case OpOuterProduct:
+		args, err := in.operands(ops[2:4])
+		if err != nil {
+			return err
+		}
+		return result(matrix(ty, func(c, r int) float64 {
+			return toFloat(args[0].component(r)) * toFloat(args[1].component(c))
+		}))
+
+	case OpDot:
+		args, err := in.operands(ops[2:4])
+		if err != nil {
+			return err
+		}
+		return result(Value{Type: ty, Bits: fromFloat(dot(args[0], args[1]))})
+
+	case OpAny, OpAll:
+		v, err := in.value(ops[2])
+		if err != nil {
+			return err
+		}
+		any, all := false, true
+		for i := 0; i < size(v); i++ {
+			any, all = any || v.component(i) != 0, all && v.component(i) != 0
+		}
+		if inst.Opcode == OpAny {
+			return result(Value{Type: ty, Bits: fromBool(any)})
+		}
+		return result(Value{Type: ty, Bits: fromBool(all)})
+
+	case OpExtInst:
+		if ops[2] != in.m.glslStd450 {
+			return fmt.Errorf("Unsupported extended instruction set")
+		}
+		args, err := in.operands(ops[4:])
+		if err != nil {
+			return err
+		}
+		v, err := glslStd450(ty, ops[3], args)
+		if err != nil {
+			return err
+		}
+		return result(v)
+
+	case OpSampledImage, OpImage:
+		return result(Value{Type: ty})
+
+	case OpPhi:
+		for i := 2; i+1 < len(ops); i += 2 {
+			if ops[i+1] == f.prev {
+				v, err := in.value(ops[i])
+				if err != nil {
+					return err
+				}
+				return result(v)
+			}
+		}
+		return fmt.Errorf("No incoming value from block %%%d", f.prev)
+
+	case OpFunctionCall:
+		args, err := in.operands(ops[3:])
+		if err != nil {
+			return err
+		}
+		step.Name = in.names[ops[2]]
+		return in.call(ops[2], args, ops[1])
+
+	case OpBranch:
+		return in.branch(f, ops[0])
+
+	case OpBranchConditional:
+		cond, err := in.value(ops[0])
+		if err != nil {
+			return err
+		}
+		step.Value = &cond
+		if cond.Bits != 0 {
+			return in.branch(f, ops[1])
+		}
+		return in.branch(f, ops[2])
+
+	case OpSwitch:
+		selector, err := in.value(ops[0])
+		if err != nil {
+			return err
+		}
+		step.Value = &selector
+		for i := 2; i+1 < len(ops); i += 2 {
+			if ops[i] == selector.Bits {
+				return in.branch(f, ops[i+1])
+			}
+		}
+		return in.branch(f, ops[1])
+
+	case OpReturn:
+		in.ret(nil)
+		return nil
+
+	case OpReturnValue:
+		v, err := in.value(ops[0])
+		if err != nil {
+			return err
+		}
+		step.Value = &v
+		in.ret(&v)
+		return nil
+
+	case OpKill:
+		in.frames, in.Discarded = nil, true
+		return nil
+	}
+
+	switch op := inst.Opcode; {
+	case op >= OpImageSampleImplicitLod && op <= OpImageRead,
+		op >= OpImageQuerySizeLod && op <= OpImageQuerySamples:
+		step.Note = "Image accesses are not emulated and return zero"
+		return result(Zero(ty))
+	case op >= OpDPdx && op <= OpFwidthCoarse:
+		step.Note = "Derivatives cannot be emulated for a single invocation and return zero"
+		return result(Zero(ty))
+	}
+	return fmt.Errorf("Unsupported instruction")
+}
+
+// memberName returns the debug name of the member of the struct type t.
+func (in *Invocation) memberName(t *Type, member uint32) (string, bool) {
+	for id, ty := range in.m.types {
+		if ty == t {
+			n, ok := in.m.memberNames[id][member]
+			return n, ok
+		}
+	}
+	return "", false
+}
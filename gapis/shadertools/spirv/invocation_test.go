@@ -0,0 +1,141 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spirv_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/shadertools/spirv"
+)
+
+// module assembles a SPIR-V module from its instructions, each given as the
+// opcode followed by the operands.
+func module(insts ...[]uint32) []uint32 {
+	words := []uint32{0x07230203, 0x00010000, 0, 100, 0}
+	for _, inst := range insts {
+		words = append(words, uint32(len(inst))<<16|inst[0])
+		words = append(words, inst[1:]...)
+	}
+	return words
+}
+
+func inst(op uint32, operands ...uint32) []uint32 {
+	return append([]uint32{op}, operands...)
+}
+
+// str returns the words of the string literal s.
+func str(s string) []uint32 {
+	words := make([]uint32, len(s)/4+1)
+	for i := 0; i < len(s); i++ {
+		words[i/4] |= uint32(s[i]) << (8 * uint(i%4))
+	}
+	return words
+}
+
+func named(op uint32, id uint32, name string) []uint32 {
+	return append([]uint32{op, id}, str(name)...)
+}
+
+// fragment is the module of the fragment shader:
+//
+//	in float x;
+//	out float color;
+//	void main() {
+//	  if (x < 0.5) { color = x * 2.0; } else { discard; }
+//	}
+var fragment = module(
+	inst(spirv.OpCapability, 1),
+	inst(spirv.OpMemoryModel, 0, 1),
+	append(inst(spirv.OpEntryPoint, 4, 11), append(str("main"), 6, 7)...),
+	named(spirv.OpName, 6, "x"),
+	named(spirv.OpName, 7, "color"),
+	named(spirv.OpName, 18, "product"),
+	inst(spirv.OpTypeVoid, 1),
+	inst(spirv.OpTypeFunction, 2, 1),
+	inst(spirv.OpTypeFloat, 3, 32),
+	inst(spirv.OpTypePointer, 4, spirv.StorageInput, 3),
+	inst(spirv.OpTypePointer, 5, spirv.StorageOutput, 3),
+	inst(spirv.OpVariable, 4, 6, spirv.StorageInput),
+	inst(spirv.OpVariable, 5, 7, spirv.StorageOutput),
+	inst(spirv.OpConstant, 3, 8, math.Float32bits(2)),
+	inst(spirv.OpConstant, 3, 9, math.Float32bits(0.5)),
+	inst(spirv.OpTypeBool, 10),
+	inst(spirv.OpFunction, 1, 11, 0, 2),
+	inst(spirv.OpLabel, 12),
+	inst(spirv.OpLoad, 3, 13, 6),
+	inst(spirv.OpFOrdLessThan, 10, 14, 13, 9),
+	inst(spirv.OpSelectionMerge, 16, 0),
+	inst(spirv.OpBranchConditional, 14, 15, 17),
+	inst(spirv.OpLabel, 15),
+	inst(spirv.OpFMul, 3, 18, 13, 8),
+	inst(spirv.OpStore, 7, 18),
+	inst(spirv.OpReturn),
+	inst(spirv.OpLabel, 17),
+	inst(spirv.OpKill),
+	inst(spirv.OpLabel, 16),
+	inst(spirv.OpReturn),
+	inst(spirv.OpFunctionEnd),
+)
+
+func TestInvocation(t *testing.T) {
+	ctx := log.Testing(t)
+	m, err := spirv.Parse(fragment)
+	if !assert.For(ctx, "Parse").ThatError(err).Succeeded() {
+		return
+	}
+
+	for _, test := range []struct {
+		x         float32
+		steps     []string
+		color     float32
+		discarded bool
+	}{
+		{0.25, []string{"", "", "", "product", "color", ""}, 0.5, false},
+		{0.75, []string{"", "", "", ""}, 0, true},
+	} {
+		in, err := spirv.NewInvocation(m, "main")
+		if !assert.For(ctx, "NewInvocation").ThatError(err).Succeeded() {
+			return
+		}
+		assert.For(ctx, "Set").ThatError(in.Set("x", test.x)).Succeeded()
+
+		steps := []string{}
+		for !in.Done() {
+			step, err := in.Step()
+			if !assert.For(ctx, "Step").ThatError(err).Succeeded() {
+				return
+			}
+			steps = append(steps, step.Name)
+		}
+		assert.For(ctx, "steps").ThatSlice(steps).Equals(test.steps)
+		assert.For(ctx, "discarded").That(in.Discarded).Equals(test.discarded)
+		if !test.discarded {
+			color, _ := in.Get("color")
+			assert.For(ctx, "color").That(color.Float()).Equals(test.color)
+		}
+	}
+}
+
+func TestUnknownEntryPoint(t *testing.T) {
+	ctx := log.Testing(t)
+	m, err := spirv.Parse(fragment)
+	if assert.For(ctx, "Parse").ThatError(err).Succeeded() {
+		_, err := spirv.NewInvocation(m, "other")
+		assert.For(ctx, "NewInvocation").ThatError(err).Failed()
+	}
+}
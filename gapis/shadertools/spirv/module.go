@@ -0,0 +1,419 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spirv implements an interpreter of SPIR-V shader modules, executing
+// a single shader invocation one instruction at a time.
+package spirv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// magic is the first word of every SPIR-V module.
+const magic = 0x07230203
+
+// Instruction is a single instruction of a SPIR-V module.
+type Instruction struct {
+	// Opcode is the opcode of the instruction.
+	Opcode uint32
+	// Operands are the words following the first word of the instruction.
+	Operands []uint32
+}
+
+// hasResultOnly returns true if the first operand of instructions with the
+// opcode op is a result id not preceded by a result type.
+func hasResultOnly(op uint32) bool {
+	switch {
+	case op >= OpTypeVoid && op <= OpTypeFunction,
+		op == OpLabel, op == OpExtInstImport, op == OpString, op == OpDecorationGroup:
+		return true
+	}
+	return false
+}
+
+// hasNoResult returns true if instructions with the opcode op have no result.
+func hasNoResult(op uint32) bool {
+	switch op {
+	case OpNop, OpSourceContinued, OpSource, OpSourceExtension, OpName,
+		OpMemberName, OpLine, OpExtension, OpMemoryModel, OpEntryPoint,
+		OpExecutionMode, OpCapability, OpFunctionEnd, OpStore, OpCopyMemory,
+		OpDecorate, OpMemberDecorate, OpLoopMerge, OpSelectionMerge, OpBranch,
+		OpBranchConditional, OpSwitch, OpKill, OpReturn, OpReturnValue,
+		OpUnreachable, OpNoLine, OpModuleProcessed:
+		return true
+	}
+	return false
+}
+
+// ResultType returns the id of the result type of the instruction, or 0 if
+// it has none.
+func (i Instruction) ResultType() uint32 {
+	if hasNoResult(i.Opcode) || hasResultOnly(i.Opcode) || len(i.Operands) < 2 {
+		return 0
+	}
+	return i.Operands[0]
+}
+
+// Result returns the result id of the instruction, or 0 if it has none.
+func (i Instruction) Result() uint32 {
+	switch {
+	case hasNoResult(i.Opcode) || len(i.Operands) == 0:
+		return 0
+	case hasResultOnly(i.Opcode):
+		return i.Operands[0]
+	case len(i.Operands) < 2:
+		return 0
+	}
+	return i.Operands[1]
+}
+
+// String returns the instruction in the SPIR-V assembly syntax, with all the
+// operands printed as ids.
+func (i Instruction) String() string {
+	sb := strings.Builder{}
+	operands := i.Operands
+	switch r := i.Result(); {
+	case r == 0:
+	case hasResultOnly(i.Opcode):
+		fmt.Fprintf(&sb, "%%%d = ", r)
+		operands = operands[1:]
+	default:
+		fmt.Fprintf(&sb, "%%%d = ", r)
+		operands = append([]uint32{operands[0]}, operands[2:]...)
+	}
+	sb.WriteString(OpcodeName(i.Opcode))
+	for _, o := range operands {
+		fmt.Fprintf(&sb, " %%%d", o)
+	}
+	return sb.String()
+}
+
+// Module is a parsed SPIR-V module.
+type Module struct {
+	// Instructions are the instructions of the module, in order.
+	Instructions []Instruction
+
+	names       map[uint32]string
+	memberNames map[uint32]map[uint32]string
+	builtins    map[uint32]uint32
+	bindings    map[uint32]Binding
+	types       map[uint32]*Type
+	constants   map[uint32]Value
+	pointees    map[uint32]uint32
+	globals     []int
+	entryPoints map[string]uint32
+	functions   map[uint32]int
+	labels      map[uint32]int
+	glslStd450  uint32
+}
+
+// Parse parses the SPIR-V module from its words.
+func Parse(words []uint32) (*Module, error) {
+	if len(words) < 5 || words[0] != magic {
+		return nil, fmt.Errorf("Not a SPIR-V module")
+	}
+	m := &Module{
+		names:       map[uint32]string{},
+		memberNames: map[uint32]map[uint32]string{},
+		builtins:    map[uint32]uint32{},
+		bindings:    map[uint32]Binding{},
+		types:       map[uint32]*Type{},
+		constants:   map[uint32]Value{},
+		entryPoints: map[string]uint32{},
+		functions:   map[uint32]int{},
+		labels:      map[uint32]int{},
+		pointees:    map[uint32]uint32{},
+	}
+	for at := 5; at < len(words); {
+		count, op := int(words[at]>>16), words[at]&0xffff
+		if count == 0 || at+count > len(words) {
+			return nil, fmt.Errorf("Invalid word count %d of instruction at word %d", count, at)
+		}
+		inst := Instruction{Opcode: op, Operands: words[at+1 : at+count]}
+		if err := m.declare(len(m.Instructions), inst); err != nil {
+			return nil, fmt.Errorf("Instruction %d (%v): %v", len(m.Instructions), inst, err)
+		}
+		m.Instructions = append(m.Instructions, inst)
+		at += count
+	}
+	return m, nil
+}
+
+// declare records the declarations made by the instruction at index.
+func (m *Module) declare(index int, inst Instruction) error {
+	ops := inst.Operands
+	if len(ops) < operandCount(inst.Opcode) {
+		return fmt.Errorf("Too few operands")
+	}
+	switch inst.Opcode {
+	case OpName:
+		m.names[ops[0]], _ = literalString(ops[1:])
+	case OpMemberName:
+		if m.memberNames[ops[0]] == nil {
+			m.memberNames[ops[0]] = map[uint32]string{}
+		}
+		m.memberNames[ops[0]][ops[1]], _ = literalString(ops[2:])
+	case OpDecorate:
+		if len(ops) > 2 {
+			switch ops[1] {
+			case decorationBuiltIn:
+				m.builtins[ops[0]] = ops[2]
+			case decorationBinding:
+				b := m.bindings[ops[0]]
+				b.Binding = ops[2]
+				m.bindings[ops[0]] = b
+			case decorationDescriptorSet:
+				b := m.bindings[ops[0]]
+				b.Set = ops[2]
+				m.bindings[ops[0]] = b
+			}
+		}
+	case OpEntryPoint:
+		name, _ := literalString(ops[2:])
+		m.entryPoints[name] = ops[1]
+	case OpExtInstImport:
+		if name, _ := literalString(ops[1:]); name == "GLSL.std.450" {
+			m.glslStd450 = ops[0]
+		}
+	case OpFunction:
+		m.functions[ops[1]] = index
+	case OpLabel:
+		m.labels[ops[0]] = index
+	case OpVariable:
+		if ops[2] != StorageFunction {
+			m.globals = append(m.globals, index)
+		}
+	case OpTypeVoid, OpTypeBool, OpTypeInt, OpTypeFloat, OpTypeVector,
+		OpTypeMatrix, OpTypeImage, OpTypeSampler, OpTypeSampledImage,
+		OpTypeArray, OpTypeRuntimeArray, OpTypeStruct, OpTypeOpaque,
+		OpTypePointer, OpTypeFunction:
+		t, err := m.declareType(inst)
+		if err != nil {
+			return err
+		}
+		m.types[ops[0]] = t
+		if inst.Opcode == OpTypePointer {
+			m.pointees[ops[0]] = ops[2]
+		}
+	case OpConstantTrue, OpConstantFalse, OpConstant, OpConstantComposite,
+		OpConstantNull, OpSpecConstantTrue, OpSpecConstantFalse,
+		OpSpecConstant, OpSpecConstantComposite, OpUndef:
+		if inst.Opcode == OpUndef && m.inFunction(index) {
+			return nil // Function scope undefined values are made on execution.
+		}
+		c, err := m.declareConstant(inst)
+		if err != nil {
+			return err
+		}
+		m.constants[ops[1]] = c
+	}
+	return nil
+}
+
+// inFunction returns true if the instruction at index is in a function body.
+func (m *Module) inFunction(index int) bool {
+	for i := index - 1; i >= 0; i-- {
+		switch m.Instructions[i].Opcode {
+		case OpFunctionEnd:
+			return false
+		case OpFunction:
+			return true
+		}
+	}
+	return false
+}
+
+// operandCount returns the minimum number of operands of the instructions
+// with the opcode op that are used by the interpreter.
+func operandCount(op uint32) int {
+	switch op {
+	case OpName, OpDecorate, OpExtInstImport, OpTypeFloat, OpTypeFunction:
+		return 2
+	case OpMemberName, OpMemberDecorate, OpEntryPoint, OpVariable,
+		OpTypeInt, OpTypeVector, OpTypeMatrix, OpTypeArray, OpTypePointer:
+		return 3
+	case OpFunction:
+		return 4
+	case OpTypeVoid, OpTypeBool, OpTypeImage, OpTypeSampler,
+		OpTypeSampledImage, OpTypeRuntimeArray, OpTypeStruct, OpTypeOpaque,
+		OpLabel:
+		return 1
+	}
+	if !hasNoResult(op) && !hasResultOnly(op) {
+		return 2
+	}
+	return 0
+}
+
+func (m *Module) declareType(inst Instruction) (*Type, error) {
+	ops := inst.Operands
+	lookup := func(id uint32) (*Type, error) {
+		if t, ok := m.types[id]; ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("Undeclared type %%%d", id)
+	}
+	switch inst.Opcode {
+	case OpTypeVoid:
+		return &Type{Kind: KindVoid}, nil
+	case OpTypeBool:
+		return &Type{Kind: KindBool}, nil
+	case OpTypeInt:
+		if ops[1] != 32 {
+			return &Type{Kind: KindOpaque, Name: fmt.Sprintf("int%d", ops[1])}, nil
+		}
+		return &Type{Kind: KindInt, Signed: len(ops) > 2 && ops[2] != 0}, nil
+	case OpTypeFloat:
+		if ops[1] != 32 {
+			return &Type{Kind: KindOpaque, Name: fmt.Sprintf("float%d", ops[1])}, nil
+		}
+		return &Type{Kind: KindFloat}, nil
+	case OpTypeVector, OpTypeMatrix:
+		el, err := lookup(ops[1])
+		if err != nil {
+			return nil, err
+		}
+		kind := KindVector
+		if inst.Opcode == OpTypeMatrix {
+			kind = KindMatrix
+		}
+		return &Type{Kind: kind, Element: el, Count: int(ops[2])}, nil
+	case OpTypeArray:
+		el, err := lookup(ops[1])
+		if err != nil {
+			return nil, err
+		}
+		length, ok := m.constants[ops[2]]
+		if !ok {
+			return nil, fmt.Errorf("Undeclared array length %%%d", ops[2])
+		}
+		return &Type{Kind: KindArray, Element: el, Count: int(length.Bits)}, nil
+	case OpTypeStruct:
+		t := &Type{Kind: KindStruct}
+		for _, id := range ops[1:] {
+			member, err := lookup(id)
+			if err != nil {
+				return nil, err
+			}
+			t.Members = append(t.Members, member)
+		}
+		return t, nil
+	case OpTypePointer:
+		el, err := lookup(ops[2])
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Kind: KindPointer, Element: el, StorageClass: ops[1]}, nil
+	case OpTypeFunction:
+		return &Type{Kind: KindFunction}, nil
+	case OpTypeImage, OpTypeSampledImage:
+		return &Type{Kind: KindOpaque, Name: "image"}, nil
+	case OpTypeSampler:
+		return &Type{Kind: KindOpaque, Name: "sampler"}, nil
+	default:
+		return &Type{Kind: KindOpaque, Name: OpcodeName(inst.Opcode)[6:]}, nil
+	}
+}
+
+func (m *Module) declareConstant(inst Instruction) (Value, error) {
+	ops := inst.Operands
+	t, ok := m.types[ops[0]]
+	if !ok {
+		return Value{}, fmt.Errorf("Undeclared type %%%d", ops[0])
+	}
+	switch inst.Opcode {
+	case OpConstantTrue, OpSpecConstantTrue:
+		return Value{Type: t, Bits: 1}, nil
+	case OpConstant, OpSpecConstant:
+		if len(ops) < 3 {
+			return Value{}, fmt.Errorf("Missing constant value")
+		}
+		return Value{Type: t, Bits: ops[2]}, nil
+	case OpConstantComposite, OpSpecConstantComposite:
+		v := Value{Type: t}
+		for _, id := range ops[2:] {
+			c, ok := m.constants[id]
+			if !ok {
+				return Value{}, fmt.Errorf("Undeclared constant %%%d", id)
+			}
+			v.Elements = append(v.Elements, c)
+		}
+		return v, nil
+	default:
+		return Zero(t), nil
+	}
+}
+
+// Name returns the debug name of the id, or an empty string if it has none.
+func (m *Module) Name(id uint32) string {
+	return m.names[id]
+}
+
+// Binding identifies a resource variable by its descriptor set and binding
+// numbers.
+type Binding struct {
+	Set     uint32
+	Binding uint32
+}
+
+// AccessedBindings returns the bindings of the resource variables that are
+// loaded, indexed or passed to a function by the functions of the module, in
+// the order they are first accessed. Resource variables that are declared but
+// never accessed are not returned.
+func (m *Module) AccessedBindings() []Binding {
+	out := []Binding{}
+	seen := map[uint32]bool{}
+	access := func(id uint32) {
+		if b, ok := m.bindings[id]; ok && !seen[id] {
+			seen[id] = true
+			out = append(out, b)
+		}
+	}
+	for _, inst := range m.Instructions {
+		ops := inst.Operands
+		switch inst.Opcode {
+		case OpLoad, OpAccessChain, OpInBoundsAccessChain, OpPtrAccessChain,
+			OpImageTexelPointer:
+			if len(ops) > 2 {
+				access(ops[2])
+			}
+		case OpCopyMemory:
+			if len(ops) > 1 {
+				access(ops[1])
+			}
+		case OpFunctionCall:
+			for i := 3; i < len(ops); i++ {
+				access(ops[i])
+			}
+		}
+	}
+	return out
+}
+
+// literalString decodes the nul-terminated string literal at the start of
+// words, returning the string and the number of words it occupies.
+func literalString(words []uint32) (string, int) {
+	sb := strings.Builder{}
+	for i, w := range words {
+		for j := uint(0); j < 4; j++ {
+			c := byte(w >> (8 * j))
+			if c == 0 {
+				return sb.String(), i + 1
+			}
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), len(words)
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spirv_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/shadertools/spirv"
+)
+
+func TestAccessedBindings(t *testing.T) {
+	ctx := log.Testing(t)
+	// layout(set = 0, binding = 1) uniform sampler2D unused;
+	// layout(set = 0, binding = 2) uniform sampler2D tex;
+	// layout(set = 1, binding = 0) uniform U { vec4 v; };
+	// void main() { v; tex; }
+	m, err := spirv.Parse(module(
+		inst(spirv.OpCapability, 1),
+		inst(spirv.OpMemoryModel, 0, 1),
+		append(inst(spirv.OpEntryPoint, 4, 20), str("main")...),
+		inst(spirv.OpDecorate, 6, 33, 1),
+		inst(spirv.OpDecorate, 6, 34, 0),
+		inst(spirv.OpDecorate, 7, 33, 2),
+		inst(spirv.OpDecorate, 7, 34, 0),
+		inst(spirv.OpDecorate, 9, 33, 0),
+		inst(spirv.OpDecorate, 9, 34, 1),
+		inst(spirv.OpTypeVoid, 1),
+		inst(spirv.OpTypeFunction, 2, 1),
+		inst(spirv.OpTypeFloat, 3, 32),
+		inst(spirv.OpTypeVector, 4, 3, 4),
+		inst(spirv.OpTypeImage, 10, 3, 1, 0, 0, 0, 1, 0),
+		inst(spirv.OpTypeSampledImage, 11, 10),
+		inst(spirv.OpTypePointer, 12, spirv.StorageUniformConstant, 11),
+		inst(spirv.OpVariable, 12, 6, spirv.StorageUniformConstant),
+		inst(spirv.OpVariable, 12, 7, spirv.StorageUniformConstant),
+		inst(spirv.OpTypeStruct, 13, 4),
+		inst(spirv.OpTypePointer, 14, spirv.StorageUniform, 13),
+		inst(spirv.OpVariable, 14, 9, spirv.StorageUniform),
+		inst(spirv.OpTypePointer, 15, spirv.StorageUniform, 4),
+		inst(spirv.OpTypeInt, 16, 32, 1),
+		inst(spirv.OpConstant, 16, 17, 0),
+		inst(spirv.OpFunction, 1, 20, 0, 2),
+		inst(spirv.OpLabel, 21),
+		inst(spirv.OpAccessChain, 15, 22, 9, 17),
+		inst(spirv.OpLoad, 4, 23, 22),
+		inst(spirv.OpLoad, 11, 24, 7),
+		inst(spirv.OpReturn),
+		inst(spirv.OpFunctionEnd),
+	))
+	if assert.For(ctx, "Parse").ThatError(err).Succeeded() {
+		assert.For(ctx, "AccessedBindings").ThatSlice(m.AccessedBindings()).Equals([]spirv.Binding{
+			{Set: 1, Binding: 0},
+			{Set: 0, Binding: 2},
+		})
+	}
+}
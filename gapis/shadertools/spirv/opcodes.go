@@ -0,0 +1,290 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spirv
+
+import "fmt"
+
+// The opcodes of the SPIR-V instructions known to the interpreter.
+const (
+	OpNop                            = 0
+	OpUndef                          = 1
+	OpSourceContinued                = 2
+	OpSource                         = 3
+	OpSourceExtension                = 4
+	OpName                           = 5
+	OpMemberName                     = 6
+	OpString                         = 7
+	OpLine                           = 8
+	OpExtension                      = 10
+	OpExtInstImport                  = 11
+	OpExtInst                        = 12
+	OpMemoryModel                    = 14
+	OpEntryPoint                     = 15
+	OpExecutionMode                  = 16
+	OpCapability                     = 17
+	OpTypeVoid                       = 19
+	OpTypeBool                       = 20
+	OpTypeInt                        = 21
+	OpTypeFloat                      = 22
+	OpTypeVector                     = 23
+	OpTypeMatrix                     = 24
+	OpTypeImage                      = 25
+	OpTypeSampler                    = 26
+	OpTypeSampledImage               = 27
+	OpTypeArray                      = 28
+	OpTypeRuntimeArray               = 29
+	OpTypeStruct                     = 30
+	OpTypeOpaque                     = 31
+	OpTypePointer                    = 32
+	OpTypeFunction                   = 33
+	OpConstantTrue                   = 41
+	OpConstantFalse                  = 42
+	OpConstant                       = 43
+	OpConstantComposite              = 44
+	OpConstantSampler                = 45
+	OpConstantNull                   = 46
+	OpSpecConstantTrue               = 48
+	OpSpecConstantFalse              = 49
+	OpSpecConstant                   = 50
+	OpSpecConstantComposite          = 51
+	OpFunction                       = 54
+	OpFunctionParameter              = 55
+	OpFunctionEnd                    = 56
+	OpFunctionCall                   = 57
+	OpVariable                       = 59
+	OpImageTexelPointer              = 60
+	OpLoad                           = 61
+	OpStore                          = 62
+	OpCopyMemory                     = 63
+	OpAccessChain                    = 65
+	OpInBoundsAccessChain            = 66
+	OpPtrAccessChain                 = 67
+	OpDecorate                       = 71
+	OpMemberDecorate                 = 72
+	OpDecorationGroup                = 73
+	OpVectorExtractDynamic           = 77
+	OpVectorInsertDynamic            = 78
+	OpVectorShuffle                  = 79
+	OpCompositeConstruct             = 80
+	OpCompositeExtract               = 81
+	OpCompositeInsert                = 82
+	OpCopyObject                     = 83
+	OpTranspose                      = 84
+	OpSampledImage                   = 86
+	OpImageSampleImplicitLod         = 87
+	OpImageSampleProjDrefExplicitLod = 94
+	OpImageFetch                     = 95
+	OpImageGather                    = 96
+	OpImageDrefGather                = 97
+	OpImageRead                      = 98
+	OpImage                          = 100
+	OpImageQuerySizeLod              = 103
+	OpImageQuerySize                 = 104
+	OpImageQueryLod                  = 105
+	OpImageQueryLevels               = 106
+	OpImageQuerySamples              = 107
+	OpConvertFToU                    = 109
+	OpConvertFToS                    = 110
+	OpConvertSToF                    = 111
+	OpConvertUToF                    = 112
+	OpUConvert                       = 113
+	OpSConvert                       = 114
+	OpFConvert                       = 115
+	OpQuantizeToF16                  = 116
+	OpBitcast                        = 124
+	OpSNegate                        = 126
+	OpFNegate                        = 127
+	OpIAdd                           = 128
+	OpFAdd                           = 129
+	OpISub                           = 130
+	OpFSub                           = 131
+	OpIMul                           = 132
+	OpFMul                           = 133
+	OpUDiv                           = 134
+	OpSDiv                           = 135
+	OpFDiv                           = 136
+	OpUMod                           = 137
+	OpSRem                           = 138
+	OpSMod                           = 139
+	OpFRem                           = 140
+	OpFMod                           = 141
+	OpVectorTimesScalar              = 142
+	OpMatrixTimesScalar              = 143
+	OpVectorTimesMatrix              = 144
+	OpMatrixTimesVector              = 145
+	OpMatrixTimesMatrix              = 146
+	OpOuterProduct                   = 147
+	OpDot                            = 148
+	OpAny                            = 154
+	OpAll                            = 155
+	OpIsNan                          = 156
+	OpIsInf                          = 157
+	OpLogicalEqual                   = 164
+	OpLogicalNotEqual                = 165
+	OpLogicalOr                      = 166
+	OpLogicalAnd                     = 167
+	OpLogicalNot                     = 168
+	OpSelect                         = 169
+	OpIEqual                         = 170
+	OpINotEqual                      = 171
+	OpUGreaterThan                   = 172
+	OpSGreaterThan                   = 173
+	OpUGreaterThanEqual              = 174
+	OpSGreaterThanEqual              = 175
+	OpULessThan                      = 176
+	OpSLessThan                      = 177
+	OpULessThanEqual                 = 178
+	OpSLessThanEqual                 = 179
+	OpFOrdEqual                      = 180
+	OpFUnordEqual                    = 181
+	OpFOrdNotEqual                   = 182
+	OpFUnordNotEqual                 = 183
+	OpFOrdLessThan                   = 184
+	OpFUnordLessThan                 = 185
+	OpFOrdGreaterThan                = 186
+	OpFUnordGreaterThan              = 187
+	OpFOrdLessThanEqual              = 188
+	OpFUnordLessThanEqual            = 189
+	OpFOrdGreaterThanEqual           = 190
+	OpFUnordGreaterThanEqual         = 191
+	OpShiftRightLogical              = 194
+	OpShiftRightArithmetic           = 195
+	OpShiftLeftLogical               = 196
+	OpBitwiseOr                      = 197
+	OpBitwiseXor                     = 198
+	OpBitwiseAnd                     = 199
+	OpNot                            = 200
+	OpBitFieldInsert                 = 201
+	OpBitFieldSExtract               = 202
+	OpBitFieldUExtract               = 203
+	OpBitReverse                     = 204
+	OpBitCount                       = 205
+	OpDPdx                           = 207
+	OpFwidthCoarse                   = 215
+	OpPhi                            = 245
+	OpLoopMerge                      = 246
+	OpSelectionMerge                 = 247
+	OpLabel                          = 248
+	OpBranch                         = 249
+	OpBranchConditional              = 250
+	OpSwitch                         = 251
+	OpKill                           = 252
+	OpReturn                         = 253
+	OpReturnValue                    = 254
+	OpUnreachable                    = 255
+	OpNoLine                         = 317
+	OpModuleProcessed                = 330
+)
+
+// The storage classes of variables.
+const (
+	StorageUniformConstant = 0
+	StorageInput           = 1
+	StorageUniform         = 2
+	StorageOutput          = 3
+	StoragePrivate         = 6
+	StorageFunction        = 7
+	StoragePushConstant    = 9
+)
+
+// The decorations used by the interpreter.
+const (
+	// decorationBuiltIn is the decoration marking built-in variables.
+	decorationBuiltIn = 11
+	// decorationBinding and decorationDescriptorSet are the decorations giving
+	// the binding and descriptor set numbers of resource variables.
+	decorationBinding       = 33
+	decorationDescriptorSet = 34
+)
+
+// opNames are the names of the opcodes known to the interpreter.
+var opNames = map[uint32]string{
+	OpNop: "OpNop", OpUndef: "OpUndef", OpSourceContinued: "OpSourceContinued",
+	OpSource: "OpSource", OpSourceExtension: "OpSourceExtension", OpName: "OpName",
+	OpMemberName: "OpMemberName", OpString: "OpString", OpLine: "OpLine",
+	OpExtension: "OpExtension", OpExtInstImport: "OpExtInstImport", OpExtInst: "OpExtInst",
+	OpMemoryModel: "OpMemoryModel", OpEntryPoint: "OpEntryPoint", OpExecutionMode: "OpExecutionMode",
+	OpCapability: "OpCapability", OpTypeVoid: "OpTypeVoid", OpTypeBool: "OpTypeBool",
+	OpTypeInt: "OpTypeInt", OpTypeFloat: "OpTypeFloat", OpTypeVector: "OpTypeVector",
+	OpTypeMatrix: "OpTypeMatrix", OpTypeImage: "OpTypeImage", OpTypeSampler: "OpTypeSampler",
+	OpTypeSampledImage: "OpTypeSampledImage", OpTypeArray: "OpTypeArray",
+	OpTypeRuntimeArray: "OpTypeRuntimeArray", OpTypeStruct: "OpTypeStruct",
+	OpTypeOpaque: "OpTypeOpaque", OpTypePointer: "OpTypePointer", OpTypeFunction: "OpTypeFunction",
+	OpConstantTrue: "OpConstantTrue", OpConstantFalse: "OpConstantFalse", OpConstant: "OpConstant",
+	OpConstantComposite: "OpConstantComposite", OpConstantSampler: "OpConstantSampler",
+	OpConstantNull: "OpConstantNull", OpSpecConstantTrue: "OpSpecConstantTrue",
+	OpSpecConstantFalse: "OpSpecConstantFalse", OpSpecConstant: "OpSpecConstant",
+	OpSpecConstantComposite: "OpSpecConstantComposite", OpFunction: "OpFunction",
+	OpFunctionParameter: "OpFunctionParameter", OpFunctionEnd: "OpFunctionEnd",
+	OpFunctionCall: "OpFunctionCall", OpVariable: "OpVariable",
+	OpImageTexelPointer: "OpImageTexelPointer", OpLoad: "OpLoad",
+	OpStore: "OpStore", OpCopyMemory: "OpCopyMemory", OpAccessChain: "OpAccessChain",
+	OpInBoundsAccessChain: "OpInBoundsAccessChain", OpPtrAccessChain: "OpPtrAccessChain",
+	OpDecorate: "OpDecorate", OpMemberDecorate: "OpMemberDecorate",
+	OpDecorationGroup:      "OpDecorationGroup",
+	OpVectorExtractDynamic: "OpVectorExtractDynamic", OpVectorInsertDynamic: "OpVectorInsertDynamic",
+	OpVectorShuffle: "OpVectorShuffle", OpCompositeConstruct: "OpCompositeConstruct",
+	OpCompositeExtract: "OpCompositeExtract", OpCompositeInsert: "OpCompositeInsert",
+	OpCopyObject: "OpCopyObject", OpTranspose: "OpTranspose", OpSampledImage: "OpSampledImage",
+	87: "OpImageSampleImplicitLod", 88: "OpImageSampleExplicitLod",
+	89: "OpImageSampleDrefImplicitLod", 90: "OpImageSampleDrefExplicitLod",
+	91: "OpImageSampleProjImplicitLod", 92: "OpImageSampleProjExplicitLod",
+	93: "OpImageSampleProjDrefImplicitLod", 94: "OpImageSampleProjDrefExplicitLod",
+	OpImageFetch: "OpImageFetch", OpImageGather: "OpImageGather", OpImageDrefGather: "OpImageDrefGather",
+	OpImageRead: "OpImageRead", OpImage: "OpImage", OpImageQuerySizeLod: "OpImageQuerySizeLod",
+	OpImageQuerySize: "OpImageQuerySize", OpImageQueryLod: "OpImageQueryLod",
+	OpImageQueryLevels: "OpImageQueryLevels", OpImageQuerySamples: "OpImageQuerySamples",
+	OpConvertFToU: "OpConvertFToU", OpConvertFToS: "OpConvertFToS", OpConvertSToF: "OpConvertSToF",
+	OpConvertUToF: "OpConvertUToF", OpUConvert: "OpUConvert", OpSConvert: "OpSConvert",
+	OpFConvert: "OpFConvert", OpQuantizeToF16: "OpQuantizeToF16", OpBitcast: "OpBitcast",
+	OpSNegate: "OpSNegate", OpFNegate: "OpFNegate", OpIAdd: "OpIAdd", OpFAdd: "OpFAdd",
+	OpISub: "OpISub", OpFSub: "OpFSub", OpIMul: "OpIMul", OpFMul: "OpFMul", OpUDiv: "OpUDiv",
+	OpSDiv: "OpSDiv", OpFDiv: "OpFDiv", OpUMod: "OpUMod", OpSRem: "OpSRem", OpSMod: "OpSMod",
+	OpFRem: "OpFRem", OpFMod: "OpFMod", OpVectorTimesScalar: "OpVectorTimesScalar",
+	OpMatrixTimesScalar: "OpMatrixTimesScalar", OpVectorTimesMatrix: "OpVectorTimesMatrix",
+	OpMatrixTimesVector: "OpMatrixTimesVector", OpMatrixTimesMatrix: "OpMatrixTimesMatrix",
+	OpOuterProduct: "OpOuterProduct", OpDot: "OpDot", OpAny: "OpAny", OpAll: "OpAll",
+	OpIsNan: "OpIsNan", OpIsInf: "OpIsInf", OpLogicalEqual: "OpLogicalEqual",
+	OpLogicalNotEqual: "OpLogicalNotEqual", OpLogicalOr: "OpLogicalOr", OpLogicalAnd: "OpLogicalAnd",
+	OpLogicalNot: "OpLogicalNot", OpSelect: "OpSelect", OpIEqual: "OpIEqual",
+	OpINotEqual: "OpINotEqual", OpUGreaterThan: "OpUGreaterThan", OpSGreaterThan: "OpSGreaterThan",
+	OpUGreaterThanEqual: "OpUGreaterThanEqual", OpSGreaterThanEqual: "OpSGreaterThanEqual",
+	OpULessThan: "OpULessThan", OpSLessThan: "OpSLessThan", OpULessThanEqual: "OpULessThanEqual",
+	OpSLessThanEqual: "OpSLessThanEqual", OpFOrdEqual: "OpFOrdEqual", OpFUnordEqual: "OpFUnordEqual",
+	OpFOrdNotEqual: "OpFOrdNotEqual", OpFUnordNotEqual: "OpFUnordNotEqual",
+	OpFOrdLessThan: "OpFOrdLessThan", OpFUnordLessThan: "OpFUnordLessThan",
+	OpFOrdGreaterThan: "OpFOrdGreaterThan", OpFUnordGreaterThan: "OpFUnordGreaterThan",
+	OpFOrdLessThanEqual: "OpFOrdLessThanEqual", OpFUnordLessThanEqual: "OpFUnordLessThanEqual",
+	OpFOrdGreaterThanEqual: "OpFOrdGreaterThanEqual", OpFUnordGreaterThanEqual: "OpFUnordGreaterThanEqual",
+	OpShiftRightLogical: "OpShiftRightLogical", OpShiftRightArithmetic: "OpShiftRightArithmetic",
+	OpShiftLeftLogical: "OpShiftLeftLogical", OpBitwiseOr: "OpBitwiseOr", OpBitwiseXor: "OpBitwiseXor",
+	OpBitwiseAnd: "OpBitwiseAnd", OpNot: "OpNot", OpBitFieldInsert: "OpBitFieldInsert",
+	OpBitFieldSExtract: "OpBitFieldSExtract", OpBitFieldUExtract: "OpBitFieldUExtract",
+	OpBitReverse: "OpBitReverse", OpBitCount: "OpBitCount", OpDPdx: "OpDPdx", 208: "OpDPdy",
+	209: "OpFwidth", 210: "OpDPdxFine", 211: "OpDPdyFine", 212: "OpFwidthFine",
+	213: "OpDPdxCoarse", 214: "OpDPdyCoarse", OpFwidthCoarse: "OpFwidthCoarse", OpPhi: "OpPhi",
+	OpLoopMerge: "OpLoopMerge", OpSelectionMerge: "OpSelectionMerge", OpLabel: "OpLabel",
+	OpBranch: "OpBranch", OpBranchConditional: "OpBranchConditional", OpSwitch: "OpSwitch",
+	OpKill: "OpKill", OpReturn: "OpReturn", OpReturnValue: "OpReturnValue",
+	OpUnreachable: "OpUnreachable", OpNoLine: "OpNoLine", OpModuleProcessed: "OpModuleProcessed",
+}
+
+// OpcodeName returns the name of the SPIR-V opcode op.
+func OpcodeName(op uint32) string {
+	if name, ok := opNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("Op%d", op)
+}
@@ -0,0 +1,252 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spirv
+
+import (
+	"math"
+	"math/bits"
+)
+
+// scalarOp is an operation on the bits of scalars.
+type scalarOp func(c ...uint32) uint32
+
+func toFloat(b uint32) float64   { return float64(math.Float32frombits(b)) }
+func fromFloat(f float64) uint32 { return math.Float32bits(float32(f)) }
+func fromBool(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// floatOp returns the scalarOp applying f to float scalars.
+func floatOp(f func(c ...float64) float64) scalarOp {
+	return func(c ...uint32) uint32 {
+		args := make([]float64, len(c))
+		for i, b := range c {
+			args[i] = toFloat(b)
+		}
+		return fromFloat(f(args...))
+	}
+}
+
+// floatCompare returns the scalarOp comparing two float scalars with f. If
+// ordered, the comparison is false if either scalar is NaN, otherwise it is
+// true.
+func floatCompare(ordered bool, f func(a, b float64) bool) scalarOp {
+	return func(c ...uint32) uint32 {
+		a, b := toFloat(c[0]), toFloat(c[1])
+		if math.IsNaN(a) || math.IsNaN(b) {
+			return fromBool(!ordered)
+		}
+		return fromBool(f(a, b))
+	}
+}
+
+// componentwiseOps are the operations applied to each component of their
+// scalar or vector operands. Scalar operands are used for every component.
+var componentwiseOps = map[uint32]scalarOp{
+	OpSNegate:           func(c ...uint32) uint32 { return -c[0] },
+	OpFNegate:           floatOp(func(c ...float64) float64 { return -c[0] }),
+	OpIAdd:              func(c ...uint32) uint32 { return c[0] + c[1] },
+	OpFAdd:              floatOp(func(c ...float64) float64 { return c[0] + c[1] }),
+	OpISub:              func(c ...uint32) uint32 { return c[0] - c[1] },
+	OpFSub:              floatOp(func(c ...float64) float64 { return c[0] - c[1] }),
+	OpIMul:              func(c ...uint32) uint32 { return c[0] * c[1] },
+	OpFMul:              floatOp(func(c ...float64) float64 { return c[0] * c[1] }),
+	OpVectorTimesScalar: floatOp(func(c ...float64) float64 { return c[0] * c[1] }),
+	OpFDiv:              floatOp(func(c ...float64) float64 { return c[0] / c[1] }),
+	OpFRem:              floatOp(func(c ...float64) float64 { return math.Mod(c[0], c[1]) }),
+	OpFMod:              floatOp(func(c ...float64) float64 { return c[0] - c[1]*math.Floor(c[0]/c[1]) }),
+	// Integer division by zero is undefined, and results in zero.
+	OpUDiv: func(c ...uint32) uint32 {
+		if c[1] == 0 {
+			return 0
+		}
+		return c[0] / c[1]
+	},
+	OpSDiv: func(c ...uint32) uint32 {
+		if c[1] == 0 {
+			return 0
+		}
+		return uint32(int32(c[0]) / int32(c[1]))
+	},
+	OpUMod: func(c ...uint32) uint32 {
+		if c[1] == 0 {
+			return 0
+		}
+		return c[0] % c[1]
+	},
+	OpSRem: func(c ...uint32) uint32 {
+		if c[1] == 0 {
+			return 0
+		}
+		return uint32(int32(c[0]) % int32(c[1]))
+	},
+	OpSMod: func(c ...uint32) uint32 {
+		a, b := int32(c[0]), int32(c[1])
+		if b == 0 {
+			return 0
+		}
+		r := a % b
+		if r != 0 && (r < 0) != (b < 0) {
+			r += b
+		}
+		return uint32(r)
+	},
+	OpConvertFToU: func(c ...uint32) uint32 { return uint32(int64(toFloat(c[0]))) },
+	OpConvertFToS: func(c ...uint32) uint32 { return uint32(int32(toFloat(c[0]))) },
+	OpConvertSToF: func(c ...uint32) uint32 { return fromFloat(float64(int32(c[0]))) },
+	OpConvertUToF: func(c ...uint32) uint32 { return fromFloat(float64(c[0])) },
+	OpUConvert:    func(c ...uint32) uint32 { return c[0] },
+	OpSConvert:    func(c ...uint32) uint32 { return c[0] },
+	OpFConvert:    func(c ...uint32) uint32 { return c[0] },
+	OpBitcast:     func(c ...uint32) uint32 { return c[0] },
+	OpQuantizeToF16: func(c ...uint32) uint32 {
+		// Drop the mantissa bits that a 16-bit float does not have.
+		return c[0] &^ 0x1fff
+	},
+	OpIsNan:                  func(c ...uint32) uint32 { return fromBool(math.IsNaN(toFloat(c[0]))) },
+	OpIsInf:                  func(c ...uint32) uint32 { return fromBool(math.IsInf(toFloat(c[0]), 0)) },
+	OpLogicalEqual:           func(c ...uint32) uint32 { return fromBool(c[0] == c[1]) },
+	OpLogicalNotEqual:        func(c ...uint32) uint32 { return fromBool(c[0] != c[1]) },
+	OpLogicalOr:              func(c ...uint32) uint32 { return fromBool(c[0] != 0 || c[1] != 0) },
+	OpLogicalAnd:             func(c ...uint32) uint32 { return fromBool(c[0] != 0 && c[1] != 0) },
+	OpLogicalNot:             func(c ...uint32) uint32 { return fromBool(c[0] == 0) },
+	OpIEqual:                 func(c ...uint32) uint32 { return fromBool(c[0] == c[1]) },
+	OpINotEqual:              func(c ...uint32) uint32 { return fromBool(c[0] != c[1]) },
+	OpUGreaterThan:           func(c ...uint32) uint32 { return fromBool(c[0] > c[1]) },
+	OpSGreaterThan:           func(c ...uint32) uint32 { return fromBool(int32(c[0]) > int32(c[1])) },
+	OpUGreaterThanEqual:      func(c ...uint32) uint32 { return fromBool(c[0] >= c[1]) },
+	OpSGreaterThanEqual:      func(c ...uint32) uint32 { return fromBool(int32(c[0]) >= int32(c[1])) },
+	OpULessThan:              func(c ...uint32) uint32 { return fromBool(c[0] < c[1]) },
+	OpSLessThan:              func(c ...uint32) uint32 { return fromBool(int32(c[0]) < int32(c[1])) },
+	OpULessThanEqual:         func(c ...uint32) uint32 { return fromBool(c[0] <= c[1]) },
+	OpSLessThanEqual:         func(c ...uint32) uint32 { return fromBool(int32(c[0]) <= int32(c[1])) },
+	OpFOrdEqual:              floatCompare(true, func(a, b float64) bool { return a == b }),
+	OpFUnordEqual:            floatCompare(false, func(a, b float64) bool { return a == b }),
+	OpFOrdNotEqual:           floatCompare(true, func(a, b float64) bool { return a != b }),
+	OpFUnordNotEqual:         floatCompare(false, func(a, b float64) bool { return a != b }),
+	OpFOrdLessThan:           floatCompare(true, func(a, b float64) bool { return a < b }),
+	OpFUnordLessThan:         floatCompare(false, func(a, b float64) bool { return a < b }),
+	OpFOrdGreaterThan:        floatCompare(true, func(a, b float64) bool { return a > b }),
+	OpFUnordGreaterThan:      floatCompare(false, func(a, b float64) bool { return a > b }),
+	OpFOrdLessThanEqual:      floatCompare(true, func(a, b float64) bool { return a <= b }),
+	OpFUnordLessThanEqual:    floatCompare(false, func(a, b float64) bool { return a <= b }),
+	OpFOrdGreaterThanEqual:   floatCompare(true, func(a, b float64) bool { return a >= b }),
+	OpFUnordGreaterThanEqual: floatCompare(false, func(a, b float64) bool { return a >= b }),
+	OpShiftRightLogical:      func(c ...uint32) uint32 { return c[0] >> c[1] },
+	OpShiftRightArithmetic:   func(c ...uint32) uint32 { return uint32(int32(c[0]) >> c[1]) },
+	OpShiftLeftLogical:       func(c ...uint32) uint32 { return c[0] << c[1] },
+	OpBitwiseOr:              func(c ...uint32) uint32 { return c[0] | c[1] },
+	OpBitwiseXor:             func(c ...uint32) uint32 { return c[0] ^ c[1] },
+	OpBitwiseAnd:             func(c ...uint32) uint32 { return c[0] & c[1] },
+	OpNot:                    func(c ...uint32) uint32 { return ^c[0] },
+	OpBitFieldInsert: func(c ...uint32) uint32 {
+		mask := uint32((uint64(1)<<c[3] - 1) << c[2])
+		return c[0]&^mask | c[1]<<c[2]&mask
+	},
+	OpBitFieldSExtract: func(c ...uint32) uint32 {
+		if c[2] == 0 {
+			return 0
+		}
+		shift := 32 - c[2]
+		return uint32(int32(c[0]>>c[1]<<shift) >> shift)
+	},
+	OpBitFieldUExtract: func(c ...uint32) uint32 {
+		return c[0] >> c[1] & uint32(uint64(1)<<c[2]-1)
+	},
+	OpBitReverse: func(c ...uint32) uint32 { return bits.Reverse32(c[0]) },
+	OpBitCount:   func(c ...uint32) uint32 { return uint32(bits.OnesCount32(c[0])) },
+	OpSelect: func(c ...uint32) uint32 {
+		if c[0] != 0 {
+			return c[1]
+		}
+		return c[2]
+	},
+}
+
+// componentwise returns the value of type ty computed by applying f to the
+// components of args, which are scalars or vectors of the size of ty.
+func componentwise(ty *Type, f scalarOp, args ...Value) Value {
+	apply := func(i int) uint32 {
+		c := make([]uint32, len(args))
+		for j, a := range args {
+			c[j] = a.component(i)
+		}
+		return f(c...)
+	}
+	if ty.Kind != KindVector {
+		return Value{Type: ty, Bits: apply(0)}
+	}
+	out := Value{Type: ty, Elements: make([]Value, ty.Count)}
+	for i := range out.Elements {
+		out.Elements[i] = Value{Type: ty.Element, Bits: apply(i)}
+	}
+	return out
+}
+
+// size returns the number of components of a scalar or vector.
+func size(v Value) int {
+	if v.Elements != nil {
+		return len(v.Elements)
+	}
+	return 1
+}
+
+// dot returns the dot product of two float scalars or vectors.
+func dot(a, b Value) float64 {
+	sum := 0.0
+	for i := 0; i < size(a); i++ {
+		sum += toFloat(a.component(i)) * toFloat(b.component(i))
+	}
+	return sum
+}
+
+// vector returns the float scalar or vector of type ty whose i'th component
+// is f(i).
+func vector(ty *Type, f func(i int) float64) Value {
+	if ty.Kind != KindVector {
+		return Value{Type: ty, Bits: fromFloat(f(0))}
+	}
+	out := Value{Type: ty, Elements: make([]Value, ty.Count)}
+	for i := range out.Elements {
+		out.Elements[i] = Value{Type: ty.Element, Bits: fromFloat(f(i))}
+	}
+	return out
+}
+
+// matrixTimesVector returns the product of the matrix m and the vector v as a
+// vector of type ty.
+func matrixTimesVector(ty *Type, m, v Value) Value {
+	return vector(ty, func(r int) float64 {
+		sum := 0.0
+		for c, column := range m.Elements {
+			sum += toFloat(column.component(r)) * toFloat(v.component(c))
+		}
+		return sum
+	})
+}
+
+// matrix returns the float matrix of type ty whose element at column c and
+// row r is f(c, r).
+func matrix(ty *Type, f func(c, r int) float64) Value {
+	out := Value{Type: ty, Elements: make([]Value, ty.Count)}
+	for c := range out.Elements {
+		c := c
+		out.Elements[c] = vector(ty.Element, func(r int) float64 { return f(c, r) })
+	}
+	return out
+}
@@ -0,0 +1,248 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spirv
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Kind is the kind of a Type.
+type Kind int
+
+const (
+	KindVoid Kind = iota
+	KindBool
+	KindInt
+	KindFloat
+	KindVector
+	KindMatrix
+	KindArray
+	KindStruct
+	KindPointer
+	KindFunction
+	// KindOpaque is the kind of types whose values are not emulated, such as
+	// images, samplers and scalars that are not 32 bits wide.
+	KindOpaque
+)
+
+// Type is the type of a SPIR-V value.
+type Type struct {
+	Kind Kind
+	// Signed is true for signed integer types.
+	Signed bool
+	// Element is the component type of vectors, the column type of matrices,
+	// the element type of arrays and the pointee type of pointers.
+	Element *Type
+	// Count is the number of components, columns or elements of vectors,
+	// matrices and arrays.
+	Count int
+	// Members are the member types of structs.
+	Members []*Type
+	// StorageClass is the storage class of pointers.
+	StorageClass uint32
+	// Name is the name of opaque types.
+	Name string
+}
+
+func (t *Type) String() string {
+	switch t.Kind {
+	case KindVoid:
+		return "void"
+	case KindBool:
+		return "bool"
+	case KindInt:
+		if t.Signed {
+			return "int"
+		}
+		return "uint"
+	case KindFloat:
+		return "float"
+	case KindVector:
+		prefix := map[Kind]string{KindBool: "b", KindInt: "i", KindFloat: ""}[t.Element.Kind]
+		if t.Element.Kind == KindInt && !t.Element.Signed {
+			prefix = "u"
+		}
+		return fmt.Sprintf("%vvec%d", prefix, t.Count)
+	case KindMatrix:
+		if t.Count == t.Element.Count {
+			return fmt.Sprintf("mat%d", t.Count)
+		}
+		return fmt.Sprintf("mat%dx%d", t.Count, t.Element.Count)
+	case KindArray:
+		return fmt.Sprintf("%v[%d]", t.Element, t.Count)
+	case KindStruct:
+		return "struct"
+	case KindPointer:
+		return fmt.Sprintf("%v*", t.Element)
+	case KindFunction:
+		return "function"
+	default:
+		return t.Name
+	}
+}
+
+// Value is a value of the emulated shader invocation.
+type Value struct {
+	Type *Type
+	// Bits are the bits of scalars. Booleans are 0 or 1.
+	Bits uint32
+	// Elements are the components, columns, elements or members of
+	// composites.
+	Elements []Value
+	// Pointer is the value pointed to by pointers.
+	Pointer *Value
+}
+
+// Zero returns the zero value of the type t.
+func Zero(t *Type) Value {
+	v := Value{Type: t}
+	switch t.Kind {
+	case KindVector, KindMatrix, KindArray:
+		v.Elements = make([]Value, t.Count)
+		for i := range v.Elements {
+			v.Elements[i] = Zero(t.Element)
+		}
+	case KindStruct:
+		v.Elements = make([]Value, len(t.Members))
+		for i, m := range t.Members {
+			v.Elements[i] = Zero(m)
+		}
+	}
+	return v
+}
+
+// clone returns a deep copy of the value, sharing only pointees.
+func (v Value) clone() Value {
+	if v.Elements != nil {
+		elements := make([]Value, len(v.Elements))
+		for i, e := range v.Elements {
+			elements[i] = e.clone()
+		}
+		v.Elements = elements
+	}
+	return v
+}
+
+// assign sets the value to src in place, so that pointers to the elements of
+// the value remain valid.
+func (v *Value) assign(src Value) {
+	if v.Elements == nil || len(v.Elements) != len(src.Elements) {
+		*v = src.clone()
+		return
+	}
+	for i := range v.Elements {
+		v.Elements[i].assign(src.Elements[i])
+	}
+}
+
+// component returns the bits of the i'th component of a vector, or of a
+// scalar for any i.
+func (v Value) component(i int) uint32 {
+	if v.Elements != nil {
+		return v.Elements[i].Bits
+	}
+	return v.Bits
+}
+
+// Float returns the value of a float scalar.
+func (v Value) Float() float32 { return math.Float32frombits(v.Bits) }
+
+// Interface returns the value as a Go value: a bool, int32, uint32 or float32
+// for scalars, a slice of those for vectors, a []interface{} for other
+// composites and the value pointed to for pointers. Opaque values are nil.
+func (v Value) Interface() interface{} {
+	switch v.Type.Kind {
+	case KindBool:
+		return v.Bits != 0
+	case KindInt:
+		if v.Type.Signed {
+			return int32(v.Bits)
+		}
+		return v.Bits
+	case KindFloat:
+		return v.Float()
+	case KindVector:
+		out := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(Zero(v.Type.Element).Interface())), len(v.Elements), len(v.Elements))
+		for i, e := range v.Elements {
+			out.Index(i).Set(reflect.ValueOf(e.Interface()))
+		}
+		return out.Interface()
+	case KindMatrix, KindArray, KindStruct:
+		out := make([]interface{}, len(v.Elements))
+		for i, e := range v.Elements {
+			out[i] = e.Interface()
+		}
+		return out
+	case KindPointer:
+		if v.Pointer != nil {
+			return v.Pointer.Interface()
+		}
+	}
+	return nil
+}
+
+// set sets the scalars of the value, in order, to the bits in src, returning
+// the bits not used.
+func (v *Value) set(src []uint32) []uint32 {
+	switch v.Type.Kind {
+	case KindBool, KindInt, KindFloat:
+		if len(src) > 0 {
+			v.Bits, src = src[0], src[1:]
+		}
+	case KindVector, KindMatrix, KindArray, KindStruct:
+		for i := range v.Elements {
+			src = v.Elements[i].set(src)
+		}
+	}
+	return src
+}
+
+// scalarBits returns the bits of the scalars of the Go value v, which may be a
+// scalar or a possibly nested slice of bools, integers or floats.
+func scalarBits(v reflect.Value) ([]uint32, error) {
+	if !v.IsValid() {
+		return nil, fmt.Errorf("Missing value")
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return []uint32{1}, nil
+		}
+		return []uint32{0}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []uint32{uint32(v.Int())}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []uint32{uint32(v.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return []uint32{math.Float32bits(float32(v.Float()))}, nil
+	case reflect.Slice, reflect.Array:
+		out := []uint32{}
+		for i := 0; i < v.Len(); i++ {
+			bits, err := scalarBits(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, bits...)
+		}
+		return out, nil
+	case reflect.Interface, reflect.Ptr:
+		if !v.IsNil() {
+			return scalarBits(v.Elem())
+		}
+	}
+	return nil, fmt.Errorf("Unsupported value of type %v", v.Type())
+}
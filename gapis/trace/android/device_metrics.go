@@ -0,0 +1,144 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"bufio"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/adb"
+	"github.com/google/gapid/gapis/capture"
+)
+
+// deviceMetricsPeriod is the time between two samples of the device state.
+const deviceMetricsPeriod = time.Second
+
+// deviceMetricsScript prints the time since boot in seconds, which is on the
+// same clock as the trace timestamps, followed by a line per sampled thermal
+// zone, CPU frequency, GPU frequency and battery state. Errors, such as the
+// ones of files that the device does not have, are discarded.
+const deviceMetricsScript = `{ cat /proc/uptime; ` +
+	`for z in /sys/class/thermal/thermal_zone*; do echo "thermal $(cat $z/type) $(cat $z/temp)"; done; ` +
+	`for c in /sys/devices/system/cpu/cpu[0-9]*; do echo "cpu ${c##*/cpu} $(cat $c/cpufreq/scaling_cur_freq)"; done; ` +
+	`for g in /sys/class/kgsl/kgsl-3d0/gpuclk /sys/class/devfreq/*gpu*/cur_freq /sys/class/devfreq/*mali*/cur_freq; do [ -r $g ] && echo "gpu $(cat $g)" && break; done; ` +
+	`b=/sys/class/power_supply/battery; [ -d $b ] && echo "battery $(cat $b/capacity) $(cat $b/temp) $(cat $b/current_now) $(cat $b/voltage_now)"; } 2>/dev/null`
+
+// parseDeviceSample parses the output of deviceMetricsScript, returning false
+// if the output has no boot time.
+func parseDeviceSample(out string) (*capture.DeviceSample, bool) {
+	lines := bufio.NewScanner(strings.NewReader(out))
+	if !lines.Scan() {
+		return nil, false
+	}
+	uptime := strings.Fields(lines.Text())
+	if len(uptime) == 0 {
+		return nil, false
+	}
+	secs, err := strconv.ParseFloat(uptime[0], 64)
+	if err != nil {
+		return nil, false
+	}
+	s := &capture.DeviceSample{
+		Timestamp:    uint64(secs * float64(time.Second)),
+		ThermalZones: map[string]int64{},
+	}
+	for lines.Scan() {
+		f := strings.Fields(lines.Text())
+		if len(f) == 0 {
+			continue
+		}
+		switch {
+		case f[0] == "thermal" && len(f) == 3:
+			if temp, err := strconv.ParseInt(f[2], 10, 64); err == nil {
+				s.ThermalZones[f[1]] = temp
+			}
+		case f[0] == "cpu" && len(f) >= 2:
+			cpu, err := strconv.Atoi(f[1])
+			if err != nil || cpu < 0 {
+				continue
+			}
+			for len(s.CpuFrequencies) <= cpu {
+				s.CpuFrequencies = append(s.CpuFrequencies, 0)
+			}
+			if len(f) == 3 {
+				s.CpuFrequencies[cpu], _ = strconv.ParseUint(f[2], 10, 64)
+			}
+		case f[0] == "gpu" && len(f) == 2:
+			s.GpuFrequency, _ = strconv.ParseUint(f[1], 10, 64)
+		case f[0] == "battery" && len(f) == 5:
+			b := &capture.BatteryState{}
+			level, _ := strconv.ParseInt(f[1], 10, 32)
+			temp, _ := strconv.ParseInt(f[2], 10, 32)
+			b.Level, b.Temperature = int32(level), int32(temp)
+			b.Current, _ = strconv.ParseInt(f[3], 10, 64)
+			b.Voltage, _ = strconv.ParseInt(f[4], 10, 64)
+			s.Battery = b
+		}
+	}
+	return s, true
+}
+
+// recordDeviceMetrics samples the thermal, frequency and battery state of the
+// device every deviceMetricsPeriod, sending the serialized
+// capture.DeviceSample messages to the returned channel until the returned
+// cleanup is invoked.
+func recordDeviceMetrics(ctx context.Context, d adb.Device) (<-chan []byte, app.Cleanup) {
+	ctx, cancel := task.WithCancel(ctx)
+	samples := make(chan []byte, 16)
+
+	crash.Go(func() {
+		defer close(samples)
+		ticker := time.NewTicker(deviceMetricsPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-task.ShouldStop(ctx):
+				return
+			case <-ticker.C:
+			}
+			out, err := d.Shell(deviceMetricsScript).Call(ctx)
+			if err != nil {
+				if !task.Stopped(ctx) {
+					log.W(ctx, "Failed to sample the device state: %v", err)
+				}
+				continue
+			}
+			s, ok := parseDeviceSample(out)
+			if !ok {
+				continue
+			}
+			data, err := proto.Marshal(s)
+			if err != nil {
+				log.W(ctx, "Failed to encode device sample: %v", err)
+				continue
+			}
+			select {
+			case samples <- data:
+			default:
+				log.D(ctx, "Dropping device sample at %v", s.Timestamp)
+			}
+		}
+	})
+
+	return samples, func(context.Context) { cancel() }
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/app/crash"
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/adb"
+	"github.com/google/gapid/gapis/capture"
+)
+
+// inputEventBufferSize is the number of input events that are buffered before
+// new events are dropped.
+const inputEventBufferSize = 1024
+
+// inputEventRE matches the lines printed by getevent -lt, for example:
+// "[   12345.678901] /dev/input/event2: EV_ABS ABS_MT_POSITION_X 000001f4".
+var inputEventRE = regexp.MustCompile(`^\[\s*(\d+)\.(\d+)\]\s+(\S+):\s+(\S+)\s+(\S+)\s+(\S+)\s*$`)
+
+// parseInputEvent parses a line printed by getevent -lt, returning false if the
+// line is not an input event.
+func parseInputEvent(line string) (*capture.InputEvent, bool) {
+	match := inputEventRE.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+	secs, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	micros, err := strconv.ParseUint(match[2], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &capture.InputEvent{
+		Timestamp: secs*1000000000 + micros*1000,
+		Device:    match[3],
+		Type:      match[4],
+		Code:      match[5],
+		Value:     match[6],
+	}, true
+}
+
+// recordInputEvents streams the input events reported by the device to the
+// returned channel, as serialized capture.InputEvent messages, until the
+// returned cleanup is invoked.
+func recordInputEvents(ctx context.Context, d adb.Device) (<-chan []byte, app.Cleanup) {
+	ctx, cancel := task.WithCancel(ctx)
+	events := make(chan []byte, inputEventBufferSize)
+	reader, stdout := io.Pipe()
+
+	crash.Go(func() {
+		defer close(events)
+		lines := bufio.NewScanner(reader)
+		for lines.Scan() {
+			e, ok := parseInputEvent(lines.Text())
+			if !ok {
+				continue
+			}
+			data, err := proto.Marshal(e)
+			if err != nil {
+				log.W(ctx, "Failed to encode input event: %v", err)
+				continue
+			}
+			select {
+			case events <- data:
+			default:
+				log.D(ctx, "Dropping input event: %v", e)
+			}
+		}
+	})
+
+	crash.Go(func() {
+		err := d.Shell("getevent", "-lt").Capture(stdout, nil).Run(ctx)
+		if err != nil && !task.Stopped(ctx) {
+			log.W(ctx, "Failed to record input events: %v", err)
+		}
+		stdout.Close()
+	})
+
+	return events, func(context.Context) { cancel() }
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/capture"
+)
+
+func TestParseInputEvent(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		line     string
+		expected *capture.InputEvent
+	}{
+		{
+			"[   12345.678901] /dev/input/event2: EV_ABS       ABS_MT_POSITION_X    000001f4",
+			&capture.InputEvent{
+				Timestamp: 12345678901000,
+				Device:    "/dev/input/event2",
+				Type:      "EV_ABS",
+				Code:      "ABS_MT_POSITION_X",
+				Value:     "000001f4",
+			},
+		}, {
+			"[       1.000002] /dev/input/event0: EV_KEY KEY_VOLUMEDOWN DOWN",
+			&capture.InputEvent{
+				Timestamp: 1000002000,
+				Device:    "/dev/input/event0",
+				Type:      "EV_KEY",
+				Code:      "KEY_VOLUMEDOWN",
+				Value:     "DOWN",
+			},
+		},
+		{"add device 1: /dev/input/event2", nil},
+		{"  name:     \"touchscreen\"", nil},
+		{"", nil},
+	} {
+		got, ok := parseInputEvent(test.line)
+		assert.For(ctx, "ok %q", test.line).That(ok).Equals(test.expected != nil)
+		if test.expected != nil {
+			assert.For(ctx, "event %q", test.line).That(got).DeepEquals(test.expected)
+		}
+	}
+}
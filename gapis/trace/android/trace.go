@@ -665,9 +665,21 @@ func (t *androidTracer) SetupTrace(ctx context.Context, o *service.TraceOptions)
 		cleanup = cleanup.Then(perfettoCleanup)
 	} else {
 		log.I(ctx, "Starting with options %+v", tracer.GapiiOptions(o))
+		var gapiiProcess *gapii.Process
 		var gapiiCleanup app.Cleanup
-		process, gapiiCleanup, err = gapii.Start(ctx, pkg, a, tracer.GapiiOptions(o))
+		gapiiProcess, gapiiCleanup, err = gapii.Start(ctx, pkg, a, tracer.GapiiOptions(o))
 		cleanup = cleanup.Then(gapiiCleanup)
+		if err == nil && o.RecordInputEvents {
+			var inputCleanup app.Cleanup
+			gapiiProcess.InputEvents, inputCleanup = recordInputEvents(ctx, t.b)
+			cleanup = cleanup.Then(inputCleanup)
+		}
+		if err == nil && o.RecordDeviceMetrics {
+			var metricsCleanup app.Cleanup
+			gapiiProcess.DeviceSamples, metricsCleanup = recordDeviceMetrics(ctx, t.b)
+			cleanup = cleanup.Then(metricsCleanup)
+		}
+		process = gapiiProcess
 	}
 	if err != nil {
 		return ret, cleanup.Invoke(ctx), err
@@ -26,6 +26,7 @@ import (
 	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/log"
 	gapii "github.com/google/gapid/gapii/client"
+	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/config"
 	"github.com/google/gapid/gapis/service"
 	"github.com/google/gapid/gapis/service/path"
@@ -65,15 +66,21 @@ func trace(ctx context.Context, device *path.Device, start task.Signal, stop tas
 	defer cleanup.Invoke(ctx)
 
 	var writer io.Writer
+	var closer io.Closer
 	if buffer != nil {
 		writer = buffer
 	} else {
 		os.MkdirAll(filepath.Dir(options.ServerLocalSavePath), 0755)
-		writer, err = os.Create(options.ServerLocalSavePath)
+		file, err := os.Create(options.ServerLocalSavePath)
 		if err != nil {
 			return err
 		}
-		defer writer.(*os.File).Close()
+		defer file.Close()
+		encrypted, err := capture.EncryptingWriter(file, "")
+		if err != nil {
+			return err
+		}
+		writer, closer = encrypted, encrypted
 	}
 
 	if options.Duration > 0 {
@@ -81,7 +88,11 @@ func trace(ctx context.Context, device *path.Device, start task.Signal, stop tas
 	}
 
 	_, err = process.Capture(ctx, start, stop, ready, writer, written)
-
+	if closer != nil {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
 	return err
 }
 
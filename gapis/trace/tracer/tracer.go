@@ -135,6 +135,12 @@ func GapiiOptions(o *service.TraceOptions) gapii.Options {
 	if o.DisableCoherentMemoryTracker {
 		flags |= gapii.DisableCoherentMemoryTracker
 	}
+	if o.LowResObservations {
+		flags |= gapii.LowResObservations
+	}
+	if o.SampleIoCounters {
+		flags |= gapii.SampleIOCounters
+	}
 
 	return gapii.Options{
 		o.ObserveFrameFrequency,
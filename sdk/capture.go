@@ -0,0 +1,95 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Break can be returned by the callbacks of Capture.Commands and
+// Capture.Mutate to stop the iteration without an error.
+const Break = api.Break
+
+// Capture is a graphics capture loaded by Open.
+type Capture struct {
+	path    *path.Capture
+	capture *capture.GraphicsCapture
+}
+
+// Close releases the capture, along with the memory of its commands and of
+// the states built from it. The capture, and any commands and states obtained
+// from it, must not be used once closed.
+func (c *Capture) Close(ctx context.Context) error {
+	err := capture.Unload(ctx, c.path)
+	c.capture = nil
+	return err
+}
+
+// Name returns the name of the capture.
+func (c *Capture) Name() string {
+	return c.capture.Name()
+}
+
+// APIs returns the names of the graphics APIs used by the capture.
+func (c *Capture) APIs() []string {
+	out := make([]string, len(c.capture.APIs))
+	for i, a := range c.capture.APIs {
+		out[i] = a.Name()
+	}
+	return out
+}
+
+// NumCommands returns the number of commands of the capture.
+func (c *Capture) NumCommands() uint64 {
+	return uint64(len(c.capture.Commands))
+}
+
+// Command returns the command of the capture at index.
+func (c *Capture) Command(index uint64) (Command, error) {
+	if index >= c.NumCommands() {
+		return Command{}, fmt.Errorf("Command index %d out of bounds [0, %d)", index, c.NumCommands())
+	}
+	return Command{Index: index, cmd: c.capture.Commands[index]}, nil
+}
+
+// Commands calls cb with each of the commands of the capture, in order. If
+// cb returns an error, the iteration stops and the error is returned, unless
+// it is Break.
+func (c *Capture) Commands(ctx context.Context, cb func(context.Context, Command) error) error {
+	return api.ForeachCmd(ctx, c.capture.Commands, true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		return cb(ctx, Command{Index: uint64(id), cmd: cmd})
+	})
+}
+
+// Mutate calls cb with each of the commands of the capture, in order, along
+// with the state after the command. The state starts as the initial state of
+// the capture, and is mutated by each command before cb is called. The state
+// is only valid for the duration of the call. If cb returns an error, the
+// iteration stops and the error is returned, unless it is Break.
+//
+// Commands that fail to mutate the state are still passed to cb, with the
+// error returned by their Err method.
+func (c *Capture) Mutate(ctx context.Context, cb func(context.Context, Command, *State) error) error {
+	s := &State{state: c.capture.NewState(ctx)}
+	return api.ForeachCmd(ctx, c.capture.Commands, true, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		err := cmd.Mutate(ctx, id, s.state, nil, nil)
+		return cb(ctx, Command{Index: uint64(id), cmd: cmd, err: err}, s)
+	})
+}
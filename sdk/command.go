@@ -0,0 +1,117 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+)
+
+// Command is a single command of a capture.
+type Command struct {
+	// Index is the index of the command in the capture.
+	Index uint64
+
+	cmd api.Cmd
+	err error
+}
+
+// Parameter is a single parameter of a command.
+type Parameter struct {
+	// Name is the name of the parameter.
+	Name string
+	// Value is the value of the parameter, as one of the value types of the
+	// package.
+	Value interface{}
+}
+
+// Name returns the name of the command.
+func (c Command) Name() string {
+	return c.cmd.CmdName()
+}
+
+// API returns the name of the graphics API the command belongs to, or an
+// empty string if the command does not belong to an API.
+func (c Command) API() string {
+	if a := c.cmd.API(); a != nil {
+		return a.Name()
+	}
+	return ""
+}
+
+// Thread returns the index of the thread the command was called on.
+func (c Command) Thread() uint64 {
+	return c.cmd.Thread()
+}
+
+// Parameters returns the parameters of the command, in declaration order.
+func (c Command) Parameters() []Parameter {
+	params := c.cmd.CmdParams()
+	out := make([]Parameter, len(params))
+	for i, p := range params {
+		out[i] = Parameter{Name: p.Name, Value: toValue(p.Get())}
+	}
+	return out
+}
+
+// Parameter returns the value of the parameter of the command with the given
+// name, and true, or false if the command has no such parameter.
+func (c Command) Parameter(name string) (interface{}, bool) {
+	v, err := api.GetParameter(c.cmd, name)
+	if err != nil {
+		return nil, false
+	}
+	return toValue(v), true
+}
+
+// Result returns the value returned by the command, and true, or false if
+// the command does not return a value.
+func (c Command) Result() (interface{}, bool) {
+	v, err := api.GetResult(c.cmd)
+	if err != nil {
+		return nil, false
+	}
+	return toValue(v), true
+}
+
+// Err returns the error raised when the command mutated the state passed with
+// it to the callback of Capture.Mutate, or nil if the command succeeded or the
+// command was not mutated.
+func (c Command) Err() error {
+	return c.err
+}
+
+// IsDrawCall returns true if the command is a draw call, given the state s
+// after the command.
+func (c Command) IsDrawCall(ctx context.Context, s *State) bool {
+	return c.flags(ctx, s).IsDrawCall()
+}
+
+// IsEndOfFrame returns true if the command ends a frame, given the state s
+// after the command.
+func (c Command) IsEndOfFrame(ctx context.Context, s *State) bool {
+	return c.flags(ctx, s).IsEndOfFrame()
+}
+
+// IsSubmission returns true if the command submits work to the GPU, given the
+// state s after the command.
+func (c Command) IsSubmission(ctx context.Context, s *State) bool {
+	return c.flags(ctx, s).IsSubmission()
+}
+
+func (c Command) flags(ctx context.Context, s *State) api.CmdFlags {
+	return c.cmd.CmdFlags(ctx, api.CmdID(c.Index), s.state)
+}
@@ -0,0 +1,81 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sdk is a stable API for tools that analyze graphics captures.
+//
+// The package loads .gfxtrace files, iterates over their commands and
+// reconstructs the API state after each command, without depending on the
+// internals of the GAPID server, which are free to change between releases.
+//
+// All the functions of the package must be called with a context returned by
+// NewContext:
+//
+//	ctx = sdk.NewContext(ctx)
+//	c, err := sdk.Open(ctx, "capture.gfxtrace")
+//	if err != nil {
+//	  return err
+//	}
+//	defer c.Close(ctx)
+//	return c.Mutate(ctx, func(ctx context.Context, cmd sdk.Command, s *sdk.State) error {
+//	  if cmd.IsDrawCall(ctx, s) {
+//	    fmt.Println(cmd.Index, cmd.Name())
+//	  }
+//	  return nil
+//	})
+//
+// The values of command parameters and of the state are returned as values of
+// the following types, which do not depend on the internals of GAPID:
+//
+//	bool, string            for booleans and strings.
+//	int64, uint64, float64  for signed integers, unsigned integers and
+//	                        floating-point numbers of any size.
+//	Enum                    for enumerations and bitfields.
+//	Pointer                 for pointers to the application memory.
+//	Slice                   for slices of the application memory.
+//	*Object                 for structs, classes, maps and arrays.
+//	nil                     for nil references.
+package sdk
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/google/gapid/core/data/id"
+	_ "github.com/google/gapid/gapis/api/all" // Register the supported APIs.
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+)
+
+// NewContext returns a new context derived from ctx that holds the resources
+// needed by the functions of the package. ctx must not already be a context
+// returned by NewContext.
+func NewContext(ctx context.Context) context.Context {
+	return database.Put(ctx, database.NewInMemory(ctx))
+}
+
+// Open loads the graphics capture held by the file at filename. Each call
+// loads its own copy of the capture, which is released by its Close.
+func Open(ctx context.Context, filename string) (*Capture, error) {
+	key := id.Unique().String()
+	p, err := capture.Import(ctx, filepath.Base(filename), key, &capture.File{Path: filename})
+	if err != nil {
+		return nil, err
+	}
+	c, err := capture.ResolveGraphicsFromPath(ctx, p)
+	if err != nil {
+		capture.Unload(ctx, p)
+		return nil, err
+	}
+	return &Capture{path: p, capture: c}, nil
+}
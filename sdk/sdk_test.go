@@ -0,0 +1,116 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/memory/arena"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/test"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/sdk"
+)
+
+func TestOpen(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = sdk.NewContext(ctx)
+
+	dir, err := ioutil.TempDir("", "sdk")
+	if !assert.For(ctx, "TempDir").ThatError(err).Succeeded() {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	header := &capture.Header{ABI: device.WindowsX86_64}
+	cmds := []api.Cmd{test.Cmds.A, test.Cmds.B}
+	c, err := capture.NewGraphicsCapture(ctx, arena.New(), "test", header, nil, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+	filename := filepath.Join(dir, "test.gfxtrace")
+	f, err := os.Create(filename)
+	if !assert.For(ctx, "Create").ThatError(err).Succeeded() {
+		return
+	}
+	err = c.Export(ctx, f)
+	f.Close()
+	if !assert.For(ctx, "Export").ThatError(err).Succeeded() {
+		return
+	}
+
+	sc, err := sdk.Open(ctx, filename)
+	if !assert.For(ctx, "Open").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "NumCommands").That(sc.NumCommands()).Equals(uint64(2))
+
+	names, u8s := []string{}, []interface{}{}
+	err = sc.Commands(ctx, func(ctx context.Context, cmd sdk.Command) error {
+		names = append(names, cmd.Name())
+		u8, _ := cmd.Parameter("U8")
+		u8s = append(u8s, u8)
+		return nil
+	})
+	assert.For(ctx, "Commands").ThatError(err).Succeeded()
+	assert.For(ctx, "names").ThatSlice(names).Equals([]string{"cmdTypeMix", "cmdTypeMix"})
+	assert.For(ctx, "U8").ThatSlice(u8s).Equals([]interface{}{uint64(10), uint64(1)})
+
+	cmd, err := sc.Command(0)
+	if assert.For(ctx, "Command(0)").ThatError(err).Succeeded() {
+		params := map[string]interface{}{}
+		for _, p := range cmd.Parameters() {
+			params[p.Name] = p.Value
+		}
+		assert.For(ctx, "S8").That(params["S8"]).Equals(int64(20))
+		assert.For(ctx, "F64").That(params["F64"]).Equals(float64(100))
+		assert.For(ctx, "Bool").That(params["Bool"]).Equals(true)
+		assert.For(ctx, "Ptr").That(params["Ptr"]).Equals(sdk.Pointer{Address: 0x12345678})
+		res, ok := cmd.Result()
+		assert.For(ctx, "Result").That(ok).Equals(true)
+		assert.For(ctx, "Result").That(res).Equals(uint64(100))
+	}
+
+	count := 0
+	err = sc.Mutate(ctx, func(ctx context.Context, cmd sdk.Command, s *sdk.State) error {
+		count++
+		return sdk.Break
+	})
+	assert.For(ctx, "Mutate").ThatError(err).Succeeded()
+	assert.For(ctx, "Mutate count").That(count).Equals(1)
+
+	_, err = sc.Command(2)
+	assert.For(ctx, "Command(2)").ThatError(err).Failed()
+
+	// Each Open loads its own copy of the capture.
+	other, err := sdk.Open(ctx, filename)
+	if !assert.For(ctx, "Open again").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "Close").ThatError(sc.Close(ctx)).Succeeded()
+	assert.For(ctx, "Loaded after Close").ThatSlice(capture.Loaded(ctx)).IsLength(1)
+	_, err = other.Command(1)
+	assert.For(ctx, "Command(1) of other").ThatError(err).Succeeded()
+
+	assert.For(ctx, "Close other").ThatError(other.Close(ctx)).Succeeded()
+	assert.For(ctx, "Loaded after Close other").ThatSlice(capture.Loaded(ctx)).IsEmpty()
+}
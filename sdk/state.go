@@ -0,0 +1,81 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// State is the state of the graphics APIs and the application memory at a
+// point in a capture.
+type State struct {
+	state *api.GlobalState
+}
+
+// API returns the state of the graphics API with the given name, such as
+// "vulkan", or nil if the API has no state.
+func (s *State) API(name string) *Object {
+	if st := s.api(name); st != nil {
+		return &Object{st}
+	}
+	return nil
+}
+
+func (s *State) api(name string) api.State {
+	for id, st := range s.state.APIs {
+		if a := api.Find(id); a != nil && a.Name() == name {
+			return st
+		}
+	}
+	return nil
+}
+
+// Lookup returns the value of the member of the state of the graphics API
+// with the given name, found by following the members named by path from the
+// root of the API's state. Each member name is a field name, a map key or an
+// array index, formatted as they are in the state tree, for example:
+//
+//	s.Lookup("vulkan", "Devices", "1", "PhysicalDevice")
+//
+// The value is returned as one of the value types of the package.
+func (s *State) Lookup(name string, path ...string) (interface{}, error) {
+	st := s.api(name)
+	if st == nil {
+		return nil, fmt.Errorf("No state for API '%v'", name)
+	}
+	var v interface{} = st
+	for i, member := range path {
+		next, ok := lookupMember(v, member)
+		if !ok {
+			return nil, fmt.Errorf("No member '%v' of %v", member, path[:i])
+		}
+		v = next
+	}
+	return toValue(v), nil
+}
+
+// ReadMemory returns size bytes of the application memory at address base.
+func (s *State) ReadMemory(ctx context.Context, base, size uint64) ([]byte, error) {
+	out := make([]byte, size)
+	rng := memory.Range{Base: base, Size: size}
+	if err := s.state.Memory.ApplicationPool().Slice(rng).Get(ctx, 0, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,201 @@
+// Copyright (C) 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/google/gapid/core/data/dictionary"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// Enum is the value of an enumeration or bitfield.
+type Enum struct {
+	// Value is the numerical value.
+	Value int64
+	// Name is the name of the value, as displayed in the state tree.
+	Name string
+}
+
+// Pointer is a pointer to the application memory.
+type Pointer struct {
+	// Address is the address pointed to.
+	Address uint64
+}
+
+// Slice is a slice of the application memory.
+type Slice struct {
+	// Base is the address of the first element.
+	Base uint64
+	// Count is the number of elements.
+	Count uint64
+	// Size is the size of the slice in bytes.
+	Size uint64
+}
+
+// Object is a struct, class, map or array, whose members are read by name.
+type Object struct {
+	v interface{}
+}
+
+// Members returns the names of the members of the object: the field names of
+// a struct or class, the keys of a map or the indices of an array, formatted
+// as they are in the state tree.
+func (o *Object) Members() []string {
+	if pp, ok := o.v.(api.PropertyProvider); ok {
+		props := pp.Properties()
+		out := make([]string, len(props))
+		for i, p := range props {
+			out[i] = p.Name
+		}
+		return out
+	}
+	if d := dictionary.From(o.v); d != nil {
+		keys := d.Keys()
+		out := make([]string, len(keys))
+		for i, k := range keys {
+			out[i] = fmt.Sprint(k)
+		}
+		return out
+	}
+	r := deref(reflect.ValueOf(o.v))
+	switch r.Kind() {
+	case reflect.Array, reflect.Slice:
+		out := make([]string, r.Len())
+		for i := range out {
+			out[i] = strconv.Itoa(i)
+		}
+		return out
+	case reflect.Struct:
+		out := []string{}
+		for i, t := 0, r.Type(); i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath == "" {
+				out = append(out, f.Name)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// Member returns the value of the member of the object with the given name,
+// and true, or false if the object has no such member.
+func (o *Object) Member(name string) (interface{}, bool) {
+	v, ok := lookupMember(o.v, name)
+	if !ok {
+		return nil, false
+	}
+	return toValue(v), true
+}
+
+// lookupMember returns the member of v with the given name.
+func lookupMember(v interface{}, name string) (interface{}, bool) {
+	if v == nil {
+		return nil, false
+	}
+	if pp, ok := v.(api.PropertyProvider); ok {
+		if p := pp.Properties().Find(name); p != nil {
+			return p.Get(), true
+		}
+		return nil, false
+	}
+	if d := dictionary.From(v); d != nil {
+		for _, k := range d.Keys() {
+			if fmt.Sprint(k) == name {
+				return d.Get(k), true
+			}
+		}
+		return nil, false
+	}
+	r := deref(reflect.ValueOf(v))
+	switch r.Kind() {
+	case reflect.Array, reflect.Slice:
+		i, err := strconv.Atoi(name)
+		if err != nil || i < 0 || i >= r.Len() {
+			return nil, false
+		}
+		return r.Index(i).Interface(), true
+	case reflect.Struct:
+		if f, ok := r.Type().FieldByName(name); ok && f.PkgPath == "" {
+			return r.FieldByIndex(f.Index).Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// toValue returns v converted to one of the value types of the package.
+func toValue(v interface{}) interface{} {
+	if v == nil || isNil(v) {
+		return nil
+	}
+	switch v := v.(type) {
+	case memory.Pointer:
+		return Pointer{Address: v.Address()}
+	case memory.Slice:
+		return Slice{Base: v.Base(), Count: v.Count(), Size: v.Size()}
+	case api.PropertyProvider:
+		return &Object{v}
+	}
+	if dictionary.From(v) != nil {
+		return &Object{v}
+	}
+	r := deref(reflect.ValueOf(v))
+	_, named := r.Interface().(fmt.Stringer)
+	switch r.Kind() {
+	case reflect.Bool:
+		return r.Bool()
+	case reflect.String:
+		return r.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if named {
+			return Enum{Value: r.Int(), Name: fmt.Sprint(r.Interface())}
+		}
+		return r.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if named {
+			return Enum{Value: int64(r.Uint()), Name: fmt.Sprint(r.Interface())}
+		}
+		return r.Uint()
+	case reflect.Float32, reflect.Float64:
+		return r.Float()
+	case reflect.Array, reflect.Slice, reflect.Struct:
+		return &Object{r.Interface()}
+	}
+	return nil
+}
+
+// deref returns v with the pointers and interfaces it holds dereferenced.
+func deref(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// isNil returns true if v is a nil pointer, or a nil reference of the state.
+func isNil(v interface{}) bool {
+	if n, ok := v.(interface{ IsNil() bool }); ok {
+		return n.IsNil()
+	}
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return r.IsNil()
+	}
+	return false
+}